@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+var (
+	errUnauthenticated = errors.New("authentication required")
+	errNotAccountOwner = errors.New("from account does not belong to the caller")
+)
+
+// Authenticator identifies the user making a request, e.g. by verifying a bearer token.
+// It returns the username of the caller, which is the owner of their accounts.
+type Authenticator interface {
+	Authenticate(r *http.Request) (username string, err error)
+}
+
+// WithAuthenticator authenticates the callers of the routes that act on their accounts, e.g. POST /transfers.
+// Those routes are not served without an Authenticator.
+func WithAuthenticator(authenticator Authenticator) ServerOption {
+	return func(server *Server) {
+		server.authenticator = authenticator
+	}
+}
+
+type usernameContextKey struct{}
+
+// authenticated 驗證呼叫者，把 username 放進 ctx，audit log 的 actor 記的是它的 keyed hash
+func (server *Server) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, err := server.authenticator.Authenticate(r)
+		if err != nil || username == "" {
+			writeJSON(w, http.StatusUnauthorized, errorResponse(errUnauthenticated))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), usernameContextKey{}, username)
+		next(w, r.WithContext(db.WithUserActor(ctx, username)))
+	}
+}
+
+// authenticatedUsername 回傳 authenticated 放進 ctx 的 username
+func authenticatedUsername(ctx context.Context) string {
+	username, _ := ctx.Value(usernameContextKey{}).(string)
+	return username
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// readyzTimeout 是 readiness probe 等資料庫 ping 的上限，要比 probe 本身的 timeout 短
+const readyzTimeout = 2 * time.Second
+
+// readyz reports whether the service can take traffic: GET /readyz answers 200 with the pool statistics,
+// or 503 when the primary database does not answer the ping in time
+func (server *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	report, err := server.store.HealthCheck(r.Context(), readyzTimeout)
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, report)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
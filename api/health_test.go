@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestReadyz(t *testing.T) {
+	testCases := []struct {
+		name        string
+		healthCheck func(ctx context.Context, timeout time.Duration) (db.HealthReport, error)
+		status      int
+	}{
+		{
+			name: "Ready",
+			healthCheck: func(ctx context.Context, timeout time.Duration) (db.HealthReport, error) {
+				return db.HealthReport{Healthy: true, Primary: db.PoolHealth{Healthy: true, OpenConnections: 3, WaitCount: 1}}, nil
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "PrimaryDown",
+			healthCheck: func(ctx context.Context, timeout time.Duration) (db.HealthReport, error) {
+				return db.HealthReport{Primary: db.PoolHealth{Error: context.DeadlineExceeded.Error()}}, context.DeadlineExceeded
+			},
+			status: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := NewServer(&fakeStore{healthCheck: tc.healthCheck})
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			server.Handler().ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.status, recorder.Code)
+			var report db.HealthReport
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+			require.Equal(t, tc.status == http.StatusOK, report.Healthy)
+		})
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	server := NewServer(&fakeStore{})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	server.AdminHandler().ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "go_goroutines")
+
+	// 對外的路由沒有 /metrics
+	recorder = httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestReadyzTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+
+	var handlerSpan trace.SpanContext
+	store := &fakeStore{healthCheck: func(ctx context.Context, timeout time.Duration) (db.HealthReport, error) {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		return db.HealthReport{}, context.DeadlineExceeded
+	}}
+	server := NewServer(store, WithTracerProvider(provider))
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	server.Handler().ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	require.Equal(t, "GET /readyz", span.Name)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	require.Equal(t, codes.Error, span.Status.Code)
+	// handler 拿到的 ctx 帶著這個 span，Store 的 span 才接得上
+	require.Equal(t, span.SpanContext.SpanID(), handlerSpan.SpanID())
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Store contains the database operations used by the HTTP handlers, *db.Store implements it
+type Store interface {
+	GetAccount(ctx context.Context, id int64) (db.Account, error)
+	GetAccountByNumber(ctx context.Context, accountNumber string) (db.Account, error)
+	TransferTx(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error)
+	VerifyEmailTx(ctx context.Context, arg db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error)
+	HealthCheck(ctx context.Context, timeout time.Duration) (db.HealthReport, error)
+}
+
+var _ Store = (*db.Store)(nil)
+
+// RequestIDHeader carries the request ID, it is generated when the client does not send one
+const RequestIDHeader = "X-Request-Id"
+
+// Server serves HTTP requests for our banking service
+type Server struct {
+	store   Store
+	router  *http.ServeMux
+	handler http.Handler
+	// admin 只給內部的監控用，和對外的 handler 分開 listen
+	admin http.Handler
+	// tracer 由 WithTracerProvider 設定，nil 就不開 span
+	tracer trace.Tracer
+	// authenticator 由 WithAuthenticator 設定，nil 就不提供要登入的路由
+	authenticator Authenticator
+}
+
+// ServerOption configures a Server
+type ServerOption func(server *Server)
+
+// NewServer creates a new HTTP server and sets up routing
+func NewServer(store Store, opts ...ServerOption) *Server {
+	server := &Server{store: store}
+	for _, opt := range opts {
+		opt(server)
+	}
+	server.setupRouter()
+	return server
+}
+
+func (server *Server) setupRouter() {
+	router := http.NewServeMux()
+
+	router.HandleFunc("GET /verify_email", server.verifyEmail)
+	if server.authenticator != nil {
+		router.HandleFunc("POST /transfers", server.authenticated(server.createTransfer))
+	}
+	router.HandleFunc("GET /readyz", server.readyz)
+
+	server.router = router
+	// withTracing 要直接包住 router，才讀得到 router 在同一個 *http.Request 上設定的 Pattern
+	var handler http.Handler = router
+	if server.tracer != nil {
+		handler = withTracing(server.tracer, handler)
+	}
+	server.handler = withRequestID(handler)
+
+	// db.NewMetrics(prometheus.DefaultRegisterer) 註冊的指標從這裡匯出；指標裡有查詢名稱和連線池的狀態，不放在對外的路由
+	admin := http.NewServeMux()
+	admin.Handle("GET /metrics", promhttp.Handler())
+	server.admin = admin
+}
+
+// Handler returns the http.Handler serving every route
+func (server *Server) Handler() http.Handler {
+	return server.handler
+}
+
+// AdminHandler returns the http.Handler serving GET /metrics, it must only be reachable from the internal network
+func (server *Server) AdminHandler() http.Handler {
+	return server.admin
+}
+
+// Start runs the HTTP server on a specific address
+func (server *Server) Start(address string) error {
+	return http.ListenAndServe(address, server.handler)
+}
+
+// StartAdmin runs the admin HTTP server of AdminHandler on a specific address, e.g. one bound to a private interface
+func (server *Server) StartAdmin(address string) error {
+	return http.ListenAndServe(address, server.admin)
+}
+
+// withRequestID 把 request ID 放進 ctx，Store 寫 audit log 時會記下來，也回傳給 client 方便對照
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			buf := make([]byte, 16)
+			_, _ = rand.Read(buf)
+			requestID = hex.EncodeToString(buf)
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(db.WithRequestID(r.Context(), requestID)))
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func errorResponse(err error) map[string]string {
+	return map[string]string{"error": err.Error()}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/andyrestart9/bank/util"
+)
+
+var (
+	errInvalidTransferBody = errors.New("request body must be a JSON transfer")
+	errInvalidAmount       = errors.New("amount must be positive")
+	errSameAccount         = errors.New("cannot transfer to the same account")
+)
+
+// transferRequest 的帳戶可以用 id 或對外的帳號指定，兩者只能擇一
+type transferRequest struct {
+	FromAccountID     int64  `json:"from_account_id"`
+	FromAccountNumber string `json:"from_account_number"`
+	ToAccountID       int64  `json:"to_account_id"`
+	ToAccountNumber   string `json:"to_account_number"`
+	Amount            int64  `json:"amount"`
+	Currency          string `json:"currency"`
+}
+
+type transferResponse struct {
+	TransferID        int64     `json:"transfer_id"`
+	FromAccountNumber string    `json:"from_account_number"`
+	ToAccountNumber   string    `json:"to_account_number"`
+	Amount            int64     `json:"amount"`
+	Currency          string    `json:"currency"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// createTransfer moves money from an account of the authenticated caller to another account of the same currency: POST /transfers
+func (server *Server) createTransfer(w http.ResponseWriter, r *http.Request) {
+	var req transferRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(errInvalidTransferBody))
+		return
+	}
+	if req.Amount <= 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse(errInvalidAmount))
+		return
+	}
+	if !util.IsSupportedCurrency(req.Currency) {
+		writeJSON(w, http.StatusBadRequest, errorResponse(fmt.Errorf("currency %q is not supported", req.Currency)))
+		return
+	}
+
+	fromAccount, status, err := server.transferAccount(r.Context(), "from", req.FromAccountID, req.FromAccountNumber, req.Currency)
+	if err != nil {
+		writeJSON(w, status, errorResponse(err))
+		return
+	}
+	// 只能從自己的帳戶轉出
+	if fromAccount.Owner != authenticatedUsername(r.Context()) {
+		writeJSON(w, http.StatusForbidden, errorResponse(errNotAccountOwner))
+		return
+	}
+	toAccount, status, err := server.transferAccount(r.Context(), "to", req.ToAccountID, req.ToAccountNumber, req.Currency)
+	if err != nil {
+		writeJSON(w, status, errorResponse(err))
+		return
+	}
+	if fromAccount.ID == toAccount.ID {
+		writeJSON(w, http.StatusBadRequest, errorResponse(errSameAccount))
+		return
+	}
+
+	result, err := server.store.TransferTx(r.Context(), db.TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        req.Amount,
+	})
+	if err != nil {
+		writeJSON(w, transferErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transferResponse{
+		TransferID:        result.Transfer.ID,
+		FromAccountNumber: result.FromAccount.AccountNumber,
+		ToAccountNumber:   result.ToAccount.AccountNumber,
+		Amount:            result.Transfer.Amount,
+		Currency:          result.FromAccount.Currency,
+		CreatedAt:         result.Transfer.CreatedAt,
+	})
+}
+
+// transferAccount 依 id 或帳號找出轉帳的一方並檢查幣別；err 不是 nil 時 status 是要回的 HTTP 狀態碼
+func (server *Server) transferAccount(ctx context.Context, side string, id int64, number string, currency string) (db.Account, int, error) {
+	var account db.Account
+	var err error
+
+	switch {
+	case id != 0 && number != "":
+		return db.Account{}, http.StatusBadRequest, fmt.Errorf("%s_account_id and %s_account_number cannot both be set", side, side)
+	case number != "":
+		number = util.NormalizeAccountNumber(number)
+		if err := util.ValidateAccountNumber(number); err != nil {
+			return db.Account{}, http.StatusBadRequest, fmt.Errorf("%s_account_number: %w", side, err)
+		}
+		account, err = server.store.GetAccountByNumber(ctx, number)
+	case id > 0:
+		account, err = server.store.GetAccount(ctx, id)
+	default:
+		return db.Account{}, http.StatusBadRequest, fmt.Errorf("%s_account_id or %s_account_number is required", side, side)
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Account{}, http.StatusNotFound, fmt.Errorf("%s account not found", side)
+	}
+	if err != nil {
+		return db.Account{}, http.StatusInternalServerError, err
+	}
+	// 系統帳戶不對外，當成不存在
+	if db.IsSystemAccount(account) {
+		return db.Account{}, http.StatusNotFound, fmt.Errorf("%s account not found", side)
+	}
+	if account.Currency != currency {
+		return db.Account{}, http.StatusBadRequest, fmt.Errorf("%s account currency mismatch: %s vs %s", side, account.Currency, currency)
+	}
+	return account, http.StatusOK, nil
+}
+
+// transferErrorStatus 把 TransferTx 的業務錯誤對應到 HTTP 狀態碼，其他的是 500
+func transferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, db.ErrTransferHeld):
+		return http.StatusAccepted
+	case errors.Is(err, db.ErrTransferBlocked):
+		return http.StatusForbidden
+	case errors.Is(err, db.ErrInsufficientFunds), errors.Is(err, db.ErrTransferLimitExceeded), errors.Is(err, db.ErrAccountClosed):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
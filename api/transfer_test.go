@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// headerAuthenticator 把 Authorization: Bearer <username> 的 username 當成呼叫者
+type headerAuthenticator struct{}
+
+func (headerAuthenticator) Authenticate(r *http.Request) (string, error) {
+	username, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return "", errors.New("missing bearer token")
+	}
+	return username, nil
+}
+
+func TestCreateTransfer(t *testing.T) {
+	accounts := []db.Account{
+		{ID: 1, Owner: "alice", Currency: "EUR", AccountNumber: "TW40ANDY000000000001"},
+		{ID: 2, Owner: "bob", Currency: "EUR", AccountNumber: "TW13ANDY000000000002"},
+		{ID: 3, Owner: "carol", Currency: "USD", AccountNumber: "TW83ANDY000000000003"},
+		{ID: 4, Owner: "system:clearing", Currency: "EUR", AccountNumber: "TW18ANDY000000000009"},
+	}
+	createdAt := time.Date(2026, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	transferred := func(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+		if arg.FromAccountID != 1 || arg.ToAccountID != 2 || arg.Amount != 250 {
+			return db.TransferTxResult{}, fmt.Errorf("unexpected params %+v", arg)
+		}
+		return db.TransferTxResult{
+			Transfer:    db.Transfer{ID: 77, FromAccountID: 1, ToAccountID: 2, Amount: 250, CreatedAt: createdAt},
+			FromAccount: accounts[0],
+			ToAccount:   accounts[1],
+		}, nil
+	}
+	failed := func(err error) func(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+		return func(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+			return db.TransferTxResult{}, err
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		username   string
+		body       string
+		transferTx func(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error)
+		status     int
+		response   string
+	}{
+		{
+			name:   "Unauthenticated",
+			body:   `{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"EUR"}`,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:     "NotAccountOwner",
+			username: "bob",
+			body:     `{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"EUR"}`,
+			status:   http.StatusForbidden,
+		},
+		{
+			name:       "ByAccountNumber",
+			body:       `{"from_account_number":"tw40 andy 0000 0000 0001","to_account_number":"TW13ANDY000000000002","amount":250,"currency":"EUR"}`,
+			transferTx: transferred,
+			status:     http.StatusOK,
+			response:   `{"transfer_id":77,"from_account_number":"TW40ANDY000000000001","to_account_number":"TW13ANDY000000000002","amount":250,"currency":"EUR","created_at":"2026-05-01T12:00:00Z"}`,
+		},
+		{
+			name:       "ByID",
+			body:       `{"from_account_id":1,"to_account_number":"TW13ANDY000000000002","amount":250,"currency":"EUR"}`,
+			transferTx: transferred,
+			status:     http.StatusOK,
+		},
+		{
+			name:   "InvalidBody",
+			body:   `{"from_account_id":"1"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "InvalidAmount",
+			body:   `{"from_account_id":1,"to_account_id":2,"amount":0,"currency":"EUR"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "UnsupportedCurrency",
+			body:   `{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"JPY"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "BothIDAndNumber",
+			body:   `{"from_account_id":1,"from_account_number":"TW40ANDY000000000001","to_account_id":2,"amount":250,"currency":"EUR"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "MissingAccount",
+			body:   `{"from_account_id":1,"amount":250,"currency":"EUR"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "WrongCheckDigits",
+			body:   `{"from_account_number":"TW41ANDY000000000001","to_account_id":2,"amount":250,"currency":"EUR"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "ForeignIBAN",
+			body:   `{"from_account_id":1,"to_account_number":"DE89370400440532013000","amount":250,"currency":"EUR"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "UnknownAccountNumber",
+			body:   `{"from_account_id":1,"to_account_number":"TW18ANDY000000000009","amount":250,"currency":"EUR"}`,
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "UnknownAccountID",
+			body:   `{"from_account_id":1,"to_account_id":99,"amount":250,"currency":"EUR"}`,
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "CurrencyMismatch",
+			body:   `{"from_account_id":1,"to_account_id":3,"amount":250,"currency":"EUR"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "SameAccount",
+			body:   `{"from_account_id":1,"to_account_number":"TW40ANDY000000000001","amount":250,"currency":"EUR"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:       "InsufficientFunds",
+			body:       `{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"EUR"}`,
+			transferTx: failed(fmt.Errorf("%w: account 1", db.ErrInsufficientFunds)),
+			status:     http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Held",
+			body:       `{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"EUR"}`,
+			transferTx: failed(&db.ScreeningError{Outcome: db.RuleHold, CaseID: 5}),
+			status:     http.StatusAccepted,
+		},
+		{
+			name:       "Blocked",
+			body:       `{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"EUR"}`,
+			transferTx: failed(&db.ScreeningError{Outcome: db.RuleBlock}),
+			status:     http.StatusForbidden,
+		},
+		{
+			name:       "InternalError",
+			body:       `{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"EUR"}`,
+			transferTx: failed(context.DeadlineExceeded),
+			status:     http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeStore{accounts: accounts, transferTx: tc.transferTx}
+			server := NewServer(store, WithAuthenticator(headerAuthenticator{}))
+
+			username := tc.username
+			if username == "" && tc.status != http.StatusUnauthorized {
+				username = "alice"
+			}
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, "/transfers", strings.NewReader(tc.body))
+			if username != "" {
+				request.Header.Set("Authorization", "Bearer "+username)
+			}
+			server.Handler().ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.status, recorder.Code, recorder.Body.String())
+			switch {
+			case tc.response != "":
+				require.JSONEq(t, tc.response, recorder.Body.String())
+			case tc.status != http.StatusOK:
+				var body map[string]string
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+				require.NotEmpty(t, body["error"])
+			}
+		})
+	}
+}
+
+func TestCreateTransferActor(t *testing.T) {
+	var actor string
+	store := &fakeStore{
+		accounts: []db.Account{
+			{ID: 1, Owner: "alice", Currency: "EUR", AccountNumber: "TW40ANDY000000000001"},
+			{ID: 2, Owner: "bob", Currency: "EUR", AccountNumber: "TW13ANDY000000000002"},
+		},
+		transferTx: func(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+			actor = db.ActorFromContext(ctx)
+			return db.TransferTxResult{}, nil
+		},
+	}
+	server := NewServer(store, WithAuthenticator(headerAuthenticator{}))
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/transfers", strings.NewReader(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"EUR"}`))
+	request.Header.Set("Authorization", "Bearer alice")
+	server.Handler().ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "alice", actor)
+}
+
+func TestCreateTransferWithoutAuthenticator(t *testing.T) {
+	// 沒有 Authenticator 就不提供轉帳
+	server := NewServer(&fakeStore{})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/transfers", strings.NewReader(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"EUR"}`))
+	server.Handler().ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
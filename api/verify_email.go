@@ -0,0 +1,52 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+var (
+	errInvalidEmailID       = errors.New("email_id must be a positive integer")
+	errInvalidSecretCode    = errors.New("secret_code must be 32 characters long")
+	errVerifyEmailNotUsable = errors.New("verification link is invalid, expired or already used")
+)
+
+type verifyEmailResponse struct {
+	IsVerified bool `json:"is_verified"`
+}
+
+// verifyEmail consumes the verification code sent by email: GET /verify_email?email_id=...&secret_code=...
+func (server *Server) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	emailID, err := strconv.ParseInt(query.Get("email_id"), 10, 64)
+	if err != nil || emailID <= 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse(errInvalidEmailID))
+		return
+	}
+
+	secretCode := query.Get("secret_code")
+	if len(secretCode) != 32 {
+		writeJSON(w, http.StatusBadRequest, errorResponse(errInvalidSecretCode))
+		return
+	}
+
+	result, err := server.store.VerifyEmailTx(r.Context(), db.VerifyEmailTxParams{
+		EmailID:    emailID,
+		SecretCode: secretCode,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, http.StatusBadRequest, errorResponse(errVerifyEmailNotUsable))
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, verifyEmailResponse{IsVerified: result.User.IsEmailVerified})
+}
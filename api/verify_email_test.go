@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	accounts      []db.Account
+	transferTx    func(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error)
+	verifyEmailTx func(ctx context.Context, arg db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error)
+	healthCheck   func(ctx context.Context, timeout time.Duration) (db.HealthReport, error)
+}
+
+func (store *fakeStore) GetAccount(ctx context.Context, id int64) (db.Account, error) {
+	for _, account := range store.accounts {
+		if account.ID == id {
+			return account, nil
+		}
+	}
+	return db.Account{}, sql.ErrNoRows
+}
+
+func (store *fakeStore) GetAccountByNumber(ctx context.Context, accountNumber string) (db.Account, error) {
+	for _, account := range store.accounts {
+		if account.AccountNumber == accountNumber {
+			return account, nil
+		}
+	}
+	return db.Account{}, sql.ErrNoRows
+}
+
+func (store *fakeStore) TransferTx(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+	return store.transferTx(ctx, arg)
+}
+
+func (store *fakeStore) VerifyEmailTx(ctx context.Context, arg db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error) {
+	return store.verifyEmailTx(ctx, arg)
+}
+
+func (store *fakeStore) HealthCheck(ctx context.Context, timeout time.Duration) (db.HealthReport, error) {
+	return store.healthCheck(ctx, timeout)
+}
+
+func TestVerifyEmail(t *testing.T) {
+	secretCode := "0123456789abcdef0123456789abcdef"
+
+	testCases := []struct {
+		name          string
+		query         string
+		verifyEmailTx func(ctx context.Context, arg db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error)
+		status        int
+		body          string
+	}{
+		{
+			name:  "OK",
+			query: "email_id=7&secret_code=" + secretCode,
+			verifyEmailTx: func(ctx context.Context, arg db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error) {
+				if arg.EmailID != 7 || arg.SecretCode != secretCode {
+					return db.VerifyEmailTxResult{}, errors.New("unexpected params")
+				}
+				return db.VerifyEmailTxResult{User: db.User{IsEmailVerified: true}}, nil
+			},
+			status: http.StatusOK,
+			body:   `{"is_verified":true}`,
+		},
+		{
+			name:   "InvalidEmailID",
+			query:  "email_id=abc&secret_code=" + secretCode,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "InvalidSecretCode",
+			query:  "email_id=7&secret_code=short",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:  "ExpiredOrUsed",
+			query: "email_id=7&secret_code=" + secretCode,
+			verifyEmailTx: func(ctx context.Context, arg db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error) {
+				return db.VerifyEmailTxResult{}, sql.ErrNoRows
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:  "InternalError",
+			query: "email_id=7&secret_code=" + secretCode,
+			verifyEmailTx: func(ctx context.Context, arg db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error) {
+				return db.VerifyEmailTxResult{}, sql.ErrConnDone
+			},
+			status: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeStore{verifyEmailTx: tc.verifyEmailTx}
+			server := NewServer(store)
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, "/verify_email?"+tc.query, nil)
+			server.Handler().ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.status, recorder.Code)
+			if tc.body != "" {
+				require.JSONEq(t, tc.body, recorder.Body.String())
+			} else {
+				var body map[string]string
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+				require.NotEmpty(t, body["error"])
+			}
+		})
+	}
+}
+
+func TestVerifyEmailRequestID(t *testing.T) {
+	var requestID string
+	store := &fakeStore{verifyEmailTx: func(ctx context.Context, arg db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error) {
+		requestID = db.RequestIDFromContext(ctx)
+		return db.VerifyEmailTxResult{}, nil
+	}}
+	server := NewServer(store)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/verify_email?email_id=7&secret_code=0123456789abcdef0123456789abcdef", nil)
+	request.Header.Set(RequestIDHeader, "req-123")
+	server.Handler().ServeHTTP(recorder, request)
+
+	require.Equal(t, "req-123", requestID)
+	require.Equal(t, "req-123", recorder.Header().Get(RequestIDHeader))
+
+	recorder = httptest.NewRecorder()
+	request = httptest.NewRequest(http.MethodGet, "/verify_email?email_id=7&secret_code=0123456789abcdef0123456789abcdef", nil)
+	server.Handler().ServeHTTP(recorder, request)
+
+	require.Len(t, requestID, 32)
+	require.Equal(t, requestID, recorder.Header().Get(RequestIDHeader))
+}
@@ -0,0 +1,15 @@
+CREATE TABLE "outbox" (
+  "id" bigserial PRIMARY KEY,
+  "aggregate_type" varchar NOT NULL,
+  "aggregate_id" bigint NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "delivered_at" timestamptz
+);
+
+CREATE INDEX ON "outbox" ("aggregate_type", "aggregate_id");
+
+CREATE INDEX ON "outbox" ("id") WHERE "delivered_at" IS NULL;
+
+COMMENT ON COLUMN "outbox"."delivered_at" IS 'null until the relay has handed the event to a publisher';
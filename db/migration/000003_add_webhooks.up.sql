@@ -0,0 +1,50 @@
+CREATE TABLE "webhook_endpoints" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "event_types" varchar[] NOT NULL DEFAULT '{}',
+  "is_active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "endpoint_id" bigint NOT NULL,
+  "event_id" varchar NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "last_error" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "webhook_deliveries_status_check" CHECK ("status" IN ('pending', 'succeeded', 'dead'))
+);
+
+CREATE TABLE "webhook_delivery_attempts" (
+  "id" bigserial PRIMARY KEY,
+  "delivery_id" bigint NOT NULL,
+  "attempt" integer NOT NULL,
+  "status_code" integer,
+  "error" varchar,
+  "duration_ms" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "webhook_endpoints" ("owner");
+
+CREATE UNIQUE INDEX ON "webhook_deliveries" ("endpoint_id", "event_id");
+
+CREATE INDEX ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
+
+CREATE INDEX ON "webhook_delivery_attempts" ("delivery_id");
+
+COMMENT ON COLUMN "webhook_endpoints"."event_types" IS 'empty means every event type';
+
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending, succeeded or dead';
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("endpoint_id") REFERENCES "webhook_endpoints" ("id");
+
+ALTER TABLE "webhook_delivery_attempts" ADD FOREIGN KEY ("delivery_id") REFERENCES "webhook_deliveries" ("id");
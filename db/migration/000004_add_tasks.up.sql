@@ -0,0 +1,24 @@
+CREATE TABLE "tasks" (
+  "id" bigserial PRIMARY KEY,
+  "queue" varchar NOT NULL DEFAULT 'default',
+  "type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "priority" integer NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "max_attempts" integer NOT NULL DEFAULT 10,
+  "run_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  "last_error" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "tasks_status_check" CHECK ("status" IN ('pending', 'running', 'succeeded', 'failed'))
+);
+
+CREATE INDEX ON "tasks" ("queue", "priority" DESC, "run_at", "id") WHERE "status" = 'pending';
+
+CREATE INDEX ON "tasks" ("locked_until") WHERE "status" = 'running';
+
+COMMENT ON COLUMN "tasks"."priority" IS 'higher runs first';
+
+COMMENT ON COLUMN "tasks"."locked_until" IS 'a running task whose lock expired is handed out again';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "verify_emails";
+DROP TABLE IF EXISTS "users";
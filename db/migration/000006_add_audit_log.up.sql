@@ -0,0 +1,39 @@
+-- before / after 用 json 而不是 jsonb：jsonb 會重排 key、去掉空白，存進去的文字和算 hash 時的不一樣，驗證就會失敗
+CREATE TABLE "audit_log" (
+  "id" bigserial PRIMARY KEY,
+  "actor" varchar NOT NULL,
+  "action" varchar NOT NULL,
+  "entity_type" varchar NOT NULL,
+  "entity_id" varchar NOT NULL,
+  "before" json NOT NULL,
+  "after" json NOT NULL,
+  "request_id" varchar NOT NULL,
+  "prev_hash" varchar NOT NULL,
+  "hash" varchar UNIQUE NOT NULL,
+  "created_at" timestamptz NOT NULL
+);
+
+CREATE INDEX ON "audit_log" ("entity_type", "entity_id");
+
+COMMENT ON COLUMN "audit_log"."before" IS 'null for creates';
+
+COMMENT ON COLUMN "audit_log"."after" IS 'null for deletes';
+
+COMMENT ON COLUMN "audit_log"."prev_hash" IS 'hash of the previous row, empty for the first row';
+
+COMMENT ON COLUMN "audit_log"."hash" IS 'hex SHA-256 of prev_hash and the other columns except id';
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only, % is not allowed', TG_OP;
+END;
+$$;
+
+CREATE TRIGGER audit_log_no_update_delete
+BEFORE UPDATE OR DELETE ON "audit_log"
+FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+BEFORE TRUNCATE ON "audit_log"
+FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS accounts_balance_guard ON "accounts";
+
+DROP FUNCTION IF EXISTS accounts_guard_balance();
+
+DROP TABLE IF EXISTS "balance_adjustments";
+
+DROP TABLE IF EXISTS "system_accounts";
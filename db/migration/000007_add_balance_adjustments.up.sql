@@ -0,0 +1,62 @@
+-- 系統自己用的帳戶（例如每個幣別一個 suspense 帳戶），調帳時另一邊的分錄記在這裡
+CREATE TABLE "system_accounts" (
+  "purpose" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "account_id" bigint UNIQUE NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("purpose", "currency")
+);
+
+CREATE TABLE "balance_adjustments" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "suspense_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "reason_code" varchar NOT NULL,
+  "operator" varchar NOT NULL,
+  "note" varchar NOT NULL,
+  "entry_id" bigint NOT NULL,
+  "suspense_entry_id" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "balance_adjustments" ("account_id");
+
+COMMENT ON COLUMN "balance_adjustments"."amount" IS 'added to the account balance, the suspense account gets the opposite';
+
+ALTER TABLE "system_accounts" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "balance_adjustments" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "balance_adjustments" ADD FOREIGN KEY ("suspense_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "balance_adjustments" ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "balance_adjustments" ADD FOREIGN KEY ("suspense_entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "balance_adjustments" ADD CONSTRAINT "balance_adjustments_amount_check" CHECK ("amount" <> 0);
+
+ALTER TABLE "balance_adjustments" ADD CONSTRAINT "balance_adjustments_reason_code_check" CHECK ("reason_code" <> '');
+
+ALTER TABLE "balance_adjustments" ADD CONSTRAINT "balance_adjustments_operator_check" CHECK ("operator" <> '');
+
+-- accounts.balance 只能在記帳的時候改：先在同一個事務裡 set_config('bank.ledger_writes', 'on', true)（見 EnableLedgerWrites），
+-- 直接 UPDATE accounts SET balance = ... 會被擋下來，餘額就不會在沒有 entries 的情況下被改掉。
+-- 這是防呆而不是權限控管：能連上資料庫的人一樣可以自己 set_config；
+-- 應用程式如果用獨立的 role 連線，應該再 REVOKE UPDATE ("balance") ON "accounts"，只留給記帳用的 SECURITY DEFINER 函式。
+CREATE FUNCTION accounts_guard_balance() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+  IF current_setting('bank.ledger_writes', true) IS DISTINCT FROM 'on' THEN
+    RAISE EXCEPTION 'accounts.balance of account % can only be changed by ledger postings', OLD.id
+      USING HINT = 'post entries through TransferTx or AdjustBalanceTx';
+  END IF;
+  RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER accounts_balance_guard
+BEFORE UPDATE OF "balance" ON "accounts"
+FOR EACH ROW
+WHEN (OLD.balance IS DISTINCT FROM NEW.balance)
+EXECUTE FUNCTION accounts_guard_balance();
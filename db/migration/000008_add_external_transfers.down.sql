@@ -0,0 +1,15 @@
+-- 補記的期初 entries 留著：balance 本來就包含期初餘額，刪掉反而會對不上
+DROP TRIGGER IF EXISTS accounts_opening_balance_guard ON "accounts";
+
+CREATE OR REPLACE FUNCTION accounts_guard_balance() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+  IF current_setting('bank.ledger_writes', true) IS DISTINCT FROM 'on' THEN
+    RAISE EXCEPTION 'accounts.balance of account % can only be changed by ledger postings', OLD.id
+      USING HINT = 'post entries through TransferTx or AdjustBalanceTx';
+  END IF;
+  RETURN NEW;
+END;
+$$;
+
+DROP TABLE IF EXISTS "external_transfers";
@@ -0,0 +1,122 @@
+-- 入金 / 出金：客戶帳戶和該幣別的 clearing 帳戶（system_accounts.purpose = 'clearing'）之間的轉帳，
+-- clearing 帳戶代表銀行在外部清算機構的部位，餘額是負的表示外面欠我們錢已經入到客戶帳上
+CREATE TABLE "external_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "clearing_account_id" bigint NOT NULL,
+  "direction" varchar NOT NULL,
+  "amount" bigint NOT NULL,
+  "external_ref" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "failure_reason" varchar NOT NULL DEFAULT '',
+  "entry_id" bigint,
+  "clearing_entry_id" bigint,
+  "reversal_entry_id" bigint,
+  "clearing_reversal_entry_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "external_transfers" ("account_id");
+
+CREATE UNIQUE INDEX ON "external_transfers" ("direction", "external_ref");
+
+COMMENT ON COLUMN "external_transfers"."direction" IS 'deposit or withdrawal';
+
+COMMENT ON COLUMN "external_transfers"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "external_transfers"."external_ref" IS 'reference of the payment rail, opening:<account id> for opening balances';
+
+COMMENT ON COLUMN "external_transfers"."status" IS 'pending, settled or failed';
+
+COMMENT ON COLUMN "external_transfers"."entry_id" IS 'deposits are booked when settled, withdrawals when created';
+
+COMMENT ON COLUMN "external_transfers"."reversal_entry_id" IS 'set when a booked withdrawal fails';
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("clearing_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("clearing_entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("reversal_entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("clearing_reversal_entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "external_transfers" ADD CONSTRAINT "external_transfers_direction_check" CHECK ("direction" IN ('deposit', 'withdrawal'));
+
+ALTER TABLE "external_transfers" ADD CONSTRAINT "external_transfers_status_check" CHECK ("status" IN ('pending', 'settled', 'failed'));
+
+ALTER TABLE "external_transfers" ADD CONSTRAINT "external_transfers_amount_check" CHECK ("amount" > 0);
+
+-- 新帳戶的期初餘額也要有 entries：INSERT 時 balance 不是 0 也要先 EnableLedgerWrites
+CREATE OR REPLACE FUNCTION accounts_guard_balance() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+  IF current_setting('bank.ledger_writes', true) IS DISTINCT FROM 'on' THEN
+    RAISE EXCEPTION 'accounts.balance of account % can only be changed by ledger postings', NEW.id
+      USING HINT = 'post entries through TransferTx, AdjustBalanceTx or DepositTx';
+  END IF;
+  RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER accounts_opening_balance_guard
+BEFORE INSERT ON "accounts"
+FOR EACH ROW
+WHEN (NEW.balance <> 0)
+EXECUTE FUNCTION accounts_guard_balance();
+
+-- 補記既有帳戶的期初餘額：balance 和 entries 加總的差額，記成一筆已入帳的 opening:<id> 入金（差額是負的就記成出金）
+DO $$
+DECLARE
+  a record;
+  clearing_id bigint;
+  customer_entry_id bigint;
+  clearing_entry_id bigint;
+BEGIN
+  PERFORM set_config('bank.ledger_writes', 'on', true);
+
+  INSERT INTO accounts (owner, balance, currency)
+  SELECT 'system:clearing', 0, currencies.currency
+  FROM (SELECT DISTINCT currency FROM accounts) AS currencies
+  WHERE NOT EXISTS (
+    SELECT 1 FROM system_accounts
+    WHERE system_accounts.purpose = 'clearing' AND system_accounts.currency = currencies.currency
+  );
+
+  INSERT INTO system_accounts (purpose, currency, account_id)
+  SELECT 'clearing', currency, id FROM accounts WHERE owner = 'system:clearing'
+  ON CONFLICT DO NOTHING;
+
+  FOR a IN
+    SELECT accounts.id, accounts.currency,
+      accounts.balance - COALESCE((SELECT sum(entries.amount) FROM entries WHERE entries.account_id = accounts.id), 0) AS amount
+    FROM accounts
+    WHERE accounts.id NOT IN (SELECT account_id FROM system_accounts)
+    ORDER BY accounts.id
+  LOOP
+    CONTINUE WHEN a.amount = 0;
+
+    SELECT account_id INTO clearing_id FROM system_accounts
+    WHERE purpose = 'clearing' AND currency = a.currency;
+
+    INSERT INTO entries (account_id, amount) VALUES (a.id, a.amount)
+    RETURNING id INTO customer_entry_id;
+
+    INSERT INTO entries (account_id, amount) VALUES (clearing_id, -a.amount)
+    RETURNING id INTO clearing_entry_id;
+
+    UPDATE accounts SET balance = balance - a.amount WHERE id = clearing_id;
+
+    INSERT INTO external_transfers (
+      account_id, clearing_account_id, direction, amount, external_ref, status, entry_id, clearing_entry_id
+    ) VALUES (
+      a.id, clearing_id, CASE WHEN a.amount > 0 THEN 'deposit' ELSE 'withdrawal' END, abs(a.amount),
+      'opening:' || a.id, 'settled', customer_entry_id, clearing_entry_id
+    );
+  END LOOP;
+END;
+$$;
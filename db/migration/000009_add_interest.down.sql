@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "interest_accruals";
+
+DROP TABLE IF EXISTS "interest_postings";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "product_id";
+
+DROP TABLE IF EXISTS "account_products";
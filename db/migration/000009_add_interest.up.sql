@@ -0,0 +1,76 @@
+CREATE TABLE "account_products" (
+  "id" bigserial PRIMARY KEY,
+  "name" varchar UNIQUE NOT NULL,
+  "annual_rate_bps" int NOT NULL,
+  "day_count" varchar NOT NULL,
+  "posting_frequency" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "accounts" ADD COLUMN "product_id" bigint;
+
+-- 每個帳戶每天一筆，UNIQUE 讓同一天重跑不會重複計息
+CREATE TABLE "interest_accruals" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "product_id" bigint NOT NULL,
+  "accrual_date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "annual_rate_bps" int NOT NULL,
+  "day_count" varchar NOT NULL,
+  "days" int NOT NULL,
+  "units" bigint NOT NULL,
+  "posting_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("account_id", "accrual_date")
+);
+
+CREATE TABLE "interest_postings" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "period_end" date NOT NULL,
+  "units" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "carry_units" bigint NOT NULL,
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("account_id", "period_end")
+);
+
+CREATE INDEX ON "interest_accruals" ("account_id", "posting_id");
+
+COMMENT ON COLUMN "account_products"."annual_rate_bps" IS '125 is 1.25% a year';
+
+COMMENT ON COLUMN "account_products"."day_count" IS 'ACT/365 or 30/360';
+
+COMMENT ON COLUMN "account_products"."posting_frequency" IS 'monthly, quarterly or annually';
+
+COMMENT ON COLUMN "interest_accruals"."balance" IS 'end of day balance from entries';
+
+COMMENT ON COLUMN "interest_accruals"."days" IS 'days counted for the accrual date by the day count convention';
+
+COMMENT ON COLUMN "interest_accruals"."units" IS 'interest in 1/(10000*26280) of the minor unit, exact for both day counts';
+
+COMMENT ON COLUMN "interest_postings"."units" IS 'carry of the previous posting plus the accruals of the period';
+
+COMMENT ON COLUMN "interest_postings"."carry_units" IS 'units below one minor unit, carried to the next posting';
+
+COMMENT ON COLUMN "interest_postings"."transfer_id" IS 'null when the amount is 0';
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("product_id") REFERENCES "account_products" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("product_id") REFERENCES "account_products" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("posting_id") REFERENCES "interest_postings" ("id");
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "account_products" ADD CONSTRAINT "account_products_annual_rate_bps_check" CHECK ("annual_rate_bps" >= 0);
+
+ALTER TABLE "account_products" ADD CONSTRAINT "account_products_day_count_check" CHECK ("day_count" IN ('ACT/365', '30/360'));
+
+ALTER TABLE "account_products" ADD CONSTRAINT "account_products_posting_frequency_check" CHECK ("posting_frequency" IN ('monthly', 'quarterly', 'annually'));
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "overdraft_charges";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "overdraft_fee";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "overdraft_rate_bps";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "overdraft_limit";
@@ -0,0 +1,46 @@
+ALTER TABLE "accounts" ADD COLUMN "overdraft_limit" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD COLUMN "overdraft_rate_bps" int NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD COLUMN "overdraft_fee" bigint NOT NULL DEFAULT 0;
+
+-- 每個帳戶每一期一筆，UNIQUE 讓同一期重跑不會重複收費
+CREATE TABLE "overdraft_charges" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "period_start" date NOT NULL,
+  "period_end" date NOT NULL,
+  "overdrawn_days" int NOT NULL,
+  "rate_bps" int NOT NULL,
+  "units" bigint NOT NULL,
+  "fee" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "carry_units" bigint NOT NULL,
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("account_id", "period_end")
+);
+
+COMMENT ON COLUMN "accounts"."overdraft_limit" IS 'how far below 0 customer debits may take the balance';
+
+COMMENT ON COLUMN "accounts"."overdraft_rate_bps" IS 'yearly interest on the overdrawn end of day balance, ACT/365';
+
+COMMENT ON COLUMN "accounts"."overdraft_fee" IS 'charged once for every period with an overdrawn day';
+
+COMMENT ON COLUMN "overdraft_charges"."units" IS 'carry of the previous charge plus the interest of the period, in 1/(10000*26280) of the minor unit';
+
+COMMENT ON COLUMN "overdraft_charges"."amount" IS 'whole minor units of units plus fee';
+
+COMMENT ON COLUMN "overdraft_charges"."carry_units" IS 'units below one minor unit, carried to the next charge';
+
+COMMENT ON COLUMN "overdraft_charges"."transfer_id" IS 'null when the amount is 0';
+
+ALTER TABLE "overdraft_charges" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "overdraft_charges" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_overdraft_limit_check" CHECK ("overdraft_limit" >= 0);
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_overdraft_rate_bps_check" CHECK ("overdraft_rate_bps" >= 0);
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_overdraft_fee_check" CHECK ("overdraft_fee" >= 0);
@@ -0,0 +1,34 @@
+-- 一筆限額設定只屬於一個帳戶，或一個 owner 在某個幣別的所有帳戶；欄位是 null 表示不限制
+CREATE TABLE "transfer_limits" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint UNIQUE,
+  "owner" varchar,
+  "currency" varchar,
+  "max_single" bigint,
+  "max_daily" bigint,
+  "max_monthly" bigint,
+  "max_hourly_count" int,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("owner", "currency")
+);
+
+-- 限額檢查用 from_account_id + created_at 彙總轉出金額
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+COMMENT ON COLUMN "transfer_limits"."max_daily" IS 'outgoing total of the UTC calendar day';
+
+COMMENT ON COLUMN "transfer_limits"."max_monthly" IS 'outgoing total of the UTC calendar month';
+
+COMMENT ON COLUMN "transfer_limits"."max_hourly_count" IS 'outgoing transfers in the last 60 minutes';
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_limits" ADD CONSTRAINT "transfer_limits_scope_check" CHECK (
+  ("account_id" IS NOT NULL AND "owner" IS NULL AND "currency" IS NULL) OR
+  ("account_id" IS NULL AND "owner" IS NOT NULL AND "currency" IS NOT NULL)
+);
+
+ALTER TABLE "transfer_limits" ADD CONSTRAINT "transfer_limits_amount_check" CHECK (
+  "max_single" >= 0 AND "max_daily" >= 0 AND "max_monthly" >= 0 AND "max_hourly_count" >= 0
+);
@@ -0,0 +1,32 @@
+-- 被規則攔下等人工審核的轉帳，核准時才真的轉帳
+CREATE TABLE "review_cases" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "hits" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "reviewer" varchar NOT NULL DEFAULT '',
+  "decision_note" varchar NOT NULL DEFAULT '',
+  "decided_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "review_cases" ("status", "id");
+
+CREATE INDEX ON "review_cases" ("from_account_id");
+
+COMMENT ON COLUMN "review_cases"."hits" IS 'the rules that flagged the transfer and why';
+
+COMMENT ON COLUMN "review_cases"."transfer_id" IS 'set when the case is approved and the transfer is made';
+
+ALTER TABLE "review_cases" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "review_cases" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "review_cases" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "review_cases" ADD CONSTRAINT "review_cases_amount_check" CHECK ("amount" > 0);
+
+ALTER TABLE "review_cases" ADD CONSTRAINT "review_cases_status_check" CHECK ("status" IN ('pending', 'approved', 'rejected'));
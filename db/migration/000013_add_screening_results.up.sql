@@ -0,0 +1,24 @@
+-- 開戶時 owner 名稱比對制裁名單的結果，擋下來的開戶也會留一筆（account_id 是 null）
+CREATE TABLE "screening_results" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint,
+  "name" varchar NOT NULL,
+  "normalized_name" varchar NOT NULL,
+  "outcome" varchar NOT NULL,
+  "score" double precision NOT NULL,
+  "entry_id" varchar NOT NULL,
+  "matched_name" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "screening_results" ("account_id");
+
+CREATE INDEX ON "screening_results" ("outcome", "id");
+
+COMMENT ON COLUMN "screening_results"."score" IS 'similarity of the best match, 0 to 1';
+
+COMMENT ON COLUMN "screening_results"."entry_id" IS 'list entry of the best match, empty when nothing matched';
+
+ALTER TABLE "screening_results" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "screening_results" ADD CONSTRAINT "screening_results_outcome_check" CHECK ("outcome" IN ('allow', 'hold', 'block'));
@@ -0,0 +1,3 @@
+ALTER TABLE "users" DROP COLUMN IF EXISTS "anonymized_at";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "closed_at";
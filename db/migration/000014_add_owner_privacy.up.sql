@@ -0,0 +1,7 @@
+ALTER TABLE "accounts" ADD COLUMN "closed_at" timestamptz;
+
+ALTER TABLE "users" ADD COLUMN "anonymized_at" timestamptz;
+
+COMMENT ON COLUMN "accounts"."closed_at" IS 'closed accounts take no more transfers, their owner can be anonymized';
+
+COMMENT ON COLUMN "users"."anonymized_at" IS 'set when the personal data was replaced with a pseudonym';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "account_import_rows";
+
+DROP TABLE IF EXISTS "account_imports";
@@ -0,0 +1,23 @@
+CREATE TABLE "account_imports" (
+  "id" bigserial PRIMARY KEY,
+  "source" varchar NOT NULL,
+  "accepted" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- COPY 進來的暫存列，合併完就在同一個事務裡刪掉；不寫 WAL，Rollback 或當機也不會留下東西
+CREATE UNLOGGED TABLE "account_import_rows" (
+  "import_id" bigint NOT NULL,
+  "line" bigint NOT NULL,
+  "owner" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "balance" bigint NOT NULL,
+  "account_id" bigint,
+  "entry_id" bigint,
+  "clearing_entry_id" bigint,
+  PRIMARY KEY ("import_id", "line")
+);
+
+COMMENT ON COLUMN "account_import_rows"."line" IS 'line of the row in the source file, for the reject file';
+
+COMMENT ON COLUMN "account_import_rows"."account_id" IS 'taken from accounts_id_seq before the accounts are inserted';
@@ -0,0 +1,20 @@
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint;
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+CREATE INDEX ON "entries" ("account_id", "created_at");
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'the transfer the entry was posted for, null for entries of external transfers and adjustments';
+
+-- 補上既有 entries 的 transfer：TransferTx 在同一個事務裡建 transfer 和兩筆 entries，now() 是事務開始的時間，所以 created_at 一樣
+UPDATE "entries"
+SET "transfer_id" = "transfers"."id"
+FROM "transfers"
+WHERE "entries"."transfer_id" IS NULL
+  AND "entries"."created_at" = "transfers"."created_at"
+  AND (
+    ("entries"."account_id" = "transfers"."from_account_id" AND "entries"."amount" = -"transfers"."amount")
+    OR ("entries"."account_id" = "transfers"."to_account_id" AND "entries"."amount" = "transfers"."amount")
+  );
@@ -0,0 +1,34 @@
+CREATE TABLE "payment_instructions" (
+  "id" bigserial PRIMARY KEY,
+  "message_id" varchar NOT NULL,
+  "payment_info_id" varchar NOT NULL,
+  "instruction_id" varchar NOT NULL DEFAULT '',
+  "end_to_end_id" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "status" varchar NOT NULL,
+  "reason_code" varchar NOT NULL DEFAULT '',
+  "reason" varchar NOT NULL DEFAULT '',
+  "transfer_id" bigint,
+  "review_case_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "payment_instructions" ("from_account_id", "end_to_end_id");
+
+CREATE INDEX ON "payment_instructions" ("message_id");
+
+COMMENT ON COLUMN "payment_instructions"."end_to_end_id" IS 'set by the debtor, a second instruction with the same id from the same account is a replay';
+
+COMMENT ON COLUMN "payment_instructions"."status" IS 'accepted, rejected or pending';
+
+COMMENT ON COLUMN "payment_instructions"."reason_code" IS 'ISO 20022 ExternalStatusReason1Code of a rejected instruction';
+
+ALTER TABLE "payment_instructions" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "payment_instructions" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "payment_instructions" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "payment_instructions" ADD FOREIGN KEY ("review_case_id") REFERENCES "review_cases" ("id");
@@ -0,0 +1,5 @@
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "account_number";
+
+DROP FUNCTION IF EXISTS generate_account_number();
+
+DROP FUNCTION IF EXISTS account_number_check_digits(text, text);
@@ -0,0 +1,47 @@
+-- 對外的帳號照 IBAN 的規則組成：國碼 TW + 兩位檢查碼 + 銀行代碼 ANDY + 12 位隨機數字，
+-- 檢查碼和 util.IBANCheckDigits 算法相同。台灣不在 IBAN 註冊表裡，所以這是 IBAN 格式的帳號，不是註冊過的 IBAN。
+CREATE FUNCTION account_number_check_digits(country text, bban text) RETURNS text
+LANGUAGE plpgsql IMMUTABLE AS $$
+DECLARE
+  digits text := '';
+  c text;
+BEGIN
+  -- BBAN 加國碼加 00，字母換成 10 到 35，整串數字 mod 97
+  FOREACH c IN ARRAY regexp_split_to_array(upper(bban || country) || '00', '') LOOP
+    IF c BETWEEN '0' AND '9' THEN
+      digits := digits || c;
+    ELSE
+      digits := digits || (ascii(c) - 55)::text;
+    END IF;
+  END LOOP;
+  RETURN lpad((98 - mod(digits::numeric, 97))::text, 2, '0');
+END;
+$$;
+
+-- 帳號是隨機的，不像 id 那樣猜得到，也看不出有多少帳戶；撞號就重抽，unique index 是最後一道防線
+CREATE FUNCTION generate_account_number() RETURNS varchar
+LANGUAGE plpgsql VOLATILE AS $$
+DECLARE
+  bban text;
+  number varchar;
+BEGIN
+  LOOP
+    bban := 'ANDY' || lpad(floor(random() * 1e12)::bigint::text, 12, '0');
+    number := 'TW' || account_number_check_digits('TW', bban) || bban;
+    EXIT WHEN NOT EXISTS (SELECT 1 FROM accounts WHERE account_number = number);
+  END LOOP;
+  RETURN number;
+END;
+$$;
+
+ALTER TABLE "accounts" ADD COLUMN "account_number" varchar;
+
+UPDATE "accounts" SET "account_number" = generate_account_number();
+
+ALTER TABLE "accounts" ALTER COLUMN "account_number" SET DEFAULT generate_account_number();
+
+ALTER TABLE "accounts" ALTER COLUMN "account_number" SET NOT NULL;
+
+CREATE UNIQUE INDEX ON "accounts" ("account_number");
+
+COMMENT ON COLUMN "accounts"."account_number" IS 'public IBAN-style account number, stored without spaces';
@@ -0,0 +1,106 @@
+-- 換回沒有分區的表。已經卸下（detach）的分區不會搬回來，要先手動 ATTACH 回去再跑這個 migration
+DROP TRIGGER IF EXISTS "entries_transfer_reference" ON "entries";
+
+DROP TRIGGER IF EXISTS "balance_adjustments_entry_reference" ON "balance_adjustments";
+
+DROP TRIGGER IF EXISTS "external_transfers_entry_reference" ON "external_transfers";
+
+DROP TRIGGER IF EXISTS "interest_postings_transfer_reference" ON "interest_postings";
+
+DROP TRIGGER IF EXISTS "overdraft_charges_transfer_reference" ON "overdraft_charges";
+
+DROP TRIGGER IF EXISTS "review_cases_transfer_reference" ON "review_cases";
+
+DROP TRIGGER IF EXISTS "payment_instructions_transfer_reference" ON "payment_instructions";
+
+DROP FUNCTION IF EXISTS ledger_reference_check();
+
+DROP FUNCTION IF EXISTS detach_ledger_partitions(date, text);
+
+DROP FUNCTION IF EXISTS create_ledger_partitions(date);
+
+DROP FUNCTION IF EXISTS create_ledger_partition(text, date);
+
+DROP TABLE IF EXISTS "archived_entry_totals";
+
+ALTER TABLE "entries" RENAME TO "entries_partitioned";
+
+ALTER TABLE "transfers" RENAME TO "transfers_partitioned";
+
+CREATE TABLE "entries" (
+  "id" bigint PRIMARY KEY DEFAULT nextval('entries_id_seq'),
+  "account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "transfer_id" bigint
+);
+
+CREATE TABLE "transfers" (
+  "id" bigint PRIMARY KEY DEFAULT nextval('transfers_id_seq'),
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER SEQUENCE "entries_id_seq" OWNED BY "entries"."id";
+
+ALTER SEQUENCE "transfers_id_seq" OWNED BY "transfers"."id";
+
+INSERT INTO "transfers" ("id", "from_account_id", "to_account_id", "amount", "created_at")
+SELECT "id", "from_account_id", "to_account_id", "amount", "created_at" FROM "transfers_partitioned";
+
+INSERT INTO "entries" ("id", "account_id", "amount", "created_at", "transfer_id")
+SELECT "id", "account_id", "amount", "created_at", "transfer_id" FROM "entries_partitioned";
+
+DROP TABLE "entries_partitioned";
+
+DROP TABLE "transfers_partitioned";
+
+CREATE INDEX ON "entries" ("account_id");
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+CREATE INDEX ON "entries" ("account_id", "created_at");
+
+CREATE INDEX ON "transfers" ("from_account_id");
+
+CREATE INDEX ON "transfers" ("to_account_id");
+
+CREATE INDEX ON "transfers" ("from_account_id", "to_account_id");
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+COMMENT ON COLUMN "entries"."amount" IS 'can be negative or positive';
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'the transfer the entry was posted for, null for entries of external transfers and adjustments';
+
+COMMENT ON COLUMN "transfers"."amount" IS 'must be positive';
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "balance_adjustments" ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "balance_adjustments" ADD FOREIGN KEY ("suspense_entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("clearing_entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("reversal_entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("clearing_reversal_entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "overdraft_charges" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "review_cases" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "payment_instructions" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
@@ -0,0 +1,306 @@
+-- entries 和 transfers 改成依 created_at（UTC 的月份）分區的表，另外有一個 default 分區接住還沒建分區的月份。
+-- 這個 migration 會把兩張表整個複製一次，期間兩張表都鎖住，大的資料庫要在維護時段跑。
+--
+-- 分區表的 unique / primary key 一定要包含分區鍵，所以主鍵變成 (id, created_at)，id 仍然由同一個 sequence 產生。
+-- 其他表指向 entries.id / transfers.id 的 foreign key 沒辦法再用宣告式的 FOREIGN KEY（被參照的欄位必須是 unique），
+-- 改成 ledger_reference_check trigger 在寫入時檢查一樣的事；entries 和 transfers 只會新增不會刪除，所以只要檢查參照的一方。
+
+-- 指向 entries / transfers 的 foreign key 先拿掉，最後換成 trigger
+ALTER TABLE "entries" DROP CONSTRAINT "entries_transfer_id_fkey";
+
+ALTER TABLE "balance_adjustments" DROP CONSTRAINT "balance_adjustments_entry_id_fkey";
+
+ALTER TABLE "balance_adjustments" DROP CONSTRAINT "balance_adjustments_suspense_entry_id_fkey";
+
+ALTER TABLE "external_transfers" DROP CONSTRAINT "external_transfers_entry_id_fkey";
+
+ALTER TABLE "external_transfers" DROP CONSTRAINT "external_transfers_clearing_entry_id_fkey";
+
+ALTER TABLE "external_transfers" DROP CONSTRAINT "external_transfers_reversal_entry_id_fkey";
+
+ALTER TABLE "external_transfers" DROP CONSTRAINT "external_transfers_clearing_reversal_entry_id_fkey";
+
+ALTER TABLE "interest_postings" DROP CONSTRAINT "interest_postings_transfer_id_fkey";
+
+ALTER TABLE "overdraft_charges" DROP CONSTRAINT "overdraft_charges_transfer_id_fkey";
+
+ALTER TABLE "review_cases" DROP CONSTRAINT "review_cases_transfer_id_fkey";
+
+ALTER TABLE "payment_instructions" DROP CONSTRAINT "payment_instructions_transfer_id_fkey";
+
+-- 舊表改名後把索引拿掉、主鍵改名，新表才能用原本的名字
+ALTER TABLE "entries" RENAME TO "entries_unpartitioned";
+
+ALTER TABLE "transfers" RENAME TO "transfers_unpartitioned";
+
+ALTER TABLE "entries_unpartitioned" RENAME CONSTRAINT "entries_pkey" TO "entries_unpartitioned_pkey";
+
+ALTER TABLE "transfers_unpartitioned" RENAME CONSTRAINT "transfers_pkey" TO "transfers_unpartitioned_pkey";
+
+DROP INDEX "entries_account_id_idx";
+
+DROP INDEX "entries_transfer_id_idx";
+
+DROP INDEX "entries_account_id_created_at_idx";
+
+DROP INDEX "transfers_from_account_id_idx";
+
+DROP INDEX "transfers_to_account_id_idx";
+
+DROP INDEX "transfers_from_account_id_to_account_id_idx";
+
+DROP INDEX "transfers_from_account_id_created_at_idx";
+
+-- 欄位順序和原本的表一樣，SELECT * 的查詢和 sqlc 產生的 struct 都不用改
+CREATE TABLE "entries" (
+  "id" bigint NOT NULL DEFAULT nextval('entries_id_seq'),
+  "account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "transfer_id" bigint,
+  PRIMARY KEY ("id", "created_at")
+) PARTITION BY RANGE ("created_at");
+
+CREATE TABLE "transfers" (
+  "id" bigint NOT NULL DEFAULT nextval('transfers_id_seq'),
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("id", "created_at")
+) PARTITION BY RANGE ("created_at");
+
+-- sequence 跟著新表，舊表 DROP 的時候才不會一起被刪掉
+ALTER SEQUENCE "entries_id_seq" OWNED BY "entries"."id";
+
+ALTER SEQUENCE "transfers_id_seq" OWNED BY "transfers"."id";
+
+CREATE INDEX ON "entries" ("account_id");
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+CREATE INDEX ON "entries" ("account_id", "created_at");
+
+CREATE INDEX ON "transfers" ("from_account_id");
+
+CREATE INDEX ON "transfers" ("to_account_id");
+
+CREATE INDEX ON "transfers" ("from_account_id", "to_account_id");
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+COMMENT ON COLUMN "entries"."amount" IS 'can be negative or positive';
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'the transfer the entry was posted for, null for entries of external transfers and adjustments';
+
+COMMENT ON COLUMN "transfers"."amount" IS 'must be positive';
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+-- create_ledger_partition 建立 parent（entries 或 transfers）在 month_start 那個 UTC 月份的分區 <parent>_pYYYYMM，已經有了就回傳 NULL。
+-- default 分區裡這個月的資料會先搬進新分區，不然 ATTACH 會失敗
+CREATE FUNCTION create_ledger_partition(parent text, month_start date) RETURNS text
+LANGUAGE plpgsql AS $$
+DECLARE
+  partition_name text := format('%s_p%s', parent, to_char(month_start, 'YYYYMM'));
+  lower_bound timestamptz := date_trunc('month', month_start::timestamp) AT TIME ZONE 'UTC';
+  upper_bound timestamptz := (date_trunc('month', month_start::timestamp) + interval '1 month') AT TIME ZONE 'UTC';
+BEGIN
+  IF parent NOT IN ('entries', 'transfers') THEN
+    RAISE EXCEPTION '% is not a ledger table', parent;
+  END IF;
+  IF to_regclass(partition_name) IS NOT NULL THEN
+    RETURN NULL;
+  END IF;
+
+  EXECUTE format('CREATE TABLE %I (LIKE %I INCLUDING DEFAULTS)', partition_name, parent);
+  EXECUTE format(
+    'WITH moved AS (DELETE FROM %I WHERE created_at >= $1 AND created_at < $2 RETURNING *) INSERT INTO %I SELECT * FROM moved',
+    parent || '_default', partition_name
+  ) USING lower_bound, upper_bound;
+  EXECUTE format('ALTER TABLE %I ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)', parent, partition_name, lower_bound, upper_bound);
+  RETURN partition_name;
+END;
+$$;
+
+-- create_ledger_partitions 建立 entries 和 transfers 從目前的 UTC 月份到 through_month 那個月的分區，回傳新建的分區
+CREATE FUNCTION create_ledger_partitions(through_month date) RETURNS SETOF text
+LANGUAGE plpgsql AS $$
+DECLARE
+  month_start date := date_trunc('month', now() AT TIME ZONE 'UTC')::date;
+  parent text;
+  partition_name text;
+BEGIN
+  WHILE month_start <= through_month LOOP
+    FOREACH parent IN ARRAY ARRAY['entries', 'transfers'] LOOP
+      partition_name := create_ledger_partition(parent, month_start);
+      IF partition_name IS NOT NULL THEN
+        RETURN NEXT partition_name;
+      END IF;
+    END LOOP;
+    month_start := (month_start + interval '1 month')::date;
+  END LOOP;
+END;
+$$;
+
+-- 卸下的 entries 分區在這裡留下每個帳戶的加總，對帳和 GetBalanceAt 把它算進去，餘額才對得起來
+CREATE TABLE "archived_entry_totals" (
+  "partition_name" varchar NOT NULL,
+  "account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "archived_before" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("partition_name", "account_id")
+);
+
+CREATE INDEX ON "archived_entry_totals" ("account_id");
+
+COMMENT ON COLUMN "archived_entry_totals"."amount" IS 'sum of the entries of the account in the detached partition';
+
+COMMENT ON COLUMN "archived_entry_totals"."archived_before" IS 'upper bound of the detached partition, every entry of the partition was created before it';
+
+ALTER TABLE "archived_entry_totals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+-- detach_ledger_partitions 卸下 entries 和 transfers 所有在 detach_before 之前結束的月份分區，回傳卸下的分區。
+-- archive_schema 不是空字串時，卸下的表搬到那個 schema；資料不會被刪掉，只是不再出現在 entries / transfers 裡。
+-- 目前和未來的月份不能卸下
+CREATE FUNCTION detach_ledger_partitions(detach_before date, archive_schema text) RETURNS SETOF text
+LANGUAGE plpgsql AS $$
+DECLARE
+  parent text;
+  partition_name text;
+  upper_bound timestamptz;
+BEGIN
+  IF detach_before > date_trunc('month', now() AT TIME ZONE 'UTC')::date THEN
+    RAISE EXCEPTION 'cannot detach the partitions of the current month or later, % is after the start of the month', detach_before;
+  END IF;
+  IF archive_schema <> '' THEN
+    EXECUTE format('CREATE SCHEMA IF NOT EXISTS %I', archive_schema);
+  END IF;
+
+  FOREACH parent IN ARRAY ARRAY['entries', 'transfers'] LOOP
+    FOR partition_name IN
+      SELECT child.relname
+      FROM pg_inherits
+      JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+      WHERE pg_inherits.inhparent = parent::regclass
+        AND child.relname ~ ('^' || parent || '_p[0-9]{6}$')
+      ORDER BY child.relname
+    LOOP
+      upper_bound := (to_date(right(partition_name, 6), 'YYYYMM') + interval '1 month')::timestamp AT TIME ZONE 'UTC';
+      CONTINUE WHEN upper_bound > (detach_before::timestamp AT TIME ZONE 'UTC');
+
+      IF parent = 'entries' THEN
+        EXECUTE format(
+          'INSERT INTO archived_entry_totals (partition_name, account_id, amount, archived_before) '
+          'SELECT %L, account_id, sum(amount), $1 FROM %I GROUP BY account_id',
+          partition_name, partition_name
+        ) USING upper_bound;
+      END IF;
+
+      EXECUTE format('ALTER TABLE %I DETACH PARTITION %I', parent, partition_name);
+      IF archive_schema <> '' THEN
+        EXECUTE format('ALTER TABLE %I SET SCHEMA %I', partition_name, archive_schema);
+      END IF;
+      RETURN NEXT partition_name;
+    END LOOP;
+  END LOOP;
+END;
+$$;
+
+-- 舊資料的月份加上接下來三個月先建好分區，再把資料搬過去
+DO $$
+DECLARE
+  month_start date;
+BEGIN
+  EXECUTE 'CREATE TABLE entries_default PARTITION OF entries DEFAULT';
+  EXECUTE 'CREATE TABLE transfers_default PARTITION OF transfers DEFAULT';
+
+  SELECT date_trunc('month', min(created_at) AT TIME ZONE 'UTC')::date INTO month_start
+  FROM (
+    SELECT created_at FROM entries_unpartitioned
+    UNION ALL
+    SELECT created_at FROM transfers_unpartitioned
+  ) AS ledger;
+
+  month_start := LEAST(COALESCE(month_start, 'infinity'), date_trunc('month', now() AT TIME ZONE 'UTC')::date);
+  WHILE month_start <= (now() AT TIME ZONE 'UTC' + interval '3 months')::date LOOP
+    PERFORM create_ledger_partition('entries', month_start);
+    PERFORM create_ledger_partition('transfers', month_start);
+    month_start := (month_start + interval '1 month')::date;
+  END LOOP;
+END;
+$$;
+
+INSERT INTO "transfers" ("id", "from_account_id", "to_account_id", "amount", "created_at")
+SELECT "id", "from_account_id", "to_account_id", "amount", "created_at" FROM "transfers_unpartitioned";
+
+INSERT INTO "entries" ("id", "account_id", "amount", "created_at", "transfer_id")
+SELECT "id", "account_id", "amount", "created_at", "transfer_id" FROM "entries_unpartitioned";
+
+DROP TABLE "entries_unpartitioned";
+
+DROP TABLE "transfers_unpartitioned";
+
+-- ledger_reference_check 取代指向 entries / transfers 的 foreign key：TG_ARGV[0] 是被參照的表，其餘是參照它 id 的欄位
+CREATE FUNCTION ledger_reference_check() RETURNS trigger
+LANGUAGE plpgsql AS $$
+DECLARE
+  referenced text := TG_ARGV[0];
+  i int;
+  referenced_id bigint;
+  present boolean;
+BEGIN
+  FOR i IN 1 .. TG_NARGS - 1 LOOP
+    referenced_id := (to_jsonb(NEW) ->> TG_ARGV[i])::bigint;
+    CONTINUE WHEN referenced_id IS NULL;
+
+    EXECUTE format('SELECT EXISTS (SELECT 1 FROM %I WHERE id = $1)', referenced) INTO present USING referenced_id;
+    IF NOT present THEN
+      RAISE EXCEPTION 'insert or update on table "%" violates reference to "%"', TG_TABLE_NAME, referenced
+        USING ERRCODE = 'foreign_key_violation',
+              DETAIL = format('Key (%s)=(%s) is not present in table "%s".', TG_ARGV[i], referenced_id, referenced);
+    END IF;
+  END LOOP;
+  RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER entries_transfer_reference
+AFTER INSERT OR UPDATE OF "transfer_id" ON "entries"
+FOR EACH ROW
+EXECUTE FUNCTION ledger_reference_check('transfers', 'transfer_id');
+
+CREATE TRIGGER balance_adjustments_entry_reference
+AFTER INSERT OR UPDATE OF "entry_id", "suspense_entry_id" ON "balance_adjustments"
+FOR EACH ROW
+EXECUTE FUNCTION ledger_reference_check('entries', 'entry_id', 'suspense_entry_id');
+
+CREATE TRIGGER external_transfers_entry_reference
+AFTER INSERT OR UPDATE OF "entry_id", "clearing_entry_id", "reversal_entry_id", "clearing_reversal_entry_id" ON "external_transfers"
+FOR EACH ROW
+EXECUTE FUNCTION ledger_reference_check('entries', 'entry_id', 'clearing_entry_id', 'reversal_entry_id', 'clearing_reversal_entry_id');
+
+CREATE TRIGGER interest_postings_transfer_reference
+AFTER INSERT OR UPDATE OF "transfer_id" ON "interest_postings"
+FOR EACH ROW
+EXECUTE FUNCTION ledger_reference_check('transfers', 'transfer_id');
+
+CREATE TRIGGER overdraft_charges_transfer_reference
+AFTER INSERT OR UPDATE OF "transfer_id" ON "overdraft_charges"
+FOR EACH ROW
+EXECUTE FUNCTION ledger_reference_check('transfers', 'transfer_id');
+
+CREATE TRIGGER review_cases_transfer_reference
+AFTER INSERT OR UPDATE OF "transfer_id" ON "review_cases"
+FOR EACH ROW
+EXECUTE FUNCTION ledger_reference_check('transfers', 'transfer_id');
+
+CREATE TRIGGER payment_instructions_transfer_reference
+AFTER INSERT OR UPDATE OF "transfer_id" ON "payment_instructions"
+FOR EACH ROW
+EXECUTE FUNCTION ledger_reference_check('transfers', 'transfer_id');
@@ -0,0 +1,2 @@
+-- 補上的結果是 case 的決定，不還原
+DROP INDEX IF EXISTS "payment_instructions_review_case_id_idx";
@@ -0,0 +1,12 @@
+CREATE INDEX ON "payment_instructions" ("review_case_id");
+
+-- 之前審核 case 決定之後沒有更新被 hold 的 instruction，照 case 的結果補上
+UPDATE "payment_instructions" pi
+SET "status" = 'accepted', "transfer_id" = rc."transfer_id"
+FROM "review_cases" rc
+WHERE pi."review_case_id" = rc."id" AND pi."status" = 'pending' AND rc."status" = 'approved';
+
+UPDATE "payment_instructions" pi
+SET "status" = 'rejected', "reason_code" = 'AG01', "reason" = 'rejected in review case ' || rc."id"
+FROM "review_cases" rc
+WHERE pi."review_case_id" = rc."id" AND pi."status" = 'pending' AND rc."status" = 'rejected';
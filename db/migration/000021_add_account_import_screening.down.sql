@@ -0,0 +1,9 @@
+ALTER TABLE "account_import_rows" DROP COLUMN IF EXISTS "screening_matched_name";
+
+ALTER TABLE "account_import_rows" DROP COLUMN IF EXISTS "screening_entry_id";
+
+ALTER TABLE "account_import_rows" DROP COLUMN IF EXISTS "screening_score";
+
+ALTER TABLE "account_import_rows" DROP COLUMN IF EXISTS "screening_normalized_name";
+
+ALTER TABLE "account_import_rows" DROP COLUMN IF EXISTS "screening_outcome";
@@ -0,0 +1,10 @@
+-- 有 WithOwnerScreening 時每一列的篩查結果，合併時和帳戶一起寫進 screening_results；沒有篩查就是 null
+ALTER TABLE "account_import_rows" ADD COLUMN "screening_outcome" varchar;
+
+ALTER TABLE "account_import_rows" ADD COLUMN "screening_normalized_name" varchar;
+
+ALTER TABLE "account_import_rows" ADD COLUMN "screening_score" double precision;
+
+ALTER TABLE "account_import_rows" ADD COLUMN "screening_entry_id" varchar;
+
+ALTER TABLE "account_import_rows" ADD COLUMN "screening_matched_name" varchar;
@@ -0,0 +1 @@
+DROP FUNCTION IF EXISTS anonymize_owner_jsonb(jsonb, text, text);
@@ -0,0 +1,27 @@
+-- AnonymizeOwnerTx 用來清掉 jsonb 裡的 owner：任何一層 "owner" 欄位等於 owner 的值換成 pseudonym，
+-- 字串裡用 %q 印出來的 "owner"（例如 OwnerScreeningRule 的 reason）也一起換掉
+CREATE FUNCTION anonymize_owner_jsonb(doc jsonb, owner text, pseudonym text) RETURNS jsonb
+LANGUAGE plpgsql IMMUTABLE AS $$
+DECLARE
+  result jsonb;
+BEGIN
+  CASE jsonb_typeof(doc)
+  WHEN 'object' THEN
+    SELECT coalesce(jsonb_object_agg(key, CASE
+        WHEN key = 'owner' AND value = to_jsonb(owner) THEN to_jsonb(pseudonym)
+        ELSE anonymize_owner_jsonb(value, owner, pseudonym)
+      END), '{}'::jsonb)
+    INTO result
+    FROM jsonb_each(doc);
+  WHEN 'array' THEN
+    SELECT coalesce(jsonb_agg(anonymize_owner_jsonb(value, owner, pseudonym) ORDER BY ordinality), '[]'::jsonb)
+    INTO result
+    FROM jsonb_array_elements(doc) WITH ORDINALITY;
+  WHEN 'string' THEN
+    result := to_jsonb(replace(doc #>> '{}', '"' || owner || '"', '"' || pseudonym || '"'));
+  ELSE
+    result := doc;
+  END CASE;
+  RETURN result;
+END;
+$$;
@@ -0,0 +1,44 @@
+DROP FUNCTION IF EXISTS create_ledger_partitions(date, date);
+
+CREATE OR REPLACE FUNCTION create_ledger_partition(parent text, month_start date) RETURNS text
+LANGUAGE plpgsql AS $$
+DECLARE
+  partition_name text := format('%s_p%s', parent, to_char(month_start, 'YYYYMM'));
+  lower_bound timestamptz := date_trunc('month', month_start::timestamp) AT TIME ZONE 'UTC';
+  upper_bound timestamptz := (date_trunc('month', month_start::timestamp) + interval '1 month') AT TIME ZONE 'UTC';
+BEGIN
+  IF parent NOT IN ('entries', 'transfers') THEN
+    RAISE EXCEPTION '% is not a ledger table', parent;
+  END IF;
+  IF to_regclass(partition_name) IS NOT NULL THEN
+    RETURN NULL;
+  END IF;
+
+  EXECUTE format('CREATE TABLE %I (LIKE %I INCLUDING DEFAULTS)', partition_name, parent);
+  EXECUTE format(
+    'WITH moved AS (DELETE FROM %I WHERE created_at >= $1 AND created_at < $2 RETURNING *) INSERT INTO %I SELECT * FROM moved',
+    parent || '_default', partition_name
+  ) USING lower_bound, upper_bound;
+  EXECUTE format('ALTER TABLE %I ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)', parent, partition_name, lower_bound, upper_bound);
+  RETURN partition_name;
+END;
+$$;
+
+CREATE FUNCTION create_ledger_partitions(through_month date) RETURNS SETOF text
+LANGUAGE plpgsql AS $$
+DECLARE
+  month_start date := date_trunc('month', now() AT TIME ZONE 'UTC')::date;
+  parent text;
+  partition_name text;
+BEGIN
+  WHILE month_start <= through_month LOOP
+    FOREACH parent IN ARRAY ARRAY['entries', 'transfers'] LOOP
+      partition_name := create_ledger_partition(parent, month_start);
+      IF partition_name IS NOT NULL THEN
+        RETURN NEXT partition_name;
+      END IF;
+    END LOOP;
+    month_start := (month_start + interval '1 month')::date;
+  END LOOP;
+END;
+$$;
@@ -0,0 +1,5 @@
+ALTER TABLE "webhook_delivery_attempts" DROP COLUMN IF EXISTS "replay";
+
+ALTER TABLE "webhook_deliveries" DROP COLUMN IF EXISTS "replayed_attempts";
+
+ALTER TABLE "webhook_deliveries" DROP COLUMN IF EXISTS "replays";
@@ -0,0 +1,11 @@
+-- replay 不再把 attempts 歸零，delivery log 的 attempt 編號一直遞增；
+-- replays 是第幾次 replay，replayed_attempts 是最近一次 replay 時已經用掉的 attempts，重試次數從那裡重新算
+ALTER TABLE "webhook_deliveries" ADD COLUMN "replays" integer NOT NULL DEFAULT 0;
+
+ALTER TABLE "webhook_deliveries" ADD COLUMN "replayed_attempts" integer NOT NULL DEFAULT 0;
+
+ALTER TABLE "webhook_delivery_attempts" ADD COLUMN "replay" integer NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "webhook_deliveries"."replayed_attempts" IS 'attempts made before the last replay';
+
+COMMENT ON COLUMN "webhook_delivery_attempts"."replay" IS 'replays of the delivery when the attempt was made';
@@ -0,0 +1,9 @@
+-- audit_log 是 append-only，個資不能事後改掉，所以寫進去的時候就換成每個人一把 key 的 HMAC；
+-- 刪掉那把 key（crypto-shredding）之後 hash 就對不回原本的值，audit_log 一筆都不用改，hash 鏈照樣驗得過
+CREATE TABLE "audit_subject_keys" (
+  "subject" varchar PRIMARY KEY,
+  "key" bytea NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "audit_subject_keys"."subject" IS 'username / account owner whose personal data is hashed with the key';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "tasks_unique_key_key";
+
+ALTER TABLE "tasks" DROP COLUMN IF EXISTS "unique_key";
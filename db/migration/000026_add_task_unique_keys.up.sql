@@ -0,0 +1,7 @@
+-- 週期性的 task 跑完之後在同一個事務裡排下一次，unique_key 標出是哪一期，同一期只會排一次；
+-- 沒有 unique_key 的 task 不受限制
+ALTER TABLE "tasks" ADD COLUMN "unique_key" varchar;
+
+CREATE UNIQUE INDEX "tasks_unique_key_key" ON "tasks" ("unique_key");
+
+COMMENT ON COLUMN "tasks"."unique_key" IS 'at most one task per key, e.g. the period of a periodic task';
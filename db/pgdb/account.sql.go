@@ -0,0 +1,226 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: account.sql
+
+package pgdb
+
+import (
+	"context"
+)
+
+const accountHasLedgerRows = `-- name: AccountHasLedgerRows :one
+SELECT (
+  EXISTS (SELECT 1 FROM entries WHERE entries.account_id = $1)
+  OR EXISTS (SELECT 1 FROM transfers WHERE transfers.from_account_id = $1 OR transfers.to_account_id = $1)
+  OR EXISTS (SELECT 1 FROM external_transfers WHERE external_transfers.account_id = $1 OR external_transfers.clearing_account_id = $1)
+  OR EXISTS (SELECT 1 FROM balance_adjustments WHERE balance_adjustments.account_id = $1 OR balance_adjustments.suspense_account_id = $1)
+)::boolean AS has_ledger_rows
+`
+
+// 記過帳（entries、轉帳、出入金、調帳）的帳戶不能刪，帳本要對得起來
+func (q *Queries) AccountHasLedgerRows(ctx context.Context, id int64) (bool, error) {
+	row := q.db.QueryRow(ctx, accountHasLedgerRows, id)
+	var has_ledger_rows bool
+	err := row.Scan(&has_ledger_rows)
+	return has_ledger_rows, err
+}
+
+const createAccount = `-- name: CreateAccount :one
+INSERT INTO accounts (
+  owner, balance, currency
+) VALUES (
+  $1, $2, $3
+)
+RETURNING id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number
+`
+
+type CreateAccountParams struct {
+	Owner    string `json:"owner"`
+	Balance  int64  `json:"balance"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	row := q.db.QueryRow(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.ProductID,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.OverdraftFee,
+		&i.ClosedAt,
+		&i.AccountNumber,
+	)
+	return i, err
+}
+
+const deleteAccount = `-- name: DeleteAccount :exec
+DELETE FROM accounts
+WHERE id = $1
+`
+
+func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, deleteAccount, id)
+	return err
+}
+
+const getAccount = `-- name: GetAccount :one
+SELECT id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number FROM accounts
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRow(ctx, getAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.ProductID,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.OverdraftFee,
+		&i.ClosedAt,
+		&i.AccountNumber,
+	)
+	return i, err
+}
+
+const getAccountByNumber = `-- name: GetAccountByNumber :one
+SELECT id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number FROM accounts
+WHERE account_number = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error) {
+	row := q.db.QueryRow(ctx, getAccountByNumber, accountNumber)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.ProductID,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.OverdraftFee,
+		&i.ClosedAt,
+		&i.AccountNumber,
+	)
+	return i, err
+}
+
+const getAccountForUpdate = `-- name: GetAccountForUpdate :one
+SELECT id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number FROM accounts
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRow(ctx, getAccountForUpdate, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.ProductID,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.OverdraftFee,
+		&i.ClosedAt,
+		&i.AccountNumber,
+	)
+	return i, err
+}
+
+const listAccounts = `-- name: ListAccounts :many
+SELECT id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number FROM accounts
+ORDER BY id
+LIMIT $1
+OFFSET $2
+`
+
+type ListAccountsParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listAccounts, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.ProductID,
+			&i.OverdraftLimit,
+			&i.OverdraftRateBps,
+			&i.OverdraftFee,
+			&i.ClosedAt,
+			&i.AccountNumber,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsByNumbers = `-- name: ListAccountsByNumbers :many
+SELECT id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number FROM accounts
+WHERE account_number = ANY($1::varchar[])
+ORDER BY id
+`
+
+func (q *Queries) ListAccountsByNumbers(ctx context.Context, accountNumbers []string) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listAccountsByNumbers, accountNumbers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.ProductID,
+			&i.OverdraftLimit,
+			&i.OverdraftRateBps,
+			&i.OverdraftFee,
+			&i.ClosedAt,
+			&i.AccountNumber,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,247 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: account_import.sql
+
+package pgdb
+
+import (
+	"context"
+)
+
+const allocateImportedAccountIDs = `-- name: AllocateImportedAccountIDs :execrows
+UPDATE account_import_rows
+  set account_id = allocated.account_id,
+  entry_id = allocated.entry_id,
+  clearing_entry_id = allocated.clearing_entry_id
+FROM (
+  SELECT ordered.line,
+    nextval('accounts_id_seq') AS account_id,
+    CASE WHEN ordered.balance > 0 THEN nextval('entries_id_seq') END AS entry_id,
+    CASE WHEN ordered.balance > 0 THEN nextval('entries_id_seq') END AS clearing_entry_id
+  FROM (
+    SELECT line, balance FROM account_import_rows
+    WHERE import_id = $1
+    ORDER BY line
+  ) AS ordered
+) AS allocated
+WHERE account_import_rows.import_id = $1 AND account_import_rows.line = allocated.line
+`
+
+// 先從 sequence 拿好 id，依檔案的行號順序，後面的 INSERT 才能把帳戶、entries 和暫存列對起來
+func (q *Queries) AllocateImportedAccountIDs(ctx context.Context, importID int64) (int64, error) {
+	result, err := q.db.Exec(ctx, allocateImportedAccountIDs, importID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const applyImportedBalances = `-- name: ApplyImportedBalances :execrows
+UPDATE accounts
+  set balance = accounts.balance + deltas.amount
+FROM (
+  SELECT account_import_rows.account_id, account_import_rows.balance AS amount FROM account_import_rows
+  WHERE account_import_rows.import_id = $1 AND account_import_rows.balance > 0
+  UNION ALL
+  SELECT system_accounts.account_id, -sum(account_import_rows.balance)::bigint
+  FROM account_import_rows
+  JOIN system_accounts ON system_accounts.purpose = 'clearing' AND system_accounts.currency = account_import_rows.currency
+  WHERE account_import_rows.import_id = $1 AND account_import_rows.balance > 0
+  GROUP BY system_accounts.account_id
+) AS deltas
+WHERE accounts.id = deltas.account_id
+`
+
+// 要先 enableLedgerWrites；新帳戶加上期初餘額，clearing 帳戶扣掉同幣別的總額
+func (q *Queries) ApplyImportedBalances(ctx context.Context, importID int64) (int64, error) {
+	result, err := q.db.Exec(ctx, applyImportedBalances, importID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createAccountImport = `-- name: CreateAccountImport :one
+INSERT INTO account_imports (
+  source
+) VALUES (
+  $1
+)
+RETURNING id, source, accepted, created_at
+`
+
+func (q *Queries) CreateAccountImport(ctx context.Context, source string) (AccountImport, error) {
+	row := q.db.QueryRow(ctx, createAccountImport, source)
+	var i AccountImport
+	err := row.Scan(
+		&i.ID,
+		&i.Source,
+		&i.Accepted,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteAccountImportRows = `-- name: DeleteAccountImportRows :exec
+DELETE FROM account_import_rows
+WHERE import_id = $1
+`
+
+func (q *Queries) DeleteAccountImportRows(ctx context.Context, importID int64) error {
+	_, err := q.db.Exec(ctx, deleteAccountImportRows, importID)
+	return err
+}
+
+const getAccountImport = `-- name: GetAccountImport :one
+SELECT id, source, accepted, created_at FROM account_imports
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccountImport(ctx context.Context, id int64) (AccountImport, error) {
+	row := q.db.QueryRow(ctx, getAccountImport, id)
+	var i AccountImport
+	err := row.Scan(
+		&i.ID,
+		&i.Source,
+		&i.Accepted,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const insertImportedAccounts = `-- name: InsertImportedAccounts :execrows
+INSERT INTO accounts (id, owner, balance, currency)
+SELECT account_id, owner, 0, currency FROM account_import_rows
+WHERE import_id = $1
+ORDER BY line
+`
+
+// 和 Store.CreateAccount 一樣先用 0 開戶，期初餘額另外記帳
+func (q *Queries) InsertImportedAccounts(ctx context.Context, importID int64) (int64, error) {
+	result, err := q.db.Exec(ctx, insertImportedAccounts, importID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const insertImportedEntries = `-- name: InsertImportedEntries :execrows
+INSERT INTO entries (id, account_id, amount)
+SELECT account_import_rows.entry_id, account_import_rows.account_id, account_import_rows.balance
+FROM account_import_rows
+WHERE account_import_rows.import_id = $1 AND account_import_rows.balance > 0
+UNION ALL
+SELECT account_import_rows.clearing_entry_id, system_accounts.account_id, -account_import_rows.balance
+FROM account_import_rows
+JOIN system_accounts ON system_accounts.purpose = 'clearing' AND system_accounts.currency = account_import_rows.currency
+WHERE account_import_rows.import_id = $1 AND account_import_rows.balance > 0
+`
+
+func (q *Queries) InsertImportedEntries(ctx context.Context, importID int64) (int64, error) {
+	result, err := q.db.Exec(ctx, insertImportedEntries, importID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const insertImportedExternalTransfers = `-- name: InsertImportedExternalTransfers :execrows
+INSERT INTO external_transfers (
+  account_id, clearing_account_id, direction, amount, external_ref, status, entry_id, clearing_entry_id
+)
+SELECT account_import_rows.account_id, system_accounts.account_id, 'deposit', account_import_rows.balance,
+  'opening:' || account_import_rows.account_id, 'settled', account_import_rows.entry_id, account_import_rows.clearing_entry_id
+FROM account_import_rows
+JOIN system_accounts ON system_accounts.purpose = 'clearing' AND system_accounts.currency = account_import_rows.currency
+WHERE account_import_rows.import_id = $1 AND account_import_rows.balance > 0
+ORDER BY account_import_rows.line
+`
+
+// 期初餘額和 Store.CreateAccount 一樣記成已入帳的 opening:<id> 入金
+func (q *Queries) InsertImportedExternalTransfers(ctx context.Context, importID int64) (int64, error) {
+	result, err := q.db.Exec(ctx, insertImportedExternalTransfers, importID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const insertImportedScreeningResults = `-- name: InsertImportedScreeningResults :execrows
+INSERT INTO screening_results (
+  account_id, name, normalized_name, outcome, score, entry_id, matched_name
+)
+SELECT account_id, owner, screening_normalized_name, screening_outcome, screening_score, screening_entry_id, screening_matched_name
+FROM account_import_rows
+WHERE import_id = $1 AND screening_outcome IS NOT NULL
+ORDER BY line
+`
+
+// 和 Store.CreateAccount 一樣，開了戶的 owner 篩查結果掛在帳戶上
+func (q *Queries) InsertImportedScreeningResults(ctx context.Context, importID int64) (int64, error) {
+	result, err := q.db.Exec(ctx, insertImportedScreeningResults, importID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listAccountImportCurrencies = `-- name: ListAccountImportCurrencies :many
+SELECT DISTINCT currency FROM account_import_rows
+WHERE import_id = $1
+ORDER BY currency
+`
+
+func (q *Queries) ListAccountImportCurrencies(ctx context.Context, importID int64) ([]string, error) {
+	rows, err := q.db.Query(ctx, listAccountImportCurrencies, importID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var currency string
+		if err := rows.Scan(&currency); err != nil {
+			return nil, err
+		}
+		items = append(items, currency)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockAccountImports = `-- name: LockAccountImports :exec
+SELECT pg_advisory_xact_lock(hashtext('account_imports'))
+`
+
+// 一次只跑一個匯入，多個 clearing 帳戶的餘額一起更新時才不會和另一個匯入互鎖
+func (q *Queries) LockAccountImports(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, lockAccountImports)
+	return err
+}
+
+const setAccountImportAccepted = `-- name: SetAccountImportAccepted :one
+UPDATE account_imports
+  set accepted = $2
+WHERE id = $1
+RETURNING id, source, accepted, created_at
+`
+
+type SetAccountImportAcceptedParams struct {
+	ID       int64 `json:"id"`
+	Accepted int64 `json:"accepted"`
+}
+
+func (q *Queries) SetAccountImportAccepted(ctx context.Context, arg SetAccountImportAcceptedParams) (AccountImport, error) {
+	row := q.db.QueryRow(ctx, setAccountImportAccepted, arg.ID, arg.Accepted)
+	var i AccountImport
+	err := row.Scan(
+		&i.ID,
+		&i.Source,
+		&i.Accepted,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,59 @@
+package pgdb
+
+import (
+	"database/sql"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// 事件 payload、audit log 和限額檢查沿用 db 套件的型別，JSON 才和 db.Store 寫的一模一樣
+
+func toDBAccount(account Account) db.Account {
+	return db.Account{
+		ID:               account.ID,
+		Owner:            account.Owner,
+		Balance:          account.Balance,
+		Currency:         account.Currency,
+		CreatedAt:        account.CreatedAt,
+		ProductID:        nullInt64(account.ProductID),
+		OverdraftLimit:   account.OverdraftLimit,
+		OverdraftRateBps: account.OverdraftRateBps,
+		OverdraftFee:     account.OverdraftFee,
+		ClosedAt:         sql.NullTime{Time: account.ClosedAt.Time, Valid: account.ClosedAt.Valid},
+		AccountNumber:    account.AccountNumber,
+	}
+}
+
+func toDBTransfer(transfer Transfer) db.Transfer {
+	return db.Transfer(transfer)
+}
+
+func toDBEntry(entry Entry) db.Entry {
+	return db.Entry{
+		ID:         entry.ID,
+		AccountID:  entry.AccountID,
+		Amount:     entry.Amount,
+		CreatedAt:  entry.CreatedAt,
+		TransferID: nullInt64(entry.TransferID),
+	}
+}
+
+func toDBTransferLimit(limit TransferLimit) db.TransferLimit {
+	return db.TransferLimit{
+		ID:             limit.ID,
+		AccountID:      nullInt64(limit.AccountID),
+		Owner:          sql.NullString{String: limit.Owner.String, Valid: limit.Owner.Valid},
+		Currency:       sql.NullString{String: limit.Currency.String, Valid: limit.Currency.Valid},
+		MaxSingle:      nullInt64(limit.MaxSingle),
+		MaxDaily:       nullInt64(limit.MaxDaily),
+		MaxMonthly:     nullInt64(limit.MaxMonthly),
+		MaxHourlyCount: sql.NullInt32{Int32: limit.MaxHourlyCount.Int32, Valid: limit.MaxHourlyCount.Valid},
+		CreatedAt:      limit.CreatedAt,
+		UpdatedAt:      limit.UpdatedAt,
+	}
+}
+
+func nullInt64(value pgtype.Int8) sql.NullInt64 {
+	return sql.NullInt64{Int64: value.Int64, Valid: value.Valid}
+}
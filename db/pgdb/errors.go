@@ -0,0 +1,62 @@
+package pgdb
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATE codes the store and its callers handle, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	UniqueViolation      = "23505"
+	ForeignKeyViolation  = "23503"
+	CheckViolation       = "23514"
+	NotNullViolation     = "23502"
+	SerializationFailure = "40001"
+	DeadlockDetected     = "40P01"
+	LockNotAvailable     = "55P03"
+	RaiseException       = "P0001"
+)
+
+// ErrRecordNotFound is returned by the :one queries when no row matches
+var ErrRecordNotFound = pgx.ErrNoRows
+
+// PgError returns the *pgconn.PgError wrapped in err, or nil when err does not come from the server
+func PgError(err error) *pgconn.PgError {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr
+	}
+	return nil
+}
+
+// ErrorCode returns the SQLSTATE of err, or an empty string when err does not come from the server
+func ErrorCode(err error) string {
+	if pgErr := PgError(err); pgErr != nil {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// IsUniqueViolation reports whether err is a unique_violation
+func IsUniqueViolation(err error) bool {
+	return ErrorCode(err) == UniqueViolation
+}
+
+// IsForeignKeyViolation reports whether err is a foreign_key_violation
+func IsForeignKeyViolation(err error) bool {
+	return ErrorCode(err) == ForeignKeyViolation
+}
+
+// IsCheckViolation reports whether err is a check_violation
+func IsCheckViolation(err error) bool {
+	return ErrorCode(err) == CheckViolation
+}
+
+// IsRetryable reports whether the transaction that failed with err can be retried as a whole:
+// a serialization failure or a deadlock
+func IsRetryable(err error) bool {
+	code := ErrorCode(err)
+	return code == SerializationFailure || code == DeadlockDetected
+}
@@ -0,0 +1,29 @@
+package pgdb
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCode(t *testing.T) {
+	unique := fmt.Errorf("create account: %w", &pgconn.PgError{Code: UniqueViolation, ConstraintName: "accounts_account_number_idx"})
+	require.Equal(t, UniqueViolation, ErrorCode(unique))
+	require.True(t, IsUniqueViolation(unique))
+	require.False(t, IsForeignKeyViolation(unique))
+	require.False(t, IsRetryable(unique))
+	require.Equal(t, "accounts_account_number_idx", PgError(unique).ConstraintName)
+
+	require.True(t, IsForeignKeyViolation(&pgconn.PgError{Code: ForeignKeyViolation}))
+	require.True(t, IsCheckViolation(&pgconn.PgError{Code: CheckViolation}))
+	require.True(t, IsRetryable(&pgconn.PgError{Code: SerializationFailure}))
+	require.True(t, IsRetryable(&pgconn.PgError{Code: DeadlockDetected}))
+
+	// 不是伺服器回的錯誤就沒有 SQLSTATE
+	require.Nil(t, PgError(errors.New("connection reset")))
+	require.Empty(t, ErrorCode(ErrRecordNotFound))
+	require.Empty(t, ErrorCode(nil))
+}
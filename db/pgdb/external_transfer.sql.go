@@ -0,0 +1,307 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: external_transfer.sql
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createExternalTransfer = `-- name: CreateExternalTransfer :one
+INSERT INTO external_transfers (
+  account_id, clearing_account_id, direction, amount, external_ref
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (direction, external_ref) DO NOTHING
+RETURNING id, account_id, clearing_account_id, direction, amount, external_ref, status, failure_reason, entry_id, clearing_entry_id, reversal_entry_id, clearing_reversal_entry_id, created_at, updated_at
+`
+
+type CreateExternalTransferParams struct {
+	AccountID         int64  `json:"account_id"`
+	ClearingAccountID int64  `json:"clearing_account_id"`
+	Direction         string `json:"direction"`
+	Amount            int64  `json:"amount"`
+	ExternalRef       string `json:"external_ref"`
+}
+
+// 同一個 direction + external_ref 已經存在時不插入也不回傳，呼叫端再用 GetExternalTransferByRef 拿已經存在的那筆
+func (q *Queries) CreateExternalTransfer(ctx context.Context, arg CreateExternalTransferParams) (ExternalTransfer, error) {
+	row := q.db.QueryRow(ctx, createExternalTransfer,
+		arg.AccountID,
+		arg.ClearingAccountID,
+		arg.Direction,
+		arg.Amount,
+		arg.ExternalRef,
+	)
+	var i ExternalTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ClearingAccountID,
+		&i.Direction,
+		&i.Amount,
+		&i.ExternalRef,
+		&i.Status,
+		&i.FailureReason,
+		&i.EntryID,
+		&i.ClearingEntryID,
+		&i.ReversalEntryID,
+		&i.ClearingReversalEntryID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const failExternalTransfer = `-- name: FailExternalTransfer :one
+UPDATE external_transfers
+  set status = 'failed',
+  failure_reason = $2,
+  reversal_entry_id = $3,
+  clearing_reversal_entry_id = $4,
+  updated_at = now()
+WHERE id = $1
+RETURNING id, account_id, clearing_account_id, direction, amount, external_ref, status, failure_reason, entry_id, clearing_entry_id, reversal_entry_id, clearing_reversal_entry_id, created_at, updated_at
+`
+
+type FailExternalTransferParams struct {
+	ID                      int64       `json:"id"`
+	FailureReason           string      `json:"failure_reason"`
+	ReversalEntryID         pgtype.Int8 `json:"reversal_entry_id"`
+	ClearingReversalEntryID pgtype.Int8 `json:"clearing_reversal_entry_id"`
+}
+
+func (q *Queries) FailExternalTransfer(ctx context.Context, arg FailExternalTransferParams) (ExternalTransfer, error) {
+	row := q.db.QueryRow(ctx, failExternalTransfer,
+		arg.ID,
+		arg.FailureReason,
+		arg.ReversalEntryID,
+		arg.ClearingReversalEntryID,
+	)
+	var i ExternalTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ClearingAccountID,
+		&i.Direction,
+		&i.Amount,
+		&i.ExternalRef,
+		&i.Status,
+		&i.FailureReason,
+		&i.EntryID,
+		&i.ClearingEntryID,
+		&i.ReversalEntryID,
+		&i.ClearingReversalEntryID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getExternalTransfer = `-- name: GetExternalTransfer :one
+SELECT id, account_id, clearing_account_id, direction, amount, external_ref, status, failure_reason, entry_id, clearing_entry_id, reversal_entry_id, clearing_reversal_entry_id, created_at, updated_at FROM external_transfers
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetExternalTransfer(ctx context.Context, id int64) (ExternalTransfer, error) {
+	row := q.db.QueryRow(ctx, getExternalTransfer, id)
+	var i ExternalTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ClearingAccountID,
+		&i.Direction,
+		&i.Amount,
+		&i.ExternalRef,
+		&i.Status,
+		&i.FailureReason,
+		&i.EntryID,
+		&i.ClearingEntryID,
+		&i.ReversalEntryID,
+		&i.ClearingReversalEntryID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getExternalTransferByRef = `-- name: GetExternalTransferByRef :one
+SELECT id, account_id, clearing_account_id, direction, amount, external_ref, status, failure_reason, entry_id, clearing_entry_id, reversal_entry_id, clearing_reversal_entry_id, created_at, updated_at FROM external_transfers
+WHERE direction = $1 AND external_ref = $2 LIMIT 1
+`
+
+type GetExternalTransferByRefParams struct {
+	Direction   string `json:"direction"`
+	ExternalRef string `json:"external_ref"`
+}
+
+func (q *Queries) GetExternalTransferByRef(ctx context.Context, arg GetExternalTransferByRefParams) (ExternalTransfer, error) {
+	row := q.db.QueryRow(ctx, getExternalTransferByRef, arg.Direction, arg.ExternalRef)
+	var i ExternalTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ClearingAccountID,
+		&i.Direction,
+		&i.Amount,
+		&i.ExternalRef,
+		&i.Status,
+		&i.FailureReason,
+		&i.EntryID,
+		&i.ClearingEntryID,
+		&i.ReversalEntryID,
+		&i.ClearingReversalEntryID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getExternalTransferForUpdate = `-- name: GetExternalTransferForUpdate :one
+SELECT id, account_id, clearing_account_id, direction, amount, external_ref, status, failure_reason, entry_id, clearing_entry_id, reversal_entry_id, clearing_reversal_entry_id, created_at, updated_at FROM external_transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetExternalTransferForUpdate(ctx context.Context, id int64) (ExternalTransfer, error) {
+	row := q.db.QueryRow(ctx, getExternalTransferForUpdate, id)
+	var i ExternalTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ClearingAccountID,
+		&i.Direction,
+		&i.Amount,
+		&i.ExternalRef,
+		&i.Status,
+		&i.FailureReason,
+		&i.EntryID,
+		&i.ClearingEntryID,
+		&i.ReversalEntryID,
+		&i.ClearingReversalEntryID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listExternalTransfers = `-- name: ListExternalTransfers :many
+SELECT id, account_id, clearing_account_id, direction, amount, external_ref, status, failure_reason, entry_id, clearing_entry_id, reversal_entry_id, clearing_reversal_entry_id, created_at, updated_at FROM external_transfers
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListExternalTransfersParams struct {
+	AccountID int64 `json:"account_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+func (q *Queries) ListExternalTransfers(ctx context.Context, arg ListExternalTransfersParams) ([]ExternalTransfer, error) {
+	rows, err := q.db.Query(ctx, listExternalTransfers, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ExternalTransfer{}
+	for rows.Next() {
+		var i ExternalTransfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.ClearingAccountID,
+			&i.Direction,
+			&i.Amount,
+			&i.ExternalRef,
+			&i.Status,
+			&i.FailureReason,
+			&i.EntryID,
+			&i.ClearingEntryID,
+			&i.ReversalEntryID,
+			&i.ClearingReversalEntryID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setExternalTransferEntries = `-- name: SetExternalTransferEntries :one
+UPDATE external_transfers
+  set entry_id = $2,
+  clearing_entry_id = $3,
+  updated_at = now()
+WHERE id = $1
+RETURNING id, account_id, clearing_account_id, direction, amount, external_ref, status, failure_reason, entry_id, clearing_entry_id, reversal_entry_id, clearing_reversal_entry_id, created_at, updated_at
+`
+
+type SetExternalTransferEntriesParams struct {
+	ID              int64       `json:"id"`
+	EntryID         pgtype.Int8 `json:"entry_id"`
+	ClearingEntryID pgtype.Int8 `json:"clearing_entry_id"`
+}
+
+func (q *Queries) SetExternalTransferEntries(ctx context.Context, arg SetExternalTransferEntriesParams) (ExternalTransfer, error) {
+	row := q.db.QueryRow(ctx, setExternalTransferEntries, arg.ID, arg.EntryID, arg.ClearingEntryID)
+	var i ExternalTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ClearingAccountID,
+		&i.Direction,
+		&i.Amount,
+		&i.ExternalRef,
+		&i.Status,
+		&i.FailureReason,
+		&i.EntryID,
+		&i.ClearingEntryID,
+		&i.ReversalEntryID,
+		&i.ClearingReversalEntryID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const settleExternalTransfer = `-- name: SettleExternalTransfer :one
+UPDATE external_transfers
+  set status = 'settled',
+  updated_at = now()
+WHERE id = $1
+RETURNING id, account_id, clearing_account_id, direction, amount, external_ref, status, failure_reason, entry_id, clearing_entry_id, reversal_entry_id, clearing_reversal_entry_id, created_at, updated_at
+`
+
+func (q *Queries) SettleExternalTransfer(ctx context.Context, id int64) (ExternalTransfer, error) {
+	row := q.db.QueryRow(ctx, settleExternalTransfer, id)
+	var i ExternalTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ClearingAccountID,
+		&i.Direction,
+		&i.Amount,
+		&i.ExternalRef,
+		&i.Status,
+		&i.FailureReason,
+		&i.EntryID,
+		&i.ClearingEntryID,
+		&i.ReversalEntryID,
+		&i.ClearingReversalEntryID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,375 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+
+package pgdb
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Account struct {
+	ID        int64       `json:"id"`
+	Owner     string      `json:"owner"`
+	Balance   int64       `json:"balance"`
+	Currency  string      `json:"currency"`
+	CreatedAt time.Time   `json:"created_at"`
+	ProductID pgtype.Int8 `json:"product_id"`
+	// how far below 0 customer debits may take the balance
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	// yearly interest on the overdrawn end of day balance, ACT/365
+	OverdraftRateBps int32 `json:"overdraft_rate_bps"`
+	// charged once for every period with an overdrawn day
+	OverdraftFee int64 `json:"overdraft_fee"`
+	// closed accounts take no more transfers, their owner can be anonymized
+	ClosedAt pgtype.Timestamptz `json:"closed_at"`
+	// public IBAN-style account number, stored without spaces
+	AccountNumber string `json:"account_number"`
+}
+
+type AccountImport struct {
+	ID        int64     `json:"id"`
+	Source    string    `json:"source"`
+	Accepted  int64     `json:"accepted"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AccountImportRow struct {
+	ImportID int64 `json:"import_id"`
+	// line of the row in the source file, for the reject file
+	Line     int64  `json:"line"`
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+	Balance  int64  `json:"balance"`
+	// taken from accounts_id_seq before the accounts are inserted
+	AccountID               pgtype.Int8   `json:"account_id"`
+	EntryID                 pgtype.Int8   `json:"entry_id"`
+	ClearingEntryID         pgtype.Int8   `json:"clearing_entry_id"`
+	ScreeningOutcome        pgtype.Text   `json:"screening_outcome"`
+	ScreeningNormalizedName pgtype.Text   `json:"screening_normalized_name"`
+	ScreeningScore          pgtype.Float8 `json:"screening_score"`
+	ScreeningEntryID        pgtype.Text   `json:"screening_entry_id"`
+	ScreeningMatchedName    pgtype.Text   `json:"screening_matched_name"`
+}
+
+type AccountProduct struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// 125 is 1.25% a year
+	AnnualRateBps int32 `json:"annual_rate_bps"`
+	// ACT/365 or 30/360
+	DayCount string `json:"day_count"`
+	// monthly, quarterly or annually
+	PostingFrequency string    `json:"posting_frequency"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type ArchivedEntryTotal struct {
+	PartitionName string `json:"partition_name"`
+	AccountID     int64  `json:"account_id"`
+	// sum of the entries of the account in the detached partition
+	Amount int64 `json:"amount"`
+	// upper bound of the detached partition, every entry of the partition was created before it
+	ArchivedBefore time.Time `json:"archived_before"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type AuditLog struct {
+	ID         int64  `json:"id"`
+	Actor      string `json:"actor"`
+	Action     string `json:"action"`
+	EntityType string `json:"entity_type"`
+	EntityID   string `json:"entity_id"`
+	// null for creates
+	Before json.RawMessage `json:"before"`
+	// null for deletes
+	After     json.RawMessage `json:"after"`
+	RequestID string          `json:"request_id"`
+	// hash of the previous row, empty for the first row
+	PrevHash string `json:"prev_hash"`
+	// hex SHA-256 of prev_hash and the other columns except id
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AuditSubjectKey struct {
+	// username / account owner whose personal data is hashed with the key
+	Subject   string    `json:"subject"`
+	Key       []byte    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BalanceAdjustment struct {
+	ID                int64 `json:"id"`
+	AccountID         int64 `json:"account_id"`
+	SuspenseAccountID int64 `json:"suspense_account_id"`
+	// added to the account balance, the suspense account gets the opposite
+	Amount          int64     `json:"amount"`
+	ReasonCode      string    `json:"reason_code"`
+	Operator        string    `json:"operator"`
+	Note            string    `json:"note"`
+	EntryID         int64     `json:"entry_id"`
+	SuspenseEntryID int64     `json:"suspense_entry_id"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type Entry struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	// can be negative or positive
+	Amount    int64     `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+	// the transfer the entry was posted for, null for entries of external transfers and adjustments
+	TransferID pgtype.Int8 `json:"transfer_id"`
+}
+
+type ExternalTransfer struct {
+	ID                int64 `json:"id"`
+	AccountID         int64 `json:"account_id"`
+	ClearingAccountID int64 `json:"clearing_account_id"`
+	// deposit or withdrawal
+	Direction string `json:"direction"`
+	// must be positive
+	Amount int64 `json:"amount"`
+	// reference of the payment rail, opening:<account id> for opening balances
+	ExternalRef string `json:"external_ref"`
+	// pending, settled or failed
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason"`
+	// deposits are booked when settled, withdrawals when created
+	EntryID         pgtype.Int8 `json:"entry_id"`
+	ClearingEntryID pgtype.Int8 `json:"clearing_entry_id"`
+	// set when a booked withdrawal fails
+	ReversalEntryID         pgtype.Int8 `json:"reversal_entry_id"`
+	ClearingReversalEntryID pgtype.Int8 `json:"clearing_reversal_entry_id"`
+	CreatedAt               time.Time   `json:"created_at"`
+	UpdatedAt               time.Time   `json:"updated_at"`
+}
+
+type InterestAccrual struct {
+	ID          int64     `json:"id"`
+	AccountID   int64     `json:"account_id"`
+	ProductID   int64     `json:"product_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+	// end of day balance from entries
+	Balance       int64  `json:"balance"`
+	AnnualRateBps int32  `json:"annual_rate_bps"`
+	DayCount      string `json:"day_count"`
+	// days counted for the accrual date by the day count convention
+	Days int32 `json:"days"`
+	// interest in 1/(10000*26280) of the minor unit, exact for both day counts
+	Units     int64       `json:"units"`
+	PostingID pgtype.Int8 `json:"posting_id"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type InterestPosting struct {
+	ID        int64     `json:"id"`
+	AccountID int64     `json:"account_id"`
+	PeriodEnd time.Time `json:"period_end"`
+	// carry of the previous posting plus the accruals of the period
+	Units  int64 `json:"units"`
+	Amount int64 `json:"amount"`
+	// units below one minor unit, carried to the next posting
+	CarryUnits int64 `json:"carry_units"`
+	// null when the amount is 0
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+type Outbox struct {
+	ID            int64           `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+	// null until the relay has handed the event to a publisher
+	DeliveredAt pgtype.Timestamptz `json:"delivered_at"`
+}
+
+type OverdraftCharge struct {
+	ID            int64     `json:"id"`
+	AccountID     int64     `json:"account_id"`
+	PeriodStart   time.Time `json:"period_start"`
+	PeriodEnd     time.Time `json:"period_end"`
+	OverdrawnDays int32     `json:"overdrawn_days"`
+	RateBps       int32     `json:"rate_bps"`
+	// carry of the previous charge plus the interest of the period, in 1/(10000*26280) of the minor unit
+	Units int64 `json:"units"`
+	Fee   int64 `json:"fee"`
+	// whole minor units of units plus fee
+	Amount int64 `json:"amount"`
+	// units below one minor unit, carried to the next charge
+	CarryUnits int64 `json:"carry_units"`
+	// null when the amount is 0
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+type PaymentInstruction struct {
+	ID            int64  `json:"id"`
+	MessageID     string `json:"message_id"`
+	PaymentInfoID string `json:"payment_info_id"`
+	InstructionID string `json:"instruction_id"`
+	// set by the debtor, a second instruction with the same id from the same account is a replay
+	EndToEndID    string `json:"end_to_end_id"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	// accepted, rejected or pending
+	Status string `json:"status"`
+	// ISO 20022 ExternalStatusReason1Code of a rejected instruction
+	ReasonCode   string      `json:"reason_code"`
+	Reason       string      `json:"reason"`
+	TransferID   pgtype.Int8 `json:"transfer_id"`
+	ReviewCaseID pgtype.Int8 `json:"review_case_id"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+type ReviewCase struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	Amount        int64 `json:"amount"`
+	// the rules that flagged the transfer and why
+	Hits   json.RawMessage `json:"hits"`
+	Status string          `json:"status"`
+	// set when the case is approved and the transfer is made
+	TransferID   pgtype.Int8        `json:"transfer_id"`
+	Reviewer     string             `json:"reviewer"`
+	DecisionNote string             `json:"decision_note"`
+	DecidedAt    pgtype.Timestamptz `json:"decided_at"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+type ScreeningResult struct {
+	ID             int64       `json:"id"`
+	AccountID      pgtype.Int8 `json:"account_id"`
+	Name           string      `json:"name"`
+	NormalizedName string      `json:"normalized_name"`
+	Outcome        string      `json:"outcome"`
+	// similarity of the best match, 0 to 1
+	Score float64 `json:"score"`
+	// list entry of the best match, empty when nothing matched
+	EntryID     string    `json:"entry_id"`
+	MatchedName string    `json:"matched_name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type SystemAccount struct {
+	Purpose   string    `json:"purpose"`
+	Currency  string    `json:"currency"`
+	AccountID int64     `json:"account_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Task struct {
+	ID      int64           `json:"id"`
+	Queue   string          `json:"queue"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+	// higher runs first
+	Priority    int32     `json:"priority"`
+	Status      string    `json:"status"`
+	Attempts    int32     `json:"attempts"`
+	MaxAttempts int32     `json:"max_attempts"`
+	RunAt       time.Time `json:"run_at"`
+	// a running task whose lock expired is handed out again
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	LastError   pgtype.Text        `json:"last_error"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	// at most one task per key, e.g. the period of a periodic task
+	UniqueKey pgtype.Text `json:"unique_key"`
+}
+
+type Transfer struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	// must be positive
+	Amount    int64     `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TransferLimit struct {
+	ID        int64       `json:"id"`
+	AccountID pgtype.Int8 `json:"account_id"`
+	Owner     pgtype.Text `json:"owner"`
+	Currency  pgtype.Text `json:"currency"`
+	MaxSingle pgtype.Int8 `json:"max_single"`
+	// outgoing total of the UTC calendar day
+	MaxDaily pgtype.Int8 `json:"max_daily"`
+	// outgoing total of the UTC calendar month
+	MaxMonthly pgtype.Int8 `json:"max_monthly"`
+	// outgoing transfers in the last 60 minutes
+	MaxHourlyCount pgtype.Int4 `json:"max_hourly_count"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+type User struct {
+	// matches accounts.owner
+	Username        string    `json:"username"`
+	FullName        string    `json:"full_name"`
+	Email           string    `json:"email"`
+	IsEmailVerified bool      `json:"is_email_verified"`
+	CreatedAt       time.Time `json:"created_at"`
+	// set when the personal data was replaced with a pseudonym
+	AnonymizedAt pgtype.Timestamptz `json:"anonymized_at"`
+}
+
+type VerifyEmail struct {
+	ID         int64     `json:"id"`
+	Username   string    `json:"username"`
+	Email      string    `json:"email"`
+	SecretCode string    `json:"secret_code"`
+	IsUsed     bool      `json:"is_used"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiredAt  time.Time `json:"expired_at"`
+}
+
+type WebhookDelivery struct {
+	ID         int64           `json:"id"`
+	EndpointID int64           `json:"endpoint_id"`
+	EventID    string          `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	Payload    json.RawMessage `json:"payload"`
+	// pending, succeeded or dead
+	Status        string      `json:"status"`
+	Attempts      int32       `json:"attempts"`
+	NextAttemptAt time.Time   `json:"next_attempt_at"`
+	LastError     pgtype.Text `json:"last_error"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+	Replays       int32       `json:"replays"`
+	// attempts made before the last replay
+	ReplayedAttempts int32 `json:"replayed_attempts"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID         int64       `json:"id"`
+	DeliveryID int64       `json:"delivery_id"`
+	Attempt    int32       `json:"attempt"`
+	StatusCode pgtype.Int4 `json:"status_code"`
+	Error      pgtype.Text `json:"error"`
+	DurationMs int64       `json:"duration_ms"`
+	CreatedAt  time.Time   `json:"created_at"`
+	// replays of the delivery when the attempt was made
+	Replay int32 `json:"replay"`
+}
+
+type WebhookEndpoint struct {
+	ID     int64  `json:"id"`
+	Owner  string `json:"owner"`
+	Url    string `json:"url"`
+	Secret string `json:"secret"`
+	// empty means every event type
+	EventTypes []string  `json:"event_types"`
+	IsActive   bool      `json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,221 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: payment_instruction.sql
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createPaymentInstruction = `-- name: CreatePaymentInstruction :one
+INSERT INTO payment_instructions (
+  message_id, payment_info_id, instruction_id, end_to_end_id, from_account_id, to_account_id, amount,
+  status, reason_code, reason, transfer_id, review_case_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+ON CONFLICT (from_account_id, end_to_end_id) DO NOTHING
+RETURNING id, message_id, payment_info_id, instruction_id, end_to_end_id, from_account_id, to_account_id, amount, status, reason_code, reason, transfer_id, review_case_id, created_at
+`
+
+type CreatePaymentInstructionParams struct {
+	MessageID     string      `json:"message_id"`
+	PaymentInfoID string      `json:"payment_info_id"`
+	InstructionID string      `json:"instruction_id"`
+	EndToEndID    string      `json:"end_to_end_id"`
+	FromAccountID int64       `json:"from_account_id"`
+	ToAccountID   int64       `json:"to_account_id"`
+	Amount        int64       `json:"amount"`
+	Status        string      `json:"status"`
+	ReasonCode    string      `json:"reason_code"`
+	Reason        string      `json:"reason"`
+	TransferID    pgtype.Int8 `json:"transfer_id"`
+	ReviewCaseID  pgtype.Int8 `json:"review_case_id"`
+}
+
+// 同一個帳戶的 end_to_end_id 已經存在時不插入也不回傳，呼叫端再用 GetPaymentInstructionByEndToEndID 拿已經存在的那筆
+func (q *Queries) CreatePaymentInstruction(ctx context.Context, arg CreatePaymentInstructionParams) (PaymentInstruction, error) {
+	row := q.db.QueryRow(ctx, createPaymentInstruction,
+		arg.MessageID,
+		arg.PaymentInfoID,
+		arg.InstructionID,
+		arg.EndToEndID,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Status,
+		arg.ReasonCode,
+		arg.Reason,
+		arg.TransferID,
+		arg.ReviewCaseID,
+	)
+	var i PaymentInstruction
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.PaymentInfoID,
+		&i.InstructionID,
+		&i.EndToEndID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Status,
+		&i.ReasonCode,
+		&i.Reason,
+		&i.TransferID,
+		&i.ReviewCaseID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const decidePaymentInstruction = `-- name: DecidePaymentInstruction :one
+UPDATE payment_instructions
+  set status = $2,
+  reason_code = $3,
+  reason = $4,
+  transfer_id = $5
+WHERE id = $1
+RETURNING id, message_id, payment_info_id, instruction_id, end_to_end_id, from_account_id, to_account_id, amount, status, reason_code, reason, transfer_id, review_case_id, created_at
+`
+
+type DecidePaymentInstructionParams struct {
+	ID         int64       `json:"id"`
+	Status     string      `json:"status"`
+	ReasonCode string      `json:"reason_code"`
+	Reason     string      `json:"reason"`
+	TransferID pgtype.Int8 `json:"transfer_id"`
+}
+
+func (q *Queries) DecidePaymentInstruction(ctx context.Context, arg DecidePaymentInstructionParams) (PaymentInstruction, error) {
+	row := q.db.QueryRow(ctx, decidePaymentInstruction,
+		arg.ID,
+		arg.Status,
+		arg.ReasonCode,
+		arg.Reason,
+		arg.TransferID,
+	)
+	var i PaymentInstruction
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.PaymentInfoID,
+		&i.InstructionID,
+		&i.EndToEndID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Status,
+		&i.ReasonCode,
+		&i.Reason,
+		&i.TransferID,
+		&i.ReviewCaseID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPaymentInstructionByEndToEndID = `-- name: GetPaymentInstructionByEndToEndID :one
+SELECT id, message_id, payment_info_id, instruction_id, end_to_end_id, from_account_id, to_account_id, amount, status, reason_code, reason, transfer_id, review_case_id, created_at FROM payment_instructions
+WHERE from_account_id = $1 AND end_to_end_id = $2 LIMIT 1
+`
+
+type GetPaymentInstructionByEndToEndIDParams struct {
+	FromAccountID int64  `json:"from_account_id"`
+	EndToEndID    string `json:"end_to_end_id"`
+}
+
+func (q *Queries) GetPaymentInstructionByEndToEndID(ctx context.Context, arg GetPaymentInstructionByEndToEndIDParams) (PaymentInstruction, error) {
+	row := q.db.QueryRow(ctx, getPaymentInstructionByEndToEndID, arg.FromAccountID, arg.EndToEndID)
+	var i PaymentInstruction
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.PaymentInfoID,
+		&i.InstructionID,
+		&i.EndToEndID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Status,
+		&i.ReasonCode,
+		&i.Reason,
+		&i.TransferID,
+		&i.ReviewCaseID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPaymentInstructionByReviewCaseForUpdate = `-- name: GetPaymentInstructionByReviewCaseForUpdate :one
+SELECT id, message_id, payment_info_id, instruction_id, end_to_end_id, from_account_id, to_account_id, amount, status, reason_code, reason, transfer_id, review_case_id, created_at FROM payment_instructions
+WHERE review_case_id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetPaymentInstructionByReviewCaseForUpdate(ctx context.Context, reviewCaseID pgtype.Int8) (PaymentInstruction, error) {
+	row := q.db.QueryRow(ctx, getPaymentInstructionByReviewCaseForUpdate, reviewCaseID)
+	var i PaymentInstruction
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.PaymentInfoID,
+		&i.InstructionID,
+		&i.EndToEndID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Status,
+		&i.ReasonCode,
+		&i.Reason,
+		&i.TransferID,
+		&i.ReviewCaseID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listPaymentInstructionsByMessage = `-- name: ListPaymentInstructionsByMessage :many
+SELECT id, message_id, payment_info_id, instruction_id, end_to_end_id, from_account_id, to_account_id, amount, status, reason_code, reason, transfer_id, review_case_id, created_at FROM payment_instructions
+WHERE message_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListPaymentInstructionsByMessage(ctx context.Context, messageID string) ([]PaymentInstruction, error) {
+	rows, err := q.db.Query(ctx, listPaymentInstructionsByMessage, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PaymentInstruction{}
+	for rows.Next() {
+		var i PaymentInstruction
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.PaymentInfoID,
+			&i.InstructionID,
+			&i.EndToEndID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Status,
+			&i.ReasonCode,
+			&i.Reason,
+			&i.TransferID,
+			&i.ReviewCaseID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
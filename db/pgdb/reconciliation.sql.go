@@ -0,0 +1,96 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: reconciliation.sql
+
+package pgdb
+
+import (
+	"context"
+)
+
+const listCurrencyImbalances = `-- name: ListCurrencyImbalances :many
+SELECT accounts.currency, sum(entries.amount)::bigint AS total
+FROM (
+  SELECT account_id, amount FROM entries
+  UNION ALL
+  SELECT account_id, amount FROM archived_entry_totals
+) AS entries
+JOIN accounts ON accounts.id = entries.account_id
+GROUP BY accounts.currency
+HAVING sum(entries.amount) <> 0
+ORDER BY accounts.currency
+`
+
+type ListCurrencyImbalancesRow struct {
+	Currency string `json:"currency"`
+	Total    int64  `json:"total"`
+}
+
+// 複式記帳下每個幣別的 entries 加總一定是 0（含 clearing / suspense 帳戶和卸下的分區）
+func (q *Queries) ListCurrencyImbalances(ctx context.Context) ([]ListCurrencyImbalancesRow, error) {
+	rows, err := q.db.Query(ctx, listCurrencyImbalances)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListCurrencyImbalancesRow{}
+	for rows.Next() {
+		var i ListCurrencyImbalancesRow
+		if err := rows.Scan(&i.Currency, &i.Total); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLedgerMismatches = `-- name: ListLedgerMismatches :many
+SELECT accounts.id, accounts.currency, accounts.balance,
+  COALESCE(sum(entries.amount), 0)::bigint AS entries_total
+FROM accounts
+LEFT JOIN (
+  SELECT account_id, amount FROM entries
+  UNION ALL
+  SELECT account_id, amount FROM archived_entry_totals
+) AS entries ON entries.account_id = accounts.id
+GROUP BY accounts.id
+HAVING accounts.balance <> COALESCE(sum(entries.amount), 0)
+ORDER BY accounts.id
+`
+
+type ListLedgerMismatchesRow struct {
+	ID           int64  `json:"id"`
+	Currency     string `json:"currency"`
+	Balance      int64  `json:"balance"`
+	EntriesTotal int64  `json:"entries_total"`
+}
+
+// 餘額和 entries 加總對不上的帳戶；卸下的分區用 archived_entry_totals 的加總代替
+func (q *Queries) ListLedgerMismatches(ctx context.Context) ([]ListLedgerMismatchesRow, error) {
+	rows, err := q.db.Query(ctx, listLedgerMismatches)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLedgerMismatchesRow{}
+	for rows.Next() {
+		var i ListLedgerMismatchesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Currency,
+			&i.Balance,
+			&i.EntriesTotal,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
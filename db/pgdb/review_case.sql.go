@@ -0,0 +1,280 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: review_case.sql
+
+package pgdb
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countTransfersInAmountRange = `-- name: CountTransfersInAmountRange :one
+SELECT count(*) FROM transfers
+WHERE from_account_id = $1
+  AND amount BETWEEN $2 AND $3
+  AND created_at >= $4
+`
+
+type CountTransfersInAmountRangeParams struct {
+	AccountID int64     `json:"account_id"`
+	MinAmount int64     `json:"min_amount"`
+	MaxAmount int64     `json:"max_amount"`
+	Since     time.Time `json:"since"`
+}
+
+// 給 StructuringRule：since 之後轉出、金額在 [min_amount, max_amount] 之間的筆數
+func (q *Queries) CountTransfersInAmountRange(ctx context.Context, arg CountTransfersInAmountRangeParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countTransfersInAmountRange,
+		arg.AccountID,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Since,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createRejectedReviewCase = `-- name: CreateRejectedReviewCase :one
+INSERT INTO review_cases (
+  from_account_id, to_account_id, amount, hits, status, reviewer, decided_at
+) VALUES (
+  $1, $2, $3, $4, 'rejected', $5, now()
+)
+RETURNING id, from_account_id, to_account_id, amount, hits, status, transfer_id, reviewer, decision_note, decided_at, created_at
+`
+
+type CreateRejectedReviewCaseParams struct {
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	Hits          json.RawMessage `json:"hits"`
+	Reviewer      string          `json:"reviewer"`
+}
+
+// 被規則擋下的轉帳直接記成已拒絕的 case，擋下的決定也查得到
+func (q *Queries) CreateRejectedReviewCase(ctx context.Context, arg CreateRejectedReviewCaseParams) (ReviewCase, error) {
+	row := q.db.QueryRow(ctx, createRejectedReviewCase,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Hits,
+		arg.Reviewer,
+	)
+	var i ReviewCase
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Hits,
+		&i.Status,
+		&i.TransferID,
+		&i.Reviewer,
+		&i.DecisionNote,
+		&i.DecidedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createReviewCase = `-- name: CreateReviewCase :one
+INSERT INTO review_cases (
+  from_account_id, to_account_id, amount, hits
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING id, from_account_id, to_account_id, amount, hits, status, transfer_id, reviewer, decision_note, decided_at, created_at
+`
+
+type CreateReviewCaseParams struct {
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	Hits          json.RawMessage `json:"hits"`
+}
+
+func (q *Queries) CreateReviewCase(ctx context.Context, arg CreateReviewCaseParams) (ReviewCase, error) {
+	row := q.db.QueryRow(ctx, createReviewCase,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Hits,
+	)
+	var i ReviewCase
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Hits,
+		&i.Status,
+		&i.TransferID,
+		&i.Reviewer,
+		&i.DecisionNote,
+		&i.DecidedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const decideReviewCase = `-- name: DecideReviewCase :one
+UPDATE review_cases
+  set status = $2,
+  transfer_id = $3,
+  reviewer = $4,
+  decision_note = $5,
+  decided_at = now()
+WHERE id = $1
+RETURNING id, from_account_id, to_account_id, amount, hits, status, transfer_id, reviewer, decision_note, decided_at, created_at
+`
+
+type DecideReviewCaseParams struct {
+	ID           int64       `json:"id"`
+	Status       string      `json:"status"`
+	TransferID   pgtype.Int8 `json:"transfer_id"`
+	Reviewer     string      `json:"reviewer"`
+	DecisionNote string      `json:"decision_note"`
+}
+
+func (q *Queries) DecideReviewCase(ctx context.Context, arg DecideReviewCaseParams) (ReviewCase, error) {
+	row := q.db.QueryRow(ctx, decideReviewCase,
+		arg.ID,
+		arg.Status,
+		arg.TransferID,
+		arg.Reviewer,
+		arg.DecisionNote,
+	)
+	var i ReviewCase
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Hits,
+		&i.Status,
+		&i.TransferID,
+		&i.Reviewer,
+		&i.DecisionNote,
+		&i.DecidedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getReviewCase = `-- name: GetReviewCase :one
+SELECT id, from_account_id, to_account_id, amount, hits, status, transfer_id, reviewer, decision_note, decided_at, created_at FROM review_cases
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetReviewCase(ctx context.Context, id int64) (ReviewCase, error) {
+	row := q.db.QueryRow(ctx, getReviewCase, id)
+	var i ReviewCase
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Hits,
+		&i.Status,
+		&i.TransferID,
+		&i.Reviewer,
+		&i.DecisionNote,
+		&i.DecidedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getReviewCaseForUpdate = `-- name: GetReviewCaseForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, hits, status, transfer_id, reviewer, decision_note, decided_at, created_at FROM review_cases
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetReviewCaseForUpdate(ctx context.Context, id int64) (ReviewCase, error) {
+	row := q.db.QueryRow(ctx, getReviewCaseForUpdate, id)
+	var i ReviewCase
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Hits,
+		&i.Status,
+		&i.TransferID,
+		&i.Reviewer,
+		&i.DecisionNote,
+		&i.DecidedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listReviewCases = `-- name: ListReviewCases :many
+SELECT id, from_account_id, to_account_id, amount, hits, status, transfer_id, reviewer, decision_note, decided_at, created_at FROM review_cases
+WHERE status = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListReviewCasesParams struct {
+	Status     string `json:"status"`
+	AfterID    int64  `json:"after_id"`
+	LimitCount int32  `json:"limit_count"`
+}
+
+func (q *Queries) ListReviewCases(ctx context.Context, arg ListReviewCasesParams) ([]ReviewCase, error) {
+	rows, err := q.db.Query(ctx, listReviewCases, arg.Status, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ReviewCase{}
+	for rows.Next() {
+		var i ReviewCase
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Hits,
+			&i.Status,
+			&i.TransferID,
+			&i.Reviewer,
+			&i.DecisionNote,
+			&i.DecidedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sumIncomingEntries = `-- name: SumIncomingEntries :one
+SELECT COALESCE(sum(amount), 0)::bigint AS total FROM entries
+WHERE account_id = $1 AND amount > 0 AND created_at >= $2
+`
+
+type SumIncomingEntriesParams struct {
+	AccountID int64     `json:"account_id"`
+	Since     time.Time `json:"since"`
+}
+
+// 給 RapidMovementRule：since 之後入帳的總額
+func (q *Queries) SumIncomingEntries(ctx context.Context, arg SumIncomingEntriesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, sumIncomingEntries, arg.AccountID, arg.Since)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
@@ -0,0 +1,95 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: statement.sql
+
+package pgdb
+
+import (
+	"context"
+	"time"
+)
+
+const listStatementEntries = `-- name: ListStatementEntries :many
+SELECT
+  entries.id,
+  entries.amount,
+  entries.created_at,
+  CASE
+    WHEN transfers.id IS NOT NULL THEN 'transfer'
+    WHEN external_transfers.reversal_entry_id = entries.id THEN 'reversal'
+    WHEN external_transfers.id IS NOT NULL THEN external_transfers.direction
+    WHEN balance_adjustments.id IS NOT NULL THEN 'adjustment'
+    ELSE ''
+  END::varchar AS kind,
+  COALESCE(transfers.id, 0)::bigint AS transfer_id,
+  COALESCE(
+    CASE WHEN transfers.from_account_id = entries.account_id THEN transfers.to_account_id ELSE transfers.from_account_id END,
+    external_transfers.clearing_account_id,
+    balance_adjustments.suspense_account_id,
+    0
+  )::bigint AS counterparty_account_id,
+  COALESCE(counterparty.account_number, '')::varchar AS counterparty_account_number,
+  COALESCE(external_transfers.external_ref, '')::varchar AS reference
+FROM entries
+LEFT JOIN transfers ON transfers.id = entries.transfer_id
+LEFT JOIN external_transfers ON external_transfers.entry_id = entries.id OR external_transfers.reversal_entry_id = entries.id
+LEFT JOIN balance_adjustments ON balance_adjustments.entry_id = entries.id
+LEFT JOIN accounts counterparty ON counterparty.id = COALESCE(
+  CASE WHEN transfers.from_account_id = entries.account_id THEN transfers.to_account_id ELSE transfers.from_account_id END,
+  external_transfers.clearing_account_id,
+  balance_adjustments.suspense_account_id
+)
+WHERE entries.account_id = $1
+  AND entries.created_at >= $2::timestamptz
+  AND entries.created_at < $3::timestamptz
+ORDER BY entries.created_at, entries.id
+`
+
+type ListStatementEntriesParams struct {
+	AccountID int64     `json:"account_id"`
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+}
+
+type ListStatementEntriesRow struct {
+	ID                        int64     `json:"id"`
+	Amount                    int64     `json:"amount"`
+	CreatedAt                 time.Time `json:"created_at"`
+	Kind                      string    `json:"kind"`
+	TransferID                int64     `json:"transfer_id"`
+	CounterpartyAccountID     int64     `json:"counterparty_account_id"`
+	CounterpartyAccountNumber string    `json:"counterparty_account_number"`
+	Reference                 string    `json:"reference"`
+}
+
+// created_at 在 [from_time, to_time) 之間記到帳戶的 entries，連同對方帳戶、對方的帳號和參考號；
+// 對方帳戶：轉帳是另一邊的帳戶，出入金是 clearing 帳戶，調帳是 suspense 帳戶
+func (q *Queries) ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error) {
+	rows, err := q.db.Query(ctx, listStatementEntries, arg.AccountID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListStatementEntriesRow{}
+	for rows.Next() {
+		var i ListStatementEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Kind,
+			&i.TransferID,
+			&i.CounterpartyAccountID,
+			&i.CounterpartyAccountNumber,
+			&i.Reference,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
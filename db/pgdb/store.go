@@ -0,0 +1,61 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrScreeningRequired is returned by NewStore when the db.Store it runs next to screens transfers:
+// Store would move the money without running the rules
+var ErrScreeningRequired = errors.New("pgdb.Store only runs unscreened transfers")
+
+// Store runs TransferTx over a pgx connection pool, with the writes pipelined in batches.
+// It is a narrow fast path next to db.Store, not a replacement: it has no transfer rules, no AfterTransfer,
+// no metrics and no tracing, see NewStore.
+// The raw queries are not exposed, accounts are opened and everything else is written through db.Store,
+// which keeps the outbox, the audit log and the opening balances in one place.
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+// NewStore creates a Store next to store, the db.Store of the same database.
+// It fails with ErrScreeningRequired when store screens transfers, those have to keep going through store.TransferTx.
+func NewStore(pool *pgxpool.Pool, store *db.Store) (*Store, error) {
+	// *pgxpool.Pool 和 pgx.Tx 都實作了 sqlc 產生的 DBTX
+	var _ DBTX = (*pgxpool.Pool)(nil)
+
+	// 沒有規則的 TransferTx 會把該 hold 或 block 的轉帳直接轉掉，不能讓它靜靜地跑起來
+	if store.ScreensTransfers() {
+		return nil, fmt.Errorf("%w: the db.Store has transfer rules, send transfers through db.Store.TransferTx", ErrScreeningRequired)
+	}
+	return &Store{pool: pool}, nil
+}
+
+// Pool returns the connection pool of the store
+func (store *Store) Pool() *pgxpool.Pool {
+	return store.pool
+}
+
+// execTx executes a function within a database transaction
+func (store *Store) execTx(ctx context.Context, fn func(tx pgx.Tx, q *Queries) error) error {
+	tx, err := store.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	var _ DBTX = (pgx.Tx)(nil)
+	err = fn(tx, New(tx))
+	if err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,197 @@
+package pgdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomAccount 借用 db.Store 開帳戶，期初餘額才會記成 entries
+func createRandomAccount(t *testing.T, currency string, balance int64) db.Account {
+	account, err := db.NewStore(testSQLDB).CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    util.RandomOwner(),
+		Balance:  balance,
+		Currency: currency,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+// newTestStore 建一個跑在沒有規則的 db.Store 旁邊的 Store
+func newTestStore(t testing.TB) *Store {
+	store, err := NewStore(testPool, db.NewStore(testSQLDB))
+	require.NoError(t, err)
+	return store
+}
+
+func TestNewStoreScreening(t *testing.T) {
+	// 旁邊的 db.Store 要篩查轉帳的話，不能建沒有規則的快速路徑
+	screened := db.NewStore(testSQLDB, db.WithTransferRules(db.NewAccountRule{MinAmount: 1}))
+	store, err := NewStore(testPool, screened)
+	require.ErrorIs(t, err, ErrScreeningRequired)
+	require.Nil(t, store)
+}
+
+func TestTransferTx(t *testing.T) {
+	store := newTestStore(t)
+	account1 := createRandomAccount(t, util.EUR, 1000)
+	account2 := createRandomAccount(t, util.EUR, 1000)
+
+	// 兩個方向同時轉，鎖序固定就不會 deadlock
+	n := 10
+	amount := int64(10)
+	errs := make(chan error)
+	results := make(chan TransferTxResult)
+	for i := 0; i < n; i++ {
+		fromAccountID, toAccountID := account1.ID, account2.ID
+		if i%2 == 1 {
+			fromAccountID, toAccountID = toAccountID, fromAccountID
+		}
+		go func() {
+			result, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: fromAccountID,
+				ToAccountID:   toAccountID,
+				Amount:        amount,
+			})
+			errs <- err
+			results <- result
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+		result := <-results
+
+		require.NotZero(t, result.Transfer.ID)
+		require.Equal(t, amount, result.Transfer.Amount)
+		require.Equal(t, result.Transfer.FromAccountID, result.FromAccount.ID)
+		require.Equal(t, result.Transfer.ToAccountID, result.ToAccount.ID)
+		require.Equal(t, -amount, result.FromEntry.Amount)
+		require.Equal(t, amount, result.ToEntry.Amount)
+		require.Equal(t, result.Transfer.ID, result.FromEntry.TransferID.Int64)
+
+		entry, err := testQueries.GetEntry(context.Background(), result.ToEntry.ID)
+		require.NoError(t, err)
+		require.Equal(t, result.ToEntry, entry)
+
+		// 事件 payload 和 db.Store 寫的是同一個格式
+		events, err := testQueries.ListOutboxEventsByAggregate(context.Background(), ListOutboxEventsByAggregateParams{
+			AggregateType: db.AggregateTransfer,
+			AggregateID:   result.Transfer.ID,
+		})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		payload, err := json.Marshal(toDBTransferTxResult(result))
+		require.NoError(t, err)
+		require.JSONEq(t, string(payload), string(events[0].Payload))
+	}
+
+	updated1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	updated2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updated1.Balance)
+	require.Equal(t, account2.Balance, updated2.Balance)
+
+	// audit log 的 hash 鏈要接得上 db.Store 寫的
+	_, err = db.NewStore(testSQLDB).VerifyAuditLog(context.Background(), 500)
+	require.NoError(t, err)
+}
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := newTestStore(t)
+	account1 := createRandomAccount(t, util.USD, 10)
+	account2 := createRandomAccount(t, util.USD, 0)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 11})
+	require.ErrorIs(t, err, db.ErrInsufficientFunds)
+
+	// 整批都 rollback 了
+	account, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), account.Balance)
+}
+
+func TestTransferTxLimitExceeded(t *testing.T) {
+	store := newTestStore(t)
+	account1 := createRandomAccount(t, util.USD, 100)
+	account2 := createRandomAccount(t, util.USD, 0)
+
+	// 限額和 db.Store 用同一個 db.CheckTransferLimits 檢查
+	_, err := db.NewStore(testSQLDB).SetAccountTransferLimit(context.Background(), db.SetAccountTransferLimitParams{
+		AccountID: sql.NullInt64{Int64: account1.ID, Valid: true},
+		MaxSingle: sql.NullInt64{Int64: 50, Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 51})
+	var exceeded *db.LimitExceededError
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, db.LimitSingle, exceeded.Limit)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 50})
+	require.NoError(t, err)
+}
+
+func TestTransferTxPgError(t *testing.T) {
+	store := newTestStore(t)
+	account := createRandomAccount(t, util.USD, 10)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account.ID, ToAccountID: account.ID + 1_000_000_000, Amount: 1})
+	require.Error(t, err)
+	require.True(t, IsForeignKeyViolation(err), err)
+
+	_, err = testQueries.GetAccount(context.Background(), account.ID+1_000_000_000)
+	require.True(t, errors.Is(err, ErrRecordNotFound))
+}
+
+// BenchmarkTransferTx 比較 db.Store（lib/pq，一個語句一個來回）和 pgx Store（pipeline batch）的轉帳吞吐量：
+// go test ./db/pgdb -run '^$' -bench TransferTx
+func BenchmarkTransferTx(b *testing.B) {
+	sqlStore := db.NewStore(testSQLDB)
+	pgxStore := newTestStore(b)
+
+	benchmarks := []struct {
+		name     string
+		transfer func(ctx context.Context, fromAccountID int64, toAccountID int64) error
+	}{
+		{"database/sql", func(ctx context.Context, fromAccountID int64, toAccountID int64) error {
+			_, err := sqlStore.TransferTx(ctx, db.TransferTxParams{FromAccountID: fromAccountID, ToAccountID: toAccountID, Amount: 1})
+			return err
+		}},
+		{"pgx", func(ctx context.Context, fromAccountID int64, toAccountID int64) error {
+			_, err := pgxStore.TransferTx(ctx, TransferTxParams{FromAccountID: fromAccountID, ToAccountID: toAccountID, Amount: 1})
+			return err
+		}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			// 每個 goroutine 用自己的一對帳戶，量的是來回次數而不是 row lock 的排隊
+			b.RunParallel(func(pb *testing.PB) {
+				from, err := sqlStore.CreateAccount(context.Background(), db.CreateAccountParams{Owner: util.RandomOwner(), Balance: 1 << 40, Currency: util.EUR})
+				if err != nil {
+					b.Error(err)
+					return
+				}
+				to, err := sqlStore.CreateAccount(context.Background(), db.CreateAccountParams{Owner: util.RandomOwner(), Currency: util.EUR})
+				if err != nil {
+					b.Error(err)
+					return
+				}
+				for pb.Next() {
+					if err := bm.transfer(context.Background(), from.ID, to.ID); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package pgdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// 改餘額的查詢不放在 db/query，sqlc 不會產生，和 db 套件一樣手寫成 unexported，見 db/sqlc/ledger_writes.go
+
+const enableLedgerWrites = `-- name: EnableLedgerWrites :exec
+SELECT set_config('bank.ledger_writes', 'on', true)
+`
+
+const addAccountBalance = `-- name: AddAccountBalance :one
+UPDATE accounts
+  set balance = balance + $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number
+`
+
+// TransferTxParams contains the input parameters of the transfer transaction
+type TransferTxParams struct {
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	Amount        int64 `json:"amount"`
+}
+
+// TransferTxResult is the result of the transfer transaction
+type TransferTxResult struct {
+	Transfer    Transfer `json:"transfer"`
+	FromAccount Account  `json:"from_account"`
+	ToAccount   Account  `json:"to_account"`
+	FromEntry   Entry    `json:"from_entry"`
+	ToEntry     Entry    `json:"to_entry"`
+}
+
+// TransferTx performs a money transfer from one account to the other like db.Store.TransferTx without transfer rules:
+// it creates the transfer and its entries, updates both balances, records a transfer.created outbox event and an audit log row
+// within a single database transaction, and fails with db.ErrAccountClosed, db.ErrInsufficientFunds or a *db.LimitExceededError
+// from the same checks as db.Store.
+// The writes are sent as three pipelined batches instead of one round trip per statement.
+func (store *Store) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(ctx, func(tx pgx.Tx, q *Queries) error {
+		// 限額的 advisory lock 要在鎖 row 之前拿，見 db.CheckTransferLimits
+		if err := db.CheckTransferLimits(ctx, limitQuerier{q}, arg.FromAccountID, arg.Amount); err != nil {
+			return err
+		}
+
+		// 第一批：轉帳和兩邊的餘額。鎖序和 db.Store 一樣，先更新 id 小的帳戶
+		first, second := &result.FromAccount, &result.ToAccount
+		firstID, firstAmount, secondID, secondAmount := arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount
+		if arg.ToAccountID < arg.FromAccountID {
+			first, second = second, first
+			firstID, firstAmount, secondID, secondAmount = secondID, secondAmount, firstID, firstAmount
+		}
+		batch := &pgx.Batch{}
+		batch.Queue(createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount).QueryRow(scanInto(&result.Transfer, scanTransfer))
+		batch.Queue(enableLedgerWrites)
+		batch.Queue(addAccountBalance, firstAmount, firstID).QueryRow(scanInto(first, scanAccount))
+		batch.Queue(addAccountBalance, secondAmount, secondID).QueryRow(scanInto(second, scanAccount))
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return err
+		}
+
+		// 扣款之後才檢查，兩邊帳戶的 row 都已經被鎖住
+		if err := db.CheckOpen(toDBAccount(result.FromAccount), toDBAccount(result.ToAccount)); err != nil {
+			return err
+		}
+		if err := db.CheckFunds(toDBAccount(result.FromAccount), arg.Amount); err != nil {
+			return err
+		}
+
+		// 個資的 key 要在拿 audit log 的鎖之前讀好或建好，之後 Redact 只用讀好的 key，見 db.AuditRedactor
+		redactor := db.NewAuditRedactor(auditKeyQuerier{q})
+		actor, err := redactor.Actor(ctx)
+		if err != nil {
+			return err
+		}
+		for _, owner := range []string{result.FromAccount.Owner, result.ToAccount.Owner} {
+			if _, err := redactor.Subject(ctx, owner); err != nil {
+				return err
+			}
+		}
+
+		// 第二批：兩筆 entries，順便拿 audit log 的鎖和上一筆 hash。
+		// 拿鎖之後只會新增 row，不會再鎖別的 row，所以不會 deadlock，見 db 套件的 recordAudit
+		transferID := pgtype.Int8{Int64: result.Transfer.ID, Valid: true}
+		var prevHash string
+		batch = &pgx.Batch{}
+		batch.Queue(createEntry, arg.FromAccountID, -arg.Amount, transferID).QueryRow(scanInto(&result.FromEntry, scanEntry))
+		batch.Queue(createEntry, arg.ToAccountID, arg.Amount, transferID).QueryRow(scanInto(&result.ToEntry, scanEntry))
+		batch.Queue(lockAuditLog)
+		batch.Queue(getLastAuditLog).QueryRow(func(row pgx.Row) error {
+			var last AuditLog
+			err := row.Scan(&last.ID, &last.Actor, &last.Action, &last.EntityType, &last.EntityID,
+				&last.Before, &last.After, &last.RequestID, &last.PrevHash, &last.Hash, &last.CreatedAt)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			prevHash = last.Hash
+			return err
+		})
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return err
+		}
+
+		// 第三批：outbox 事件和 audit log，payload 用 db 的型別，和 db.Store 寫的一樣
+		payload, err := json.Marshal(toDBTransferTxResult(result))
+		if err != nil {
+			return fmt.Errorf("cannot marshal %s payload: %w", db.EventTransferCreated, err)
+		}
+		after, err := redactor.Redact(ctx, toDBTransferTxResult(result))
+		if err != nil {
+			return fmt.Errorf("cannot marshal audit after: %w", err)
+		}
+		// hash 和 db.Store 寫的用同一個函式算，鏈才接得上
+		audit := db.NewAuditLogParams(ctx, actor, db.AuditActionCreate, db.AuditEntityTransfer, strconv.FormatInt(result.Transfer.ID, 10),
+			json.RawMessage("null"), after, prevHash)
+		batch = &pgx.Batch{}
+		batch.Queue(createOutboxEvent, db.AggregateTransfer, result.Transfer.ID, db.EventTransferCreated, payload)
+		batch.Queue(createAuditLog, audit.Actor, audit.Action, audit.EntityType, audit.EntityID,
+			audit.Before, audit.After, audit.RequestID, audit.PrevHash, audit.Hash, audit.CreatedAt)
+		return tx.SendBatch(ctx, batch).Close()
+	})
+
+	return result, err
+}
+
+func toDBTransferTxResult(result TransferTxResult) db.TransferTxResult {
+	return db.TransferTxResult{
+		Transfer:    toDBTransfer(result.Transfer),
+		FromAccount: toDBAccount(result.FromAccount),
+		ToAccount:   toDBAccount(result.ToAccount),
+		FromEntry:   toDBEntry(result.FromEntry),
+		ToEntry:     toDBEntry(result.ToEntry),
+	}
+}
+
+// limitQuerier 讓 db.CheckTransferLimits 透過 pgx 查詢，型別換成 db 的，查不到換成 sql.ErrNoRows
+type limitQuerier struct {
+	q *Queries
+}
+
+var _ db.TransferLimitQuerier = limitQuerier{}
+
+func (querier limitQuerier) GetAccount(ctx context.Context, id int64) (db.Account, error) {
+	account, err := querier.q.GetAccount(ctx, id)
+	return toDBAccount(account), sqlNoRows(err)
+}
+
+func (querier limitQuerier) GetOwnerTransferLimit(ctx context.Context, arg db.GetOwnerTransferLimitParams) (db.TransferLimit, error) {
+	limit, err := querier.q.GetOwnerTransferLimit(ctx, GetOwnerTransferLimitParams{
+		Owner:    pgtype.Text{String: arg.Owner.String, Valid: arg.Owner.Valid},
+		Currency: pgtype.Text{String: arg.Currency.String, Valid: arg.Currency.Valid},
+	})
+	return toDBTransferLimit(limit), sqlNoRows(err)
+}
+
+func (querier limitQuerier) GetAccountTransferLimit(ctx context.Context, accountID sql.NullInt64) (db.TransferLimit, error) {
+	limit, err := querier.q.GetAccountTransferLimit(ctx, pgtype.Int8{Int64: accountID.Int64, Valid: accountID.Valid})
+	return toDBTransferLimit(limit), sqlNoRows(err)
+}
+
+func (querier limitQuerier) LockTransferLimit(ctx context.Context, lockKey string) error {
+	return querier.q.LockTransferLimit(ctx, lockKey)
+}
+
+func (querier limitQuerier) GetOwnerTransferUsage(ctx context.Context, arg db.GetOwnerTransferUsageParams) (db.GetOwnerTransferUsageRow, error) {
+	usage, err := querier.q.GetOwnerTransferUsage(ctx, GetOwnerTransferUsageParams(arg))
+	return db.GetOwnerTransferUsageRow(usage), sqlNoRows(err)
+}
+
+func (querier limitQuerier) GetAccountTransferUsage(ctx context.Context, fromAccountID int64) (db.GetAccountTransferUsageRow, error) {
+	usage, err := querier.q.GetAccountTransferUsage(ctx, fromAccountID)
+	return db.GetAccountTransferUsageRow(usage), sqlNoRows(err)
+}
+
+// auditKeyQuerier 讓 db.AuditRedactor 透過 pgx 讀寫 audit_subject_keys
+type auditKeyQuerier struct {
+	q *Queries
+}
+
+var _ db.AuditSubjectKeyQuerier = auditKeyQuerier{}
+
+func (querier auditKeyQuerier) GetAuditSubjectKey(ctx context.Context, subject string) ([]byte, error) {
+	key, err := querier.q.GetAuditSubjectKey(ctx, subject)
+	return key, sqlNoRows(err)
+}
+
+func (querier auditKeyQuerier) CreateAuditSubjectKey(ctx context.Context, arg db.CreateAuditSubjectKeyParams) error {
+	return querier.q.CreateAuditSubjectKey(ctx, CreateAuditSubjectKeyParams(arg))
+}
+
+// sqlNoRows 把 pgx.ErrNoRows 換成 db 套件認得的 sql.ErrNoRows
+func sqlNoRows(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// scanInto 把 scan 的結果寫到 dst，給 batch 的 QueryRow callback 用
+func scanInto[T any](dst *T, scan func(row pgx.Row) (T, error)) func(row pgx.Row) error {
+	return func(row pgx.Row) error {
+		value, err := scan(row)
+		if err != nil {
+			return err
+		}
+		*dst = value
+		return nil
+	}
+}
+
+// 欄位順序和 sqlc 產生的 RETURNING 一樣
+
+func scanAccount(row pgx.Row) (Account, error) {
+	var i Account
+	err := row.Scan(&i.ID, &i.Owner, &i.Balance, &i.Currency, &i.CreatedAt, &i.ProductID,
+		&i.OverdraftLimit, &i.OverdraftRateBps, &i.OverdraftFee, &i.ClosedAt, &i.AccountNumber)
+	return i, err
+}
+
+func scanTransfer(row pgx.Row) (Transfer, error) {
+	var i Transfer
+	err := row.Scan(&i.ID, &i.FromAccountID, &i.ToAccountID, &i.Amount, &i.CreatedAt)
+	return i, err
+}
+
+func scanEntry(row pgx.Row) (Entry, error) {
+	var i Entry
+	err := row.Scan(&i.ID, &i.AccountID, &i.Amount, &i.CreatedAt, &i.TransferID)
+	return i, err
+}
@@ -0,0 +1,79 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: user.sql
+
+package pgdb
+
+import (
+	"context"
+)
+
+const createUser = `-- name: CreateUser :one
+INSERT INTO users (
+  username, full_name, email
+) VALUES (
+  $1, $2, $3
+)
+RETURNING username, full_name, email, is_email_verified, created_at, anonymized_at
+`
+
+type CreateUserParams struct {
+	Username string `json:"username"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, createUser, arg.Username, arg.FullName, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.FullName,
+		&i.Email,
+		&i.IsEmailVerified,
+		&i.CreatedAt,
+		&i.AnonymizedAt,
+	)
+	return i, err
+}
+
+const getUser = `-- name: GetUser :one
+SELECT username, full_name, email, is_email_verified, created_at, anonymized_at FROM users
+WHERE username = $1 LIMIT 1
+`
+
+func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, getUser, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.FullName,
+		&i.Email,
+		&i.IsEmailVerified,
+		&i.CreatedAt,
+		&i.AnonymizedAt,
+	)
+	return i, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users
+  set is_email_verified = true
+WHERE username = $1
+RETURNING username, full_name, email, is_email_verified, created_at, anonymized_at
+`
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, verifyUserEmail, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.FullName,
+		&i.Email,
+		&i.IsEmailVerified,
+		&i.CreatedAt,
+		&i.AnonymizedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,522 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: webhook.sql
+
+package pgdb
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimDueWebhookDeliveries = `-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries
+  set next_attempt_at = $1, updated_at = now()
+WHERE id IN (
+  SELECT id FROM webhook_deliveries
+  WHERE status = 'pending' AND next_attempt_at <= now()
+  ORDER BY next_attempt_at, id
+  LIMIT $2
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at, replays, replayed_attempts
+`
+
+type ClaimDueWebhookDeliveriesParams struct {
+	LeaseUntil time.Time `json:"lease_until"`
+	LimitCount int32     `json:"limit_count"`
+}
+
+func (q *Queries) ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, claimDueWebhookDeliveries, arg.LeaseUntil, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.EndpointID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Replays,
+			&i.ReplayedAttempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (
+  endpoint_id, event_id, event_type, payload
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (endpoint_id, event_id) DO NOTHING
+`
+
+type CreateWebhookDeliveryParams struct {
+	EndpointID int64           `json:"endpoint_id"`
+	EventID    string          `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) error {
+	_, err := q.db.Exec(ctx, createWebhookDelivery,
+		arg.EndpointID,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+	)
+	return err
+}
+
+const createWebhookDeliveryAttempt = `-- name: CreateWebhookDeliveryAttempt :one
+INSERT INTO webhook_delivery_attempts (
+  delivery_id, attempt, replay, status_code, error, duration_ms
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING id, delivery_id, attempt, status_code, error, duration_ms, created_at, replay
+`
+
+type CreateWebhookDeliveryAttemptParams struct {
+	DeliveryID int64       `json:"delivery_id"`
+	Attempt    int32       `json:"attempt"`
+	Replay     int32       `json:"replay"`
+	StatusCode pgtype.Int4 `json:"status_code"`
+	Error      pgtype.Text `json:"error"`
+	DurationMs int64       `json:"duration_ms"`
+}
+
+func (q *Queries) CreateWebhookDeliveryAttempt(ctx context.Context, arg CreateWebhookDeliveryAttemptParams) (WebhookDeliveryAttempt, error) {
+	row := q.db.QueryRow(ctx, createWebhookDeliveryAttempt,
+		arg.DeliveryID,
+		arg.Attempt,
+		arg.Replay,
+		arg.StatusCode,
+		arg.Error,
+		arg.DurationMs,
+	)
+	var i WebhookDeliveryAttempt
+	err := row.Scan(
+		&i.ID,
+		&i.DeliveryID,
+		&i.Attempt,
+		&i.StatusCode,
+		&i.Error,
+		&i.DurationMs,
+		&i.CreatedAt,
+		&i.Replay,
+	)
+	return i, err
+}
+
+const createWebhookEndpoint = `-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (
+  owner, url, secret, event_types
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING id, owner, url, secret, event_types, is_active, created_at
+`
+
+type CreateWebhookEndpointParams struct {
+	Owner      string   `json:"owner"`
+	Url        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+func (q *Queries) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, createWebhookEndpoint,
+		arg.Owner,
+		arg.Url,
+		arg.Secret,
+		arg.EventTypes,
+	)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.IsActive,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at, replays, replayed_attempts FROM webhook_deliveries
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, getWebhookDelivery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Replays,
+		&i.ReplayedAttempts,
+	)
+	return i, err
+}
+
+const getWebhookEndpoint = `-- name: GetWebhookEndpoint :one
+SELECT id, owner, url, secret, event_types, is_active, created_at FROM webhook_endpoints
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetWebhookEndpoint(ctx context.Context, id int64) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, getWebhookEndpoint, id)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.IsActive,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listActiveWebhookEndpointsByOwner = `-- name: ListActiveWebhookEndpointsByOwner :many
+SELECT id, owner, url, secret, event_types, is_active, created_at FROM webhook_endpoints
+WHERE owner = $1 AND is_active
+ORDER BY id
+`
+
+func (q *Queries) ListActiveWebhookEndpointsByOwner(ctx context.Context, owner string) ([]WebhookEndpoint, error) {
+	rows, err := q.db.Query(ctx, listActiveWebhookEndpointsByOwner, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookEndpoint{}
+	for rows.Next() {
+		var i WebhookEndpoint
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Url,
+			&i.Secret,
+			&i.EventTypes,
+			&i.IsActive,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at, replays, replayed_attempts FROM webhook_deliveries
+WHERE endpoint_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListWebhookDeliveriesParams struct {
+	EndpointID int64 `json:"endpoint_id"`
+	Limit      int32 `json:"limit"`
+	Offset     int32 `json:"offset"`
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveries, arg.EndpointID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.EndpointID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Replays,
+			&i.ReplayedAttempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveriesByStatus = `-- name: ListWebhookDeliveriesByStatus :many
+SELECT id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at, replays, replayed_attempts FROM webhook_deliveries
+WHERE endpoint_id = $1 AND status = $2
+ORDER BY id
+LIMIT $3
+OFFSET $4
+`
+
+type ListWebhookDeliveriesByStatusParams struct {
+	EndpointID int64  `json:"endpoint_id"`
+	Status     string `json:"status"`
+	Limit      int32  `json:"limit"`
+	Offset     int32  `json:"offset"`
+}
+
+func (q *Queries) ListWebhookDeliveriesByStatus(ctx context.Context, arg ListWebhookDeliveriesByStatusParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveriesByStatus,
+		arg.EndpointID,
+		arg.Status,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.EndpointID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Replays,
+			&i.ReplayedAttempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveryAttempts = `-- name: ListWebhookDeliveryAttempts :many
+SELECT id, delivery_id, attempt, status_code, error, duration_ms, created_at, replay FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY attempt
+`
+
+func (q *Queries) ListWebhookDeliveryAttempts(ctx context.Context, deliveryID int64) ([]WebhookDeliveryAttempt, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveryAttempts, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDeliveryAttempt{}
+	for rows.Next() {
+		var i WebhookDeliveryAttempt
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeliveryID,
+			&i.Attempt,
+			&i.StatusCode,
+			&i.Error,
+			&i.DurationMs,
+			&i.CreatedAt,
+			&i.Replay,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookEndpointsByOwner = `-- name: ListWebhookEndpointsByOwner :many
+SELECT id, owner, url, secret, event_types, is_active, created_at FROM webhook_endpoints
+WHERE owner = $1
+ORDER BY id
+`
+
+func (q *Queries) ListWebhookEndpointsByOwner(ctx context.Context, owner string) ([]WebhookEndpoint, error) {
+	rows, err := q.db.Query(ctx, listWebhookEndpointsByOwner, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookEndpoint{}
+	for rows.Next() {
+		var i WebhookEndpoint
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Url,
+			&i.Secret,
+			&i.EventTypes,
+			&i.IsActive,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const replayWebhookDelivery = `-- name: ReplayWebhookDelivery :one
+UPDATE webhook_deliveries
+  set status = 'pending', replays = replays + 1, replayed_attempts = attempts,
+    next_attempt_at = now(), last_error = NULL, updated_at = now()
+WHERE id = $1
+RETURNING id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at, replays, replayed_attempts
+`
+
+// attempts 不歸零，只記下 replay 前用掉的 attempts，重試次數從這裡重新算
+func (q *Queries) ReplayWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, replayWebhookDelivery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Replays,
+		&i.ReplayedAttempts,
+	)
+	return i, err
+}
+
+const setWebhookEndpointActive = `-- name: SetWebhookEndpointActive :one
+UPDATE webhook_endpoints
+  set is_active = $2
+WHERE id = $1
+RETURNING id, owner, url, secret, event_types, is_active, created_at
+`
+
+type SetWebhookEndpointActiveParams struct {
+	ID       int64 `json:"id"`
+	IsActive bool  `json:"is_active"`
+}
+
+func (q *Queries) SetWebhookEndpointActive(ctx context.Context, arg SetWebhookEndpointActiveParams) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, setWebhookEndpointActive, arg.ID, arg.IsActive)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.IsActive,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const updateWebhookDeliveryResult = `-- name: UpdateWebhookDeliveryResult :one
+UPDATE webhook_deliveries
+  set status = $1, attempts = $2, next_attempt_at = $3,
+    last_error = $4, updated_at = now()
+WHERE id = $5 AND status = 'pending' AND attempts = $2::integer - 1
+RETURNING id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at, replays, replayed_attempts
+`
+
+type UpdateWebhookDeliveryResultParams struct {
+	Status        string      `json:"status"`
+	Attempt       int32       `json:"attempt"`
+	NextAttemptAt time.Time   `json:"next_attempt_at"`
+	LastError     pgtype.Text `json:"last_error"`
+	ID            int64       `json:"id"`
+}
+
+// 和 CompleteTask 一樣只有還拿著這次 claim 的 deliverer 才能寫結果：別的 deliverer 先寫了這一次 attempt 的話 attempts 已經加一，
+// 這裡查不到資料
+func (q *Queries) UpdateWebhookDeliveryResult(ctx context.Context, arg UpdateWebhookDeliveryResultParams) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, updateWebhookDeliveryResult,
+		arg.Status,
+		arg.Attempt,
+		arg.NextAttemptAt,
+		arg.LastError,
+		arg.ID,
+	)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Replays,
+		&i.ReplayedAttempts,
+	)
+	return i, err
+}
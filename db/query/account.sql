@@ -21,18 +21,24 @@ ORDER BY id
 LIMIT $1
 OFFSET $2;
 
--- name: UpdateAccount :one
-UPDATE accounts
-  set balance = $2
-WHERE id = $1
-RETURNING *;
-
--- name: AddAccountBalance :one
-UPDATE accounts
-  set balance = balance + sqlc.arg(amount)
-WHERE id = sqlc.arg(id)
-RETURNING *;
-
 -- name: DeleteAccount :exec
 DELETE FROM accounts
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: AccountHasLedgerRows :one
+-- 記過帳（entries、轉帳、出入金、調帳）的帳戶不能刪，帳本要對得起來
+SELECT (
+  EXISTS (SELECT 1 FROM entries WHERE entries.account_id = sqlc.arg(id))
+  OR EXISTS (SELECT 1 FROM transfers WHERE transfers.from_account_id = sqlc.arg(id) OR transfers.to_account_id = sqlc.arg(id))
+  OR EXISTS (SELECT 1 FROM external_transfers WHERE external_transfers.account_id = sqlc.arg(id) OR external_transfers.clearing_account_id = sqlc.arg(id))
+  OR EXISTS (SELECT 1 FROM balance_adjustments WHERE balance_adjustments.account_id = sqlc.arg(id) OR balance_adjustments.suspense_account_id = sqlc.arg(id))
+)::boolean AS has_ledger_rows;
+
+-- name: GetAccountByNumber :one
+SELECT * FROM accounts
+WHERE account_number = $1 LIMIT 1;
+
+-- name: ListAccountsByNumbers :many
+SELECT * FROM accounts
+WHERE account_number = ANY(sqlc.arg(account_numbers)::varchar[])
+ORDER BY id;
@@ -0,0 +1,105 @@
+-- name: LockAccountImports :exec
+-- 一次只跑一個匯入，多個 clearing 帳戶的餘額一起更新時才不會和另一個匯入互鎖
+SELECT pg_advisory_xact_lock(hashtext('account_imports'));
+
+-- name: CreateAccountImport :one
+INSERT INTO account_imports (
+  source
+) VALUES (
+  $1
+)
+RETURNING *;
+
+-- name: GetAccountImport :one
+SELECT * FROM account_imports
+WHERE id = $1 LIMIT 1;
+
+-- name: SetAccountImportAccepted :one
+UPDATE account_imports
+  set accepted = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ListAccountImportCurrencies :many
+SELECT DISTINCT currency FROM account_import_rows
+WHERE import_id = $1
+ORDER BY currency;
+
+-- name: AllocateImportedAccountIDs :execrows
+-- 先從 sequence 拿好 id，依檔案的行號順序，後面的 INSERT 才能把帳戶、entries 和暫存列對起來
+UPDATE account_import_rows
+  set account_id = allocated.account_id,
+  entry_id = allocated.entry_id,
+  clearing_entry_id = allocated.clearing_entry_id
+FROM (
+  SELECT ordered.line,
+    nextval('accounts_id_seq') AS account_id,
+    CASE WHEN ordered.balance > 0 THEN nextval('entries_id_seq') END AS entry_id,
+    CASE WHEN ordered.balance > 0 THEN nextval('entries_id_seq') END AS clearing_entry_id
+  FROM (
+    SELECT line, balance FROM account_import_rows
+    WHERE import_id = sqlc.arg(import_id)
+    ORDER BY line
+  ) AS ordered
+) AS allocated
+WHERE account_import_rows.import_id = sqlc.arg(import_id) AND account_import_rows.line = allocated.line;
+
+-- name: InsertImportedAccounts :execrows
+-- 和 Store.CreateAccount 一樣先用 0 開戶，期初餘額另外記帳
+INSERT INTO accounts (id, owner, balance, currency)
+SELECT account_id, owner, 0, currency FROM account_import_rows
+WHERE import_id = $1
+ORDER BY line;
+
+-- name: InsertImportedEntries :execrows
+INSERT INTO entries (id, account_id, amount)
+SELECT account_import_rows.entry_id, account_import_rows.account_id, account_import_rows.balance
+FROM account_import_rows
+WHERE account_import_rows.import_id = sqlc.arg(import_id) AND account_import_rows.balance > 0
+UNION ALL
+SELECT account_import_rows.clearing_entry_id, system_accounts.account_id, -account_import_rows.balance
+FROM account_import_rows
+JOIN system_accounts ON system_accounts.purpose = 'clearing' AND system_accounts.currency = account_import_rows.currency
+WHERE account_import_rows.import_id = sqlc.arg(import_id) AND account_import_rows.balance > 0;
+
+-- name: InsertImportedExternalTransfers :execrows
+-- 期初餘額和 Store.CreateAccount 一樣記成已入帳的 opening:<id> 入金
+INSERT INTO external_transfers (
+  account_id, clearing_account_id, direction, amount, external_ref, status, entry_id, clearing_entry_id
+)
+SELECT account_import_rows.account_id, system_accounts.account_id, 'deposit', account_import_rows.balance,
+  'opening:' || account_import_rows.account_id, 'settled', account_import_rows.entry_id, account_import_rows.clearing_entry_id
+FROM account_import_rows
+JOIN system_accounts ON system_accounts.purpose = 'clearing' AND system_accounts.currency = account_import_rows.currency
+WHERE account_import_rows.import_id = $1 AND account_import_rows.balance > 0
+ORDER BY account_import_rows.line;
+
+-- name: ApplyImportedBalances :execrows
+-- 要先 enableLedgerWrites；新帳戶加上期初餘額，clearing 帳戶扣掉同幣別的總額
+UPDATE accounts
+  set balance = accounts.balance + deltas.amount
+FROM (
+  SELECT account_import_rows.account_id, account_import_rows.balance AS amount FROM account_import_rows
+  WHERE account_import_rows.import_id = sqlc.arg(import_id) AND account_import_rows.balance > 0
+  UNION ALL
+  SELECT system_accounts.account_id, -sum(account_import_rows.balance)::bigint
+  FROM account_import_rows
+  JOIN system_accounts ON system_accounts.purpose = 'clearing' AND system_accounts.currency = account_import_rows.currency
+  WHERE account_import_rows.import_id = sqlc.arg(import_id) AND account_import_rows.balance > 0
+  GROUP BY system_accounts.account_id
+) AS deltas
+WHERE accounts.id = deltas.account_id;
+
+-- name: InsertImportedScreeningResults :execrows
+-- 和 Store.CreateAccount 一樣，開了戶的 owner 篩查結果掛在帳戶上
+INSERT INTO screening_results (
+  account_id, name, normalized_name, outcome, score, entry_id, matched_name
+)
+SELECT account_id, owner, screening_normalized_name, screening_outcome, screening_score, screening_entry_id, screening_matched_name
+FROM account_import_rows
+WHERE import_id = $1 AND screening_outcome IS NOT NULL
+ORDER BY line;
+
+-- name: DeleteAccountImportRows :exec
+DELETE FROM account_import_rows
+WHERE import_id = $1;
@@ -0,0 +1,45 @@
+-- name: LockAuditLog :exec
+-- 交易層級的 advisory lock，Commit / Rollback 時自動釋放；
+-- 拿到鎖之後才讀最後一筆 hash 再寫入，同時寫 audit log 的交易會排隊，鏈不會分岔
+SELECT pg_advisory_xact_lock(hashtext('audit_log'));
+
+-- name: GetLastAuditLog :one
+SELECT * FROM audit_log
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: CreateAuditLog :one
+INSERT INTO audit_log (
+  actor, action, entity_type, entity_id, before, after, request_id, prev_hash, hash, created_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+RETURNING *;
+
+-- name: ListAuditLogs :many
+SELECT * FROM audit_log
+WHERE id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: ListAuditLogsByEntity :many
+SELECT * FROM audit_log
+WHERE entity_type = $1 AND entity_id = $2
+ORDER BY id;
+
+-- name: GetAuditSubjectKey :one
+SELECT key FROM audit_subject_keys
+WHERE subject = $1;
+
+-- name: CreateAuditSubjectKey :exec
+-- 另一個事務同時建了同一個 subject 的話，等它 Commit 之後什麼都不做，再用 GetAuditSubjectKey 讀它的 key
+INSERT INTO audit_subject_keys (
+  subject, key
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (subject) DO NOTHING;
+
+-- name: DeleteAuditSubjectKey :execrows
+DELETE FROM audit_subject_keys
+WHERE subject = $1;
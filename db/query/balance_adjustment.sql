@@ -0,0 +1,32 @@
+-- name: LockSystemAccount :exec
+-- 建立系統帳戶前先拿 advisory lock，同時第一次用到同一個系統帳戶的事務不會各建一個
+SELECT pg_advisory_xact_lock(hashtext('system_accounts'), hashtext(sqlc.arg(purpose)::text || ':' || sqlc.arg(currency)::text));
+
+-- name: GetSystemAccount :one
+SELECT accounts.* FROM system_accounts
+JOIN accounts ON accounts.id = system_accounts.account_id
+WHERE system_accounts.purpose = $1 AND system_accounts.currency = $2
+LIMIT 1;
+
+-- name: CreateSystemAccount :one
+INSERT INTO system_accounts (
+  purpose, currency, account_id
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: CreateBalanceAdjustment :one
+INSERT INTO balance_adjustments (
+  account_id, suspense_account_id, amount, reason_code, operator, note, entry_id, suspense_entry_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: ListBalanceAdjustments :many
+SELECT * FROM balance_adjustments
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
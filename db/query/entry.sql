@@ -1,8 +1,8 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
-  account_id, amount
+  account_id, amount, transfer_id
 ) VALUES (
-  $1, $2
+  $1, $2, $3
 )
 RETURNING *;
 
@@ -0,0 +1,54 @@
+-- name: CreateExternalTransfer :one
+-- 同一個 direction + external_ref 已經存在時不插入也不回傳，呼叫端再用 GetExternalTransferByRef 拿已經存在的那筆
+INSERT INTO external_transfers (
+  account_id, clearing_account_id, direction, amount, external_ref
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (direction, external_ref) DO NOTHING
+RETURNING *;
+
+-- name: GetExternalTransfer :one
+SELECT * FROM external_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: GetExternalTransferForUpdate :one
+SELECT * FROM external_transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetExternalTransferByRef :one
+SELECT * FROM external_transfers
+WHERE direction = $1 AND external_ref = $2 LIMIT 1;
+
+-- name: ListExternalTransfers :many
+SELECT * FROM external_transfers
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: SetExternalTransferEntries :one
+UPDATE external_transfers
+  set entry_id = $2,
+  clearing_entry_id = $3,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: SettleExternalTransfer :one
+UPDATE external_transfers
+  set status = 'settled',
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: FailExternalTransfer :one
+UPDATE external_transfers
+  set status = 'failed',
+  failure_reason = $2,
+  reversal_entry_id = $3,
+  clearing_reversal_entry_id = $4,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,103 @@
+-- name: CreateAccountProduct :one
+INSERT INTO account_products (
+  name, annual_rate_bps, day_count, posting_frequency
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetAccountProduct :one
+SELECT * FROM account_products
+WHERE id = $1 LIMIT 1;
+
+-- name: SetAccountProduct :one
+UPDATE accounts
+  set product_id = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ListAccruingAccounts :many
+-- 有掛利率產品的帳戶，用 id 分頁
+SELECT accounts.id AS account_id, accounts.currency,
+  account_products.id AS product_id, account_products.annual_rate_bps,
+  account_products.day_count, account_products.posting_frequency
+FROM accounts
+JOIN account_products ON account_products.id = accounts.product_id
+WHERE accounts.id > sqlc.arg(after_id)
+ORDER BY accounts.id
+LIMIT sqlc.arg(limit_count);
+
+-- name: GetBalanceAt :one
+-- 由 entries 推出 at 之前的餘額（不含 at）；卸下的分區整個在 at 之前時算進它的加總。
+-- at 落在卸下的月份裡面時，那個月在 at 之前的 entries 查不到，archived 是 true，balance 不能用
+SELECT (
+  COALESCE((
+    SELECT sum(entries.amount) FROM entries
+    WHERE entries.account_id = sqlc.arg(account_id) AND entries.created_at < sqlc.arg(at)::timestamptz
+  ), 0) +
+  COALESCE((
+    SELECT sum(archived_entry_totals.amount) FROM archived_entry_totals
+    WHERE archived_entry_totals.account_id = sqlc.arg(account_id) AND archived_entry_totals.archived_before <= sqlc.arg(at)::timestamptz
+  ), 0)
+)::bigint AS balance,
+EXISTS (
+  SELECT 1 FROM archived_entry_totals
+  WHERE archived_entry_totals.account_id = sqlc.arg(account_id)
+    AND archived_entry_totals.archived_before > sqlc.arg(at)::timestamptz
+    AND archived_entry_totals.archived_before - interval '1 month' < sqlc.arg(at)::timestamptz
+) AS archived;
+
+-- name: CreateInterestAccrual :one
+INSERT INTO interest_accruals (
+  account_id, product_id, accrual_date, balance, annual_rate_bps, day_count, days, units
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (account_id, accrual_date) DO NOTHING
+RETURNING *;
+
+-- name: GetInterestAccrual :one
+SELECT * FROM interest_accruals
+WHERE account_id = $1 AND accrual_date = $2 LIMIT 1;
+
+-- name: SumUnpostedInterestAccruals :one
+SELECT count(*) AS accruals, COALESCE(sum(units), 0)::bigint AS units
+FROM interest_accruals
+WHERE account_id = sqlc.arg(account_id) AND accrual_date <= sqlc.arg(period_end) AND posting_id IS NULL;
+
+-- name: MarkInterestAccrualsPosted :execrows
+UPDATE interest_accruals
+  set posting_id = sqlc.arg(posting_id)
+WHERE account_id = sqlc.arg(account_id)
+  AND accrual_date <= sqlc.arg(period_end)
+  AND posting_id IS NULL;
+
+-- name: GetLastInterestPosting :one
+SELECT * FROM interest_postings
+WHERE account_id = $1
+ORDER BY period_end DESC
+LIMIT 1;
+
+-- name: CreateInterestPosting :one
+INSERT INTO interest_postings (
+  account_id, period_end, units, amount, carry_units
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (account_id, period_end) DO NOTHING
+RETURNING *;
+
+-- name: SetInterestPostingTransfer :one
+UPDATE interest_postings
+  set transfer_id = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ListInterestPostings :many
+SELECT * FROM interest_postings
+WHERE account_id = $1
+ORDER BY period_end;
+
+-- name: GetInterestPosting :one
+SELECT * FROM interest_postings
+WHERE account_id = $1 AND period_end = $2 LIMIT 1;
@@ -0,0 +1,28 @@
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox (
+  aggregate_type, aggregate_id, event_type, payload
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetOutboxEvent :one
+SELECT * FROM outbox
+WHERE id = $1 LIMIT 1;
+
+-- name: ListOutboxEventsByAggregate :many
+SELECT * FROM outbox
+WHERE aggregate_type = $1 AND aggregate_id = $2
+ORDER BY id;
+
+-- name: ListPendingOutboxEvents :many
+SELECT * FROM outbox
+WHERE delivered_at IS NULL
+ORDER BY id
+LIMIT $1
+FOR UPDATE;
+
+-- name: MarkOutboxEventDelivered :exec
+UPDATE outbox
+  set delivered_at = now()
+WHERE id = $1;
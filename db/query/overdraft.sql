@@ -0,0 +1,68 @@
+-- name: SetAccountOverdraft :one
+UPDATE accounts
+  set overdraft_limit = $2,
+  overdraft_rate_bps = $3,
+  overdraft_fee = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: ListOverdraftUsage :many
+-- 目前透支中的帳戶，用得最多的排前面
+SELECT id AS account_id, owner, currency, balance, overdraft_limit,
+  (-balance)::bigint AS used,
+  (overdraft_limit + balance)::bigint AS available
+FROM accounts
+WHERE balance < 0 AND owner NOT LIKE 'system:%'
+ORDER BY balance, id
+LIMIT $1
+OFFSET $2;
+
+-- name: SummarizeOverdraftUsage :many
+-- 各幣別的額度和使用量；超過額度的帳戶（例如被調帳或收費扣到額度以下）另外計數
+SELECT currency,
+  count(*) FILTER (WHERE overdraft_limit > 0) AS accounts_with_limit,
+  count(*) FILTER (WHERE balance < 0) AS overdrawn_accounts,
+  count(*) FILTER (WHERE balance < -overdraft_limit) AS over_limit_accounts,
+  COALESCE(sum(overdraft_limit), 0)::bigint AS total_limit,
+  COALESCE(sum(-balance) FILTER (WHERE balance < 0), 0)::bigint AS total_used
+FROM accounts
+WHERE owner NOT LIKE 'system:%'
+GROUP BY currency
+ORDER BY currency;
+
+-- name: ListChargeableOverdraftAccounts :many
+-- 有設透支利率或手續費的帳戶，用 id 分頁
+SELECT id AS account_id, currency, overdraft_rate_bps, overdraft_fee
+FROM accounts
+WHERE (overdraft_rate_bps > 0 OR overdraft_fee > 0) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: GetLastOverdraftCharge :one
+SELECT * FROM overdraft_charges
+WHERE account_id = $1
+ORDER BY period_end DESC
+LIMIT 1;
+
+-- name: GetOverdraftCharge :one
+SELECT * FROM overdraft_charges
+WHERE account_id = $1 AND period_end = $2 LIMIT 1;
+
+-- name: CreateOverdraftCharge :one
+INSERT INTO overdraft_charges (
+  account_id, period_start, period_end, overdrawn_days, rate_bps, units, fee, amount, carry_units
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: SetOverdraftChargeTransfer :one
+UPDATE overdraft_charges
+  set transfer_id = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ListOverdraftCharges :many
+SELECT * FROM overdraft_charges
+WHERE account_id = $1
+ORDER BY period_end;
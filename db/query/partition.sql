@@ -0,0 +1,17 @@
+-- name: CreateLedgerPartitions :many
+-- 建好 entries 和 transfers 從 from_month 到 through_month 的月份分區，回傳新建的分區
+SELECT create_ledger_partitions(sqlc.arg(from_month)::date, sqlc.arg(through_month)::date)::text AS partition;
+
+-- name: DetachLedgerPartitions :many
+-- 卸下在 detach_before 之前結束的月份分區，archive_schema 不是空字串時搬到那個 schema
+SELECT detach_ledger_partitions(sqlc.arg(detach_before)::date, sqlc.arg(archive_schema)::text)::text AS partition;
+
+-- name: ListArchivedEntryTotals :many
+SELECT * FROM archived_entry_totals
+WHERE account_id = $1
+ORDER BY archived_before;
+
+-- name: ListArchivedEntryTotalsByAccounts :many
+SELECT * FROM archived_entry_totals
+WHERE account_id = ANY(sqlc.arg(account_ids)::bigint[])
+ORDER BY account_id, archived_before;
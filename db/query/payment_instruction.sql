@@ -0,0 +1,33 @@
+-- name: CreatePaymentInstruction :one
+-- 同一個帳戶的 end_to_end_id 已經存在時不插入也不回傳，呼叫端再用 GetPaymentInstructionByEndToEndID 拿已經存在的那筆
+INSERT INTO payment_instructions (
+  message_id, payment_info_id, instruction_id, end_to_end_id, from_account_id, to_account_id, amount,
+  status, reason_code, reason, transfer_id, review_case_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+ON CONFLICT (from_account_id, end_to_end_id) DO NOTHING
+RETURNING *;
+
+-- name: GetPaymentInstructionByEndToEndID :one
+SELECT * FROM payment_instructions
+WHERE from_account_id = $1 AND end_to_end_id = $2 LIMIT 1;
+
+-- name: ListPaymentInstructionsByMessage :many
+SELECT * FROM payment_instructions
+WHERE message_id = $1
+ORDER BY id;
+
+-- name: GetPaymentInstructionByReviewCaseForUpdate :one
+SELECT * FROM payment_instructions
+WHERE review_case_id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: DecidePaymentInstruction :one
+UPDATE payment_instructions
+  set status = $2,
+  reason_code = $3,
+  reason = $4,
+  transfer_id = $5
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,86 @@
+-- name: CloseAccount :one
+UPDATE accounts
+  set closed_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: ListAccountsByOwner :many
+SELECT * FROM accounts
+WHERE owner = $1
+ORDER BY id;
+
+-- name: ListEntriesByAccounts :many
+SELECT * FROM entries
+WHERE account_id = ANY(sqlc.arg(account_ids)::bigint[])
+ORDER BY id;
+
+-- name: ListTransfersByAccounts :many
+SELECT * FROM transfers
+WHERE from_account_id = ANY(sqlc.arg(account_ids)::bigint[]) OR to_account_id = ANY(sqlc.arg(account_ids)::bigint[])
+ORDER BY id;
+
+-- name: ListExternalTransfersByAccounts :many
+SELECT * FROM external_transfers
+WHERE account_id = ANY(sqlc.arg(account_ids)::bigint[])
+ORDER BY id;
+
+-- name: AnonymizeAccountsOwner :execrows
+-- 只改已經關閉的帳戶，ListAccountsByOwner 之後才開的帳戶不會被一起匿名化
+UPDATE accounts
+  set owner = sqlc.arg(pseudonym)
+WHERE owner = sqlc.arg(owner) AND closed_at IS NOT NULL;
+
+-- name: DeleteVerifyEmailsByUsername :exec
+DELETE FROM verify_emails
+WHERE username = $1;
+
+-- name: AnonymizeUser :one
+UPDATE users
+  set username = sqlc.arg(pseudonym),
+  full_name = '',
+  email = sqlc.arg(email),
+  anonymized_at = now()
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: AnonymizeWebhookEndpoints :execrows
+UPDATE webhook_endpoints
+  set owner = sqlc.arg(pseudonym),
+  url = '',
+  secret = '',
+  is_active = false
+WHERE owner = sqlc.arg(owner);
+
+-- name: AnonymizeTransferLimits :execrows
+UPDATE transfer_limits
+  set owner = sqlc.arg(pseudonym)
+WHERE owner = sqlc.arg(owner);
+
+-- name: AnonymizeScreeningResults :execrows
+UPDATE screening_results
+  set name = sqlc.arg(pseudonym),
+  normalized_name = sqlc.arg(pseudonym)
+WHERE name = sqlc.arg(owner);
+
+-- name: AnonymizeReviewCaseHits :execrows
+-- 舊版 OwnerScreeningRule 的 reason 裡有轉出帳戶的 owner，現在只記帳戶 id，但 matched name 仍可能和 owner 一樣
+UPDATE review_cases
+  set hits = anonymize_owner_jsonb(hits, sqlc.arg(owner), sqlc.arg(pseudonym))
+WHERE (from_account_id = ANY(sqlc.arg(account_ids)::bigint[]) OR to_account_id = ANY(sqlc.arg(account_ids)::bigint[]))
+  AND hits <> anonymize_owner_jsonb(hits, sqlc.arg(owner), sqlc.arg(pseudonym));
+
+-- name: AnonymizeOutboxPayloads :execrows
+-- account.* 事件的 payload 是帳戶本身，transfer.created 的 payload 帶著兩邊的帳戶，external_transfer.* 的帶著入出金的帳戶
+UPDATE outbox
+  set payload = anonymize_owner_jsonb(payload, sqlc.arg(owner), sqlc.arg(pseudonym))
+WHERE (
+    (aggregate_type = 'account' AND aggregate_id = ANY(sqlc.arg(account_ids)::bigint[]))
+    OR (aggregate_type = 'transfer' AND aggregate_id IN (
+      SELECT id FROM transfers
+      WHERE from_account_id = ANY(sqlc.arg(account_ids)::bigint[]) OR to_account_id = ANY(sqlc.arg(account_ids)::bigint[])
+    ))
+    OR (aggregate_type = 'external_transfer' AND aggregate_id IN (
+      SELECT id FROM external_transfers WHERE account_id = ANY(sqlc.arg(account_ids)::bigint[])
+    ))
+  )
+  AND payload <> anonymize_owner_jsonb(payload, sqlc.arg(owner), sqlc.arg(pseudonym));
@@ -0,0 +1,26 @@
+-- name: ListLedgerMismatches :many
+-- 餘額和 entries 加總對不上的帳戶；卸下的分區用 archived_entry_totals 的加總代替
+SELECT accounts.id, accounts.currency, accounts.balance,
+  COALESCE(sum(entries.amount), 0)::bigint AS entries_total
+FROM accounts
+LEFT JOIN (
+  SELECT account_id, amount FROM entries
+  UNION ALL
+  SELECT account_id, amount FROM archived_entry_totals
+) AS entries ON entries.account_id = accounts.id
+GROUP BY accounts.id
+HAVING accounts.balance <> COALESCE(sum(entries.amount), 0)
+ORDER BY accounts.id;
+
+-- name: ListCurrencyImbalances :many
+-- 複式記帳下每個幣別的 entries 加總一定是 0（含 clearing / suspense 帳戶和卸下的分區）
+SELECT accounts.currency, sum(entries.amount)::bigint AS total
+FROM (
+  SELECT account_id, amount FROM entries
+  UNION ALL
+  SELECT account_id, amount FROM archived_entry_totals
+) AS entries
+JOIN accounts ON accounts.id = entries.account_id
+GROUP BY accounts.currency
+HAVING sum(entries.amount) <> 0
+ORDER BY accounts.currency;
@@ -0,0 +1,53 @@
+-- name: CreateReviewCase :one
+INSERT INTO review_cases (
+  from_account_id, to_account_id, amount, hits
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetReviewCase :one
+SELECT * FROM review_cases
+WHERE id = $1 LIMIT 1;
+
+-- name: GetReviewCaseForUpdate :one
+SELECT * FROM review_cases
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListReviewCases :many
+SELECT * FROM review_cases
+WHERE status = sqlc.arg(status) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: DecideReviewCase :one
+UPDATE review_cases
+  set status = $2,
+  transfer_id = $3,
+  reviewer = $4,
+  decision_note = $5,
+  decided_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: CountTransfersInAmountRange :one
+-- 給 StructuringRule：since 之後轉出、金額在 [min_amount, max_amount] 之間的筆數
+SELECT count(*) FROM transfers
+WHERE from_account_id = sqlc.arg(account_id)
+  AND amount BETWEEN sqlc.arg(min_amount) AND sqlc.arg(max_amount)
+  AND created_at >= sqlc.arg(since);
+
+-- name: SumIncomingEntries :one
+-- 給 RapidMovementRule：since 之後入帳的總額
+SELECT COALESCE(sum(amount), 0)::bigint AS total FROM entries
+WHERE account_id = sqlc.arg(account_id) AND amount > 0 AND created_at >= sqlc.arg(since);
+
+-- name: CreateRejectedReviewCase :one
+-- 被規則擋下的轉帳直接記成已拒絕的 case，擋下的決定也查得到
+INSERT INTO review_cases (
+  from_account_id, to_account_id, amount, hits, status, reviewer, decided_at
+) VALUES (
+  $1, $2, $3, $4, 'rejected', sqlc.arg(reviewer), now()
+)
+RETURNING *;
@@ -0,0 +1,18 @@
+-- name: CreateScreeningResult :one
+INSERT INTO screening_results (
+  account_id, name, normalized_name, outcome, score, entry_id, matched_name
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: ListScreeningResultsByAccount :many
+SELECT * FROM screening_results
+WHERE account_id = $1
+ORDER BY id;
+
+-- name: ListScreeningResults :many
+SELECT * FROM screening_results
+WHERE outcome = sqlc.arg(outcome) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
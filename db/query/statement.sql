@@ -0,0 +1,36 @@
+-- name: ListStatementEntries :many
+-- created_at 在 [from_time, to_time) 之間記到帳戶的 entries，連同對方帳戶、對方的帳號和參考號；
+-- 對方帳戶：轉帳是另一邊的帳戶，出入金是 clearing 帳戶，調帳是 suspense 帳戶
+SELECT
+  entries.id,
+  entries.amount,
+  entries.created_at,
+  CASE
+    WHEN transfers.id IS NOT NULL THEN 'transfer'
+    WHEN external_transfers.reversal_entry_id = entries.id THEN 'reversal'
+    WHEN external_transfers.id IS NOT NULL THEN external_transfers.direction
+    WHEN balance_adjustments.id IS NOT NULL THEN 'adjustment'
+    ELSE ''
+  END::varchar AS kind,
+  COALESCE(transfers.id, 0)::bigint AS transfer_id,
+  COALESCE(
+    CASE WHEN transfers.from_account_id = entries.account_id THEN transfers.to_account_id ELSE transfers.from_account_id END,
+    external_transfers.clearing_account_id,
+    balance_adjustments.suspense_account_id,
+    0
+  )::bigint AS counterparty_account_id,
+  COALESCE(counterparty.account_number, '')::varchar AS counterparty_account_number,
+  COALESCE(external_transfers.external_ref, '')::varchar AS reference
+FROM entries
+LEFT JOIN transfers ON transfers.id = entries.transfer_id
+LEFT JOIN external_transfers ON external_transfers.entry_id = entries.id OR external_transfers.reversal_entry_id = entries.id
+LEFT JOIN balance_adjustments ON balance_adjustments.entry_id = entries.id
+LEFT JOIN accounts counterparty ON counterparty.id = COALESCE(
+  CASE WHEN transfers.from_account_id = entries.account_id THEN transfers.to_account_id ELSE transfers.from_account_id END,
+  external_transfers.clearing_account_id,
+  balance_adjustments.suspense_account_id
+)
+WHERE entries.account_id = sqlc.arg(account_id)
+  AND entries.created_at >= sqlc.arg(from_time)::timestamptz
+  AND entries.created_at < sqlc.arg(to_time)::timestamptz
+ORDER BY entries.created_at, entries.id;
@@ -0,0 +1,46 @@
+-- name: CreateTask :one
+INSERT INTO tasks (
+  queue, type, payload, priority, max_attempts, run_at, unique_key
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (unique_key) DO NOTHING
+RETURNING *;
+
+-- name: GetTask :one
+SELECT * FROM tasks
+WHERE id = $1 LIMIT 1;
+
+-- name: ClaimNextTask :one
+UPDATE tasks
+  set status = 'running', attempts = attempts + 1, locked_until = sqlc.arg(locked_until)::timestamptz, updated_at = now()
+WHERE id = (
+  SELECT t.id FROM tasks t
+  WHERE t.queue = sqlc.arg(queue) AND t.status = 'pending' AND t.run_at <= now()
+  ORDER BY t.priority DESC, t.run_at, t.id
+  LIMIT 1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- 只有還拿著這次 claim 的 worker 才能改結果：鎖過期被別的 worker 重新 claim 的話 attempts 會加一，
+-- 舊的 worker 改到 0 筆，不會蓋掉新的那次
+-- name: CompleteTask :execrows
+UPDATE tasks
+  set status = 'succeeded', locked_until = NULL, last_error = NULL, updated_at = now()
+WHERE id = $1 AND status = 'running' AND attempts = $2;
+
+-- name: RetryTask :execrows
+UPDATE tasks
+  set status = 'pending', run_at = $3, last_error = $4, locked_until = NULL, updated_at = now()
+WHERE id = $1 AND status = 'running' AND attempts = $2;
+
+-- name: FailTask :execrows
+UPDATE tasks
+  set status = 'failed', last_error = $3, locked_until = NULL, updated_at = now()
+WHERE id = $1 AND status = 'running' AND attempts = $2;
+
+-- name: RequeueExpiredTasks :execrows
+UPDATE tasks
+  set status = 'pending', locked_until = NULL, updated_at = now()
+WHERE status = 'running' AND locked_until < now();
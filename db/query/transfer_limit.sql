@@ -0,0 +1,67 @@
+-- name: SetAccountTransferLimit :one
+INSERT INTO transfer_limits (
+  account_id, max_single, max_daily, max_monthly, max_hourly_count
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (account_id) DO UPDATE SET
+  max_single = EXCLUDED.max_single,
+  max_daily = EXCLUDED.max_daily,
+  max_monthly = EXCLUDED.max_monthly,
+  max_hourly_count = EXCLUDED.max_hourly_count,
+  updated_at = now()
+RETURNING *;
+
+-- name: SetOwnerTransferLimit :one
+INSERT INTO transfer_limits (
+  owner, currency, max_single, max_daily, max_monthly, max_hourly_count
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (owner, currency) DO UPDATE SET
+  max_single = EXCLUDED.max_single,
+  max_daily = EXCLUDED.max_daily,
+  max_monthly = EXCLUDED.max_monthly,
+  max_hourly_count = EXCLUDED.max_hourly_count,
+  updated_at = now()
+RETURNING *;
+
+-- name: GetAccountTransferLimit :one
+SELECT * FROM transfer_limits
+WHERE account_id = $1 LIMIT 1;
+
+-- name: GetOwnerTransferLimit :one
+SELECT * FROM transfer_limits
+WHERE owner = $1 AND currency = $2 LIMIT 1;
+
+-- name: DeleteTransferLimit :exec
+DELETE FROM transfer_limits
+WHERE id = $1;
+
+-- name: LockTransferLimit :exec
+-- 同一個限額的檢查和轉帳排隊，彙總和寫入之間不會有別的轉帳插進來；Commit / Rollback 時自動釋放
+SELECT pg_advisory_xact_lock(hashtext('transfer_limits'), hashtext(sqlc.arg(lock_key)::text));
+
+-- name: GetAccountTransferUsage :one
+-- 轉出到系統帳戶的（例如透支收費）不是客戶發起的，不算進限額
+SELECT
+  COALESCE(sum(transfers.amount) FILTER (WHERE transfers.created_at >= date_trunc('day', now(), 'UTC')), 0)::bigint AS day_total,
+  COALESCE(sum(transfers.amount) FILTER (WHERE transfers.created_at >= date_trunc('month', now(), 'UTC')), 0)::bigint AS month_total,
+  count(*) FILTER (WHERE transfers.created_at > now() - interval '1 hour') AS hour_count
+FROM transfers
+JOIN accounts AS to_account ON to_account.id = transfers.to_account_id
+WHERE transfers.from_account_id = $1
+  AND transfers.created_at >= LEAST(date_trunc('month', now(), 'UTC'), now() - interval '1 hour')
+  AND to_account.owner NOT LIKE 'system:%';
+
+-- name: GetOwnerTransferUsage :one
+SELECT
+  COALESCE(sum(transfers.amount) FILTER (WHERE transfers.created_at >= date_trunc('day', now(), 'UTC')), 0)::bigint AS day_total,
+  COALESCE(sum(transfers.amount) FILTER (WHERE transfers.created_at >= date_trunc('month', now(), 'UTC')), 0)::bigint AS month_total,
+  count(*) FILTER (WHERE transfers.created_at > now() - interval '1 hour') AS hour_count
+FROM transfers
+JOIN accounts AS from_account ON from_account.id = transfers.from_account_id
+JOIN accounts AS to_account ON to_account.id = transfers.to_account_id
+WHERE from_account.owner = $1 AND from_account.currency = $2
+  AND transfers.created_at >= LEAST(date_trunc('month', now(), 'UTC'), now() - interval '1 hour')
+  AND to_account.owner NOT LIKE 'system:%';
@@ -0,0 +1,16 @@
+-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (
+  username, email, secret_code
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: UseVerifyEmail :one
+UPDATE verify_emails
+  set is_used = true
+WHERE id = $1
+  AND secret_code = $2
+  AND is_used = false
+  AND expired_at > now()
+RETURNING *;
@@ -0,0 +1,95 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (
+  owner, url, secret, event_types
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookEndpointsByOwner :many
+SELECT * FROM webhook_endpoints
+WHERE owner = $1
+ORDER BY id;
+
+-- name: ListActiveWebhookEndpointsByOwner :many
+SELECT * FROM webhook_endpoints
+WHERE owner = $1 AND is_active
+ORDER BY id;
+
+-- name: SetWebhookEndpointActive :one
+UPDATE webhook_endpoints
+  set is_active = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (
+  endpoint_id, event_id, event_type, payload
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (endpoint_id, event_id) DO NOTHING;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListWebhookDeliveriesByStatus :many
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = $1 AND status = $2
+ORDER BY id
+LIMIT $3
+OFFSET $4;
+
+-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries
+  set next_attempt_at = sqlc.arg(lease_until), updated_at = now()
+WHERE id IN (
+  SELECT id FROM webhook_deliveries
+  WHERE status = 'pending' AND next_attempt_at <= now()
+  ORDER BY next_attempt_at, id
+  LIMIT sqlc.arg(limit_count)
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateWebhookDeliveryResult :one
+-- 和 CompleteTask 一樣只有還拿著這次 claim 的 deliverer 才能寫結果：別的 deliverer 先寫了這一次 attempt 的話 attempts 已經加一，
+-- 這裡查不到資料
+UPDATE webhook_deliveries
+  set status = sqlc.arg(status), attempts = sqlc.arg(attempt), next_attempt_at = sqlc.arg(next_attempt_at),
+    last_error = sqlc.arg(last_error), updated_at = now()
+WHERE id = sqlc.arg(id) AND status = 'pending' AND attempts = sqlc.arg(attempt)::integer - 1
+RETURNING *;
+
+-- name: ReplayWebhookDelivery :one
+-- attempts 不歸零，只記下 replay 前用掉的 attempts，重試次數從這裡重新算
+UPDATE webhook_deliveries
+  set status = 'pending', replays = replays + 1, replayed_attempts = attempts,
+    next_attempt_at = now(), last_error = NULL, updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateWebhookDeliveryAttempt :one
+INSERT INTO webhook_delivery_attempts (
+  delivery_id, attempt, replay, status_code, error, duration_ms
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: ListWebhookDeliveryAttempts :many
+SELECT * FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY attempt;
@@ -7,31 +7,25 @@ package db
 
 import (
 	"context"
+
+	"github.com/lib/pq"
 )
 
-const addAccountBalance = `-- name: AddAccountBalance :one
-UPDATE accounts
-  set balance = balance + $1
-WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+const accountHasLedgerRows = `-- name: AccountHasLedgerRows :one
+SELECT (
+  EXISTS (SELECT 1 FROM entries WHERE entries.account_id = $1)
+  OR EXISTS (SELECT 1 FROM transfers WHERE transfers.from_account_id = $1 OR transfers.to_account_id = $1)
+  OR EXISTS (SELECT 1 FROM external_transfers WHERE external_transfers.account_id = $1 OR external_transfers.clearing_account_id = $1)
+  OR EXISTS (SELECT 1 FROM balance_adjustments WHERE balance_adjustments.account_id = $1 OR balance_adjustments.suspense_account_id = $1)
+)::boolean AS has_ledger_rows
 `
 
-type AddAccountBalanceParams struct {
-	Amount int64 `json:"amount"`
-	ID     int64 `json:"id"`
-}
-
-func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, addAccountBalance, arg.Amount, arg.ID)
-	var i Account
-	err := row.Scan(
-		&i.ID,
-		&i.Owner,
-		&i.Balance,
-		&i.Currency,
-		&i.CreatedAt,
-	)
-	return i, err
+// 記過帳（entries、轉帳、出入金、調帳）的帳戶不能刪，帳本要對得起來
+func (q *Queries) AccountHasLedgerRows(ctx context.Context, id int64) (bool, error) {
+	row := q.queryRow(ctx, q.accountHasLedgerRowsStmt, accountHasLedgerRows, id)
+	var has_ledger_rows bool
+	err := row.Scan(&has_ledger_rows)
+	return has_ledger_rows, err
 }
 
 const createAccount = `-- name: CreateAccount :one
@@ -40,7 +34,7 @@ INSERT INTO accounts (
 ) VALUES (
   $1, $2, $3
 )
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number
 `
 
 type CreateAccountParams struct {
@@ -50,7 +44,7 @@ type CreateAccountParams struct {
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := q.queryRow(ctx, q.createAccountStmt, createAccount, arg.Owner, arg.Balance, arg.Currency)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -58,6 +52,12 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.ProductID,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.OverdraftFee,
+		&i.ClosedAt,
+		&i.AccountNumber,
 	)
 	return i, err
 }
@@ -68,17 +68,41 @@ WHERE id = $1
 `
 
 func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteAccount, id)
+	_, err := q.exec(ctx, q.deleteAccountStmt, deleteAccount, id)
 	return err
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
-	row := q.db.QueryRowContext(ctx, getAccount, id)
+	row := q.queryRow(ctx, q.getAccountStmt, getAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.ProductID,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.OverdraftFee,
+		&i.ClosedAt,
+		&i.AccountNumber,
+	)
+	return i, err
+}
+
+const getAccountByNumber = `-- name: GetAccountByNumber :one
+SELECT id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number FROM accounts
+WHERE account_number = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error) {
+	row := q.queryRow(ctx, q.getAccountByNumberStmt, getAccountByNumber, accountNumber)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -86,18 +110,24 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.ProductID,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.OverdraftFee,
+		&i.ClosedAt,
+		&i.AccountNumber,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
 
 func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, error) {
-	row := q.db.QueryRowContext(ctx, getAccountForUpdate, id)
+	row := q.queryRow(ctx, q.getAccountForUpdateStmt, getAccountForUpdate, id)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -105,12 +135,18 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.ProductID,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.OverdraftFee,
+		&i.ClosedAt,
+		&i.AccountNumber,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number FROM accounts
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -122,7 +158,7 @@ type ListAccountsParams struct {
 }
 
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
-	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Limit, arg.Offset)
+	rows, err := q.query(ctx, q.listAccountsStmt, listAccounts, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -136,6 +172,12 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.ProductID,
+			&i.OverdraftLimit,
+			&i.OverdraftRateBps,
+			&i.OverdraftFee,
+			&i.ClosedAt,
+			&i.AccountNumber,
 		); err != nil {
 			return nil, err
 		}
@@ -150,27 +192,43 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	return items, nil
 }
 
-const updateAccount = `-- name: UpdateAccount :one
-UPDATE accounts
-  set balance = $2
-WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+const listAccountsByNumbers = `-- name: ListAccountsByNumbers :many
+SELECT id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number FROM accounts
+WHERE account_number = ANY($1::varchar[])
+ORDER BY id
 `
 
-type UpdateAccountParams struct {
-	ID      int64 `json:"id"`
-	Balance int64 `json:"balance"`
-}
-
-func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, updateAccount, arg.ID, arg.Balance)
-	var i Account
-	err := row.Scan(
-		&i.ID,
-		&i.Owner,
-		&i.Balance,
-		&i.Currency,
-		&i.CreatedAt,
-	)
-	return i, err
+func (q *Queries) ListAccountsByNumbers(ctx context.Context, accountNumbers []string) ([]Account, error) {
+	rows, err := q.query(ctx, q.listAccountsByNumbersStmt, listAccountsByNumbers, pq.Array(accountNumbers))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.ProductID,
+			&i.OverdraftLimit,
+			&i.OverdraftRateBps,
+			&i.OverdraftFee,
+			&i.ClosedAt,
+			&i.AccountNumber,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
@@ -12,14 +12,20 @@ import (
 
 // createRandomAccount 會使用隨機資料呼叫 CreateAccount，並驗證資料庫回傳的 Account 欄位是否正確
 func createRandomAccount(t *testing.T) Account {
+	// 隨機產生一個金額（100～1000），轉帳的測試才不會餘額不足
+	return createRandomAccountWithBalance(t, util.RandomInt(100, 1000))
+}
+
+// createRandomAccountWithBalance 和 createRandomAccount 一樣，只是期初餘額由呼叫端決定；0 的話不會記任何帳
+func createRandomAccountWithBalance(t *testing.T, balance int64) Account {
 	arg := CreateAccountParams{
 		Owner:    util.RandomOwner(),    // 隨機產生一個擁有者名稱（6 個隨機漢字）
-		Balance:  util.RandomMoney(),    // 隨機產生一個金額（0～1000）
+		Balance:  balance,               // 期初餘額
 		Currency: util.RandomCurrency(), // 隨機挑選一種貨幣（USD/EUR/CAD）
 	}
 
-	// 呼叫事先在 TestMain 裡建立好連線的 testQueries.CreateAccount，將參數 arg 插入資料庫
-	account, err := testQueries.CreateAccount(context.Background(), arg)
+	// 透過 Store.CreateAccount 建立，期初餘額才會記成 entries；直接用 testQueries.CreateAccount 帶非 0 的 balance 會被 trigger 擋下來
+	account, err := NewStore(testDB).CreateAccount(context.Background(), arg)
 	// 確認不會有錯誤發生
 	require.NoError(t, err)
 	// 確認回傳的 account 結構不為空
@@ -33,6 +39,8 @@ func createRandomAccount(t *testing.T) Account {
 	// 資料庫應該自動填充 ID（流水號）與 CreatedAt（建立時間），因此不能為零值
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
+	// 帳號由資料庫的 generate_account_number 產生，要能通過 util 的檢查
+	require.NoError(t, util.ValidateAccountNumber(account.AccountNumber))
 
 	return account // 回傳新建的帳戶，以供之後測試使用
 }
@@ -58,31 +66,70 @@ func TestGetAccount(t *testing.T) {
 	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
 }
 
-// TestUpdateAccount 先建立一筆隨機帳戶，然後呼叫 UpdateAccount 更新 balance，最後驗證只有 balance 變更
-func TestUpdateAccount(t *testing.T) {
-	account1 := createRandomAccount(t) // 插入隨機帳戶
+// TestGetAccountByNumber 用對外的帳號查帳戶，不存在的帳號回傳 sql.ErrNoRows
+func TestGetAccountByNumber(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	require.NotEqual(t, account1.AccountNumber, account2.AccountNumber)
+
+	found, err := testQueries.GetAccountByNumber(context.Background(), account1.AccountNumber)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, found.ID)
+
+	_, err = testQueries.GetAccountByNumber(context.Background(), util.RandomAccountNumber())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	accounts, err := testQueries.ListAccountsByNumbers(context.Background(), []string{account2.AccountNumber, account1.AccountNumber, util.RandomAccountNumber()})
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+	require.Equal(t, account1.ID, accounts[0].ID)
+	require.Equal(t, account2.ID, accounts[1].ID)
+}
+
+// TestAccountNumberCheckDigits 確認資料庫和 util 算出來的檢查碼一樣
+func TestAccountNumberCheckDigits(t *testing.T) {
+	for _, bban := range []string{"ANDY000000000000", "ANDY999999999999", "ANDY012345678901", "370400440532013000"} {
+		var got string
+		err := testDB.QueryRow("SELECT account_number_check_digits($1, $2)", util.AccountNumberCountry, bban).Scan(&got)
+		require.NoError(t, err)
 
-	arg := UpdateAccountParams{
-		ID:      account1.ID,        // 指定要更新哪一筆帳戶
-		Balance: util.RandomMoney(), // 隨機產生新的金額
+		want, err := util.IBANCheckDigits(util.AccountNumberCountry, bban)
+		require.NoError(t, err)
+		require.Equal(t, want, got, bban)
 	}
+}
 
-	account2, err := testQueries.UpdateAccount(context.Background(), arg) // 執行更新
-	require.NoError(t, err)                                               // 確認更新不會返回錯誤
-	require.NotEmpty(t, account2)                                         // 確認回傳的結構不為空
+// TestAccountBalanceGuard 確認不經過記帳直接改 balance 會被 accounts_balance_guard trigger 擋下來，
+// 同一個事務先 enableLedgerWrites 才能改
+func TestAccountBalanceGuard(t *testing.T) {
+	account1 := createRandomAccount(t)
 
-	// ID、Owner、Currency 都不應該變，只有 Balance 被新值取代
-	require.Equal(t, account1.ID, account2.ID)             // ID 不應改變
-	require.Equal(t, account1.Owner, account2.Owner)       // Owner 不應改變
-	require.Equal(t, arg.Balance, account2.Balance)        // Balance 應該被更新
-	require.Equal(t, account1.Currency, account2.Currency) // Currency 不應改變
-	// CreatedAt 應該保留原本的建立時間，允許一秒之內誤差
-	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
+	_, err := testDB.Exec("UPDATE accounts SET balance = balance + 1 WHERE id = $1", account1.ID)
+	require.ErrorContains(t, err, "ledger postings")
+
+	// 沒有開事務的話 set_config 只對那一個 statement 有效，一樣改不了
+	require.NoError(t, testQueries.enableLedgerWrites(context.Background()))
+	_, err = testQueries.addAccountBalance(context.Background(), addAccountBalanceParams{ID: account1.ID, Amount: 1})
+	require.ErrorContains(t, err, "ledger postings")
+
+	// 其他欄位不受影響
+	_, err = testDB.Exec("UPDATE accounts SET owner = owner WHERE id = $1", account1.ID)
+	require.NoError(t, err)
+
+	tx, err := testDB.Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	q := New(tx)
+	require.NoError(t, q.enableLedgerWrites(context.Background()))
+	account2, err := q.addAccountBalance(context.Background(), addAccountBalanceParams{ID: account1.ID, Amount: 1})
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance+1, account2.Balance)
 }
 
 // TestDeleteAccount 先建立隨機帳戶，呼叫 DeleteAccount 再用 GetAccount 驗證該筆已不存在
 func TestDeleteAccount(t *testing.T) {
-	account1 := createRandomAccount(t)                                  // 插入一筆帳戶
+	account1 := createRandomAccountWithBalance(t, 0)                    // 插入一筆沒有記過帳的帳戶
 	err := testQueries.DeleteAccount(context.Background(), account1.ID) // 刪除該帳戶
 	require.NoError(t, err)                                             // 確認刪除不會有錯誤
 
@@ -93,6 +140,22 @@ func TestDeleteAccount(t *testing.T) {
 	require.Empty(t, account2)                        // account2 結構應該是空值
 }
 
+func TestStoreDeleteAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	// 有期初餘額的帳戶記過帳，只能關帳
+	funded := createRandomAccount(t)
+	err := store.DeleteAccount(context.Background(), funded.ID)
+	require.ErrorIs(t, err, ErrAccountHasLedger)
+	_, err = store.GetAccount(context.Background(), funded.ID)
+	require.NoError(t, err)
+
+	empty := createRandomAccountWithBalance(t, 0)
+	require.NoError(t, store.DeleteAccount(context.Background(), empty.ID))
+	_, err = store.GetAccount(context.Background(), empty.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
 // TestListAccounts 建立 10 筆隨機帳戶，然後測試分頁查詢：Limit=5, Offset=5，應該拿到第 6～10 筆
 func TestListAccounts(t *testing.T) {
 	// 先插入 10 筆隨機帳戶
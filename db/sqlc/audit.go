@@ -0,0 +1,281 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Entity types recorded in the audit log
+const (
+	AuditEntityAccount          = "account"
+	AuditEntityTransfer         = "transfer"
+	AuditEntityUser             = "user"
+	AuditEntityExternalTransfer = "external_transfer"
+	AuditEntityTransferLimit    = "transfer_limit"
+	AuditEntityReviewCase       = "review_case"
+	AuditEntityScreeningResult  = "screening_result"
+	AuditEntityWebhookEndpoint  = "webhook_endpoint"
+)
+
+// Actions recorded in the audit log
+const (
+	AuditActionCreate      = "create"
+	AuditActionUpdate      = "update"
+	AuditActionDelete      = "delete"
+	AuditActionVerifyEmail = "verify_email"
+)
+
+// SystemActor is the actor recorded when the context does not carry one
+const SystemActor = "system"
+
+type auditContextKey int
+
+const (
+	actorContextKey auditContextKey = iota
+	userActorContextKey
+	requestIDContextKey
+)
+
+// WithActor returns a copy of ctx that records actor as the actor of the audit log rows written with it
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(context.WithValue(ctx, actorContextKey, actor), userActorContextKey, false)
+}
+
+// WithUserActor is WithActor for a user acting on their own data: the audit log records the keyed hash of the username,
+// see AuditRedactor
+func WithUserActor(ctx context.Context, username string) context.Context {
+	return context.WithValue(context.WithValue(ctx, actorContextKey, username), userActorContextKey, true)
+}
+
+// ActorFromContext returns the actor stored by WithActor, or SystemActor
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorContextKey).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
+
+// WithRequestID returns a copy of ctx that records requestID in the audit log rows written with it
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored by WithRequestID, or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
+
+// auditRecord 是還沒寫的一筆 audit log：事務裡要鎖 row 的步驟都做完之後，再用 recordAudits 一起寫
+type auditRecord struct {
+	action     string
+	entityType string
+	entityID   string
+	before     any
+	after      any
+}
+
+// recordAudit 在 audit_log 追加一筆，hash 串在上一筆後面，見 recordAudits
+func recordAudit(ctx context.Context, q *Queries, action string, entityType string, entityID string, before any, after any) error {
+	return recordAudits(ctx, q, auditRecord{action: action, entityType: entityType, entityID: entityID, before: before, after: after})
+}
+
+// recordAudits 依序在 audit_log 追加 records，hash 一筆串一筆，個資先用 AuditRedactor 換成 keyed hash。
+// 一定要是事務裡最後一個寫入：LockAuditLog 會把所有寫 audit log 的事務排隊到 Commit 為止，
+// 拿鎖前已經鎖好業務資料的 row、建好個資的 key，拿鎖後不再鎖其他 row，所以不會和別的事務互相等待造成 deadlock；
+// 同一個事務要寫好幾筆的話要一次傳進來，否則後面那筆建 key 時已經拿著鎖了。
+// 代價是 audit log 的寫入是全域序列化的，鎖只持有到 Commit，時間很短。
+func recordAudits(ctx context.Context, q *Queries, records ...auditRecord) error {
+	redactor := NewAuditRedactor(q)
+	actor, err := redactor.Actor(ctx)
+	if err != nil {
+		return err
+	}
+
+	type redacted struct{ before, after json.RawMessage }
+	contents := make([]redacted, len(records))
+	for i, record := range records {
+		before, err := redactor.Redact(ctx, record.before)
+		if err != nil {
+			return fmt.Errorf("cannot marshal audit before: %w", err)
+		}
+		after, err := redactor.Redact(ctx, record.after)
+		if err != nil {
+			return fmt.Errorf("cannot marshal audit after: %w", err)
+		}
+		contents[i] = redacted{before: before, after: after}
+	}
+
+	if err := q.LockAuditLog(ctx); err != nil {
+		return err
+	}
+
+	var prevHash string
+	last, err := q.GetLastAuditLog(ctx)
+	switch {
+	case err == nil:
+		prevHash = last.Hash
+	case errors.Is(err, sql.ErrNoRows):
+	default:
+		return err
+	}
+
+	for i, record := range records {
+		arg := NewAuditLogParams(ctx, actor, record.action, record.entityType, record.entityID, contents[i].before, contents[i].after, prevHash)
+		if _, err := q.CreateAuditLog(ctx, arg); err != nil {
+			return err
+		}
+		prevHash = arg.Hash
+	}
+	return nil
+}
+
+// NewAuditLogParams returns the audit log row that follows the row hashed prevHash, with its hash computed by AuditHash.
+// actor, before and after are written as they are, so they have to be redacted with AuditRedactor first.
+// It is called with the audit log lock held, see LockAuditLog.
+func NewAuditLogParams(ctx context.Context, actor string, action string, entityType string, entityID string, before json.RawMessage, after json.RawMessage, prevHash string) CreateAuditLogParams {
+	arg := CreateAuditLogParams{
+		Actor:      actor,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     before,
+		After:      after,
+		RequestID:  RequestIDFromContext(ctx),
+		PrevHash:   prevHash,
+		// timestamptz 只存到微秒，先截掉才能讓讀回來的值算出一樣的 hash
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	arg.Hash = AuditHash(AuditLog{
+		Actor:      arg.Actor,
+		Action:     arg.Action,
+		EntityType: arg.EntityType,
+		EntityID:   arg.EntityID,
+		Before:     arg.Before,
+		After:      arg.After,
+		RequestID:  arg.RequestID,
+		PrevHash:   arg.PrevHash,
+		CreatedAt:  arg.CreatedAt,
+	})
+	return arg
+}
+
+// AuditHash returns the hex SHA-256 of an audit log row, computed over prev_hash and every other column except id and hash
+func AuditHash(log AuditLog) string {
+	h := sha256.New()
+	// 每個欄位前面加上長度，避免 ("ab", "c") 和 ("a", "bc") 算出一樣的 hash
+	for _, field := range [][]byte{
+		[]byte(log.PrevHash),
+		[]byte(log.Actor),
+		[]byte(log.Action),
+		[]byte(log.EntityType),
+		[]byte(log.EntityID),
+		log.Before,
+		log.After,
+		[]byte(log.RequestID),
+		[]byte(log.CreatedAt.UTC().Format(time.RFC3339Nano)),
+	} {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(field)))
+		h.Write(length[:])
+		h.Write(field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AuditChainError reports the first audit log row that breaks the hash chain
+type AuditChainError struct {
+	ID     int64
+	Reason string
+}
+
+func (e *AuditChainError) Error() string {
+	return fmt.Sprintf("audit log row %d: %s", e.ID, e.Reason)
+}
+
+// AuditCheckpoint is the id and hash of an audit log row kept outside the database,
+// e.g. the Last of an earlier VerifyAuditLogResult.
+// Deleting the newest rows leaves a valid but shorter chain, only a checkpoint can detect it.
+type AuditCheckpoint struct {
+	ID   int64  `json:"id"`
+	Hash string `json:"hash"`
+}
+
+// VerifyAuditChain checks that logs, ordered by id, follow the row whose hash is prevHash.
+// A modified row fails its own hash; a deleted row breaks the prev_hash of the next one.
+// It returns the hash of the last row, to be passed as prevHash with the next page.
+func VerifyAuditChain(prevHash string, logs []AuditLog) (string, error) {
+	for _, log := range logs {
+		if log.PrevHash != prevHash {
+			return prevHash, &AuditChainError{ID: log.ID, Reason: "prev_hash does not match the previous row, a row was deleted or modified"}
+		}
+		if AuditHash(log) != log.Hash {
+			return prevHash, &AuditChainError{ID: log.ID, Reason: "hash does not match the row content, the row was modified"}
+		}
+		prevHash = log.Hash
+	}
+	return prevHash, nil
+}
+
+// VerifyAuditLogResult is the result of VerifyAuditLog
+type VerifyAuditLogResult struct {
+	Rows int64           `json:"rows"`
+	Last AuditCheckpoint `json:"last"`
+}
+
+// VerifyAuditLog walks the whole audit log in id order, pageSize rows at a time, and verifies the hash chain.
+// Every checkpoint must still be in the log with the same hash.
+// It returns an *AuditChainError describing the first problem found.
+func (store *Store) VerifyAuditLog(ctx context.Context, pageSize int32, checkpoints ...AuditCheckpoint) (VerifyAuditLogResult, error) {
+	var result VerifyAuditLogResult
+	if pageSize <= 0 {
+		return result, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+
+	pending := make(map[int64]string, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		pending[checkpoint.ID] = checkpoint.Hash
+	}
+
+	for {
+		logs, err := store.ListAuditLogs(ctx, ListAuditLogsParams{
+			AfterID:    result.Last.ID,
+			LimitCount: pageSize,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		if _, err := VerifyAuditChain(result.Last.Hash, logs); err != nil {
+			return result, err
+		}
+
+		for _, log := range logs {
+			if hash, ok := pending[log.ID]; ok {
+				if hash != log.Hash {
+					return result, &AuditChainError{ID: log.ID, Reason: "hash does not match the checkpoint"}
+				}
+				delete(pending, log.ID)
+			}
+			result.Rows++
+			result.Last = AuditCheckpoint{ID: log.ID, Hash: log.Hash}
+		}
+
+		if len(logs) < int(pageSize) {
+			break
+		}
+	}
+
+	for id := range pending {
+		return result, &AuditChainError{ID: id, Reason: "checkpoint row is missing, rows were deleted"}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,238 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: audit_log.sql
+
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const createAuditLog = `-- name: CreateAuditLog :one
+INSERT INTO audit_log (
+  actor, action, entity_type, entity_id, before, after, request_id, prev_hash, hash, created_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+RETURNING id, actor, action, entity_type, entity_id, before, after, request_id, prev_hash, hash, created_at
+`
+
+type CreateAuditLogParams struct {
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestID  string          `json:"request_id"`
+	PrevHash   string          `json:"prev_hash"`
+	Hash       string          `json:"hash"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error) {
+	row := q.queryRow(ctx, q.createAuditLogStmt, createAuditLog,
+		arg.Actor,
+		arg.Action,
+		arg.EntityType,
+		arg.EntityID,
+		arg.Before,
+		arg.After,
+		arg.RequestID,
+		arg.PrevHash,
+		arg.Hash,
+		arg.CreatedAt,
+	)
+	var i AuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.Actor,
+		&i.Action,
+		&i.EntityType,
+		&i.EntityID,
+		&i.Before,
+		&i.After,
+		&i.RequestID,
+		&i.PrevHash,
+		&i.Hash,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createAuditSubjectKey = `-- name: CreateAuditSubjectKey :exec
+INSERT INTO audit_subject_keys (
+  subject, key
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (subject) DO NOTHING
+`
+
+type CreateAuditSubjectKeyParams struct {
+	Subject string `json:"subject"`
+	Key     []byte `json:"key"`
+}
+
+// 另一個事務同時建了同一個 subject 的話，等它 Commit 之後什麼都不做，再用 GetAuditSubjectKey 讀它的 key
+func (q *Queries) CreateAuditSubjectKey(ctx context.Context, arg CreateAuditSubjectKeyParams) error {
+	_, err := q.exec(ctx, q.createAuditSubjectKeyStmt, createAuditSubjectKey, arg.Subject, arg.Key)
+	return err
+}
+
+const deleteAuditSubjectKey = `-- name: DeleteAuditSubjectKey :execrows
+DELETE FROM audit_subject_keys
+WHERE subject = $1
+`
+
+func (q *Queries) DeleteAuditSubjectKey(ctx context.Context, subject string) (int64, error) {
+	result, err := q.exec(ctx, q.deleteAuditSubjectKeyStmt, deleteAuditSubjectKey, subject)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAuditSubjectKey = `-- name: GetAuditSubjectKey :one
+SELECT key FROM audit_subject_keys
+WHERE subject = $1
+`
+
+func (q *Queries) GetAuditSubjectKey(ctx context.Context, subject string) ([]byte, error) {
+	row := q.queryRow(ctx, q.getAuditSubjectKeyStmt, getAuditSubjectKey, subject)
+	var key []byte
+	err := row.Scan(&key)
+	return key, err
+}
+
+const getLastAuditLog = `-- name: GetLastAuditLog :one
+SELECT id, actor, action, entity_type, entity_id, before, after, request_id, prev_hash, hash, created_at FROM audit_log
+ORDER BY id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastAuditLog(ctx context.Context) (AuditLog, error) {
+	row := q.queryRow(ctx, q.getLastAuditLogStmt, getLastAuditLog)
+	var i AuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.Actor,
+		&i.Action,
+		&i.EntityType,
+		&i.EntityID,
+		&i.Before,
+		&i.After,
+		&i.RequestID,
+		&i.PrevHash,
+		&i.Hash,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAuditLogs = `-- name: ListAuditLogs :many
+SELECT id, actor, action, entity_type, entity_id, before, after, request_id, prev_hash, hash, created_at FROM audit_log
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListAuditLogsParams struct {
+	AfterID    int64 `json:"after_id"`
+	LimitCount int32 `json:"limit_count"`
+}
+
+func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error) {
+	rows, err := q.query(ctx, q.listAuditLogsStmt, listAuditLogs, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Actor,
+			&i.Action,
+			&i.EntityType,
+			&i.EntityID,
+			&i.Before,
+			&i.After,
+			&i.RequestID,
+			&i.PrevHash,
+			&i.Hash,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditLogsByEntity = `-- name: ListAuditLogsByEntity :many
+SELECT id, actor, action, entity_type, entity_id, before, after, request_id, prev_hash, hash, created_at FROM audit_log
+WHERE entity_type = $1 AND entity_id = $2
+ORDER BY id
+`
+
+type ListAuditLogsByEntityParams struct {
+	EntityType string `json:"entity_type"`
+	EntityID   string `json:"entity_id"`
+}
+
+func (q *Queries) ListAuditLogsByEntity(ctx context.Context, arg ListAuditLogsByEntityParams) ([]AuditLog, error) {
+	rows, err := q.query(ctx, q.listAuditLogsByEntityStmt, listAuditLogsByEntity, arg.EntityType, arg.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Actor,
+			&i.Action,
+			&i.EntityType,
+			&i.EntityID,
+			&i.Before,
+			&i.After,
+			&i.RequestID,
+			&i.PrevHash,
+			&i.Hash,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockAuditLog = `-- name: LockAuditLog :exec
+SELECT pg_advisory_xact_lock(hashtext('audit_log'))
+`
+
+// 交易層級的 advisory lock，Commit / Rollback 時自動釋放；
+// 拿到鎖之後才讀最後一筆 hash 再寫入，同時寫 audit log 的交易會排隊，鏈不會分岔
+func (q *Queries) LockAuditLog(ctx context.Context) error {
+	_, err := q.exec(ctx, q.lockAuditLogStmt, lockAuditLog)
+	return err
+}
@@ -0,0 +1,208 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// auditHashPrefix 開頭的值是 audit log 裡換掉個資的 keyed hash
+const auditHashPrefix = "hmac:"
+
+// auditSubjectFields 是標出個資主體的欄位，一個 JSON object 裡依序取第一個有值的
+var auditSubjectFields = []string{"owner", "username"}
+
+// auditPersonalFields 是和主體同一個 object 裡要換成 keyed hash 的欄位
+var auditPersonalFields = []string{"owner", "username", "full_name", "email", "name", "normalized_name", "screening_normalized_name"}
+
+// AuditSubjectKeyQuerier reads and creates the keys that hash the personal data of the audit log,
+// GetAuditSubjectKey returns sql.ErrNoRows when the subject has no key yet
+type AuditSubjectKeyQuerier interface {
+	GetAuditSubjectKey(ctx context.Context, subject string) ([]byte, error)
+	CreateAuditSubjectKey(ctx context.Context, arg CreateAuditSubjectKeyParams) error
+}
+
+// AuditRedactor replaces the personal data written to the audit log with keyed hashes: the username, full name and email of users,
+// the owner of accounts, webhook endpoints and transfer limits, and the screened name of screening results.
+// Each owner has its own HMAC key in audit_subject_keys; AnonymizeOwnerTx deletes it, so the hashes no longer lead back
+// to the owner while the audit log and its hash chain stay as they were written.
+// Keys are created on first use, so a transaction redacts everything before it takes the audit log lock.
+type AuditRedactor struct {
+	q    AuditSubjectKeyQuerier
+	keys map[string][]byte
+}
+
+// NewAuditRedactor creates an AuditRedactor that reads and creates the keys through q, within its transaction
+func NewAuditRedactor(q AuditSubjectKeyQuerier) *AuditRedactor {
+	return &AuditRedactor{q: q, keys: map[string][]byte{}}
+}
+
+// Subject returns the keyed hash recorded for subject, e.g. as the entity ID of a user.
+// System accounts and anonymized owners are not personal data and are returned as they are.
+// The key stays loaded, a later Redact of the same subject does not touch the database.
+func (redactor *AuditRedactor) Subject(ctx context.Context, subject string) (string, error) {
+	return redactor.hash(ctx, subject, subject)
+}
+
+// Actor returns the actor of ctx as recorded in the audit log: the keyed hash of a user set with WithUserActor, otherwise ActorFromContext
+func (redactor *AuditRedactor) Actor(ctx context.Context) (string, error) {
+	actor := ActorFromContext(ctx)
+	if isUser, _ := ctx.Value(userActorContextKey).(bool); !isUser {
+		return actor, nil
+	}
+	return redactor.Subject(ctx, actor)
+}
+
+// Redact marshals v to JSON with its personal data replaced by keyed hashes
+func (redactor *AuditRedactor) Redact(ctx context.Context, v any) (json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// UseNumber 才不會把 bigint 轉成 float64 弄丟精度
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var node any
+	if err := decoder.Decode(&node); err != nil {
+		return nil, err
+	}
+
+	changed, err := redactor.redactNode(ctx, node)
+	if err != nil || !changed {
+		return data, err
+	}
+	return json.Marshal(node)
+}
+
+// redactNode 就地換掉 node 裡的個資，changed 表示有沒有換
+func (redactor *AuditRedactor) redactNode(ctx context.Context, node any) (changed bool, err error) {
+	switch node := node.(type) {
+	case map[string]any:
+		if subject := auditSubject(node); subject != "" {
+			for _, field := range auditPersonalFields {
+				fieldChanged, err := redactor.redactField(ctx, node, field, subject)
+				if err != nil {
+					return changed, err
+				}
+				changed = changed || fieldChanged
+			}
+		}
+		for _, child := range node {
+			childChanged, err := redactor.redactNode(ctx, child)
+			if err != nil {
+				return changed, err
+			}
+			changed = changed || childChanged
+		}
+	case []any:
+		for _, child := range node {
+			childChanged, err := redactor.redactNode(ctx, child)
+			if err != nil {
+				return changed, err
+			}
+			changed = changed || childChanged
+		}
+	}
+	return changed, nil
+}
+
+// redactField 換掉 object 的一個欄位，sql.NullString 序列化成 {"String": ..., "Valid": ...}，換的是裡面的 String
+func (redactor *AuditRedactor) redactField(ctx context.Context, object map[string]any, field string, subject string) (bool, error) {
+	target, key := object, field
+	if nested, ok := object[field].(map[string]any); ok {
+		target, key = nested, "String"
+	}
+	value, ok := target[key].(string)
+	if !ok || value == "" {
+		return false, nil
+	}
+
+	hashed, err := redactor.hash(ctx, subject, value)
+	if err != nil || hashed == value {
+		return false, err
+	}
+	target[key] = hashed
+	return true, nil
+}
+
+// hash 用 subject 的 key 算 value 的 HMAC
+func (redactor *AuditRedactor) hash(ctx context.Context, subject string, value string) (string, error) {
+	if !isPersonalSubject(subject) {
+		return value, nil
+	}
+	key, err := redactor.key(ctx, subject)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return auditHashPrefix + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// key 讀 subject 的 key，還沒有的話建一把；同時建的事務會等先建的 Commit，再讀它的 key
+func (redactor *AuditRedactor) key(ctx context.Context, subject string) ([]byte, error) {
+	if key, ok := redactor.keys[subject]; ok {
+		return key, nil
+	}
+
+	key, err := redactor.q.GetAuditSubjectKey(ctx, subject)
+	if errors.Is(err, sql.ErrNoRows) {
+		newKey := make([]byte, 32)
+		if _, err := rand.Read(newKey); err != nil {
+			return nil, err
+		}
+		if err := redactor.q.CreateAuditSubjectKey(ctx, CreateAuditSubjectKeyParams{Subject: subject, Key: newKey}); err != nil {
+			return nil, err
+		}
+		key, err = redactor.q.GetAuditSubjectKey(ctx, subject)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	redactor.keys[subject] = key
+	return key, nil
+}
+
+// auditSubject 回傳 object 的個資主體：owner 或 username；screening_results 沒有 owner，被篩查的 name 就是 owner
+func auditSubject(object map[string]any) string {
+	for _, field := range auditSubjectFields {
+		if subject := jsonString(object[field]); subject != "" {
+			return subject
+		}
+	}
+	if _, ok := object["normalized_name"]; ok {
+		return jsonString(object["name"])
+	}
+	return ""
+}
+
+// jsonString 取出 JSON 字串，或 sql.NullString 序列化後的 String
+func jsonString(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case map[string]any:
+		if valid, _ := value["Valid"].(bool); valid {
+			s, _ := value["String"].(string)
+			return s
+		}
+	}
+	return ""
+}
+
+// isPersonalSubject 排除系統帳戶、已經匿名化的 owner 和已經換成 hash 的值
+func isPersonalSubject(subject string) bool {
+	return subject != "" &&
+		!strings.HasPrefix(subject, systemAccountOwner("")) &&
+		!strings.HasPrefix(subject, pseudonymPrefix) &&
+		!strings.HasPrefix(subject, auditHashPrefix)
+}
@@ -0,0 +1,269 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// buildAuditChain builds n chained audit log rows in memory
+func buildAuditChain(n int) []AuditLog {
+	logs := make([]AuditLog, n)
+	var prevHash string
+	for i := range logs {
+		logs[i] = AuditLog{
+			ID:         int64(i + 1),
+			Actor:      "alice",
+			Action:     AuditActionUpdate,
+			EntityType: AuditEntityAccount,
+			EntityID:   strconv.Itoa(i),
+			Before:     json.RawMessage(`{"balance": 10}`),
+			After:      json.RawMessage(`{"balance": 20}`),
+			RequestID:  "req",
+			PrevHash:   prevHash,
+			CreatedAt:  time.Date(2025, 1, 1, 0, 0, i, 1000, time.UTC),
+		}
+		logs[i].Hash = AuditHash(logs[i])
+		prevHash = logs[i].Hash
+	}
+	return logs
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+	logs := buildAuditChain(5)
+
+	last, err := VerifyAuditChain("", logs)
+	require.NoError(t, err)
+	require.Equal(t, logs[4].Hash, last)
+
+	// 分頁驗證：第二頁接著第一頁的最後一個 hash
+	last, err = VerifyAuditChain("", logs[:2])
+	require.NoError(t, err)
+	_, err = VerifyAuditChain(last, logs[2:])
+	require.NoError(t, err)
+}
+
+func TestVerifyAuditChainModified(t *testing.T) {
+	logs := buildAuditChain(5)
+	logs[2].After = json.RawMessage(`{"balance": 2000}`)
+
+	_, err := VerifyAuditChain("", logs)
+	var chainErr *AuditChainError
+	require.ErrorAs(t, err, &chainErr)
+	require.Equal(t, int64(3), chainErr.ID)
+
+	// 連 hash 一起改掉，下一筆的 prev_hash 會對不上
+	logs[2].Hash = AuditHash(logs[2])
+	_, err = VerifyAuditChain("", logs)
+	require.ErrorAs(t, err, &chainErr)
+	require.Equal(t, int64(4), chainErr.ID)
+}
+
+func TestVerifyAuditChainDeleted(t *testing.T) {
+	logs := buildAuditChain(5)
+
+	_, err := VerifyAuditChain("", append(append([]AuditLog{}, logs[:2]...), logs[3:]...))
+	var chainErr *AuditChainError
+	require.ErrorAs(t, err, &chainErr)
+	require.Equal(t, int64(4), chainErr.ID)
+
+	// 刪掉第一筆
+	_, err = VerifyAuditChain("", logs[1:])
+	require.ErrorAs(t, err, &chainErr)
+	require.Equal(t, int64(2), chainErr.ID)
+}
+
+func TestAuditHashCreatedAtLocation(t *testing.T) {
+	log := buildAuditChain(1)[0]
+	// 從資料庫讀回來的時間可能在別的時區，hash 要一樣
+	log.CreatedAt = log.CreatedAt.In(time.FixedZone("UTC+8", 8*60*60))
+	require.Equal(t, log.Hash, AuditHash(log))
+}
+
+// fakeAuditSubjectKeys 是記憶體裡的 audit_subject_keys
+type fakeAuditSubjectKeys map[string][]byte
+
+func (keys fakeAuditSubjectKeys) GetAuditSubjectKey(ctx context.Context, subject string) ([]byte, error) {
+	key, ok := keys[subject]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return key, nil
+}
+
+func (keys fakeAuditSubjectKeys) CreateAuditSubjectKey(ctx context.Context, arg CreateAuditSubjectKeyParams) error {
+	if _, ok := keys[arg.Subject]; !ok {
+		keys[arg.Subject] = arg.Key
+	}
+	return nil
+}
+
+func TestAuditRedactor(t *testing.T) {
+	ctx := context.Background()
+	keys := fakeAuditSubjectKeys{}
+	redactor := NewAuditRedactor(keys)
+
+	result := TransferTxResult{
+		FromAccount: Account{ID: 1, Owner: "alice", Balance: 1<<62 + 1},
+		ToAccount:   Account{ID: 2, Owner: systemAccountOwner("clearing")},
+	}
+	after, err := redactor.Redact(ctx, result)
+	require.NoError(t, err)
+	require.NotContains(t, string(after), "alice")
+	// system 帳戶不是個資，大數字也不能變成浮點數
+	require.Contains(t, string(after), systemAccountOwner("clearing"))
+
+	var redacted TransferTxResult
+	require.NoError(t, json.Unmarshal(after, &redacted))
+	require.Equal(t, result.FromAccount.Balance, redacted.FromAccount.Balance)
+	alice, err := redactor.Subject(ctx, "alice")
+	require.NoError(t, err)
+	require.Equal(t, alice, redacted.FromAccount.Owner)
+
+	user := User{Username: "alice", FullName: "Alice Liddell", Email: "alice@example.com"}
+	after, err = redactor.Redact(ctx, user)
+	require.NoError(t, err)
+	for _, value := range []string{user.Username, user.FullName, user.Email} {
+		require.NotContains(t, string(after), value)
+	}
+
+	limit := TransferLimit{Owner: sql.NullString{String: "alice", Valid: true}}
+	after, err = redactor.Redact(ctx, limit)
+	require.NoError(t, err)
+	require.NotContains(t, string(after), "alice")
+
+	screening := ScreeningResult{Name: "Alice", NormalizedName: "alice", MatchedName: "ALICE LIST"}
+	after, err = redactor.Redact(ctx, screening)
+	require.NoError(t, err)
+	require.NotContains(t, string(after), `"alice"`)
+	require.NotContains(t, string(after), `"Alice"`)
+	require.Contains(t, string(after), screening.MatchedName)
+
+	actor, err := redactor.Actor(WithUserActor(ctx, "alice"))
+	require.NoError(t, err)
+	require.Equal(t, alice, actor)
+	actor, err = redactor.Actor(WithActor(ctx, "compliance@example.com"))
+	require.NoError(t, err)
+	require.Equal(t, "compliance@example.com", actor)
+
+	// 刪掉 key 之後同一個 owner 算出來的 hash 就不一樣了
+	delete(keys, "alice")
+	shredded, err := NewAuditRedactor(keys).Subject(ctx, "alice")
+	require.NoError(t, err)
+	require.NotEqual(t, alice, shredded)
+}
+
+func TestStoreAuditLog(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := WithRequestID(WithActor(context.Background(), "operator"), "req-"+util.RandomString(8, false))
+
+	account, err := store.CreateAccount(ctx, CreateAccountParams{
+		Owner:    util.RandomOwner(),
+		Balance:  util.RandomMoney(),
+		Currency: util.RandomCurrency(),
+	})
+	require.NoError(t, err)
+
+	adjusted, err := store.AdjustBalanceTx(ctx, AdjustBalanceTxParams{
+		AccountID:  account.ID,
+		Balance:    account.Balance + 10,
+		ReasonCode: AdjustmentReasonCorrection,
+		Operator:   "operator",
+	})
+	require.NoError(t, err)
+
+	logs, err := store.ListAuditLogsByEntity(context.Background(), ListAuditLogsByEntityParams{
+		EntityType: AuditEntityAccount,
+		EntityID:   strconv.FormatInt(account.ID, 10),
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+
+	require.Equal(t, AuditActionCreate, logs[0].Action)
+	require.Equal(t, "operator", logs[0].Actor)
+	require.Equal(t, RequestIDFromContext(ctx), logs[0].RequestID)
+	require.JSONEq(t, "null", string(logs[0].Before))
+
+	require.Equal(t, AuditActionAdjustBalance, logs[1].Action)
+	var before Account
+	var after AdjustBalanceTxResult
+	require.NoError(t, json.Unmarshal(logs[1].Before, &before))
+	require.NoError(t, json.Unmarshal(logs[1].After, &after))
+	require.Equal(t, account.Balance, before.Balance)
+	require.Equal(t, adjusted.Account.Balance, after.Account.Balance)
+
+	for _, log := range logs {
+		require.Equal(t, AuditHash(log), log.Hash)
+	}
+}
+
+func TestStoreAuditLogTransfer(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	logs, err := store.ListAuditLogsByEntity(context.Background(), ListAuditLogsByEntityParams{
+		EntityType: AuditEntityTransfer,
+		EntityID:   strconv.FormatInt(result.Transfer.ID, 10),
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, SystemActor, logs[0].Actor)
+}
+
+func TestAuditLogAppendOnly(t *testing.T) {
+	store := NewStore(testDB)
+	_, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    util.RandomOwner(),
+		Balance:  util.RandomMoney(),
+		Currency: util.RandomCurrency(),
+	})
+	require.NoError(t, err)
+
+	last, err := testQueries.GetLastAuditLog(context.Background())
+	require.NoError(t, err)
+
+	_, err = testDB.Exec("UPDATE audit_log SET actor = 'mallory' WHERE id = $1", last.ID)
+	require.ErrorContains(t, err, "append-only")
+
+	_, err = testDB.Exec("DELETE FROM audit_log WHERE id = $1", last.ID)
+	require.ErrorContains(t, err, "append-only")
+}
+
+func TestVerifyAuditLog(t *testing.T) {
+	store := NewStore(testDB)
+
+	for i := 0; i < 3; i++ {
+		_, err := store.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    util.RandomOwner(),
+			Balance:  util.RandomMoney(),
+			Currency: util.RandomCurrency(),
+		})
+		require.NoError(t, err)
+	}
+
+	result, err := store.VerifyAuditLog(context.Background(), 2)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, result.Rows, int64(3))
+
+	_, err = store.VerifyAuditLog(context.Background(), 100, result.Last)
+	require.NoError(t, err)
+
+	_, err = store.VerifyAuditLog(context.Background(), 100, AuditCheckpoint{ID: result.Last.ID, Hash: "bad"})
+	var chainErr *AuditChainError
+	require.ErrorAs(t, err, &chainErr)
+	require.Equal(t, result.Last.ID, chainErr.ID)
+}
@@ -0,0 +1,178 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: balance_adjustment.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createBalanceAdjustment = `-- name: CreateBalanceAdjustment :one
+INSERT INTO balance_adjustments (
+  account_id, suspense_account_id, amount, reason_code, operator, note, entry_id, suspense_entry_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING id, account_id, suspense_account_id, amount, reason_code, operator, note, entry_id, suspense_entry_id, created_at
+`
+
+type CreateBalanceAdjustmentParams struct {
+	AccountID         int64  `json:"account_id"`
+	SuspenseAccountID int64  `json:"suspense_account_id"`
+	Amount            int64  `json:"amount"`
+	ReasonCode        string `json:"reason_code"`
+	Operator          string `json:"operator"`
+	Note              string `json:"note"`
+	EntryID           int64  `json:"entry_id"`
+	SuspenseEntryID   int64  `json:"suspense_entry_id"`
+}
+
+func (q *Queries) CreateBalanceAdjustment(ctx context.Context, arg CreateBalanceAdjustmentParams) (BalanceAdjustment, error) {
+	row := q.queryRow(ctx, q.createBalanceAdjustmentStmt, createBalanceAdjustment,
+		arg.AccountID,
+		arg.SuspenseAccountID,
+		arg.Amount,
+		arg.ReasonCode,
+		arg.Operator,
+		arg.Note,
+		arg.EntryID,
+		arg.SuspenseEntryID,
+	)
+	var i BalanceAdjustment
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.SuspenseAccountID,
+		&i.Amount,
+		&i.ReasonCode,
+		&i.Operator,
+		&i.Note,
+		&i.EntryID,
+		&i.SuspenseEntryID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createSystemAccount = `-- name: CreateSystemAccount :one
+INSERT INTO system_accounts (
+  purpose, currency, account_id
+) VALUES (
+  $1, $2, $3
+)
+RETURNING purpose, currency, account_id, created_at
+`
+
+type CreateSystemAccountParams struct {
+	Purpose   string `json:"purpose"`
+	Currency  string `json:"currency"`
+	AccountID int64  `json:"account_id"`
+}
+
+func (q *Queries) CreateSystemAccount(ctx context.Context, arg CreateSystemAccountParams) (SystemAccount, error) {
+	row := q.queryRow(ctx, q.createSystemAccountStmt, createSystemAccount, arg.Purpose, arg.Currency, arg.AccountID)
+	var i SystemAccount
+	err := row.Scan(
+		&i.Purpose,
+		&i.Currency,
+		&i.AccountID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getSystemAccount = `-- name: GetSystemAccount :one
+SELECT accounts.id, accounts.owner, accounts.balance, accounts.currency, accounts.created_at, accounts.product_id, accounts.overdraft_limit, accounts.overdraft_rate_bps, accounts.overdraft_fee, accounts.closed_at, accounts.account_number FROM system_accounts
+JOIN accounts ON accounts.id = system_accounts.account_id
+WHERE system_accounts.purpose = $1 AND system_accounts.currency = $2
+LIMIT 1
+`
+
+type GetSystemAccountParams struct {
+	Purpose  string `json:"purpose"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (Account, error) {
+	row := q.queryRow(ctx, q.getSystemAccountStmt, getSystemAccount, arg.Purpose, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.ProductID,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.OverdraftFee,
+		&i.ClosedAt,
+		&i.AccountNumber,
+	)
+	return i, err
+}
+
+const listBalanceAdjustments = `-- name: ListBalanceAdjustments :many
+SELECT id, account_id, suspense_account_id, amount, reason_code, operator, note, entry_id, suspense_entry_id, created_at FROM balance_adjustments
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListBalanceAdjustmentsParams struct {
+	AccountID int64 `json:"account_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+func (q *Queries) ListBalanceAdjustments(ctx context.Context, arg ListBalanceAdjustmentsParams) ([]BalanceAdjustment, error) {
+	rows, err := q.query(ctx, q.listBalanceAdjustmentsStmt, listBalanceAdjustments, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []BalanceAdjustment{}
+	for rows.Next() {
+		var i BalanceAdjustment
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.SuspenseAccountID,
+			&i.Amount,
+			&i.ReasonCode,
+			&i.Operator,
+			&i.Note,
+			&i.EntryID,
+			&i.SuspenseEntryID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockSystemAccount = `-- name: LockSystemAccount :exec
+SELECT pg_advisory_xact_lock(hashtext('system_accounts'), hashtext($1::text || ':' || $2::text))
+`
+
+type LockSystemAccountParams struct {
+	Purpose  string `json:"purpose"`
+	Currency string `json:"currency"`
+}
+
+// 建立系統帳戶前先拿 advisory lock，同時第一次用到同一個系統帳戶的事務不會各建一個
+func (q *Queries) LockSystemAccount(ctx context.Context, arg LockSystemAccountParams) error {
+	_, err := q.exec(ctx, q.lockSystemAccountStmt, lockSystemAccount, arg.Purpose, arg.Currency)
+	return err
+}
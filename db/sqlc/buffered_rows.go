@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+)
+
+// bufferedRows 是已經讀完的查詢結果。*sql.Rows 和 *sql.Row 是 struct，DBTX 沒辦法包一層在 Close 時結束 span，
+// 所以 tracingDBTX 先把結果讀完、記下筆數，再透過 replayDB 交還一個一樣的 *sql.Rows 給 sqlc 產生的程式碼
+type bufferedRows struct {
+	columns []string
+	values  [][]driver.Value
+	// err 是查詢本身或讀到一半的錯誤，replay 的 rows 讀完之後由 Err 回傳
+	err error
+}
+
+// readRows 讀完並關掉 rows；Scan 到 *any 拿到的是 driver 原本的值，交還之後轉型和直接讀一樣
+func readRows(rows *sql.Rows) *bufferedRows {
+	defer rows.Close()
+
+	buffered := &bufferedRows{}
+	buffered.columns, buffered.err = rows.Columns()
+	if buffered.err != nil {
+		return buffered
+	}
+
+	for rows.Next() {
+		values := make([]any, len(buffered.columns))
+		dest := make([]any, len(values))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			buffered.err = err
+			return buffered
+		}
+
+		row := make([]driver.Value, len(values))
+		for i, value := range values {
+			row[i] = value
+		}
+		buffered.values = append(buffered.values, row)
+	}
+	if err := rows.Close(); err != nil {
+		buffered.err = err
+		return buffered
+	}
+	buffered.err = rows.Err()
+	return buffered
+}
+
+// replayDB 把 bufferedRows 原樣交還給 database/sql，不連任何資料庫
+var replayDB = sql.OpenDB(replayConnector{})
+
+// replay 回傳 buffered 的 *sql.Rows；查詢本身就失敗（沒有欄位）的話回傳它的錯誤
+func (buffered *bufferedRows) replay(ctx context.Context) (*sql.Rows, error) {
+	return replayDB.QueryContext(ctx, "", buffered)
+}
+
+// replayRow 回傳 buffered 的 *sql.Row，沒有資料的話 Scan 一樣是 sql.ErrNoRows
+func (buffered *bufferedRows) replayRow(ctx context.Context) *sql.Row {
+	return replayDB.QueryRowContext(ctx, "", buffered)
+}
+
+type replayConnector struct{}
+
+func (replayConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return replayConn{}, nil
+}
+
+func (replayConnector) Driver() driver.Driver {
+	return replayDriver{}
+}
+
+type replayDriver struct{}
+
+func (replayDriver) Open(name string) (driver.Conn, error) {
+	return replayConn{}, nil
+}
+
+var errReplayOnly = errors.New("replay connection only replays buffered rows")
+
+// replayConn 只實作 QueryerContext，參數就是要交還的 *bufferedRows
+type replayConn struct{}
+
+func (replayConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errReplayOnly
+}
+
+func (replayConn) Close() error {
+	return nil
+}
+
+func (replayConn) Begin() (driver.Tx, error) {
+	return nil, errReplayOnly
+}
+
+// CheckNamedValue 讓 *bufferedRows 不經轉換直接傳給 QueryContext
+func (replayConn) CheckNamedValue(value *driver.NamedValue) error {
+	return nil
+}
+
+func (replayConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if len(args) != 1 {
+		return nil, errReplayOnly
+	}
+	buffered, ok := args[0].Value.(*bufferedRows)
+	if !ok {
+		return nil, errReplayOnly
+	}
+	if buffered.columns == nil && buffered.err != nil {
+		return nil, buffered.err
+	}
+	return &replayRows{buffered: buffered}, nil
+}
+
+type replayRows struct {
+	buffered *bufferedRows
+	next     int
+}
+
+func (rows *replayRows) Columns() []string {
+	return rows.buffered.columns
+}
+
+func (rows *replayRows) Close() error {
+	return nil
+}
+
+func (rows *replayRows) Next(dest []driver.Value) error {
+	if rows.next >= len(rows.buffered.values) {
+		if rows.buffered.err != nil {
+			return rows.buffered.err
+		}
+		return io.EOF
+	}
+	copy(dest, rows.buffered.values[rows.next])
+	rows.next++
+	return nil
+}
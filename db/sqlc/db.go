@@ -7,6 +7,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"fmt"
 )
 
 type DBTX interface {
@@ -20,12 +21,1508 @@ func New(db DBTX) *Queries {
 	return &Queries{db: db}
 }
 
+func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
+	q := Queries{db: db}
+	var err error
+	if q.accountHasLedgerRowsStmt, err = db.PrepareContext(ctx, accountHasLedgerRows); err != nil {
+		return nil, fmt.Errorf("error preparing query AccountHasLedgerRows: %w", err)
+	}
+	if q.allocateImportedAccountIDsStmt, err = db.PrepareContext(ctx, allocateImportedAccountIDs); err != nil {
+		return nil, fmt.Errorf("error preparing query AllocateImportedAccountIDs: %w", err)
+	}
+	if q.anonymizeAccountsOwnerStmt, err = db.PrepareContext(ctx, anonymizeAccountsOwner); err != nil {
+		return nil, fmt.Errorf("error preparing query AnonymizeAccountsOwner: %w", err)
+	}
+	if q.anonymizeOutboxPayloadsStmt, err = db.PrepareContext(ctx, anonymizeOutboxPayloads); err != nil {
+		return nil, fmt.Errorf("error preparing query AnonymizeOutboxPayloads: %w", err)
+	}
+	if q.anonymizeReviewCaseHitsStmt, err = db.PrepareContext(ctx, anonymizeReviewCaseHits); err != nil {
+		return nil, fmt.Errorf("error preparing query AnonymizeReviewCaseHits: %w", err)
+	}
+	if q.anonymizeScreeningResultsStmt, err = db.PrepareContext(ctx, anonymizeScreeningResults); err != nil {
+		return nil, fmt.Errorf("error preparing query AnonymizeScreeningResults: %w", err)
+	}
+	if q.anonymizeTransferLimitsStmt, err = db.PrepareContext(ctx, anonymizeTransferLimits); err != nil {
+		return nil, fmt.Errorf("error preparing query AnonymizeTransferLimits: %w", err)
+	}
+	if q.anonymizeUserStmt, err = db.PrepareContext(ctx, anonymizeUser); err != nil {
+		return nil, fmt.Errorf("error preparing query AnonymizeUser: %w", err)
+	}
+	if q.anonymizeWebhookEndpointsStmt, err = db.PrepareContext(ctx, anonymizeWebhookEndpoints); err != nil {
+		return nil, fmt.Errorf("error preparing query AnonymizeWebhookEndpoints: %w", err)
+	}
+	if q.applyImportedBalancesStmt, err = db.PrepareContext(ctx, applyImportedBalances); err != nil {
+		return nil, fmt.Errorf("error preparing query ApplyImportedBalances: %w", err)
+	}
+	if q.claimDueWebhookDeliveriesStmt, err = db.PrepareContext(ctx, claimDueWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimDueWebhookDeliveries: %w", err)
+	}
+	if q.claimNextTaskStmt, err = db.PrepareContext(ctx, claimNextTask); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimNextTask: %w", err)
+	}
+	if q.closeAccountStmt, err = db.PrepareContext(ctx, closeAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query CloseAccount: %w", err)
+	}
+	if q.completeTaskStmt, err = db.PrepareContext(ctx, completeTask); err != nil {
+		return nil, fmt.Errorf("error preparing query CompleteTask: %w", err)
+	}
+	if q.countTransfersInAmountRangeStmt, err = db.PrepareContext(ctx, countTransfersInAmountRange); err != nil {
+		return nil, fmt.Errorf("error preparing query CountTransfersInAmountRange: %w", err)
+	}
+	if q.createAccountStmt, err = db.PrepareContext(ctx, createAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAccount: %w", err)
+	}
+	if q.createAccountImportStmt, err = db.PrepareContext(ctx, createAccountImport); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAccountImport: %w", err)
+	}
+	if q.createAccountProductStmt, err = db.PrepareContext(ctx, createAccountProduct); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAccountProduct: %w", err)
+	}
+	if q.createAuditLogStmt, err = db.PrepareContext(ctx, createAuditLog); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAuditLog: %w", err)
+	}
+	if q.createAuditSubjectKeyStmt, err = db.PrepareContext(ctx, createAuditSubjectKey); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAuditSubjectKey: %w", err)
+	}
+	if q.createBalanceAdjustmentStmt, err = db.PrepareContext(ctx, createBalanceAdjustment); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateBalanceAdjustment: %w", err)
+	}
+	if q.createEntryStmt, err = db.PrepareContext(ctx, createEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateEntry: %w", err)
+	}
+	if q.createExternalTransferStmt, err = db.PrepareContext(ctx, createExternalTransfer); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateExternalTransfer: %w", err)
+	}
+	if q.createInterestAccrualStmt, err = db.PrepareContext(ctx, createInterestAccrual); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateInterestAccrual: %w", err)
+	}
+	if q.createInterestPostingStmt, err = db.PrepareContext(ctx, createInterestPosting); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateInterestPosting: %w", err)
+	}
+	if q.createLedgerPartitionsStmt, err = db.PrepareContext(ctx, createLedgerPartitions); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateLedgerPartitions: %w", err)
+	}
+	if q.createOutboxEventStmt, err = db.PrepareContext(ctx, createOutboxEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateOutboxEvent: %w", err)
+	}
+	if q.createOverdraftChargeStmt, err = db.PrepareContext(ctx, createOverdraftCharge); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateOverdraftCharge: %w", err)
+	}
+	if q.createPaymentInstructionStmt, err = db.PrepareContext(ctx, createPaymentInstruction); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePaymentInstruction: %w", err)
+	}
+	if q.createRejectedReviewCaseStmt, err = db.PrepareContext(ctx, createRejectedReviewCase); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateRejectedReviewCase: %w", err)
+	}
+	if q.createReviewCaseStmt, err = db.PrepareContext(ctx, createReviewCase); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateReviewCase: %w", err)
+	}
+	if q.createScreeningResultStmt, err = db.PrepareContext(ctx, createScreeningResult); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateScreeningResult: %w", err)
+	}
+	if q.createSystemAccountStmt, err = db.PrepareContext(ctx, createSystemAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateSystemAccount: %w", err)
+	}
+	if q.createTaskStmt, err = db.PrepareContext(ctx, createTask); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTask: %w", err)
+	}
+	if q.createTransferStmt, err = db.PrepareContext(ctx, createTransfer); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTransfer: %w", err)
+	}
+	if q.createUserStmt, err = db.PrepareContext(ctx, createUser); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateUser: %w", err)
+	}
+	if q.createVerifyEmailStmt, err = db.PrepareContext(ctx, createVerifyEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateVerifyEmail: %w", err)
+	}
+	if q.createWebhookDeliveryStmt, err = db.PrepareContext(ctx, createWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhookDelivery: %w", err)
+	}
+	if q.createWebhookDeliveryAttemptStmt, err = db.PrepareContext(ctx, createWebhookDeliveryAttempt); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhookDeliveryAttempt: %w", err)
+	}
+	if q.createWebhookEndpointStmt, err = db.PrepareContext(ctx, createWebhookEndpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhookEndpoint: %w", err)
+	}
+	if q.decidePaymentInstructionStmt, err = db.PrepareContext(ctx, decidePaymentInstruction); err != nil {
+		return nil, fmt.Errorf("error preparing query DecidePaymentInstruction: %w", err)
+	}
+	if q.decideReviewCaseStmt, err = db.PrepareContext(ctx, decideReviewCase); err != nil {
+		return nil, fmt.Errorf("error preparing query DecideReviewCase: %w", err)
+	}
+	if q.deleteAccountStmt, err = db.PrepareContext(ctx, deleteAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAccount: %w", err)
+	}
+	if q.deleteAccountImportRowsStmt, err = db.PrepareContext(ctx, deleteAccountImportRows); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAccountImportRows: %w", err)
+	}
+	if q.deleteAuditSubjectKeyStmt, err = db.PrepareContext(ctx, deleteAuditSubjectKey); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAuditSubjectKey: %w", err)
+	}
+	if q.deleteTransferLimitStmt, err = db.PrepareContext(ctx, deleteTransferLimit); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteTransferLimit: %w", err)
+	}
+	if q.deleteVerifyEmailsByUsernameStmt, err = db.PrepareContext(ctx, deleteVerifyEmailsByUsername); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteVerifyEmailsByUsername: %w", err)
+	}
+	if q.detachLedgerPartitionsStmt, err = db.PrepareContext(ctx, detachLedgerPartitions); err != nil {
+		return nil, fmt.Errorf("error preparing query DetachLedgerPartitions: %w", err)
+	}
+	if q.failExternalTransferStmt, err = db.PrepareContext(ctx, failExternalTransfer); err != nil {
+		return nil, fmt.Errorf("error preparing query FailExternalTransfer: %w", err)
+	}
+	if q.failTaskStmt, err = db.PrepareContext(ctx, failTask); err != nil {
+		return nil, fmt.Errorf("error preparing query FailTask: %w", err)
+	}
+	if q.getAccountStmt, err = db.PrepareContext(ctx, getAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAccount: %w", err)
+	}
+	if q.getAccountByNumberStmt, err = db.PrepareContext(ctx, getAccountByNumber); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAccountByNumber: %w", err)
+	}
+	if q.getAccountForUpdateStmt, err = db.PrepareContext(ctx, getAccountForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAccountForUpdate: %w", err)
+	}
+	if q.getAccountImportStmt, err = db.PrepareContext(ctx, getAccountImport); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAccountImport: %w", err)
+	}
+	if q.getAccountProductStmt, err = db.PrepareContext(ctx, getAccountProduct); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAccountProduct: %w", err)
+	}
+	if q.getAccountTransferLimitStmt, err = db.PrepareContext(ctx, getAccountTransferLimit); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAccountTransferLimit: %w", err)
+	}
+	if q.getAccountTransferUsageStmt, err = db.PrepareContext(ctx, getAccountTransferUsage); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAccountTransferUsage: %w", err)
+	}
+	if q.getAuditSubjectKeyStmt, err = db.PrepareContext(ctx, getAuditSubjectKey); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAuditSubjectKey: %w", err)
+	}
+	if q.getBalanceAtStmt, err = db.PrepareContext(ctx, getBalanceAt); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBalanceAt: %w", err)
+	}
+	if q.getEntryStmt, err = db.PrepareContext(ctx, getEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEntry: %w", err)
+	}
+	if q.getExternalTransferStmt, err = db.PrepareContext(ctx, getExternalTransfer); err != nil {
+		return nil, fmt.Errorf("error preparing query GetExternalTransfer: %w", err)
+	}
+	if q.getExternalTransferByRefStmt, err = db.PrepareContext(ctx, getExternalTransferByRef); err != nil {
+		return nil, fmt.Errorf("error preparing query GetExternalTransferByRef: %w", err)
+	}
+	if q.getExternalTransferForUpdateStmt, err = db.PrepareContext(ctx, getExternalTransferForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetExternalTransferForUpdate: %w", err)
+	}
+	if q.getInterestAccrualStmt, err = db.PrepareContext(ctx, getInterestAccrual); err != nil {
+		return nil, fmt.Errorf("error preparing query GetInterestAccrual: %w", err)
+	}
+	if q.getInterestPostingStmt, err = db.PrepareContext(ctx, getInterestPosting); err != nil {
+		return nil, fmt.Errorf("error preparing query GetInterestPosting: %w", err)
+	}
+	if q.getLastAuditLogStmt, err = db.PrepareContext(ctx, getLastAuditLog); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLastAuditLog: %w", err)
+	}
+	if q.getLastInterestPostingStmt, err = db.PrepareContext(ctx, getLastInterestPosting); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLastInterestPosting: %w", err)
+	}
+	if q.getLastOverdraftChargeStmt, err = db.PrepareContext(ctx, getLastOverdraftCharge); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLastOverdraftCharge: %w", err)
+	}
+	if q.getOutboxEventStmt, err = db.PrepareContext(ctx, getOutboxEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOutboxEvent: %w", err)
+	}
+	if q.getOverdraftChargeStmt, err = db.PrepareContext(ctx, getOverdraftCharge); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOverdraftCharge: %w", err)
+	}
+	if q.getOwnerTransferLimitStmt, err = db.PrepareContext(ctx, getOwnerTransferLimit); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOwnerTransferLimit: %w", err)
+	}
+	if q.getOwnerTransferUsageStmt, err = db.PrepareContext(ctx, getOwnerTransferUsage); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOwnerTransferUsage: %w", err)
+	}
+	if q.getPaymentInstructionByEndToEndIDStmt, err = db.PrepareContext(ctx, getPaymentInstructionByEndToEndID); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPaymentInstructionByEndToEndID: %w", err)
+	}
+	if q.getPaymentInstructionByReviewCaseForUpdateStmt, err = db.PrepareContext(ctx, getPaymentInstructionByReviewCaseForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPaymentInstructionByReviewCaseForUpdate: %w", err)
+	}
+	if q.getReviewCaseStmt, err = db.PrepareContext(ctx, getReviewCase); err != nil {
+		return nil, fmt.Errorf("error preparing query GetReviewCase: %w", err)
+	}
+	if q.getReviewCaseForUpdateStmt, err = db.PrepareContext(ctx, getReviewCaseForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetReviewCaseForUpdate: %w", err)
+	}
+	if q.getSystemAccountStmt, err = db.PrepareContext(ctx, getSystemAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSystemAccount: %w", err)
+	}
+	if q.getTaskStmt, err = db.PrepareContext(ctx, getTask); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTask: %w", err)
+	}
+	if q.getTransferStmt, err = db.PrepareContext(ctx, getTransfer); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTransfer: %w", err)
+	}
+	if q.getUserStmt, err = db.PrepareContext(ctx, getUser); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUser: %w", err)
+	}
+	if q.getWebhookDeliveryStmt, err = db.PrepareContext(ctx, getWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhookDelivery: %w", err)
+	}
+	if q.getWebhookEndpointStmt, err = db.PrepareContext(ctx, getWebhookEndpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhookEndpoint: %w", err)
+	}
+	if q.insertImportedAccountsStmt, err = db.PrepareContext(ctx, insertImportedAccounts); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertImportedAccounts: %w", err)
+	}
+	if q.insertImportedEntriesStmt, err = db.PrepareContext(ctx, insertImportedEntries); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertImportedEntries: %w", err)
+	}
+	if q.insertImportedExternalTransfersStmt, err = db.PrepareContext(ctx, insertImportedExternalTransfers); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertImportedExternalTransfers: %w", err)
+	}
+	if q.insertImportedScreeningResultsStmt, err = db.PrepareContext(ctx, insertImportedScreeningResults); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertImportedScreeningResults: %w", err)
+	}
+	if q.listAccountImportCurrenciesStmt, err = db.PrepareContext(ctx, listAccountImportCurrencies); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAccountImportCurrencies: %w", err)
+	}
+	if q.listAccountsStmt, err = db.PrepareContext(ctx, listAccounts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAccounts: %w", err)
+	}
+	if q.listAccountsByNumbersStmt, err = db.PrepareContext(ctx, listAccountsByNumbers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAccountsByNumbers: %w", err)
+	}
+	if q.listAccountsByOwnerStmt, err = db.PrepareContext(ctx, listAccountsByOwner); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAccountsByOwner: %w", err)
+	}
+	if q.listAccruingAccountsStmt, err = db.PrepareContext(ctx, listAccruingAccounts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAccruingAccounts: %w", err)
+	}
+	if q.listActiveWebhookEndpointsByOwnerStmt, err = db.PrepareContext(ctx, listActiveWebhookEndpointsByOwner); err != nil {
+		return nil, fmt.Errorf("error preparing query ListActiveWebhookEndpointsByOwner: %w", err)
+	}
+	if q.listArchivedEntryTotalsStmt, err = db.PrepareContext(ctx, listArchivedEntryTotals); err != nil {
+		return nil, fmt.Errorf("error preparing query ListArchivedEntryTotals: %w", err)
+	}
+	if q.listArchivedEntryTotalsByAccountsStmt, err = db.PrepareContext(ctx, listArchivedEntryTotalsByAccounts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListArchivedEntryTotalsByAccounts: %w", err)
+	}
+	if q.listAuditLogsStmt, err = db.PrepareContext(ctx, listAuditLogs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAuditLogs: %w", err)
+	}
+	if q.listAuditLogsByEntityStmt, err = db.PrepareContext(ctx, listAuditLogsByEntity); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAuditLogsByEntity: %w", err)
+	}
+	if q.listBalanceAdjustmentsStmt, err = db.PrepareContext(ctx, listBalanceAdjustments); err != nil {
+		return nil, fmt.Errorf("error preparing query ListBalanceAdjustments: %w", err)
+	}
+	if q.listChargeableOverdraftAccountsStmt, err = db.PrepareContext(ctx, listChargeableOverdraftAccounts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListChargeableOverdraftAccounts: %w", err)
+	}
+	if q.listCurrencyImbalancesStmt, err = db.PrepareContext(ctx, listCurrencyImbalances); err != nil {
+		return nil, fmt.Errorf("error preparing query ListCurrencyImbalances: %w", err)
+	}
+	if q.listEntriesStmt, err = db.PrepareContext(ctx, listEntries); err != nil {
+		return nil, fmt.Errorf("error preparing query ListEntries: %w", err)
+	}
+	if q.listEntriesByAccountsStmt, err = db.PrepareContext(ctx, listEntriesByAccounts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListEntriesByAccounts: %w", err)
+	}
+	if q.listExternalTransfersStmt, err = db.PrepareContext(ctx, listExternalTransfers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListExternalTransfers: %w", err)
+	}
+	if q.listExternalTransfersByAccountsStmt, err = db.PrepareContext(ctx, listExternalTransfersByAccounts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListExternalTransfersByAccounts: %w", err)
+	}
+	if q.listInterestPostingsStmt, err = db.PrepareContext(ctx, listInterestPostings); err != nil {
+		return nil, fmt.Errorf("error preparing query ListInterestPostings: %w", err)
+	}
+	if q.listLedgerMismatchesStmt, err = db.PrepareContext(ctx, listLedgerMismatches); err != nil {
+		return nil, fmt.Errorf("error preparing query ListLedgerMismatches: %w", err)
+	}
+	if q.listOutboxEventsByAggregateStmt, err = db.PrepareContext(ctx, listOutboxEventsByAggregate); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOutboxEventsByAggregate: %w", err)
+	}
+	if q.listOverdraftChargesStmt, err = db.PrepareContext(ctx, listOverdraftCharges); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOverdraftCharges: %w", err)
+	}
+	if q.listOverdraftUsageStmt, err = db.PrepareContext(ctx, listOverdraftUsage); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOverdraftUsage: %w", err)
+	}
+	if q.listPaymentInstructionsByMessageStmt, err = db.PrepareContext(ctx, listPaymentInstructionsByMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPaymentInstructionsByMessage: %w", err)
+	}
+	if q.listPendingOutboxEventsStmt, err = db.PrepareContext(ctx, listPendingOutboxEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPendingOutboxEvents: %w", err)
+	}
+	if q.listReviewCasesStmt, err = db.PrepareContext(ctx, listReviewCases); err != nil {
+		return nil, fmt.Errorf("error preparing query ListReviewCases: %w", err)
+	}
+	if q.listScreeningResultsStmt, err = db.PrepareContext(ctx, listScreeningResults); err != nil {
+		return nil, fmt.Errorf("error preparing query ListScreeningResults: %w", err)
+	}
+	if q.listScreeningResultsByAccountStmt, err = db.PrepareContext(ctx, listScreeningResultsByAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query ListScreeningResultsByAccount: %w", err)
+	}
+	if q.listStatementEntriesStmt, err = db.PrepareContext(ctx, listStatementEntries); err != nil {
+		return nil, fmt.Errorf("error preparing query ListStatementEntries: %w", err)
+	}
+	if q.listTransfersStmt, err = db.PrepareContext(ctx, listTransfers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTransfers: %w", err)
+	}
+	if q.listTransfersByAccountsStmt, err = db.PrepareContext(ctx, listTransfersByAccounts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTransfersByAccounts: %w", err)
+	}
+	if q.listWebhookDeliveriesStmt, err = db.PrepareContext(ctx, listWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhookDeliveries: %w", err)
+	}
+	if q.listWebhookDeliveriesByStatusStmt, err = db.PrepareContext(ctx, listWebhookDeliveriesByStatus); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhookDeliveriesByStatus: %w", err)
+	}
+	if q.listWebhookDeliveryAttemptsStmt, err = db.PrepareContext(ctx, listWebhookDeliveryAttempts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhookDeliveryAttempts: %w", err)
+	}
+	if q.listWebhookEndpointsByOwnerStmt, err = db.PrepareContext(ctx, listWebhookEndpointsByOwner); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhookEndpointsByOwner: %w", err)
+	}
+	if q.lockAccountImportsStmt, err = db.PrepareContext(ctx, lockAccountImports); err != nil {
+		return nil, fmt.Errorf("error preparing query LockAccountImports: %w", err)
+	}
+	if q.lockAuditLogStmt, err = db.PrepareContext(ctx, lockAuditLog); err != nil {
+		return nil, fmt.Errorf("error preparing query LockAuditLog: %w", err)
+	}
+	if q.lockSystemAccountStmt, err = db.PrepareContext(ctx, lockSystemAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query LockSystemAccount: %w", err)
+	}
+	if q.lockTransferLimitStmt, err = db.PrepareContext(ctx, lockTransferLimit); err != nil {
+		return nil, fmt.Errorf("error preparing query LockTransferLimit: %w", err)
+	}
+	if q.markInterestAccrualsPostedStmt, err = db.PrepareContext(ctx, markInterestAccrualsPosted); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkInterestAccrualsPosted: %w", err)
+	}
+	if q.markOutboxEventDeliveredStmt, err = db.PrepareContext(ctx, markOutboxEventDelivered); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkOutboxEventDelivered: %w", err)
+	}
+	if q.replayWebhookDeliveryStmt, err = db.PrepareContext(ctx, replayWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query ReplayWebhookDelivery: %w", err)
+	}
+	if q.requeueExpiredTasksStmt, err = db.PrepareContext(ctx, requeueExpiredTasks); err != nil {
+		return nil, fmt.Errorf("error preparing query RequeueExpiredTasks: %w", err)
+	}
+	if q.retryTaskStmt, err = db.PrepareContext(ctx, retryTask); err != nil {
+		return nil, fmt.Errorf("error preparing query RetryTask: %w", err)
+	}
+	if q.setAccountImportAcceptedStmt, err = db.PrepareContext(ctx, setAccountImportAccepted); err != nil {
+		return nil, fmt.Errorf("error preparing query SetAccountImportAccepted: %w", err)
+	}
+	if q.setAccountOverdraftStmt, err = db.PrepareContext(ctx, setAccountOverdraft); err != nil {
+		return nil, fmt.Errorf("error preparing query SetAccountOverdraft: %w", err)
+	}
+	if q.setAccountProductStmt, err = db.PrepareContext(ctx, setAccountProduct); err != nil {
+		return nil, fmt.Errorf("error preparing query SetAccountProduct: %w", err)
+	}
+	if q.setAccountTransferLimitStmt, err = db.PrepareContext(ctx, setAccountTransferLimit); err != nil {
+		return nil, fmt.Errorf("error preparing query SetAccountTransferLimit: %w", err)
+	}
+	if q.setExternalTransferEntriesStmt, err = db.PrepareContext(ctx, setExternalTransferEntries); err != nil {
+		return nil, fmt.Errorf("error preparing query SetExternalTransferEntries: %w", err)
+	}
+	if q.setInterestPostingTransferStmt, err = db.PrepareContext(ctx, setInterestPostingTransfer); err != nil {
+		return nil, fmt.Errorf("error preparing query SetInterestPostingTransfer: %w", err)
+	}
+	if q.setOverdraftChargeTransferStmt, err = db.PrepareContext(ctx, setOverdraftChargeTransfer); err != nil {
+		return nil, fmt.Errorf("error preparing query SetOverdraftChargeTransfer: %w", err)
+	}
+	if q.setOwnerTransferLimitStmt, err = db.PrepareContext(ctx, setOwnerTransferLimit); err != nil {
+		return nil, fmt.Errorf("error preparing query SetOwnerTransferLimit: %w", err)
+	}
+	if q.setWebhookEndpointActiveStmt, err = db.PrepareContext(ctx, setWebhookEndpointActive); err != nil {
+		return nil, fmt.Errorf("error preparing query SetWebhookEndpointActive: %w", err)
+	}
+	if q.settleExternalTransferStmt, err = db.PrepareContext(ctx, settleExternalTransfer); err != nil {
+		return nil, fmt.Errorf("error preparing query SettleExternalTransfer: %w", err)
+	}
+	if q.sumIncomingEntriesStmt, err = db.PrepareContext(ctx, sumIncomingEntries); err != nil {
+		return nil, fmt.Errorf("error preparing query SumIncomingEntries: %w", err)
+	}
+	if q.sumUnpostedInterestAccrualsStmt, err = db.PrepareContext(ctx, sumUnpostedInterestAccruals); err != nil {
+		return nil, fmt.Errorf("error preparing query SumUnpostedInterestAccruals: %w", err)
+	}
+	if q.summarizeOverdraftUsageStmt, err = db.PrepareContext(ctx, summarizeOverdraftUsage); err != nil {
+		return nil, fmt.Errorf("error preparing query SummarizeOverdraftUsage: %w", err)
+	}
+	if q.updateWebhookDeliveryResultStmt, err = db.PrepareContext(ctx, updateWebhookDeliveryResult); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateWebhookDeliveryResult: %w", err)
+	}
+	if q.useVerifyEmailStmt, err = db.PrepareContext(ctx, useVerifyEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query UseVerifyEmail: %w", err)
+	}
+	if q.verifyUserEmailStmt, err = db.PrepareContext(ctx, verifyUserEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query VerifyUserEmail: %w", err)
+	}
+	return &q, nil
+}
+
+func (q *Queries) Close() error {
+	var err error
+	if q.accountHasLedgerRowsStmt != nil {
+		if cerr := q.accountHasLedgerRowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing accountHasLedgerRowsStmt: %w", cerr)
+		}
+	}
+	if q.allocateImportedAccountIDsStmt != nil {
+		if cerr := q.allocateImportedAccountIDsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing allocateImportedAccountIDsStmt: %w", cerr)
+		}
+	}
+	if q.anonymizeAccountsOwnerStmt != nil {
+		if cerr := q.anonymizeAccountsOwnerStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing anonymizeAccountsOwnerStmt: %w", cerr)
+		}
+	}
+	if q.anonymizeOutboxPayloadsStmt != nil {
+		if cerr := q.anonymizeOutboxPayloadsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing anonymizeOutboxPayloadsStmt: %w", cerr)
+		}
+	}
+	if q.anonymizeReviewCaseHitsStmt != nil {
+		if cerr := q.anonymizeReviewCaseHitsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing anonymizeReviewCaseHitsStmt: %w", cerr)
+		}
+	}
+	if q.anonymizeScreeningResultsStmt != nil {
+		if cerr := q.anonymizeScreeningResultsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing anonymizeScreeningResultsStmt: %w", cerr)
+		}
+	}
+	if q.anonymizeTransferLimitsStmt != nil {
+		if cerr := q.anonymizeTransferLimitsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing anonymizeTransferLimitsStmt: %w", cerr)
+		}
+	}
+	if q.anonymizeUserStmt != nil {
+		if cerr := q.anonymizeUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing anonymizeUserStmt: %w", cerr)
+		}
+	}
+	if q.anonymizeWebhookEndpointsStmt != nil {
+		if cerr := q.anonymizeWebhookEndpointsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing anonymizeWebhookEndpointsStmt: %w", cerr)
+		}
+	}
+	if q.applyImportedBalancesStmt != nil {
+		if cerr := q.applyImportedBalancesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing applyImportedBalancesStmt: %w", cerr)
+		}
+	}
+	if q.claimDueWebhookDeliveriesStmt != nil {
+		if cerr := q.claimDueWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimDueWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.claimNextTaskStmt != nil {
+		if cerr := q.claimNextTaskStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimNextTaskStmt: %w", cerr)
+		}
+	}
+	if q.closeAccountStmt != nil {
+		if cerr := q.closeAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing closeAccountStmt: %w", cerr)
+		}
+	}
+	if q.completeTaskStmt != nil {
+		if cerr := q.completeTaskStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing completeTaskStmt: %w", cerr)
+		}
+	}
+	if q.countTransfersInAmountRangeStmt != nil {
+		if cerr := q.countTransfersInAmountRangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countTransfersInAmountRangeStmt: %w", cerr)
+		}
+	}
+	if q.createAccountStmt != nil {
+		if cerr := q.createAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAccountStmt: %w", cerr)
+		}
+	}
+	if q.createAccountImportStmt != nil {
+		if cerr := q.createAccountImportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAccountImportStmt: %w", cerr)
+		}
+	}
+	if q.createAccountProductStmt != nil {
+		if cerr := q.createAccountProductStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAccountProductStmt: %w", cerr)
+		}
+	}
+	if q.createAuditLogStmt != nil {
+		if cerr := q.createAuditLogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAuditLogStmt: %w", cerr)
+		}
+	}
+	if q.createAuditSubjectKeyStmt != nil {
+		if cerr := q.createAuditSubjectKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAuditSubjectKeyStmt: %w", cerr)
+		}
+	}
+	if q.createBalanceAdjustmentStmt != nil {
+		if cerr := q.createBalanceAdjustmentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createBalanceAdjustmentStmt: %w", cerr)
+		}
+	}
+	if q.createEntryStmt != nil {
+		if cerr := q.createEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createEntryStmt: %w", cerr)
+		}
+	}
+	if q.createExternalTransferStmt != nil {
+		if cerr := q.createExternalTransferStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createExternalTransferStmt: %w", cerr)
+		}
+	}
+	if q.createInterestAccrualStmt != nil {
+		if cerr := q.createInterestAccrualStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createInterestAccrualStmt: %w", cerr)
+		}
+	}
+	if q.createInterestPostingStmt != nil {
+		if cerr := q.createInterestPostingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createInterestPostingStmt: %w", cerr)
+		}
+	}
+	if q.createLedgerPartitionsStmt != nil {
+		if cerr := q.createLedgerPartitionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createLedgerPartitionsStmt: %w", cerr)
+		}
+	}
+	if q.createOutboxEventStmt != nil {
+		if cerr := q.createOutboxEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createOutboxEventStmt: %w", cerr)
+		}
+	}
+	if q.createOverdraftChargeStmt != nil {
+		if cerr := q.createOverdraftChargeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createOverdraftChargeStmt: %w", cerr)
+		}
+	}
+	if q.createPaymentInstructionStmt != nil {
+		if cerr := q.createPaymentInstructionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPaymentInstructionStmt: %w", cerr)
+		}
+	}
+	if q.createRejectedReviewCaseStmt != nil {
+		if cerr := q.createRejectedReviewCaseStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createRejectedReviewCaseStmt: %w", cerr)
+		}
+	}
+	if q.createReviewCaseStmt != nil {
+		if cerr := q.createReviewCaseStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createReviewCaseStmt: %w", cerr)
+		}
+	}
+	if q.createScreeningResultStmt != nil {
+		if cerr := q.createScreeningResultStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createScreeningResultStmt: %w", cerr)
+		}
+	}
+	if q.createSystemAccountStmt != nil {
+		if cerr := q.createSystemAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createSystemAccountStmt: %w", cerr)
+		}
+	}
+	if q.createTaskStmt != nil {
+		if cerr := q.createTaskStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTaskStmt: %w", cerr)
+		}
+	}
+	if q.createTransferStmt != nil {
+		if cerr := q.createTransferStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTransferStmt: %w", cerr)
+		}
+	}
+	if q.createUserStmt != nil {
+		if cerr := q.createUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createUserStmt: %w", cerr)
+		}
+	}
+	if q.createVerifyEmailStmt != nil {
+		if cerr := q.createVerifyEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createVerifyEmailStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookDeliveryStmt != nil {
+		if cerr := q.createWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookDeliveryAttemptStmt != nil {
+		if cerr := q.createWebhookDeliveryAttemptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookDeliveryAttemptStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookEndpointStmt != nil {
+		if cerr := q.createWebhookEndpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookEndpointStmt: %w", cerr)
+		}
+	}
+	if q.decidePaymentInstructionStmt != nil {
+		if cerr := q.decidePaymentInstructionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing decidePaymentInstructionStmt: %w", cerr)
+		}
+	}
+	if q.decideReviewCaseStmt != nil {
+		if cerr := q.decideReviewCaseStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing decideReviewCaseStmt: %w", cerr)
+		}
+	}
+	if q.deleteAccountStmt != nil {
+		if cerr := q.deleteAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAccountStmt: %w", cerr)
+		}
+	}
+	if q.deleteAccountImportRowsStmt != nil {
+		if cerr := q.deleteAccountImportRowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAccountImportRowsStmt: %w", cerr)
+		}
+	}
+	if q.deleteAuditSubjectKeyStmt != nil {
+		if cerr := q.deleteAuditSubjectKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAuditSubjectKeyStmt: %w", cerr)
+		}
+	}
+	if q.deleteTransferLimitStmt != nil {
+		if cerr := q.deleteTransferLimitStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteTransferLimitStmt: %w", cerr)
+		}
+	}
+	if q.deleteVerifyEmailsByUsernameStmt != nil {
+		if cerr := q.deleteVerifyEmailsByUsernameStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteVerifyEmailsByUsernameStmt: %w", cerr)
+		}
+	}
+	if q.detachLedgerPartitionsStmt != nil {
+		if cerr := q.detachLedgerPartitionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing detachLedgerPartitionsStmt: %w", cerr)
+		}
+	}
+	if q.failExternalTransferStmt != nil {
+		if cerr := q.failExternalTransferStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing failExternalTransferStmt: %w", cerr)
+		}
+	}
+	if q.failTaskStmt != nil {
+		if cerr := q.failTaskStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing failTaskStmt: %w", cerr)
+		}
+	}
+	if q.getAccountStmt != nil {
+		if cerr := q.getAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAccountStmt: %w", cerr)
+		}
+	}
+	if q.getAccountByNumberStmt != nil {
+		if cerr := q.getAccountByNumberStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAccountByNumberStmt: %w", cerr)
+		}
+	}
+	if q.getAccountForUpdateStmt != nil {
+		if cerr := q.getAccountForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAccountForUpdateStmt: %w", cerr)
+		}
+	}
+	if q.getAccountImportStmt != nil {
+		if cerr := q.getAccountImportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAccountImportStmt: %w", cerr)
+		}
+	}
+	if q.getAccountProductStmt != nil {
+		if cerr := q.getAccountProductStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAccountProductStmt: %w", cerr)
+		}
+	}
+	if q.getAccountTransferLimitStmt != nil {
+		if cerr := q.getAccountTransferLimitStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAccountTransferLimitStmt: %w", cerr)
+		}
+	}
+	if q.getAccountTransferUsageStmt != nil {
+		if cerr := q.getAccountTransferUsageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAccountTransferUsageStmt: %w", cerr)
+		}
+	}
+	if q.getAuditSubjectKeyStmt != nil {
+		if cerr := q.getAuditSubjectKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAuditSubjectKeyStmt: %w", cerr)
+		}
+	}
+	if q.getBalanceAtStmt != nil {
+		if cerr := q.getBalanceAtStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBalanceAtStmt: %w", cerr)
+		}
+	}
+	if q.getEntryStmt != nil {
+		if cerr := q.getEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEntryStmt: %w", cerr)
+		}
+	}
+	if q.getExternalTransferStmt != nil {
+		if cerr := q.getExternalTransferStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getExternalTransferStmt: %w", cerr)
+		}
+	}
+	if q.getExternalTransferByRefStmt != nil {
+		if cerr := q.getExternalTransferByRefStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getExternalTransferByRefStmt: %w", cerr)
+		}
+	}
+	if q.getExternalTransferForUpdateStmt != nil {
+		if cerr := q.getExternalTransferForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getExternalTransferForUpdateStmt: %w", cerr)
+		}
+	}
+	if q.getInterestAccrualStmt != nil {
+		if cerr := q.getInterestAccrualStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getInterestAccrualStmt: %w", cerr)
+		}
+	}
+	if q.getInterestPostingStmt != nil {
+		if cerr := q.getInterestPostingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getInterestPostingStmt: %w", cerr)
+		}
+	}
+	if q.getLastAuditLogStmt != nil {
+		if cerr := q.getLastAuditLogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLastAuditLogStmt: %w", cerr)
+		}
+	}
+	if q.getLastInterestPostingStmt != nil {
+		if cerr := q.getLastInterestPostingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLastInterestPostingStmt: %w", cerr)
+		}
+	}
+	if q.getLastOverdraftChargeStmt != nil {
+		if cerr := q.getLastOverdraftChargeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLastOverdraftChargeStmt: %w", cerr)
+		}
+	}
+	if q.getOutboxEventStmt != nil {
+		if cerr := q.getOutboxEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOutboxEventStmt: %w", cerr)
+		}
+	}
+	if q.getOverdraftChargeStmt != nil {
+		if cerr := q.getOverdraftChargeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOverdraftChargeStmt: %w", cerr)
+		}
+	}
+	if q.getOwnerTransferLimitStmt != nil {
+		if cerr := q.getOwnerTransferLimitStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOwnerTransferLimitStmt: %w", cerr)
+		}
+	}
+	if q.getOwnerTransferUsageStmt != nil {
+		if cerr := q.getOwnerTransferUsageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOwnerTransferUsageStmt: %w", cerr)
+		}
+	}
+	if q.getPaymentInstructionByEndToEndIDStmt != nil {
+		if cerr := q.getPaymentInstructionByEndToEndIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPaymentInstructionByEndToEndIDStmt: %w", cerr)
+		}
+	}
+	if q.getPaymentInstructionByReviewCaseForUpdateStmt != nil {
+		if cerr := q.getPaymentInstructionByReviewCaseForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPaymentInstructionByReviewCaseForUpdateStmt: %w", cerr)
+		}
+	}
+	if q.getReviewCaseStmt != nil {
+		if cerr := q.getReviewCaseStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getReviewCaseStmt: %w", cerr)
+		}
+	}
+	if q.getReviewCaseForUpdateStmt != nil {
+		if cerr := q.getReviewCaseForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getReviewCaseForUpdateStmt: %w", cerr)
+		}
+	}
+	if q.getSystemAccountStmt != nil {
+		if cerr := q.getSystemAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSystemAccountStmt: %w", cerr)
+		}
+	}
+	if q.getTaskStmt != nil {
+		if cerr := q.getTaskStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTaskStmt: %w", cerr)
+		}
+	}
+	if q.getTransferStmt != nil {
+		if cerr := q.getTransferStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTransferStmt: %w", cerr)
+		}
+	}
+	if q.getUserStmt != nil {
+		if cerr := q.getUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookDeliveryStmt != nil {
+		if cerr := q.getWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookEndpointStmt != nil {
+		if cerr := q.getWebhookEndpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookEndpointStmt: %w", cerr)
+		}
+	}
+	if q.insertImportedAccountsStmt != nil {
+		if cerr := q.insertImportedAccountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertImportedAccountsStmt: %w", cerr)
+		}
+	}
+	if q.insertImportedEntriesStmt != nil {
+		if cerr := q.insertImportedEntriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertImportedEntriesStmt: %w", cerr)
+		}
+	}
+	if q.insertImportedExternalTransfersStmt != nil {
+		if cerr := q.insertImportedExternalTransfersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertImportedExternalTransfersStmt: %w", cerr)
+		}
+	}
+	if q.insertImportedScreeningResultsStmt != nil {
+		if cerr := q.insertImportedScreeningResultsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertImportedScreeningResultsStmt: %w", cerr)
+		}
+	}
+	if q.listAccountImportCurrenciesStmt != nil {
+		if cerr := q.listAccountImportCurrenciesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAccountImportCurrenciesStmt: %w", cerr)
+		}
+	}
+	if q.listAccountsStmt != nil {
+		if cerr := q.listAccountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAccountsStmt: %w", cerr)
+		}
+	}
+	if q.listAccountsByNumbersStmt != nil {
+		if cerr := q.listAccountsByNumbersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAccountsByNumbersStmt: %w", cerr)
+		}
+	}
+	if q.listAccountsByOwnerStmt != nil {
+		if cerr := q.listAccountsByOwnerStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAccountsByOwnerStmt: %w", cerr)
+		}
+	}
+	if q.listAccruingAccountsStmt != nil {
+		if cerr := q.listAccruingAccountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAccruingAccountsStmt: %w", cerr)
+		}
+	}
+	if q.listActiveWebhookEndpointsByOwnerStmt != nil {
+		if cerr := q.listActiveWebhookEndpointsByOwnerStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listActiveWebhookEndpointsByOwnerStmt: %w", cerr)
+		}
+	}
+	if q.listArchivedEntryTotalsStmt != nil {
+		if cerr := q.listArchivedEntryTotalsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listArchivedEntryTotalsStmt: %w", cerr)
+		}
+	}
+	if q.listArchivedEntryTotalsByAccountsStmt != nil {
+		if cerr := q.listArchivedEntryTotalsByAccountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listArchivedEntryTotalsByAccountsStmt: %w", cerr)
+		}
+	}
+	if q.listAuditLogsStmt != nil {
+		if cerr := q.listAuditLogsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAuditLogsStmt: %w", cerr)
+		}
+	}
+	if q.listAuditLogsByEntityStmt != nil {
+		if cerr := q.listAuditLogsByEntityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAuditLogsByEntityStmt: %w", cerr)
+		}
+	}
+	if q.listBalanceAdjustmentsStmt != nil {
+		if cerr := q.listBalanceAdjustmentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listBalanceAdjustmentsStmt: %w", cerr)
+		}
+	}
+	if q.listChargeableOverdraftAccountsStmt != nil {
+		if cerr := q.listChargeableOverdraftAccountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listChargeableOverdraftAccountsStmt: %w", cerr)
+		}
+	}
+	if q.listCurrencyImbalancesStmt != nil {
+		if cerr := q.listCurrencyImbalancesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listCurrencyImbalancesStmt: %w", cerr)
+		}
+	}
+	if q.listEntriesStmt != nil {
+		if cerr := q.listEntriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listEntriesStmt: %w", cerr)
+		}
+	}
+	if q.listEntriesByAccountsStmt != nil {
+		if cerr := q.listEntriesByAccountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listEntriesByAccountsStmt: %w", cerr)
+		}
+	}
+	if q.listExternalTransfersStmt != nil {
+		if cerr := q.listExternalTransfersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listExternalTransfersStmt: %w", cerr)
+		}
+	}
+	if q.listExternalTransfersByAccountsStmt != nil {
+		if cerr := q.listExternalTransfersByAccountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listExternalTransfersByAccountsStmt: %w", cerr)
+		}
+	}
+	if q.listInterestPostingsStmt != nil {
+		if cerr := q.listInterestPostingsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listInterestPostingsStmt: %w", cerr)
+		}
+	}
+	if q.listLedgerMismatchesStmt != nil {
+		if cerr := q.listLedgerMismatchesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listLedgerMismatchesStmt: %w", cerr)
+		}
+	}
+	if q.listOutboxEventsByAggregateStmt != nil {
+		if cerr := q.listOutboxEventsByAggregateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOutboxEventsByAggregateStmt: %w", cerr)
+		}
+	}
+	if q.listOverdraftChargesStmt != nil {
+		if cerr := q.listOverdraftChargesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOverdraftChargesStmt: %w", cerr)
+		}
+	}
+	if q.listOverdraftUsageStmt != nil {
+		if cerr := q.listOverdraftUsageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOverdraftUsageStmt: %w", cerr)
+		}
+	}
+	if q.listPaymentInstructionsByMessageStmt != nil {
+		if cerr := q.listPaymentInstructionsByMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPaymentInstructionsByMessageStmt: %w", cerr)
+		}
+	}
+	if q.listPendingOutboxEventsStmt != nil {
+		if cerr := q.listPendingOutboxEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPendingOutboxEventsStmt: %w", cerr)
+		}
+	}
+	if q.listReviewCasesStmt != nil {
+		if cerr := q.listReviewCasesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listReviewCasesStmt: %w", cerr)
+		}
+	}
+	if q.listScreeningResultsStmt != nil {
+		if cerr := q.listScreeningResultsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listScreeningResultsStmt: %w", cerr)
+		}
+	}
+	if q.listScreeningResultsByAccountStmt != nil {
+		if cerr := q.listScreeningResultsByAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listScreeningResultsByAccountStmt: %w", cerr)
+		}
+	}
+	if q.listStatementEntriesStmt != nil {
+		if cerr := q.listStatementEntriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listStatementEntriesStmt: %w", cerr)
+		}
+	}
+	if q.listTransfersStmt != nil {
+		if cerr := q.listTransfersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTransfersStmt: %w", cerr)
+		}
+	}
+	if q.listTransfersByAccountsStmt != nil {
+		if cerr := q.listTransfersByAccountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTransfersByAccountsStmt: %w", cerr)
+		}
+	}
+	if q.listWebhookDeliveriesStmt != nil {
+		if cerr := q.listWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.listWebhookDeliveriesByStatusStmt != nil {
+		if cerr := q.listWebhookDeliveriesByStatusStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhookDeliveriesByStatusStmt: %w", cerr)
+		}
+	}
+	if q.listWebhookDeliveryAttemptsStmt != nil {
+		if cerr := q.listWebhookDeliveryAttemptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhookDeliveryAttemptsStmt: %w", cerr)
+		}
+	}
+	if q.listWebhookEndpointsByOwnerStmt != nil {
+		if cerr := q.listWebhookEndpointsByOwnerStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhookEndpointsByOwnerStmt: %w", cerr)
+		}
+	}
+	if q.lockAccountImportsStmt != nil {
+		if cerr := q.lockAccountImportsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing lockAccountImportsStmt: %w", cerr)
+		}
+	}
+	if q.lockAuditLogStmt != nil {
+		if cerr := q.lockAuditLogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing lockAuditLogStmt: %w", cerr)
+		}
+	}
+	if q.lockSystemAccountStmt != nil {
+		if cerr := q.lockSystemAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing lockSystemAccountStmt: %w", cerr)
+		}
+	}
+	if q.lockTransferLimitStmt != nil {
+		if cerr := q.lockTransferLimitStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing lockTransferLimitStmt: %w", cerr)
+		}
+	}
+	if q.markInterestAccrualsPostedStmt != nil {
+		if cerr := q.markInterestAccrualsPostedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markInterestAccrualsPostedStmt: %w", cerr)
+		}
+	}
+	if q.markOutboxEventDeliveredStmt != nil {
+		if cerr := q.markOutboxEventDeliveredStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markOutboxEventDeliveredStmt: %w", cerr)
+		}
+	}
+	if q.replayWebhookDeliveryStmt != nil {
+		if cerr := q.replayWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing replayWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.requeueExpiredTasksStmt != nil {
+		if cerr := q.requeueExpiredTasksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing requeueExpiredTasksStmt: %w", cerr)
+		}
+	}
+	if q.retryTaskStmt != nil {
+		if cerr := q.retryTaskStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing retryTaskStmt: %w", cerr)
+		}
+	}
+	if q.setAccountImportAcceptedStmt != nil {
+		if cerr := q.setAccountImportAcceptedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setAccountImportAcceptedStmt: %w", cerr)
+		}
+	}
+	if q.setAccountOverdraftStmt != nil {
+		if cerr := q.setAccountOverdraftStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setAccountOverdraftStmt: %w", cerr)
+		}
+	}
+	if q.setAccountProductStmt != nil {
+		if cerr := q.setAccountProductStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setAccountProductStmt: %w", cerr)
+		}
+	}
+	if q.setAccountTransferLimitStmt != nil {
+		if cerr := q.setAccountTransferLimitStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setAccountTransferLimitStmt: %w", cerr)
+		}
+	}
+	if q.setExternalTransferEntriesStmt != nil {
+		if cerr := q.setExternalTransferEntriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setExternalTransferEntriesStmt: %w", cerr)
+		}
+	}
+	if q.setInterestPostingTransferStmt != nil {
+		if cerr := q.setInterestPostingTransferStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setInterestPostingTransferStmt: %w", cerr)
+		}
+	}
+	if q.setOverdraftChargeTransferStmt != nil {
+		if cerr := q.setOverdraftChargeTransferStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setOverdraftChargeTransferStmt: %w", cerr)
+		}
+	}
+	if q.setOwnerTransferLimitStmt != nil {
+		if cerr := q.setOwnerTransferLimitStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setOwnerTransferLimitStmt: %w", cerr)
+		}
+	}
+	if q.setWebhookEndpointActiveStmt != nil {
+		if cerr := q.setWebhookEndpointActiveStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setWebhookEndpointActiveStmt: %w", cerr)
+		}
+	}
+	if q.settleExternalTransferStmt != nil {
+		if cerr := q.settleExternalTransferStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing settleExternalTransferStmt: %w", cerr)
+		}
+	}
+	if q.sumIncomingEntriesStmt != nil {
+		if cerr := q.sumIncomingEntriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sumIncomingEntriesStmt: %w", cerr)
+		}
+	}
+	if q.sumUnpostedInterestAccrualsStmt != nil {
+		if cerr := q.sumUnpostedInterestAccrualsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sumUnpostedInterestAccrualsStmt: %w", cerr)
+		}
+	}
+	if q.summarizeOverdraftUsageStmt != nil {
+		if cerr := q.summarizeOverdraftUsageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing summarizeOverdraftUsageStmt: %w", cerr)
+		}
+	}
+	if q.updateWebhookDeliveryResultStmt != nil {
+		if cerr := q.updateWebhookDeliveryResultStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateWebhookDeliveryResultStmt: %w", cerr)
+		}
+	}
+	if q.useVerifyEmailStmt != nil {
+		if cerr := q.useVerifyEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing useVerifyEmailStmt: %w", cerr)
+		}
+	}
+	if q.verifyUserEmailStmt != nil {
+		if cerr := q.verifyUserEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing verifyUserEmailStmt: %w", cerr)
+		}
+	}
+	return err
+}
+
+func (q *Queries) exec(ctx context.Context, stmt *sql.Stmt, query string, args ...interface{}) (sql.Result, error) {
+	switch {
+	case stmt != nil && q.tx != nil:
+		return q.tx.StmtContext(ctx, stmt).ExecContext(ctx, args...)
+	case stmt != nil:
+		return stmt.ExecContext(ctx, args...)
+	default:
+		return q.db.ExecContext(ctx, query, args...)
+	}
+}
+
+func (q *Queries) query(ctx context.Context, stmt *sql.Stmt, query string, args ...interface{}) (*sql.Rows, error) {
+	switch {
+	case stmt != nil && q.tx != nil:
+		return q.tx.StmtContext(ctx, stmt).QueryContext(ctx, args...)
+	case stmt != nil:
+		return stmt.QueryContext(ctx, args...)
+	default:
+		return q.db.QueryContext(ctx, query, args...)
+	}
+}
+
+func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, args ...interface{}) *sql.Row {
+	switch {
+	case stmt != nil && q.tx != nil:
+		return q.tx.StmtContext(ctx, stmt).QueryRowContext(ctx, args...)
+	case stmt != nil:
+		return stmt.QueryRowContext(ctx, args...)
+	default:
+		return q.db.QueryRowContext(ctx, query, args...)
+	}
+}
+
 type Queries struct {
-	db DBTX
+	db                                             DBTX
+	tx                                             *sql.Tx
+	accountHasLedgerRowsStmt                       *sql.Stmt
+	allocateImportedAccountIDsStmt                 *sql.Stmt
+	anonymizeAccountsOwnerStmt                     *sql.Stmt
+	anonymizeOutboxPayloadsStmt                    *sql.Stmt
+	anonymizeReviewCaseHitsStmt                    *sql.Stmt
+	anonymizeScreeningResultsStmt                  *sql.Stmt
+	anonymizeTransferLimitsStmt                    *sql.Stmt
+	anonymizeUserStmt                              *sql.Stmt
+	anonymizeWebhookEndpointsStmt                  *sql.Stmt
+	applyImportedBalancesStmt                      *sql.Stmt
+	claimDueWebhookDeliveriesStmt                  *sql.Stmt
+	claimNextTaskStmt                              *sql.Stmt
+	closeAccountStmt                               *sql.Stmt
+	completeTaskStmt                               *sql.Stmt
+	countTransfersInAmountRangeStmt                *sql.Stmt
+	createAccountStmt                              *sql.Stmt
+	createAccountImportStmt                        *sql.Stmt
+	createAccountProductStmt                       *sql.Stmt
+	createAuditLogStmt                             *sql.Stmt
+	createAuditSubjectKeyStmt                      *sql.Stmt
+	createBalanceAdjustmentStmt                    *sql.Stmt
+	createEntryStmt                                *sql.Stmt
+	createExternalTransferStmt                     *sql.Stmt
+	createInterestAccrualStmt                      *sql.Stmt
+	createInterestPostingStmt                      *sql.Stmt
+	createLedgerPartitionsStmt                     *sql.Stmt
+	createOutboxEventStmt                          *sql.Stmt
+	createOverdraftChargeStmt                      *sql.Stmt
+	createPaymentInstructionStmt                   *sql.Stmt
+	createRejectedReviewCaseStmt                   *sql.Stmt
+	createReviewCaseStmt                           *sql.Stmt
+	createScreeningResultStmt                      *sql.Stmt
+	createSystemAccountStmt                        *sql.Stmt
+	createTaskStmt                                 *sql.Stmt
+	createTransferStmt                             *sql.Stmt
+	createUserStmt                                 *sql.Stmt
+	createVerifyEmailStmt                          *sql.Stmt
+	createWebhookDeliveryStmt                      *sql.Stmt
+	createWebhookDeliveryAttemptStmt               *sql.Stmt
+	createWebhookEndpointStmt                      *sql.Stmt
+	decidePaymentInstructionStmt                   *sql.Stmt
+	decideReviewCaseStmt                           *sql.Stmt
+	deleteAccountStmt                              *sql.Stmt
+	deleteAccountImportRowsStmt                    *sql.Stmt
+	deleteAuditSubjectKeyStmt                      *sql.Stmt
+	deleteTransferLimitStmt                        *sql.Stmt
+	deleteVerifyEmailsByUsernameStmt               *sql.Stmt
+	detachLedgerPartitionsStmt                     *sql.Stmt
+	failExternalTransferStmt                       *sql.Stmt
+	failTaskStmt                                   *sql.Stmt
+	getAccountStmt                                 *sql.Stmt
+	getAccountByNumberStmt                         *sql.Stmt
+	getAccountForUpdateStmt                        *sql.Stmt
+	getAccountImportStmt                           *sql.Stmt
+	getAccountProductStmt                          *sql.Stmt
+	getAccountTransferLimitStmt                    *sql.Stmt
+	getAccountTransferUsageStmt                    *sql.Stmt
+	getAuditSubjectKeyStmt                         *sql.Stmt
+	getBalanceAtStmt                               *sql.Stmt
+	getEntryStmt                                   *sql.Stmt
+	getExternalTransferStmt                        *sql.Stmt
+	getExternalTransferByRefStmt                   *sql.Stmt
+	getExternalTransferForUpdateStmt               *sql.Stmt
+	getInterestAccrualStmt                         *sql.Stmt
+	getInterestPostingStmt                         *sql.Stmt
+	getLastAuditLogStmt                            *sql.Stmt
+	getLastInterestPostingStmt                     *sql.Stmt
+	getLastOverdraftChargeStmt                     *sql.Stmt
+	getOutboxEventStmt                             *sql.Stmt
+	getOverdraftChargeStmt                         *sql.Stmt
+	getOwnerTransferLimitStmt                      *sql.Stmt
+	getOwnerTransferUsageStmt                      *sql.Stmt
+	getPaymentInstructionByEndToEndIDStmt          *sql.Stmt
+	getPaymentInstructionByReviewCaseForUpdateStmt *sql.Stmt
+	getReviewCaseStmt                              *sql.Stmt
+	getReviewCaseForUpdateStmt                     *sql.Stmt
+	getSystemAccountStmt                           *sql.Stmt
+	getTaskStmt                                    *sql.Stmt
+	getTransferStmt                                *sql.Stmt
+	getUserStmt                                    *sql.Stmt
+	getWebhookDeliveryStmt                         *sql.Stmt
+	getWebhookEndpointStmt                         *sql.Stmt
+	insertImportedAccountsStmt                     *sql.Stmt
+	insertImportedEntriesStmt                      *sql.Stmt
+	insertImportedExternalTransfersStmt            *sql.Stmt
+	insertImportedScreeningResultsStmt             *sql.Stmt
+	listAccountImportCurrenciesStmt                *sql.Stmt
+	listAccountsStmt                               *sql.Stmt
+	listAccountsByNumbersStmt                      *sql.Stmt
+	listAccountsByOwnerStmt                        *sql.Stmt
+	listAccruingAccountsStmt                       *sql.Stmt
+	listActiveWebhookEndpointsByOwnerStmt          *sql.Stmt
+	listArchivedEntryTotalsStmt                    *sql.Stmt
+	listArchivedEntryTotalsByAccountsStmt          *sql.Stmt
+	listAuditLogsStmt                              *sql.Stmt
+	listAuditLogsByEntityStmt                      *sql.Stmt
+	listBalanceAdjustmentsStmt                     *sql.Stmt
+	listChargeableOverdraftAccountsStmt            *sql.Stmt
+	listCurrencyImbalancesStmt                     *sql.Stmt
+	listEntriesStmt                                *sql.Stmt
+	listEntriesByAccountsStmt                      *sql.Stmt
+	listExternalTransfersStmt                      *sql.Stmt
+	listExternalTransfersByAccountsStmt            *sql.Stmt
+	listInterestPostingsStmt                       *sql.Stmt
+	listLedgerMismatchesStmt                       *sql.Stmt
+	listOutboxEventsByAggregateStmt                *sql.Stmt
+	listOverdraftChargesStmt                       *sql.Stmt
+	listOverdraftUsageStmt                         *sql.Stmt
+	listPaymentInstructionsByMessageStmt           *sql.Stmt
+	listPendingOutboxEventsStmt                    *sql.Stmt
+	listReviewCasesStmt                            *sql.Stmt
+	listScreeningResultsStmt                       *sql.Stmt
+	listScreeningResultsByAccountStmt              *sql.Stmt
+	listStatementEntriesStmt                       *sql.Stmt
+	listTransfersStmt                              *sql.Stmt
+	listTransfersByAccountsStmt                    *sql.Stmt
+	listWebhookDeliveriesStmt                      *sql.Stmt
+	listWebhookDeliveriesByStatusStmt              *sql.Stmt
+	listWebhookDeliveryAttemptsStmt                *sql.Stmt
+	listWebhookEndpointsByOwnerStmt                *sql.Stmt
+	lockAccountImportsStmt                         *sql.Stmt
+	lockAuditLogStmt                               *sql.Stmt
+	lockSystemAccountStmt                          *sql.Stmt
+	lockTransferLimitStmt                          *sql.Stmt
+	markInterestAccrualsPostedStmt                 *sql.Stmt
+	markOutboxEventDeliveredStmt                   *sql.Stmt
+	replayWebhookDeliveryStmt                      *sql.Stmt
+	requeueExpiredTasksStmt                        *sql.Stmt
+	retryTaskStmt                                  *sql.Stmt
+	setAccountImportAcceptedStmt                   *sql.Stmt
+	setAccountOverdraftStmt                        *sql.Stmt
+	setAccountProductStmt                          *sql.Stmt
+	setAccountTransferLimitStmt                    *sql.Stmt
+	setExternalTransferEntriesStmt                 *sql.Stmt
+	setInterestPostingTransferStmt                 *sql.Stmt
+	setOverdraftChargeTransferStmt                 *sql.Stmt
+	setOwnerTransferLimitStmt                      *sql.Stmt
+	setWebhookEndpointActiveStmt                   *sql.Stmt
+	settleExternalTransferStmt                     *sql.Stmt
+	sumIncomingEntriesStmt                         *sql.Stmt
+	sumUnpostedInterestAccrualsStmt                *sql.Stmt
+	summarizeOverdraftUsageStmt                    *sql.Stmt
+	updateWebhookDeliveryResultStmt                *sql.Stmt
+	useVerifyEmailStmt                             *sql.Stmt
+	verifyUserEmailStmt                            *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db: tx,
+		db:                                             tx,
+		tx:                                             tx,
+		accountHasLedgerRowsStmt:                       q.accountHasLedgerRowsStmt,
+		allocateImportedAccountIDsStmt:                 q.allocateImportedAccountIDsStmt,
+		anonymizeAccountsOwnerStmt:                     q.anonymizeAccountsOwnerStmt,
+		anonymizeOutboxPayloadsStmt:                    q.anonymizeOutboxPayloadsStmt,
+		anonymizeReviewCaseHitsStmt:                    q.anonymizeReviewCaseHitsStmt,
+		anonymizeScreeningResultsStmt:                  q.anonymizeScreeningResultsStmt,
+		anonymizeTransferLimitsStmt:                    q.anonymizeTransferLimitsStmt,
+		anonymizeUserStmt:                              q.anonymizeUserStmt,
+		anonymizeWebhookEndpointsStmt:                  q.anonymizeWebhookEndpointsStmt,
+		applyImportedBalancesStmt:                      q.applyImportedBalancesStmt,
+		claimDueWebhookDeliveriesStmt:                  q.claimDueWebhookDeliveriesStmt,
+		claimNextTaskStmt:                              q.claimNextTaskStmt,
+		closeAccountStmt:                               q.closeAccountStmt,
+		completeTaskStmt:                               q.completeTaskStmt,
+		countTransfersInAmountRangeStmt:                q.countTransfersInAmountRangeStmt,
+		createAccountStmt:                              q.createAccountStmt,
+		createAccountImportStmt:                        q.createAccountImportStmt,
+		createAccountProductStmt:                       q.createAccountProductStmt,
+		createAuditLogStmt:                             q.createAuditLogStmt,
+		createAuditSubjectKeyStmt:                      q.createAuditSubjectKeyStmt,
+		createBalanceAdjustmentStmt:                    q.createBalanceAdjustmentStmt,
+		createEntryStmt:                                q.createEntryStmt,
+		createExternalTransferStmt:                     q.createExternalTransferStmt,
+		createInterestAccrualStmt:                      q.createInterestAccrualStmt,
+		createInterestPostingStmt:                      q.createInterestPostingStmt,
+		createLedgerPartitionsStmt:                     q.createLedgerPartitionsStmt,
+		createOutboxEventStmt:                          q.createOutboxEventStmt,
+		createOverdraftChargeStmt:                      q.createOverdraftChargeStmt,
+		createPaymentInstructionStmt:                   q.createPaymentInstructionStmt,
+		createRejectedReviewCaseStmt:                   q.createRejectedReviewCaseStmt,
+		createReviewCaseStmt:                           q.createReviewCaseStmt,
+		createScreeningResultStmt:                      q.createScreeningResultStmt,
+		createSystemAccountStmt:                        q.createSystemAccountStmt,
+		createTaskStmt:                                 q.createTaskStmt,
+		createTransferStmt:                             q.createTransferStmt,
+		createUserStmt:                                 q.createUserStmt,
+		createVerifyEmailStmt:                          q.createVerifyEmailStmt,
+		createWebhookDeliveryStmt:                      q.createWebhookDeliveryStmt,
+		createWebhookDeliveryAttemptStmt:               q.createWebhookDeliveryAttemptStmt,
+		createWebhookEndpointStmt:                      q.createWebhookEndpointStmt,
+		decidePaymentInstructionStmt:                   q.decidePaymentInstructionStmt,
+		decideReviewCaseStmt:                           q.decideReviewCaseStmt,
+		deleteAccountStmt:                              q.deleteAccountStmt,
+		deleteAccountImportRowsStmt:                    q.deleteAccountImportRowsStmt,
+		deleteAuditSubjectKeyStmt:                      q.deleteAuditSubjectKeyStmt,
+		deleteTransferLimitStmt:                        q.deleteTransferLimitStmt,
+		deleteVerifyEmailsByUsernameStmt:               q.deleteVerifyEmailsByUsernameStmt,
+		detachLedgerPartitionsStmt:                     q.detachLedgerPartitionsStmt,
+		failExternalTransferStmt:                       q.failExternalTransferStmt,
+		failTaskStmt:                                   q.failTaskStmt,
+		getAccountStmt:                                 q.getAccountStmt,
+		getAccountByNumberStmt:                         q.getAccountByNumberStmt,
+		getAccountForUpdateStmt:                        q.getAccountForUpdateStmt,
+		getAccountImportStmt:                           q.getAccountImportStmt,
+		getAccountProductStmt:                          q.getAccountProductStmt,
+		getAccountTransferLimitStmt:                    q.getAccountTransferLimitStmt,
+		getAccountTransferUsageStmt:                    q.getAccountTransferUsageStmt,
+		getAuditSubjectKeyStmt:                         q.getAuditSubjectKeyStmt,
+		getBalanceAtStmt:                               q.getBalanceAtStmt,
+		getEntryStmt:                                   q.getEntryStmt,
+		getExternalTransferStmt:                        q.getExternalTransferStmt,
+		getExternalTransferByRefStmt:                   q.getExternalTransferByRefStmt,
+		getExternalTransferForUpdateStmt:               q.getExternalTransferForUpdateStmt,
+		getInterestAccrualStmt:                         q.getInterestAccrualStmt,
+		getInterestPostingStmt:                         q.getInterestPostingStmt,
+		getLastAuditLogStmt:                            q.getLastAuditLogStmt,
+		getLastInterestPostingStmt:                     q.getLastInterestPostingStmt,
+		getLastOverdraftChargeStmt:                     q.getLastOverdraftChargeStmt,
+		getOutboxEventStmt:                             q.getOutboxEventStmt,
+		getOverdraftChargeStmt:                         q.getOverdraftChargeStmt,
+		getOwnerTransferLimitStmt:                      q.getOwnerTransferLimitStmt,
+		getOwnerTransferUsageStmt:                      q.getOwnerTransferUsageStmt,
+		getPaymentInstructionByEndToEndIDStmt:          q.getPaymentInstructionByEndToEndIDStmt,
+		getPaymentInstructionByReviewCaseForUpdateStmt: q.getPaymentInstructionByReviewCaseForUpdateStmt,
+		getReviewCaseStmt:                              q.getReviewCaseStmt,
+		getReviewCaseForUpdateStmt:                     q.getReviewCaseForUpdateStmt,
+		getSystemAccountStmt:                           q.getSystemAccountStmt,
+		getTaskStmt:                                    q.getTaskStmt,
+		getTransferStmt:                                q.getTransferStmt,
+		getUserStmt:                                    q.getUserStmt,
+		getWebhookDeliveryStmt:                         q.getWebhookDeliveryStmt,
+		getWebhookEndpointStmt:                         q.getWebhookEndpointStmt,
+		insertImportedAccountsStmt:                     q.insertImportedAccountsStmt,
+		insertImportedEntriesStmt:                      q.insertImportedEntriesStmt,
+		insertImportedExternalTransfersStmt:            q.insertImportedExternalTransfersStmt,
+		insertImportedScreeningResultsStmt:             q.insertImportedScreeningResultsStmt,
+		listAccountImportCurrenciesStmt:                q.listAccountImportCurrenciesStmt,
+		listAccountsStmt:                               q.listAccountsStmt,
+		listAccountsByNumbersStmt:                      q.listAccountsByNumbersStmt,
+		listAccountsByOwnerStmt:                        q.listAccountsByOwnerStmt,
+		listAccruingAccountsStmt:                       q.listAccruingAccountsStmt,
+		listActiveWebhookEndpointsByOwnerStmt:          q.listActiveWebhookEndpointsByOwnerStmt,
+		listArchivedEntryTotalsStmt:                    q.listArchivedEntryTotalsStmt,
+		listArchivedEntryTotalsByAccountsStmt:          q.listArchivedEntryTotalsByAccountsStmt,
+		listAuditLogsStmt:                              q.listAuditLogsStmt,
+		listAuditLogsByEntityStmt:                      q.listAuditLogsByEntityStmt,
+		listBalanceAdjustmentsStmt:                     q.listBalanceAdjustmentsStmt,
+		listChargeableOverdraftAccountsStmt:            q.listChargeableOverdraftAccountsStmt,
+		listCurrencyImbalancesStmt:                     q.listCurrencyImbalancesStmt,
+		listEntriesStmt:                                q.listEntriesStmt,
+		listEntriesByAccountsStmt:                      q.listEntriesByAccountsStmt,
+		listExternalTransfersStmt:                      q.listExternalTransfersStmt,
+		listExternalTransfersByAccountsStmt:            q.listExternalTransfersByAccountsStmt,
+		listInterestPostingsStmt:                       q.listInterestPostingsStmt,
+		listLedgerMismatchesStmt:                       q.listLedgerMismatchesStmt,
+		listOutboxEventsByAggregateStmt:                q.listOutboxEventsByAggregateStmt,
+		listOverdraftChargesStmt:                       q.listOverdraftChargesStmt,
+		listOverdraftUsageStmt:                         q.listOverdraftUsageStmt,
+		listPaymentInstructionsByMessageStmt:           q.listPaymentInstructionsByMessageStmt,
+		listPendingOutboxEventsStmt:                    q.listPendingOutboxEventsStmt,
+		listReviewCasesStmt:                            q.listReviewCasesStmt,
+		listScreeningResultsStmt:                       q.listScreeningResultsStmt,
+		listScreeningResultsByAccountStmt:              q.listScreeningResultsByAccountStmt,
+		listStatementEntriesStmt:                       q.listStatementEntriesStmt,
+		listTransfersStmt:                              q.listTransfersStmt,
+		listTransfersByAccountsStmt:                    q.listTransfersByAccountsStmt,
+		listWebhookDeliveriesStmt:                      q.listWebhookDeliveriesStmt,
+		listWebhookDeliveriesByStatusStmt:              q.listWebhookDeliveriesByStatusStmt,
+		listWebhookDeliveryAttemptsStmt:                q.listWebhookDeliveryAttemptsStmt,
+		listWebhookEndpointsByOwnerStmt:                q.listWebhookEndpointsByOwnerStmt,
+		lockAccountImportsStmt:                         q.lockAccountImportsStmt,
+		lockAuditLogStmt:                               q.lockAuditLogStmt,
+		lockSystemAccountStmt:                          q.lockSystemAccountStmt,
+		lockTransferLimitStmt:                          q.lockTransferLimitStmt,
+		markInterestAccrualsPostedStmt:                 q.markInterestAccrualsPostedStmt,
+		markOutboxEventDeliveredStmt:                   q.markOutboxEventDeliveredStmt,
+		replayWebhookDeliveryStmt:                      q.replayWebhookDeliveryStmt,
+		requeueExpiredTasksStmt:                        q.requeueExpiredTasksStmt,
+		retryTaskStmt:                                  q.retryTaskStmt,
+		setAccountImportAcceptedStmt:                   q.setAccountImportAcceptedStmt,
+		setAccountOverdraftStmt:                        q.setAccountOverdraftStmt,
+		setAccountProductStmt:                          q.setAccountProductStmt,
+		setAccountTransferLimitStmt:                    q.setAccountTransferLimitStmt,
+		setExternalTransferEntriesStmt:                 q.setExternalTransferEntriesStmt,
+		setInterestPostingTransferStmt:                 q.setInterestPostingTransferStmt,
+		setOverdraftChargeTransferStmt:                 q.setOverdraftChargeTransferStmt,
+		setOwnerTransferLimitStmt:                      q.setOwnerTransferLimitStmt,
+		setWebhookEndpointActiveStmt:                   q.setWebhookEndpointActiveStmt,
+		settleExternalTransferStmt:                     q.settleExternalTransferStmt,
+		sumIncomingEntriesStmt:                         q.sumIncomingEntriesStmt,
+		sumUnpostedInterestAccrualsStmt:                q.sumUnpostedInterestAccrualsStmt,
+		summarizeOverdraftUsageStmt:                    q.summarizeOverdraftUsageStmt,
+		updateWebhookDeliveryResultStmt:                q.updateWebhookDeliveryResultStmt,
+		useVerifyEmailStmt:                             q.useVerifyEmailStmt,
+		verifyUserEmailStmt:                            q.verifyUserEmailStmt,
 	}
 }
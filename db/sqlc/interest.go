@@ -0,0 +1,106 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Day count conventions of account products
+const (
+	DayCountACT365 = "ACT/365"
+	DayCount30360  = "30/360"
+)
+
+// Posting frequencies of account products
+const (
+	PostingMonthly   = "monthly"
+	PostingQuarterly = "quarterly"
+	PostingAnnually  = "annually"
+)
+
+// 365 和 360 的最小公倍數；一天的利息用 1/(10000*26280) 個最小貨幣單位當單位，
+// ACT/365 一天是 balance*bps*72 單位、30/360 一天是 balance*bps*73 單位，都是整數，不會有捨入誤差
+const interestYearUnits = 26280
+
+// InterestUnitsPerMinor is the number of interest units in one minor currency unit (one cent)
+const InterestUnitsPerMinor = 10000 * interestYearUnits
+
+// ErrInterestOverflow is returned when the interest of a day does not fit in the units column
+var ErrInterestOverflow = errors.New("interest units overflow")
+
+// accrualDays returns the days counted for the accrual of date, that is from date to the next day
+func accrualDays(dayCount string, date time.Time) (int32, error) {
+	switch dayCount {
+	case DayCountACT365:
+		return 1, nil
+	case DayCount30360:
+		return int32(days30360(date, date.AddDate(0, 0, 1))), nil
+	default:
+		return 0, fmt.Errorf("unknown day count %q", dayCount)
+	}
+}
+
+// days30360 counts the days from start to end with the 30/360 bond basis:
+// a 31st start day becomes 30, and a 31st end day becomes 30 when the start day is 30 or 31.
+// Every full month counts 30 days whatever its length.
+func days30360(start time.Time, end time.Time) int {
+	y1, m1, d1 := start.Date()
+	y2, m2, d2 := end.Date()
+	if d1 == 31 {
+		d1 = 30
+	}
+	if d2 == 31 && d1 == 30 {
+		d2 = 30
+	}
+	return 360*(y2-y1) + 30*(int(m2)-int(m1)) + (d2 - d1)
+}
+
+// interestUnits returns the interest of balance for days at annualRateBps, in 1/InterestUnitsPerMinor of the minor unit.
+// Only positive balances earn interest.
+func interestUnits(balance int64, annualRateBps int32, dayCount string, days int32) (int64, error) {
+	if balance <= 0 || annualRateBps <= 0 || days <= 0 {
+		return 0, nil
+	}
+
+	var perDay int64
+	switch dayCount {
+	case DayCountACT365:
+		perDay = interestYearUnits / 365
+	case DayCount30360:
+		perDay = interestYearUnits / 360
+	default:
+		return 0, fmt.Errorf("unknown day count %q", dayCount)
+	}
+
+	units := new(big.Int).SetInt64(balance)
+	units.Mul(units, big.NewInt(int64(annualRateBps)))
+	units.Mul(units, big.NewInt(perDay))
+	units.Mul(units, big.NewInt(int64(days)))
+	if !units.IsInt64() {
+		return 0, fmt.Errorf("%w: balance %d at %d bps", ErrInterestOverflow, balance, annualRateBps)
+	}
+	return units.Int64(), nil
+}
+
+// IsPostingDate reports whether date is the last day of a posting period of frequency
+func IsPostingDate(frequency string, date time.Time) (bool, error) {
+	lastDayOfMonth := date.AddDate(0, 0, 1).Day() == 1
+	switch frequency {
+	case PostingMonthly:
+		return lastDayOfMonth, nil
+	case PostingQuarterly:
+		return lastDayOfMonth && date.Month()%3 == 0, nil
+	case PostingAnnually:
+		return lastDayOfMonth && date.Month() == time.December, nil
+	default:
+		return false, fmt.Errorf("unknown posting frequency %q", frequency)
+	}
+}
+
+// civilDate 只留下 date 在它自己時區的年月日，轉成 UTC 午夜，當 date 欄位的參數
+func civilDate(date time.Time) time.Time {
+	y, m, d := date.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,469 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: interest.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createAccountProduct = `-- name: CreateAccountProduct :one
+INSERT INTO account_products (
+  name, annual_rate_bps, day_count, posting_frequency
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING id, name, annual_rate_bps, day_count, posting_frequency, created_at
+`
+
+type CreateAccountProductParams struct {
+	Name             string `json:"name"`
+	AnnualRateBps    int32  `json:"annual_rate_bps"`
+	DayCount         string `json:"day_count"`
+	PostingFrequency string `json:"posting_frequency"`
+}
+
+func (q *Queries) CreateAccountProduct(ctx context.Context, arg CreateAccountProductParams) (AccountProduct, error) {
+	row := q.queryRow(ctx, q.createAccountProductStmt, createAccountProduct,
+		arg.Name,
+		arg.AnnualRateBps,
+		arg.DayCount,
+		arg.PostingFrequency,
+	)
+	var i AccountProduct
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.AnnualRateBps,
+		&i.DayCount,
+		&i.PostingFrequency,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createInterestAccrual = `-- name: CreateInterestAccrual :one
+INSERT INTO interest_accruals (
+  account_id, product_id, accrual_date, balance, annual_rate_bps, day_count, days, units
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (account_id, accrual_date) DO NOTHING
+RETURNING id, account_id, product_id, accrual_date, balance, annual_rate_bps, day_count, days, units, posting_id, created_at
+`
+
+type CreateInterestAccrualParams struct {
+	AccountID     int64     `json:"account_id"`
+	ProductID     int64     `json:"product_id"`
+	AccrualDate   time.Time `json:"accrual_date"`
+	Balance       int64     `json:"balance"`
+	AnnualRateBps int32     `json:"annual_rate_bps"`
+	DayCount      string    `json:"day_count"`
+	Days          int32     `json:"days"`
+	Units         int64     `json:"units"`
+}
+
+func (q *Queries) CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (InterestAccrual, error) {
+	row := q.queryRow(ctx, q.createInterestAccrualStmt, createInterestAccrual,
+		arg.AccountID,
+		arg.ProductID,
+		arg.AccrualDate,
+		arg.Balance,
+		arg.AnnualRateBps,
+		arg.DayCount,
+		arg.Days,
+		arg.Units,
+	)
+	var i InterestAccrual
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ProductID,
+		&i.AccrualDate,
+		&i.Balance,
+		&i.AnnualRateBps,
+		&i.DayCount,
+		&i.Days,
+		&i.Units,
+		&i.PostingID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createInterestPosting = `-- name: CreateInterestPosting :one
+INSERT INTO interest_postings (
+  account_id, period_end, units, amount, carry_units
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (account_id, period_end) DO NOTHING
+RETURNING id, account_id, period_end, units, amount, carry_units, transfer_id, created_at
+`
+
+type CreateInterestPostingParams struct {
+	AccountID  int64     `json:"account_id"`
+	PeriodEnd  time.Time `json:"period_end"`
+	Units      int64     `json:"units"`
+	Amount     int64     `json:"amount"`
+	CarryUnits int64     `json:"carry_units"`
+}
+
+func (q *Queries) CreateInterestPosting(ctx context.Context, arg CreateInterestPostingParams) (InterestPosting, error) {
+	row := q.queryRow(ctx, q.createInterestPostingStmt, createInterestPosting,
+		arg.AccountID,
+		arg.PeriodEnd,
+		arg.Units,
+		arg.Amount,
+		arg.CarryUnits,
+	)
+	var i InterestPosting
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.PeriodEnd,
+		&i.Units,
+		&i.Amount,
+		&i.CarryUnits,
+		&i.TransferID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAccountProduct = `-- name: GetAccountProduct :one
+SELECT id, name, annual_rate_bps, day_count, posting_frequency, created_at FROM account_products
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccountProduct(ctx context.Context, id int64) (AccountProduct, error) {
+	row := q.queryRow(ctx, q.getAccountProductStmt, getAccountProduct, id)
+	var i AccountProduct
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.AnnualRateBps,
+		&i.DayCount,
+		&i.PostingFrequency,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getBalanceAt = `-- name: GetBalanceAt :one
+SELECT (
+  COALESCE((
+    SELECT sum(entries.amount) FROM entries
+    WHERE entries.account_id = $1 AND entries.created_at < $2::timestamptz
+  ), 0) +
+  COALESCE((
+    SELECT sum(archived_entry_totals.amount) FROM archived_entry_totals
+    WHERE archived_entry_totals.account_id = $1 AND archived_entry_totals.archived_before <= $2::timestamptz
+  ), 0)
+)::bigint AS balance,
+EXISTS (
+  SELECT 1 FROM archived_entry_totals
+  WHERE archived_entry_totals.account_id = $1
+    AND archived_entry_totals.archived_before > $2::timestamptz
+    AND archived_entry_totals.archived_before - interval '1 month' < $2::timestamptz
+) AS archived
+`
+
+type GetBalanceAtParams struct {
+	AccountID int64     `json:"account_id"`
+	At        time.Time `json:"at"`
+}
+
+type GetBalanceAtRow struct {
+	Balance  int64 `json:"balance"`
+	Archived bool  `json:"archived"`
+}
+
+// 由 entries 推出 at 之前的餘額（不含 at）；卸下的分區整個在 at 之前時算進它的加總。
+// at 落在卸下的月份裡面時，那個月在 at 之前的 entries 查不到，archived 是 true，balance 不能用
+func (q *Queries) GetBalanceAt(ctx context.Context, arg GetBalanceAtParams) (GetBalanceAtRow, error) {
+	row := q.queryRow(ctx, q.getBalanceAtStmt, getBalanceAt, arg.AccountID, arg.At)
+	var i GetBalanceAtRow
+	err := row.Scan(&i.Balance, &i.Archived)
+	return i, err
+}
+
+const getInterestAccrual = `-- name: GetInterestAccrual :one
+SELECT id, account_id, product_id, accrual_date, balance, annual_rate_bps, day_count, days, units, posting_id, created_at FROM interest_accruals
+WHERE account_id = $1 AND accrual_date = $2 LIMIT 1
+`
+
+type GetInterestAccrualParams struct {
+	AccountID   int64     `json:"account_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+}
+
+func (q *Queries) GetInterestAccrual(ctx context.Context, arg GetInterestAccrualParams) (InterestAccrual, error) {
+	row := q.queryRow(ctx, q.getInterestAccrualStmt, getInterestAccrual, arg.AccountID, arg.AccrualDate)
+	var i InterestAccrual
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ProductID,
+		&i.AccrualDate,
+		&i.Balance,
+		&i.AnnualRateBps,
+		&i.DayCount,
+		&i.Days,
+		&i.Units,
+		&i.PostingID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getInterestPosting = `-- name: GetInterestPosting :one
+SELECT id, account_id, period_end, units, amount, carry_units, transfer_id, created_at FROM interest_postings
+WHERE account_id = $1 AND period_end = $2 LIMIT 1
+`
+
+type GetInterestPostingParams struct {
+	AccountID int64     `json:"account_id"`
+	PeriodEnd time.Time `json:"period_end"`
+}
+
+func (q *Queries) GetInterestPosting(ctx context.Context, arg GetInterestPostingParams) (InterestPosting, error) {
+	row := q.queryRow(ctx, q.getInterestPostingStmt, getInterestPosting, arg.AccountID, arg.PeriodEnd)
+	var i InterestPosting
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.PeriodEnd,
+		&i.Units,
+		&i.Amount,
+		&i.CarryUnits,
+		&i.TransferID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLastInterestPosting = `-- name: GetLastInterestPosting :one
+SELECT id, account_id, period_end, units, amount, carry_units, transfer_id, created_at FROM interest_postings
+WHERE account_id = $1
+ORDER BY period_end DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastInterestPosting(ctx context.Context, accountID int64) (InterestPosting, error) {
+	row := q.queryRow(ctx, q.getLastInterestPostingStmt, getLastInterestPosting, accountID)
+	var i InterestPosting
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.PeriodEnd,
+		&i.Units,
+		&i.Amount,
+		&i.CarryUnits,
+		&i.TransferID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAccruingAccounts = `-- name: ListAccruingAccounts :many
+SELECT accounts.id AS account_id, accounts.currency,
+  account_products.id AS product_id, account_products.annual_rate_bps,
+  account_products.day_count, account_products.posting_frequency
+FROM accounts
+JOIN account_products ON account_products.id = accounts.product_id
+WHERE accounts.id > $1
+ORDER BY accounts.id
+LIMIT $2
+`
+
+type ListAccruingAccountsParams struct {
+	AfterID    int64 `json:"after_id"`
+	LimitCount int32 `json:"limit_count"`
+}
+
+type ListAccruingAccountsRow struct {
+	AccountID        int64  `json:"account_id"`
+	Currency         string `json:"currency"`
+	ProductID        int64  `json:"product_id"`
+	AnnualRateBps    int32  `json:"annual_rate_bps"`
+	DayCount         string `json:"day_count"`
+	PostingFrequency string `json:"posting_frequency"`
+}
+
+// 有掛利率產品的帳戶，用 id 分頁
+func (q *Queries) ListAccruingAccounts(ctx context.Context, arg ListAccruingAccountsParams) ([]ListAccruingAccountsRow, error) {
+	rows, err := q.query(ctx, q.listAccruingAccountsStmt, listAccruingAccounts, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccruingAccountsRow{}
+	for rows.Next() {
+		var i ListAccruingAccountsRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Currency,
+			&i.ProductID,
+			&i.AnnualRateBps,
+			&i.DayCount,
+			&i.PostingFrequency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInterestPostings = `-- name: ListInterestPostings :many
+SELECT id, account_id, period_end, units, amount, carry_units, transfer_id, created_at FROM interest_postings
+WHERE account_id = $1
+ORDER BY period_end
+`
+
+func (q *Queries) ListInterestPostings(ctx context.Context, accountID int64) ([]InterestPosting, error) {
+	rows, err := q.query(ctx, q.listInterestPostingsStmt, listInterestPostings, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []InterestPosting{}
+	for rows.Next() {
+		var i InterestPosting
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.PeriodEnd,
+			&i.Units,
+			&i.Amount,
+			&i.CarryUnits,
+			&i.TransferID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markInterestAccrualsPosted = `-- name: MarkInterestAccrualsPosted :execrows
+UPDATE interest_accruals
+  set posting_id = $1
+WHERE account_id = $2
+  AND accrual_date <= $3
+  AND posting_id IS NULL
+`
+
+type MarkInterestAccrualsPostedParams struct {
+	PostingID sql.NullInt64 `json:"posting_id"`
+	AccountID int64         `json:"account_id"`
+	PeriodEnd time.Time     `json:"period_end"`
+}
+
+func (q *Queries) MarkInterestAccrualsPosted(ctx context.Context, arg MarkInterestAccrualsPostedParams) (int64, error) {
+	result, err := q.exec(ctx, q.markInterestAccrualsPostedStmt, markInterestAccrualsPosted, arg.PostingID, arg.AccountID, arg.PeriodEnd)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setAccountProduct = `-- name: SetAccountProduct :one
+UPDATE accounts
+  set product_id = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number
+`
+
+type SetAccountProductParams struct {
+	ID        int64         `json:"id"`
+	ProductID sql.NullInt64 `json:"product_id"`
+}
+
+func (q *Queries) SetAccountProduct(ctx context.Context, arg SetAccountProductParams) (Account, error) {
+	row := q.queryRow(ctx, q.setAccountProductStmt, setAccountProduct, arg.ID, arg.ProductID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.ProductID,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.OverdraftFee,
+		&i.ClosedAt,
+		&i.AccountNumber,
+	)
+	return i, err
+}
+
+const setInterestPostingTransfer = `-- name: SetInterestPostingTransfer :one
+UPDATE interest_postings
+  set transfer_id = $2
+WHERE id = $1
+RETURNING id, account_id, period_end, units, amount, carry_units, transfer_id, created_at
+`
+
+type SetInterestPostingTransferParams struct {
+	ID         int64         `json:"id"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+}
+
+func (q *Queries) SetInterestPostingTransfer(ctx context.Context, arg SetInterestPostingTransferParams) (InterestPosting, error) {
+	row := q.queryRow(ctx, q.setInterestPostingTransferStmt, setInterestPostingTransfer, arg.ID, arg.TransferID)
+	var i InterestPosting
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.PeriodEnd,
+		&i.Units,
+		&i.Amount,
+		&i.CarryUnits,
+		&i.TransferID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const sumUnpostedInterestAccruals = `-- name: SumUnpostedInterestAccruals :one
+SELECT count(*) AS accruals, COALESCE(sum(units), 0)::bigint AS units
+FROM interest_accruals
+WHERE account_id = $1 AND accrual_date <= $2 AND posting_id IS NULL
+`
+
+type SumUnpostedInterestAccrualsParams struct {
+	AccountID int64     `json:"account_id"`
+	PeriodEnd time.Time `json:"period_end"`
+}
+
+type SumUnpostedInterestAccrualsRow struct {
+	Accruals int64 `json:"accruals"`
+	Units    int64 `json:"units"`
+}
+
+func (q *Queries) SumUnpostedInterestAccruals(ctx context.Context, arg SumUnpostedInterestAccrualsParams) (SumUnpostedInterestAccrualsRow, error) {
+	row := q.queryRow(ctx, q.sumUnpostedInterestAccrualsStmt, sumUnpostedInterestAccruals, arg.AccountID, arg.PeriodEnd)
+	var i SumUnpostedInterestAccrualsRow
+	err := row.Scan(&i.Accruals, &i.Units)
+	return i, err
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ymd(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDays30360(t *testing.T) {
+	testCases := []struct {
+		start time.Time
+		end   time.Time
+		days  int
+	}{
+		{ymd(2025, 1, 1), ymd(2025, 2, 1), 30},
+		{ymd(2025, 1, 30), ymd(2025, 1, 31), 0},
+		{ymd(2025, 1, 31), ymd(2025, 2, 1), 1},
+		{ymd(2025, 2, 28), ymd(2025, 3, 1), 3},
+		{ymd(2024, 2, 28), ymd(2024, 2, 29), 1},
+		{ymd(2024, 2, 29), ymd(2024, 3, 1), 2},
+		{ymd(2025, 1, 1), ymd(2026, 1, 1), 360},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.days, days30360(tc.start, tc.end), "%s - %s", tc.start.Format(time.DateOnly), tc.end.Format(time.DateOnly))
+	}
+}
+
+func TestAccrualDays30360MonthTotal(t *testing.T) {
+	// 30/360 每個月不管幾天，逐日加起來都是 30 天
+	for month := time.January; month <= time.December; month++ {
+		var total int32
+		for day := ymd(2024, month, 1); day.Month() == month; day = day.AddDate(0, 0, 1) {
+			days, err := accrualDays(DayCount30360, day)
+			require.NoError(t, err)
+			total += days
+		}
+		require.Equal(t, int32(30), total, month.String())
+	}
+}
+
+func TestInterestUnitsNoDrift(t *testing.T) {
+	// 1,000.00 at 3.65% ACT/365：一天 0.10，一年剛好 36.50，沒有捨入誤差
+	var total int64
+	for day := ymd(2025, 1, 1); day.Year() == 2025; day = day.AddDate(0, 0, 1) {
+		days, err := accrualDays(DayCountACT365, day)
+		require.NoError(t, err)
+		units, err := interestUnits(100000, 365, DayCountACT365, days)
+		require.NoError(t, err)
+		total += units
+	}
+	require.Zero(t, total%InterestUnitsPerMinor)
+	require.Equal(t, int64(3650), total/InterestUnitsPerMinor)
+
+	// 30/360 一年 360 天也一樣
+	total = 0
+	for day := ymd(2025, 1, 1); day.Year() == 2025; day = day.AddDate(0, 0, 1) {
+		days, err := accrualDays(DayCount30360, day)
+		require.NoError(t, err)
+		units, err := interestUnits(100000, 365, DayCount30360, days)
+		require.NoError(t, err)
+		total += units
+	}
+	require.Zero(t, total%InterestUnitsPerMinor)
+	require.Equal(t, int64(3650), total/InterestUnitsPerMinor)
+
+	// 一天不到一分錢的利息也會留著累積，不會被捨掉
+	units, err := interestUnits(1, 100, DayCountACT365, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(100*72), units)
+}
+
+func TestInterestUnitsNoInterest(t *testing.T) {
+	for _, balance := range []int64{0, -100} {
+		units, err := interestUnits(balance, 500, DayCountACT365, 1)
+		require.NoError(t, err)
+		require.Zero(t, units)
+	}
+
+	_, err := interestUnits(100, 500, "ACT/ACT", 1)
+	require.Error(t, err)
+
+	_, err = interestUnits(1<<62, 500, DayCountACT365, 1)
+	require.ErrorIs(t, err, ErrInterestOverflow)
+}
+
+func TestIsPostingDate(t *testing.T) {
+	testCases := []struct {
+		frequency string
+		date      time.Time
+		due       bool
+	}{
+		{PostingMonthly, ymd(2025, 1, 31), true},
+		{PostingMonthly, ymd(2025, 1, 30), false},
+		{PostingMonthly, ymd(2024, 2, 29), true},
+		{PostingMonthly, ymd(2025, 2, 28), true},
+		{PostingQuarterly, ymd(2025, 2, 28), false},
+		{PostingQuarterly, ymd(2025, 3, 31), true},
+		{PostingAnnually, ymd(2025, 11, 30), false},
+		{PostingAnnually, ymd(2025, 12, 31), true},
+	}
+
+	for _, tc := range testCases {
+		due, err := IsPostingDate(tc.frequency, tc.date)
+		require.NoError(t, err)
+		require.Equal(t, tc.due, due, "%s %s", tc.frequency, tc.date.Format(time.DateOnly))
+	}
+
+	_, err := IsPostingDate("weekly", ymd(2025, 1, 31))
+	require.Error(t, err)
+}
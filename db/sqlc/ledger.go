@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// postTransfer 記一筆帳戶之間的轉帳：transfer、兩筆 entries、依 id 順序更新兩邊餘額，和 TransferTx 的核心相同，
+// 給利息、手續費這類由系統帳戶出入的轉帳用；不寫 outbox / audit，也不跑 TransferTx 的檢查，由呼叫端負責
+func postTransfer(ctx context.Context, q *Queries, fromAccountID int64, toAccountID int64, amount int64) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Amount:        amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  fromAccountID,
+		Amount:     -amount,
+		TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  toAccountID,
+		Amount:     amount,
+		TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if fromAccountID < toAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, fromAccountID, -amount, toAccountID, amount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, toAccountID, amount, fromAccountID, -amount)
+	}
+	return result, err
+}
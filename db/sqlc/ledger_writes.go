@@ -0,0 +1,48 @@
+package db
+
+import "context"
+
+// 改餘額的兩個查詢手寫在這裡而不是 db/query：sqlc 產生的方法都是 exported，會掛在 Querier 和 Store（嵌入的 *Queries）上，
+// 任何呼叫端都能不寫 entries 就改餘額。只有 addMoney 和匯入的 merge 會用到，保持 unexported。
+// 查詢開頭一樣是 "-- name: X :kind"，tracing 和 replicaRouter 照樣認得。
+
+const enableLedgerWrites = `-- name: EnableLedgerWrites :exec
+SELECT set_config('bank.ledger_writes', 'on', true)
+`
+
+// enableLedgerWrites 只在目前的事務有效，讓 accounts_balance_guard 放行這個事務裡的 addAccountBalance
+func (q *Queries) enableLedgerWrites(ctx context.Context) error {
+	_, err := q.exec(ctx, nil, enableLedgerWrites)
+	return err
+}
+
+const addAccountBalance = `-- name: AddAccountBalance :one
+UPDATE accounts
+  set balance = balance + $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number
+`
+
+type addAccountBalanceParams struct {
+	Amount int64
+	ID     int64
+}
+
+func (q *Queries) addAccountBalance(ctx context.Context, arg addAccountBalanceParams) (Account, error) {
+	row := q.queryRow(ctx, nil, addAccountBalance, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.ProductID,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.OverdraftFee,
+		&i.ClosedAt,
+		&i.AccountNumber,
+	)
+	return i, err
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Outcomes of the queries and transactions recorded by Metrics
+const (
+	OutcomeOK       = "ok"
+	OutcomeError    = "error"
+	OutcomeCommit   = "commit"
+	OutcomeRollback = "rollback"
+)
+
+// Metrics contains the Prometheus collectors of the queries, transactions and connection pools of a Store
+type Metrics struct {
+	registerer    prometheus.Registerer
+	queryDuration *prometheus.HistogramVec
+	queries       *prometheus.CounterVec
+	txDuration    *prometheus.HistogramVec
+	txs           *prometheus.CounterVec
+}
+
+// NewMetrics creates the collectors of a Store and registers them with registerer, e.g. prometheus.DefaultRegisterer.
+// It panics when they are already registered, like prometheus.MustRegister.
+func NewMetrics(registerer prometheus.Registerer) *Metrics {
+	metrics := &Metrics{
+		registerer: registerer,
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "bank",
+			Subsystem: "db",
+			Name:      "query_duration_seconds",
+			Help:      "Duration of the sqlc queries, by query name and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"query", "outcome"}),
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "bank",
+			Subsystem: "db",
+			Name:      "queries_total",
+			Help:      "Number of sqlc queries, by query name, outcome and SQLSTATE of the error.",
+		}, []string{"query", "outcome", "sqlstate"}),
+		txDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "bank",
+			Subsystem: "db",
+			Name:      "transaction_duration_seconds",
+			Help:      "Duration of the Store transactions, by Store method and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"tx", "outcome"}),
+		txs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "bank",
+			Subsystem: "db",
+			Name:      "transactions_total",
+			Help:      "Number of Store transactions, by Store method, outcome and SQLSTATE of the error.",
+		}, []string{"tx", "outcome", "sqlstate"}),
+	}
+	registerer.MustRegister(metrics.queryDuration, metrics.queries, metrics.txDuration, metrics.txs)
+	return metrics
+}
+
+// WithMetrics records the queries and transactions of the Store in metrics,
+// and exports the connection pool statistics of its primary and replica as the go_sql_* gauges.
+func WithMetrics(metrics *Metrics) StoreOption {
+	return func(store *Store) {
+		store.metrics = metrics
+	}
+}
+
+// registerPool 匯出連線池的統計；同一個資料庫已經被別的 Store 註冊過的話沿用那一份
+func (metrics *Metrics) registerPool(db *sql.DB, name string) {
+	err := metrics.registerer.Register(collectors.NewDBStatsCollector(db, name))
+	var registered prometheus.AlreadyRegisteredError
+	if err != nil && !errors.As(err, &registered) {
+		panic(err)
+	}
+}
+
+// instrument 包一層 DBTX，記下每個查詢花的時間和結果；metrics 是 nil 就原樣回傳
+func (metrics *Metrics) instrument(db DBTX) DBTX {
+	if metrics == nil {
+		return db
+	}
+	return &instrumentedDBTX{DBTX: db, metrics: metrics}
+}
+
+// startTx 在 execTx 開頭呼叫，回傳的 func 在事務結束時記下結果
+func (metrics *Metrics) startTx(name string) func(outcome string, err error) {
+	if metrics == nil {
+		return func(string, error) {}
+	}
+	start := time.Now()
+	return func(outcome string, err error) {
+		metrics.txDuration.WithLabelValues(name, outcome).Observe(time.Since(start).Seconds())
+		metrics.txs.WithLabelValues(name, outcome, sqlState(err)).Inc()
+	}
+}
+
+func (metrics *Metrics) observeQuery(query string, start time.Time, err error) {
+	name := queryName(query)
+	if name == "" {
+		name = "unknown"
+	}
+	outcome := OutcomeOK
+	if err != nil {
+		outcome = OutcomeError
+	}
+	metrics.queryDuration.WithLabelValues(name, outcome).Observe(time.Since(start).Seconds())
+	metrics.queries.WithLabelValues(name, outcome, sqlState(err)).Inc()
+}
+
+// instrumentedDBTX 是記錄查詢指標的 DBTX；QueryContext 只量到拿到第一批結果為止，不含讀 rows 的時間
+type instrumentedDBTX struct {
+	DBTX
+	metrics *Metrics
+}
+
+func (db *instrumentedDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := db.DBTX.ExecContext(ctx, query, args...)
+	db.metrics.observeQuery(query, start, err)
+	return result, err
+}
+
+func (db *instrumentedDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := db.DBTX.QueryContext(ctx, query, args...)
+	db.metrics.observeQuery(query, start, err)
+	return rows, err
+}
+
+func (db *instrumentedDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := db.DBTX.QueryRowContext(ctx, query, args...)
+	// row.Err 是執行查詢的錯誤；查不到資料的 sql.ErrNoRows 要到 Scan 才知道，算成功
+	db.metrics.observeQuery(query, start, row.Err())
+	return row
+}
+
+// queryName 回傳 sqlc 產生的 "-- name: X :kind" 裡的 X，沒有名稱就回傳空字串
+func queryName(query string) string {
+	header, _, _ := strings.Cut(query, "\n")
+	if !strings.HasPrefix(header, "-- name:") {
+		return ""
+	}
+	fields := strings.Fields(strings.TrimPrefix(header, "-- name:"))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// sqlState 回傳 Postgres 錯誤的 SQLSTATE，其他錯誤和 nil 都是空字串
+func sqlState(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	return ""
+}
+
+// callerName 回傳往上 skip 層的函式名稱，只留方法名，例如 (*Store).TransferTx 是 "TransferTx"
+func callerName(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip)
+	if !ok {
+		return "unknown"
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "unknown"
+	}
+	name := fn.Name()
+	// 在 closure 裡呼叫的話名稱後面會多 .func1
+	for {
+		i := strings.LastIndex(name, ".func")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return name[strings.LastIndex(name, ".")+1:]
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// failingDBTX 的 ExecContext 一律回傳 err
+type failingDBTX struct {
+	recordingDBTX
+	err error
+}
+
+func (db *failingDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, db.err
+}
+
+func TestQueryName(t *testing.T) {
+	require.Equal(t, "CreateTransfer", queryName(createTransfer))
+	require.Equal(t, "GetAccountForUpdate", queryName(getAccountForUpdate))
+	require.Empty(t, queryName("SELECT 1"))
+	require.Empty(t, queryName("-- name:"))
+}
+
+func TestInstrumentedDBTX(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	db := metrics.instrument(&failingDBTX{err: &pq.Error{Code: "23503"}})
+
+	_, err := db.ExecContext(context.Background(), createTransfer)
+	require.Error(t, err)
+	_, err = db.QueryContext(context.Background(), listEntries)
+	require.NoError(t, err)
+	_, err = db.QueryContext(context.Background(), "SELECT 1")
+	require.NoError(t, err)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.queries.WithLabelValues("CreateTransfer", OutcomeError, "23503")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.queries.WithLabelValues("ListEntries", OutcomeOK, "")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.queries.WithLabelValues("unknown", OutcomeOK, "")))
+	require.Equal(t, 3, testutil.CollectAndCount(metrics.queryDuration))
+
+	// metrics 是 nil 就不包
+	var none *Metrics
+	recording := &recordingDBTX{}
+	require.Same(t, recording, none.instrument(recording))
+}
+
+func TestStoreWithMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics := NewMetrics(registry)
+	store := NewStore(testDB, WithMetrics(metrics))
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 1})
+	require.NoError(t, err)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: account1.Balance})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account1.ID, ToAccountID: account1.ID + 1_000_000_000, Amount: 1})
+	require.Error(t, err)
+	_, err = store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.txs.WithLabelValues("TransferTx", OutcomeCommit, "")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.txs.WithLabelValues("TransferTx", OutcomeRollback, "")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.txs.WithLabelValues("TransferTx", OutcomeRollback, "23503")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.queries.WithLabelValues("CreateTransfer", OutcomeError, "23503")))
+	// 事務裡的限額檢查也會讀帳戶
+	require.Equal(t, 4.0, testutil.ToFloat64(metrics.queries.WithLabelValues("GetAccount", OutcomeOK, "")))
+
+	// 連線池的 gauge
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	names := make([]string, 0, len(families))
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	require.Contains(t, names, "go_sql_open_connections")
+	require.Contains(t, names, "go_sql_wait_count_total")
+
+	// 同一個資料庫的連線池再註冊一次不會 panic
+	require.NotPanics(t, func() { NewStore(testDB, WithMetrics(metrics)) })
+}
+
+func TestPreparedStoreWithMetrics(t *testing.T) {
+	ctx := context.Background()
+	metrics := NewMetrics(prometheus.NewRegistry())
+	store, err := NewPreparedStore(ctx, testDB, WithMetrics(metrics))
+	require.NoError(t, err)
+	defer store.Close()
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 1})
+	require.NoError(t, err)
+
+	// prepared statement 跑的查詢一樣要記
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.txs.WithLabelValues("TransferTx", OutcomeCommit, "")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.queries.WithLabelValues("CreateTransfer", OutcomeOK, "")))
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.queries.WithLabelValues("CreateEntry", OutcomeOK, "")))
+}
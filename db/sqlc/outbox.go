@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Aggregate types recorded in the outbox
+const (
+	AggregateAccount          = "account"
+	AggregateTransfer         = "transfer"
+	AggregateExternalTransfer = "external_transfer"
+)
+
+// Event types recorded in the outbox
+const (
+	EventAccountCreated          = "account.created"
+	EventAccountUpdated          = "account.updated"
+	EventAccountDeleted          = "account.deleted"
+	EventTransferCreated         = "transfer.created"
+	EventExternalTransferSettled = "external_transfer.settled"
+	EventExternalTransferFailed  = "external_transfer.failed"
+)
+
+// AccountDeletedPayload is the payload of an account.deleted event
+type AccountDeletedPayload struct {
+	ID int64 `json:"id"`
+}
+
+// recordOutboxEvent 把事件寫進 outbox 表。
+// 一定要傳入 execTx 給的 q（綁在同一個 tx 上），這樣事件和業務資料會一起 Commit 或一起 Rollback：
+// 不會發生「轉帳成功但事件遺失」或「事件送出但轉帳被回滾」的情況。
+func recordOutboxEvent(ctx context.Context, q *Queries, aggregateType string, aggregateID int64, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("cannot marshal %s payload: %w", eventType, err)
+	}
+
+	_, err = q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       data,
+	})
+	return err
+}
+
+// ProcessOutboxTx locks up to limit pending outbox events in id order and passes them to handle one by one.
+// Every event that handle accepts is marked as delivered in the same transaction.
+// Processing stops at the first handler error so that events are never delivered out of order;
+// the events handled before it are still committed, and the handler error is returned together with their count.
+// If the commit fails the events will be handed out again, so delivery is at-least-once.
+func (store *Store) ProcessOutboxTx(ctx context.Context, limit int32, handle func(Outbox) error) (int, error) {
+	var delivered int
+	var handleErr error
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// ListPendingOutboxEvents 用 FOR UPDATE 鎖住這批事件，同時跑多個 relay 時後來的會等前一個 Commit，
+		// 再重新檢查 delivered_at，所以同一個事件不會被兩個 relay 同時送出，順序也不會亂。
+		events, err := q.ListPendingOutboxEvents(ctx, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if handleErr = handle(event); handleErr != nil {
+				// 不回傳 error：前面已經送出的事件仍然要 Commit 標記為已送達
+				return nil
+			}
+
+			if err := q.MarkOutboxEventDelivered(ctx, event.ID); err != nil {
+				return err
+			}
+			delivered++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return delivered, handleErr
+}
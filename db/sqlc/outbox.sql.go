@@ -0,0 +1,159 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: outbox.sql
+
+package db
+
+import (
+	"context"
+	"encoding/json"
+)
+
+const createOutboxEvent = `-- name: CreateOutboxEvent :one
+INSERT INTO outbox (
+  aggregate_type, aggregate_id, event_type, payload
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING id, aggregate_type, aggregate_id, event_type, payload, created_at, delivered_at
+`
+
+type CreateOutboxEventParams struct {
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (Outbox, error) {
+	row := q.queryRow(ctx, q.createOutboxEventStmt, createOutboxEvent,
+		arg.AggregateType,
+		arg.AggregateID,
+		arg.EventType,
+		arg.Payload,
+	)
+	var i Outbox
+	err := row.Scan(
+		&i.ID,
+		&i.AggregateType,
+		&i.AggregateID,
+		&i.EventType,
+		&i.Payload,
+		&i.CreatedAt,
+		&i.DeliveredAt,
+	)
+	return i, err
+}
+
+const getOutboxEvent = `-- name: GetOutboxEvent :one
+SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at, delivered_at FROM outbox
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetOutboxEvent(ctx context.Context, id int64) (Outbox, error) {
+	row := q.queryRow(ctx, q.getOutboxEventStmt, getOutboxEvent, id)
+	var i Outbox
+	err := row.Scan(
+		&i.ID,
+		&i.AggregateType,
+		&i.AggregateID,
+		&i.EventType,
+		&i.Payload,
+		&i.CreatedAt,
+		&i.DeliveredAt,
+	)
+	return i, err
+}
+
+const listOutboxEventsByAggregate = `-- name: ListOutboxEventsByAggregate :many
+SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at, delivered_at FROM outbox
+WHERE aggregate_type = $1 AND aggregate_id = $2
+ORDER BY id
+`
+
+type ListOutboxEventsByAggregateParams struct {
+	AggregateType string `json:"aggregate_type"`
+	AggregateID   int64  `json:"aggregate_id"`
+}
+
+func (q *Queries) ListOutboxEventsByAggregate(ctx context.Context, arg ListOutboxEventsByAggregateParams) ([]Outbox, error) {
+	rows, err := q.query(ctx, q.listOutboxEventsByAggregateStmt, listOutboxEventsByAggregate, arg.AggregateType, arg.AggregateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Outbox{}
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateType,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingOutboxEvents = `-- name: ListPendingOutboxEvents :many
+SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at, delivered_at FROM outbox
+WHERE delivered_at IS NULL
+ORDER BY id
+LIMIT $1
+FOR UPDATE
+`
+
+func (q *Queries) ListPendingOutboxEvents(ctx context.Context, limit int32) ([]Outbox, error) {
+	rows, err := q.query(ctx, q.listPendingOutboxEventsStmt, listPendingOutboxEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Outbox{}
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateType,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOutboxEventDelivered = `-- name: MarkOutboxEventDelivered :exec
+UPDATE outbox
+  set delivered_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) MarkOutboxEventDelivered(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.markOutboxEventDeliveredStmt, markOutboxEventDelivered, id)
+	return err
+}
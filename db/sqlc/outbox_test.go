@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreCreateAccountOutbox(t *testing.T) {
+	store := NewStore(testDB)
+
+	account, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    util.RandomOwner(),
+		Balance:  util.RandomMoney(),
+		Currency: util.RandomCurrency(),
+	})
+	require.NoError(t, err)
+
+	events, err := store.ListOutboxEventsByAggregate(context.Background(), ListOutboxEventsByAggregateParams{
+		AggregateType: AggregateAccount,
+		AggregateID:   account.ID,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, EventAccountCreated, events[0].EventType)
+	require.False(t, events[0].DeliveredAt.Valid)
+
+	var payload Account
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+	require.Equal(t, account.ID, payload.ID)
+	require.Equal(t, account.Owner, payload.Owner)
+}
+
+func TestTransferTxOutbox(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	events, err := store.ListOutboxEventsByAggregate(context.Background(), ListOutboxEventsByAggregateParams{
+		AggregateType: AggregateTransfer,
+		AggregateID:   result.Transfer.ID,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, EventTransferCreated, events[0].EventType)
+
+	var payload TransferTxResult
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+	require.Equal(t, result.Transfer.ID, payload.Transfer.ID)
+	require.Equal(t, result.FromAccount.Balance, payload.FromAccount.Balance)
+	require.Equal(t, result.ToAccount.Balance, payload.ToAccount.Balance)
+}
+
+func TestProcessOutboxTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    util.RandomOwner(),
+		Balance:  util.RandomMoney(),
+		Currency: util.RandomCurrency(),
+	})
+	require.NoError(t, err)
+
+	events, err := store.ListOutboxEventsByAggregate(context.Background(), ListOutboxEventsByAggregateParams{
+		AggregateType: AggregateAccount,
+		AggregateID:   account.ID,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	eventID := events[0].ID
+
+	// 其他測試也會寫入 outbox，所以一直處理到我們的事件被送出為止，並檢查順序是遞增的
+	var lastID int64
+	delivered := false
+	for !delivered {
+		n, err := store.ProcessOutboxTx(context.Background(), 100, func(event Outbox) error {
+			require.Greater(t, event.ID, lastID)
+			lastID = event.ID
+			if event.ID == eventID {
+				delivered = true
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.True(t, delivered || n > 0)
+	}
+
+	event, err := store.GetOutboxEvent(context.Background(), eventID)
+	require.NoError(t, err)
+	require.True(t, event.DeliveredAt.Valid)
+}
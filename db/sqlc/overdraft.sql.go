@@ -0,0 +1,394 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: overdraft.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createOverdraftCharge = `-- name: CreateOverdraftCharge :one
+INSERT INTO overdraft_charges (
+  account_id, period_start, period_end, overdrawn_days, rate_bps, units, fee, amount, carry_units
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING id, account_id, period_start, period_end, overdrawn_days, rate_bps, units, fee, amount, carry_units, transfer_id, created_at
+`
+
+type CreateOverdraftChargeParams struct {
+	AccountID     int64     `json:"account_id"`
+	PeriodStart   time.Time `json:"period_start"`
+	PeriodEnd     time.Time `json:"period_end"`
+	OverdrawnDays int32     `json:"overdrawn_days"`
+	RateBps       int32     `json:"rate_bps"`
+	Units         int64     `json:"units"`
+	Fee           int64     `json:"fee"`
+	Amount        int64     `json:"amount"`
+	CarryUnits    int64     `json:"carry_units"`
+}
+
+func (q *Queries) CreateOverdraftCharge(ctx context.Context, arg CreateOverdraftChargeParams) (OverdraftCharge, error) {
+	row := q.queryRow(ctx, q.createOverdraftChargeStmt, createOverdraftCharge,
+		arg.AccountID,
+		arg.PeriodStart,
+		arg.PeriodEnd,
+		arg.OverdrawnDays,
+		arg.RateBps,
+		arg.Units,
+		arg.Fee,
+		arg.Amount,
+		arg.CarryUnits,
+	)
+	var i OverdraftCharge
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.OverdrawnDays,
+		&i.RateBps,
+		&i.Units,
+		&i.Fee,
+		&i.Amount,
+		&i.CarryUnits,
+		&i.TransferID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLastOverdraftCharge = `-- name: GetLastOverdraftCharge :one
+SELECT id, account_id, period_start, period_end, overdrawn_days, rate_bps, units, fee, amount, carry_units, transfer_id, created_at FROM overdraft_charges
+WHERE account_id = $1
+ORDER BY period_end DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastOverdraftCharge(ctx context.Context, accountID int64) (OverdraftCharge, error) {
+	row := q.queryRow(ctx, q.getLastOverdraftChargeStmt, getLastOverdraftCharge, accountID)
+	var i OverdraftCharge
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.OverdrawnDays,
+		&i.RateBps,
+		&i.Units,
+		&i.Fee,
+		&i.Amount,
+		&i.CarryUnits,
+		&i.TransferID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getOverdraftCharge = `-- name: GetOverdraftCharge :one
+SELECT id, account_id, period_start, period_end, overdrawn_days, rate_bps, units, fee, amount, carry_units, transfer_id, created_at FROM overdraft_charges
+WHERE account_id = $1 AND period_end = $2 LIMIT 1
+`
+
+type GetOverdraftChargeParams struct {
+	AccountID int64     `json:"account_id"`
+	PeriodEnd time.Time `json:"period_end"`
+}
+
+func (q *Queries) GetOverdraftCharge(ctx context.Context, arg GetOverdraftChargeParams) (OverdraftCharge, error) {
+	row := q.queryRow(ctx, q.getOverdraftChargeStmt, getOverdraftCharge, arg.AccountID, arg.PeriodEnd)
+	var i OverdraftCharge
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.OverdrawnDays,
+		&i.RateBps,
+		&i.Units,
+		&i.Fee,
+		&i.Amount,
+		&i.CarryUnits,
+		&i.TransferID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listChargeableOverdraftAccounts = `-- name: ListChargeableOverdraftAccounts :many
+SELECT id AS account_id, currency, overdraft_rate_bps, overdraft_fee
+FROM accounts
+WHERE (overdraft_rate_bps > 0 OR overdraft_fee > 0) AND id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListChargeableOverdraftAccountsParams struct {
+	AfterID    int64 `json:"after_id"`
+	LimitCount int32 `json:"limit_count"`
+}
+
+type ListChargeableOverdraftAccountsRow struct {
+	AccountID        int64  `json:"account_id"`
+	Currency         string `json:"currency"`
+	OverdraftRateBps int32  `json:"overdraft_rate_bps"`
+	OverdraftFee     int64  `json:"overdraft_fee"`
+}
+
+// 有設透支利率或手續費的帳戶，用 id 分頁
+func (q *Queries) ListChargeableOverdraftAccounts(ctx context.Context, arg ListChargeableOverdraftAccountsParams) ([]ListChargeableOverdraftAccountsRow, error) {
+	rows, err := q.query(ctx, q.listChargeableOverdraftAccountsStmt, listChargeableOverdraftAccounts, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListChargeableOverdraftAccountsRow{}
+	for rows.Next() {
+		var i ListChargeableOverdraftAccountsRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Currency,
+			&i.OverdraftRateBps,
+			&i.OverdraftFee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOverdraftCharges = `-- name: ListOverdraftCharges :many
+SELECT id, account_id, period_start, period_end, overdrawn_days, rate_bps, units, fee, amount, carry_units, transfer_id, created_at FROM overdraft_charges
+WHERE account_id = $1
+ORDER BY period_end
+`
+
+func (q *Queries) ListOverdraftCharges(ctx context.Context, accountID int64) ([]OverdraftCharge, error) {
+	rows, err := q.query(ctx, q.listOverdraftChargesStmt, listOverdraftCharges, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OverdraftCharge{}
+	for rows.Next() {
+		var i OverdraftCharge
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.PeriodStart,
+			&i.PeriodEnd,
+			&i.OverdrawnDays,
+			&i.RateBps,
+			&i.Units,
+			&i.Fee,
+			&i.Amount,
+			&i.CarryUnits,
+			&i.TransferID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOverdraftUsage = `-- name: ListOverdraftUsage :many
+SELECT id AS account_id, owner, currency, balance, overdraft_limit,
+  (-balance)::bigint AS used,
+  (overdraft_limit + balance)::bigint AS available
+FROM accounts
+WHERE balance < 0 AND owner NOT LIKE 'system:%'
+ORDER BY balance, id
+LIMIT $1
+OFFSET $2
+`
+
+type ListOverdraftUsageParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+type ListOverdraftUsageRow struct {
+	AccountID      int64  `json:"account_id"`
+	Owner          string `json:"owner"`
+	Currency       string `json:"currency"`
+	Balance        int64  `json:"balance"`
+	OverdraftLimit int64  `json:"overdraft_limit"`
+	Used           int64  `json:"used"`
+	Available      int64  `json:"available"`
+}
+
+// 目前透支中的帳戶，用得最多的排前面
+func (q *Queries) ListOverdraftUsage(ctx context.Context, arg ListOverdraftUsageParams) ([]ListOverdraftUsageRow, error) {
+	rows, err := q.query(ctx, q.listOverdraftUsageStmt, listOverdraftUsage, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListOverdraftUsageRow{}
+	for rows.Next() {
+		var i ListOverdraftUsageRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Owner,
+			&i.Currency,
+			&i.Balance,
+			&i.OverdraftLimit,
+			&i.Used,
+			&i.Available,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setAccountOverdraft = `-- name: SetAccountOverdraft :one
+UPDATE accounts
+  set overdraft_limit = $2,
+  overdraft_rate_bps = $3,
+  overdraft_fee = $4
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number
+`
+
+type SetAccountOverdraftParams struct {
+	ID               int64 `json:"id"`
+	OverdraftLimit   int64 `json:"overdraft_limit"`
+	OverdraftRateBps int32 `json:"overdraft_rate_bps"`
+	OverdraftFee     int64 `json:"overdraft_fee"`
+}
+
+func (q *Queries) SetAccountOverdraft(ctx context.Context, arg SetAccountOverdraftParams) (Account, error) {
+	row := q.queryRow(ctx, q.setAccountOverdraftStmt, setAccountOverdraft,
+		arg.ID,
+		arg.OverdraftLimit,
+		arg.OverdraftRateBps,
+		arg.OverdraftFee,
+	)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.ProductID,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.OverdraftFee,
+		&i.ClosedAt,
+		&i.AccountNumber,
+	)
+	return i, err
+}
+
+const setOverdraftChargeTransfer = `-- name: SetOverdraftChargeTransfer :one
+UPDATE overdraft_charges
+  set transfer_id = $2
+WHERE id = $1
+RETURNING id, account_id, period_start, period_end, overdrawn_days, rate_bps, units, fee, amount, carry_units, transfer_id, created_at
+`
+
+type SetOverdraftChargeTransferParams struct {
+	ID         int64         `json:"id"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+}
+
+func (q *Queries) SetOverdraftChargeTransfer(ctx context.Context, arg SetOverdraftChargeTransferParams) (OverdraftCharge, error) {
+	row := q.queryRow(ctx, q.setOverdraftChargeTransferStmt, setOverdraftChargeTransfer, arg.ID, arg.TransferID)
+	var i OverdraftCharge
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.OverdrawnDays,
+		&i.RateBps,
+		&i.Units,
+		&i.Fee,
+		&i.Amount,
+		&i.CarryUnits,
+		&i.TransferID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const summarizeOverdraftUsage = `-- name: SummarizeOverdraftUsage :many
+SELECT currency,
+  count(*) FILTER (WHERE overdraft_limit > 0) AS accounts_with_limit,
+  count(*) FILTER (WHERE balance < 0) AS overdrawn_accounts,
+  count(*) FILTER (WHERE balance < -overdraft_limit) AS over_limit_accounts,
+  COALESCE(sum(overdraft_limit), 0)::bigint AS total_limit,
+  COALESCE(sum(-balance) FILTER (WHERE balance < 0), 0)::bigint AS total_used
+FROM accounts
+WHERE owner NOT LIKE 'system:%'
+GROUP BY currency
+ORDER BY currency
+`
+
+type SummarizeOverdraftUsageRow struct {
+	Currency          string `json:"currency"`
+	AccountsWithLimit int64  `json:"accounts_with_limit"`
+	OverdrawnAccounts int64  `json:"overdrawn_accounts"`
+	OverLimitAccounts int64  `json:"over_limit_accounts"`
+	TotalLimit        int64  `json:"total_limit"`
+	TotalUsed         int64  `json:"total_used"`
+}
+
+// 各幣別的額度和使用量；超過額度的帳戶（例如被調帳或收費扣到額度以下）另外計數
+func (q *Queries) SummarizeOverdraftUsage(ctx context.Context) ([]SummarizeOverdraftUsageRow, error) {
+	rows, err := q.query(ctx, q.summarizeOverdraftUsageStmt, summarizeOverdraftUsage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SummarizeOverdraftUsageRow{}
+	for rows.Next() {
+		var i SummarizeOverdraftUsageRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.AccountsWithLimit,
+			&i.OverdrawnAccounts,
+			&i.OverLimitAccounts,
+			&i.TotalLimit,
+			&i.TotalUsed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
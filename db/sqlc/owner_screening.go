@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrOwnerBlocked is returned by CreateAccount when the owner is blocked by the name screener,
+// ImportAccountsTx rejects the row with it and DepositTx fails the deposit with it
+var ErrOwnerBlocked = errors.New("owner blocked by screening")
+
+// NameScreening is the decision of a NameScreener about one name
+type NameScreening struct {
+	Outcome RuleOutcome `json:"outcome"`
+	// NormalizedName is the name as it was compared
+	NormalizedName string `json:"normalized_name"`
+	// Score, EntryID and MatchedName describe the best match, they are empty when nothing matched
+	Score       float64 `json:"score"`
+	EntryID     string  `json:"entry_id"`
+	MatchedName string  `json:"matched_name"`
+}
+
+// NameScreener screens names against a watch list, e.g. *sanctions.Screener
+type NameScreener interface {
+	ScreenName(name string) NameScreening
+}
+
+// WithOwnerScreening makes CreateAccount and ImportAccountsTx screen the owner of every new account with screener and store the decision
+func WithOwnerScreening(screener NameScreener) StoreOption {
+	return func(store *Store) {
+		store.ownerScreener = screener
+	}
+}
+
+// screenOwner 記下 owner 的篩查結果；accountID 是 null 表示開戶被擋下
+func screenOwner(ctx context.Context, q *Queries, screening NameScreening, owner string, accountID sql.NullInt64) (ScreeningResult, error) {
+	return q.CreateScreeningResult(ctx, CreateScreeningResultParams{
+		AccountID:      accountID,
+		Name:           owner,
+		NormalizedName: screening.NormalizedName,
+		Outcome:        string(screening.Outcome),
+		Score:          screening.Score,
+		EntryID:        screening.EntryID,
+		MatchedName:    screening.MatchedName,
+	})
+}
+
+// createScreenedAccount 是有 ownerScreener 時的 CreateAccount：block 的話只 Commit 篩查結果和 audit log，不開戶
+func (store *Store) createScreenedAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	screening := store.ownerScreener.ScreenName(arg.Owner)
+	if screening.Outcome != RuleBlock {
+		return store.createAccount(ctx, arg, func(q *Queries, account Account) error {
+			_, err := screenOwner(ctx, q, screening, arg.Owner, sql.NullInt64{Int64: account.ID, Valid: true})
+			return err
+		})
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result, err := screenOwner(ctx, q, screening, arg.Owner, sql.NullInt64{})
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, AuditActionCreate, AuditEntityScreeningResult, strconv.FormatInt(result.ID, 10), nil, result)
+	})
+	if err != nil {
+		return Account{}, err
+	}
+	return Account{}, ownerBlockedError(arg.Owner, screening)
+}
+
+func ownerBlockedError(owner string, screening NameScreening) error {
+	return fmt.Errorf("%w: %q matches %q (entry %s) with score %.2f", ErrOwnerBlocked, owner, screening.MatchedName, screening.EntryID, screening.Score)
+}
+
+// OwnerScreeningRule screens the owners of the accounts sending and receiving a transfer with a NameScreener,
+// and stores every decision, allowed ones included, as a screening result of the account.
+// Owners are screened again on every transfer, so a list update also catches accounts opened before it.
+// DepositTx screens the owner receiving a deposit with it too.
+type OwnerScreeningRule struct {
+	Screener NameScreener
+}
+
+// Name implements TransferRule
+func (rule OwnerScreeningRule) Name() string {
+	return "owner_screening"
+}
+
+// Evaluate implements TransferRule
+func (rule OwnerScreeningRule) Evaluate(ctx context.Context, q Querier, transfer ScreenedTransfer) (RuleResult, error) {
+	from, err := rule.screenAccount(ctx, q, transfer.FromAccount, "sending")
+	if err != nil {
+		return from, err
+	}
+	to, err := rule.screenAccount(ctx, q, transfer.ToAccount, "receiving")
+	if err != nil {
+		return to, err
+	}
+	return strongerRuleResult(from, to), nil
+}
+
+// screenAccount 篩查帳戶的 owner 並記下決定，放行的也記；role 寫在 reason 裡，說明是哪一邊的帳戶
+func (rule OwnerScreeningRule) screenAccount(ctx context.Context, q Querier, account Account, role string) (RuleResult, error) {
+	// 系統帳戶的 owner 是 system:<purpose>，不是真的名字
+	if strings.HasPrefix(account.Owner, systemAccountOwner("")) {
+		return RuleResult{Outcome: RuleAllow}, nil
+	}
+
+	screening := rule.Screener.ScreenName(account.Owner)
+	_, err := q.CreateScreeningResult(ctx, CreateScreeningResultParams{
+		AccountID:      sql.NullInt64{Int64: account.ID, Valid: true},
+		Name:           account.Owner,
+		NormalizedName: screening.NormalizedName,
+		Outcome:        string(screening.Outcome),
+		Score:          screening.Score,
+		EntryID:        screening.EntryID,
+		MatchedName:    screening.MatchedName,
+	})
+	if err != nil || screening.Outcome == RuleAllow {
+		return RuleResult{Outcome: RuleAllow}, err
+	}
+
+	return RuleResult{
+		Outcome: screening.Outcome,
+		// reason 會寫進 review case 和 audit log，只記帳戶 id，不記 owner 的名字
+		Reason: fmt.Sprintf("owner of %s account %d matches %q (entry %s) with score %.2f", role, account.ID, screening.MatchedName, screening.EntryID, screening.Score),
+	}, nil
+}
+
+// strongerRuleResult 取比較嚴重的結果，一樣嚴重的話 reason 接在一起
+func strongerRuleResult(a RuleResult, b RuleResult) RuleResult {
+	switch {
+	case ruleOutcomeRank[b.Outcome] > ruleOutcomeRank[a.Outcome]:
+		return b
+	case ruleOutcomeRank[b.Outcome] < ruleOutcomeRank[a.Outcome] || b.Outcome == RuleAllow:
+		return a
+	}
+	return RuleResult{Outcome: a.Outcome, Reason: a.Reason + "; " + b.Reason}
+}
+
+// screenDeposit 用 store 的每條 OwnerScreeningRule 篩查收入金的帳戶，回傳最嚴重的結果；
+// 入金沒有轉出帳戶，其他規則看的是轉出方的行為，所以不跑
+func (store *Store) screenDeposit(ctx context.Context, q *Queries, account Account) (RuleResult, error) {
+	result := RuleResult{Outcome: RuleAllow}
+	for _, rule := range store.rules {
+		ownerRule, ok := rule.(OwnerScreeningRule)
+		if !ok {
+			continue
+		}
+		ruleResult, err := ownerRule.screenAccount(ctx, q, account, "receiving")
+		if err != nil {
+			return result, fmt.Errorf("rule %s: %w", ownerRule.Name(), err)
+		}
+		result = strongerRuleResult(result, ruleResult)
+	}
+	return result, nil
+}
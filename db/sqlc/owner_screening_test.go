@@ -0,0 +1,198 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNameScreener returns the screening stored for a name, other names are allowed
+type fakeNameScreener map[string]NameScreening
+
+func (screener fakeNameScreener) ScreenName(name string) NameScreening {
+	if screening, ok := screener[name]; ok {
+		return screening
+	}
+	return NameScreening{Outcome: RuleAllow, NormalizedName: name}
+}
+
+// fakeScreeningResults keeps the screening results created by OwnerScreeningRule, the other methods of Querier are not used
+type fakeScreeningResults struct {
+	Querier
+	results []CreateScreeningResultParams
+}
+
+func (q *fakeScreeningResults) CreateScreeningResult(ctx context.Context, arg CreateScreeningResultParams) (ScreeningResult, error) {
+	q.results = append(q.results, arg)
+	return ScreeningResult{}, nil
+}
+
+func TestOwnerScreeningRuleEvaluate(t *testing.T) {
+	rule := OwnerScreeningRule{Screener: fakeNameScreener{
+		"mallory": {Outcome: RuleHold, NormalizedName: "mallory", Score: 0.9, EntryID: "SDN-1", MatchedName: "MALLORY"},
+		"trudy":   {Outcome: RuleBlock, NormalizedName: "trudy", Score: 1, EntryID: "SDN-2", MatchedName: "TRUDY"},
+	}}
+
+	testCases := []struct {
+		name    string
+		from    string
+		to      string
+		outcome RuleOutcome
+		results int
+	}{
+		{"both allowed", "alice", "bob", RuleAllow, 2},
+		{"sender held", "mallory", "bob", RuleHold, 2},
+		{"receiver blocked", "alice", "trudy", RuleBlock, 2},
+		{"block wins", "mallory", "trudy", RuleBlock, 2},
+		// 系統帳戶不篩查也不記
+		{"system sender", systemAccountOwner("clearing"), "trudy", RuleBlock, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := &fakeScreeningResults{}
+			result, err := rule.Evaluate(context.Background(), q, ScreenedTransfer{
+				FromAccount: Account{ID: 1, Owner: tc.from},
+				ToAccount:   Account{ID: 2, Owner: tc.to},
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.outcome, result.Outcome)
+			require.Len(t, q.results, tc.results)
+			require.NotContains(t, result.Reason, tc.from)
+			require.NotContains(t, result.Reason, tc.to)
+		})
+	}
+}
+
+func TestCreateAccountOwnerScreening(t *testing.T) {
+	blocked, flagged, clear := util.RandomOwner(), util.RandomOwner(), util.RandomOwner()
+	screener := fakeNameScreener{
+		blocked: {Outcome: RuleBlock, NormalizedName: blocked, Score: 0.98, EntryID: "SDN-1", MatchedName: blocked},
+		flagged: {Outcome: RuleHold, NormalizedName: flagged, Score: 0.9, EntryID: "SDN-2", MatchedName: flagged},
+	}
+	store := NewStore(testDB, WithOwnerScreening(screener))
+
+	_, err := store.CreateAccount(context.Background(), CreateAccountParams{Owner: blocked, Balance: 100, Currency: util.RandomCurrency()})
+	require.ErrorIs(t, err, ErrOwnerBlocked)
+
+	// 擋下的決定也留著
+	results, err := store.ListScreeningResults(context.Background(), ListScreeningResultsParams{Outcome: string(RuleBlock), LimitCount: 1000})
+	require.NoError(t, err)
+	var found bool
+	for _, result := range results {
+		if result.Name == blocked {
+			found = true
+			require.False(t, result.AccountID.Valid)
+			require.Equal(t, "SDN-1", result.EntryID)
+		}
+	}
+	require.True(t, found)
+
+	for _, tc := range []struct {
+		owner   string
+		outcome RuleOutcome
+	}{
+		{flagged, RuleHold},
+		{clear, RuleAllow},
+	} {
+		account, err := store.CreateAccount(context.Background(), CreateAccountParams{Owner: tc.owner, Balance: 100, Currency: util.RandomCurrency()})
+		require.NoError(t, err)
+
+		results, err := store.ListScreeningResultsByAccount(context.Background(), sql.NullInt64{Int64: account.ID, Valid: true})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, string(tc.outcome), results[0].Outcome)
+	}
+}
+
+func TestTransferTxOwnerScreeningRule(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	screener := fakeNameScreener{
+		account1.Owner: {Outcome: RuleBlock, NormalizedName: account1.Owner, Score: 1, EntryID: "SDN-1", MatchedName: account1.Owner},
+	}
+	store := NewStore(testDB, WithTransferRules(OwnerScreeningRule{Screener: screener}))
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrTransferBlocked)
+	var screening *ScreeningError
+	require.True(t, errors.As(err, &screening))
+	require.Equal(t, "owner_screening", screening.Hits[0].Rule)
+
+	// 收款方也篩查，列名的 account1 也不能收款
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrTransferBlocked)
+	require.True(t, errors.As(err, &screening))
+	require.Contains(t, screening.Hits[0].Reason, "receiving")
+
+	// 放行的決定也留著
+	account3 := createRandomAccount(t)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account3.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	results, err := store.ListScreeningResultsByAccount(context.Background(), sql.NullInt64{Int64: account3.ID, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, string(RuleAllow), results[0].Outcome)
+}
+
+func TestDepositTxOwnerScreening(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	screener := fakeNameScreener{
+		account1.Owner: {Outcome: RuleBlock, NormalizedName: account1.Owner, Score: 1, EntryID: "SDN-1", MatchedName: account1.Owner},
+	}
+	store := NewStore(testDB, WithTransferRules(OwnerScreeningRule{Screener: screener}))
+
+	arg := ExternalTransferTxParams{
+		AccountID:   account1.ID,
+		Amount:      10,
+		ExternalRef: "dep-" + util.RandomString(12, false),
+	}
+	result, err := store.DepositTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrOwnerBlocked)
+	require.Equal(t, ExternalFailed, result.ExternalTransfer.Status)
+
+	// 重送拿到的是同一筆失敗的入金
+	replay, err := store.DepositTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, result.ExternalTransfer.ID, replay.ExternalTransfer.ID)
+	require.Equal(t, ExternalFailed, replay.ExternalTransfer.Status)
+
+	_, err = store.SettleExternalTransferTx(context.Background(), result.ExternalTransfer.ID)
+	require.ErrorIs(t, err, ErrExternalTransferNotPending)
+
+	results, err := store.ListScreeningResultsByAccount(context.Background(), sql.NullInt64{Int64: account1.ID, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, string(RuleBlock), results[0].Outcome)
+
+	allowed, err := store.DepositTx(context.Background(), ExternalTransferTxParams{
+		AccountID:   account2.ID,
+		Amount:      10,
+		ExternalRef: "dep-" + util.RandomString(12, false),
+	})
+	require.NoError(t, err)
+	require.Equal(t, ExternalPending, allowed.ExternalTransfer.Status)
+
+	results, err = store.ListScreeningResultsByAccount(context.Background(), sql.NullInt64{Int64: account2.ID, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, string(RuleAllow), results[0].Outcome)
+}
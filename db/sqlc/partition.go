@@ -0,0 +1,24 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBalanceArchived is returned when a balance is asked for at a time inside a detached ledger partition
+// holding entries of the account: the entries of that month before the time are no longer in entries
+var ErrBalanceArchived = errors.New("balance falls in an archived ledger partition")
+
+// balanceAt 是 GetBalanceAt 的餘額；at 落在卸下的月份裡面的話算不出來，回傳 ErrBalanceArchived
+func balanceAt(ctx context.Context, q *Queries, arg GetBalanceAtParams) (int64, error) {
+	row, err := q.GetBalanceAt(ctx, arg)
+	if err != nil {
+		return 0, err
+	}
+	if row.Archived {
+		return 0, fmt.Errorf("%w: account %d at %s", ErrBalanceArchived, arg.AccountID, arg.At.UTC().Format(time.RFC3339))
+	}
+	return row.Balance, nil
+}
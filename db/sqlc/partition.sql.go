@@ -0,0 +1,149 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: partition.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const createLedgerPartitions = `-- name: CreateLedgerPartitions :many
+SELECT create_ledger_partitions($1::date, $2::date)::text AS partition
+`
+
+type CreateLedgerPartitionsParams struct {
+	FromMonth    time.Time `json:"from_month"`
+	ThroughMonth time.Time `json:"through_month"`
+}
+
+// 建好 entries 和 transfers 從 from_month 到 through_month 的月份分區，回傳新建的分區
+func (q *Queries) CreateLedgerPartitions(ctx context.Context, arg CreateLedgerPartitionsParams) ([]string, error) {
+	rows, err := q.query(ctx, q.createLedgerPartitionsStmt, createLedgerPartitions, arg.FromMonth, arg.ThroughMonth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var partition string
+		if err := rows.Scan(&partition); err != nil {
+			return nil, err
+		}
+		items = append(items, partition)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const detachLedgerPartitions = `-- name: DetachLedgerPartitions :many
+SELECT detach_ledger_partitions($1::date, $2::text)::text AS partition
+`
+
+type DetachLedgerPartitionsParams struct {
+	DetachBefore  time.Time `json:"detach_before"`
+	ArchiveSchema string    `json:"archive_schema"`
+}
+
+// 卸下在 detach_before 之前結束的月份分區，archive_schema 不是空字串時搬到那個 schema
+func (q *Queries) DetachLedgerPartitions(ctx context.Context, arg DetachLedgerPartitionsParams) ([]string, error) {
+	rows, err := q.query(ctx, q.detachLedgerPartitionsStmt, detachLedgerPartitions, arg.DetachBefore, arg.ArchiveSchema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var partition string
+		if err := rows.Scan(&partition); err != nil {
+			return nil, err
+		}
+		items = append(items, partition)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listArchivedEntryTotals = `-- name: ListArchivedEntryTotals :many
+SELECT partition_name, account_id, amount, archived_before, created_at FROM archived_entry_totals
+WHERE account_id = $1
+ORDER BY archived_before
+`
+
+func (q *Queries) ListArchivedEntryTotals(ctx context.Context, accountID int64) ([]ArchivedEntryTotal, error) {
+	rows, err := q.query(ctx, q.listArchivedEntryTotalsStmt, listArchivedEntryTotals, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ArchivedEntryTotal{}
+	for rows.Next() {
+		var i ArchivedEntryTotal
+		if err := rows.Scan(
+			&i.PartitionName,
+			&i.AccountID,
+			&i.Amount,
+			&i.ArchivedBefore,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listArchivedEntryTotalsByAccounts = `-- name: ListArchivedEntryTotalsByAccounts :many
+SELECT partition_name, account_id, amount, archived_before, created_at FROM archived_entry_totals
+WHERE account_id = ANY($1::bigint[])
+ORDER BY account_id, archived_before
+`
+
+func (q *Queries) ListArchivedEntryTotalsByAccounts(ctx context.Context, accountIds []int64) ([]ArchivedEntryTotal, error) {
+	rows, err := q.query(ctx, q.listArchivedEntryTotalsByAccountsStmt, listArchivedEntryTotalsByAccounts, pq.Array(accountIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ArchivedEntryTotal{}
+	for rows.Next() {
+		var i ArchivedEntryTotal
+		if err := rows.Scan(
+			&i.PartitionName,
+			&i.AccountID,
+			&i.Amount,
+			&i.ArchivedBefore,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateLedgerPartitions 重跑不會重建已經有的分區
+func TestCreateLedgerPartitions(t *testing.T) {
+	throughMonth := time.Now().UTC().AddDate(0, 2, 0)
+	arg := CreateLedgerPartitionsParams{FromMonth: time.Now().UTC(), ThroughMonth: throughMonth}
+	_, err := testQueries.CreateLedgerPartitions(context.Background(), arg)
+	require.NoError(t, err)
+
+	created, err := testQueries.CreateLedgerPartitions(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, created)
+
+	var count int
+	err = testDB.QueryRow(`SELECT count(*) FROM pg_inherits WHERE inhparent = 'entries'::regclass AND inhrelid = to_regclass($1)`,
+		"entries_p"+throughMonth.Format("200601")).Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
+
+// TestDetachLedgerPartitions 在會 rollback 的事務裡建一個很久以前的月份分區再卸下，卸下的加總要算進 GetBalanceAt，
+// at 落在卸下的月份裡面時算不出餘額
+func TestDetachLedgerPartitions(t *testing.T) {
+	account := createRandomAccount(t)
+	ctx := context.Background()
+
+	// 這個月還在用，不能卸下
+	_, err := testQueries.DetachLedgerPartitions(ctx, DetachLedgerPartitionsParams{DetachBefore: time.Now().UTC().AddDate(0, 2, 0)})
+	require.Error(t, err)
+
+	tx, err := testDB.Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+	q := New(tx)
+
+	// 先寫進 default 分區，建分區的時候會被搬過去
+	for _, amount := range []int64{100, -30} {
+		_, err = tx.ExecContext(ctx, `INSERT INTO entries (account_id, amount, created_at) VALUES ($1, $2, '2001-01-15 12:00:00+00')`, account.ID, amount)
+		require.NoError(t, err)
+	}
+	var partition string
+	require.NoError(t, tx.QueryRowContext(ctx, `SELECT create_ledger_partition('entries', '2001-01-01')`).Scan(&partition))
+	require.Equal(t, "entries_p200101", partition)
+
+	var inDefault int
+	require.NoError(t, tx.QueryRowContext(ctx, `SELECT count(*) FROM entries_default WHERE account_id = $1`, account.ID).Scan(&inDefault))
+	require.Zero(t, inDefault)
+
+	detached, err := q.DetachLedgerPartitions(ctx, DetachLedgerPartitionsParams{
+		DetachBefore:  time.Date(2001, time.February, 1, 0, 0, 0, 0, time.UTC),
+		ArchiveSchema: "ledger_archive",
+	})
+	require.NoError(t, err)
+	require.Contains(t, detached, "entries_p200101")
+
+	totals, err := q.ListArchivedEntryTotals(ctx, account.ID)
+	require.NoError(t, err)
+	require.Len(t, totals, 1)
+	require.Equal(t, "entries_p200101", totals[0].PartitionName)
+	require.Equal(t, int64(70), totals[0].Amount)
+	require.True(t, totals[0].ArchivedBefore.Equal(time.Date(2001, time.February, 1, 0, 0, 0, 0, time.UTC)))
+
+	// 卸下的資料搬到 archive schema 還查得到，但不在 entries 裡
+	var archived int
+	require.NoError(t, tx.QueryRowContext(ctx, `SELECT count(*) FROM ledger_archive.entries_p200101 WHERE account_id = $1`, account.ID).Scan(&archived))
+	require.Equal(t, 2, archived)
+	var attached int
+	require.NoError(t, tx.QueryRowContext(ctx, `SELECT count(*) FROM entries WHERE account_id = $1 AND created_at < '2001-02-01'`, account.ID).Scan(&attached))
+	require.Zero(t, attached)
+
+	// 卸下的月份不會再被建一個空的分區
+	created, err := q.CreateLedgerPartitions(ctx, CreateLedgerPartitionsParams{
+		FromMonth:    time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC),
+		ThroughMonth: time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.Empty(t, created)
+
+	// 卸下的分區整個在 at 之前才算進去，at 在那個月裡面就算不出來
+	_, err = balanceAt(ctx, q, GetBalanceAtParams{AccountID: account.ID, At: time.Date(2001, time.January, 20, 0, 0, 0, 0, time.UTC)})
+	require.ErrorIs(t, err, ErrBalanceArchived)
+	balance, err := balanceAt(ctx, q, GetBalanceAtParams{AccountID: account.ID, At: time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	require.Zero(t, balance)
+	balance, err = balanceAt(ctx, q, GetBalanceAtParams{AccountID: account.ID, At: time.Date(2001, time.March, 1, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	require.Equal(t, int64(70), balance)
+}
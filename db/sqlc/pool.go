@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DefaultHealthCheckTimeout is the ping timeout of HealthCheck when it is given no timeout
+const DefaultHealthCheckTimeout = 2 * time.Second
+
+// PoolConfig contains the connection pool settings of the databases of a Store.
+// A zero field keeps the database/sql default.
+type PoolConfig struct {
+	MaxOpenConns    int           `json:"max_open_conns"`
+	MaxIdleConns    int           `json:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `json:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `json:"conn_max_idle_time"`
+}
+
+// WithPoolConfig applies config to the primary database of the Store, and to the replica of WithReplica
+func WithPoolConfig(config PoolConfig) StoreOption {
+	return func(store *Store) {
+		store.poolConfig = &config
+	}
+}
+
+// apply 設定 db 的連線池，0 的欄位不動
+func (config PoolConfig) apply(db *sql.DB) {
+	if config.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+	if config.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	}
+}
+
+// PoolHealth is the result of pinging one database and the statistics of its connection pool
+type PoolHealth struct {
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+	// PingDuration is how long the ping took, or how long it waited before failing
+	PingDuration       time.Duration `json:"ping_duration"`
+	MaxOpenConnections int           `json:"max_open_connections"`
+	OpenConnections    int           `json:"open_connections"`
+	InUse              int           `json:"in_use"`
+	Idle               int           `json:"idle"`
+	// WaitCount and WaitDuration add up the waits for a free connection since the pool was opened
+	WaitCount         int64         `json:"wait_count"`
+	WaitDuration      time.Duration `json:"wait_duration"`
+	MaxIdleClosed     int64         `json:"max_idle_closed"`
+	MaxIdleTimeClosed int64         `json:"max_idle_time_closed"`
+	MaxLifetimeClosed int64         `json:"max_lifetime_closed"`
+}
+
+// HealthReport is the result of HealthCheck
+type HealthReport struct {
+	// Healthy follows the primary: reads fall back to the primary when the replica is down
+	Healthy bool        `json:"healthy"`
+	Primary PoolHealth  `json:"primary"`
+	Replica *PoolHealth `json:"replica,omitempty"`
+}
+
+// HealthCheck pings the primary database, and the replica of WithReplica, each within timeout and reports their pool statistics.
+// It returns the error of the primary ping together with the report when the primary is not reachable.
+func (store *Store) HealthCheck(ctx context.Context, timeout time.Duration) (HealthReport, error) {
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+
+	primary, err := checkPool(ctx, store.db, timeout)
+	report := HealthReport{Healthy: err == nil, Primary: primary}
+	if store.replica != nil {
+		replica, _ := checkPool(ctx, store.replica, timeout)
+		report.Replica = &replica
+	}
+	return report, err
+}
+
+func checkPool(ctx context.Context, db *sql.DB, timeout time.Duration) (PoolHealth, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := db.PingContext(ctx)
+	// 統計要在 ping 之後拿，才算得到 ping 用掉的連線
+	stats := db.Stats()
+	health := PoolHealth{
+		Healthy:            err == nil,
+		PingDuration:       time.Since(start),
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration,
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}
+	if err != nil {
+		health.Error = err.Error()
+	}
+	return health, err
+}
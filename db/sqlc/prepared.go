@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lib/pq"
+)
+
+// NewPreparedStore creates a Store whose transactions run the generated queries as prepared statements.
+// Every query is prepared once here; database/sql prepares it again on each new connection, also after a reconnect.
+// The reads made outside a transaction are not prepared, so they still go through WithReplica.
+// Close releases the statements.
+func NewPreparedStore(ctx context.Context, db *sql.DB, opts ...StoreOption) (*Store, error) {
+	store := NewStore(db, opts...)
+	store.prepared = &preparedQueries{}
+	if err := store.prepared.prepare(ctx, db); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Close closes the prepared statements of a Store created by NewPreparedStore, the database stays open
+func (store *Store) Close() error {
+	if store.prepared == nil {
+		return nil
+	}
+	return store.prepared.close()
+}
+
+// preparedQueries 保存 Prepare 出來的 Queries 和以 SQL 為 key 的同一批 statement；事務在用的時候拿讀鎖，
+// 重新 prepare 的時候拿寫鎖，所以舊的 statement 一定等到沒有事務在用了才會被關掉
+type preparedQueries struct {
+	mu    sync.RWMutex
+	q     *Queries
+	stmts map[string]*sql.Stmt
+	// reprepareRunning 讓同時失敗的事務只觸發一次重新 prepare
+	reprepareRunning atomic.Bool
+}
+
+func (prepared *preparedQueries) prepare(ctx context.Context, db DBTX) error {
+	recorder := &statementRecorder{DBTX: db, stmts: make(map[string]*sql.Stmt)}
+	q, err := Prepare(ctx, recorder)
+	if err != nil {
+		return err
+	}
+
+	prepared.mu.Lock()
+	defer prepared.mu.Unlock()
+	old := prepared.q
+	prepared.q = q
+	prepared.stmts = recorder.stmts
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+// statementRecorder 記下 Prepare 準備的每個 statement，事務才能依 SQL 找到它們
+type statementRecorder struct {
+	DBTX
+	stmts map[string]*sql.Stmt
+}
+
+func (recorder *statementRecorder) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	stmt, err := recorder.DBTX.PrepareContext(ctx, query)
+	if err == nil {
+		recorder.stmts[query] = stmt
+	}
+	return stmt, err
+}
+
+// reprepare 在背景重新 prepare 所有查詢，換掉失效的 statement；失敗的話保留舊的，下一次失效再試
+func (prepared *preparedQueries) reprepare(db DBTX) {
+	if !prepared.reprepareRunning.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer prepared.reprepareRunning.Store(false)
+		_ = prepared.prepare(context.Background(), db)
+	}()
+}
+
+// withTx 回傳綁在 tx 上、用 tx.StmtContext 重用 prepared statement 的 DBTX；事務結束後要呼叫 release。
+// 回傳的是 DBTX 而不是 Queries，execTx 才能和沒有 prepare 的時候一樣把指標和 span 包在外面。
+// Close 之後就不再用 prepared statement
+func (prepared *preparedQueries) withTx(tx *sql.Tx) (db DBTX, release func()) {
+	prepared.mu.RLock()
+	if prepared.q == nil {
+		return tx, prepared.mu.RUnlock
+	}
+	return &preparedTx{Tx: tx, stmts: prepared.stmts}, prepared.mu.RUnlock
+}
+
+func (prepared *preparedQueries) close() error {
+	prepared.mu.Lock()
+	defer prepared.mu.Unlock()
+	if prepared.q == nil {
+		return nil
+	}
+	err := prepared.q.Close()
+	prepared.q = nil
+	prepared.stmts = nil
+	return err
+}
+
+// preparedTx 是綁在事務上的 DBTX：prepare 過的查詢和 sqlc 產生的 WithTx 一樣用 tx.StmtContext 跑，其他的直接在 tx 上跑
+type preparedTx struct {
+	*sql.Tx
+	stmts map[string]*sql.Stmt
+}
+
+func (tx *preparedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if stmt, ok := tx.stmts[query]; ok {
+		return tx.StmtContext(ctx, stmt).ExecContext(ctx, args...)
+	}
+	return tx.Tx.ExecContext(ctx, query, args...)
+}
+
+func (tx *preparedTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if stmt, ok := tx.stmts[query]; ok {
+		return tx.StmtContext(ctx, stmt).QueryContext(ctx, args...)
+	}
+	return tx.Tx.QueryContext(ctx, query, args...)
+}
+
+func (tx *preparedTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if stmt, ok := tx.stmts[query]; ok {
+		return tx.StmtContext(ctx, stmt).QueryRowContext(ctx, args...)
+	}
+	return tx.Tx.QueryRowContext(ctx, query, args...)
+}
+
+// isStalePreparedStatement reports whether err means a prepared statement is no longer valid on the server:
+// it was deallocated (e.g. DISCARD ALL by a connection pooler) or a schema change altered its result type
+func isStalePreparedStatement(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code {
+	case "26000": // invalid_sql_statement_name
+		return true
+	case "0A000": // feature_not_supported
+		return strings.Contains(pqErr.Message, "cached plan must not change result type")
+	}
+	return false
+}
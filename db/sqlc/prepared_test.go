@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsStalePreparedStatement(t *testing.T) {
+	require.True(t, isStalePreparedStatement(&pq.Error{Code: "26000", Message: `prepared statement "1" does not exist`}))
+	require.True(t, isStalePreparedStatement(fmt.Errorf("cannot get account: %w", &pq.Error{Code: "0A000", Message: "cached plan must not change result type"})))
+	require.False(t, isStalePreparedStatement(&pq.Error{Code: "0A000", Message: "cannot use a deferrable unique constraint"}))
+	require.False(t, isStalePreparedStatement(&pq.Error{Code: "23505"}))
+	require.False(t, isStalePreparedStatement(errors.New("sql: statement is closed")))
+}
+
+func TestPreparedStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewPreparedStore(ctx, testDB)
+	require.NoError(t, err)
+	defer store.Close()
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	require.NoError(t, err)
+
+	// 重新 prepare 之後舊的 statement 會被關掉，事務改用新的
+	old := store.prepared.q
+	require.NoError(t, store.prepared.prepare(ctx, testDB))
+	require.NotSame(t, old, store.prepared.q)
+	require.Same(t, store.prepared.q.getAccountStmt, store.prepared.stmts[getAccount])
+	err = old.getAccountStmt.QueryRowContext(ctx, account1.ID).Scan()
+	require.Error(t, err)
+
+	result, err := store.TransferTx(ctx, TransferTxParams{FromAccountID: account2.ID, ToAccountID: account1.ID, Amount: 10})
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, result.ToAccount.Balance)
+
+	// Close 之後事務照樣能跑，只是不再用 prepared statement
+	require.NoError(t, store.Close())
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	require.NoError(t, err)
+}
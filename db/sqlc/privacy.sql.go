@@ -0,0 +1,368 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: privacy.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const anonymizeAccountsOwner = `-- name: AnonymizeAccountsOwner :execrows
+UPDATE accounts
+  set owner = $1
+WHERE owner = $2 AND closed_at IS NOT NULL
+`
+
+type AnonymizeAccountsOwnerParams struct {
+	Pseudonym string `json:"pseudonym"`
+	Owner     string `json:"owner"`
+}
+
+// 只改已經關閉的帳戶，ListAccountsByOwner 之後才開的帳戶不會被一起匿名化
+func (q *Queries) AnonymizeAccountsOwner(ctx context.Context, arg AnonymizeAccountsOwnerParams) (int64, error) {
+	result, err := q.exec(ctx, q.anonymizeAccountsOwnerStmt, anonymizeAccountsOwner, arg.Pseudonym, arg.Owner)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const anonymizeOutboxPayloads = `-- name: AnonymizeOutboxPayloads :execrows
+UPDATE outbox
+  set payload = anonymize_owner_jsonb(payload, $1, $2)
+WHERE (
+    (aggregate_type = 'account' AND aggregate_id = ANY($3::bigint[]))
+    OR (aggregate_type = 'transfer' AND aggregate_id IN (
+      SELECT id FROM transfers
+      WHERE from_account_id = ANY($3::bigint[]) OR to_account_id = ANY($3::bigint[])
+    ))
+    OR (aggregate_type = 'external_transfer' AND aggregate_id IN (
+      SELECT id FROM external_transfers WHERE account_id = ANY($3::bigint[])
+    ))
+  )
+  AND payload <> anonymize_owner_jsonb(payload, $1, $2)
+`
+
+type AnonymizeOutboxPayloadsParams struct {
+	Owner      string  `json:"owner"`
+	Pseudonym  string  `json:"pseudonym"`
+	AccountIds []int64 `json:"account_ids"`
+}
+
+// account.* 事件的 payload 是帳戶本身，transfer.created 的 payload 帶著兩邊的帳戶，external_transfer.* 的帶著入出金的帳戶
+func (q *Queries) AnonymizeOutboxPayloads(ctx context.Context, arg AnonymizeOutboxPayloadsParams) (int64, error) {
+	result, err := q.exec(ctx, q.anonymizeOutboxPayloadsStmt, anonymizeOutboxPayloads, arg.Owner, arg.Pseudonym, pq.Array(arg.AccountIds))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const anonymizeReviewCaseHits = `-- name: AnonymizeReviewCaseHits :execrows
+UPDATE review_cases
+  set hits = anonymize_owner_jsonb(hits, $1, $2)
+WHERE (from_account_id = ANY($3::bigint[]) OR to_account_id = ANY($3::bigint[]))
+  AND hits <> anonymize_owner_jsonb(hits, $1, $2)
+`
+
+type AnonymizeReviewCaseHitsParams struct {
+	Owner      string  `json:"owner"`
+	Pseudonym  string  `json:"pseudonym"`
+	AccountIds []int64 `json:"account_ids"`
+}
+
+// 舊版 OwnerScreeningRule 的 reason 裡有轉出帳戶的 owner，現在只記帳戶 id，但 matched name 仍可能和 owner 一樣
+func (q *Queries) AnonymizeReviewCaseHits(ctx context.Context, arg AnonymizeReviewCaseHitsParams) (int64, error) {
+	result, err := q.exec(ctx, q.anonymizeReviewCaseHitsStmt, anonymizeReviewCaseHits, arg.Owner, arg.Pseudonym, pq.Array(arg.AccountIds))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const anonymizeScreeningResults = `-- name: AnonymizeScreeningResults :execrows
+UPDATE screening_results
+  set name = $1,
+  normalized_name = $1
+WHERE name = $2
+`
+
+type AnonymizeScreeningResultsParams struct {
+	Pseudonym string `json:"pseudonym"`
+	Owner     string `json:"owner"`
+}
+
+func (q *Queries) AnonymizeScreeningResults(ctx context.Context, arg AnonymizeScreeningResultsParams) (int64, error) {
+	result, err := q.exec(ctx, q.anonymizeScreeningResultsStmt, anonymizeScreeningResults, arg.Pseudonym, arg.Owner)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const anonymizeTransferLimits = `-- name: AnonymizeTransferLimits :execrows
+UPDATE transfer_limits
+  set owner = $1
+WHERE owner = $2
+`
+
+type AnonymizeTransferLimitsParams struct {
+	Pseudonym sql.NullString `json:"pseudonym"`
+	Owner     sql.NullString `json:"owner"`
+}
+
+func (q *Queries) AnonymizeTransferLimits(ctx context.Context, arg AnonymizeTransferLimitsParams) (int64, error) {
+	result, err := q.exec(ctx, q.anonymizeTransferLimitsStmt, anonymizeTransferLimits, arg.Pseudonym, arg.Owner)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const anonymizeUser = `-- name: AnonymizeUser :one
+UPDATE users
+  set username = $1,
+  full_name = '',
+  email = $2,
+  anonymized_at = now()
+WHERE username = $3
+RETURNING username, full_name, email, is_email_verified, created_at, anonymized_at
+`
+
+type AnonymizeUserParams struct {
+	Pseudonym string `json:"pseudonym"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+}
+
+func (q *Queries) AnonymizeUser(ctx context.Context, arg AnonymizeUserParams) (User, error) {
+	row := q.queryRow(ctx, q.anonymizeUserStmt, anonymizeUser, arg.Pseudonym, arg.Email, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.FullName,
+		&i.Email,
+		&i.IsEmailVerified,
+		&i.CreatedAt,
+		&i.AnonymizedAt,
+	)
+	return i, err
+}
+
+const anonymizeWebhookEndpoints = `-- name: AnonymizeWebhookEndpoints :execrows
+UPDATE webhook_endpoints
+  set owner = $1,
+  url = '',
+  secret = '',
+  is_active = false
+WHERE owner = $2
+`
+
+type AnonymizeWebhookEndpointsParams struct {
+	Pseudonym string `json:"pseudonym"`
+	Owner     string `json:"owner"`
+}
+
+func (q *Queries) AnonymizeWebhookEndpoints(ctx context.Context, arg AnonymizeWebhookEndpointsParams) (int64, error) {
+	result, err := q.exec(ctx, q.anonymizeWebhookEndpointsStmt, anonymizeWebhookEndpoints, arg.Pseudonym, arg.Owner)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const closeAccount = `-- name: CloseAccount :one
+UPDATE accounts
+  set closed_at = now()
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number
+`
+
+func (q *Queries) CloseAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.queryRow(ctx, q.closeAccountStmt, closeAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.ProductID,
+		&i.OverdraftLimit,
+		&i.OverdraftRateBps,
+		&i.OverdraftFee,
+		&i.ClosedAt,
+		&i.AccountNumber,
+	)
+	return i, err
+}
+
+const deleteVerifyEmailsByUsername = `-- name: DeleteVerifyEmailsByUsername :exec
+DELETE FROM verify_emails
+WHERE username = $1
+`
+
+func (q *Queries) DeleteVerifyEmailsByUsername(ctx context.Context, username string) error {
+	_, err := q.exec(ctx, q.deleteVerifyEmailsByUsernameStmt, deleteVerifyEmailsByUsername, username)
+	return err
+}
+
+const listAccountsByOwner = `-- name: ListAccountsByOwner :many
+SELECT id, owner, balance, currency, created_at, product_id, overdraft_limit, overdraft_rate_bps, overdraft_fee, closed_at, account_number FROM accounts
+WHERE owner = $1
+ORDER BY id
+`
+
+func (q *Queries) ListAccountsByOwner(ctx context.Context, owner string) ([]Account, error) {
+	rows, err := q.query(ctx, q.listAccountsByOwnerStmt, listAccountsByOwner, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.ProductID,
+			&i.OverdraftLimit,
+			&i.OverdraftRateBps,
+			&i.OverdraftFee,
+			&i.ClosedAt,
+			&i.AccountNumber,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEntriesByAccounts = `-- name: ListEntriesByAccounts :many
+SELECT id, account_id, amount, created_at, transfer_id FROM entries
+WHERE account_id = ANY($1::bigint[])
+ORDER BY id
+`
+
+func (q *Queries) ListEntriesByAccounts(ctx context.Context, accountIds []int64) ([]Entry, error) {
+	rows, err := q.query(ctx, q.listEntriesByAccountsStmt, listEntriesByAccounts, pq.Array(accountIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExternalTransfersByAccounts = `-- name: ListExternalTransfersByAccounts :many
+SELECT id, account_id, clearing_account_id, direction, amount, external_ref, status, failure_reason, entry_id, clearing_entry_id, reversal_entry_id, clearing_reversal_entry_id, created_at, updated_at FROM external_transfers
+WHERE account_id = ANY($1::bigint[])
+ORDER BY id
+`
+
+func (q *Queries) ListExternalTransfersByAccounts(ctx context.Context, accountIds []int64) ([]ExternalTransfer, error) {
+	rows, err := q.query(ctx, q.listExternalTransfersByAccountsStmt, listExternalTransfersByAccounts, pq.Array(accountIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ExternalTransfer{}
+	for rows.Next() {
+		var i ExternalTransfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.ClearingAccountID,
+			&i.Direction,
+			&i.Amount,
+			&i.ExternalRef,
+			&i.Status,
+			&i.FailureReason,
+			&i.EntryID,
+			&i.ClearingEntryID,
+			&i.ReversalEntryID,
+			&i.ClearingReversalEntryID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfersByAccounts = `-- name: ListTransfersByAccounts :many
+SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+WHERE from_account_id = ANY($1::bigint[]) OR to_account_id = ANY($1::bigint[])
+ORDER BY id
+`
+
+func (q *Queries) ListTransfersByAccounts(ctx context.Context, accountIds []int64) ([]Transfer, error) {
+	rows, err := q.query(ctx, q.listTransfersByAccountsStmt, listTransfersByAccounts, pq.Array(accountIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -6,22 +6,193 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 type Querier interface {
-	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	// 記過帳（entries、轉帳、出入金、調帳）的帳戶不能刪，帳本要對得起來
+	AccountHasLedgerRows(ctx context.Context, id int64) (bool, error)
+	// 先從 sequence 拿好 id，依檔案的行號順序，後面的 INSERT 才能把帳戶、entries 和暫存列對起來
+	AllocateImportedAccountIDs(ctx context.Context, importID int64) (int64, error)
+	// 只改已經關閉的帳戶，ListAccountsByOwner 之後才開的帳戶不會被一起匿名化
+	AnonymizeAccountsOwner(ctx context.Context, arg AnonymizeAccountsOwnerParams) (int64, error)
+	// account.* 事件的 payload 是帳戶本身，transfer.created 的 payload 帶著兩邊的帳戶，external_transfer.* 的帶著入出金的帳戶
+	AnonymizeOutboxPayloads(ctx context.Context, arg AnonymizeOutboxPayloadsParams) (int64, error)
+	// 舊版 OwnerScreeningRule 的 reason 裡有轉出帳戶的 owner，現在只記帳戶 id，但 matched name 仍可能和 owner 一樣
+	AnonymizeReviewCaseHits(ctx context.Context, arg AnonymizeReviewCaseHitsParams) (int64, error)
+	AnonymizeScreeningResults(ctx context.Context, arg AnonymizeScreeningResultsParams) (int64, error)
+	AnonymizeTransferLimits(ctx context.Context, arg AnonymizeTransferLimitsParams) (int64, error)
+	AnonymizeUser(ctx context.Context, arg AnonymizeUserParams) (User, error)
+	AnonymizeWebhookEndpoints(ctx context.Context, arg AnonymizeWebhookEndpointsParams) (int64, error)
+	// 要先 enableLedgerWrites；新帳戶加上期初餘額，clearing 帳戶扣掉同幣別的總額
+	ApplyImportedBalances(ctx context.Context, importID int64) (int64, error)
+	ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ClaimNextTask(ctx context.Context, arg ClaimNextTaskParams) (Task, error)
+	CloseAccount(ctx context.Context, id int64) (Account, error)
+	// 只有還拿著這次 claim 的 worker 才能改結果：鎖過期被別的 worker 重新 claim 的話 attempts 會加一，
+	// 舊的 worker 改到 0 筆，不會蓋掉新的那次
+	CompleteTask(ctx context.Context, arg CompleteTaskParams) (int64, error)
+	// 給 StructuringRule：since 之後轉出、金額在 [min_amount, max_amount] 之間的筆數
+	CountTransfersInAmountRange(ctx context.Context, arg CountTransfersInAmountRangeParams) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountImport(ctx context.Context, source string) (AccountImport, error)
+	CreateAccountProduct(ctx context.Context, arg CreateAccountProductParams) (AccountProduct, error)
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error)
+	// 另一個事務同時建了同一個 subject 的話，等它 Commit 之後什麼都不做，再用 GetAuditSubjectKey 讀它的 key
+	CreateAuditSubjectKey(ctx context.Context, arg CreateAuditSubjectKeyParams) error
+	CreateBalanceAdjustment(ctx context.Context, arg CreateBalanceAdjustmentParams) (BalanceAdjustment, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	// 同一個 direction + external_ref 已經存在時不插入也不回傳，呼叫端再用 GetExternalTransferByRef 拿已經存在的那筆
+	CreateExternalTransfer(ctx context.Context, arg CreateExternalTransferParams) (ExternalTransfer, error)
+	CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (InterestAccrual, error)
+	CreateInterestPosting(ctx context.Context, arg CreateInterestPostingParams) (InterestPosting, error)
+	// 建好 entries 和 transfers 從 from_month 到 through_month 的月份分區，回傳新建的分區
+	CreateLedgerPartitions(ctx context.Context, arg CreateLedgerPartitionsParams) ([]string, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (Outbox, error)
+	CreateOverdraftCharge(ctx context.Context, arg CreateOverdraftChargeParams) (OverdraftCharge, error)
+	// 同一個帳戶的 end_to_end_id 已經存在時不插入也不回傳，呼叫端再用 GetPaymentInstructionByEndToEndID 拿已經存在的那筆
+	CreatePaymentInstruction(ctx context.Context, arg CreatePaymentInstructionParams) (PaymentInstruction, error)
+	// 被規則擋下的轉帳直接記成已拒絕的 case，擋下的決定也查得到
+	CreateRejectedReviewCase(ctx context.Context, arg CreateRejectedReviewCaseParams) (ReviewCase, error)
+	CreateReviewCase(ctx context.Context, arg CreateReviewCaseParams) (ReviewCase, error)
+	CreateScreeningResult(ctx context.Context, arg CreateScreeningResultParams) (ScreeningResult, error)
+	CreateSystemAccount(ctx context.Context, arg CreateSystemAccountParams) (SystemAccount, error)
+	CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) error
+	CreateWebhookDeliveryAttempt(ctx context.Context, arg CreateWebhookDeliveryAttemptParams) (WebhookDeliveryAttempt, error)
+	CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error)
+	DecidePaymentInstruction(ctx context.Context, arg DecidePaymentInstructionParams) (PaymentInstruction, error)
+	DecideReviewCase(ctx context.Context, arg DecideReviewCaseParams) (ReviewCase, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteAccountImportRows(ctx context.Context, importID int64) error
+	DeleteAuditSubjectKey(ctx context.Context, subject string) (int64, error)
+	DeleteTransferLimit(ctx context.Context, id int64) error
+	DeleteVerifyEmailsByUsername(ctx context.Context, username string) error
+	// 卸下在 detach_before 之前結束的月份分區，archive_schema 不是空字串時搬到那個 schema
+	DetachLedgerPartitions(ctx context.Context, arg DetachLedgerPartitionsParams) ([]string, error)
+	FailExternalTransfer(ctx context.Context, arg FailExternalTransferParams) (ExternalTransfer, error)
+	FailTask(ctx context.Context, arg FailTaskParams) (int64, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountImport(ctx context.Context, id int64) (AccountImport, error)
+	GetAccountProduct(ctx context.Context, id int64) (AccountProduct, error)
+	GetAccountTransferLimit(ctx context.Context, accountID sql.NullInt64) (TransferLimit, error)
+	// 轉出到系統帳戶的（例如透支收費）不是客戶發起的，不算進限額
+	GetAccountTransferUsage(ctx context.Context, fromAccountID int64) (GetAccountTransferUsageRow, error)
+	GetAuditSubjectKey(ctx context.Context, subject string) ([]byte, error)
+	// 由 entries 推出 at 之前的餘額（不含 at）；卸下的分區整個在 at 之前時算進它的加總。
+	// at 落在卸下的月份裡面時，那個月在 at 之前的 entries 查不到，archived 是 true，balance 不能用
+	GetBalanceAt(ctx context.Context, arg GetBalanceAtParams) (GetBalanceAtRow, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetExternalTransfer(ctx context.Context, id int64) (ExternalTransfer, error)
+	GetExternalTransferByRef(ctx context.Context, arg GetExternalTransferByRefParams) (ExternalTransfer, error)
+	GetExternalTransferForUpdate(ctx context.Context, id int64) (ExternalTransfer, error)
+	GetInterestAccrual(ctx context.Context, arg GetInterestAccrualParams) (InterestAccrual, error)
+	GetInterestPosting(ctx context.Context, arg GetInterestPostingParams) (InterestPosting, error)
+	GetLastAuditLog(ctx context.Context) (AuditLog, error)
+	GetLastInterestPosting(ctx context.Context, accountID int64) (InterestPosting, error)
+	GetLastOverdraftCharge(ctx context.Context, accountID int64) (OverdraftCharge, error)
+	GetOutboxEvent(ctx context.Context, id int64) (Outbox, error)
+	GetOverdraftCharge(ctx context.Context, arg GetOverdraftChargeParams) (OverdraftCharge, error)
+	GetOwnerTransferLimit(ctx context.Context, arg GetOwnerTransferLimitParams) (TransferLimit, error)
+	GetOwnerTransferUsage(ctx context.Context, arg GetOwnerTransferUsageParams) (GetOwnerTransferUsageRow, error)
+	GetPaymentInstructionByEndToEndID(ctx context.Context, arg GetPaymentInstructionByEndToEndIDParams) (PaymentInstruction, error)
+	GetPaymentInstructionByReviewCaseForUpdate(ctx context.Context, reviewCaseID sql.NullInt64) (PaymentInstruction, error)
+	GetReviewCase(ctx context.Context, id int64) (ReviewCase, error)
+	GetReviewCaseForUpdate(ctx context.Context, id int64) (ReviewCase, error)
+	GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (Account, error)
+	GetTask(ctx context.Context, id int64) (Task, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetUser(ctx context.Context, username string) (User, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	GetWebhookEndpoint(ctx context.Context, id int64) (WebhookEndpoint, error)
+	// 和 Store.CreateAccount 一樣先用 0 開戶，期初餘額另外記帳
+	InsertImportedAccounts(ctx context.Context, importID int64) (int64, error)
+	InsertImportedEntries(ctx context.Context, importID int64) (int64, error)
+	// 期初餘額和 Store.CreateAccount 一樣記成已入帳的 opening:<id> 入金
+	InsertImportedExternalTransfers(ctx context.Context, importID int64) (int64, error)
+	// 和 Store.CreateAccount 一樣，開了戶的 owner 篩查結果掛在帳戶上
+	InsertImportedScreeningResults(ctx context.Context, importID int64) (int64, error)
+	ListAccountImportCurrencies(ctx context.Context, importID int64) ([]string, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsByNumbers(ctx context.Context, accountNumbers []string) ([]Account, error)
+	ListAccountsByOwner(ctx context.Context, owner string) ([]Account, error)
+	// 有掛利率產品的帳戶，用 id 分頁
+	ListAccruingAccounts(ctx context.Context, arg ListAccruingAccountsParams) ([]ListAccruingAccountsRow, error)
+	ListActiveWebhookEndpointsByOwner(ctx context.Context, owner string) ([]WebhookEndpoint, error)
+	ListArchivedEntryTotals(ctx context.Context, accountID int64) ([]ArchivedEntryTotal, error)
+	ListArchivedEntryTotalsByAccounts(ctx context.Context, accountIds []int64) ([]ArchivedEntryTotal, error)
+	ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error)
+	ListAuditLogsByEntity(ctx context.Context, arg ListAuditLogsByEntityParams) ([]AuditLog, error)
+	ListBalanceAdjustments(ctx context.Context, arg ListBalanceAdjustmentsParams) ([]BalanceAdjustment, error)
+	// 有設透支利率或手續費的帳戶，用 id 分頁
+	ListChargeableOverdraftAccounts(ctx context.Context, arg ListChargeableOverdraftAccountsParams) ([]ListChargeableOverdraftAccountsRow, error)
+	// 複式記帳下每個幣別的 entries 加總一定是 0（含 clearing / suspense 帳戶和卸下的分區）
+	ListCurrencyImbalances(ctx context.Context) ([]ListCurrencyImbalancesRow, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListEntriesByAccounts(ctx context.Context, accountIds []int64) ([]Entry, error)
+	ListExternalTransfers(ctx context.Context, arg ListExternalTransfersParams) ([]ExternalTransfer, error)
+	ListExternalTransfersByAccounts(ctx context.Context, accountIds []int64) ([]ExternalTransfer, error)
+	ListInterestPostings(ctx context.Context, accountID int64) ([]InterestPosting, error)
+	// 餘額和 entries 加總對不上的帳戶；卸下的分區用 archived_entry_totals 的加總代替
+	ListLedgerMismatches(ctx context.Context) ([]ListLedgerMismatchesRow, error)
+	ListOutboxEventsByAggregate(ctx context.Context, arg ListOutboxEventsByAggregateParams) ([]Outbox, error)
+	ListOverdraftCharges(ctx context.Context, accountID int64) ([]OverdraftCharge, error)
+	// 目前透支中的帳戶，用得最多的排前面
+	ListOverdraftUsage(ctx context.Context, arg ListOverdraftUsageParams) ([]ListOverdraftUsageRow, error)
+	ListPaymentInstructionsByMessage(ctx context.Context, messageID string) ([]PaymentInstruction, error)
+	ListPendingOutboxEvents(ctx context.Context, limit int32) ([]Outbox, error)
+	ListReviewCases(ctx context.Context, arg ListReviewCasesParams) ([]ReviewCase, error)
+	ListScreeningResults(ctx context.Context, arg ListScreeningResultsParams) ([]ScreeningResult, error)
+	ListScreeningResultsByAccount(ctx context.Context, accountID sql.NullInt64) ([]ScreeningResult, error)
+	// created_at 在 [from_time, to_time) 之間記到帳戶的 entries，連同對方帳戶、對方的帳號和參考號；
+	// 對方帳戶：轉帳是另一邊的帳戶，出入金是 clearing 帳戶，調帳是 suspense 帳戶
+	ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
-	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	ListTransfersByAccounts(ctx context.Context, accountIds []int64) ([]Transfer, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhookDeliveriesByStatus(ctx context.Context, arg ListWebhookDeliveriesByStatusParams) ([]WebhookDelivery, error)
+	ListWebhookDeliveryAttempts(ctx context.Context, deliveryID int64) ([]WebhookDeliveryAttempt, error)
+	ListWebhookEndpointsByOwner(ctx context.Context, owner string) ([]WebhookEndpoint, error)
+	// 一次只跑一個匯入，多個 clearing 帳戶的餘額一起更新時才不會和另一個匯入互鎖
+	LockAccountImports(ctx context.Context) error
+	// 交易層級的 advisory lock，Commit / Rollback 時自動釋放；
+	// 拿到鎖之後才讀最後一筆 hash 再寫入，同時寫 audit log 的交易會排隊，鏈不會分岔
+	LockAuditLog(ctx context.Context) error
+	// 建立系統帳戶前先拿 advisory lock，同時第一次用到同一個系統帳戶的事務不會各建一個
+	LockSystemAccount(ctx context.Context, arg LockSystemAccountParams) error
+	// 同一個限額的檢查和轉帳排隊，彙總和寫入之間不會有別的轉帳插進來；Commit / Rollback 時自動釋放
+	LockTransferLimit(ctx context.Context, lockKey string) error
+	MarkInterestAccrualsPosted(ctx context.Context, arg MarkInterestAccrualsPostedParams) (int64, error)
+	MarkOutboxEventDelivered(ctx context.Context, id int64) error
+	// attempts 不歸零，只記下 replay 前用掉的 attempts，重試次數從這裡重新算
+	ReplayWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	RequeueExpiredTasks(ctx context.Context) (int64, error)
+	RetryTask(ctx context.Context, arg RetryTaskParams) (int64, error)
+	SetAccountImportAccepted(ctx context.Context, arg SetAccountImportAcceptedParams) (AccountImport, error)
+	SetAccountOverdraft(ctx context.Context, arg SetAccountOverdraftParams) (Account, error)
+	SetAccountProduct(ctx context.Context, arg SetAccountProductParams) (Account, error)
+	SetAccountTransferLimit(ctx context.Context, arg SetAccountTransferLimitParams) (TransferLimit, error)
+	SetExternalTransferEntries(ctx context.Context, arg SetExternalTransferEntriesParams) (ExternalTransfer, error)
+	SetInterestPostingTransfer(ctx context.Context, arg SetInterestPostingTransferParams) (InterestPosting, error)
+	SetOverdraftChargeTransfer(ctx context.Context, arg SetOverdraftChargeTransferParams) (OverdraftCharge, error)
+	SetOwnerTransferLimit(ctx context.Context, arg SetOwnerTransferLimitParams) (TransferLimit, error)
+	SetWebhookEndpointActive(ctx context.Context, arg SetWebhookEndpointActiveParams) (WebhookEndpoint, error)
+	SettleExternalTransfer(ctx context.Context, id int64) (ExternalTransfer, error)
+	// 給 RapidMovementRule：since 之後入帳的總額
+	SumIncomingEntries(ctx context.Context, arg SumIncomingEntriesParams) (int64, error)
+	SumUnpostedInterestAccruals(ctx context.Context, arg SumUnpostedInterestAccrualsParams) (SumUnpostedInterestAccrualsRow, error)
+	// 各幣別的額度和使用量；超過額度的帳戶（例如被調帳或收費扣到額度以下）另外計數
+	SummarizeOverdraftUsage(ctx context.Context) ([]SummarizeOverdraftUsageRow, error)
+	// 和 CompleteTask 一樣只有還拿著這次 claim 的 deliverer 才能寫結果：別的 deliverer 先寫了這一次 attempt 的話 attempts 已經加一，
+	// 這裡查不到資料
+	UpdateWebhookDeliveryResult(ctx context.Context, arg UpdateWebhookDeliveryResultParams) (WebhookDelivery, error)
+	UseVerifyEmail(ctx context.Context, arg UseVerifyEmailParams) (VerifyEmail, error)
+	VerifyUserEmail(ctx context.Context, username string) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ReconciliationReport lists the ledger invariants that do not hold:
+// every account balance equals the sum of its entries, and the entries of every currency sum to zero
+type ReconciliationReport struct {
+	Mismatches []ListLedgerMismatchesRow   `json:"mismatches"`
+	Imbalances []ListCurrencyImbalancesRow `json:"imbalances"`
+}
+
+// Balanced reports whether both invariants hold
+func (report ReconciliationReport) Balanced() bool {
+	return len(report.Mismatches) == 0 && len(report.Imbalances) == 0
+}
+
+// Reconcile checks the ledger invariants on a single snapshot of the database
+func (store *Store) Reconcile(ctx context.Context) (ReconciliationReport, error) {
+	var report ReconciliationReport
+
+	// 兩個查詢要看到同一個快照，否則中間有轉帳 Commit 的話會誤報
+	tx, err := store.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback()
+
+	q := New(tx)
+	report.Mismatches, err = q.ListLedgerMismatches(ctx)
+	if err != nil {
+		return report, fmt.Errorf("cannot list ledger mismatches: %w", err)
+	}
+	report.Imbalances, err = q.ListCurrencyImbalances(ctx)
+	if err != nil {
+		return report, fmt.Errorf("cannot list currency imbalances: %w", err)
+	}
+
+	return report, tx.Commit()
+}
@@ -0,0 +1,176 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultReplicaLagCheckInterval is how long the replication lag measured by a Store is trusted before it is measured again
+const DefaultReplicaLagCheckInterval = time.Second
+
+// ReplicaOption configures the read replica of a Store
+type ReplicaOption func(router *replicaRouter)
+
+// WithReplicaLagCheckInterval sets how long a measured replication lag is trusted, 0 measures it before every read
+func WithReplicaLagCheckInterval(interval time.Duration) ReplicaOption {
+	return func(router *replicaRouter) {
+		router.lagCheckInterval = interval
+	}
+}
+
+// WithReplica sends the read-only queries of the Store to replica while its replication lag is at most maxLag.
+// Transactions, writes and locking reads always use the primary, as do the reads made with a context from UsePrimary.
+// When the lag is higher or cannot be measured, reads fall back to the primary.
+func WithReplica(replica *sql.DB, maxLag time.Duration, opts ...ReplicaOption) StoreOption {
+	return func(store *Store) {
+		router := &replicaRouter{
+			primary:          store.db,
+			replica:          replica,
+			maxLag:           maxLag,
+			lagCheckInterval: DefaultReplicaLagCheckInterval,
+		}
+		router.lag = router.replicaLag
+		for _, opt := range opts {
+			opt(router)
+		}
+		store.setQueries(New(router))
+		store.replica = replica
+	}
+}
+
+type primaryContextKey struct{}
+
+// UsePrimary returns a copy of ctx whose reads go to the primary, e.g. to read a row right after writing it
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryContextKey{}, true)
+}
+
+func usesPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryContextKey{}).(bool)
+	return primary
+}
+
+// replicaLagQuery 量 replica 落後多少秒；WAL 都已經 replay 完就是 0，
+// 不然主庫閒著的時候 pg_last_xact_replay_timestamp 停在最後一筆交易，會被誤判成落後很久。
+// 不是 replica（pg_is_in_recovery 是 false）也算 0
+const replicaLagQuery = `SELECT CASE
+  WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+  ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 'Infinity')
+END::float8`
+
+// replicaRouter 是給 Queries 用的 DBTX：唯讀的查詢在 replica 落後不超過 maxLag 時送到 replica，其他都送到 primary
+type replicaRouter struct {
+	primary          DBTX
+	replica          DBTX
+	maxLag           time.Duration
+	lagCheckInterval time.Duration
+	lag              func(ctx context.Context) (time.Duration, error)
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	usable    bool
+	// checking 表示有一個查詢正在量落後，其他查詢先沿用上一次的結果
+	checking bool
+}
+
+func (router *replicaRouter) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return router.primary.ExecContext(ctx, query, args...)
+}
+
+func (router *replicaRouter) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return router.primary.PrepareContext(ctx, query)
+}
+
+func (router *replicaRouter) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return router.route(ctx, query).QueryContext(ctx, query, args...)
+}
+
+func (router *replicaRouter) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return router.route(ctx, query).QueryRowContext(ctx, query, args...)
+}
+
+func (router *replicaRouter) route(ctx context.Context, query string) DBTX {
+	if usesPrimary(ctx) || !isReadOnlyQuery(query) || !router.replicaUsable(ctx) {
+		return router.primary
+	}
+	return router.replica
+}
+
+// replicaLagTimeout 是量一次落後最多等多久，replica 沒回應就當不能用
+const replicaLagTimeout = 2 * time.Second
+
+// replicaUsable 回傳 replica 目前能不能用；量到的結果在 lagCheckInterval 內沿用，量不到就當不能用
+func (router *replicaRouter) replicaUsable(ctx context.Context) bool {
+	router.mu.Lock()
+	fresh := !router.checkedAt.IsZero() && time.Since(router.checkedAt) < router.lagCheckInterval
+	if fresh || router.checking {
+		usable := router.usable
+		router.mu.Unlock()
+		return usable
+	}
+	router.checking = true
+	router.mu.Unlock()
+
+	// 量的時候不拿著鎖，慢的 replica 不會卡住其他查詢；
+	// ctx 不跟著呼叫端取消，不然一個被取消的請求會讓 replica 被記成不能用
+	lagCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), replicaLagTimeout)
+	defer cancel()
+	lag, err := router.lag(lagCtx)
+
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	router.checking = false
+	router.usable = err == nil && lag <= router.maxLag
+	router.checkedAt = time.Now()
+	return router.usable
+}
+
+func (router *replicaRouter) replicaLag(ctx context.Context) (time.Duration, error) {
+	var seconds float64
+	if err := router.replica.QueryRowContext(ctx, replicaLagQuery).Scan(&seconds); err != nil {
+		return 0, err
+	}
+	if seconds >= float64(math.MaxInt64/int64(time.Second)) {
+		return time.Duration(math.MaxInt64), nil
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// readOnlyQueryPrefixes 是只讀資料的 sqlc 查詢名稱的開頭，見 db/query；
+// SELECT 也可能呼叫會寫入的函式（例如 CreateLedgerPartitions），所以看名稱不看 SQL
+var readOnlyQueryPrefixes = []string{"Get", "List", "Count", "Sum", "Summarize"}
+
+// lockingClauses 會鎖 row 的讀取只能在 primary 上跑
+var lockingClauses = []string{"FOR UPDATE", "FOR NO KEY UPDATE", "FOR SHARE", "FOR KEY SHARE"}
+
+// isReadOnlyQuery 依 sqlc 產生的 "-- name: X :kind" 判斷查詢是不是唯讀的，沒有名稱的查詢都當成會寫入
+func isReadOnlyQuery(query string) bool {
+	name := queryName(query)
+	_, body, ok := strings.Cut(query, "\n")
+	if !ok || name == "" {
+		return false
+	}
+
+	readOnly := false
+	for _, prefix := range readOnlyQueryPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			readOnly = true
+			break
+		}
+	}
+	if !readOnly {
+		return false
+	}
+
+	body = strings.ToUpper(strings.Join(strings.Fields(body), " "))
+	for _, clause := range lockingClauses {
+		if strings.Contains(body, clause) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDBTX 記下收到的查詢，不連資料庫
+type recordingDBTX struct {
+	queries []string
+}
+
+func (db *recordingDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	db.queries = append(db.queries, query)
+	return nil, nil
+}
+
+func (db *recordingDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	db.queries = append(db.queries, query)
+	return nil, nil
+}
+
+func (db *recordingDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	db.queries = append(db.queries, query)
+	return nil, nil
+}
+
+func (db *recordingDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	db.queries = append(db.queries, query)
+	return nil
+}
+
+func TestIsReadOnlyQuery(t *testing.T) {
+	testCases := []struct {
+		query    string
+		readOnly bool
+	}{
+		{getAccount, true},
+		{listEntries, true},
+		{listTransfers, true},
+		{countTransfersInAmountRange, true},
+		{sumIncomingEntries, true},
+		{summarizeOverdraftUsage, true},
+		{getAccountForUpdate, false},
+		{getReviewCaseForUpdate, false},
+		{listPendingOutboxEvents, false},
+		{createTransfer, false},
+		{addAccountBalance, false},
+		{createLedgerPartitions, false},
+		{"SELECT 1", false},
+		{"-- name: GetOne :one", false},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.readOnly, isReadOnlyQuery(tc.query), tc.query)
+	}
+}
+
+func TestReplicaRouter(t *testing.T) {
+	primary := &recordingDBTX{}
+	replica := &recordingDBTX{}
+	lag := time.Duration(0)
+	var lagErr error
+	checks := 0
+	router := &replicaRouter{
+		primary:          primary,
+		replica:          replica,
+		maxLag:           time.Second,
+		lagCheckInterval: time.Hour,
+		lag: func(ctx context.Context) (time.Duration, error) {
+			checks++
+			return lag, lagErr
+		},
+	}
+	ctx := context.Background()
+
+	router.QueryRowContext(ctx, getAccount, 1)
+	router.QueryContext(ctx, listEntries, 1, 10, 0)
+	require.Equal(t, []string{getAccount, listEntries}, replica.queries)
+	require.Empty(t, primary.queries)
+	// 量到的落後在 lagCheckInterval 內沿用
+	require.Equal(t, 1, checks)
+
+	// 寫入、鎖 row 的讀取和 UsePrimary 的讀取都走 primary
+	router.QueryRowContext(ctx, createTransfer, 1, 2, 10)
+	router.QueryRowContext(ctx, getAccountForUpdate, 1)
+	router.ExecContext(ctx, deleteAccount, 1)
+	router.QueryRowContext(UsePrimary(ctx), getAccount, 1)
+	require.Equal(t, []string{createTransfer, getAccountForUpdate, deleteAccount, getAccount}, primary.queries)
+	require.Len(t, replica.queries, 2)
+}
+
+func TestReplicaRouterLag(t *testing.T) {
+	primary := &recordingDBTX{}
+	replica := &recordingDBTX{}
+	lag := 5 * time.Second
+	var lagErr error
+	router := &replicaRouter{
+		primary: primary,
+		replica: replica,
+		maxLag:  time.Second,
+		lag: func(ctx context.Context) (time.Duration, error) {
+			return lag, lagErr
+		},
+	}
+	ctx := context.Background()
+
+	// 落後太多時讀 primary，追上之後回到 replica
+	router.QueryRowContext(ctx, getAccount, 1)
+	require.Len(t, primary.queries, 1)
+	lag = time.Second
+	router.QueryRowContext(ctx, getAccount, 1)
+	require.Len(t, replica.queries, 1)
+
+	// 量不到落後也讀 primary
+	lagErr = errors.New("connection refused")
+	router.QueryRowContext(ctx, getAccount, 1)
+	require.Len(t, primary.queries, 2)
+	require.Len(t, replica.queries, 1)
+}
+
+func TestReplicaRouterCanceledContext(t *testing.T) {
+	primary := &recordingDBTX{}
+	replica := &recordingDBTX{}
+	router := &replicaRouter{
+		primary: primary,
+		replica: replica,
+		maxLag:  time.Second,
+		lag: func(ctx context.Context) (time.Duration, error) {
+			return 0, ctx.Err()
+		},
+	}
+
+	// 呼叫端的 ctx 取消了，落後還是量得到，replica 不會被記成不能用
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	router.QueryRowContext(ctx, getAccount, 1)
+	require.Len(t, replica.queries, 1)
+	require.True(t, router.usable)
+}
+
+// TestStoreWithReplica 用同一個資料庫當 replica：不在 recovery 的資料庫落後是 0，讀取和寫入都要能用
+func TestStoreWithReplica(t *testing.T) {
+	store := NewStore(testDB, WithReplica(testDB, time.Second))
+	account1 := createRandomAccount(t)
+
+	lag, err := store.queries.db.(*replicaRouter).replicaLag(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, lag)
+
+	account2, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+
+	// 寫入一定走 primary
+	account3, err := store.CreateAccount(context.Background(), CreateAccountParams{Owner: util.RandomOwner(), Currency: util.RandomCurrency()})
+	require.NoError(t, err)
+	account3, err = store.GetAccount(UsePrimary(context.Background()), account3.ID)
+	require.NoError(t, err)
+	require.NotZero(t, account3.ID)
+}
@@ -0,0 +1,244 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// RuleOutcome is what a transfer rule decides about a transfer
+type RuleOutcome string
+
+// Outcomes of transfer rules, from the weakest to the strongest
+const (
+	RuleAllow RuleOutcome = "allow"
+	RuleHold  RuleOutcome = "hold"
+	RuleBlock RuleOutcome = "block"
+)
+
+var ruleOutcomeRank = map[RuleOutcome]int{
+	RuleAllow: 0,
+	RuleHold:  1,
+	RuleBlock: 2,
+}
+
+var (
+	// ErrTransferHeld is matched by errors.Is for a ScreeningError with RuleHold
+	ErrTransferHeld = errors.New("transfer held for review")
+	// ErrTransferBlocked is matched by errors.Is for a ScreeningError with RuleBlock
+	ErrTransferBlocked = errors.New("transfer blocked")
+)
+
+// ScreenedTransfer is what transfer rules look at
+type ScreenedTransfer struct {
+	FromAccount Account   `json:"from_account"`
+	ToAccount   Account   `json:"to_account"`
+	Amount      int64     `json:"amount"`
+	Now         time.Time `json:"now"`
+}
+
+// RuleResult is the decision of one rule, Reason says why when the outcome is not RuleAllow
+type RuleResult struct {
+	Outcome RuleOutcome `json:"outcome"`
+	Reason  string      `json:"reason,omitempty"`
+}
+
+// RuleHit is a rule that did not allow a transfer, recorded in review_cases.hits
+type RuleHit struct {
+	Rule    string      `json:"rule"`
+	Outcome RuleOutcome `json:"outcome"`
+	Reason  string      `json:"reason"`
+}
+
+// TransferRule screens transfers before TransferTx moves any money.
+// Evaluate runs inside the transfer transaction, q reads the history as the transaction sees it.
+type TransferRule interface {
+	Name() string
+	Evaluate(ctx context.Context, q Querier, transfer ScreenedTransfer) (RuleResult, error)
+}
+
+// ScreeningError is returned by TransferTx when a rule holds or blocks the transfer
+type ScreeningError struct {
+	Outcome RuleOutcome `json:"outcome"`
+	Hits    []RuleHit   `json:"hits"`
+	// CaseID is the review case of the transfer: pending when held, already rejected when blocked
+	CaseID int64 `json:"case_id"`
+}
+
+func (e *ScreeningError) Error() string {
+	if e.Outcome == RuleHold {
+		return fmt.Sprintf("%s: review case %d, %d rule(s) hit", ErrTransferHeld, e.CaseID, len(e.Hits))
+	}
+	return fmt.Sprintf("%s: %d rule(s) hit", ErrTransferBlocked, len(e.Hits))
+}
+
+// Is makes errors.Is match ErrTransferHeld or ErrTransferBlocked by the outcome
+func (e *ScreeningError) Is(target error) bool {
+	return (target == ErrTransferHeld && e.Outcome == RuleHold) || (target == ErrTransferBlocked && e.Outcome == RuleBlock)
+}
+
+// screenTransfer 依序跑所有規則，結果取最嚴重的；每條沒放行的規則都記成 hit。規則出錯就整筆轉帳失敗，不會默默放行
+func screenTransfer(ctx context.Context, q Querier, rules []TransferRule, transfer ScreenedTransfer) (RuleOutcome, []RuleHit, error) {
+	outcome := RuleAllow
+	var hits []RuleHit
+
+	for _, rule := range rules {
+		result, err := rule.Evaluate(ctx, q, transfer)
+		if err != nil {
+			return outcome, hits, fmt.Errorf("rule %s: %w", rule.Name(), err)
+		}
+
+		rank, ok := ruleOutcomeRank[result.Outcome]
+		if !ok {
+			return outcome, hits, fmt.Errorf("rule %s: unknown outcome %q", rule.Name(), result.Outcome)
+		}
+		if result.Outcome == RuleAllow {
+			continue
+		}
+
+		hits = append(hits, RuleHit{Rule: rule.Name(), Outcome: result.Outcome, Reason: result.Reason})
+		if rank > ruleOutcomeRank[outcome] {
+			outcome = result.Outcome
+		}
+	}
+
+	return outcome, hits, nil
+}
+
+// recordScreenedTransfer 記一筆 review case，回傳它還沒寫的 audit log，轉帳本身不寫：
+// hold 的 case 等人工決定，block 的 case 直接記成已拒絕
+func recordScreenedTransfer(ctx context.Context, q *Queries, transfer ScreenedTransfer, outcome RuleOutcome, hits []RuleHit) (ReviewCase, auditRecord, error) {
+	payload, err := json.Marshal(hits)
+	if err != nil {
+		return ReviewCase{}, auditRecord{}, err
+	}
+
+	var reviewCase ReviewCase
+	if outcome == RuleBlock {
+		reviewCase, err = q.CreateRejectedReviewCase(ctx, CreateRejectedReviewCaseParams{
+			FromAccountID: transfer.FromAccount.ID,
+			ToAccountID:   transfer.ToAccount.ID,
+			Amount:        transfer.Amount,
+			Hits:          payload,
+			Reviewer:      SystemActor,
+		})
+	} else {
+		reviewCase, err = q.CreateReviewCase(ctx, CreateReviewCaseParams{
+			FromAccountID: transfer.FromAccount.ID,
+			ToAccountID:   transfer.ToAccount.ID,
+			Amount:        transfer.Amount,
+			Hits:          payload,
+		})
+	}
+	if err != nil {
+		return reviewCase, auditRecord{}, err
+	}
+
+	return reviewCase, auditRecord{action: AuditActionCreate, entityType: AuditEntityReviewCase, entityID: strconv.FormatInt(reviewCase.ID, 10), after: reviewCase}, nil
+}
+
+// StructuringRule holds transfers just under a reporting threshold when the account keeps making them,
+// e.g. several 9,900 transfers to stay under 10,000
+type StructuringRule struct {
+	Threshold int64
+	// Margin is how far under Threshold counts as just under
+	Margin int64
+	Window time.Duration
+	// Count is how many such transfers within Window, including this one, are flagged
+	Count int64
+}
+
+// Name implements TransferRule
+func (rule StructuringRule) Name() string {
+	return "structuring"
+}
+
+// Evaluate implements TransferRule
+func (rule StructuringRule) Evaluate(ctx context.Context, q Querier, transfer ScreenedTransfer) (RuleResult, error) {
+	lower, upper := rule.Threshold-rule.Margin, rule.Threshold-1
+	if transfer.Amount < lower || transfer.Amount > upper {
+		return RuleResult{Outcome: RuleAllow}, nil
+	}
+
+	count, err := q.CountTransfersInAmountRange(ctx, CountTransfersInAmountRangeParams{
+		AccountID: transfer.FromAccount.ID,
+		MinAmount: lower,
+		MaxAmount: upper,
+		Since:     transfer.Now.Add(-rule.Window),
+	})
+	if err != nil {
+		return RuleResult{}, err
+	}
+	if count+1 < rule.Count {
+		return RuleResult{Outcome: RuleAllow}, nil
+	}
+
+	return RuleResult{
+		Outcome: RuleHold,
+		Reason:  fmt.Sprintf("%d transfers between %d and %d within %s", count+1, lower, upper, rule.Window),
+	}, nil
+}
+
+// RapidMovementRule holds transfers that send out most of what the account received within a short window
+type RapidMovementRule struct {
+	Window time.Duration
+	// MinAmount ignores small transfers
+	MinAmount int64
+	// Percent of the money received within Window that may leave in one transfer
+	Percent int64
+}
+
+// Name implements TransferRule
+func (rule RapidMovementRule) Name() string {
+	return "rapid_movement"
+}
+
+// Evaluate implements TransferRule
+func (rule RapidMovementRule) Evaluate(ctx context.Context, q Querier, transfer ScreenedTransfer) (RuleResult, error) {
+	if transfer.Amount < rule.MinAmount {
+		return RuleResult{Outcome: RuleAllow}, nil
+	}
+
+	received, err := q.SumIncomingEntries(ctx, SumIncomingEntriesParams{
+		AccountID: transfer.FromAccount.ID,
+		Since:     transfer.Now.Add(-rule.Window),
+	})
+	if err != nil {
+		return RuleResult{}, err
+	}
+	if received == 0 || transfer.Amount*100 < received*rule.Percent {
+		return RuleResult{Outcome: RuleAllow}, nil
+	}
+
+	return RuleResult{
+		Outcome: RuleHold,
+		Reason:  fmt.Sprintf("sends %d of %d received within %s", transfer.Amount, received, rule.Window),
+	}, nil
+}
+
+// NewAccountRule holds large transfers to accounts opened recently
+type NewAccountRule struct {
+	MaxAge    time.Duration
+	MinAmount int64
+}
+
+// Name implements TransferRule
+func (rule NewAccountRule) Name() string {
+	return "new_account"
+}
+
+// Evaluate implements TransferRule
+func (rule NewAccountRule) Evaluate(ctx context.Context, q Querier, transfer ScreenedTransfer) (RuleResult, error) {
+	age := transfer.Now.Sub(transfer.ToAccount.CreatedAt)
+	if transfer.Amount < rule.MinAmount || age >= rule.MaxAge {
+		return RuleResult{Outcome: RuleAllow}, nil
+	}
+
+	return RuleResult{
+		Outcome: RuleHold,
+		Reason:  fmt.Sprintf("%d to account %d opened %s ago", transfer.Amount, transfer.ToAccount.ID, age.Round(time.Second)),
+	}, nil
+}
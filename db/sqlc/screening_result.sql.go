@@ -0,0 +1,140 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: screening_result.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createScreeningResult = `-- name: CreateScreeningResult :one
+INSERT INTO screening_results (
+  account_id, name, normalized_name, outcome, score, entry_id, matched_name
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING id, account_id, name, normalized_name, outcome, score, entry_id, matched_name, created_at
+`
+
+type CreateScreeningResultParams struct {
+	AccountID      sql.NullInt64 `json:"account_id"`
+	Name           string        `json:"name"`
+	NormalizedName string        `json:"normalized_name"`
+	Outcome        string        `json:"outcome"`
+	Score          float64       `json:"score"`
+	EntryID        string        `json:"entry_id"`
+	MatchedName    string        `json:"matched_name"`
+}
+
+func (q *Queries) CreateScreeningResult(ctx context.Context, arg CreateScreeningResultParams) (ScreeningResult, error) {
+	row := q.queryRow(ctx, q.createScreeningResultStmt, createScreeningResult,
+		arg.AccountID,
+		arg.Name,
+		arg.NormalizedName,
+		arg.Outcome,
+		arg.Score,
+		arg.EntryID,
+		arg.MatchedName,
+	)
+	var i ScreeningResult
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Name,
+		&i.NormalizedName,
+		&i.Outcome,
+		&i.Score,
+		&i.EntryID,
+		&i.MatchedName,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listScreeningResults = `-- name: ListScreeningResults :many
+SELECT id, account_id, name, normalized_name, outcome, score, entry_id, matched_name, created_at FROM screening_results
+WHERE outcome = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListScreeningResultsParams struct {
+	Outcome    string `json:"outcome"`
+	AfterID    int64  `json:"after_id"`
+	LimitCount int32  `json:"limit_count"`
+}
+
+func (q *Queries) ListScreeningResults(ctx context.Context, arg ListScreeningResultsParams) ([]ScreeningResult, error) {
+	rows, err := q.query(ctx, q.listScreeningResultsStmt, listScreeningResults, arg.Outcome, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ScreeningResult{}
+	for rows.Next() {
+		var i ScreeningResult
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Name,
+			&i.NormalizedName,
+			&i.Outcome,
+			&i.Score,
+			&i.EntryID,
+			&i.MatchedName,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScreeningResultsByAccount = `-- name: ListScreeningResultsByAccount :many
+SELECT id, account_id, name, normalized_name, outcome, score, entry_id, matched_name, created_at FROM screening_results
+WHERE account_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListScreeningResultsByAccount(ctx context.Context, accountID sql.NullInt64) ([]ScreeningResult, error) {
+	rows, err := q.query(ctx, q.listScreeningResultsByAccountStmt, listScreeningResultsByAccount, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ScreeningResult{}
+	for rows.Next() {
+		var i ScreeningResult
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Name,
+			&i.NormalizedName,
+			&i.Outcome,
+			&i.Score,
+			&i.EntryID,
+			&i.MatchedName,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRule always returns result
+type fakeRule struct {
+	name   string
+	result RuleResult
+	err    error
+}
+
+func (rule fakeRule) Name() string {
+	return rule.name
+}
+
+func (rule fakeRule) Evaluate(ctx context.Context, q Querier, transfer ScreenedTransfer) (RuleResult, error) {
+	return rule.result, rule.err
+}
+
+// fakeHistory answers the history queries of the built-in rules, the other methods of Querier are not used
+type fakeHistory struct {
+	Querier
+	count    int64
+	received int64
+}
+
+func (history fakeHistory) CountTransfersInAmountRange(ctx context.Context, arg CountTransfersInAmountRangeParams) (int64, error) {
+	return history.count, nil
+}
+
+func (history fakeHistory) SumIncomingEntries(ctx context.Context, arg SumIncomingEntriesParams) (int64, error) {
+	return history.received, nil
+}
+
+func TestScreenTransfer(t *testing.T) {
+	allow := fakeRule{name: "allow", result: RuleResult{Outcome: RuleAllow}}
+	hold := fakeRule{name: "hold", result: RuleResult{Outcome: RuleHold, Reason: "looks odd"}}
+	block := fakeRule{name: "block", result: RuleResult{Outcome: RuleBlock, Reason: "sanctioned"}}
+
+	testCases := []struct {
+		name    string
+		rules   []TransferRule
+		outcome RuleOutcome
+		hits    []string
+	}{
+		{"no rules", nil, RuleAllow, nil},
+		{"allow", []TransferRule{allow, allow}, RuleAllow, nil},
+		{"hold", []TransferRule{allow, hold}, RuleHold, []string{"hold"}},
+		// 最嚴重的結果勝出，但每條規則的 hit 都留著
+		{"block wins", []TransferRule{hold, block, hold}, RuleBlock, []string{"hold", "block", "hold"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			outcome, hits, err := screenTransfer(context.Background(), nil, tc.rules, ScreenedTransfer{})
+			require.NoError(t, err)
+			require.Equal(t, tc.outcome, outcome)
+
+			var names []string
+			for _, hit := range hits {
+				names = append(names, hit.Rule)
+			}
+			require.Equal(t, tc.hits, names)
+		})
+	}
+
+	errRule := errors.New("history unavailable")
+	_, _, err := screenTransfer(context.Background(), nil, []TransferRule{fakeRule{name: "broken", err: errRule}}, ScreenedTransfer{})
+	require.ErrorIs(t, err, errRule)
+
+	_, _, err = screenTransfer(context.Background(), nil, []TransferRule{fakeRule{name: "odd", result: RuleResult{Outcome: "maybe"}}}, ScreenedTransfer{})
+	require.Error(t, err)
+}
+
+func TestScreeningError(t *testing.T) {
+	held := &ScreeningError{Outcome: RuleHold, CaseID: 1}
+	require.ErrorIs(t, held, ErrTransferHeld)
+	require.NotErrorIs(t, held, ErrTransferBlocked)
+
+	blocked := &ScreeningError{Outcome: RuleBlock}
+	require.ErrorIs(t, blocked, ErrTransferBlocked)
+	require.NotErrorIs(t, blocked, ErrTransferHeld)
+}
+
+func TestBuiltInRules(t *testing.T) {
+	now := time.Now()
+	structuring := StructuringRule{Threshold: 10000, Margin: 500, Window: 24 * time.Hour, Count: 3}
+	rapid := RapidMovementRule{Window: time.Hour, MinAmount: 1000, Percent: 80}
+	newAccount := NewAccountRule{MaxAge: 7 * 24 * time.Hour, MinAmount: 5000}
+
+	testCases := []struct {
+		name     string
+		rule     TransferRule
+		history  fakeHistory
+		transfer ScreenedTransfer
+		outcome  RuleOutcome
+	}{
+		{"structuring third time", structuring, fakeHistory{count: 2}, ScreenedTransfer{Amount: 9900}, RuleHold},
+		{"structuring second time", structuring, fakeHistory{count: 1}, ScreenedTransfer{Amount: 9900}, RuleAllow},
+		{"structuring at threshold", structuring, fakeHistory{count: 5}, ScreenedTransfer{Amount: 10000}, RuleAllow},
+		{"rapid out", rapid, fakeHistory{received: 5000}, ScreenedTransfer{Amount: 4000}, RuleHold},
+		{"rapid part", rapid, fakeHistory{received: 5000}, ScreenedTransfer{Amount: 3999}, RuleAllow},
+		{"rapid nothing received", rapid, fakeHistory{}, ScreenedTransfer{Amount: 4000}, RuleAllow},
+		{"rapid small", rapid, fakeHistory{received: 500}, ScreenedTransfer{Amount: 500}, RuleAllow},
+		{"new account large", newAccount, fakeHistory{}, ScreenedTransfer{Amount: 5000, ToAccount: Account{CreatedAt: now.Add(-time.Hour)}, Now: now}, RuleHold},
+		{"old account large", newAccount, fakeHistory{}, ScreenedTransfer{Amount: 5000, ToAccount: Account{CreatedAt: now.AddDate(0, 0, -8)}, Now: now}, RuleAllow},
+		{"new account small", newAccount, fakeHistory{}, ScreenedTransfer{Amount: 4999, ToAccount: Account{CreatedAt: now}, Now: now}, RuleAllow},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := tc.rule.Evaluate(context.Background(), tc.history, tc.transfer)
+			require.NoError(t, err)
+			require.Equal(t, tc.outcome, result.Outcome)
+			if tc.outcome != RuleAllow {
+				require.NotEmpty(t, result.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListStatementEntries(t *testing.T) {
+	store := NewStore(testDB)
+	currency := util.RandomCurrency()
+	account1, err := store.CreateAccount(context.Background(), CreateAccountParams{Owner: util.RandomOwner(), Balance: 100, Currency: currency})
+	require.NoError(t, err)
+	account2, err := store.CreateAccount(context.Background(), CreateAccountParams{Owner: util.RandomOwner(), Balance: 0, Currency: currency})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        30,
+	})
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.ID, result.FromEntry.TransferID.Int64)
+	require.Equal(t, result.Transfer.ID, result.ToEntry.TransferID.Int64)
+
+	rows, err := store.ListStatementEntries(context.Background(), ListStatementEntriesParams{
+		AccountID: account1.ID,
+		FromTime:  time.Now().Add(-time.Hour),
+		ToTime:    time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	// 期初餘額是 clearing 帳戶入金，參考號是 opening:<id>
+	require.Equal(t, "deposit", rows[0].Kind)
+	require.Equal(t, int64(100), rows[0].Amount)
+	require.Zero(t, rows[0].TransferID)
+	require.Equal(t, "opening:"+strconv.FormatInt(account1.ID, 10), rows[0].Reference)
+	clearing, err := store.GetSystemAccount(context.Background(), GetSystemAccountParams{Purpose: SystemAccountClearing, Currency: currency})
+	require.NoError(t, err)
+	require.Equal(t, clearing.ID, rows[0].CounterpartyAccountID)
+	require.Equal(t, clearing.AccountNumber, rows[0].CounterpartyAccountNumber)
+
+	require.Equal(t, "transfer", rows[1].Kind)
+	require.Equal(t, result.FromEntry.ID, rows[1].ID)
+	require.Equal(t, int64(-30), rows[1].Amount)
+	require.Equal(t, result.Transfer.ID, rows[1].TransferID)
+	require.Equal(t, account2.ID, rows[1].CounterpartyAccountID)
+	require.Equal(t, account2.AccountNumber, rows[1].CounterpartyAccountNumber)
+	require.Empty(t, rows[1].Reference)
+}
@@ -4,12 +4,44 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Store provides all functions to execute database transactions
 type Store struct {
-	*Queries
-	db *sql.DB
+	// Store 只內嵌 StoreQuerier：其他會寫入的查詢不寫 audit log，只能在 execTx 的事務裡用
+	StoreQuerier
+	queries       *Queries
+	db            *sql.DB
+	rules         []TransferRule
+	ownerScreener NameScreener
+	// prepared 只有 NewPreparedStore 建的 Store 才有
+	prepared *preparedQueries
+	// replica 和 poolConfig 分別由 WithReplica 和 WithPoolConfig 設定
+	replica    *sql.DB
+	poolConfig *PoolConfig
+	// metrics 和 tracer 分別由 WithMetrics 和 WithTracing 設定，nil 就不記錄
+	metrics *Metrics
+	tracer  trace.Tracer
+}
+
+// StoreOption configures a Store
+type StoreOption func(store *Store)
+
+// WithTransferRules makes TransferTx screen every transfer with rules before moving any money
+func WithTransferRules(rules ...TransferRule) StoreOption {
+	return func(store *Store) {
+		store.rules = append(store.rules, rules...)
+	}
+}
+
+// ScreensTransfers reports whether TransferTx screens transfers with rules, see WithTransferRules
+func (store *Store) ScreensTransfers() bool {
+	return len(store.rules) > 0
 }
 
 // NewStore 就是给外部用的“构造函数”：
@@ -32,7 +64,7 @@ type Store struct {
 // 这样，你就能把跨多张表、多个 CRUD 操作的业务流程，包装在同一个事务里，保证要么全成功要么全回滚，而不是把这些关键信息散落到单个 Queries 方法里去管理。
 
 // NewStore creates a new Store instance
-func NewStore(db *sql.DB) *Store {
+func NewStore(db *sql.DB, opts ...StoreOption) *Store {
 	// 怎麼確認 *sql.DB 實例實現了 DBTX interface？
 	// 要在代码里确保无误、并让其他读代码的人也一看就懂，var _ Interface = (*Type)(nil) 就是最简洁、最惯用的做法
 	// (*sql.DB)(nil)——“把 nil 转成 *sql.DB 类型”
@@ -40,11 +72,36 @@ func NewStore(db *sql.DB) *Store {
 	var _ DBTX = (*sql.DB)(nil) // 確認 *sql.DB 實例實現了 DBTX interface
 
 	// 創建一個新的 SQLStore 實例，並返回一個 Store 實例
-	return &Store{
+	store := &Store{
 		db: db,
-		// func New(db DBTX) *Queries 接收一個 DBTX type 參數，因為 db 是 *sql.DB 實例， *sql.DB 實現了 DBTX interface，所以可以傳入 db 參數
-		Queries: New(db),
 	}
+	// func New(db DBTX) *Queries 接收一個 DBTX type 參數，因為 db 是 *sql.DB 實例， *sql.DB 實現了 DBTX interface，所以可以傳入 db 參數
+	store.setQueries(New(db))
+	for _, opt := range opts {
+		opt(store)
+	}
+	// 所有選項都套用完才設定連線池，WithPoolConfig 寫在 WithReplica 前面也會套用到 replica
+	if store.poolConfig != nil {
+		store.poolConfig.apply(db)
+		if store.replica != nil {
+			store.poolConfig.apply(store.replica)
+		}
+	}
+	// 包在 replica 的路由外面，送到 replica 的查詢也記得到
+	store.setQueries(New(store.instrument(store.queries.db, nil)))
+	if store.metrics != nil {
+		store.metrics.registerPool(db, "primary")
+		if store.replica != nil {
+			store.metrics.registerPool(store.replica, "replica")
+		}
+	}
+	return store
+}
+
+// setQueries 換掉 Store 在事務外用的 *Queries
+func (store *Store) setQueries(queries *Queries) {
+	store.queries = queries
+	store.StoreQuerier = queries
 }
 
 // 為什麼這邊需要 ctx context.Context ？
@@ -57,7 +114,7 @@ func NewStore(db *sql.DB) *Store {
 // 為什麼這邊需要 fn func(*Queries) error ？
 // 这里用 fn func(*Queries) error 是为了把 “在同一个事务里执行一系列查询” 的逻辑抽象出来：
 // execTx 负责打开事务（BeginTx）、在结束时根据 fn 返回的 error 决定是 Rollback 还是 Commit，把事务的生命周期管理（开启/回滚/提交）都封装好了。
-// fn 就是让你传入一段“具体要在这次事务里做什么”的业务代码，它接收一个 *Queries（内部其实是绑定在这个事务 tx 上的），你在里面可以任意调用 q.CreateTransfer(ctx,…)、q.UpdateAccount(ctx,…) 等操作。
+// fn 就是让你传入一段“具体要在这次事务里做什么”的业务代码，它接收一个 *Queries（内部其实是绑定在这个事务 tx 上的），你在里面可以任意调用 q.CreateTransfer(ctx,…)、q.CreateEntry(ctx,…) 等操作。
 // 这样做的好处是：
 // 把事务的模板代码（Begin/Commit/Rollback）统一放在 execTx 里，业务逻辑里只管写自己的 fn，不必每次都重复写错误处理和回滚代码。
 // 保证所有通过 q := New(tx) 得到的查询都在同一个事务上下文里执行。
@@ -65,8 +122,27 @@ func NewStore(db *sql.DB) *Store {
 
 // execTx executes a function within a database transaction
 func (store *Store) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.db.BeginTx(ctx, nil)
+	// 指標和 span 都用呼叫 execTx 的 Store 方法當事務名稱
+	var name string
+	if store.metrics != nil || store.tracer != nil {
+		name = callerName(2)
+	}
+	ctx, span := store.startSpan(ctx, name, semconv.DBSystemNamePostgreSQL)
+	defer span.End()
+	observe := store.metrics.startTx(name)
+	finish := func(outcome string, err error) {
+		observe(outcome, err)
+		span.SetAttributes(txOutcomeKey.String(outcome))
+		endSpan(span, err)
+	}
+
+	var tx *sql.Tx
+	err := store.traceStep(ctx, "BEGIN", func(ctx context.Context) (err error) {
+		tx, err = store.db.BeginTx(ctx, nil)
+		return err
+	})
 	if err != nil {
+		finish(OutcomeError, err)
 		return err
 	}
 
@@ -77,16 +153,34 @@ func (store *Store) execTx(ctx context.Context, fn func(*Queries) error) error {
 	// 一旦中途出错，tx.Rollback() 也不会撤销那些已经跑在 *sql.DB 上的操作，失去原子性保证。
 	// 虽然它“不报错”，但你会丢失事务的语义。为了确保一组读写要么全成功要么全回滚，一定要用 New(tx)。
 	// 所以在 execTx 里要用 New(tx)，才能把后续的所有操作都“绑在”这个事务里，保证 fn(q) 里所有的 q.* 调用都在同一个事务上下文执行，做到原子性。
-	q := New(tx)
+	var db DBTX = tx
+	if store.prepared != nil {
+		var release func()
+		db, release = store.prepared.withTx(tx)
+		defer release()
+	}
+	q := New(store.instrument(db, span))
 	err = fn(q)
 	if err != nil {
-		if rbErr := tx.Rollback(); rbErr != nil {
+		if rbErr := store.traceStep(ctx, "ROLLBACK", func(context.Context) error { return tx.Rollback() }); rbErr != nil {
+			finish(OutcomeError, err)
 			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
 		}
+		finish(OutcomeRollback, err)
+		if store.prepared != nil && isStalePreparedStatement(err) {
+			// 伺服器上的 statement 失效了，這次照樣回傳錯誤，下一個事務用重新 prepare 的
+			store.prepared.reprepare(store.db)
+		}
 		return err
 	}
 
-	return tx.Commit()
+	err = store.traceStep(ctx, "COMMIT", func(context.Context) error { return tx.Commit() })
+	if err != nil {
+		finish(OutcomeError, err)
+		return err
+	}
+	finish(OutcomeCommit, nil)
+	return nil
 }
 
 // 这些 json:"..." 标签是给 Go 的 encoding/json（或者其他兼容 JSON tag 的库）用的，作用就是：
@@ -99,6 +193,18 @@ type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	Amount        int64 `json:"amount"`
+	// AfterTransfer runs inside the transaction once the transfer is recorded, e.g. to enqueue tasks through q.
+	// Returning an error rolls back the whole transfer.
+	AfterTransfer func(q *Queries, result TransferTxResult) error `json:"-"`
+
+	// reviewed 由 ApproveReviewCaseTx 設定：規則在 hold 的時候已經跑過，核准後不再攔一次
+	reviewed bool
+	// afterScreening 在規則 hold 或 block 轉帳時、review case 寫好之後在同一個事務裡執行，
+	// 回傳的 audit log 和 case 的一起在最後寫；PaymentInstructionTx 用它把 instruction 和 case 一起 Commit
+	afterScreening func(q *Queries, screening *ScreeningError) ([]auditRecord, error)
+	// afterTransferAudited 在 AfterTransfer 之後執行，回傳的 audit log 和轉帳的一起用 recordAudits 寫；
+	// ApproveReviewCaseTx 用它在同一個事務決定 case
+	afterTransferAudited func(q *Queries, result TransferTxResult) ([]auditRecord, error)
 }
 
 // TransferTxResult is the result of the transfer transaction
@@ -117,15 +223,45 @@ type TransferTxResult struct {
 // var txKey = txKeyType{} // debug
 
 // TransferTx performs a money transfer from one account to the other.
-// It creates the transfer, add account entries, and update accounts' balance within a single database transaction
+// It creates the transfer, add account entries, update accounts' balance, record a transfer.created outbox event and an audit log row within a single database transaction.
+// It fails with ErrAccountClosed when either account is closed, with ErrInsufficientFunds when the from account would go below its overdraft limit,
+// and with a *LimitExceededError when it would break a transfer limit of the from account or its owner.
+// With WithTransferRules, a *ScreeningError is returned when a rule holds the transfer for review or blocks it;
+// only a review case is committed then, pending for a held transfer and already rejected for a blocked one.
 func (store *Store) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
+	var screening *ScreeningError
 
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
 
 		// txName := ctx.Value(txKey) // debug
 
+		if len(store.rules) > 0 && !arg.reviewed {
+			var audits []auditRecord
+			screening, audits, err = store.screenTransfer(ctx, q, arg)
+			if err != nil {
+				return err
+			}
+			if screening != nil {
+				// hold 和 block 都只 Commit review case，錢不動
+				if arg.afterScreening != nil {
+					more, err := arg.afterScreening(q, screening)
+					if err != nil {
+						return err
+					}
+					audits = append(audits, more...)
+				}
+				return recordAudits(ctx, q, audits...)
+			}
+		}
+
+		// 限額的 advisory lock 要在鎖 row 之前拿，見 CheckTransferLimits
+		err = CheckTransferLimits(ctx, q, arg.FromAccountID, arg.Amount)
+		if err != nil {
+			return err
+		}
+
 		// fmt.Println(txName, "create transfer") // debug
 		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
 			FromAccountID: arg.FromAccountID,
@@ -138,8 +274,9 @@ func (store *Store) TransferTx(ctx context.Context, arg TransferTxParams) (Trans
 
 		// fmt.Println(txName, "create entry 1") // debug
 		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
+			AccountID:  arg.FromAccountID,
+			Amount:     -arg.Amount,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
 		})
 		if err != nil {
 			return err
@@ -147,8 +284,9 @@ func (store *Store) TransferTx(ctx context.Context, arg TransferTxParams) (Trans
 
 		// fmt.Println(txName, "create entry 2") // debug
 		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
+			AccountID:  arg.ToAccountID,
+			Amount:     arg.Amount,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
 		})
 		if err != nil {
 			return err
@@ -199,12 +337,72 @@ func (store *Store) TransferTx(ctx context.Context, arg TransferTxParams) (Trans
 			}
 		}
 
-		return nil
+		// 扣款之後才檢查，兩邊帳戶的 row 都已經被鎖住
+		if err := CheckOpen(result.FromAccount, result.ToAccount); err != nil {
+			return err
+		}
+		if err := CheckFunds(result.FromAccount, arg.Amount); err != nil {
+			return err
+		}
+
+		// 事件放在最後寫，payload 才會帶到更新後的帳戶餘額
+		err = recordOutboxEvent(ctx, q, AggregateTransfer, result.Transfer.ID, EventTransferCreated, result)
+		if err != nil {
+			return err
+		}
+
+		// 在同一個事務裡把後續工作（寄信、webhook、對帳單）排進 tasks 表：
+		// 轉帳 Commit 了 task 才存在，轉帳被 Rollback 的話 task 也一起消失
+		if arg.AfterTransfer != nil {
+			if err := arg.AfterTransfer(q, result); err != nil {
+				return err
+			}
+		}
+
+		var audits []auditRecord
+		if arg.afterTransferAudited != nil {
+			audits, err = arg.afterTransferAudited(q, result)
+			if err != nil {
+				return err
+			}
+		}
+
+		// audit log 一定要最後寫，見 recordAudits
+		audits = append(audits, auditRecord{action: AuditActionCreate, entityType: AuditEntityTransfer, entityID: strconv.FormatInt(result.Transfer.ID, 10), after: result})
+		return recordAudits(ctx, q, audits...)
 	})
+	if err == nil && screening != nil {
+		return result, screening
+	}
 
 	return result, err
 }
 
+// screenTransfer runs the rules of the store on a transfer; a held or blocked transfer gets a review case,
+// returned with its audit log row still to be written. It returns nil when every rule allows the transfer.
+func (store *Store) screenTransfer(ctx context.Context, q *Queries, arg TransferTxParams) (*ScreeningError, []auditRecord, error) {
+	from, err := q.GetAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := q.GetAccount(ctx, arg.ToAccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transfer := ScreenedTransfer{FromAccount: from, ToAccount: to, Amount: arg.Amount, Now: time.Now()}
+	outcome, hits, err := screenTransfer(ctx, q, store.rules, transfer)
+	if err != nil || outcome == RuleAllow {
+		return nil, nil, err
+	}
+
+	reviewCase, audit, err := recordScreenedTransfer(ctx, q, transfer, outcome, hits)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &ScreeningError{Outcome: outcome, Hits: hits, CaseID: reviewCase.ID}, []auditRecord{audit}, nil
+}
+
 func addMoney(
 	ctx context.Context,
 	q *Queries,
@@ -213,7 +411,14 @@ func addMoney(
 	accountID2 int64,
 	amount2 int64,
 ) (account1 Account, account2 Account, err error) {
-	account1, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+	// 所有改餘額的路徑都經過 addMoney，呼叫前都已經寫好對應的 entries；
+	// 在這裡打開 ledger writes，accounts_balance_guard trigger 才會放行
+	err = q.enableLedgerWrites(ctx)
+	if err != nil {
+		return
+	}
+
+	account1, err = q.addAccountBalance(ctx, addAccountBalanceParams{
 		ID:     accountID1,
 		Amount: amount1,
 	})
@@ -223,7 +428,7 @@ func addMoney(
 		return
 	}
 
-	account2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+	account2, err = q.addAccountBalance(ctx, addAccountBalanceParams{
 		ID:     accountID2,
 		Amount: amount2,
 	})
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// StoreQuerier is the part of Querier a Store runs outside its transactions: the reads, and the writes of the task queue,
+// webhook deliveries, email verification codes and ledger partitions, which are not recorded in the audit log.
+// Every other write only runs inside a Store transaction that records its audit log row, e.g. CreateAccount or TransferTx.
+type StoreQuerier interface {
+	AccountHasLedgerRows(ctx context.Context, id int64) (bool, error)
+	CountTransfersInAmountRange(ctx context.Context, arg CountTransfersInAmountRangeParams) (int64, error)
+	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error)
+	GetAccountImport(ctx context.Context, id int64) (AccountImport, error)
+	GetAccountProduct(ctx context.Context, id int64) (AccountProduct, error)
+	GetAccountTransferLimit(ctx context.Context, accountID sql.NullInt64) (TransferLimit, error)
+	GetAccountTransferUsage(ctx context.Context, fromAccountID int64) (GetAccountTransferUsageRow, error)
+	GetBalanceAt(ctx context.Context, arg GetBalanceAtParams) (GetBalanceAtRow, error)
+	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetExternalTransfer(ctx context.Context, id int64) (ExternalTransfer, error)
+	GetExternalTransferByRef(ctx context.Context, arg GetExternalTransferByRefParams) (ExternalTransfer, error)
+	GetInterestAccrual(ctx context.Context, arg GetInterestAccrualParams) (InterestAccrual, error)
+	GetInterestPosting(ctx context.Context, arg GetInterestPostingParams) (InterestPosting, error)
+	GetLastInterestPosting(ctx context.Context, accountID int64) (InterestPosting, error)
+	GetLastOverdraftCharge(ctx context.Context, accountID int64) (OverdraftCharge, error)
+	GetOutboxEvent(ctx context.Context, id int64) (Outbox, error)
+	GetOverdraftCharge(ctx context.Context, arg GetOverdraftChargeParams) (OverdraftCharge, error)
+	GetOwnerTransferLimit(ctx context.Context, arg GetOwnerTransferLimitParams) (TransferLimit, error)
+	GetOwnerTransferUsage(ctx context.Context, arg GetOwnerTransferUsageParams) (GetOwnerTransferUsageRow, error)
+	GetPaymentInstructionByEndToEndID(ctx context.Context, arg GetPaymentInstructionByEndToEndIDParams) (PaymentInstruction, error)
+	GetReviewCase(ctx context.Context, id int64) (ReviewCase, error)
+	GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (Account, error)
+	GetTask(ctx context.Context, id int64) (Task, error)
+	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetUser(ctx context.Context, username string) (User, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	GetWebhookEndpoint(ctx context.Context, id int64) (WebhookEndpoint, error)
+	ListAccountImportCurrencies(ctx context.Context, importID int64) ([]string, error)
+	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsByNumbers(ctx context.Context, accountNumbers []string) ([]Account, error)
+	ListAccountsByOwner(ctx context.Context, owner string) ([]Account, error)
+	ListAccruingAccounts(ctx context.Context, arg ListAccruingAccountsParams) ([]ListAccruingAccountsRow, error)
+	ListActiveWebhookEndpointsByOwner(ctx context.Context, owner string) ([]WebhookEndpoint, error)
+	ListArchivedEntryTotals(ctx context.Context, accountID int64) ([]ArchivedEntryTotal, error)
+	ListArchivedEntryTotalsByAccounts(ctx context.Context, accountIds []int64) ([]ArchivedEntryTotal, error)
+	ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error)
+	ListAuditLogsByEntity(ctx context.Context, arg ListAuditLogsByEntityParams) ([]AuditLog, error)
+	ListBalanceAdjustments(ctx context.Context, arg ListBalanceAdjustmentsParams) ([]BalanceAdjustment, error)
+	ListChargeableOverdraftAccounts(ctx context.Context, arg ListChargeableOverdraftAccountsParams) ([]ListChargeableOverdraftAccountsRow, error)
+	ListCurrencyImbalances(ctx context.Context) ([]ListCurrencyImbalancesRow, error)
+	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListEntriesByAccounts(ctx context.Context, accountIds []int64) ([]Entry, error)
+	ListExternalTransfers(ctx context.Context, arg ListExternalTransfersParams) ([]ExternalTransfer, error)
+	ListExternalTransfersByAccounts(ctx context.Context, accountIds []int64) ([]ExternalTransfer, error)
+	ListInterestPostings(ctx context.Context, accountID int64) ([]InterestPosting, error)
+	ListLedgerMismatches(ctx context.Context) ([]ListLedgerMismatchesRow, error)
+	ListOutboxEventsByAggregate(ctx context.Context, arg ListOutboxEventsByAggregateParams) ([]Outbox, error)
+	ListOverdraftCharges(ctx context.Context, accountID int64) ([]OverdraftCharge, error)
+	ListOverdraftUsage(ctx context.Context, arg ListOverdraftUsageParams) ([]ListOverdraftUsageRow, error)
+	ListPaymentInstructionsByMessage(ctx context.Context, messageID string) ([]PaymentInstruction, error)
+	ListPendingOutboxEvents(ctx context.Context, limit int32) ([]Outbox, error)
+	ListReviewCases(ctx context.Context, arg ListReviewCasesParams) ([]ReviewCase, error)
+	ListScreeningResults(ctx context.Context, arg ListScreeningResultsParams) ([]ScreeningResult, error)
+	ListScreeningResultsByAccount(ctx context.Context, accountID sql.NullInt64) ([]ScreeningResult, error)
+	ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error)
+	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListTransfersByAccounts(ctx context.Context, accountIds []int64) ([]Transfer, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhookDeliveriesByStatus(ctx context.Context, arg ListWebhookDeliveriesByStatusParams) ([]WebhookDelivery, error)
+	ListWebhookDeliveryAttempts(ctx context.Context, deliveryID int64) ([]WebhookDeliveryAttempt, error)
+	ListWebhookEndpointsByOwner(ctx context.Context, owner string) ([]WebhookEndpoint, error)
+	SumIncomingEntries(ctx context.Context, arg SumIncomingEntriesParams) (int64, error)
+	SumUnpostedInterestAccruals(ctx context.Context, arg SumUnpostedInterestAccrualsParams) (SumUnpostedInterestAccrualsRow, error)
+	SummarizeOverdraftUsage(ctx context.Context) ([]SummarizeOverdraftUsageRow, error)
+	// task 佇列，見 worker.PGTaskProcessor
+	ClaimNextTask(ctx context.Context, arg ClaimNextTaskParams) (Task, error)
+	CompleteTask(ctx context.Context, arg CompleteTaskParams) (int64, error)
+	CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error)
+	FailTask(ctx context.Context, arg FailTaskParams) (int64, error)
+	RequeueExpiredTasks(ctx context.Context) (int64, error)
+	RetryTask(ctx context.Context, arg RetryTaskParams) (int64, error)
+	// webhook 的投遞，嘗試的結果用 RecordWebhookAttemptTx 記
+	ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) error
+	ReplayWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	// email 驗證碼，用掉驗證碼的是 VerifyEmailTx
+	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
+	// 分區維護只建立和卸下分區，不改任何一筆資料
+	CreateLedgerPartitions(ctx context.Context, arg CreateLedgerPartitionsParams) ([]string, error)
+	DetachLedgerPartitions(ctx context.Context, arg DetachLedgerPartitionsParams) ([]string, error)
+}
+
+var _ StoreQuerier = (*Queries)(nil)
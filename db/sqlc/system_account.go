@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// Purposes of the system accounts, every purpose has one account per currency
+const (
+	// SystemAccountSuspense takes the other side of manual balance adjustments
+	SystemAccountSuspense = "suspense"
+	// SystemAccountClearing takes the other side of deposits, withdrawals and opening balances
+	SystemAccountClearing = "clearing"
+	// SystemAccountInterestExpense pays the interest posted to the accounts
+	SystemAccountInterestExpense = "interest_expense"
+	// SystemAccountOverdraftIncome receives the overdraft interest and fees charged to the accounts
+	SystemAccountOverdraftIncome = "overdraft_income"
+)
+
+// systemAccountOwner is the owner of the accounts created for purpose
+func systemAccountOwner(purpose string) string {
+	return "system:" + purpose
+}
+
+// IsSystemAccount reports whether account is one of the system accounts
+func IsSystemAccount(account Account) bool {
+	return strings.HasPrefix(account.Owner, systemAccountOwner(""))
+}
+
+// getOrCreateSystemAccount 回傳 purpose + currency 對應的系統帳戶，第一次用到時才建立。
+// 要在鎖其他帳戶的 row 之前呼叫：LockSystemAccount 拿的 advisory lock 一律排在 row lock 前面，不會和記帳的事務互相等待。
+func getOrCreateSystemAccount(ctx context.Context, q *Queries, purpose string, currency string) (Account, error) {
+	account, err := q.GetSystemAccount(ctx, GetSystemAccountParams{Purpose: purpose, Currency: currency})
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return account, err
+	}
+
+	err = q.LockSystemAccount(ctx, LockSystemAccountParams{Purpose: purpose, Currency: currency})
+	if err != nil {
+		return Account{}, err
+	}
+
+	// 拿到鎖之後再查一次，可能已經被前一個拿鎖的事務建好了
+	account, err = q.GetSystemAccount(ctx, GetSystemAccountParams{Purpose: purpose, Currency: currency})
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return account, err
+	}
+
+	account, err = q.CreateAccount(ctx, CreateAccountParams{
+		Owner:    systemAccountOwner(purpose),
+		Balance:  0,
+		Currency: currency,
+	})
+	if err != nil {
+		return Account{}, err
+	}
+
+	_, err = q.CreateSystemAccount(ctx, CreateSystemAccountParams{
+		Purpose:   purpose,
+		Currency:  currency,
+		AccountID: account.ID,
+	})
+	return account, err
+}
@@ -0,0 +1,211 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: task.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const claimNextTask = `-- name: ClaimNextTask :one
+UPDATE tasks
+  set status = 'running', attempts = attempts + 1, locked_until = $1::timestamptz, updated_at = now()
+WHERE id = (
+  SELECT t.id FROM tasks t
+  WHERE t.queue = $2 AND t.status = 'pending' AND t.run_at <= now()
+  ORDER BY t.priority DESC, t.run_at, t.id
+  LIMIT 1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, queue, type, payload, priority, status, attempts, max_attempts, run_at, locked_until, last_error, created_at, updated_at, unique_key
+`
+
+type ClaimNextTaskParams struct {
+	LockedUntil time.Time `json:"locked_until"`
+	Queue       string    `json:"queue"`
+}
+
+func (q *Queries) ClaimNextTask(ctx context.Context, arg ClaimNextTaskParams) (Task, error) {
+	row := q.queryRow(ctx, q.claimNextTaskStmt, claimNextTask, arg.LockedUntil, arg.Queue)
+	var i Task
+	err := row.Scan(
+		&i.ID,
+		&i.Queue,
+		&i.Type,
+		&i.Payload,
+		&i.Priority,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UniqueKey,
+	)
+	return i, err
+}
+
+const completeTask = `-- name: CompleteTask :execrows
+UPDATE tasks
+  set status = 'succeeded', locked_until = NULL, last_error = NULL, updated_at = now()
+WHERE id = $1 AND status = 'running' AND attempts = $2
+`
+
+type CompleteTaskParams struct {
+	ID       int64 `json:"id"`
+	Attempts int32 `json:"attempts"`
+}
+
+// 只有還拿著這次 claim 的 worker 才能改結果：鎖過期被別的 worker 重新 claim 的話 attempts 會加一，
+// 舊的 worker 改到 0 筆，不會蓋掉新的那次
+func (q *Queries) CompleteTask(ctx context.Context, arg CompleteTaskParams) (int64, error) {
+	result, err := q.exec(ctx, q.completeTaskStmt, completeTask, arg.ID, arg.Attempts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createTask = `-- name: CreateTask :one
+INSERT INTO tasks (
+  queue, type, payload, priority, max_attempts, run_at, unique_key
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (unique_key) DO NOTHING
+RETURNING id, queue, type, payload, priority, status, attempts, max_attempts, run_at, locked_until, last_error, created_at, updated_at, unique_key
+`
+
+type CreateTaskParams struct {
+	Queue       string          `json:"queue"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Priority    int32           `json:"priority"`
+	MaxAttempts int32           `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	UniqueKey   sql.NullString  `json:"unique_key"`
+}
+
+func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error) {
+	row := q.queryRow(ctx, q.createTaskStmt, createTask,
+		arg.Queue,
+		arg.Type,
+		arg.Payload,
+		arg.Priority,
+		arg.MaxAttempts,
+		arg.RunAt,
+		arg.UniqueKey,
+	)
+	var i Task
+	err := row.Scan(
+		&i.ID,
+		&i.Queue,
+		&i.Type,
+		&i.Payload,
+		&i.Priority,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UniqueKey,
+	)
+	return i, err
+}
+
+const failTask = `-- name: FailTask :execrows
+UPDATE tasks
+  set status = 'failed', last_error = $3, locked_until = NULL, updated_at = now()
+WHERE id = $1 AND status = 'running' AND attempts = $2
+`
+
+type FailTaskParams struct {
+	ID        int64          `json:"id"`
+	Attempts  int32          `json:"attempts"`
+	LastError sql.NullString `json:"last_error"`
+}
+
+func (q *Queries) FailTask(ctx context.Context, arg FailTaskParams) (int64, error) {
+	result, err := q.exec(ctx, q.failTaskStmt, failTask, arg.ID, arg.Attempts, arg.LastError)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getTask = `-- name: GetTask :one
+SELECT id, queue, type, payload, priority, status, attempts, max_attempts, run_at, locked_until, last_error, created_at, updated_at, unique_key FROM tasks
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetTask(ctx context.Context, id int64) (Task, error) {
+	row := q.queryRow(ctx, q.getTaskStmt, getTask, id)
+	var i Task
+	err := row.Scan(
+		&i.ID,
+		&i.Queue,
+		&i.Type,
+		&i.Payload,
+		&i.Priority,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UniqueKey,
+	)
+	return i, err
+}
+
+const requeueExpiredTasks = `-- name: RequeueExpiredTasks :execrows
+UPDATE tasks
+  set status = 'pending', locked_until = NULL, updated_at = now()
+WHERE status = 'running' AND locked_until < now()
+`
+
+func (q *Queries) RequeueExpiredTasks(ctx context.Context) (int64, error) {
+	result, err := q.exec(ctx, q.requeueExpiredTasksStmt, requeueExpiredTasks)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const retryTask = `-- name: RetryTask :execrows
+UPDATE tasks
+  set status = 'pending', run_at = $3, last_error = $4, locked_until = NULL, updated_at = now()
+WHERE id = $1 AND status = 'running' AND attempts = $2
+`
+
+type RetryTaskParams struct {
+	ID        int64          `json:"id"`
+	Attempts  int32          `json:"attempts"`
+	RunAt     time.Time      `json:"run_at"`
+	LastError sql.NullString `json:"last_error"`
+}
+
+func (q *Queries) RetryTask(ctx context.Context, arg RetryTaskParams) (int64, error) {
+	result, err := q.exec(ctx, q.retryTaskStmt, retryTask,
+		arg.ID,
+		arg.Attempts,
+		arg.RunAt,
+		arg.LastError,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
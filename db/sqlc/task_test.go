@@ -0,0 +1,223 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomTask(t *testing.T, queue string, priority int32) Task {
+	arg := CreateTaskParams{
+		Queue:       queue,
+		Type:        "test:" + util.RandomString(6, false),
+		Payload:     json.RawMessage(`{}`),
+		Priority:    priority,
+		MaxAttempts: 3,
+		RunAt:       time.Now(),
+	}
+
+	task, err := testQueries.CreateTask(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, task)
+
+	require.Equal(t, arg.Queue, task.Queue)
+	require.Equal(t, arg.Type, task.Type)
+	require.Equal(t, arg.Priority, task.Priority)
+	require.Equal(t, "pending", task.Status)
+	require.Zero(t, task.Attempts)
+	require.NotZero(t, task.ID)
+
+	return task
+}
+
+func TestClaimNextTask(t *testing.T) {
+	// 每個測試用自己的 queue，不會拿到其他測試的 task
+	queue := "test-" + util.RandomString(10, false)
+	low := createRandomTask(t, queue, 0)
+	high := createRandomTask(t, queue, 5)
+
+	lockedUntil := time.Now().Add(time.Minute)
+	task, err := testQueries.ClaimNextTask(context.Background(), ClaimNextTaskParams{LockedUntil: lockedUntil, Queue: queue})
+	require.NoError(t, err)
+	require.Equal(t, high.ID, task.ID)
+	require.Equal(t, "running", task.Status)
+	require.Equal(t, int32(1), task.Attempts)
+	require.WithinDuration(t, lockedUntil, task.LockedUntil.Time, time.Second)
+
+	task, err = testQueries.ClaimNextTask(context.Background(), ClaimNextTaskParams{LockedUntil: lockedUntil, Queue: queue})
+	require.NoError(t, err)
+	require.Equal(t, low.ID, task.ID)
+
+	_, err = testQueries.ClaimNextTask(context.Background(), ClaimNextTaskParams{LockedUntil: lockedUntil, Queue: queue})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	rows, err := testQueries.CompleteTask(context.Background(), CompleteTaskParams{ID: high.ID, Attempts: 1})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+	rows, err = testQueries.RetryTask(context.Background(), RetryTaskParams{
+		ID:        low.ID,
+		Attempts:  1,
+		RunAt:     time.Now().Add(time.Hour),
+		LastError: sql.NullString{String: "boom", Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	// 已經不是 running 的 task 不會再被改
+	rows, err = testQueries.FailTask(context.Background(), FailTaskParams{ID: high.ID, Attempts: 1})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	// 延後執行的 task 還沒到時間，不會被拿到
+	_, err = testQueries.ClaimNextTask(context.Background(), ClaimNextTaskParams{LockedUntil: lockedUntil, Queue: queue})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	task, err = testQueries.GetTask(context.Background(), high.ID)
+	require.NoError(t, err)
+	require.Equal(t, "succeeded", task.Status)
+	require.False(t, task.LockedUntil.Valid)
+}
+
+func TestRequeueExpiredTasks(t *testing.T) {
+	queue := "test-" + util.RandomString(10, false)
+	created := createRandomTask(t, queue, 0)
+
+	_, err := testQueries.ClaimNextTask(context.Background(), ClaimNextTaskParams{
+		LockedUntil: time.Now().Add(-time.Second),
+		Queue:       queue,
+	})
+	require.NoError(t, err)
+
+	n, err := testQueries.RequeueExpiredTasks(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, n, int64(1))
+
+	task, err := testQueries.ClaimNextTask(context.Background(), ClaimNextTaskParams{
+		LockedUntil: time.Now().Add(time.Minute),
+		Queue:       queue,
+	})
+	require.NoError(t, err)
+	require.Equal(t, created.ID, task.ID)
+	require.Equal(t, int32(2), task.Attempts)
+}
+
+func TestCompleteTaskTx(t *testing.T) {
+	store := NewStore(testDB)
+	queue := "test-" + util.RandomString(10, false)
+	created := createRandomTask(t, queue, 0)
+
+	claimed, err := store.ClaimNextTask(context.Background(), ClaimNextTaskParams{LockedUntil: time.Now().Add(time.Minute), Queue: queue})
+	require.NoError(t, err)
+	require.Equal(t, created.ID, claimed.ID)
+
+	next := CreateTaskParams{
+		Queue:       queue,
+		Type:        "test:periodic",
+		Payload:     json.RawMessage(`{}`),
+		MaxAttempts: 3,
+		RunAt:       time.Now().Add(time.Hour),
+		UniqueKey:   sql.NullString{String: "test:periodic:" + util.RandomString(10, false), Valid: true},
+	}
+	afterComplete := func(q *Queries) error {
+		_, err := q.CreateTask(context.Background(), next)
+		return err
+	}
+
+	// 已經不是這次 claim 的 attempts：不算完成，也不排下一個
+	rows, err := store.CompleteTaskTx(context.Background(), CompleteTaskTxParams{ID: claimed.ID, Attempts: claimed.Attempts + 1, AfterComplete: afterComplete})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = store.CompleteTaskTx(context.Background(), CompleteTaskTxParams{ID: claimed.ID, Attempts: claimed.Attempts, AfterComplete: afterComplete})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	task, err := store.GetTask(context.Background(), claimed.ID)
+	require.NoError(t, err)
+	require.Equal(t, "succeeded", task.Status)
+
+	// 同一個 unique_key 不會再排一次
+	_, err = store.CreateTask(context.Background(), next)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestTransferTxAfterTransfer(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	queue := "test-" + util.RandomString(10, false)
+
+	var task Task
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		AfterTransfer: func(q *Queries, result TransferTxResult) error {
+			payload, err := json.Marshal(result.Transfer)
+			if err != nil {
+				return err
+			}
+
+			task, err = q.CreateTask(context.Background(), CreateTaskParams{
+				Queue:       queue,
+				Type:        "test:transfer",
+				Payload:     payload,
+				MaxAttempts: 3,
+				RunAt:       time.Now(),
+			})
+			return err
+		},
+	})
+	require.NoError(t, err)
+
+	task, err = store.GetTask(context.Background(), task.ID)
+	require.NoError(t, err)
+
+	var transfer Transfer
+	require.NoError(t, json.Unmarshal(task.Payload, &transfer))
+	require.Equal(t, result.Transfer.ID, transfer.ID)
+}
+
+func TestTransferTxAfterTransferRollback(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	queue := "test-" + util.RandomString(10, false)
+	errAfterTransfer := errors.New("cannot enqueue")
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		AfterTransfer: func(q *Queries, result TransferTxResult) error {
+			_, err := q.CreateTask(context.Background(), CreateTaskParams{
+				Queue:       queue,
+				Type:        "test:transfer",
+				Payload:     json.RawMessage(`{}`),
+				MaxAttempts: 3,
+				RunAt:       time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+			return errAfterTransfer
+		},
+	})
+	require.ErrorIs(t, err, errAfterTransfer)
+
+	// 轉帳和 task 都被回滾
+	_, err = store.ClaimNextTask(context.Background(), ClaimNextTaskParams{LockedUntil: time.Now(), Queue: queue})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
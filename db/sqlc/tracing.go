@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name of the spans started by a Store
+const TracerName = "github.com/andyrestart9/bank/db/sqlc"
+
+// WithTracing starts OpenTelemetry spans from provider for the transactions and queries of the Store, as children of the span in their ctx.
+// A transaction span covers BEGIN, the queries and COMMIT or ROLLBACK, each in a child span.
+// Query spans carry the sqlc query name and the statement but never the parameter values;
+// an Exec span also carries the number of affected rows and a Query span the number of returned rows:
+// the Query span reads the whole result before it ends, the rows are then handed back from memory.
+func WithTracing(provider trace.TracerProvider) StoreOption {
+	return func(store *Store) {
+		store.tracer = provider.Tracer(TracerName)
+	}
+}
+
+// affectedRowsKey 記 Exec 改到幾筆，semconv 只有查詢回傳的 db.response.returned_rows；
+// txOutcomeKey 記事務是 commit 還是 rollback
+const (
+	affectedRowsKey = attribute.Key("db.response.affected_rows")
+	txOutcomeKey    = attribute.Key("db.transaction.outcome")
+)
+
+// startSpan 在沒有設定 WithTracing 時回傳 ctx 和不做事的 span
+func (store *Store) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if store.tracer == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	return store.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// traceStep 把 BEGIN、COMMIT、ROLLBACK 各包成一個 span
+func (store *Store) traceStep(ctx context.Context, name string, step func(ctx context.Context) error) error {
+	ctx, span := store.startSpan(ctx, name, semconv.DBSystemNamePostgreSQL, semconv.DBOperationName(name))
+	defer span.End()
+	err := step(ctx)
+	endSpan(span, err)
+	return err
+}
+
+// instrument 把 WithMetrics 和 WithTracing 的 DBTX 包在 db 外面；parent 不是 nil 的話查詢的 span 都掛在它下面
+func (store *Store) instrument(db DBTX, parent trace.Span) DBTX {
+	db = store.metrics.instrument(db)
+	if store.tracer == nil {
+		return db
+	}
+	return &tracingDBTX{DBTX: db, tracer: store.tracer, parent: parent}
+}
+
+// tracingDBTX 是每個查詢開一個 span 的 DBTX
+type tracingDBTX struct {
+	DBTX
+	tracer trace.Tracer
+	// parent 是事務的 span：fn 裡用的是呼叫端的 ctx，查詢要改掛到事務下面
+	parent trace.Span
+}
+
+func (db *tracingDBTX) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	if db.parent != nil {
+		ctx = trace.ContextWithSpan(ctx, db.parent)
+	}
+	name := queryName(query)
+	if name == "" {
+		name = "query"
+	}
+	return db.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		semconv.DBSystemNamePostgreSQL,
+		semconv.DBOperationName(name),
+		semconv.DBQueryText(query),
+	))
+}
+
+func (db *tracingDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := db.start(ctx, query)
+	defer span.End()
+	result, err := db.DBTX.ExecContext(ctx, query, args...)
+	if err == nil {
+		if rows, rowsErr := result.RowsAffected(); rowsErr == nil {
+			span.SetAttributes(affectedRowsKey.Int64(rows))
+		}
+	}
+	endSpan(span, err)
+	return result, err
+}
+
+func (db *tracingDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	buffered := db.query(ctx, query, args...)
+	return buffered.replay(ctx)
+}
+
+func (db *tracingDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	// sql.ErrNoRows 要到 Scan 才會出現，span 記的是回傳 0 筆，不算錯誤
+	buffered := db.query(ctx, query, args...)
+	return buffered.replayRow(ctx)
+}
+
+// query 在 span 裡把結果讀完，span 記下回傳的筆數再結束，Query span 才涵蓋讀取結果的時間
+func (db *tracingDBTX) query(ctx context.Context, query string, args ...interface{}) *bufferedRows {
+	spanCtx, span := db.start(ctx, query)
+	defer span.End()
+
+	rows, err := db.DBTX.QueryContext(spanCtx, query, args...)
+	if err != nil {
+		endSpan(span, err)
+		return &bufferedRows{err: err}
+	}
+	buffered := readRows(rows)
+	span.SetAttributes(semconv.DBResponseReturnedRows(len(buffered.values)))
+	endSpan(span, buffered.err)
+	return buffered
+}
+
+// endSpan 把錯誤和 SQLSTATE 記到 span 上，span 由呼叫端 End
+func endSpan(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	if code := sqlState(err); code != "" {
+		span.SetAttributes(semconv.DBResponseStatusCode(code))
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
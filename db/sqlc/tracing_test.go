@@ -0,0 +1,214 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+)
+
+// execDBTX 的 ExecContext 回傳 result 和 err
+type execDBTX struct {
+	recordingDBTX
+	result sql.Result
+	err    error
+}
+
+func (db *execDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.result, db.err
+}
+
+// queryDBTX 的 QueryContext 回傳 rows 裡的資料，或查詢的錯誤 err
+type queryDBTX struct {
+	recordingDBTX
+	rows *bufferedRows
+	err  error
+}
+
+func (db *queryDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if db.err != nil {
+		return nil, db.err
+	}
+	return db.rows.replay(ctx)
+}
+
+func newTestTracing(t *testing.T) (*tracetest.InMemoryExporter, *sdktrace.TracerProvider) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	return exporter, provider
+}
+
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, attr := range span.Attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracingDBTX(t *testing.T) {
+	exporter, provider := newTestTracing(t)
+	store := NewStore(nil, WithTracing(provider))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	db := store.instrument(&execDBTX{result: driver.RowsAffected(3)}, nil)
+	_, err := db.ExecContext(ctx, addAccountBalance, int64(100), int64(7))
+	require.NoError(t, err)
+	db = store.instrument(&execDBTX{err: &pq.Error{Code: "23503", Message: "violates foreign key constraint"}}, nil)
+	_, err = db.ExecContext(ctx, createTransfer, int64(1), int64(2), int64(100))
+	require.Error(t, err)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	ok := spans[0]
+	require.Equal(t, "AddAccountBalance", ok.Name)
+	require.Equal(t, parent.SpanContext().SpanID(), ok.Parent.SpanID())
+	text, found := spanAttribute(ok, semconv.DBQueryTextKey)
+	require.True(t, found)
+	require.Equal(t, addAccountBalance, text.AsString())
+	rows, found := spanAttribute(ok, affectedRowsKey)
+	require.True(t, found)
+	require.Equal(t, int64(3), rows.AsInt64())
+	// 參數值不能出現在 span 上
+	for _, attr := range ok.Attributes {
+		require.NotContains(t, attr.Value.Emit(), "100")
+	}
+
+	failed := spans[1]
+	require.Equal(t, "CreateTransfer", failed.Name)
+	require.Equal(t, codes.Error, failed.Status.Code)
+	code, found := spanAttribute(failed, semconv.DBResponseStatusCodeKey)
+	require.True(t, found)
+	require.Equal(t, "23503", code.AsString())
+	require.Len(t, failed.Events, 1)
+}
+
+func TestTracingDBTXQuery(t *testing.T) {
+	exporter, provider := newTestTracing(t)
+	store := NewStore(nil, WithTracing(provider))
+	ctx := context.Background()
+
+	db := store.instrument(&queryDBTX{rows: &bufferedRows{
+		columns: []string{"id", "owner"},
+		values:  [][]driver.Value{{int64(1), "alice"}, {int64(2), "bob"}},
+	}}, nil)
+	rows, err := db.QueryContext(ctx, listAccounts)
+	require.NoError(t, err)
+	var owners []string
+	for rows.Next() {
+		var id int64
+		var owner string
+		require.NoError(t, rows.Scan(&id, &owner))
+		owners = append(owners, owner)
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+	require.Equal(t, []string{"alice", "bob"}, owners)
+
+	// QueryRow 查不到資料：Scan 還是 sql.ErrNoRows，span 記 0 筆、不算錯誤
+	db = store.instrument(&queryDBTX{rows: &bufferedRows{columns: []string{"id"}}}, nil)
+	var id int64
+	require.ErrorIs(t, db.QueryRowContext(ctx, getAccount, int64(1)).Scan(&id), sql.ErrNoRows)
+
+	queryErr := &pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"}
+	db = store.instrument(&queryDBTX{err: queryErr}, nil)
+	_, err = db.QueryContext(ctx, listAccounts)
+	require.ErrorIs(t, err, queryErr)
+	require.ErrorIs(t, db.QueryRowContext(ctx, getAccount, int64(1)).Err(), queryErr)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 4)
+
+	returned, found := spanAttribute(spans[0], semconv.DBResponseReturnedRowsKey)
+	require.True(t, found)
+	require.Equal(t, int64(2), returned.AsInt64())
+
+	returned, found = spanAttribute(spans[1], semconv.DBResponseReturnedRowsKey)
+	require.True(t, found)
+	require.Zero(t, returned.AsInt64())
+	require.NotEqual(t, codes.Error, spans[1].Status.Code)
+
+	for _, failed := range spans[2:] {
+		require.Equal(t, codes.Error, failed.Status.Code)
+		_, found = spanAttribute(failed, semconv.DBResponseReturnedRowsKey)
+		require.False(t, found)
+	}
+}
+
+func TestTracingDBTXParent(t *testing.T) {
+	exporter, provider := newTestTracing(t)
+	store := NewStore(nil, WithTracing(provider))
+
+	// 事務的 DBTX 不管 ctx 裡是哪個 span，查詢都掛在事務下面
+	_, txSpan := provider.Tracer("test").Start(context.Background(), "tx")
+	db := store.instrument(&execDBTX{result: driver.RowsAffected(1)}, txSpan)
+	_, err := db.ExecContext(context.Background(), createTransfer)
+	require.NoError(t, err)
+	txSpan.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	require.Equal(t, txSpan.SpanContext().TraceID(), spans[0].SpanContext.TraceID())
+	require.Equal(t, txSpan.SpanContext().SpanID(), spans[0].Parent.SpanID())
+
+	// 沒有 WithTracing 就不包
+	recording := &recordingDBTX{}
+	require.Same(t, recording, NewStore(nil).instrument(recording, nil))
+}
+
+func TestStoreWithTracing(t *testing.T) {
+	exporter, provider := newTestTracing(t)
+	store := NewStore(testDB, WithTracing(provider))
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	ctx, request := provider.Tracer("test").Start(context.Background(), "request")
+	_, err := store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 1})
+	require.NoError(t, err)
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: account1.Balance})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	request.End()
+
+	spans := exporter.GetSpans()
+	var txs []tracetest.SpanStub
+	children := map[string][]string{}
+	for _, span := range spans {
+		if span.Name == "TransferTx" {
+			txs = append(txs, span)
+		}
+		children[span.Parent.SpanID().String()] = append(children[span.Parent.SpanID().String()], span.Name)
+	}
+	require.Len(t, txs, 2)
+
+	for i, tx := range txs {
+		require.Equal(t, request.SpanContext().SpanID(), tx.Parent.SpanID())
+		steps := children[tx.SpanContext.SpanID().String()]
+		require.Equal(t, "BEGIN", steps[0])
+		require.Contains(t, steps, "GetAccount")
+		require.Contains(t, steps, "AddAccountBalance")
+
+		outcome, found := spanAttribute(tx, txOutcomeKey)
+		require.True(t, found)
+		if i == 0 {
+			require.Equal(t, "COMMIT", steps[len(steps)-1])
+			require.Contains(t, steps, "CreateAuditLog")
+			require.Equal(t, OutcomeCommit, outcome.AsString())
+		} else {
+			require.Equal(t, "ROLLBACK", steps[len(steps)-1])
+			require.Equal(t, OutcomeRollback, outcome.AsString())
+			require.Equal(t, codes.Error, tx.Status.Code)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: transfer_limit.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const deleteTransferLimit = `-- name: DeleteTransferLimit :exec
+DELETE FROM transfer_limits
+WHERE id = $1
+`
+
+func (q *Queries) DeleteTransferLimit(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.deleteTransferLimitStmt, deleteTransferLimit, id)
+	return err
+}
+
+const getAccountTransferLimit = `-- name: GetAccountTransferLimit :one
+SELECT id, account_id, owner, currency, max_single, max_daily, max_monthly, max_hourly_count, created_at, updated_at FROM transfer_limits
+WHERE account_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccountTransferLimit(ctx context.Context, accountID sql.NullInt64) (TransferLimit, error) {
+	row := q.queryRow(ctx, q.getAccountTransferLimitStmt, getAccountTransferLimit, accountID)
+	var i TransferLimit
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Owner,
+		&i.Currency,
+		&i.MaxSingle,
+		&i.MaxDaily,
+		&i.MaxMonthly,
+		&i.MaxHourlyCount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getAccountTransferUsage = `-- name: GetAccountTransferUsage :one
+SELECT
+  COALESCE(sum(transfers.amount) FILTER (WHERE transfers.created_at >= date_trunc('day', now(), 'UTC')), 0)::bigint AS day_total,
+  COALESCE(sum(transfers.amount) FILTER (WHERE transfers.created_at >= date_trunc('month', now(), 'UTC')), 0)::bigint AS month_total,
+  count(*) FILTER (WHERE transfers.created_at > now() - interval '1 hour') AS hour_count
+FROM transfers
+JOIN accounts AS to_account ON to_account.id = transfers.to_account_id
+WHERE transfers.from_account_id = $1
+  AND transfers.created_at >= LEAST(date_trunc('month', now(), 'UTC'), now() - interval '1 hour')
+  AND to_account.owner NOT LIKE 'system:%'
+`
+
+type GetAccountTransferUsageRow struct {
+	DayTotal   int64 `json:"day_total"`
+	MonthTotal int64 `json:"month_total"`
+	HourCount  int64 `json:"hour_count"`
+}
+
+// 轉出到系統帳戶的（例如透支收費）不是客戶發起的，不算進限額
+func (q *Queries) GetAccountTransferUsage(ctx context.Context, fromAccountID int64) (GetAccountTransferUsageRow, error) {
+	row := q.queryRow(ctx, q.getAccountTransferUsageStmt, getAccountTransferUsage, fromAccountID)
+	var i GetAccountTransferUsageRow
+	err := row.Scan(&i.DayTotal, &i.MonthTotal, &i.HourCount)
+	return i, err
+}
+
+const getOwnerTransferLimit = `-- name: GetOwnerTransferLimit :one
+SELECT id, account_id, owner, currency, max_single, max_daily, max_monthly, max_hourly_count, created_at, updated_at FROM transfer_limits
+WHERE owner = $1 AND currency = $2 LIMIT 1
+`
+
+type GetOwnerTransferLimitParams struct {
+	Owner    sql.NullString `json:"owner"`
+	Currency sql.NullString `json:"currency"`
+}
+
+func (q *Queries) GetOwnerTransferLimit(ctx context.Context, arg GetOwnerTransferLimitParams) (TransferLimit, error) {
+	row := q.queryRow(ctx, q.getOwnerTransferLimitStmt, getOwnerTransferLimit, arg.Owner, arg.Currency)
+	var i TransferLimit
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Owner,
+		&i.Currency,
+		&i.MaxSingle,
+		&i.MaxDaily,
+		&i.MaxMonthly,
+		&i.MaxHourlyCount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getOwnerTransferUsage = `-- name: GetOwnerTransferUsage :one
+SELECT
+  COALESCE(sum(transfers.amount) FILTER (WHERE transfers.created_at >= date_trunc('day', now(), 'UTC')), 0)::bigint AS day_total,
+  COALESCE(sum(transfers.amount) FILTER (WHERE transfers.created_at >= date_trunc('month', now(), 'UTC')), 0)::bigint AS month_total,
+  count(*) FILTER (WHERE transfers.created_at > now() - interval '1 hour') AS hour_count
+FROM transfers
+JOIN accounts AS from_account ON from_account.id = transfers.from_account_id
+JOIN accounts AS to_account ON to_account.id = transfers.to_account_id
+WHERE from_account.owner = $1 AND from_account.currency = $2
+  AND transfers.created_at >= LEAST(date_trunc('month', now(), 'UTC'), now() - interval '1 hour')
+  AND to_account.owner NOT LIKE 'system:%'
+`
+
+type GetOwnerTransferUsageParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+type GetOwnerTransferUsageRow struct {
+	DayTotal   int64 `json:"day_total"`
+	MonthTotal int64 `json:"month_total"`
+	HourCount  int64 `json:"hour_count"`
+}
+
+func (q *Queries) GetOwnerTransferUsage(ctx context.Context, arg GetOwnerTransferUsageParams) (GetOwnerTransferUsageRow, error) {
+	row := q.queryRow(ctx, q.getOwnerTransferUsageStmt, getOwnerTransferUsage, arg.Owner, arg.Currency)
+	var i GetOwnerTransferUsageRow
+	err := row.Scan(&i.DayTotal, &i.MonthTotal, &i.HourCount)
+	return i, err
+}
+
+const lockTransferLimit = `-- name: LockTransferLimit :exec
+SELECT pg_advisory_xact_lock(hashtext('transfer_limits'), hashtext($1::text))
+`
+
+// 同一個限額的檢查和轉帳排隊，彙總和寫入之間不會有別的轉帳插進來；Commit / Rollback 時自動釋放
+func (q *Queries) LockTransferLimit(ctx context.Context, lockKey string) error {
+	_, err := q.exec(ctx, q.lockTransferLimitStmt, lockTransferLimit, lockKey)
+	return err
+}
+
+const setAccountTransferLimit = `-- name: SetAccountTransferLimit :one
+INSERT INTO transfer_limits (
+  account_id, max_single, max_daily, max_monthly, max_hourly_count
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (account_id) DO UPDATE SET
+  max_single = EXCLUDED.max_single,
+  max_daily = EXCLUDED.max_daily,
+  max_monthly = EXCLUDED.max_monthly,
+  max_hourly_count = EXCLUDED.max_hourly_count,
+  updated_at = now()
+RETURNING id, account_id, owner, currency, max_single, max_daily, max_monthly, max_hourly_count, created_at, updated_at
+`
+
+type SetAccountTransferLimitParams struct {
+	AccountID      sql.NullInt64 `json:"account_id"`
+	MaxSingle      sql.NullInt64 `json:"max_single"`
+	MaxDaily       sql.NullInt64 `json:"max_daily"`
+	MaxMonthly     sql.NullInt64 `json:"max_monthly"`
+	MaxHourlyCount sql.NullInt32 `json:"max_hourly_count"`
+}
+
+func (q *Queries) SetAccountTransferLimit(ctx context.Context, arg SetAccountTransferLimitParams) (TransferLimit, error) {
+	row := q.queryRow(ctx, q.setAccountTransferLimitStmt, setAccountTransferLimit,
+		arg.AccountID,
+		arg.MaxSingle,
+		arg.MaxDaily,
+		arg.MaxMonthly,
+		arg.MaxHourlyCount,
+	)
+	var i TransferLimit
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Owner,
+		&i.Currency,
+		&i.MaxSingle,
+		&i.MaxDaily,
+		&i.MaxMonthly,
+		&i.MaxHourlyCount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const setOwnerTransferLimit = `-- name: SetOwnerTransferLimit :one
+INSERT INTO transfer_limits (
+  owner, currency, max_single, max_daily, max_monthly, max_hourly_count
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (owner, currency) DO UPDATE SET
+  max_single = EXCLUDED.max_single,
+  max_daily = EXCLUDED.max_daily,
+  max_monthly = EXCLUDED.max_monthly,
+  max_hourly_count = EXCLUDED.max_hourly_count,
+  updated_at = now()
+RETURNING id, account_id, owner, currency, max_single, max_daily, max_monthly, max_hourly_count, created_at, updated_at
+`
+
+type SetOwnerTransferLimitParams struct {
+	Owner          sql.NullString `json:"owner"`
+	Currency       sql.NullString `json:"currency"`
+	MaxSingle      sql.NullInt64  `json:"max_single"`
+	MaxDaily       sql.NullInt64  `json:"max_daily"`
+	MaxMonthly     sql.NullInt64  `json:"max_monthly"`
+	MaxHourlyCount sql.NullInt32  `json:"max_hourly_count"`
+}
+
+func (q *Queries) SetOwnerTransferLimit(ctx context.Context, arg SetOwnerTransferLimitParams) (TransferLimit, error) {
+	row := q.queryRow(ctx, q.setOwnerTransferLimitStmt, setOwnerTransferLimit,
+		arg.Owner,
+		arg.Currency,
+		arg.MaxSingle,
+		arg.MaxDaily,
+		arg.MaxMonthly,
+		arg.MaxHourlyCount,
+	)
+	var i TransferLimit
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Owner,
+		&i.Currency,
+		&i.MaxSingle,
+		&i.MaxDaily,
+		&i.MaxMonthly,
+		&i.MaxHourlyCount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrAccountHasLedger is returned by DeleteAccount for an account with entries, transfers, external transfers or adjustments;
+// such an account is closed with CloseAccountTx instead
+var ErrAccountHasLedger = errors.New("account has ledger rows")
+
+// Store 上的 CreateAccount / DeleteAccount 和 *Queries 的同名方法簽名完全相同，呼叫端不用改；
+// Store 只內嵌 StoreQuerier，沒有 audit log 的 q.CreateAccount 只能在事務裡用。差別是這裡會開一個事務，
+// 把帳戶的變更、對應的 outbox 事件和 audit log 寫在同一個事務裡。
+// 餘額不能直接改，要改用 AdjustBalanceTx 記帳。
+
+// CreateAccount creates a new account, records an account.created event and an audit log row within a single database transaction.
+// A non-zero arg.Balance is booked as a settled opening balance deposit from the clearing account of the currency.
+// With WithOwnerScreening, the owner is screened first and the decision is stored;
+// a blocked owner fails with ErrOwnerBlocked and no account is created.
+func (store *Store) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	if store.ownerScreener != nil {
+		return store.createScreenedAccount(ctx, arg)
+	}
+	return store.createAccount(ctx, arg, nil)
+}
+
+// createAccount 是 CreateAccount 的事務本體，afterCreate 在帳戶建好、audit log 之前執行
+func (store *Store) createAccount(ctx context.Context, arg CreateAccountParams, afterCreate func(q *Queries, account Account) error) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		openingBalance := arg.Balance
+		arg.Balance = 0
+		account, err = q.CreateAccount(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		if openingBalance != 0 {
+			account, err = bookOpeningBalance(ctx, q, account, openingBalance)
+			if err != nil {
+				return err
+			}
+		}
+
+		if afterCreate != nil {
+			if err := afterCreate(q, account); err != nil {
+				return err
+			}
+		}
+
+		err = recordOutboxEvent(ctx, q, AggregateAccount, account.ID, EventAccountCreated, account)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, AuditActionCreate, AuditEntityAccount, strconv.FormatInt(account.ID, 10), nil, account)
+	})
+
+	return account, err
+}
+
+// DeleteAccount deletes an account, records an account.deleted event and an audit log row within a single database transaction.
+// Only accounts that never had money moved can be deleted, the others fail with ErrAccountHasLedger and are closed with CloseAccountTx.
+func (store *Store) DeleteAccount(ctx context.Context, id int64) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetAccountForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		hasLedger, err := q.AccountHasLedgerRows(ctx, id)
+		if err != nil {
+			return err
+		}
+		if hasLedger {
+			return fmt.Errorf("%w: account %d, close it instead", ErrAccountHasLedger, id)
+		}
+
+		if err := q.DeleteAccount(ctx, id); err != nil {
+			return err
+		}
+
+		err = recordOutboxEvent(ctx, q, AggregateAccount, id, EventAccountDeleted, AccountDeletedPayload{ID: id})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, AuditActionDelete, AuditEntityAccount, strconv.FormatInt(id, 10), before, nil)
+	})
+}
@@ -0,0 +1,247 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/lib/pq"
+)
+
+// AuditEntityAccountImport is the audit log entity type of ImportAccountsTx
+const AuditEntityAccountImport = "account_import"
+
+// ErrInvalidAccountImport is returned by ImportAccountsTx when a row cannot be staged
+var ErrInvalidAccountImport = errors.New("invalid account import")
+
+// errAccountImportDryRun 讓 dry run 跑完整個合併之後 Rollback，不是真的錯誤
+var errAccountImportDryRun = errors.New("account import dry run")
+
+// ImportedAccount is a validated row of an account import
+type ImportedAccount struct {
+	// Line is the line of the row in the source file
+	Line     int64  `json:"line"`
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+	// Balance is the opening balance, booked like the one of CreateAccount
+	Balance int64 `json:"balance"`
+}
+
+// AccountImportSource streams the rows of an account import, Next returns io.EOF after the last row.
+// Reject is called with the row last returned by Next when ImportAccountsTx does not import it, before Next is called again.
+type AccountImportSource interface {
+	Next() (ImportedAccount, error)
+	Reject(row ImportedAccount, reason string) error
+}
+
+// ImportAccountsTxParams contains the input parameters of the import accounts transaction
+type ImportAccountsTxParams struct {
+	// Source names the imported file, recorded on the import
+	Source string `json:"source"`
+	Rows   AccountImportSource
+	// DryRun runs the whole import and rolls it back
+	DryRun bool `json:"dry_run"`
+}
+
+// ImportAccountsTxResult is the result of the import accounts transaction
+type ImportAccountsTxResult struct {
+	Import AccountImport `json:"import"`
+	DryRun bool          `json:"dry_run"`
+}
+
+// ImportAccountsTx streams the rows with COPY into a staging table, then creates all the accounts and books
+// their opening balances against the clearing accounts within a single database transaction.
+// With WithOwnerScreening every owner is screened while the rows are streamed: the rows of blocked owners are
+// passed to Rows.Reject, and the decisions are stored like the ones of CreateAccount.
+// Only one import runs at a time. The import is recorded with one audit log row;
+// unlike CreateAccount no account.created events are written, consumers see the accounts through the import.
+// With arg.DryRun everything is rolled back, so the returned import id is never committed.
+func (store *Store) ImportAccountsTx(ctx context.Context, arg ImportAccountsTxParams) (ImportAccountsTxResult, error) {
+	result := ImportAccountsTxResult{DryRun: arg.DryRun}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.LockAccountImports(ctx)
+		if err != nil {
+			return err
+		}
+
+		result.Import, err = q.CreateAccountImport(ctx, arg.Source)
+		if err != nil {
+			return err
+		}
+
+		screenings := &importScreenings{screener: store.ownerScreener, results: make(map[string]NameScreening)}
+		accepted, blocked, err := copyImportedAccounts(ctx, q, result.Import.ID, arg.Rows, screenings)
+		if err != nil {
+			return err
+		}
+
+		blockedResults, err := recordBlockedImportedAccounts(ctx, q, blocked, screenings)
+		if err != nil {
+			return err
+		}
+
+		if accepted > 0 {
+			err = mergeImportedAccounts(ctx, q, result.Import.ID, screenings.screener != nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Import, err = q.SetAccountImportAccepted(ctx, SetAccountImportAcceptedParams{
+			ID:       result.Import.ID,
+			Accepted: accepted,
+		})
+		if err != nil {
+			return err
+		}
+
+		// audit log 一定要最後一次寫完：拿了 audit log 的鎖之後不能再鎖 clearing 帳戶這類別的 row，見 recordAudits
+		audits := make([]auditRecord, 0, len(blockedResults)+1)
+		for _, blockedResult := range blockedResults {
+			audits = append(audits, auditRecord{action: AuditActionCreate, entityType: AuditEntityScreeningResult, entityID: strconv.FormatInt(blockedResult.ID, 10), after: blockedResult})
+		}
+		audits = append(audits, auditRecord{action: AuditActionCreate, entityType: AuditEntityAccountImport, entityID: strconv.FormatInt(result.Import.ID, 10), after: result.Import})
+		err = recordAudits(ctx, q, audits...)
+		if err != nil {
+			return err
+		}
+
+		if arg.DryRun {
+			return errAccountImportDryRun
+		}
+		return nil
+	})
+	if arg.DryRun && errors.Is(err, errAccountImportDryRun) {
+		err = nil
+	}
+
+	return result, err
+}
+
+// importScreenings 記住這次匯入每個 owner 的篩查結果，同一個 owner 的多個帳戶只篩一次；screener 是 nil 就不篩
+type importScreenings struct {
+	screener NameScreener
+	results  map[string]NameScreening
+}
+
+func (screenings *importScreenings) screen(owner string) NameScreening {
+	screening, ok := screenings.results[owner]
+	if !ok {
+		screening = screenings.screener.ScreenName(owner)
+		screenings.results[owner] = screening
+	}
+	return screening
+}
+
+// copyImportedAccounts 用 COPY 把 rows 寫進 account_import_rows，回傳寫入的列數和被篩查擋下的列。
+// COPY 還沒結束之前同一個連線不能跑別的查詢，所以要先把 rows 讀完才能合併，篩查結果也先跟著暫存列寫進去。
+func copyImportedAccounts(ctx context.Context, q *Queries, importID int64, rows AccountImportSource, screenings *importScreenings) (int64, []ImportedAccount, error) {
+	stmt, err := q.db.PrepareContext(ctx, pq.CopyIn("account_import_rows", "import_id", "line", "owner", "currency", "balance",
+		"screening_outcome", "screening_normalized_name", "screening_score", "screening_entry_id", "screening_matched_name"))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer stmt.Close()
+
+	var count int64
+	var blocked []ImportedAccount
+	for {
+		row, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		if row.Balance < 0 {
+			return 0, nil, fmt.Errorf("%w: line %d has a negative balance", ErrInvalidAccountImport, row.Line)
+		}
+
+		// 沒有篩查的話篩查的欄位都是 null
+		screening := []interface{}{nil, nil, nil, nil, nil}
+		if screenings.screener != nil {
+			result := screenings.screen(row.Owner)
+			if result.Outcome == RuleBlock {
+				if err := rows.Reject(row, ownerBlockedError(row.Owner, result).Error()); err != nil {
+					return 0, nil, err
+				}
+				blocked = append(blocked, row)
+				continue
+			}
+			screening = []interface{}{string(result.Outcome), result.NormalizedName, result.Score, result.EntryID, result.MatchedName}
+		}
+
+		_, err = stmt.ExecContext(ctx, append([]interface{}{importID, row.Line, row.Owner, row.Currency, row.Balance}, screening...)...)
+		if err != nil {
+			return 0, nil, err
+		}
+		count++
+	}
+
+	// 沒有參數的 Exec 把緩衝的資料送出去並結束 COPY
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return 0, nil, err
+	}
+	return count, blocked, nil
+}
+
+// recordBlockedImportedAccounts 和 CreateAccount 被擋下時一樣，每一列記一筆沒有帳戶的篩查結果；
+// 它們的 audit log 由 ImportAccountsTx 在合併之後才寫
+func recordBlockedImportedAccounts(ctx context.Context, q *Queries, blocked []ImportedAccount, screenings *importScreenings) ([]ScreeningResult, error) {
+	results := make([]ScreeningResult, 0, len(blocked))
+	for _, row := range blocked {
+		result, err := screenOwner(ctx, q, screenings.screen(row.Owner), row.Owner, sql.NullInt64{})
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// mergeImportedAccounts 把暫存列建成帳戶、entries 和 opening:<id> 入金，更新餘額後清掉暫存列；
+// screened 的話也把暫存的篩查結果掛到新帳戶上
+func mergeImportedAccounts(ctx context.Context, q *Queries, importID int64, screened bool) error {
+	// 和 getOrCreateSystemAccount 的其他呼叫端一樣，先拿 clearing 帳戶，再動任何帳戶的 row
+	currencies, err := q.ListAccountImportCurrencies(ctx, importID)
+	if err != nil {
+		return err
+	}
+	for _, currency := range currencies {
+		if _, err := getOrCreateSystemAccount(ctx, q, SystemAccountClearing, currency); err != nil {
+			return err
+		}
+	}
+
+	if _, err := q.AllocateImportedAccountIDs(ctx, importID); err != nil {
+		return err
+	}
+	if _, err := q.InsertImportedAccounts(ctx, importID); err != nil {
+		return err
+	}
+	if _, err := q.InsertImportedEntries(ctx, importID); err != nil {
+		return err
+	}
+	if _, err := q.InsertImportedExternalTransfers(ctx, importID); err != nil {
+		return err
+	}
+
+	// 和 addMoney 一樣，entries 寫好之後才打開 ledger writes 改餘額
+	if err := q.enableLedgerWrites(ctx); err != nil {
+		return err
+	}
+	if _, err := q.ApplyImportedBalances(ctx, importID); err != nil {
+		return err
+	}
+	if screened {
+		if _, err := q.InsertImportedScreeningResults(ctx, importID); err != nil {
+			return err
+		}
+	}
+
+	return q.DeleteAccountImportRows(ctx, importID)
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceImportSource 依序回傳 rows，退回的列記在 rejected
+type sliceImportSource struct {
+	rows     []ImportedAccount
+	rejected []ImportedAccount
+}
+
+func (source *sliceImportSource) Next() (ImportedAccount, error) {
+	if len(source.rows) == 0 {
+		return ImportedAccount{}, io.EOF
+	}
+	row := source.rows[0]
+	source.rows = source.rows[1:]
+	return row, nil
+}
+
+func (source *sliceImportSource) Reject(row ImportedAccount, reason string) error {
+	source.rejected = append(source.rejected, row)
+	return nil
+}
+
+func TestImportAccountsTx(t *testing.T) {
+	store := NewStore(testDB)
+	owner := util.RandomOwner()
+	rows := []ImportedAccount{
+		{Line: 2, Owner: owner, Currency: util.USD, Balance: 1000},
+		{Line: 3, Owner: owner, Currency: util.EUR, Balance: 0},
+		{Line: 5, Owner: owner, Currency: util.USD, Balance: 250},
+	}
+
+	result, err := store.ImportAccountsTx(context.Background(), ImportAccountsTxParams{
+		Source: "accounts.csv",
+		Rows:   &sliceImportSource{rows: rows},
+	})
+	require.NoError(t, err)
+	require.False(t, result.DryRun)
+	require.Equal(t, "accounts.csv", result.Import.Source)
+	require.Equal(t, int64(3), result.Import.Accepted)
+
+	// 帳戶 id 依行號順序分配
+	accounts, err := store.ListAccountsByOwner(context.Background(), owner)
+	require.NoError(t, err)
+	require.Len(t, accounts, 3)
+	for i, account := range accounts {
+		require.Equal(t, rows[i].Currency, account.Currency)
+		require.Equal(t, rows[i].Balance, account.Balance)
+
+		transfers, err := store.ListExternalTransfers(context.Background(), ListExternalTransfersParams{AccountID: account.ID, Limit: 10})
+		require.NoError(t, err)
+		if rows[i].Balance == 0 {
+			require.Empty(t, transfers)
+			continue
+		}
+		require.Len(t, transfers, 1)
+		require.Equal(t, "opening:"+strconv.FormatInt(account.ID, 10), transfers[0].ExternalRef)
+		require.Equal(t, ExternalSettled, transfers[0].Status)
+	}
+	requireReconciled(t, store, accounts...)
+
+	clearing, err := store.GetSystemAccount(context.Background(), GetSystemAccountParams{Purpose: SystemAccountClearing, Currency: util.USD})
+	require.NoError(t, err)
+	requireReconciled(t, store, clearing)
+
+	logs, err := store.ListAuditLogsByEntity(context.Background(), ListAuditLogsByEntityParams{
+		EntityType: AuditEntityAccountImport,
+		EntityID:   strconv.FormatInt(result.Import.ID, 10),
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+
+	// 暫存列在同一個事務裡清掉
+	var staged int
+	err = testDB.QueryRow("SELECT count(*) FROM account_import_rows WHERE import_id = $1", result.Import.ID).Scan(&staged)
+	require.NoError(t, err)
+	require.Zero(t, staged)
+}
+
+func TestImportAccountsTxDryRun(t *testing.T) {
+	store := NewStore(testDB)
+	owner := util.RandomOwner()
+
+	result, err := store.ImportAccountsTx(context.Background(), ImportAccountsTxParams{
+		Source: "accounts.ndjson",
+		Rows:   &sliceImportSource{rows: []ImportedAccount{{Line: 1, Owner: owner, Currency: util.CAD, Balance: 10}}},
+		DryRun: true,
+	})
+	require.NoError(t, err)
+	require.True(t, result.DryRun)
+	require.Equal(t, int64(1), result.Import.Accepted)
+
+	accounts, err := store.ListAccountsByOwner(context.Background(), owner)
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	_, err = store.GetAccountImport(context.Background(), result.Import.ID)
+	require.Error(t, err)
+}
+
+func TestImportAccountsTxNegativeBalance(t *testing.T) {
+	store := NewStore(testDB)
+	owner := util.RandomOwner()
+
+	_, err := store.ImportAccountsTx(context.Background(), ImportAccountsTxParams{
+		Rows: &sliceImportSource{rows: []ImportedAccount{
+			{Line: 1, Owner: owner, Currency: util.USD, Balance: 10},
+			{Line: 2, Owner: owner, Currency: util.USD, Balance: -10},
+		}},
+	})
+	require.ErrorIs(t, err, ErrInvalidAccountImport)
+
+	accounts, err := store.ListAccountsByOwner(context.Background(), owner)
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+}
+
+func TestImportAccountsTxOwnerScreening(t *testing.T) {
+	blocked, flagged, clear := util.RandomOwner(), util.RandomOwner(), util.RandomOwner()
+	screener := fakeNameScreener{
+		blocked: {Outcome: RuleBlock, NormalizedName: blocked, Score: 0.98, EntryID: "SDN-1", MatchedName: blocked},
+		flagged: {Outcome: RuleHold, NormalizedName: flagged, Score: 0.9, EntryID: "SDN-2", MatchedName: flagged},
+	}
+	store := NewStore(testDB, WithOwnerScreening(screener))
+	source := &sliceImportSource{rows: []ImportedAccount{
+		{Line: 1, Owner: clear, Currency: util.USD, Balance: 10},
+		{Line: 2, Owner: blocked, Currency: util.USD, Balance: 20},
+		{Line: 3, Owner: flagged, Currency: util.EUR, Balance: 30},
+	}}
+
+	result, err := store.ImportAccountsTx(context.Background(), ImportAccountsTxParams{Source: "accounts.csv", Rows: source})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Import.Accepted)
+	require.Equal(t, []ImportedAccount{{Line: 2, Owner: blocked, Currency: util.USD, Balance: 20}}, source.rejected)
+
+	accounts, err := store.ListAccountsByOwner(context.Background(), blocked)
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	// 擋下的決定也留著
+	results, err := store.ListScreeningResults(context.Background(), ListScreeningResultsParams{Outcome: string(RuleBlock), LimitCount: 1000})
+	require.NoError(t, err)
+	var found bool
+	for _, result := range results {
+		if result.Name == blocked {
+			found = true
+			require.False(t, result.AccountID.Valid)
+		}
+	}
+	require.True(t, found)
+
+	for _, tc := range []struct {
+		owner   string
+		outcome RuleOutcome
+	}{
+		{clear, RuleAllow},
+		{flagged, RuleHold},
+	} {
+		accounts, err := store.ListAccountsByOwner(context.Background(), tc.owner)
+		require.NoError(t, err)
+		require.Len(t, accounts, 1)
+
+		results, err := store.ListScreeningResultsByAccount(context.Background(), sql.NullInt64{Int64: accounts[0].ID, Valid: true})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, string(tc.outcome), results[0].Outcome)
+		require.Equal(t, tc.owner, results[0].Name)
+	}
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Reason codes accepted by AdjustBalanceTx
+const (
+	AdjustmentReasonCorrection = "correction"
+	AdjustmentReasonFeeRefund  = "fee_refund"
+	AdjustmentReasonChargeback = "chargeback"
+	AdjustmentReasonWriteOff   = "write_off"
+	AdjustmentReasonGoodwill   = "goodwill"
+)
+
+var adjustmentReasons = map[string]bool{
+	AdjustmentReasonCorrection: true,
+	AdjustmentReasonFeeRefund:  true,
+	AdjustmentReasonChargeback: true,
+	AdjustmentReasonWriteOff:   true,
+	AdjustmentReasonGoodwill:   true,
+}
+
+// AuditActionAdjustBalance is the audit log action of AdjustBalanceTx
+const AuditActionAdjustBalance = "adjust_balance"
+
+// ErrInvalidAdjustment is returned by AdjustBalanceTx when the parameters are rejected before touching the database
+var ErrInvalidAdjustment = errors.New("invalid balance adjustment")
+
+// AdjustBalanceTxParams contains the input parameters of the adjust balance transaction
+type AdjustBalanceTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// Balance is the balance the account should have after the adjustment
+	Balance int64 `json:"balance"`
+	// ReasonCode is one of the AdjustmentReason constants
+	ReasonCode string `json:"reason_code"`
+	// Operator is the person doing the adjustment, recorded as the audit log actor
+	Operator string `json:"operator"`
+	Note     string `json:"note"`
+}
+
+// AdjustBalanceTxResult is the result of the adjust balance transaction
+type AdjustBalanceTxResult struct {
+	Adjustment      BalanceAdjustment `json:"adjustment"`
+	Account         Account           `json:"account"`
+	SuspenseAccount Account           `json:"suspense_account"`
+	Entry           Entry             `json:"entry"`
+	SuspenseEntry   Entry             `json:"suspense_entry"`
+}
+
+// AdjustBalanceTx sets the balance of an account by posting the difference as balanced entries
+// against the suspense account of its currency, then records the adjustment, an account.updated event
+// and an audit log row within a single database transaction.
+// It replaces UpdateAccount, which changed the balance without any entries.
+func (store *Store) AdjustBalanceTx(ctx context.Context, arg AdjustBalanceTxParams) (AdjustBalanceTxResult, error) {
+	var result AdjustBalanceTxResult
+
+	if !adjustmentReasons[arg.ReasonCode] {
+		return result, fmt.Errorf("%w: unknown reason code %q", ErrInvalidAdjustment, arg.ReasonCode)
+	}
+	if arg.Operator == "" {
+		return result, fmt.Errorf("%w: operator is required", ErrInvalidAdjustment)
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// 不鎖 row 先讀幣別，suspense 帳戶要在鎖 row 之前拿到，見 getOrCreateSystemAccount
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		suspense, err := getOrCreateSystemAccount(ctx, q, SystemAccountSuspense, account.Currency)
+		if err != nil {
+			return err
+		}
+		if suspense.ID == account.ID {
+			return fmt.Errorf("%w: cannot adjust the suspense account itself", ErrInvalidAdjustment)
+		}
+
+		before, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		amount := arg.Balance - before.Balance
+		if amount == 0 {
+			return fmt.Errorf("%w: balance is already %d", ErrInvalidAdjustment, arg.Balance)
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID: account.ID,
+			Amount:    amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.SuspenseEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID: suspense.ID,
+			Amount:    -amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		// 和 TransferTx 一樣依 id 順序更新，避免 deadlock
+		if account.ID < suspense.ID {
+			result.Account, result.SuspenseAccount, err = addMoney(ctx, q, account.ID, amount, suspense.ID, -amount)
+		} else {
+			result.SuspenseAccount, result.Account, err = addMoney(ctx, q, suspense.ID, -amount, account.ID, amount)
+		}
+		if err != nil {
+			return err
+		}
+
+		result.Adjustment, err = q.CreateBalanceAdjustment(ctx, CreateBalanceAdjustmentParams{
+			AccountID:         account.ID,
+			SuspenseAccountID: suspense.ID,
+			Amount:            amount,
+			ReasonCode:        arg.ReasonCode,
+			Operator:          arg.Operator,
+			Note:              arg.Note,
+			EntryID:           result.Entry.ID,
+			SuspenseEntryID:   result.SuspenseEntry.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = recordOutboxEvent(ctx, q, AggregateAccount, account.ID, EventAccountUpdated, result.Account)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(WithActor(ctx, arg.Operator), q, AuditActionAdjustBalance, AuditEntityAccount, strconv.FormatInt(account.ID, 10), before, result)
+	})
+
+	return result, err
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdjustBalanceTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	result, err := store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{
+		AccountID:  account.ID,
+		Balance:    account.Balance - 7,
+		ReasonCode: AdjustmentReasonFeeRefund,
+		Operator:   "ops-alice",
+		Note:       "ticket 42",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account.Balance-7, result.Account.Balance)
+	require.Equal(t, int64(-7), result.Entry.Amount)
+	require.Equal(t, account.ID, result.Entry.AccountID)
+	require.Equal(t, int64(7), result.SuspenseEntry.Amount)
+	require.Equal(t, result.SuspenseAccount.ID, result.SuspenseEntry.AccountID)
+	require.Equal(t, account.Currency, result.SuspenseAccount.Currency)
+	require.Equal(t, systemAccountOwner(SystemAccountSuspense), result.SuspenseAccount.Owner)
+
+	require.Equal(t, int64(-7), result.Adjustment.Amount)
+	require.Equal(t, AdjustmentReasonFeeRefund, result.Adjustment.ReasonCode)
+	require.Equal(t, "ops-alice", result.Adjustment.Operator)
+	require.Equal(t, result.Entry.ID, result.Adjustment.EntryID)
+	require.Equal(t, result.SuspenseEntry.ID, result.Adjustment.SuspenseEntryID)
+
+	// 同一個幣別共用同一個 suspense 帳戶
+	account2 := createRandomAccount(t)
+	for account2.Currency != account.Currency {
+		account2 = createRandomAccount(t)
+	}
+	result2, err := store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{
+		AccountID:  account2.ID,
+		Balance:    account2.Balance + 3,
+		ReasonCode: AdjustmentReasonGoodwill,
+		Operator:   "ops-alice",
+	})
+	require.NoError(t, err)
+	require.Equal(t, result.SuspenseAccount.ID, result2.SuspenseAccount.ID)
+	require.Equal(t, int64(-3), result2.SuspenseEntry.Amount)
+
+	adjustments, err := store.ListBalanceAdjustments(context.Background(), ListBalanceAdjustmentsParams{
+		AccountID: account.ID,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, adjustments, 1)
+	require.Equal(t, result.Adjustment, adjustments[0])
+}
+
+func TestAdjustBalanceTxInvalid(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	testCases := []struct {
+		name string
+		arg  AdjustBalanceTxParams
+	}{
+		{
+			name: "NoReason",
+			arg:  AdjustBalanceTxParams{AccountID: account.ID, Balance: account.Balance + 1, Operator: "ops"},
+		},
+		{
+			name: "UnknownReason",
+			arg:  AdjustBalanceTxParams{AccountID: account.ID, Balance: account.Balance + 1, ReasonCode: "because", Operator: "ops"},
+		},
+		{
+			name: "NoOperator",
+			arg:  AdjustBalanceTxParams{AccountID: account.ID, Balance: account.Balance + 1, ReasonCode: AdjustmentReasonCorrection},
+		},
+		{
+			name: "Unchanged",
+			arg:  AdjustBalanceTxParams{AccountID: account.ID, Balance: account.Balance, ReasonCode: AdjustmentReasonCorrection, Operator: "ops"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := store.AdjustBalanceTx(context.Background(), tc.arg)
+			require.ErrorIs(t, err, ErrInvalidAdjustment)
+		})
+	}
+
+	_, err := store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{
+		AccountID:  -1,
+		Balance:    1,
+		ReasonCode: AdjustmentReasonCorrection,
+		Operator:   "ops",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	account2, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, account2.Balance)
+}
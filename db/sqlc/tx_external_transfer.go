@@ -0,0 +1,372 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Directions of external transfers
+const (
+	ExternalDeposit    = "deposit"
+	ExternalWithdrawal = "withdrawal"
+)
+
+// Statuses of external transfers
+const (
+	ExternalPending = "pending"
+	ExternalSettled = "settled"
+	ExternalFailed  = "failed"
+)
+
+// Audit log actions of the external transfer transactions
+const (
+	AuditActionSettle = "settle"
+	AuditActionFail   = "fail"
+)
+
+var (
+	// ErrInvalidExternalTransfer is returned when the parameters are rejected before touching the database
+	ErrInvalidExternalTransfer = errors.New("invalid external transfer")
+	// ErrExternalRefConflict is returned when the external reference is already used by a different transfer
+	ErrExternalRefConflict = errors.New("external reference already used by a different transfer")
+	// ErrExternalTransferNotPending is returned when settling or failing a transfer that is already settled or failed
+	ErrExternalTransferNotPending = errors.New("external transfer is not pending")
+)
+
+// ExternalTransferTxParams contains the input parameters of the deposit and withdraw transactions
+type ExternalTransferTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// Amount must be positive
+	Amount int64 `json:"amount"`
+	// ExternalRef identifies the transfer on the payment rail.
+	// Calling again with the same reference returns the existing transfer instead of creating another one.
+	ExternalRef string `json:"external_ref"`
+}
+
+// ExternalTransferTxResult is the result of the external transfer transactions.
+// Entry and ClearingEntry are the entries posted by the call, they are empty when nothing was posted.
+type ExternalTransferTxResult struct {
+	ExternalTransfer ExternalTransfer `json:"external_transfer"`
+	Account          Account          `json:"account"`
+	ClearingAccount  Account          `json:"clearing_account"`
+	Entry            Entry            `json:"entry"`
+	ClearingEntry    Entry            `json:"clearing_entry"`
+}
+
+// DepositTx records a pending deposit from the payment rail into an account.
+// Nothing is posted until SettleExternalTransferTx, the money has not arrived yet.
+// With an OwnerScreeningRule in WithTransferRules the owner of the account is screened and the decision is stored;
+// a blocked owner gets the deposit recorded as failed and ErrOwnerBlocked, so the rail sends the money back.
+func (store *Store) DepositTx(ctx context.Context, arg ExternalTransferTxParams) (ExternalTransferTxResult, error) {
+	return store.createExternalTransferTx(ctx, ExternalDeposit, arg)
+}
+
+// WithdrawTx records a pending withdrawal from an account to the payment rail.
+// The money is taken from the account right away so that it cannot be spent twice;
+// FailExternalTransferTx gives it back. It fails with ErrInsufficientFunds when the account would go below its overdraft limit.
+func (store *Store) WithdrawTx(ctx context.Context, arg ExternalTransferTxParams) (ExternalTransferTxResult, error) {
+	return store.createExternalTransferTx(ctx, ExternalWithdrawal, arg)
+}
+
+func (store *Store) createExternalTransferTx(ctx context.Context, direction string, arg ExternalTransferTxParams) (ExternalTransferTxResult, error) {
+	var result ExternalTransferTxResult
+
+	if arg.Amount <= 0 {
+		return result, fmt.Errorf("%w: amount must be positive", ErrInvalidExternalTransfer)
+	}
+	if arg.ExternalRef == "" {
+		return result, fmt.Errorf("%w: external reference is required", ErrInvalidExternalTransfer)
+	}
+
+	var blocked error
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		if err := CheckOpen(account); err != nil {
+			return err
+		}
+
+		// clearing 帳戶要在鎖任何 row 之前拿到，見 getOrCreateSystemAccount
+		clearing, err := getOrCreateSystemAccount(ctx, q, SystemAccountClearing, account.Currency)
+		if err != nil {
+			return err
+		}
+
+		result.ExternalTransfer, err = q.CreateExternalTransfer(ctx, CreateExternalTransferParams{
+			AccountID:         account.ID,
+			ClearingAccountID: clearing.ID,
+			Direction:         direction,
+			Amount:            arg.Amount,
+			ExternalRef:       arg.ExternalRef,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// ON CONFLICT DO NOTHING：同一個 reference 重送，回傳已經存在的那筆
+			return loadExternalTransfer(ctx, q, direction, arg, &result)
+		}
+		if err != nil {
+			return err
+		}
+
+		result.Account, result.ClearingAccount = account, clearing
+		if direction == ExternalDeposit && len(store.rules) > 0 {
+			screening, err := store.screenDeposit(ctx, q, account)
+			if err != nil {
+				return err
+			}
+			if screening.Outcome == RuleBlock {
+				// 記成失敗而不是 Rollback：同一個 reference 重送時拿到的是這筆失敗的入金，篩查結果也留著
+				blocked = fmt.Errorf("%w: %s", ErrOwnerBlocked, screening.Reason)
+				result.ExternalTransfer, err = q.FailExternalTransfer(ctx, FailExternalTransferParams{
+					ID:            result.ExternalTransfer.ID,
+					FailureReason: blocked.Error(),
+				})
+				if err != nil {
+					return err
+				}
+				err = recordOutboxEvent(ctx, q, AggregateExternalTransfer, result.ExternalTransfer.ID, EventExternalTransferFailed, result)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if direction == ExternalWithdrawal {
+			if err := bookExternalTransfer(ctx, q, &result); err != nil {
+				return err
+			}
+			if err := CheckFunds(result.Account, arg.Amount); err != nil {
+				return err
+			}
+		}
+
+		return recordAudit(ctx, q, AuditActionCreate, AuditEntityExternalTransfer, strconv.FormatInt(result.ExternalTransfer.ID, 10), nil, result)
+	})
+	if err == nil && blocked != nil {
+		return result, blocked
+	}
+
+	return result, err
+}
+
+// loadExternalTransfer fills result with the transfer already recorded under arg.ExternalRef
+func loadExternalTransfer(ctx context.Context, q *Queries, direction string, arg ExternalTransferTxParams, result *ExternalTransferTxResult) error {
+	transfer, err := q.GetExternalTransferByRef(ctx, GetExternalTransferByRefParams{
+		Direction:   direction,
+		ExternalRef: arg.ExternalRef,
+	})
+	if err != nil {
+		return err
+	}
+	if transfer.AccountID != arg.AccountID || transfer.Amount != arg.Amount {
+		return fmt.Errorf("%w: %s %s", ErrExternalRefConflict, direction, arg.ExternalRef)
+	}
+
+	result.ExternalTransfer = transfer
+	result.Account, err = q.GetAccount(ctx, transfer.AccountID)
+	if err != nil {
+		return err
+	}
+	result.ClearingAccount, err = q.GetAccount(ctx, transfer.ClearingAccountID)
+	return err
+}
+
+// SettleExternalTransferTx marks a pending external transfer as settled by the payment rail
+// and records an external_transfer.settled outbox event. Deposits are posted to the account at this point.
+func (store *Store) SettleExternalTransferTx(ctx context.Context, id int64) (ExternalTransferTxResult, error) {
+	var result ExternalTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := lockPendingExternalTransfer(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		result.ExternalTransfer = before
+
+		if before.Direction == ExternalDeposit {
+			if err := bookExternalTransfer(ctx, q, &result); err != nil {
+				return err
+			}
+		} else {
+			result.Account, err = q.GetAccount(ctx, before.AccountID)
+			if err != nil {
+				return err
+			}
+			result.ClearingAccount, err = q.GetAccount(ctx, before.ClearingAccountID)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.ExternalTransfer, err = q.SettleExternalTransfer(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		err = recordOutboxEvent(ctx, q, AggregateExternalTransfer, id, EventExternalTransferSettled, result)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, AuditActionSettle, AuditEntityExternalTransfer, strconv.FormatInt(id, 10), before, result)
+	})
+
+	return result, err
+}
+
+// FailExternalTransferTx marks a pending external transfer as failed on the payment rail
+// and records an external_transfer.failed outbox event. Withdrawals give the money back to the account with reversal entries.
+func (store *Store) FailExternalTransferTx(ctx context.Context, id int64, reason string) (ExternalTransferTxResult, error) {
+	var result ExternalTransferTxResult
+
+	if reason == "" {
+		return result, fmt.Errorf("%w: failure reason is required", ErrInvalidExternalTransfer)
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := lockPendingExternalTransfer(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		arg := FailExternalTransferParams{
+			ID:            id,
+			FailureReason: reason,
+		}
+		if before.Direction == ExternalWithdrawal {
+			// 出金建立時已經扣款，退回去
+			result.Entry, result.ClearingEntry, result.Account, result.ClearingAccount, err = postExternalEntries(ctx, q, before.AccountID, before.ClearingAccountID, before.Amount)
+			if err != nil {
+				return err
+			}
+			arg.ReversalEntryID = sql.NullInt64{Int64: result.Entry.ID, Valid: true}
+			arg.ClearingReversalEntryID = sql.NullInt64{Int64: result.ClearingEntry.ID, Valid: true}
+		} else {
+			result.Account, err = q.GetAccount(ctx, before.AccountID)
+			if err != nil {
+				return err
+			}
+			result.ClearingAccount, err = q.GetAccount(ctx, before.ClearingAccountID)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.ExternalTransfer, err = q.FailExternalTransfer(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		err = recordOutboxEvent(ctx, q, AggregateExternalTransfer, id, EventExternalTransferFailed, result)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, AuditActionFail, AuditEntityExternalTransfer, strconv.FormatInt(id, 10), before, result)
+	})
+
+	return result, err
+}
+
+func lockPendingExternalTransfer(ctx context.Context, q *Queries, id int64) (ExternalTransfer, error) {
+	transfer, err := q.GetExternalTransferForUpdate(ctx, id)
+	if err != nil {
+		return transfer, err
+	}
+	if transfer.Status != ExternalPending {
+		return transfer, fmt.Errorf("%w: external transfer %d is %s", ErrExternalTransferNotPending, id, transfer.Status)
+	}
+	return transfer, nil
+}
+
+// bookExternalTransfer posts the entries of result.ExternalTransfer:
+// deposits move money from the clearing account to the account, withdrawals the other way
+func bookExternalTransfer(ctx context.Context, q *Queries, result *ExternalTransferTxResult) error {
+	transfer := result.ExternalTransfer
+	amount := transfer.Amount
+	if transfer.Direction == ExternalWithdrawal {
+		amount = -amount
+	}
+
+	var err error
+	result.Entry, result.ClearingEntry, result.Account, result.ClearingAccount, err = postExternalEntries(ctx, q, transfer.AccountID, transfer.ClearingAccountID, amount)
+	if err != nil {
+		return err
+	}
+
+	result.ExternalTransfer, err = q.SetExternalTransferEntries(ctx, SetExternalTransferEntriesParams{
+		ID:              transfer.ID,
+		EntryID:         sql.NullInt64{Int64: result.Entry.ID, Valid: true},
+		ClearingEntryID: sql.NullInt64{Int64: result.ClearingEntry.ID, Valid: true},
+	})
+	return err
+}
+
+// postExternalEntries adds amount to the account and takes it from the clearing account
+func postExternalEntries(
+	ctx context.Context,
+	q *Queries,
+	accountID int64,
+	clearingAccountID int64,
+	amount int64,
+) (entry Entry, clearingEntry Entry, account Account, clearing Account, err error) {
+	entry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: accountID,
+		Amount:    amount,
+	})
+	if err != nil {
+		return
+	}
+
+	clearingEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: clearingAccountID,
+		Amount:    -amount,
+	})
+	if err != nil {
+		return
+	}
+
+	// 和 TransferTx 一樣依 id 順序更新，避免 deadlock
+	if accountID < clearingAccountID {
+		account, clearing, err = addMoney(ctx, q, accountID, amount, clearingAccountID, -amount)
+	} else {
+		clearing, account, err = addMoney(ctx, q, clearingAccountID, -amount, accountID, amount)
+	}
+	return
+}
+
+// bookOpeningBalance 把新帳戶的期初餘額記成一筆已入帳的 opening:<id> 入金（負數記成出金），
+// 餘額就和 entries 對得起來
+func bookOpeningBalance(ctx context.Context, q *Queries, account Account, balance int64) (Account, error) {
+	clearing, err := getOrCreateSystemAccount(ctx, q, SystemAccountClearing, account.Currency)
+	if err != nil {
+		return account, err
+	}
+
+	direction, amount := ExternalDeposit, balance
+	if balance < 0 {
+		direction, amount = ExternalWithdrawal, -balance
+	}
+
+	result := ExternalTransferTxResult{}
+	result.ExternalTransfer, err = q.CreateExternalTransfer(ctx, CreateExternalTransferParams{
+		AccountID:         account.ID,
+		ClearingAccountID: clearing.ID,
+		Direction:         direction,
+		Amount:            amount,
+		ExternalRef:       "opening:" + strconv.FormatInt(account.ID, 10),
+	})
+	if err != nil {
+		return account, err
+	}
+
+	if err := bookExternalTransfer(ctx, q, &result); err != nil {
+		return account, err
+	}
+
+	_, err = q.SettleExternalTransfer(ctx, result.ExternalTransfer.ID)
+	return result.Account, err
+}
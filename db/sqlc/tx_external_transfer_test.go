@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// requireReconciled checks that the balances of accounts match their entries;
+// other tests insert entries without touching balances, so the whole report is not checked
+func requireReconciled(t *testing.T, store *Store, accounts ...Account) {
+	report, err := store.Reconcile(context.Background())
+	require.NoError(t, err)
+
+	for _, mismatch := range report.Mismatches {
+		for _, account := range accounts {
+			require.NotEqual(t, account.ID, mismatch.ID, "balance of account %d does not match its entries", account.ID)
+		}
+	}
+}
+
+func TestCreateAccountOpeningBalance(t *testing.T) {
+	store := NewStore(testDB)
+
+	for _, balance := range []int64{100, -50, 0} {
+		account, err := store.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    util.RandomOwner(),
+			Balance:  balance,
+			Currency: util.RandomCurrency(),
+		})
+		require.NoError(t, err)
+		require.Equal(t, balance, account.Balance)
+
+		entries, err := store.ListEntries(context.Background(), ListEntriesParams{AccountID: account.ID, Limit: 10})
+		require.NoError(t, err)
+
+		transfers, err := store.ListExternalTransfers(context.Background(), ListExternalTransfersParams{AccountID: account.ID, Limit: 10})
+		require.NoError(t, err)
+
+		if balance == 0 {
+			require.Empty(t, entries)
+			require.Empty(t, transfers)
+			continue
+		}
+
+		require.Len(t, entries, 1)
+		require.Equal(t, balance, entries[0].Amount)
+
+		require.Len(t, transfers, 1)
+		require.Equal(t, "opening:"+strconv.FormatInt(account.ID, 10), transfers[0].ExternalRef)
+		require.Equal(t, ExternalSettled, transfers[0].Status)
+		require.Equal(t, entries[0].ID, transfers[0].EntryID.Int64)
+
+		requireReconciled(t, store, account)
+	}
+}
+
+func TestDepositTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	arg := ExternalTransferTxParams{
+		AccountID:   account.ID,
+		Amount:      25,
+		ExternalRef: "dep-" + util.RandomString(12, false),
+	}
+
+	result, err := store.DepositTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, ExternalPending, result.ExternalTransfer.Status)
+	require.Equal(t, ExternalDeposit, result.ExternalTransfer.Direction)
+	require.False(t, result.ExternalTransfer.EntryID.Valid)
+	require.Equal(t, account.Balance, result.Account.Balance)
+	require.Equal(t, account.Currency, result.ClearingAccount.Currency)
+
+	// 同一個 reference 重送拿到同一筆
+	replay, err := store.DepositTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, result.ExternalTransfer.ID, replay.ExternalTransfer.ID)
+
+	arg.Amount = 26
+	_, err = store.DepositTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrExternalRefConflict)
+
+	settled, err := store.SettleExternalTransferTx(context.Background(), result.ExternalTransfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, ExternalSettled, settled.ExternalTransfer.Status)
+	require.Equal(t, account.Balance+25, settled.Account.Balance)
+	require.Equal(t, int64(25), settled.Entry.Amount)
+	require.Equal(t, int64(-25), settled.ClearingEntry.Amount)
+	require.Equal(t, settled.Entry.ID, settled.ExternalTransfer.EntryID.Int64)
+
+	events, err := store.ListOutboxEventsByAggregate(context.Background(), ListOutboxEventsByAggregateParams{
+		AggregateType: AggregateExternalTransfer,
+		AggregateID:   result.ExternalTransfer.ID,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, EventExternalTransferSettled, events[0].EventType)
+
+	_, err = store.SettleExternalTransferTx(context.Background(), result.ExternalTransfer.ID)
+	require.ErrorIs(t, err, ErrExternalTransferNotPending)
+	_, err = store.FailExternalTransferTx(context.Background(), result.ExternalTransfer.ID, "returned")
+	require.ErrorIs(t, err, ErrExternalTransferNotPending)
+
+	requireReconciled(t, store, settled.Account, settled.ClearingAccount)
+}
+
+func TestDepositTxFailed(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	result, err := store.DepositTx(context.Background(), ExternalTransferTxParams{
+		AccountID:   account.ID,
+		Amount:      25,
+		ExternalRef: "dep-" + util.RandomString(12, false),
+	})
+	require.NoError(t, err)
+
+	failed, err := store.FailExternalTransferTx(context.Background(), result.ExternalTransfer.ID, "insufficient funds at origin")
+	require.NoError(t, err)
+	require.Equal(t, ExternalFailed, failed.ExternalTransfer.Status)
+	require.Equal(t, "insufficient funds at origin", failed.ExternalTransfer.FailureReason)
+	require.False(t, failed.ExternalTransfer.ReversalEntryID.Valid)
+	require.Equal(t, account.Balance, failed.Account.Balance)
+}
+
+func TestWithdrawTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	result, err := store.WithdrawTx(context.Background(), ExternalTransferTxParams{
+		AccountID:   account.ID,
+		Amount:      10,
+		ExternalRef: "wd-" + util.RandomString(12, false),
+	})
+	require.NoError(t, err)
+	require.Equal(t, ExternalPending, result.ExternalTransfer.Status)
+	// 出金建立時就扣款
+	require.Equal(t, account.Balance-10, result.Account.Balance)
+	require.Equal(t, int64(-10), result.Entry.Amount)
+	require.Equal(t, int64(10), result.ClearingEntry.Amount)
+
+	settled, err := store.SettleExternalTransferTx(context.Background(), result.ExternalTransfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, ExternalSettled, settled.ExternalTransfer.Status)
+	require.Equal(t, account.Balance-10, settled.Account.Balance)
+
+	requireReconciled(t, store, settled.Account, settled.ClearingAccount)
+}
+
+func TestWithdrawTxFailed(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	result, err := store.WithdrawTx(context.Background(), ExternalTransferTxParams{
+		AccountID:   account.ID,
+		Amount:      10,
+		ExternalRef: "wd-" + util.RandomString(12, false),
+	})
+	require.NoError(t, err)
+
+	failed, err := store.FailExternalTransferTx(context.Background(), result.ExternalTransfer.ID, "beneficiary account closed")
+	require.NoError(t, err)
+	require.Equal(t, ExternalFailed, failed.ExternalTransfer.Status)
+	require.Equal(t, account.Balance, failed.Account.Balance)
+	require.Equal(t, int64(10), failed.Entry.Amount)
+	require.Equal(t, failed.Entry.ID, failed.ExternalTransfer.ReversalEntryID.Int64)
+	require.Equal(t, failed.ClearingEntry.ID, failed.ExternalTransfer.ClearingReversalEntryID.Int64)
+
+	events, err := store.ListOutboxEventsByAggregate(context.Background(), ListOutboxEventsByAggregateParams{
+		AggregateType: AggregateExternalTransfer,
+		AggregateID:   result.ExternalTransfer.ID,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, EventExternalTransferFailed, events[0].EventType)
+
+	requireReconciled(t, store, failed.Account, failed.ClearingAccount)
+}
+
+func TestExternalTransferTxInvalid(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	_, err := store.DepositTx(context.Background(), ExternalTransferTxParams{AccountID: account.ID, Amount: 0, ExternalRef: "x"})
+	require.ErrorIs(t, err, ErrInvalidExternalTransfer)
+
+	_, err = store.WithdrawTx(context.Background(), ExternalTransferTxParams{AccountID: account.ID, Amount: 10})
+	require.ErrorIs(t, err, ErrInvalidExternalTransfer)
+
+	_, err = store.FailExternalTransferTx(context.Background(), 1, "")
+	require.ErrorIs(t, err, ErrInvalidExternalTransfer)
+}
@@ -0,0 +1,245 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Audit log actions of the interest transactions
+const (
+	AuditActionSetProduct   = "set_product"
+	AuditActionPostInterest = "post_interest"
+)
+
+var (
+	// ErrNoAccountProduct is returned when accruing interest on an account without a product
+	ErrNoAccountProduct = errors.New("account has no product")
+	// ErrInterestPostingOutOfOrder is returned when posting a period that ends before the last posting of the account
+	ErrInterestPostingOutOfOrder = errors.New("interest posting out of order")
+)
+
+// SetAccountProduct attaches an account product to an account and records an audit log row within a single database transaction
+func (store *Store) SetAccountProduct(ctx context.Context, arg SetAccountProductParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetAccountForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		account, err = q.SetAccountProduct(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, AuditActionSetProduct, AuditEntityAccount, strconv.FormatInt(account.ID, 10), before, account)
+	})
+
+	return account, err
+}
+
+// AccrueInterestTxParams contains the input parameters of the accrue interest transaction
+type AccrueInterestTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// Date is the day to accrue, it ends at the next midnight in the location of Date
+	Date time.Time `json:"date"`
+}
+
+// AccrueInterestTxResult is the result of the accrue interest transaction
+type AccrueInterestTxResult struct {
+	Accrual InterestAccrual `json:"accrual"`
+	// Created is false when the day was already accrued and Accrual is the existing row
+	Created bool `json:"created"`
+}
+
+// AccrueInterestTx records the interest of one day on the end of day balance of an account, derived from its entries.
+// The interest is kept in units of 1/InterestUnitsPerMinor of the minor unit so that nothing is rounded until posting.
+// Accruing the same day again returns the existing accrual; a day in a detached ledger partition fails with ErrBalanceArchived.
+func (store *Store) AccrueInterestTx(ctx context.Context, arg AccrueInterestTxParams) (AccrueInterestTxResult, error) {
+	var result AccrueInterestTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		if !account.ProductID.Valid {
+			return fmt.Errorf("%w: account %d", ErrNoAccountProduct, account.ID)
+		}
+
+		product, err := q.GetAccountProduct(ctx, account.ProductID.Int64)
+		if err != nil {
+			return err
+		}
+
+		y, m, d := arg.Date.Date()
+		endOfDay := time.Date(y, m, d+1, 0, 0, 0, 0, arg.Date.Location())
+		// 用 entries 算日終餘額，不管 job 是哪天跑的，同一天算出來都一樣
+		balance, err := balanceAt(ctx, q, GetBalanceAtParams{
+			AccountID: account.ID,
+			At:        endOfDay,
+		})
+		if err != nil {
+			return err
+		}
+
+		days, err := accrualDays(product.DayCount, arg.Date)
+		if err != nil {
+			return err
+		}
+		units, err := interestUnits(balance, product.AnnualRateBps, product.DayCount, days)
+		if err != nil {
+			return err
+		}
+
+		result.Accrual, err = q.CreateInterestAccrual(ctx, CreateInterestAccrualParams{
+			AccountID:     account.ID,
+			ProductID:     product.ID,
+			AccrualDate:   civilDate(arg.Date),
+			Balance:       balance,
+			AnnualRateBps: product.AnnualRateBps,
+			DayCount:      product.DayCount,
+			Days:          days,
+			Units:         units,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// ON CONFLICT DO NOTHING：這天已經算過了
+			result.Accrual, err = q.GetInterestAccrual(ctx, GetInterestAccrualParams{
+				AccountID:   account.ID,
+				AccrualDate: civilDate(arg.Date),
+			})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		result.Created = true
+		return nil
+	})
+
+	return result, err
+}
+
+// PostInterestTxParams contains the input parameters of the post interest transaction
+type PostInterestTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// PeriodEnd is the last day of the period, every unposted accrual up to it is posted
+	PeriodEnd time.Time `json:"period_end"`
+}
+
+// PostInterestTxResult is the result of the post interest transaction
+type PostInterestTxResult struct {
+	Posting InterestPosting `json:"posting"`
+	// Transfer is empty when the interest of the period is below one minor unit
+	Transfer TransferTxResult `json:"transfer"`
+	// Created is false when the period was already posted and Posting is the existing row
+	Created bool `json:"created"`
+}
+
+// PostInterestTx posts the unposted accruals of an account up to PeriodEnd, plus the remainder carried from the previous posting,
+// as a transfer from the interest expense account of its currency.
+// Only whole minor units are transferred; the rest is carried to the next posting.
+// Posting the same period again returns the existing posting.
+func (store *Store) PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error) {
+	var result PostInterestTxResult
+	periodEnd := civilDate(arg.PeriodEnd)
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		// 系統帳戶要在鎖任何 row 之前拿到，見 getOrCreateSystemAccount
+		expense, err := getOrCreateSystemAccount(ctx, q, SystemAccountInterestExpense, account.Currency)
+		if err != nil {
+			return err
+		}
+
+		// 鎖住帳戶，同一個帳戶的入息排隊，上一期的 carry 才不會被兩個事務同時用掉
+		if _, err := q.GetAccountForUpdate(ctx, account.ID); err != nil {
+			return err
+		}
+
+		result.Posting, err = q.GetInterestPosting(ctx, GetInterestPostingParams{AccountID: account.ID, PeriodEnd: periodEnd})
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		var carry int64
+		last, err := q.GetLastInterestPosting(ctx, account.ID)
+		switch {
+		case err == nil:
+			if last.PeriodEnd.After(periodEnd) {
+				return fmt.Errorf("%w: account %d is posted up to %s", ErrInterestPostingOutOfOrder, account.ID, last.PeriodEnd.Format(time.DateOnly))
+			}
+			carry = last.CarryUnits
+		case errors.Is(err, sql.ErrNoRows):
+		default:
+			return err
+		}
+
+		accrued, err := q.SumUnpostedInterestAccruals(ctx, SumUnpostedInterestAccrualsParams{
+			AccountID: account.ID,
+			PeriodEnd: periodEnd,
+		})
+		if err != nil {
+			return err
+		}
+
+		units := carry + accrued.Units
+		result.Posting, err = q.CreateInterestPosting(ctx, CreateInterestPostingParams{
+			AccountID:  account.ID,
+			PeriodEnd:  periodEnd,
+			Units:      units,
+			Amount:     units / InterestUnitsPerMinor,
+			CarryUnits: units % InterestUnitsPerMinor,
+		})
+		if err != nil {
+			return err
+		}
+		result.Created = true
+
+		_, err = q.MarkInterestAccrualsPosted(ctx, MarkInterestAccrualsPostedParams{
+			PostingID: sql.NullInt64{Int64: result.Posting.ID, Valid: true},
+			AccountID: account.ID,
+			PeriodEnd: periodEnd,
+		})
+		if err != nil {
+			return err
+		}
+
+		if result.Posting.Amount > 0 {
+			result.Transfer, err = postTransfer(ctx, q, expense.ID, account.ID, result.Posting.Amount)
+			if err != nil {
+				return err
+			}
+
+			result.Posting, err = q.SetInterestPostingTransfer(ctx, SetInterestPostingTransferParams{
+				ID:         result.Posting.ID,
+				TransferID: sql.NullInt64{Int64: result.Transfer.Transfer.ID, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+
+			err = recordOutboxEvent(ctx, q, AggregateTransfer, result.Transfer.Transfer.ID, EventTransferCreated, result.Transfer)
+			if err != nil {
+				return err
+			}
+		}
+
+		return recordAudit(ctx, q, AuditActionPostInterest, AuditEntityAccount, strconv.FormatInt(account.ID, 10), nil, result)
+	})
+
+	return result, err
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAccountProduct(t *testing.T, annualRateBps int32, dayCount string) AccountProduct {
+	product, err := testQueries.CreateAccountProduct(context.Background(), CreateAccountProductParams{
+		Name:             "savings-" + util.RandomString(10, false),
+		AnnualRateBps:    annualRateBps,
+		DayCount:         dayCount,
+		PostingFrequency: PostingMonthly,
+	})
+	require.NoError(t, err)
+	return product
+}
+
+func TestAccrueAndPostInterestTx(t *testing.T) {
+	store := NewStore(testDB)
+	product := createRandomAccountProduct(t, 10000, DayCountACT365)
+
+	account, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    util.RandomOwner(),
+		Balance:  365000,
+		Currency: util.RandomCurrency(),
+	})
+	require.NoError(t, err)
+
+	account, err = store.SetAccountProduct(context.Background(), SetAccountProductParams{
+		ID:        account.ID,
+		ProductID: sql.NullInt64{Int64: product.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, product.ID, account.ProductID.Int64)
+
+	// 期初餘額是現在記的，今天的日終餘額才看得到
+	today := time.Now().UTC()
+
+	accrued, err := store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{AccountID: account.ID, Date: today})
+	require.NoError(t, err)
+	require.True(t, accrued.Created)
+	require.Equal(t, int64(365000), accrued.Accrual.Balance)
+	// 365,000 at 100% ACT/365：一天 1,000
+	require.Equal(t, int64(1000)*InterestUnitsPerMinor, accrued.Accrual.Units)
+
+	// 同一天重跑不會多算
+	again, err := store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{AccountID: account.ID, Date: today})
+	require.NoError(t, err)
+	require.False(t, again.Created)
+	require.Equal(t, accrued.Accrual.ID, again.Accrual.ID)
+
+	posted, err := store.PostInterestTx(context.Background(), PostInterestTxParams{AccountID: account.ID, PeriodEnd: today})
+	require.NoError(t, err)
+	require.True(t, posted.Created)
+	require.Equal(t, int64(1000), posted.Posting.Amount)
+	require.Zero(t, posted.Posting.CarryUnits)
+	require.Equal(t, posted.Transfer.Transfer.ID, posted.Posting.TransferID.Int64)
+	require.Equal(t, account.ID, posted.Transfer.ToAccount.ID)
+	require.Equal(t, systemAccountOwner(SystemAccountInterestExpense), posted.Transfer.FromAccount.Owner)
+	require.Equal(t, account.Balance+1000, posted.Transfer.ToAccount.Balance)
+
+	// 同一期重跑不會再轉一次
+	repost, err := store.PostInterestTx(context.Background(), PostInterestTxParams{AccountID: account.ID, PeriodEnd: today})
+	require.NoError(t, err)
+	require.False(t, repost.Created)
+	require.Equal(t, posted.Posting.ID, repost.Posting.ID)
+
+	account, err = store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(366000), account.Balance)
+
+	_, err = store.PostInterestTx(context.Background(), PostInterestTxParams{AccountID: account.ID, PeriodEnd: today.AddDate(0, 0, -1)})
+	require.ErrorIs(t, err, ErrInterestPostingOutOfOrder)
+
+	requireReconciled(t, store, account, posted.Transfer.FromAccount)
+}
+
+func TestPostInterestTxCarry(t *testing.T) {
+	store := NewStore(testDB)
+	// 1.00 at 1% ACT/365：一天只有 1/365 分錢，入息金額是 0，全部留到下一期
+	product := createRandomAccountProduct(t, 100, DayCountACT365)
+
+	account, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    util.RandomOwner(),
+		Balance:  100,
+		Currency: util.RandomCurrency(),
+	})
+	require.NoError(t, err)
+	_, err = store.SetAccountProduct(context.Background(), SetAccountProductParams{ID: account.ID, ProductID: sql.NullInt64{Int64: product.ID, Valid: true}})
+	require.NoError(t, err)
+
+	today := time.Now().UTC()
+	accrued, err := store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{AccountID: account.ID, Date: today})
+	require.NoError(t, err)
+
+	posted, err := store.PostInterestTx(context.Background(), PostInterestTxParams{AccountID: account.ID, PeriodEnd: today})
+	require.NoError(t, err)
+	require.Zero(t, posted.Posting.Amount)
+	require.Equal(t, accrued.Accrual.Units, posted.Posting.CarryUnits)
+	require.False(t, posted.Posting.TransferID.Valid)
+	require.Empty(t, posted.Transfer)
+
+	// 下一期從 carry 開始算
+	next, err := store.PostInterestTx(context.Background(), PostInterestTxParams{AccountID: account.ID, PeriodEnd: today.AddDate(0, 0, 1)})
+	require.NoError(t, err)
+	require.Equal(t, accrued.Accrual.Units, next.Posting.Units)
+}
+
+func TestAccrueInterestTxNoProduct(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	_, err := store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{AccountID: account.ID, Date: time.Now()})
+	require.ErrorIs(t, err, ErrNoAccountProduct)
+}
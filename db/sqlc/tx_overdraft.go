@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Audit log actions of the overdraft transactions
+const (
+	AuditActionSetOverdraft    = "set_overdraft"
+	AuditActionChargeOverdraft = "charge_overdraft"
+)
+
+var (
+	// ErrInsufficientFunds is returned when a customer debit would take the balance below the overdraft limit of the account
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrOverdraftChargeOutOfOrder is returned when charging a period that ends before the last charge of the account
+	ErrOverdraftChargeOutOfOrder = errors.New("overdraft charge out of order")
+)
+
+// CheckFunds returns ErrInsufficientFunds when account, already debited by amount, is below its overdraft limit.
+// It is only for the debits a customer makes, like TransferTx and WithdrawTx.
+func CheckFunds(account Account, amount int64) error {
+	// 扣款之後才檢查，UPDATE 已經鎖住帳戶的 row，檢查和扣款之間不會被別的事務插隊；
+	// 調帳、透支收費這些由銀行發起的入帳不受額度限制，不呼叫這裡
+	if account.Balance < -account.OverdraftLimit {
+		return fmt.Errorf("%w: account %d cannot pay %d, available %d", ErrInsufficientFunds, account.ID, amount, account.Balance+amount+account.OverdraftLimit)
+	}
+	return nil
+}
+
+// SetAccountOverdraft sets the overdraft limit, interest rate and fee of an account and records an audit log row within a single database transaction.
+// Lowering the limit below the current usage is allowed, it only blocks further debits.
+func (store *Store) SetAccountOverdraft(ctx context.Context, arg SetAccountOverdraftParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetAccountForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		account, err = q.SetAccountOverdraft(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, AuditActionSetOverdraft, AuditEntityAccount, strconv.FormatInt(account.ID, 10), before, account)
+	})
+
+	return account, err
+}
+
+// ChargeOverdraftTxParams contains the input parameters of the charge overdraft transaction
+type ChargeOverdraftTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// PeriodEnd is the last day of the period, days start and end at midnight in the location of PeriodEnd.
+	// The period starts the day after the last charge of the account, or on the day the account was created.
+	PeriodEnd time.Time `json:"period_end"`
+}
+
+// ChargeOverdraftTxResult is the result of the charge overdraft transaction
+type ChargeOverdraftTxResult struct {
+	Charge OverdraftCharge `json:"charge"`
+	// Transfer is empty when nothing is charged for the period
+	Transfer TransferTxResult `json:"transfer"`
+	// Created is false when the period was already charged and Charge is the existing row
+	Created bool `json:"created"`
+}
+
+// ChargeOverdraftTx charges an account the interest on its overdrawn end of day balances of the period,
+// plus its overdraft fee when at least one day was overdrawn, as a transfer to the overdraft income account of its currency.
+// The interest is counted like AccrueInterestTx counts it, ACT/365, and only whole minor units are charged;
+// the rest is carried to the next charge. Charging the same period again returns the existing charge;
+// a period reaching into a detached ledger partition fails with ErrBalanceArchived.
+func (store *Store) ChargeOverdraftTx(ctx context.Context, arg ChargeOverdraftTxParams) (ChargeOverdraftTxResult, error) {
+	var result ChargeOverdraftTxResult
+	periodEnd := civilDate(arg.PeriodEnd)
+	location := arg.PeriodEnd.Location()
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		// 系統帳戶要在鎖任何 row 之前拿到，見 getOrCreateSystemAccount
+		income, err := getOrCreateSystemAccount(ctx, q, SystemAccountOverdraftIncome, account.Currency)
+		if err != nil {
+			return err
+		}
+
+		// 鎖住帳戶，同一個帳戶的收費排隊，上一期的 carry 才不會被兩個事務同時用掉
+		account, err = q.GetAccountForUpdate(ctx, account.ID)
+		if err != nil {
+			return err
+		}
+
+		result.Charge, err = q.GetOverdraftCharge(ctx, GetOverdraftChargeParams{AccountID: account.ID, PeriodEnd: periodEnd})
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		y, m, d := account.CreatedAt.In(location).Date()
+		var carry int64
+		last, err := q.GetLastOverdraftCharge(ctx, account.ID)
+		switch {
+		case err == nil:
+			if last.PeriodEnd.After(periodEnd) {
+				return fmt.Errorf("%w: account %d is charged up to %s", ErrOverdraftChargeOutOfOrder, account.ID, last.PeriodEnd.Format(time.DateOnly))
+			}
+			carry = last.CarryUnits
+			y, m, d = last.PeriodEnd.Date()
+			d++
+		case errors.Is(err, sql.ErrNoRows):
+		default:
+			return err
+		}
+
+		periodStart := civilDate(time.Date(y, m, d, 0, 0, 0, 0, location))
+		if periodStart.After(periodEnd) {
+			// 帳戶在這一期結束之後才開，這一期沒有日子要算
+			periodStart = periodEnd
+		}
+
+		var overdrawnDays int32
+		units := carry
+		for day := periodStart; !day.After(periodEnd); day = day.AddDate(0, 0, 1) {
+			// 和 AccrueInterestTx 一樣用 entries 算日終餘額
+			balance, err := balanceAt(ctx, q, GetBalanceAtParams{
+				AccountID: account.ID,
+				At:        time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, location),
+			})
+			if err != nil {
+				return err
+			}
+			if balance >= 0 {
+				continue
+			}
+
+			overdrawnDays++
+			dayUnits, err := interestUnits(-balance, account.OverdraftRateBps, DayCountACT365, 1)
+			if err != nil {
+				return err
+			}
+			units += dayUnits
+		}
+
+		var fee int64
+		if overdrawnDays > 0 {
+			fee = account.OverdraftFee
+		}
+
+		result.Charge, err = q.CreateOverdraftCharge(ctx, CreateOverdraftChargeParams{
+			AccountID:     account.ID,
+			PeriodStart:   periodStart,
+			PeriodEnd:     periodEnd,
+			OverdrawnDays: overdrawnDays,
+			RateBps:       account.OverdraftRateBps,
+			Units:         units,
+			Fee:           fee,
+			Amount:        units/InterestUnitsPerMinor + fee,
+			CarryUnits:    units % InterestUnitsPerMinor,
+		})
+		if err != nil {
+			return err
+		}
+		result.Created = true
+
+		if result.Charge.Amount > 0 {
+			// 收費不檢查透支額度，可以把帳戶扣到額度以下
+			result.Transfer, err = postTransfer(ctx, q, account.ID, income.ID, result.Charge.Amount)
+			if err != nil {
+				return err
+			}
+
+			result.Charge, err = q.SetOverdraftChargeTransfer(ctx, SetOverdraftChargeTransferParams{
+				ID:         result.Charge.ID,
+				TransferID: sql.NullInt64{Int64: result.Transfer.Transfer.ID, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+
+			err = recordOutboxEvent(ctx, q, AggregateTransfer, result.Transfer.Transfer.ID, EventTransferCreated, result.Transfer)
+			if err != nil {
+				return err
+			}
+		}
+
+		return recordAudit(ctx, q, AuditActionChargeOverdraft, AuditEntityAccount, strconv.FormatInt(account.ID, 10), nil, result)
+	})
+
+	return result, err
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createOverdraftAccount(t *testing.T, store *Store, balance int64, arg SetAccountOverdraftParams) Account {
+	account, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    util.RandomOwner(),
+		Balance:  balance,
+		Currency: util.RandomCurrency(),
+	})
+	require.NoError(t, err)
+
+	arg.ID = account.ID
+	account, err = store.SetAccountOverdraft(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.OverdraftLimit, account.OverdraftLimit)
+	return account
+}
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createOverdraftAccount(t, store, 100, SetAccountOverdraftParams{OverdraftLimit: 50})
+	account2 := createRandomAccount(t)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        151,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// 用到額度剛好滿
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        150,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-50), result.FromAccount.Balance)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// 被擋下來的轉帳整筆回滾
+	account1, err = store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(-50), account1.Balance)
+	account2, err = store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.ToAccount.Balance, account2.Balance)
+
+	requireReconciled(t, store, account1, account2)
+}
+
+func TestWithdrawTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+	account := createOverdraftAccount(t, store, 10, SetAccountOverdraftParams{})
+
+	_, err := store.WithdrawTx(context.Background(), ExternalTransferTxParams{
+		AccountID:   account.ID,
+		Amount:      11,
+		ExternalRef: "wd-" + util.RandomString(12, false),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	transfers, err := store.ListExternalTransfers(context.Background(), ListExternalTransfersParams{AccountID: account.ID, Limit: 10})
+	require.NoError(t, err)
+	// 只剩期初餘額那一筆
+	require.Len(t, transfers, 1)
+}
+
+func TestChargeOverdraftTx(t *testing.T) {
+	store := NewStore(testDB)
+	// 1,000.00 overdrawn at 365% ACT/365：一天 10
+	account := createOverdraftAccount(t, store, 0, SetAccountOverdraftParams{
+		OverdraftLimit:   1000,
+		OverdraftRateBps: 36500,
+		OverdraftFee:     5,
+	})
+	other := createRandomAccount(t)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   other.ID,
+		Amount:        1000,
+	})
+	require.NoError(t, err)
+
+	today := time.Now().UTC()
+	charged, err := store.ChargeOverdraftTx(context.Background(), ChargeOverdraftTxParams{AccountID: account.ID, PeriodEnd: today})
+	require.NoError(t, err)
+	require.True(t, charged.Created)
+	require.Equal(t, int32(1), charged.Charge.OverdrawnDays)
+	require.Equal(t, int64(5), charged.Charge.Fee)
+	require.Equal(t, int64(15), charged.Charge.Amount)
+	require.Zero(t, charged.Charge.CarryUnits)
+	require.Equal(t, charged.Transfer.Transfer.ID, charged.Charge.TransferID.Int64)
+	require.Equal(t, systemAccountOwner(SystemAccountOverdraftIncome), charged.Transfer.ToAccount.Owner)
+	// 收費可以把帳戶扣到額度以下
+	require.Equal(t, int64(-1015), charged.Transfer.FromAccount.Balance)
+
+	// 同一期重跑不會再收一次
+	again, err := store.ChargeOverdraftTx(context.Background(), ChargeOverdraftTxParams{AccountID: account.ID, PeriodEnd: today})
+	require.NoError(t, err)
+	require.False(t, again.Created)
+	require.Equal(t, charged.Charge.ID, again.Charge.ID)
+
+	_, err = store.ChargeOverdraftTx(context.Background(), ChargeOverdraftTxParams{AccountID: account.ID, PeriodEnd: today.AddDate(0, 0, -1)})
+	require.ErrorIs(t, err, ErrOverdraftChargeOutOfOrder)
+
+	requireReconciled(t, store, charged.Transfer.FromAccount, charged.Transfer.ToAccount)
+}
+
+func TestChargeOverdraftTxNotOverdrawn(t *testing.T) {
+	store := NewStore(testDB)
+	account := createOverdraftAccount(t, store, 100, SetAccountOverdraftParams{
+		OverdraftLimit:   1000,
+		OverdraftRateBps: 36500,
+		OverdraftFee:     5,
+	})
+
+	charged, err := store.ChargeOverdraftTx(context.Background(), ChargeOverdraftTxParams{AccountID: account.ID, PeriodEnd: time.Now().UTC()})
+	require.NoError(t, err)
+	require.True(t, charged.Created)
+	require.Zero(t, charged.Charge.OverdrawnDays)
+	require.Zero(t, charged.Charge.Amount)
+	require.False(t, charged.Charge.TransferID.Valid)
+	require.Empty(t, charged.Transfer)
+}
@@ -0,0 +1,234 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Statuses of payment instructions
+const (
+	PaymentAccepted = "accepted"
+	PaymentRejected = "rejected"
+	// PaymentPending is an instruction whose transfer is held for review
+	PaymentPending = "pending"
+)
+
+// ISO 20022 status reason codes recorded on rejected payment instructions
+const (
+	ReasonIncorrectAccount     = "AC01"
+	ReasonClosedAccount        = "AC04"
+	ReasonZeroAmount           = "AM01"
+	ReasonNotAllowedAmount     = "AM02"
+	ReasonNotAllowedCurrency   = "AM03"
+	ReasonInsufficientFunds    = "AM04"
+	ReasonDuplication          = "AM05"
+	ReasonInvalidAmount        = "AM12"
+	ReasonTransactionForbidden = "AG01"
+)
+
+// EndToEndIDNotProvided is the ISO 20022 EndToEndId of a debtor that gave none.
+// It cannot tell two instructions apart, so PaymentInstructionTx does not accept it.
+const EndToEndIDNotProvided = "NOTPROVIDED"
+
+// AuditEntityPaymentInstruction is the audit log entity type of rejected and pending payment instructions
+const AuditEntityPaymentInstruction = "payment_instruction"
+
+var (
+	// ErrInvalidPaymentInstruction is returned when the parameters are rejected before touching the database
+	ErrInvalidPaymentInstruction = errors.New("invalid payment instruction")
+	// ErrEndToEndIDConflict is returned when the end-to-end id is already used by a different instruction of the account
+	ErrEndToEndIDConflict = errors.New("end-to-end id already used by a different instruction")
+)
+
+// errPaymentInstructionReplayed 讓 AfterTransfer 在另一個事務先記下同一個 end-to-end id 時 Rollback 這次的轉帳
+var errPaymentInstructionReplayed = errors.New("payment instruction replayed")
+
+// PaymentInstructionTxParams contains the input parameters of the payment instruction transaction
+type PaymentInstructionTxParams struct {
+	MessageID     string `json:"message_id"`
+	PaymentInfoID string `json:"payment_info_id"`
+	InstructionID string `json:"instruction_id"`
+	// EndToEndID makes the instruction idempotent for its from account, it cannot be EndToEndIDNotProvided
+	EndToEndID string           `json:"end_to_end_id"`
+	Transfer   TransferTxParams `json:"transfer"`
+}
+
+// PaymentInstructionTxResult is the result of the payment instruction transaction.
+// Transfer is empty unless the transfer was made by this call.
+type PaymentInstructionTxResult struct {
+	Instruction PaymentInstruction `json:"instruction"`
+	Transfer    TransferTxResult   `json:"transfer"`
+	// Replayed is true when the instruction was already recorded by an earlier call
+	Replayed bool `json:"replayed"`
+}
+
+// PaymentInstructionTx executes a credit transfer instruction with TransferTx and records its outcome under its end-to-end id.
+// Calling again with the same from account and end-to-end id returns the recorded instruction without moving any money.
+// Business rejections of TransferTx (funds, limits, closed accounts, blocked by screening) are recorded as rejected
+// with their reason code and are not returned as errors; a held transfer is recorded as pending.
+func (store *Store) PaymentInstructionTx(ctx context.Context, arg PaymentInstructionTxParams) (PaymentInstructionTxResult, error) {
+	var result PaymentInstructionTxResult
+
+	if arg.EndToEndID == "" {
+		return result, fmt.Errorf("%w: end-to-end id is required", ErrInvalidPaymentInstruction)
+	}
+	// NOTPROVIDED 當成 key 的話，之後每一筆都會被當成第一筆的重播
+	if arg.EndToEndID == EndToEndIDNotProvided {
+		return result, fmt.Errorf("%w: end-to-end id %s cannot identify the instruction", ErrInvalidPaymentInstruction, EndToEndIDNotProvided)
+	}
+	if arg.Transfer.Amount <= 0 {
+		return result, fmt.Errorf("%w: amount must be positive", ErrInvalidPaymentInstruction)
+	}
+
+	// 另一個事務剛 Commit 這筆 instruction，replica 可能還沒收到
+	instruction, err := store.GetPaymentInstructionByEndToEndID(UsePrimary(ctx), GetPaymentInstructionByEndToEndIDParams{
+		FromAccountID: arg.Transfer.FromAccountID,
+		EndToEndID:    arg.EndToEndID,
+	})
+	if err == nil {
+		return replayedPaymentInstruction(instruction, arg)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return result, err
+	}
+
+	transfer := arg.Transfer
+	afterTransfer := transfer.AfterTransfer
+	transfer.AfterTransfer = func(q *Queries, transferred TransferTxResult) error {
+		var err error
+		result.Instruction, err = q.CreatePaymentInstruction(ctx, paymentInstructionParams(arg, PaymentAccepted, "", "",
+			sql.NullInt64{Int64: transferred.Transfer.ID, Valid: true}, sql.NullInt64{}))
+		if errors.Is(err, sql.ErrNoRows) {
+			// 同一個 end-to-end id 的另一個事務先 Commit 了
+			return errPaymentInstructionReplayed
+		}
+		if err != nil {
+			return err
+		}
+
+		if afterTransfer != nil {
+			return afterTransfer(q, transferred)
+		}
+		return nil
+	}
+	// hold 或 block 的 instruction 和 review case 在同一個事務 Commit，
+	// 否則 case 在兩個事務之間被決定的話，instruction 會一直停在 pending
+	transfer.afterScreening = func(q *Queries, screening *ScreeningError) ([]auditRecord, error) {
+		status, reasonCode, reviewCaseID, _ := paymentOutcome(screening)
+		var err error
+		result.Instruction, err = q.CreatePaymentInstruction(ctx, paymentInstructionParams(arg, status, reasonCode, screening.Error(), sql.NullInt64{}, reviewCaseID))
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errPaymentInstructionReplayed
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []auditRecord{{action: AuditActionCreate, entityType: AuditEntityPaymentInstruction, entityID: strconv.FormatInt(result.Instruction.ID, 10), after: result.Instruction}}, nil
+	}
+
+	result.Transfer, err = store.TransferTx(ctx, transfer)
+	if err == nil {
+		return result, nil
+	}
+	if errors.Is(err, errPaymentInstructionReplayed) {
+		return store.loadPaymentInstruction(ctx, arg)
+	}
+	var screening *ScreeningError
+	if errors.As(err, &screening) && result.Instruction.ID != 0 {
+		return result, nil
+	}
+
+	status, reasonCode, reviewCaseID, ok := paymentOutcome(err)
+	if !ok {
+		return PaymentInstructionTxResult{}, err
+	}
+	return store.recordPaymentInstruction(ctx, paymentInstructionParams(arg, status, reasonCode, err.Error(), sql.NullInt64{}, reviewCaseID))
+}
+
+// paymentOutcome 把 TransferTx 的業務錯誤對應成 instruction 的狀態和 ISO 20022 的原因碼；其他錯誤（例如連線中斷）不記錄，ok 是 false
+func paymentOutcome(err error) (status string, reasonCode string, reviewCaseID sql.NullInt64, ok bool) {
+	var screening *ScreeningError
+	if errors.As(err, &screening) {
+		reviewCaseID = sql.NullInt64{Int64: screening.CaseID, Valid: true}
+		if screening.Outcome == RuleHold {
+			return PaymentPending, "", reviewCaseID, true
+		}
+		return PaymentRejected, ReasonTransactionForbidden, reviewCaseID, true
+	}
+
+	switch {
+	case errors.Is(err, ErrInsufficientFunds):
+		return PaymentRejected, ReasonInsufficientFunds, reviewCaseID, true
+	case errors.Is(err, ErrTransferLimitExceeded):
+		return PaymentRejected, ReasonNotAllowedAmount, reviewCaseID, true
+	case errors.Is(err, ErrAccountClosed):
+		return PaymentRejected, ReasonClosedAccount, reviewCaseID, true
+	}
+	return "", "", reviewCaseID, false
+}
+
+func paymentInstructionParams(arg PaymentInstructionTxParams, status string, reasonCode string, reason string, transferID sql.NullInt64, reviewCaseID sql.NullInt64) CreatePaymentInstructionParams {
+	return CreatePaymentInstructionParams{
+		MessageID:     arg.MessageID,
+		PaymentInfoID: arg.PaymentInfoID,
+		InstructionID: arg.InstructionID,
+		EndToEndID:    arg.EndToEndID,
+		FromAccountID: arg.Transfer.FromAccountID,
+		ToAccountID:   arg.Transfer.ToAccountID,
+		Amount:        arg.Transfer.Amount,
+		Status:        status,
+		ReasonCode:    reasonCode,
+		Reason:        reason,
+		TransferID:    transferID,
+		ReviewCaseID:  reviewCaseID,
+	}
+}
+
+// recordPaymentInstruction 記下沒有轉帳也沒有 review case 的 rejected instruction，連同 audit log
+func (store *Store) recordPaymentInstruction(ctx context.Context, arg CreatePaymentInstructionParams) (PaymentInstructionTxResult, error) {
+	var result PaymentInstructionTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Instruction, err = q.CreatePaymentInstruction(ctx, arg)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errPaymentInstructionReplayed
+		}
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, AuditActionCreate, AuditEntityPaymentInstruction, strconv.FormatInt(result.Instruction.ID, 10), nil, result.Instruction)
+	})
+	if errors.Is(err, errPaymentInstructionReplayed) {
+		return store.loadPaymentInstruction(ctx, PaymentInstructionTxParams{
+			EndToEndID: arg.EndToEndID,
+			Transfer:   TransferTxParams{FromAccountID: arg.FromAccountID, ToAccountID: arg.ToAccountID, Amount: arg.Amount},
+		})
+	}
+
+	return result, err
+}
+
+// loadPaymentInstruction returns the instruction already recorded under the end-to-end id of arg
+func (store *Store) loadPaymentInstruction(ctx context.Context, arg PaymentInstructionTxParams) (PaymentInstructionTxResult, error) {
+	// 另一個事務剛 Commit 這筆 instruction，replica 可能還沒收到
+	instruction, err := store.GetPaymentInstructionByEndToEndID(UsePrimary(ctx), GetPaymentInstructionByEndToEndIDParams{
+		FromAccountID: arg.Transfer.FromAccountID,
+		EndToEndID:    arg.EndToEndID,
+	})
+	if err != nil {
+		return PaymentInstructionTxResult{}, err
+	}
+	return replayedPaymentInstruction(instruction, arg)
+}
+
+func replayedPaymentInstruction(instruction PaymentInstruction, arg PaymentInstructionTxParams) (PaymentInstructionTxResult, error) {
+	if instruction.ToAccountID != arg.Transfer.ToAccountID || instruction.Amount != arg.Transfer.Amount {
+		return PaymentInstructionTxResult{}, fmt.Errorf("%w: %s", ErrEndToEndIDConflict, arg.EndToEndID)
+	}
+	return PaymentInstructionTxResult{Instruction: instruction, Replayed: true}, nil
+}
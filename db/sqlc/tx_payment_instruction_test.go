@@ -0,0 +1,210 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentInstructionTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	arg := PaymentInstructionTxParams{
+		MessageID:     "MSG-" + util.RandomString(8, false),
+		PaymentInfoID: "PMT-1",
+		EndToEndID:    "E2E-" + util.RandomString(12, false),
+		Transfer:      TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10},
+	}
+
+	result, err := store.PaymentInstructionTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, result.Replayed)
+	require.Equal(t, PaymentAccepted, result.Instruction.Status)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Instruction.TransferID.Int64)
+	require.Equal(t, account1.Balance-10, result.Transfer.FromAccount.Balance)
+
+	// 同一個 end-to-end id 再送一次不會再轉一次
+	replay, err := store.PaymentInstructionTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, replay.Replayed)
+	require.Equal(t, result.Instruction.ID, replay.Instruction.ID)
+	require.Empty(t, replay.Transfer.Transfer)
+
+	account, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-10, account.Balance)
+
+	// 同一個 id 換了金額
+	conflict := arg
+	conflict.Transfer.Amount = 11
+	_, err = store.PaymentInstructionTx(context.Background(), conflict)
+	require.ErrorIs(t, err, ErrEndToEndIDConflict)
+
+	// NOTPROVIDED 不能當成冪等的 key
+	notProvided := arg
+	notProvided.EndToEndID = EndToEndIDNotProvided
+	_, err = store.PaymentInstructionTx(context.Background(), notProvided)
+	require.ErrorIs(t, err, ErrInvalidPaymentInstruction)
+}
+
+func TestPaymentInstructionTxRejected(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	arg := PaymentInstructionTxParams{
+		MessageID:     "MSG-" + util.RandomString(8, false),
+		PaymentInfoID: "PMT-1",
+		EndToEndID:    "E2E-" + util.RandomString(12, false),
+		Transfer:      TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: account1.Balance + 1},
+	}
+
+	result, err := store.PaymentInstructionTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, PaymentRejected, result.Instruction.Status)
+	require.Equal(t, ReasonInsufficientFunds, result.Instruction.ReasonCode)
+	require.False(t, result.Instruction.TransferID.Valid)
+
+	// 被拒的結果也一樣重播，不會再試一次
+	replay, err := store.PaymentInstructionTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, replay.Replayed)
+	require.Equal(t, result.Instruction, replay.Instruction)
+
+	account, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, account.Balance)
+}
+
+func TestPaymentInstructionTxConcurrentReplay(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	arg := PaymentInstructionTxParams{
+		MessageID:     "MSG-" + util.RandomString(8, false),
+		PaymentInfoID: "PMT-1",
+		EndToEndID:    "E2E-" + util.RandomString(12, false),
+		Transfer:      TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 1},
+	}
+
+	n := 5
+	results := make(chan PaymentInstructionTxResult, n)
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			result, err := store.PaymentInstructionTx(context.Background(), arg)
+			errs <- err
+			results <- result
+		}()
+	}
+
+	var instructionID int64
+	var transferred int
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+		result := <-results
+		if instructionID == 0 {
+			instructionID = result.Instruction.ID
+		}
+		require.Equal(t, instructionID, result.Instruction.ID)
+		if !result.Replayed {
+			transferred++
+		}
+	}
+	require.Equal(t, 1, transferred)
+
+	account, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-1, account.Balance)
+}
+
+func TestPaymentInstructionTxHeldDecided(t *testing.T) {
+	store := NewStore(testDB, WithTransferRules(NewAccountRule{MaxAge: time.Hour, MinAmount: 1}))
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	held := func() PaymentInstructionTxParams {
+		arg := PaymentInstructionTxParams{
+			MessageID:     "MSG-" + util.RandomString(8, false),
+			PaymentInfoID: "PMT-1",
+			EndToEndID:    "E2E-" + util.RandomString(12, false),
+			Transfer:      TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10},
+		}
+		result, err := store.PaymentInstructionTx(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, PaymentPending, result.Instruction.Status)
+		require.True(t, result.Instruction.ReviewCaseID.Valid)
+		return arg
+	}
+
+	// 核准之後重送拿到的是 accepted 和轉帳的 id
+	approvedArg := held()
+	replay, err := store.PaymentInstructionTx(context.Background(), approvedArg)
+	require.NoError(t, err)
+	approved, err := store.ApproveReviewCaseTx(context.Background(), ReviewDecisionTxParams{CaseID: replay.Instruction.ReviewCaseID.Int64, Reviewer: "compliance@example.com"})
+	require.NoError(t, err)
+
+	replay, err = store.PaymentInstructionTx(context.Background(), approvedArg)
+	require.NoError(t, err)
+	require.True(t, replay.Replayed)
+	require.Equal(t, PaymentAccepted, replay.Instruction.Status)
+	require.Equal(t, approved.Transfer.Transfer.ID, replay.Instruction.TransferID.Int64)
+
+	// 拒絕之後重送拿到的是 rejected
+	rejectedArg := held()
+	replay, err = store.PaymentInstructionTx(context.Background(), rejectedArg)
+	require.NoError(t, err)
+	_, err = store.RejectReviewCaseTx(context.Background(), ReviewDecisionTxParams{CaseID: replay.Instruction.ReviewCaseID.Int64, Reviewer: "compliance@example.com"})
+	require.NoError(t, err)
+
+	replay, err = store.PaymentInstructionTx(context.Background(), rejectedArg)
+	require.NoError(t, err)
+	require.Equal(t, PaymentRejected, replay.Instruction.Status)
+	require.Equal(t, ReasonTransactionForbidden, replay.Instruction.ReasonCode)
+	require.False(t, replay.Instruction.TransferID.Valid)
+}
+
+func TestPaymentInstructionTxHeldAudit(t *testing.T) {
+	store := NewStore(testDB, WithTransferRules(NewAccountRule{MaxAge: time.Hour, MinAmount: 1}))
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	result, err := store.PaymentInstructionTx(context.Background(), PaymentInstructionTxParams{
+		MessageID:     "MSG-" + util.RandomString(8, false),
+		PaymentInfoID: "PMT-1",
+		EndToEndID:    "E2E-" + util.RandomString(12, false),
+		Transfer:      TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10},
+	})
+	require.NoError(t, err)
+	require.Equal(t, PaymentPending, result.Instruction.Status)
+	caseID := result.Instruction.ReviewCaseID.Int64
+
+	_, err = store.RejectReviewCaseTx(context.Background(), ReviewDecisionTxParams{CaseID: caseID, Reviewer: "compliance@example.com"})
+	require.NoError(t, err)
+
+	auditLogs := func(entityType string, id int64) []AuditLog {
+		logs, err := store.ListAuditLogsByEntity(context.Background(), ListAuditLogsByEntityParams{
+			EntityType: entityType,
+			EntityID:   strconv.FormatInt(id, 10),
+		})
+		require.NoError(t, err)
+		require.Len(t, logs, 2)
+		return logs
+	}
+	caseLogs := auditLogs(AuditEntityReviewCase, caseID)
+	instructionLogs := auditLogs(AuditEntityPaymentInstruction, result.Instruction.ID)
+
+	// case 和 instruction 在同一個事務建立、同一個事務決定，audit log 都是 case 在前、instruction 緊接在後
+	for i := range caseLogs {
+		require.Equal(t, caseLogs[i].ID+1, instructionLogs[i].ID)
+	}
+	require.Equal(t, AuditActionReject, caseLogs[1].Action)
+	require.Equal(t, AuditActionUpdate, instructionLogs[1].Action)
+}
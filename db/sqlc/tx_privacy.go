@@ -0,0 +1,267 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Audit log actions of account closing and owner anonymization
+const (
+	AuditActionClose     = "close"
+	AuditActionAnonymize = "anonymize"
+)
+
+// pseudonymPrefix 開頭的 owner 是已經匿名化的，不會和真的 username 撞在一起
+const pseudonymPrefix = "anon:"
+
+var (
+	// ErrAccountClosed is returned when a transfer, deposit or withdrawal touches a closed account
+	ErrAccountClosed = errors.New("account is closed")
+	// ErrAccountNotEmpty is returned when closing an account whose balance is not zero
+	ErrAccountNotEmpty = errors.New("account balance is not zero")
+	// ErrOwnerHasOpenAccounts is returned when anonymizing an owner that still has open accounts
+	ErrOwnerHasOpenAccounts = errors.New("owner has open accounts")
+)
+
+// CheckOpen returns ErrAccountClosed when one of accounts is closed
+func CheckOpen(accounts ...Account) error {
+	for _, account := range accounts {
+		if account.ClosedAt.Valid {
+			return fmt.Errorf("%w: account %d", ErrAccountClosed, account.ID)
+		}
+	}
+	return nil
+}
+
+// CloseAccountTx closes an account with a zero balance and records an audit log row within a single database transaction.
+// The account and its entries stay, it only takes no more transfers.
+func (store *Store) CloseAccountTx(ctx context.Context, id int64) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetAccountForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := CheckOpen(before); err != nil {
+			return err
+		}
+		if before.Balance != 0 {
+			return fmt.Errorf("%w: account %d has %d", ErrAccountNotEmpty, id, before.Balance)
+		}
+
+		account, err = q.CloseAccount(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, AuditActionClose, AuditEntityAccount, strconv.FormatInt(id, 10), before, account)
+	})
+
+	return account, err
+}
+
+// OwnerExport is everything held about an owner in the ledger
+type OwnerExport struct {
+	Owner      string    `json:"owner"`
+	ExportedAt time.Time `json:"exported_at"`
+	// User is nil when the owner has no user
+	User              *User              `json:"user"`
+	Accounts          []Account          `json:"accounts"`
+	Entries           []Entry            `json:"entries"`
+	Transfers         []Transfer         `json:"transfers"`
+	ExternalTransfers []ExternalTransfer `json:"external_transfers"`
+	// ArchivedEntryTotals flags the months detached from the ledger: their entries and transfers
+	// are not in Entries and Transfers, only the total of each account per month is
+	ArchivedEntryTotals []ArchivedEntryTotal `json:"archived_entry_totals"`
+}
+
+// ExportOwnerData collects the user, accounts, entries, transfers and external transfers of an owner on a single snapshot of the database.
+// Transfers include both directions, so the counterparty account IDs appear but not their owners.
+// The months detached with DetachLedgerPartitions are only in ArchivedEntryTotals.
+func (store *Store) ExportOwnerData(ctx context.Context, owner string) (OwnerExport, error) {
+	export := OwnerExport{Owner: owner}
+
+	// 和 Reconcile 一樣用同一個快照，匯出的 entries 和餘額才對得起來
+	tx, err := store.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return export, err
+	}
+	defer tx.Rollback()
+
+	q := New(tx)
+	export.ExportedAt = time.Now().UTC()
+
+	user, err := q.GetUser(ctx, owner)
+	switch {
+	case err == nil:
+		export.User = &user
+	case errors.Is(err, sql.ErrNoRows):
+	default:
+		return export, fmt.Errorf("cannot get user: %w", err)
+	}
+
+	export.Accounts, err = q.ListAccountsByOwner(ctx, owner)
+	if err != nil {
+		return export, fmt.Errorf("cannot list accounts: %w", err)
+	}
+
+	accountIDs := make([]int64, len(export.Accounts))
+	for i, account := range export.Accounts {
+		accountIDs[i] = account.ID
+	}
+
+	export.Entries, err = q.ListEntriesByAccounts(ctx, accountIDs)
+	if err != nil {
+		return export, fmt.Errorf("cannot list entries: %w", err)
+	}
+	export.Transfers, err = q.ListTransfersByAccounts(ctx, accountIDs)
+	if err != nil {
+		return export, fmt.Errorf("cannot list transfers: %w", err)
+	}
+	export.ExternalTransfers, err = q.ListExternalTransfersByAccounts(ctx, accountIDs)
+	if err != nil {
+		return export, fmt.Errorf("cannot list external transfers: %w", err)
+	}
+	export.ArchivedEntryTotals, err = q.ListArchivedEntryTotalsByAccounts(ctx, accountIDs)
+	if err != nil {
+		return export, fmt.Errorf("cannot list archived entry totals: %w", err)
+	}
+
+	return export, tx.Commit()
+}
+
+// AnonymizeOwnerTxResult is the result of the anonymize owner transaction, it holds no personal data
+type AnonymizeOwnerTxResult struct {
+	Pseudonym        string `json:"pseudonym"`
+	Accounts         int64  `json:"accounts"`
+	User             bool   `json:"user"`
+	WebhookEndpoints int64  `json:"webhook_endpoints"`
+	TransferLimits   int64  `json:"transfer_limits"`
+	Screenings       int64  `json:"screenings"`
+	ReviewCases      int64  `json:"review_cases"`
+	OutboxEvents     int64  `json:"outbox_events"`
+	// AuditKey is true when the key of the personal data in the audit log was deleted
+	AuditKey bool `json:"audit_key"`
+}
+
+// AnonymizeOwnerTx replaces the owner of closed accounts with a random pseudonym, and the username, full name and email
+// of the user, the owner of webhook endpoints, transfer limits and screening results, and the owner in the review case hits
+// and outbox payloads of the accounts with it, within a single database transaction.
+// Amounts, entries and transfers are not touched, so the ledger still reconciles.
+// The audit log is append-only and keeps the history written before, but it only holds keyed hashes of the personal data:
+// deleting the key of the owner leaves them pointing at nobody, see AuditRedactor.
+// Webhook payloads are not touched either, they only carry account IDs and amounts, never the owner.
+func (store *Store) AnonymizeOwnerTx(ctx context.Context, owner string) (AnonymizeOwnerTxResult, error) {
+	var result AnonymizeOwnerTxResult
+
+	if owner == "" || strings.HasPrefix(owner, pseudonymPrefix) || strings.HasPrefix(owner, systemAccountOwner("")) {
+		return result, fmt.Errorf("cannot anonymize owner %q", owner)
+	}
+
+	pseudonym, err := newPseudonym()
+	if err != nil {
+		return result, err
+	}
+	result.Pseudonym = pseudonym
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		accounts, err := q.ListAccountsByOwner(ctx, owner)
+		if err != nil {
+			return err
+		}
+		// 依 id 順序鎖，和記帳的事務一樣不會 deadlock；鎖住之後才不會在匿名化途中被轉帳或重新開啟
+		accountIDs := make([]int64, len(accounts))
+		for i, account := range accounts {
+			account, err = q.GetAccountForUpdate(ctx, account.ID)
+			if err != nil {
+				return err
+			}
+			if !account.ClosedAt.Valid {
+				return fmt.Errorf("%w: account %d", ErrOwnerHasOpenAccounts, account.ID)
+			}
+			accountIDs[i] = account.ID
+		}
+
+		result.Accounts, err = q.AnonymizeAccountsOwner(ctx, AnonymizeAccountsOwnerParams{Pseudonym: pseudonym, Owner: owner})
+		if err != nil {
+			return err
+		}
+		// 筆數不同表示 ListAccountsByOwner 之後又開了帳戶，那個帳戶沒被鎖也沒被檢查
+		if result.Accounts != int64(len(accounts)) {
+			return fmt.Errorf("%w: %d accounts locked but %d anonymized", ErrOwnerHasOpenAccounts, len(accounts), result.Accounts)
+		}
+
+		// verify_emails 的 FK 指向 username，先刪掉才能改 username；驗證碼本來就只是暫時的
+		if err := q.DeleteVerifyEmailsByUsername(ctx, owner); err != nil {
+			return err
+		}
+		_, err = q.AnonymizeUser(ctx, AnonymizeUserParams{
+			Pseudonym: pseudonym,
+			Email:     pseudonym + "@anonymized.invalid",
+			Username:  owner,
+		})
+		switch {
+		case err == nil:
+			result.User = true
+		case errors.Is(err, sql.ErrNoRows):
+		default:
+			return err
+		}
+
+		result.WebhookEndpoints, err = q.AnonymizeWebhookEndpoints(ctx, AnonymizeWebhookEndpointsParams{Pseudonym: pseudonym, Owner: owner})
+		if err != nil {
+			return err
+		}
+		result.TransferLimits, err = q.AnonymizeTransferLimits(ctx, AnonymizeTransferLimitsParams{
+			Pseudonym: sql.NullString{String: pseudonym, Valid: true},
+			Owner:     sql.NullString{String: owner, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		result.Screenings, err = q.AnonymizeScreeningResults(ctx, AnonymizeScreeningResultsParams{Pseudonym: pseudonym, Owner: owner})
+		if err != nil {
+			return err
+		}
+		result.ReviewCases, err = q.AnonymizeReviewCaseHits(ctx, AnonymizeReviewCaseHitsParams{Owner: owner, Pseudonym: pseudonym, AccountIds: accountIDs})
+		if err != nil {
+			return err
+		}
+		result.OutboxEvents, err = q.AnonymizeOutboxPayloads(ctx, AnonymizeOutboxPayloadsParams{Owner: owner, Pseudonym: pseudonym, AccountIds: accountIDs})
+		if err != nil {
+			return err
+		}
+
+		// 刪掉 key 之後，audit log 裡用它算的 hash 就對不回 owner 了（crypto-shredding）
+		keys, err := q.DeleteAuditSubjectKey(ctx, owner)
+		if err != nil {
+			return err
+		}
+		result.AuditKey = keys > 0
+
+		// audit log 只記 pseudonym 和筆數，不能把要刪掉的個資又寫進去；
+		// owner 自己申請的話 actor 也換成 pseudonym，否則寫 actor 時又會替 owner 建一把新的 key
+		if ActorFromContext(ctx) == owner {
+			ctx = WithActor(ctx, pseudonym)
+		}
+		return recordAudit(ctx, q, AuditActionAnonymize, AuditEntityUser, pseudonym, nil, result)
+	})
+
+	return result, err
+}
+
+func newPseudonym() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return pseudonymPrefix + hex.EncodeToString(b), nil
+}
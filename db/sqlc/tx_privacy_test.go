@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	other := createRandomAccount(t)
+
+	_, err := store.CloseAccountTx(context.Background(), account.ID)
+	require.ErrorIs(t, err, ErrAccountNotEmpty)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   other.ID,
+		Amount:        account.Balance,
+	})
+	require.NoError(t, err)
+
+	closed, err := store.CloseAccountTx(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.True(t, closed.ClosedAt.Valid)
+
+	_, err = store.CloseAccountTx(context.Background(), account.ID)
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	// 關掉的帳戶不能再轉入轉出
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: other.ID,
+		ToAccountID:   account.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	_, err = store.DepositTx(context.Background(), ExternalTransferTxParams{
+		AccountID:   account.ID,
+		Amount:      10,
+		ExternalRef: "dep-" + util.RandomString(12, false),
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+}
+
+func TestExportAndAnonymizeOwner(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	createRandomVerifyEmail(t, user)
+	other := createRandomAccount(t)
+
+	account, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  100,
+		Currency: other.Currency,
+	})
+	require.NoError(t, err)
+
+	// fake screener 的 matched name 就是 username，會出現在 OwnerScreeningRule 的 reason 裡
+	screened := NewStore(testDB, WithTransferRules(OwnerScreeningRule{Screener: fakeNameScreener{
+		user.Username: {Outcome: RuleHold, NormalizedName: user.Username, Score: 0.9, EntryID: "SDN-1", MatchedName: user.Username},
+	}}))
+	_, err = screened.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   other.ID,
+		Amount:        1,
+	})
+	var screening *ScreeningError
+	require.ErrorAs(t, err, &screening)
+
+	transfer, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   other.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+
+	export, err := store.ExportOwnerData(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.Email, export.User.Email)
+	require.Len(t, export.Accounts, 1)
+	// 期初餘額一筆、轉出一筆
+	require.Len(t, export.Entries, 2)
+	require.Len(t, export.Transfers, 1)
+	require.Len(t, export.ExternalTransfers, 1)
+	require.Empty(t, export.ArchivedEntryTotals)
+
+	archive, err := json.Marshal(export)
+	require.NoError(t, err)
+	require.Contains(t, string(archive), user.Email)
+
+	// audit log 只有 owner 的 keyed hash
+	logs, err := store.ListAuditLogsByEntity(context.Background(), ListAuditLogsByEntityParams{
+		EntityType: AuditEntityAccount,
+		EntityID:   strconv.FormatInt(account.ID, 10),
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, logs)
+	for _, log := range logs {
+		require.NotContains(t, string(log.After), user.Username)
+	}
+
+	_, err = store.AnonymizeOwnerTx(context.Background(), user.Username)
+	require.ErrorIs(t, err, ErrOwnerHasOpenAccounts)
+
+	_, err = store.CloseAccountTx(context.Background(), account.ID)
+	require.NoError(t, err)
+
+	result, err := store.AnonymizeOwnerTx(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(result.Pseudonym, "anon:"))
+	require.Equal(t, int64(1), result.Accounts)
+	require.True(t, result.User)
+	require.Equal(t, int64(1), result.ReviewCases)
+	// account.created 和 transfer.created
+	require.Equal(t, int64(2), result.OutboxEvents)
+	require.True(t, result.AuditKey)
+
+	_, err = store.queries.GetAuditSubjectKey(context.Background(), user.Username)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	reviewCase, err := store.GetReviewCase(context.Background(), screening.CaseID)
+	require.NoError(t, err)
+	require.NotContains(t, string(reviewCase.Hits), user.Username)
+	require.Contains(t, string(reviewCase.Hits), result.Pseudonym)
+
+	for _, aggregate := range []ListOutboxEventsByAggregateParams{
+		{AggregateType: AggregateAccount, AggregateID: account.ID},
+		{AggregateType: AggregateTransfer, AggregateID: transfer.Transfer.ID},
+	} {
+		events, err := store.ListOutboxEventsByAggregate(context.Background(), aggregate)
+		require.NoError(t, err)
+		require.NotEmpty(t, events)
+		for _, event := range events {
+			require.NotContains(t, string(event.Payload), user.Username)
+		}
+	}
+
+	_, err = store.GetUser(context.Background(), user.Username)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	anonymized, err := store.GetUser(context.Background(), result.Pseudonym)
+	require.NoError(t, err)
+	require.Empty(t, anonymized.FullName)
+	require.NotEqual(t, user.Email, anonymized.Email)
+	require.True(t, anonymized.AnonymizedAt.Valid)
+
+	// 金額和 entries 都沒動，帳還是對得起來
+	account, err = store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Pseudonym, account.Owner)
+	require.Zero(t, account.Balance)
+
+	export, err = store.ExportOwnerData(context.Background(), result.Pseudonym)
+	require.NoError(t, err)
+	require.Len(t, export.Entries, 2)
+	require.Len(t, export.Transfers, 1)
+
+	requireReconciled(t, store, account, other)
+}
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Statuses of review cases
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+// Audit log actions of the review case transactions
+const (
+	AuditActionApprove = "approve"
+	AuditActionReject  = "reject"
+)
+
+var (
+	// ErrInvalidReviewDecision is returned when the decision is rejected before touching the database
+	ErrInvalidReviewDecision = errors.New("invalid review decision")
+	// ErrReviewCaseNotPending is returned when deciding a case that is already approved or rejected
+	ErrReviewCaseNotPending = errors.New("review case is not pending")
+)
+
+// ReviewDecisionTxParams contains the input parameters of the review decision transactions
+type ReviewDecisionTxParams struct {
+	CaseID int64 `json:"case_id"`
+	// Reviewer is the person deciding, recorded as the audit log actor
+	Reviewer string `json:"reviewer"`
+	Note     string `json:"note"`
+}
+
+// ReviewDecisionTxResult is the result of the review decision transactions
+type ReviewDecisionTxResult struct {
+	ReviewCase ReviewCase `json:"review_case"`
+	// Transfer is empty when the case is rejected
+	Transfer TransferTxResult `json:"transfer"`
+}
+
+// ApproveReviewCaseTx makes the transfer held by a review case and marks the case approved within a single database transaction.
+// The rules are not run again, but the funds and transfer limits are checked as for any transfer;
+// when they fail the case stays pending.
+func (store *Store) ApproveReviewCaseTx(ctx context.Context, arg ReviewDecisionTxParams) (ReviewDecisionTxResult, error) {
+	var result ReviewDecisionTxResult
+
+	if arg.Reviewer == "" {
+		return result, fmt.Errorf("%w: reviewer is required", ErrInvalidReviewDecision)
+	}
+
+	// case 可能是剛 hold 下來的，replica 上還沒有
+	reviewCase, err := store.GetReviewCase(UsePrimary(ctx), arg.CaseID)
+	if err != nil {
+		return result, err
+	}
+
+	ctx = WithActor(ctx, arg.Reviewer)
+	result.Transfer, err = store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: reviewCase.FromAccountID,
+		ToAccountID:   reviewCase.ToAccountID,
+		Amount:        reviewCase.Amount,
+		// 在轉帳的事務裡才鎖 case，兩個人同時核准的話，後面那個會看到 case 已經不是 pending，整筆轉帳回滾
+		afterTransferAudited: func(q *Queries, transfer TransferTxResult) ([]auditRecord, error) {
+			reviewCase, audits, err := recordReviewDecision(ctx, q, arg, ReviewApproved, sql.NullInt64{Int64: transfer.Transfer.ID, Valid: true})
+			result.ReviewCase = reviewCase
+			return audits, err
+		},
+		reviewed: true,
+	})
+	if err != nil {
+		return ReviewDecisionTxResult{}, err
+	}
+
+	return result, nil
+}
+
+// RejectReviewCaseTx marks a review case rejected, the held transfer is never made
+func (store *Store) RejectReviewCaseTx(ctx context.Context, arg ReviewDecisionTxParams) (ReviewDecisionTxResult, error) {
+	var result ReviewDecisionTxResult
+
+	if arg.Reviewer == "" {
+		return result, fmt.Errorf("%w: reviewer is required", ErrInvalidReviewDecision)
+	}
+
+	ctx = WithActor(ctx, arg.Reviewer)
+	err := store.execTx(ctx, func(q *Queries) error {
+		reviewCase, audits, err := recordReviewDecision(ctx, q, arg, ReviewRejected, sql.NullInt64{})
+		if err != nil {
+			return err
+		}
+		result.ReviewCase = reviewCase
+		return recordAudits(ctx, q, audits...)
+	})
+
+	return result, err
+}
+
+// recordReviewDecision locks a pending case, records the decision on it and on its held payment instruction,
+// and returns their audit log rows for the caller to write last
+func recordReviewDecision(ctx context.Context, q *Queries, arg ReviewDecisionTxParams, status string, transferID sql.NullInt64) (ReviewCase, []auditRecord, error) {
+	before, err := q.GetReviewCaseForUpdate(ctx, arg.CaseID)
+	if err != nil {
+		return before, nil, err
+	}
+	if before.Status != ReviewPending {
+		return before, nil, fmt.Errorf("%w: review case %d is %s", ErrReviewCaseNotPending, before.ID, before.Status)
+	}
+
+	reviewCase, err := q.DecideReviewCase(ctx, DecideReviewCaseParams{
+		ID:           before.ID,
+		Status:       status,
+		TransferID:   transferID,
+		Reviewer:     arg.Reviewer,
+		DecisionNote: arg.Note,
+	})
+	if err != nil {
+		return reviewCase, nil, err
+	}
+
+	action := AuditActionApprove
+	if status == ReviewRejected {
+		action = AuditActionReject
+	}
+	audits := []auditRecord{{action: action, entityType: AuditEntityReviewCase, entityID: strconv.FormatInt(reviewCase.ID, 10), before: before, after: reviewCase}}
+
+	// instruction 的 row 也要在寫 audit log 之前鎖，見 recordAudit
+	instructionAudit, err := recordPaymentInstructionDecision(ctx, q, reviewCase)
+	if err != nil {
+		return reviewCase, nil, err
+	}
+	if instructionAudit != nil {
+		audits = append(audits, *instructionAudit)
+	}
+	return reviewCase, audits, nil
+}
+
+// recordPaymentInstructionDecision 把 case 的決定寫到被 hold 的 payment instruction，重送 pain.001 時才不會一直回報 PDNG，
+// 回傳還沒寫的 audit log；不是從 pain.001 來的轉帳沒有 instruction，回傳 nil
+func recordPaymentInstructionDecision(ctx context.Context, q *Queries, reviewCase ReviewCase) (*auditRecord, error) {
+	before, err := q.GetPaymentInstructionByReviewCaseForUpdate(ctx, sql.NullInt64{Int64: reviewCase.ID, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if before.Status != PaymentPending {
+		return nil, nil
+	}
+
+	arg := DecidePaymentInstructionParams{
+		ID:         before.ID,
+		Status:     PaymentAccepted,
+		TransferID: reviewCase.TransferID,
+	}
+	if reviewCase.Status == ReviewRejected {
+		arg.Status = PaymentRejected
+		arg.ReasonCode = ReasonTransactionForbidden
+		arg.Reason = fmt.Sprintf("rejected in review case %d", reviewCase.ID)
+	}
+	instruction, err := q.DecidePaymentInstruction(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	return &auditRecord{action: AuditActionUpdate, entityType: AuditEntityPaymentInstruction, entityID: strconv.FormatInt(instruction.ID, 10), before: before, after: instruction}, nil
+}
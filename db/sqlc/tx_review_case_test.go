@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferTxHeld(t *testing.T) {
+	store := NewStore(testDB, WithTransferRules(NewAccountRule{MaxAge: time.Hour, MinAmount: 50}))
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        50,
+	})
+	require.ErrorIs(t, err, ErrTransferHeld)
+
+	var screening *ScreeningError
+	require.True(t, errors.As(err, &screening))
+	require.Len(t, screening.Hits, 1)
+	require.Equal(t, "new_account", screening.Hits[0].Rule)
+
+	// 只有 review case 被 Commit，錢沒動
+	reviewCase, err := store.GetReviewCase(context.Background(), screening.CaseID)
+	require.NoError(t, err)
+	require.Equal(t, ReviewPending, reviewCase.Status)
+	require.Equal(t, int64(50), reviewCase.Amount)
+
+	var hits []RuleHit
+	require.NoError(t, json.Unmarshal(reviewCase.Hits, &hits))
+	require.Equal(t, screening.Hits, hits)
+
+	unchanged, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, unchanged.Balance)
+
+	// 小額的照常轉
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	approved, err := store.ApproveReviewCaseTx(context.Background(), ReviewDecisionTxParams{CaseID: reviewCase.ID, Reviewer: "compliance@example.com"})
+	require.NoError(t, err)
+	require.Equal(t, ReviewApproved, approved.ReviewCase.Status)
+	require.Equal(t, approved.Transfer.Transfer.ID, approved.ReviewCase.TransferID.Int64)
+	require.Equal(t, account1.Balance-60, approved.Transfer.FromAccount.Balance)
+
+	_, err = store.ApproveReviewCaseTx(context.Background(), ReviewDecisionTxParams{CaseID: reviewCase.ID, Reviewer: "compliance@example.com"})
+	require.ErrorIs(t, err, ErrReviewCaseNotPending)
+
+	// 第二次核准整筆回滾，不會多轉
+	updated, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-60, updated.Balance)
+}
+
+func TestRejectReviewCaseTx(t *testing.T) {
+	store := NewStore(testDB, WithTransferRules(NewAccountRule{MaxAge: time.Hour, MinAmount: 1}))
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	var screening *ScreeningError
+	require.True(t, errors.As(err, &screening))
+
+	_, err = store.RejectReviewCaseTx(context.Background(), ReviewDecisionTxParams{CaseID: screening.CaseID})
+	require.ErrorIs(t, err, ErrInvalidReviewDecision)
+
+	rejected, err := store.RejectReviewCaseTx(context.Background(), ReviewDecisionTxParams{CaseID: screening.CaseID, Reviewer: "compliance@example.com", Note: "known customer"})
+	require.NoError(t, err)
+	require.Equal(t, ReviewRejected, rejected.ReviewCase.Status)
+	require.Equal(t, "known customer", rejected.ReviewCase.DecisionNote)
+	require.False(t, rejected.ReviewCase.TransferID.Valid)
+
+	_, err = store.ApproveReviewCaseTx(context.Background(), ReviewDecisionTxParams{CaseID: screening.CaseID, Reviewer: "compliance@example.com"})
+	require.ErrorIs(t, err, ErrReviewCaseNotPending)
+}
+
+func TestTransferTxBlocked(t *testing.T) {
+	store := NewStore(testDB, WithTransferRules(fakeRule{name: "block", result: RuleResult{Outcome: RuleBlock, Reason: "blocked"}}))
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrTransferBlocked)
+
+	// 擋下的決定記成已拒絕的 case
+	var screening *ScreeningError
+	require.True(t, errors.As(err, &screening))
+	reviewCase, err := store.GetReviewCase(context.Background(), screening.CaseID)
+	require.NoError(t, err)
+	require.Equal(t, ReviewRejected, reviewCase.Status)
+	require.Equal(t, SystemActor, reviewCase.Reviewer)
+
+	transfers, err := store.ListTransfers(context.Background(), ListTransfersParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account1.ID,
+		Limit:         10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
@@ -0,0 +1,36 @@
+package db
+
+import "context"
+
+// CompleteTaskTxParams contains the input parameters of the complete task transaction
+type CompleteTaskTxParams struct {
+	ID       int64 `json:"id"`
+	Attempts int32 `json:"attempts"`
+	// AfterComplete runs inside the transaction once the task is marked as succeeded, e.g. to enqueue its next run through q.
+	// Returning an error rolls back the completion, the task stays running until its lock expires.
+	AfterComplete func(q *Queries) error `json:"-"`
+}
+
+// CompleteTaskTx marks a task as succeeded and runs AfterComplete within a single database transaction.
+// It returns the number of tasks completed: 0 when the worker no longer holds the claim of arg.Attempts,
+// AfterComplete then does not run.
+func (store *Store) CompleteTaskTx(ctx context.Context, arg CompleteTaskTxParams) (int64, error) {
+	var rows int64
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		rows, err = q.CompleteTask(ctx, CompleteTaskParams{
+			ID:       arg.ID,
+			Attempts: arg.Attempts,
+		})
+		if err != nil || rows == 0 || arg.AfterComplete == nil {
+			return err
+		}
+		return arg.AfterComplete(q)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Scopes of transfer limits
+const (
+	TransferLimitAccount = "account"
+	TransferLimitOwner   = "owner"
+)
+
+// Limits of a transfer limit, named in LimitExceededError
+const (
+	LimitSingle      = "single"
+	LimitDaily       = "daily"
+	LimitMonthly     = "monthly"
+	LimitHourlyCount = "hourly_count"
+)
+
+// ErrTransferLimitExceeded is matched by errors.Is for every LimitExceededError
+var ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+
+// LimitExceededError is returned by TransferTx when a transfer would break a transfer limit
+type LimitExceededError struct {
+	// Scope is TransferLimitAccount or TransferLimitOwner
+	Scope string `json:"scope"`
+	// Limit is one of the Limit constants
+	Limit string `json:"limit"`
+	Max   int64  `json:"max"`
+	// Remaining is what is left of the limit before the transfer: an amount, or a number of transfers for LimitHourlyCount
+	Remaining int64 `json:"remaining"`
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s: %s %s limit is %d, remaining %d", ErrTransferLimitExceeded, e.Scope, e.Limit, e.Max, e.Remaining)
+}
+
+// Is makes errors.Is(err, ErrTransferLimitExceeded) match
+func (e *LimitExceededError) Is(target error) bool {
+	return target == ErrTransferLimitExceeded
+}
+
+// TransferUsage is the current usage of a transfer limit, from GetAccountTransferUsage or GetOwnerTransferUsage
+type TransferUsage struct {
+	DayTotal   int64
+	MonthTotal int64
+	HourCount  int64
+}
+
+// CheckTransferLimit returns a LimitExceededError when a transfer of amount on top of usage would break limit
+func CheckTransferLimit(scope string, limit TransferLimit, usage TransferUsage, amount int64) error {
+	exceeded := func(name string, limitMax int64, used int64) error {
+		return &LimitExceededError{Scope: scope, Limit: name, Max: limitMax, Remaining: max(limitMax-used, 0)}
+	}
+
+	if limit.MaxSingle.Valid && amount > limit.MaxSingle.Int64 {
+		return exceeded(LimitSingle, limit.MaxSingle.Int64, 0)
+	}
+	if limit.MaxDaily.Valid && usage.DayTotal+amount > limit.MaxDaily.Int64 {
+		return exceeded(LimitDaily, limit.MaxDaily.Int64, usage.DayTotal)
+	}
+	if limit.MaxMonthly.Valid && usage.MonthTotal+amount > limit.MaxMonthly.Int64 {
+		return exceeded(LimitMonthly, limit.MaxMonthly.Int64, usage.MonthTotal)
+	}
+	if limit.MaxHourlyCount.Valid && usage.HourCount+1 > int64(limit.MaxHourlyCount.Int32) {
+		return exceeded(LimitHourlyCount, int64(limit.MaxHourlyCount.Int32), usage.HourCount)
+	}
+	return nil
+}
+
+// TransferLimitQuerier is the part of Querier used by CheckTransferLimits,
+// so that a store on another driver can check the limits the same way
+type TransferLimitQuerier interface {
+	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetOwnerTransferLimit(ctx context.Context, arg GetOwnerTransferLimitParams) (TransferLimit, error)
+	GetAccountTransferLimit(ctx context.Context, accountID sql.NullInt64) (TransferLimit, error)
+	LockTransferLimit(ctx context.Context, lockKey string) error
+	GetOwnerTransferUsage(ctx context.Context, arg GetOwnerTransferUsageParams) (GetOwnerTransferUsageRow, error)
+	GetAccountTransferUsage(ctx context.Context, fromAccountID int64) (GetAccountTransferUsageRow, error)
+}
+
+// CheckTransferLimits returns a LimitExceededError when a transfer of amount would break a limit of the from account
+// or of its owner in the same currency. It must run in the transfer transaction before any row is locked;
+// a missing limit is reported by q with sql.ErrNoRows.
+func CheckTransferLimits(ctx context.Context, q TransferLimitQuerier, fromAccountID int64, amount int64) error {
+	// 有限額才拿 advisory lock，一律先 owner 再帳戶；鎖一直拿到 Commit，同一個限額的轉帳排隊，
+	// 彙總查詢在 READ COMMITTED 下每一句都是新的 snapshot，看得到前一個拿鎖的事務寫進去的轉帳
+	account, err := q.GetAccount(ctx, fromAccountID)
+	if err != nil {
+		return err
+	}
+
+	ownerLimit, err := q.GetOwnerTransferLimit(ctx, GetOwnerTransferLimitParams{
+		Owner:    sql.NullString{String: account.Owner, Valid: true},
+		Currency: sql.NullString{String: account.Currency, Valid: true},
+	})
+	hasOwnerLimit := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	accountLimit, err := q.GetAccountTransferLimit(ctx, sql.NullInt64{Int64: account.ID, Valid: true})
+	hasAccountLimit := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if hasOwnerLimit {
+		if err := q.LockTransferLimit(ctx, "owner:"+account.Owner+":"+account.Currency); err != nil {
+			return err
+		}
+		usage, err := q.GetOwnerTransferUsage(ctx, GetOwnerTransferUsageParams{Owner: account.Owner, Currency: account.Currency})
+		if err != nil {
+			return err
+		}
+		if err := CheckTransferLimit(TransferLimitOwner, ownerLimit, TransferUsage(usage), amount); err != nil {
+			return err
+		}
+	}
+
+	if hasAccountLimit {
+		if err := q.LockTransferLimit(ctx, "account:"+strconv.FormatInt(account.ID, 10)); err != nil {
+			return err
+		}
+		usage, err := q.GetAccountTransferUsage(ctx, account.ID)
+		if err != nil {
+			return err
+		}
+		if err := CheckTransferLimit(TransferLimitAccount, accountLimit, TransferUsage(usage), amount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetAccountTransferLimit creates or replaces the transfer limit of an account and records an audit log row within a single database transaction
+func (store *Store) SetAccountTransferLimit(ctx context.Context, arg SetAccountTransferLimitParams) (TransferLimit, error) {
+	var limit TransferLimit
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetAccountTransferLimit(ctx, arg.AccountID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		limit, err = q.SetAccountTransferLimit(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, AuditActionUpdate, AuditEntityTransferLimit, strconv.FormatInt(limit.ID, 10), transferLimitBefore(before), limit)
+	})
+
+	return limit, err
+}
+
+// SetOwnerTransferLimit creates or replaces the transfer limit of an owner in one currency and records an audit log row within a single database transaction
+func (store *Store) SetOwnerTransferLimit(ctx context.Context, arg SetOwnerTransferLimitParams) (TransferLimit, error) {
+	var limit TransferLimit
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetOwnerTransferLimit(ctx, GetOwnerTransferLimitParams{Owner: arg.Owner, Currency: arg.Currency})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		limit, err = q.SetOwnerTransferLimit(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, AuditActionUpdate, AuditEntityTransferLimit, strconv.FormatInt(limit.ID, 10), transferLimitBefore(before), limit)
+	})
+
+	return limit, err
+}
+
+// transferLimitBefore 沒有舊的限額時回傳 nil，audit log 的 before 就是 null
+func transferLimitBefore(before TransferLimit) any {
+	if before.ID == 0 {
+		return nil
+	}
+	return before
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTransferLimit(t *testing.T) {
+	limit := TransferLimit{
+		MaxSingle:      sql.NullInt64{Int64: 100, Valid: true},
+		MaxDaily:       sql.NullInt64{Int64: 300, Valid: true},
+		MaxMonthly:     sql.NullInt64{Int64: 1000, Valid: true},
+		MaxHourlyCount: sql.NullInt32{Int32: 5, Valid: true},
+	}
+
+	testCases := []struct {
+		name      string
+		usage     TransferUsage
+		amount    int64
+		limit     string
+		remaining int64
+	}{
+		{"ok", TransferUsage{DayTotal: 200, MonthTotal: 900, HourCount: 4}, 100, "", 0},
+		{"single", TransferUsage{}, 101, LimitSingle, 100},
+		{"daily", TransferUsage{DayTotal: 250, MonthTotal: 250}, 51, LimitDaily, 50},
+		{"monthly", TransferUsage{DayTotal: 0, MonthTotal: 950}, 51, LimitMonthly, 50},
+		{"hourly count", TransferUsage{HourCount: 5}, 1, LimitHourlyCount, 0},
+		// 額度被調低到用量以下，剩餘是 0 不是負數
+		{"over used", TransferUsage{DayTotal: 400, MonthTotal: 400}, 1, LimitDaily, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckTransferLimit(TransferLimitAccount, limit, tc.usage, tc.amount)
+			if tc.limit == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, ErrTransferLimitExceeded)
+			var exceeded *LimitExceededError
+			require.True(t, errors.As(err, &exceeded))
+			require.Equal(t, TransferLimitAccount, exceeded.Scope)
+			require.Equal(t, tc.limit, exceeded.Limit)
+			require.Equal(t, tc.remaining, exceeded.Remaining)
+		})
+	}
+
+	// 沒有設的欄位不限制
+	require.NoError(t, CheckTransferLimit(TransferLimitOwner, TransferLimit{}, TransferUsage{DayTotal: 1 << 40}, 1<<40))
+}
+
+func TestTransferTxDailyLimitConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := store.SetAccountTransferLimit(context.Background(), SetAccountTransferLimitParams{
+		AccountID: sql.NullInt64{Int64: account1.ID, Valid: true},
+		MaxDaily:  sql.NullInt64{Int64: 50, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// 和 TestTransferTx 一樣同時跑 10 筆，只有 5 筆過得了
+	n := 10
+	amount := int64(10)
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+
+	var succeeded int
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+
+		var exceeded *LimitExceededError
+		require.True(t, errors.As(err, &exceeded), err)
+		require.Equal(t, LimitDaily, exceeded.Limit)
+		require.Zero(t, exceeded.Remaining)
+	}
+	require.Equal(t, 5, succeeded)
+
+	updated, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-50, updated.Balance)
+}
+
+func TestTransferTxOwnerLimit(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	limit, err := store.SetOwnerTransferLimit(context.Background(), SetOwnerTransferLimitParams{
+		Owner:     sql.NullString{String: account1.Owner, Valid: true},
+		Currency:  sql.NullString{String: account1.Currency, Valid: true},
+		MaxSingle: sql.NullInt64{Int64: 20, Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        21,
+	})
+	var exceeded *LimitExceededError
+	require.True(t, errors.As(err, &exceeded))
+	require.Equal(t, TransferLimitOwner, exceeded.Scope)
+	require.Equal(t, LimitSingle, exceeded.Limit)
+	require.Equal(t, int64(20), exceeded.Remaining)
+
+	// 再設一次是改同一筆
+	updated, err := store.SetOwnerTransferLimit(context.Background(), SetOwnerTransferLimitParams{
+		Owner:     limit.Owner,
+		Currency:  limit.Currency,
+		MaxSingle: sql.NullInt64{Int64: 30, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, limit.ID, updated.ID)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        21,
+	})
+	require.NoError(t, err)
+}
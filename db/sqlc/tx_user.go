@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+)
+
+// CreateUserTxParams contains the input parameters of the create user transaction
+type CreateUserTxParams struct {
+	CreateUserParams
+	// AfterCreate runs inside the transaction once the user is created, e.g. to enqueue the verification email through q.
+	// Returning an error rolls back the user creation.
+	AfterCreate func(q *Queries, user User) error `json:"-"`
+}
+
+// CreateUserTxResult is the result of the create user transaction
+type CreateUserTxResult struct {
+	User User `json:"user"`
+}
+
+// CreateUserTx creates a user, runs AfterCreate and records an audit log row within a single database transaction
+func (store *Store) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
+	var result CreateUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		if arg.AfterCreate != nil {
+			if err := arg.AfterCreate(q, result.User); err != nil {
+				return err
+			}
+		}
+
+		// entity id 是 username 的 keyed hash，不是 username 本身，見 AuditRedactor
+		entityID, err := NewAuditRedactor(q).Subject(ctx, result.User.Username)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, AuditActionCreate, AuditEntityUser, entityID, nil, result.User)
+	})
+
+	return result, err
+}
+
+// VerifyEmailTxParams contains the input parameters of the verify email transaction
+type VerifyEmailTxParams struct {
+	EmailID    int64  `json:"email_id"`
+	SecretCode string `json:"secret_code"`
+}
+
+// VerifyEmailTxResult is the result of the verify email transaction
+type VerifyEmailTxResult struct {
+	User        User        `json:"user"`
+	VerifyEmail VerifyEmail `json:"verify_email"`
+}
+
+// VerifyEmailTx consumes a verification code, marks the user's email as verified and records an audit log row within a single database transaction.
+// It returns sql.ErrNoRows if the code does not exist, does not match, is already used or has expired.
+func (store *Store) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error) {
+	var result VerifyEmailTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		// UseVerifyEmail 只會更新「未使用、未過期、secret code 正確」的那一筆，
+		// 同一個 code 被同時送兩次時，第二個會在 row lock 釋放後發現 is_used 已經是 true 而更新不到
+		result.VerifyEmail, err = q.UseVerifyEmail(ctx, UseVerifyEmailParams{
+			ID:         arg.EmailID,
+			SecretCode: arg.SecretCode,
+		})
+		if err != nil {
+			return err
+		}
+
+		before, err := q.GetUser(ctx, result.VerifyEmail.Username)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.VerifyUserEmail(ctx, result.VerifyEmail.Username)
+		if err != nil {
+			return err
+		}
+
+		entityID, err := NewAuditRedactor(q).Subject(ctx, result.User.Username)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, AuditActionVerifyEmail, AuditEntityUser, entityID, before, result.User)
+	})
+
+	return result, err
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrWebhookClaimLost is returned by RecordWebhookAttemptTx when another deliverer already recorded the attempt,
+// after the lease of the claim expired; the attempt is not recorded
+var ErrWebhookClaimLost = errors.New("webhook delivery claim lost")
+
+// CreateWebhookEndpoint creates a webhook endpoint and records an audit log row within a single database transaction;
+// the signing secret is left out of the audit log
+func (store *Store) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error) {
+	var endpoint WebhookEndpoint
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		endpoint, err = q.CreateWebhookEndpoint(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		audited := endpoint
+		audited.Secret = ""
+		return recordAudit(ctx, q, AuditActionCreate, AuditEntityWebhookEndpoint, strconv.FormatInt(endpoint.ID, 10), nil, audited)
+	})
+
+	return endpoint, err
+}
+
+// RecordWebhookAttemptTxParams contains the input parameters of the record webhook attempt transaction
+type RecordWebhookAttemptTxParams struct {
+	DeliveryID int64 `json:"delivery_id"`
+	// Attempt must be the attempts of the claimed delivery plus one
+	Attempt int32 `json:"attempt"`
+	// Replay is the replays of the claimed delivery
+	Replay     int32          `json:"replay"`
+	StatusCode sql.NullInt32  `json:"status_code"`
+	Error      sql.NullString `json:"error"`
+	Duration   time.Duration  `json:"duration"`
+	// Status is the delivery status after this attempt: pending, succeeded or dead
+	Status        string    `json:"status"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+}
+
+// RecordWebhookAttemptTxResult is the result of the record webhook attempt transaction
+type RecordWebhookAttemptTxResult struct {
+	Delivery WebhookDelivery        `json:"delivery"`
+	Attempt  WebhookDeliveryAttempt `json:"attempt"`
+}
+
+// RecordWebhookAttemptTx appends an attempt to the delivery log and updates the delivery status within a single database transaction.
+// It fails with ErrWebhookClaimLost when the delivery is no longer pending with arg.Attempt - 1 attempts.
+func (store *Store) RecordWebhookAttemptTx(ctx context.Context, arg RecordWebhookAttemptTxParams) (RecordWebhookAttemptTxResult, error) {
+	var result RecordWebhookAttemptTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Attempt, err = q.CreateWebhookDeliveryAttempt(ctx, CreateWebhookDeliveryAttemptParams{
+			DeliveryID: arg.DeliveryID,
+			Attempt:    arg.Attempt,
+			Replay:     arg.Replay,
+			StatusCode: arg.StatusCode,
+			Error:      arg.Error,
+			DurationMs: arg.Duration.Milliseconds(),
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Delivery, err = q.UpdateWebhookDeliveryResult(ctx, UpdateWebhookDeliveryResultParams{
+			ID:            arg.DeliveryID,
+			Status:        arg.Status,
+			Attempt:       arg.Attempt,
+			NextAttemptAt: arg.NextAttemptAt,
+			LastError:     arg.Error,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: delivery %d attempt %d", ErrWebhookClaimLost, arg.DeliveryID, arg.Attempt)
+		}
+		return err
+	})
+
+	return result, err
+}
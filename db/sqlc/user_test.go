@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomUser(t *testing.T) User {
+	arg := CreateUserParams{
+		Username: util.RandomOwner(),
+		FullName: util.RandomOwner(),
+		Email:    util.RandomString(10, false) + "@example.com",
+	}
+
+	user, err := testQueries.CreateUser(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, user)
+
+	require.Equal(t, arg.Username, user.Username)
+	require.Equal(t, arg.FullName, user.FullName)
+	require.Equal(t, arg.Email, user.Email)
+	require.False(t, user.IsEmailVerified)
+	require.NotZero(t, user.CreatedAt)
+
+	return user
+}
+
+func createRandomVerifyEmail(t *testing.T, user User) VerifyEmail {
+	verifyEmail, err := testQueries.CreateVerifyEmail(context.Background(), CreateVerifyEmailParams{
+		Username:   user.Username,
+		Email:      user.Email,
+		SecretCode: util.RandomString(32, false),
+	})
+	require.NoError(t, err)
+	require.False(t, verifyEmail.IsUsed)
+	require.True(t, verifyEmail.ExpiredAt.After(verifyEmail.CreatedAt))
+
+	return verifyEmail
+}
+
+func TestCreateUserTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	var called bool
+	result, err := store.CreateUserTx(context.Background(), CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username: util.RandomOwner(),
+			FullName: util.RandomOwner(),
+			Email:    util.RandomString(10, false) + "@example.com",
+		},
+		AfterCreate: func(q *Queries, user User) error {
+			called = true
+			_, err := q.GetUser(context.Background(), user.Username)
+			return err
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, called)
+
+	user, err := testQueries.GetUser(context.Background(), result.User.Username)
+	require.NoError(t, err)
+	require.Equal(t, result.User, user)
+}
+
+func TestCreateUserTxRollback(t *testing.T) {
+	store := NewStore(testDB)
+	errAfterCreate := errors.New("cannot enqueue task")
+
+	arg := CreateUserParams{
+		Username: util.RandomOwner(),
+		FullName: util.RandomOwner(),
+		Email:    util.RandomString(10, false) + "@example.com",
+	}
+	_, err := store.CreateUserTx(context.Background(), CreateUserTxParams{
+		CreateUserParams: arg,
+		AfterCreate: func(q *Queries, user User) error {
+			return errAfterCreate
+		},
+	})
+	require.ErrorIs(t, err, errAfterCreate)
+
+	_, err = testQueries.GetUser(context.Background(), arg.Username)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	verifyEmail := createRandomVerifyEmail(t, user)
+
+	// secret code 錯誤
+	_, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:    verifyEmail.ID,
+		SecretCode: util.RandomString(32, false),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	result, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:    verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.NoError(t, err)
+	require.True(t, result.VerifyEmail.IsUsed)
+	require.True(t, result.User.IsEmailVerified)
+	require.Equal(t, user.Username, result.User.Username)
+
+	// 同一個 code 不能用第二次
+	_, err = store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:    verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestVerifyEmailTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	verifyEmail := createRandomVerifyEmail(t, user)
+
+	_, err := testDB.Exec("UPDATE verify_emails SET expired_at = now() - interval '1 minute' WHERE id = $1", verifyEmail.ID)
+	require.NoError(t, err)
+
+	_, err = store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:    verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	user, err = testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.False(t, user.IsEmailVerified)
+}
@@ -0,0 +1,195 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomWebhookEndpoint(t *testing.T) WebhookEndpoint {
+	arg := CreateWebhookEndpointParams{
+		Owner:      util.RandomOwner(),
+		Url:        "https://example.com/" + util.RandomString(8, false),
+		Secret:     util.RandomString(32, false),
+		EventTypes: []string{"transfer.received"},
+	}
+
+	endpoint, err := testQueries.CreateWebhookEndpoint(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, endpoint)
+
+	require.Equal(t, arg.Owner, endpoint.Owner)
+	require.Equal(t, arg.Url, endpoint.Url)
+	require.Equal(t, arg.Secret, endpoint.Secret)
+	require.Equal(t, arg.EventTypes, endpoint.EventTypes)
+	require.True(t, endpoint.IsActive)
+	require.NotZero(t, endpoint.ID)
+	require.NotZero(t, endpoint.CreatedAt)
+
+	return endpoint
+}
+
+func TestCreateWebhookDeliveryIdempotent(t *testing.T) {
+	endpoint := createRandomWebhookEndpoint(t)
+
+	arg := CreateWebhookDeliveryParams{
+		EndpointID: endpoint.ID,
+		EventID:    util.RandomString(12, false),
+		EventType:  "transfer.received",
+		Payload:    json.RawMessage(`{"amount": 10}`),
+	}
+	require.NoError(t, testQueries.CreateWebhookDelivery(context.Background(), arg))
+	require.NoError(t, testQueries.CreateWebhookDelivery(context.Background(), arg))
+
+	deliveries, err := testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		EndpointID: endpoint.ID,
+		Limit:      10,
+		Offset:     0,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, "pending", deliveries[0].Status)
+	require.Zero(t, deliveries[0].Attempts)
+}
+
+func TestStoreCreateWebhookEndpoint(t *testing.T) {
+	store := NewStore(testDB)
+	arg := CreateWebhookEndpointParams{
+		Owner:  util.RandomOwner(),
+		Url:    "https://example.com/" + util.RandomString(8, false),
+		Secret: util.RandomString(32, false),
+	}
+
+	endpoint, err := store.CreateWebhookEndpoint(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Secret, endpoint.Secret)
+
+	logs, err := store.ListAuditLogsByEntity(context.Background(), ListAuditLogsByEntityParams{
+		EntityType: AuditEntityWebhookEndpoint,
+		EntityID:   strconv.FormatInt(endpoint.ID, 10),
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, AuditActionCreate, logs[0].Action)
+	// 簽名用的 secret 和 owner 都不能出現在 audit log 裡
+	require.NotContains(t, string(logs[0].After), arg.Secret)
+	require.NotContains(t, string(logs[0].After), arg.Owner)
+	require.Contains(t, string(logs[0].After), arg.Url)
+}
+
+func TestRecordWebhookAttemptTx(t *testing.T) {
+	store := NewStore(testDB)
+	endpoint := createRandomWebhookEndpoint(t)
+
+	err := store.CreateWebhookDelivery(context.Background(), CreateWebhookDeliveryParams{
+		EndpointID: endpoint.ID,
+		EventID:    util.RandomString(12, false),
+		EventType:  "transfer.received",
+		Payload:    json.RawMessage(`{"amount": 10}`),
+	})
+	require.NoError(t, err)
+
+	deliveries, err := store.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		EndpointID: endpoint.ID,
+		Limit:      10,
+		Offset:     0,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	delivery := deliveries[0]
+
+	nextAttemptAt := time.Now().Add(time.Minute)
+	result, err := store.RecordWebhookAttemptTx(context.Background(), RecordWebhookAttemptTxParams{
+		DeliveryID:    delivery.ID,
+		Attempt:       1,
+		StatusCode:    sql.NullInt32{Int32: 500, Valid: true},
+		Error:         sql.NullString{String: "endpoint responded with status 500", Valid: true},
+		Duration:      120 * time.Millisecond,
+		Status:        "pending",
+		NextAttemptAt: nextAttemptAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), result.Delivery.Attempts)
+	require.Equal(t, "pending", result.Delivery.Status)
+	require.WithinDuration(t, nextAttemptAt, result.Delivery.NextAttemptAt, time.Second)
+	require.Equal(t, int64(120), result.Attempt.DurationMs)
+
+	_, err = store.RecordWebhookAttemptTx(context.Background(), RecordWebhookAttemptTxParams{
+		DeliveryID:    delivery.ID,
+		Attempt:       2,
+		StatusCode:    sql.NullInt32{Int32: 500, Valid: true},
+		Error:         sql.NullString{String: "endpoint responded with status 500", Valid: true},
+		Status:        "dead",
+		NextAttemptAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	attempts, err := store.ListWebhookDeliveryAttempts(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	require.Equal(t, int32(1), attempts[0].Attempt)
+	require.Equal(t, int32(2), attempts[1].Attempt)
+
+	// 已經有人記過第 2 次，claim 同一個 delivery 的另一個 deliverer 就記不進去
+	_, err = store.RecordWebhookAttemptTx(context.Background(), RecordWebhookAttemptTxParams{
+		DeliveryID:    delivery.ID,
+		Attempt:       2,
+		Status:        "succeeded",
+		NextAttemptAt: time.Now(),
+	})
+	require.ErrorIs(t, err, ErrWebhookClaimLost)
+
+	replayed, err := store.ReplayWebhookDelivery(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, "pending", replayed.Status)
+	require.Equal(t, int32(2), replayed.Attempts)
+	require.Equal(t, int32(1), replayed.Replays)
+	require.Equal(t, int32(2), replayed.ReplayedAttempts)
+	require.False(t, replayed.LastError.Valid)
+
+	result, err = store.RecordWebhookAttemptTx(context.Background(), RecordWebhookAttemptTxParams{
+		DeliveryID:    delivery.ID,
+		Attempt:       3,
+		Replay:        replayed.Replays,
+		Status:        "succeeded",
+		NextAttemptAt: time.Now(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), result.Attempt.Attempt)
+	require.Equal(t, int32(1), result.Attempt.Replay)
+}
+
+func TestClaimDueWebhookDeliveries(t *testing.T) {
+	endpoint := createRandomWebhookEndpoint(t)
+
+	err := testQueries.CreateWebhookDelivery(context.Background(), CreateWebhookDeliveryParams{
+		EndpointID: endpoint.ID,
+		EventID:    util.RandomString(12, false),
+		EventType:  "transfer.received",
+		Payload:    json.RawMessage(`{}`),
+	})
+	require.NoError(t, err)
+
+	// 其他測試留下的 delivery 也可能到期，所以一直 claim 到我們的 delivery 被拿到為止
+	leaseUntil := time.Now().Add(time.Hour)
+	claimed := false
+	for !claimed {
+		deliveries, err := testQueries.ClaimDueWebhookDeliveries(context.Background(), ClaimDueWebhookDeliveriesParams{
+			LeaseUntil: leaseUntil,
+			LimitCount: 100,
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, deliveries)
+
+		for _, delivery := range deliveries {
+			require.WithinDuration(t, leaseUntil, delivery.NextAttemptAt, time.Second)
+			claimed = claimed || delivery.EndpointID == endpoint.ID
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package importer
+
+import (
+	"context"
+	"io"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+// Store is the part of db.Store used by Import
+type Store interface {
+	ImportAccountsTx(ctx context.Context, arg db.ImportAccountsTxParams) (db.ImportAccountsTxResult, error)
+}
+
+// Params contains the input parameters of Import
+type Params struct {
+	// Source names the imported file, recorded on the import
+	Source string
+	Format string
+	// Rejects receives the reject file, it may be nil
+	Rejects io.Writer
+	DryRun  bool
+}
+
+// Result is the result of Import
+type Result struct {
+	Import   db.AccountImport `json:"import"`
+	Accepted int64            `json:"accepted"`
+	Rejected int64            `json:"rejected"`
+	DryRun   bool             `json:"dry_run"`
+}
+
+// Import streams the accounts of r into store, the valid rows are imported in one transaction
+// and the invalid ones, and those the store rejects such as blocked owners, are written to arg.Rejects with their line and reason
+func Import(ctx context.Context, store Store, r io.Reader, arg Params) (Result, error) {
+	rejects := NewRejectWriter(arg.Rejects)
+	reader, err := NewReader(r, arg.Format, rejects)
+	if err != nil {
+		return Result{}, err
+	}
+
+	imported, err := store.ImportAccountsTx(ctx, db.ImportAccountsTxParams{
+		Source: arg.Source,
+		Rows:   reader,
+		DryRun: arg.DryRun,
+	})
+	// 匯入失敗也把已經讀到的退件寫出去
+	if flushErr := rejects.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Import:   imported.Import,
+		Accepted: imported.Import.Accepted,
+		Rejected: rejects.Count(),
+		DryRun:   imported.DryRun,
+	}, nil
+}
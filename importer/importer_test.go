@@ -0,0 +1,98 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore 像 ImportAccountsTx 一樣把 rows 讀完，只記下讀到的帳戶；blocked 的 owner 像被篩查擋下一樣退回
+type fakeStore struct {
+	rows    []db.ImportedAccount
+	blocked string
+	err     error
+}
+
+func (store *fakeStore) ImportAccountsTx(ctx context.Context, arg db.ImportAccountsTxParams) (db.ImportAccountsTxResult, error) {
+	for {
+		row, err := arg.Rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return db.ImportAccountsTxResult{}, err
+		}
+		if row.Owner == store.blocked {
+			if err := arg.Rows.Reject(row, db.ErrOwnerBlocked.Error()); err != nil {
+				return db.ImportAccountsTxResult{}, err
+			}
+			continue
+		}
+		store.rows = append(store.rows, row)
+	}
+	if store.err != nil {
+		return db.ImportAccountsTxResult{}, store.err
+	}
+
+	return db.ImportAccountsTxResult{
+		Import: db.AccountImport{ID: 1, Source: arg.Source, Accepted: int64(len(store.rows))},
+		DryRun: arg.DryRun,
+	}, nil
+}
+
+func TestImport(t *testing.T) {
+	store := &fakeStore{}
+	var rejects bytes.Buffer
+
+	input := "owner,currency,balance\nalice,USD,100\nbob,XYZ,1\n"
+	result, err := Import(context.Background(), store, strings.NewReader(input), Params{
+		Source:  "accounts.csv",
+		Format:  FormatCSV,
+		Rejects: &rejects,
+		DryRun:  true,
+	})
+	require.NoError(t, err)
+	require.True(t, result.DryRun)
+	require.Equal(t, "accounts.csv", result.Import.Source)
+	require.Equal(t, int64(1), result.Accepted)
+	require.Equal(t, int64(1), result.Rejected)
+	require.Equal(t, []db.ImportedAccount{{Line: 2, Owner: "alice", Currency: "USD", Balance: 100}}, store.rows)
+	require.Equal(t, "line,reason,record\n3,\"invalid row: unsupported currency \"\"XYZ\"\"\",\"bob,XYZ,1\"\n", rejects.String())
+}
+
+func TestImportStoreError(t *testing.T) {
+	errStore := errors.New("connection reset")
+	store := &fakeStore{err: errStore}
+	var rejects bytes.Buffer
+
+	input := "{\"owner\":\"alice\",\"currency\":\"EUR\",\"balance\":-5}\n"
+	_, err := Import(context.Background(), store, strings.NewReader(input), Params{
+		Format:  FormatNDJSON,
+		Rejects: &rejects,
+	})
+	require.ErrorIs(t, err, errStore)
+	// 匯入失敗時讀到的退件還是要寫出去
+	require.Contains(t, rejects.String(), "balance -5 is negative")
+}
+
+func TestImportBlockedOwner(t *testing.T) {
+	store := &fakeStore{blocked: "mallory"}
+	var rejects bytes.Buffer
+
+	input := "{\"owner\":\"alice\",\"currency\":\"USD\",\"balance\":10}\n{\"owner\":\"mallory\",\"currency\":\"USD\",\"balance\":20}\n"
+	result, err := Import(context.Background(), store, strings.NewReader(input), Params{
+		Format:  FormatNDJSON,
+		Rejects: &rejects,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.Accepted)
+	require.Equal(t, int64(1), result.Rejected)
+	// 退件檔記的是被擋下那一列原本的內容
+	require.Equal(t, "line,reason,record\n2,owner blocked by screening,\"{\"\"owner\"\":\"\"mallory\"\",\"\"currency\"\":\"\"USD\"\",\"\"balance\"\":20}\"\n", rejects.String())
+}
@@ -0,0 +1,181 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+// Formats of the import files
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// ErrInvalidFile is returned when the file cannot be read as an import at all, invalid rows are rejected instead
+var ErrInvalidFile = errors.New("invalid import file")
+
+// csvHeader is the header expected on the first line of a CSV import
+var csvHeader = []string{"owner", "currency", "balance"}
+
+// FormatFromPath returns the format of the file at path from its extension
+func FormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON, nil
+	default:
+		return "", fmt.Errorf("%w: unknown file type %q", ErrInvalidFile, filepath.Ext(path))
+	}
+}
+
+// Reader streams the valid rows of an import file and writes the invalid ones to a RejectWriter.
+// It implements db.AccountImportSource.
+type Reader struct {
+	rejects *RejectWriter
+	next    func() (db.ImportedAccount, error)
+	// record 是 Next 上一次回傳的那一列在檔案裡的樣子，Reject 寫進退件檔
+	record string
+}
+
+var _ db.AccountImportSource = (*Reader)(nil)
+
+// NewReader creates a Reader of r in format; for CSV the header is read and checked right away
+func NewReader(r io.Reader, format string, rejects *RejectWriter) (*Reader, error) {
+	reader := &Reader{rejects: rejects}
+	switch format {
+	case FormatCSV:
+		next, err := reader.csvRows(r)
+		if err != nil {
+			return nil, err
+		}
+		reader.next = next
+	case FormatNDJSON:
+		reader.next = reader.ndjsonRows(r)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidFile, format)
+	}
+	return reader, nil
+}
+
+// Next returns the next valid row, or io.EOF after the last one
+func (reader *Reader) Next() (db.ImportedAccount, error) {
+	return reader.next()
+}
+
+// Reject writes row, the last one returned by Next, to the reject file with reason
+func (reader *Reader) Reject(row db.ImportedAccount, reason string) error {
+	return reader.rejects.Reject(row.Line, reason, reader.record)
+}
+
+func (reader *Reader) csvRows(r io.Reader) (func() (db.ImportedAccount, error), error) {
+	records := csv.NewReader(r)
+	records.FieldsPerRecord = len(csvHeader)
+	records.ReuseRecord = true
+
+	header, err := records.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if strings.Join(header, ",") != strings.Join(csvHeader, ",") {
+		return nil, fmt.Errorf("%w: unexpected header %q", ErrInvalidFile, strings.Join(header, ","))
+	}
+
+	return func() (db.ImportedAccount, error) {
+		for {
+			record, err := records.Read()
+			if errors.Is(err, io.EOF) {
+				return db.ImportedAccount{}, io.EOF
+			}
+
+			// 欄位數不對或引號錯誤只退回這一列，csv.Reader 會從下一列繼續讀
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				reason := fmt.Sprintf("%v: %v", ErrInvalidRow, parseErr.Err)
+				if err := reader.rejects.Reject(int64(parseErr.StartLine), reason, strings.Join(record, ",")); err != nil {
+					return db.ImportedAccount{}, err
+				}
+				continue
+			}
+			if err != nil {
+				return db.ImportedAccount{}, err
+			}
+
+			line, _ := records.FieldPos(0)
+			row, err := validateRow(int64(line), record[0], record[1], record[2])
+			if err != nil {
+				if err := reader.rejects.Reject(int64(line), err.Error(), strings.Join(record, ",")); err != nil {
+					return db.ImportedAccount{}, err
+				}
+				continue
+			}
+			reader.record = strings.Join(record, ",")
+			return row, nil
+		}
+	}, nil
+}
+
+// ndjsonRow is one line of an NDJSON import: {"owner":"...","currency":"USD","balance":100}
+type ndjsonRow struct {
+	Owner    string      `json:"owner"`
+	Currency string      `json:"currency"`
+	Balance  json.Number `json:"balance"`
+}
+
+func (reader *Reader) ndjsonRows(r io.Reader) func() (db.ImportedAccount, error) {
+	lines := bufio.NewReader(r)
+	var line int64
+
+	return func() (db.ImportedAccount, error) {
+		for {
+			// 用 ReadBytes 而不是 bufio.Scanner，一列再長也不會超過緩衝區的上限
+			raw, err := lines.ReadBytes('\n')
+			if len(raw) == 0 && errors.Is(err, io.EOF) {
+				return db.ImportedAccount{}, io.EOF
+			}
+			if err != nil && !errors.Is(err, io.EOF) {
+				return db.ImportedAccount{}, err
+			}
+			line++
+
+			raw = bytes.TrimSpace(raw)
+			if len(raw) == 0 {
+				continue
+			}
+
+			row, err := decodeNDJSONRow(line, raw)
+			if err != nil {
+				if err := reader.rejects.Reject(line, err.Error(), string(raw)); err != nil {
+					return db.ImportedAccount{}, err
+				}
+				continue
+			}
+			reader.record = string(raw)
+			return row, nil
+		}
+	}
+}
+
+func decodeNDJSONRow(line int64, raw []byte) (db.ImportedAccount, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+
+	var row ndjsonRow
+	if err := decoder.Decode(&row); err != nil {
+		return db.ImportedAccount{}, fmt.Errorf("%w: %v", ErrInvalidRow, err)
+	}
+	if decoder.More() {
+		return db.ImportedAccount{}, fmt.Errorf("%w: more than one value on the line", ErrInvalidRow)
+	}
+	return validateRow(line, row.Owner, row.Currency, row.Balance.String())
+}
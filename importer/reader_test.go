@@ -0,0 +1,100 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, reader *Reader) []db.ImportedAccount {
+	var rows []db.ImportedAccount
+	for {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+}
+
+func TestReader(t *testing.T) {
+	testCases := []struct {
+		path        string
+		rows        []db.ImportedAccount
+		rejectLines []string
+	}{
+		{
+			path: "testdata/accounts.csv",
+			rows: []db.ImportedAccount{
+				{Line: 2, Owner: "alice", Currency: "USD", Balance: 1000},
+				{Line: 9, Owner: "frank", Currency: "CAD", Balance: 0},
+			},
+			rejectLines: []string{"3", "4", "5", "6", "7", "8"},
+		},
+		{
+			// 空白行不算一列，但行號照算
+			path: "testdata/accounts.ndjson",
+			rows: []db.ImportedAccount{
+				{Line: 1, Owner: "alice", Currency: "USD", Balance: 1000},
+				{Line: 9, Owner: "frank", Currency: "CAD", Balance: 0},
+			},
+			rejectLines: []string{"2", "4", "5", "6", "7", "8"},
+		},
+	}
+
+	for _, tc := range testCases {
+		format, err := FormatFromPath(tc.path)
+		require.NoError(t, err)
+		file, err := os.Open(tc.path)
+		require.NoError(t, err)
+		defer file.Close()
+
+		var out bytes.Buffer
+		rejects := NewRejectWriter(&out)
+		reader, err := NewReader(file, format, rejects)
+		require.NoError(t, err)
+
+		rows := readAll(t, reader)
+		require.NoError(t, rejects.Flush())
+
+		require.Equal(t, tc.rows, rows, tc.path)
+
+		records, err := csv.NewReader(&out).ReadAll()
+		require.NoError(t, err)
+		require.Equal(t, []string{"line", "reason", "record"}, records[0])
+		require.Len(t, records, len(tc.rejectLines)+1, tc.path)
+		require.Equal(t, int64(len(tc.rejectLines)), rejects.Count())
+		for i, line := range tc.rejectLines {
+			require.Equal(t, line, records[i+1][0], tc.path)
+			require.True(t, strings.HasPrefix(records[i+1][1], ErrInvalidRow.Error()), records[i+1][1])
+		}
+	}
+}
+
+func TestNewReaderInvalidFile(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		format string
+	}{
+		{"csv header", "owner,balance,currency\n", FormatCSV},
+		{"csv empty", "", FormatCSV},
+		{"format", "{}", "xml"},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewReader(strings.NewReader(tc.input), tc.format, NewRejectWriter(nil))
+		require.ErrorIs(t, err, ErrInvalidFile, tc.name)
+	}
+
+	_, err := FormatFromPath("accounts.xlsx")
+	require.ErrorIs(t, err, ErrInvalidFile)
+}
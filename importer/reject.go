@@ -0,0 +1,51 @@
+package importer
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// RejectWriter writes the rejected rows as CSV with the header line,reason,record
+type RejectWriter struct {
+	writer *csv.Writer
+	count  int64
+}
+
+// NewRejectWriter creates a RejectWriter writing to w, a nil w only counts the rejects
+func NewRejectWriter(w io.Writer) *RejectWriter {
+	rejects := &RejectWriter{}
+	if w != nil {
+		rejects.writer = csv.NewWriter(w)
+	}
+	return rejects
+}
+
+// Reject records that the row at line was rejected for reason, record is the row as read from the file
+func (rejects *RejectWriter) Reject(line int64, reason string, record string) error {
+	rejects.count++
+	if rejects.writer == nil {
+		return nil
+	}
+
+	if rejects.count == 1 {
+		if err := rejects.writer.Write([]string{"line", "reason", "record"}); err != nil {
+			return err
+		}
+	}
+	return rejects.writer.Write([]string{strconv.FormatInt(line, 10), reason, record})
+}
+
+// Count returns the number of rejected rows
+func (rejects *RejectWriter) Count() int64 {
+	return rejects.count
+}
+
+// Flush writes the buffered rejects to the underlying writer
+func (rejects *RejectWriter) Flush() error {
+	if rejects.writer == nil {
+		return nil
+	}
+	rejects.writer.Flush()
+	return rejects.writer.Error()
+}
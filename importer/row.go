@@ -0,0 +1,50 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/andyrestart9/bank/util"
+)
+
+// ErrInvalidRow is wrapped by the reasons a row is rejected for
+var ErrInvalidRow = errors.New("invalid row")
+
+// reservedOwnerPrefixes 是系統帳戶和匿名化之後的 owner，匯入的帳戶不能用
+var reservedOwnerPrefixes = []string{"system:", "anon:"}
+
+// validateRow checks the fields of the row at line and converts them to an imported account
+func validateRow(line int64, owner string, currency string, balance string) (db.ImportedAccount, error) {
+	owner = strings.TrimSpace(owner)
+	if owner == "" {
+		return db.ImportedAccount{}, fmt.Errorf("%w: owner is required", ErrInvalidRow)
+	}
+	for _, prefix := range reservedOwnerPrefixes {
+		if strings.HasPrefix(owner, prefix) {
+			return db.ImportedAccount{}, fmt.Errorf("%w: owner %q is reserved", ErrInvalidRow, owner)
+		}
+	}
+
+	currency = strings.TrimSpace(currency)
+	if !util.IsSupportedCurrency(currency) {
+		return db.ImportedAccount{}, fmt.Errorf("%w: unsupported currency %q", ErrInvalidRow, currency)
+	}
+
+	amount, err := strconv.ParseInt(strings.TrimSpace(balance), 10, 64)
+	if err != nil {
+		return db.ImportedAccount{}, fmt.Errorf("%w: balance %q is not an integer", ErrInvalidRow, balance)
+	}
+	if amount < 0 {
+		return db.ImportedAccount{}, fmt.Errorf("%w: balance %d is negative", ErrInvalidRow, amount)
+	}
+
+	return db.ImportedAccount{
+		Line:     line,
+		Owner:    owner,
+		Currency: currency,
+		Balance:  amount,
+	}, nil
+}
@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+// Message is an email with an HTML and a plain text version of the same content
+type Message struct {
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	HTML    string   `json:"html"`
+	Text    string   `json:"text"`
+}
+
+// EmailSender sends emails
+type EmailSender interface {
+	SendEmail(ctx context.Context, msg Message) error
+}
+
+// RecordingSender keeps every message in memory instead of sending it, for tests
+type RecordingSender struct {
+	mu       sync.Mutex
+	messages []Message
+	// Err is returned by SendEmail when it is not nil, the message is not recorded then
+	Err error
+}
+
+var _ EmailSender = (*RecordingSender)(nil)
+
+// SendEmail records msg
+func (sender *RecordingSender) SendEmail(ctx context.Context, msg Message) error {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	if sender.Err != nil {
+		return sender.Err
+	}
+	sender.messages = append(sender.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of the recorded messages
+func (sender *RecordingSender) Messages() []Message {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	return append([]Message{}, sender.messages...)
+}
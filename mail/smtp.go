@@ -0,0 +1,186 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig contains the settings of an SMTP server
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// FromName and FromAddress build the From header
+	FromName    string
+	FromAddress string
+}
+
+// SMTPSender sends emails through an SMTP server, upgrading the connection with STARTTLS when the server supports it
+type SMTPSender struct {
+	config SMTPConfig
+}
+
+var _ EmailSender = (*SMTPSender)(nil)
+
+// NewSMTPSender creates a new SMTPSender
+func NewSMTPSender(config SMTPConfig) *SMTPSender {
+	return &SMTPSender{config: config}
+}
+
+// SendEmail sends msg, the deadline of ctx bounds the whole SMTP conversation
+func (sender *SMTPSender) SendEmail(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("email has no recipient")
+	}
+
+	data, err := buildMessage(sender.from(), msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(sender.config.Host, strconv.Itoa(sender.config.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("cannot connect to smtp server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, sender.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: sender.config.Host}); err != nil {
+			return err
+		}
+	}
+
+	if sender.config.Username != "" {
+		auth := smtp.PlainAuth("", sender.config.Username, sender.config.Password, sender.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(sender.config.FromAddress); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (sender *SMTPSender) from() string {
+	return (&mail.Address{Name: sender.config.FromName, Address: sender.config.FromAddress}).String()
+}
+
+// buildMessage builds a multipart/alternative MIME message with a text part followed by an HTML part,
+// mail clients show the last part they can render
+func buildMessage(from string, msg Message, date time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+	for _, part := range parts {
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	messageID, err := newMessageID(from)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	// 標題可能有中文（例如戶名），要用 RFC 2047 編碼，不能直接放 UTF-8
+	headers := [][2]string{
+		{"From", from},
+		{"To", strings.Join(msg.To, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"Message-ID", messageID},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + writer.Boundary()},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", header[0], header[1])
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body.Bytes())
+
+	return buf.Bytes(), nil
+}
+
+func newMessageID(from string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	domain := "localhost"
+	if address, err := mail.ParseAddress(from); err == nil {
+		if _, host, ok := strings.Cut(address.Address, "@"); ok {
+			domain = host
+		}
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(buf), domain), nil
+}
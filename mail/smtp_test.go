@@ -0,0 +1,136 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer accepts a single plain text SMTP session and sends the received DATA on the returned channel
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		write := func(line string) { io.WriteString(conn, line+"\r\n") }
+		write("220 localhost ESMTP")
+
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				write("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				write("354 end with <CRLF>.<CRLF>")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				received <- data.String()
+				write("250 queued")
+			case strings.HasPrefix(cmd, "QUIT"):
+				write("221 bye")
+				return
+			default:
+				write("250 ok")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestSMTPSenderSendEmail(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+	host, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+
+	portNumber, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	sender := NewSMTPSender(SMTPConfig{
+		Host:        host,
+		Port:        portNumber,
+		FromName:    "Bank",
+		FromAddress: "noreply@bank.example",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = sender.SendEmail(ctx, Message{
+		To:      []string{"alice@example.com"},
+		Subject: "轉帳通知",
+		HTML:    "<p>Hello</p>",
+		Text:    "Hello",
+	})
+	require.NoError(t, err)
+
+	data := <-received
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	require.Equal(t, "轉帳通知", subject)
+	require.Equal(t, "alice@example.com", msg.Header.Get("To"))
+	require.Contains(t, msg.Header.Get("From"), "noreply@bank.example")
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	var contentTypes []string
+	var contents []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		// multipart.Reader 會自動解開 quoted-printable
+		content, err := io.ReadAll(part)
+		require.NoError(t, err)
+		contentTypes = append(contentTypes, part.Header.Get("Content-Type"))
+		contents = append(contents, string(content))
+	}
+	require.Equal(t, []string{"text/plain; charset=utf-8", "text/html; charset=utf-8"}, contentTypes)
+	require.Equal(t, []string{"Hello", "<p>Hello</p>"}, contents)
+}
+
+func TestSMTPSenderNoRecipient(t *testing.T) {
+	sender := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: 1})
+	require.Error(t, sender.SendEmail(context.Background(), Message{Subject: "hi"}))
+}
@@ -0,0 +1,77 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Email templates, each one has a <name>.txt file defining "<name>.subject" and a <name>.html file
+const (
+	TemplateVerifyEmail      = "verify_email"
+	TemplateTransferReceived = "transfer_received"
+	TemplateTransferSent     = "transfer_sent"
+	TemplateLowBalance       = "low_balance"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// HTML 版本用 html/template，會自動 escape 戶名等使用者輸入，避免被塞 HTML；純文字版本用 text/template
+var (
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*.html"))
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/*.txt"))
+)
+
+// VerifyEmailData is the data of the verify_email template
+type VerifyEmailData struct {
+	FullName  string
+	VerifyURL string
+}
+
+// TransferData is the data of the transfer_received and transfer_sent templates,
+// seen from the account of the recipient of the email
+type TransferData struct {
+	FullName              string
+	TransferID            int64
+	AccountID             int64
+	CounterpartyAccountID int64
+	Amount                int64
+	Balance               int64
+	Currency              string
+	CreatedAt             time.Time
+}
+
+// LowBalanceData is the data of the low_balance template
+type LowBalanceData struct {
+	FullName  string
+	AccountID int64
+	Balance   int64
+	Threshold int64
+	Currency  string
+}
+
+// Render renders the named template into a message sent to to
+func Render(name string, to []string, data any) (Message, error) {
+	var subject, text, html bytes.Buffer
+
+	if err := textTemplates.ExecuteTemplate(&subject, name+".subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := textTemplates.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return Message{}, err
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    text.String(),
+	}, nil
+}
@@ -0,0 +1,76 @@
+package mail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	transfer := TransferData{
+		FullName:              "<b>王小明</b>",
+		TransferID:            42,
+		AccountID:             1,
+		CounterpartyAccountID: 2,
+		Amount:                5000,
+		Balance:               12000,
+		Currency:              "USD",
+		CreatedAt:             time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		name    string
+		data    any
+		subject string
+		text    []string
+	}{
+		{
+			name:    TemplateVerifyEmail,
+			data:    VerifyEmailData{FullName: "Alice", VerifyURL: "https://bank.example/verify_email?email_id=1&secret_code=abc"},
+			subject: "Please verify your email address",
+			text:    []string{"Hello Alice", "https://bank.example/verify_email?email_id=1&secret_code=abc"},
+		},
+		{
+			name:    TemplateTransferReceived,
+			data:    transfer,
+			subject: "You received 5000 USD",
+			text:    []string{"Account #1 received 5000 USD from account #2", "Transfer ID: 42", "2024-01-02 03:04:05 UTC"},
+		},
+		{
+			name:    TemplateTransferSent,
+			data:    transfer,
+			subject: "You sent 5000 USD",
+			text:    []string{"Account #1 sent 5000 USD to account #2", "Balance: 12000 USD"},
+		},
+		{
+			name:    TemplateLowBalance,
+			data:    LowBalanceData{FullName: "Alice", AccountID: 1, Balance: 20, Threshold: 100, Currency: "EUR"},
+			subject: "Low balance on account #1",
+			text:    []string{"is 20 EUR, below your alert threshold of 100 EUR"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, err := Render(tc.name, []string{"alice@example.com"}, tc.data)
+			require.NoError(t, err)
+
+			require.Equal(t, []string{"alice@example.com"}, msg.To)
+			require.Equal(t, tc.subject, msg.Subject)
+			for _, text := range tc.text {
+				require.Contains(t, msg.Text, text)
+			}
+			require.NotEmpty(t, msg.HTML)
+			require.NotContains(t, msg.Text, "subject")
+		})
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	msg, err := Render(TemplateTransferReceived, []string{"bob@example.com"}, TransferData{FullName: "<b>王小明</b>"})
+	require.NoError(t, err)
+
+	require.Contains(t, msg.HTML, "&lt;b&gt;王小明&lt;/b&gt;")
+	require.Contains(t, msg.Text, "<b>王小明</b>")
+}
@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+// Publisher hands outbox events to downstream systems.
+// Publish must return nil only after the event has been accepted,
+// because the relay marks the event as delivered right after that.
+type Publisher interface {
+	Publish(ctx context.Context, event db.Outbox) error
+}
+
+// HandlerFunc handles a single outbox event published in-process
+type HandlerFunc func(ctx context.Context, event db.Outbox) error
+
+// AllEvents subscribes a handler to every event type
+const AllEvents = "*"
+
+// InProcessPublisher dispatches events to handlers registered in the same process
+type InProcessPublisher struct {
+	mu       sync.RWMutex
+	handlers map[string][]HandlerFunc
+}
+
+// NewInProcessPublisher creates a new InProcessPublisher without any handlers
+func NewInProcessPublisher() *InProcessPublisher {
+	return &InProcessPublisher{
+		handlers: make(map[string][]HandlerFunc),
+	}
+}
+
+// Subscribe registers handler for eventType, use AllEvents to receive every event
+func (publisher *InProcessPublisher) Subscribe(eventType string, handler HandlerFunc) {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	publisher.handlers[eventType] = append(publisher.handlers[eventType], handler)
+}
+
+// Publish calls every handler subscribed to the event type, stopping at the first error
+func (publisher *InProcessPublisher) Publish(ctx context.Context, event db.Outbox) error {
+	publisher.mu.RLock()
+	handlers := append([]HandlerFunc{}, publisher.handlers[event.EventType]...)
+	handlers = append(handlers, publisher.handlers[AllEvents]...)
+	publisher.mu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			return fmt.Errorf("handle %s event %d: %w", event.EventType, event.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// WriterPublisher writes every event as one JSON line to an io.Writer, e.g. a file or stdout
+type WriterPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterPublisher creates a new WriterPublisher writing to w
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+// NewStdoutPublisher creates a new WriterPublisher writing to stdout
+func NewStdoutPublisher() *WriterPublisher {
+	return NewWriterPublisher(os.Stdout)
+}
+
+// NewFilePublisher creates a new WriterPublisher appending to the file at path.
+// The caller is responsible for closing the returned file.
+func NewFilePublisher(path string) (*WriterPublisher, *os.File, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return NewWriterPublisher(file), file, nil
+}
+
+// Publish writes event as a JSON line and syncs the writer if it supports it
+func (publisher *WriterPublisher) Publish(ctx context.Context, event db.Outbox) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	if _, err := publisher.w.Write(data); err != nil {
+		return err
+	}
+
+	// 檔案要 Sync 落盤之後才算真的送出，否則 relay 標記已送達後程式當掉，事件就遺失了
+	if syncer, ok := publisher.w.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+
+	return nil
+}
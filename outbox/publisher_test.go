@@ -0,0 +1,80 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInProcessPublisher(t *testing.T) {
+	publisher := NewInProcessPublisher()
+
+	var got []string
+	publisher.Subscribe(db.EventTransferCreated, func(ctx context.Context, event db.Outbox) error {
+		got = append(got, "transfer")
+		return nil
+	})
+	publisher.Subscribe(AllEvents, func(ctx context.Context, event db.Outbox) error {
+		got = append(got, "all")
+		return nil
+	})
+
+	require.NoError(t, publisher.Publish(context.Background(), db.Outbox{ID: 1, EventType: db.EventTransferCreated}))
+	require.NoError(t, publisher.Publish(context.Background(), db.Outbox{ID: 2, EventType: db.EventAccountCreated}))
+	require.Equal(t, []string{"transfer", "all", "all"}, got)
+}
+
+func TestInProcessPublisherError(t *testing.T) {
+	publisher := NewInProcessPublisher()
+
+	errBoom := errors.New("boom")
+	publisher.Subscribe(AllEvents, func(ctx context.Context, event db.Outbox) error {
+		return errBoom
+	})
+
+	err := publisher.Publish(context.Background(), db.Outbox{ID: 1, EventType: db.EventAccountCreated})
+	require.ErrorIs(t, err, errBoom)
+}
+
+func TestWriterPublisher(t *testing.T) {
+	var buf bytes.Buffer
+	publisher := NewWriterPublisher(&buf)
+
+	for i := int64(1); i <= 3; i++ {
+		err := publisher.Publish(context.Background(), db.Outbox{
+			ID:        i,
+			EventType: db.EventAccountCreated,
+			Payload:   json.RawMessage(`{"id":1}`),
+		})
+		require.NoError(t, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+
+	var event db.Outbox
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &event))
+	require.Equal(t, int64(3), event.ID)
+	require.JSONEq(t, `{"id":1}`, string(event.Payload))
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	publisher, file, err := NewFilePublisher(path)
+	require.NoError(t, err)
+	require.NoError(t, publisher.Publish(context.Background(), db.Outbox{ID: 1, EventType: db.EventAccountCreated, Payload: json.RawMessage(`{}`)}))
+	require.NoError(t, file.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"event_type":"account.created"`)
+}
@@ -0,0 +1,93 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+const (
+	defaultBatchSize    = 100
+	defaultPollInterval = time.Second
+)
+
+// Source provides pending outbox events, *db.Store implements it
+type Source interface {
+	ProcessOutboxTx(ctx context.Context, limit int32, handle func(db.Outbox) error) (int, error)
+}
+
+var _ Source = (*db.Store)(nil)
+
+// RelayConfig contains the tuning parameters of a Relay
+type RelayConfig struct {
+	// BatchSize is the maximum number of events delivered in one database transaction
+	BatchSize int32
+	// PollInterval is how long the relay waits after the outbox has been drained
+	PollInterval time.Duration
+}
+
+// Relay reads undelivered outbox events in order and hands them to a Publisher.
+// Events are marked as delivered only after Publish returns nil, so delivery is at-least-once
+// and publishers should deduplicate by event ID.
+type Relay struct {
+	source    Source
+	publisher Publisher
+	config    RelayConfig
+}
+
+// NewRelay creates a new Relay, zero values in config are replaced by defaults
+func NewRelay(source Source, publisher Publisher, config RelayConfig) *Relay {
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultPollInterval
+	}
+
+	return &Relay{
+		source:    source,
+		publisher: publisher,
+		config:    config,
+	}
+}
+
+// RelayOnce delivers pending events batch by batch until the outbox is drained or publishing fails.
+// It returns the number of events delivered.
+func (relay *Relay) RelayOnce(ctx context.Context) (int, error) {
+	total := 0
+
+	for {
+		delivered, err := relay.source.ProcessOutboxTx(ctx, relay.config.BatchSize, func(event db.Outbox) error {
+			return relay.publisher.Publish(ctx, event)
+		})
+		total += delivered
+		if err != nil {
+			return total, err
+		}
+
+		if delivered < int(relay.config.BatchSize) {
+			return total, nil
+		}
+	}
+}
+
+// Run keeps relaying events every PollInterval until ctx is cancelled
+func (relay *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(relay.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := relay.RelayOnce(ctx); err != nil && ctx.Err() == nil {
+			// 送不出去的事件留在 outbox 裡，下一輪會從同一個事件重試
+			log.Printf("outbox relay: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
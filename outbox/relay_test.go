@@ -0,0 +1,94 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSource mimics Store.ProcessOutboxTx on an in-memory outbox
+type fakeSource struct {
+	events    []db.Outbox
+	delivered map[int64]bool
+}
+
+func newFakeSource(n int) *fakeSource {
+	source := &fakeSource{delivered: make(map[int64]bool)}
+	for i := 1; i <= n; i++ {
+		source.events = append(source.events, db.Outbox{ID: int64(i), EventType: db.EventAccountCreated})
+	}
+	return source
+}
+
+func (source *fakeSource) ProcessOutboxTx(ctx context.Context, limit int32, handle func(db.Outbox) error) (int, error) {
+	delivered := 0
+	for _, event := range source.events {
+		if delivered == int(limit) {
+			break
+		}
+		if source.delivered[event.ID] {
+			continue
+		}
+		if err := handle(event); err != nil {
+			return delivered, err
+		}
+		source.delivered[event.ID] = true
+		delivered++
+	}
+	return delivered, nil
+}
+
+// flakyPublisher fails once on failOn, then accepts everything
+type flakyPublisher struct {
+	failOn    int64
+	failed    bool
+	published []int64
+}
+
+func (publisher *flakyPublisher) Publish(ctx context.Context, event db.Outbox) error {
+	if event.ID == publisher.failOn && !publisher.failed {
+		publisher.failed = true
+		return errors.New("downstream unavailable")
+	}
+	publisher.published = append(publisher.published, event.ID)
+	return nil
+}
+
+func TestRelayOnce(t *testing.T) {
+	source := newFakeSource(25)
+	publisher := &flakyPublisher{}
+	relay := NewRelay(source, publisher, RelayConfig{BatchSize: 10})
+
+	n, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 25, n)
+	require.Len(t, publisher.published, 25)
+
+	for i, id := range publisher.published {
+		require.Equal(t, int64(i+1), id)
+	}
+
+	n, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestRelayOnceRetriesInOrder(t *testing.T) {
+	source := newFakeSource(5)
+	publisher := &flakyPublisher{failOn: 3}
+	relay := NewRelay(source, publisher, RelayConfig{})
+
+	n, err := relay.RelayOnce(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []int64{1, 2}, publisher.published)
+
+	// 失敗的事件下一輪會第一個被重送，後面的事件不會跳過它先送出
+	n, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []int64{1, 2, 3, 4, 5}, publisher.published)
+}
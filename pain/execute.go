@@ -0,0 +1,243 @@
+package pain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/andyrestart9/bank/util"
+)
+
+// Statuses of the pain.002 status report
+const (
+	StatusAccepted = "ACSC"
+	StatusRejected = "RJCT"
+	StatusPending  = "PDNG"
+	// StatusPartial is the status of a group or a PmtInf whose transactions do not all have the same status
+	StatusPartial = "PART"
+)
+
+// Store is the part of db.Store used by Execute
+type Store interface {
+	GetAccount(ctx context.Context, id int64) (db.Account, error)
+	GetAccountByNumber(ctx context.Context, accountNumber string) (db.Account, error)
+	PaymentInstructionTx(ctx context.Context, arg db.PaymentInstructionTxParams) (db.PaymentInstructionTxResult, error)
+}
+
+// Report is the outcome of an initiation, written as a pain.002 status report
+type Report struct {
+	OriginalMessageID    string          `json:"original_message_id"`
+	OriginalCreatedAt    string          `json:"original_created_at"`
+	NumberOfTransactions string          `json:"number_of_transactions"`
+	ControlSum           string          `json:"control_sum"`
+	Status               string          `json:"status"`
+	Payments             []PaymentStatus `json:"payments"`
+}
+
+// PaymentStatus is the outcome of one PmtInf
+type PaymentStatus struct {
+	OriginalID           string              `json:"original_id"`
+	NumberOfTransactions string              `json:"number_of_transactions"`
+	ControlSum           string              `json:"control_sum"`
+	Status               string              `json:"status"`
+	Transactions         []TransactionStatus `json:"transactions"`
+}
+
+// TransactionStatus is the outcome of one credit transfer
+type TransactionStatus struct {
+	InstructionID string `json:"instruction_id"`
+	EndToEndID    string `json:"end_to_end_id"`
+	Status        string `json:"status"`
+	// ReasonCode is an ISO 20022 ExternalStatusReason1Code, set when rejected
+	ReasonCode   string `json:"reason_code"`
+	Reason       string `json:"reason"`
+	TransferID   int64  `json:"transfer_id"`
+	ReviewCaseID int64  `json:"review_case_id"`
+}
+
+// Execute runs every credit transfer of initiation with PaymentInstructionTx, one transfer per transaction,
+// and reports the status of each one. Executing the same file again replays the recorded outcomes.
+// An error is returned only when the store fails; the transactions executed before it stay executed,
+// so the file can simply be executed again.
+func Execute(ctx context.Context, store Store, initiation *Initiation) (*Report, error) {
+	header := initiation.GroupHeader
+	report := &Report{
+		OriginalMessageID:    header.MessageID,
+		OriginalCreatedAt:    header.CreatedAt,
+		NumberOfTransactions: header.NumberOfTransactions,
+		ControlSum:           header.ControlSum,
+	}
+
+	var statuses []string
+	for _, payment := range initiation.Payments {
+		paymentStatus := PaymentStatus{
+			OriginalID:           payment.ID,
+			NumberOfTransactions: payment.NumberOfTransactions,
+			ControlSum:           payment.ControlSum,
+		}
+
+		debtor, reason, err := resolveAccount(ctx, store, payment.DebtorAccount)
+		if err != nil {
+			return nil, err
+		}
+
+		var transactionStatuses []string
+		for _, transaction := range payment.Transactions {
+			var status TransactionStatus
+			if reason != "" {
+				status = rejected(transaction, db.ReasonIncorrectAccount, "debtor account: "+reason)
+			} else {
+				status, err = executeTransaction(ctx, store, header.MessageID, payment.ID, debtor, transaction)
+				if err != nil {
+					return nil, err
+				}
+			}
+			paymentStatus.Transactions = append(paymentStatus.Transactions, status)
+			transactionStatuses = append(transactionStatuses, status.Status)
+		}
+
+		paymentStatus.Status = combinedStatus(transactionStatuses)
+		report.Payments = append(report.Payments, paymentStatus)
+		statuses = append(statuses, transactionStatuses...)
+	}
+
+	report.Status = combinedStatus(statuses)
+	return report, nil
+}
+
+func executeTransaction(ctx context.Context, store Store, messageID string, paymentID string, debtor db.Account, transaction CreditTransfer) (TransactionStatus, error) {
+	amount, err := minorUnits(transaction.Amount.Value)
+	if err != nil {
+		return rejected(transaction, db.ReasonInvalidAmount, err.Error()), nil
+	}
+	if amount == 0 {
+		return rejected(transaction, db.ReasonZeroAmount, "amount is zero"), nil
+	}
+
+	if transaction.CreditorAccount == nil {
+		return rejected(transaction, db.ReasonIncorrectAccount, "creditor account is missing"), nil
+	}
+	creditor, reason, err := resolveAccount(ctx, store, *transaction.CreditorAccount)
+	if err != nil {
+		return TransactionStatus{}, err
+	}
+	if reason != "" {
+		return rejected(transaction, db.ReasonIncorrectAccount, "creditor account: "+reason), nil
+	}
+	if creditor.ID == debtor.ID {
+		return rejected(transaction, db.ReasonTransactionForbidden, "debtor and creditor account are the same"), nil
+	}
+
+	currency := transaction.Amount.Currency
+	if !util.IsSupportedCurrency(currency) || currency != debtor.Currency || currency != creditor.Currency {
+		return rejected(transaction, db.ReasonNotAllowedCurrency,
+			fmt.Sprintf("amount in %s, debtor account in %s, creditor account in %s", currency, debtor.Currency, creditor.Currency)), nil
+	}
+
+	result, err := store.PaymentInstructionTx(ctx, db.PaymentInstructionTxParams{
+		MessageID:     messageID,
+		PaymentInfoID: paymentID,
+		InstructionID: transaction.InstructionID,
+		EndToEndID:    transaction.EndToEndID,
+		Transfer: db.TransferTxParams{
+			FromAccountID: debtor.ID,
+			ToAccountID:   creditor.ID,
+			Amount:        amount,
+		},
+	})
+	if errors.Is(err, db.ErrEndToEndIDConflict) {
+		return rejected(transaction, db.ReasonDuplication, "EndToEndId already used by a different transfer"), nil
+	}
+	if err != nil {
+		return TransactionStatus{}, err
+	}
+
+	instruction := result.Instruction
+	status := TransactionStatus{
+		InstructionID: transaction.InstructionID,
+		EndToEndID:    transaction.EndToEndID,
+		ReasonCode:    instruction.ReasonCode,
+		Reason:        instruction.Reason,
+		TransferID:    instruction.TransferID.Int64,
+		ReviewCaseID:  instruction.ReviewCaseID.Int64,
+	}
+	switch instruction.Status {
+	case db.PaymentAccepted:
+		status.Status = StatusAccepted
+	case db.PaymentPending:
+		status.Status = StatusPending
+		status.Reason = "held for review, case " + strconv.FormatInt(instruction.ReviewCaseID.Int64, 10)
+	default:
+		status.Status = StatusRejected
+	}
+	return status, nil
+}
+
+// resolveAccount 找出 account 指的帳戶：IBAN 是本行的帳號，Othr 是帳戶 id；找不到時 reason 說明原因，err 只有資料庫出錯才會有
+func resolveAccount(ctx context.Context, store Store, account Account) (db.Account, string, error) {
+	var found db.Account
+	var err error
+	var name string
+
+	switch {
+	case account.IBAN != "":
+		number := util.NormalizeAccountNumber(account.IBAN)
+		name = "account " + number
+		if err := util.ValidateIBAN(number); err != nil {
+			return db.Account{}, fmt.Sprintf("IBAN %s: %v", account.IBAN, err), nil
+		}
+		if !util.IsBankAccountNumber(number) {
+			return db.Account{}, "IBAN " + account.IBAN + " is not an account of the bank", nil
+		}
+		found, err = store.GetAccountByNumber(ctx, number)
+	case account.Other != "":
+		id, parseErr := strconv.ParseInt(account.Other, 10, 64)
+		if parseErr != nil || id <= 0 {
+			return db.Account{}, fmt.Sprintf("%q is not an account id", account.Other), nil
+		}
+		name = fmt.Sprintf("account %d", id)
+		found, err = store.GetAccount(ctx, id)
+	default:
+		return db.Account{}, "no account identifier", nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Account{}, name + " does not exist", nil
+	}
+	if err != nil {
+		return db.Account{}, "", err
+	}
+	if db.IsSystemAccount(found) {
+		return db.Account{}, name + " is not a customer account", nil
+	}
+	if account.Currency != "" && account.Currency != found.Currency {
+		return db.Account{}, fmt.Sprintf("%s is in %s, not %s", name, found.Currency, account.Currency), nil
+	}
+	return found, "", nil
+}
+
+func rejected(transaction CreditTransfer, reasonCode string, reason string) TransactionStatus {
+	return TransactionStatus{
+		InstructionID: transaction.InstructionID,
+		EndToEndID:    transaction.EndToEndID,
+		Status:        StatusRejected,
+		ReasonCode:    reasonCode,
+		Reason:        reason,
+	}
+}
+
+// combinedStatus is the status shared by all statuses, or StatusPartial when they differ
+func combinedStatus(statuses []string) string {
+	if len(statuses) == 0 {
+		return StatusRejected
+	}
+	for _, status := range statuses[1:] {
+		if status != statuses[0] {
+			return StatusPartial
+		}
+	}
+	return statuses[0]
+}
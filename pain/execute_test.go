@@ -0,0 +1,206 @@
+package pain
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// requireGolden compares got with testdata/name, with -update it rewrites the file instead
+func requireGolden(t *testing.T, name string, got []byte) {
+	path := "testdata/" + name
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(got))
+}
+
+// validateXML 用 xmllint 依 schema 驗證 document，沒有裝 xmllint 時跳過
+func validateXML(t *testing.T, schema string, document []byte) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint is not installed")
+	}
+
+	path := filepath.Join(t.TempDir(), "document.xml")
+	require.NoError(t, os.WriteFile(path, document, 0o644))
+
+	output, err := exec.Command(xmllint, "--noout", "--schema", schema, path).CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+// fakeStore 依 end-to-end id 決定 PaymentInstructionTx 的結果，記下收到的參數
+type fakeStore struct {
+	accounts     map[int64]db.Account
+	instructions map[string]db.PaymentInstruction
+	calls        []db.PaymentInstructionTxParams
+}
+
+func (store *fakeStore) GetAccount(ctx context.Context, id int64) (db.Account, error) {
+	account, ok := store.accounts[id]
+	if !ok {
+		return db.Account{}, sql.ErrNoRows
+	}
+	return account, nil
+}
+
+func (store *fakeStore) GetAccountByNumber(ctx context.Context, accountNumber string) (db.Account, error) {
+	for _, account := range store.accounts {
+		if account.AccountNumber == accountNumber {
+			return account, nil
+		}
+	}
+	return db.Account{}, sql.ErrNoRows
+}
+
+func (store *fakeStore) PaymentInstructionTx(ctx context.Context, arg db.PaymentInstructionTxParams) (db.PaymentInstructionTxResult, error) {
+	store.calls = append(store.calls, arg)
+	return db.PaymentInstructionTxResult{Instruction: store.instructions[arg.EndToEndID]}, nil
+}
+
+func newFakeStore() *fakeStore {
+	accounts := map[int64]db.Account{}
+	for _, account := range []db.Account{
+		{ID: 10, Owner: "Acme Trading Ltd", Currency: "EUR"},
+		{ID: 20, Owner: "Supplier One", Currency: "EUR"},
+		{ID: 21, Owner: "Dollar Account Inc", Currency: "USD"},
+		{ID: 22, Owner: "Big Invoice GmbH", Currency: "EUR"},
+		{ID: 23, Owner: "Fraction Ltd", Currency: "EUR"},
+		{ID: 24, Owner: "New Payee", Currency: "EUR"},
+	} {
+		accounts[account.ID] = account
+	}
+
+	return &fakeStore{
+		accounts: accounts,
+		instructions: map[string]db.PaymentInstruction{
+			"E2E-0001": {Status: db.PaymentAccepted, TransferID: sql.NullInt64{Int64: 900, Valid: true}},
+			"E2E-0004": {Status: db.PaymentRejected, ReasonCode: db.ReasonInsufficientFunds, Reason: "insufficient funds: account 10 would go below its overdraft limit"},
+			"E2E-0006": {Status: db.PaymentPending, ReviewCaseID: sql.NullInt64{Int64: 7, Valid: true}},
+		},
+	}
+}
+
+func TestExecute(t *testing.T) {
+	file, err := os.Open("testdata/pain001.xml")
+	require.NoError(t, err)
+	defer file.Close()
+
+	initiation, err := Parse(file)
+	require.NoError(t, err)
+
+	store := newFakeStore()
+	report, err := Execute(context.Background(), store, initiation)
+	require.NoError(t, err)
+
+	// 只有帳戶、幣別、金額都檢查過的才送進 PaymentInstructionTx
+	require.Len(t, store.calls, 3)
+	require.Equal(t, db.PaymentInstructionTxParams{
+		MessageID:     "TREASURY-20260415-001",
+		PaymentInfoID: "PMT-EUR-1",
+		InstructionID: "INSTR-1",
+		EndToEndID:    "E2E-0001",
+		Transfer:      db.TransferTxParams{FromAccountID: 10, ToAccountID: 20, Amount: 15000},
+	}, store.calls[0])
+
+	require.Equal(t, StatusPartial, report.Status)
+	require.Equal(t, StatusPartial, report.Payments[0].Status)
+	require.Equal(t, StatusRejected, report.Payments[1].Status)
+
+	var codes []string
+	for _, transaction := range report.Payments[0].Transactions {
+		codes = append(codes, transaction.Status+" "+transaction.ReasonCode)
+	}
+	require.Equal(t, []string{"ACSC ", "RJCT AM03", "RJCT AC01", "RJCT AM04", "RJCT AM12", "PDNG "}, codes)
+	require.Equal(t, db.ReasonIncorrectAccount, report.Payments[1].Transactions[0].ReasonCode)
+
+	var out bytes.Buffer
+	err = WriteStatusReport(&out, report, time.Date(2026, time.April, 15, 8, 5, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	requireGolden(t, "pain002.golden.xml", out.Bytes())
+	validateXML(t, "testdata/pain.002.001.03.xsd", out.Bytes())
+}
+
+func TestExecuteSystemAccount(t *testing.T) {
+	store := newFakeStore()
+	store.accounts[30] = db.Account{ID: 30, Owner: "system:clearing", Currency: "EUR"}
+
+	initiation := &Initiation{
+		GroupHeader: GroupHeader{MessageID: "M1", NumberOfTransactions: "1"},
+		Payments: []PaymentInformation{{
+			ID:            "P1",
+			Method:        "TRF",
+			DebtorAccount: Account{Other: "10"},
+			Transactions: []CreditTransfer{{
+				EndToEndID:      "E1",
+				Amount:          Amount{Currency: "EUR", Value: "1.00"},
+				CreditorAccount: &Account{Other: "30"},
+			}},
+		}},
+	}
+
+	report, err := Execute(context.Background(), store, initiation)
+	require.NoError(t, err)
+	require.Empty(t, store.calls)
+	require.Equal(t, StatusRejected, report.Status)
+	require.Equal(t, db.ReasonIncorrectAccount, report.Payments[0].Transactions[0].ReasonCode)
+}
+
+func TestExecuteIBAN(t *testing.T) {
+	store := newFakeStore()
+	store.accounts[25] = db.Account{ID: 25, Owner: "IBAN Payee", Currency: "EUR", AccountNumber: "TW71ANDY000000000025"}
+
+	transfer := func(endToEndID string, iban string) CreditTransfer {
+		return CreditTransfer{
+			EndToEndID:      endToEndID,
+			Amount:          Amount{Currency: "EUR", Value: "1.00"},
+			CreditorAccount: &Account{IBAN: iban},
+		}
+	}
+	initiation := &Initiation{
+		GroupHeader: GroupHeader{MessageID: "M1", NumberOfTransactions: "4"},
+		Payments: []PaymentInformation{{
+			ID:            "P1",
+			Method:        "TRF",
+			DebtorAccount: Account{Other: "10"},
+			Transactions: []CreditTransfer{
+				// 人手輸入的帳號有空白和小寫
+				transfer("E1", "tw71 andy 0000 0000 0025"),
+				transfer("E2", "TW13ANDY000000000099"),
+				transfer("E3", "TW14ANDY000000000025"),
+				transfer("E4", "DE89370400440532013000"),
+			},
+		}},
+	}
+
+	report, err := Execute(context.Background(), store, initiation)
+	require.NoError(t, err)
+	require.Len(t, store.calls, 1)
+	require.Equal(t, int64(25), store.calls[0].Transfer.ToAccountID)
+
+	var reasons []string
+	for _, transaction := range report.Payments[0].Transactions[1:] {
+		require.Equal(t, db.ReasonIncorrectAccount, transaction.ReasonCode)
+		reasons = append(reasons, transaction.Reason)
+	}
+	require.Equal(t, []string{
+		"creditor account: account TW13ANDY000000000099 does not exist",
+		"creditor account: IBAN TW14ANDY000000000025: invalid account number: check digits do not match",
+		"creditor account: IBAN DE89370400440532013000 is not an account of the bank",
+	}, reasons)
+}
@@ -0,0 +1,180 @@
+package pain
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"strconv"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+// Pain001Namespace is the namespace of the pain.001.001.03 customer credit transfer initiation
+const Pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
+
+// ErrInvalidMessage is returned when a file cannot be read as a pain.001 message, no transaction of it is executed
+var ErrInvalidMessage = errors.New("invalid pain.001 message")
+
+// Initiation is a parsed pain.001.001.03 document; elements the bank does not use are skipped
+type Initiation struct {
+	XMLName     xml.Name             `xml:"urn:iso:std:iso:20022:tech:xsd:pain.001.001.03 Document"`
+	GroupHeader GroupHeader          `xml:"CstmrCdtTrfInitn>GrpHdr"`
+	Payments    []PaymentInformation `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+// GroupHeader is the GrpHdr of an initiation
+type GroupHeader struct {
+	MessageID            string `xml:"MsgId"`
+	CreatedAt            string `xml:"CreDtTm"`
+	NumberOfTransactions string `xml:"NbOfTxs"`
+	ControlSum           string `xml:"CtrlSum"`
+	InitiatingParty      string `xml:"InitgPty>Nm"`
+}
+
+// PaymentInformation is one PmtInf block: the credit transfers paid from one debtor account
+type PaymentInformation struct {
+	ID                     string           `xml:"PmtInfId"`
+	Method                 string           `xml:"PmtMtd"`
+	NumberOfTransactions   string           `xml:"NbOfTxs"`
+	ControlSum             string           `xml:"CtrlSum"`
+	RequestedExecutionDate string           `xml:"ReqdExctnDt"`
+	DebtorName             string           `xml:"Dbtr>Nm"`
+	DebtorAccount          Account          `xml:"DbtrAcct"`
+	Transactions           []CreditTransfer `xml:"CdtTrfTxInf"`
+}
+
+// Account identifies an account by IBAN, which is the account number for the accounts of the bank, or by another identifier, which is the account id
+type Account struct {
+	IBAN     string `xml:"Id>IBAN"`
+	Other    string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy"`
+}
+
+// CreditTransfer is one CdtTrfTxInf
+type CreditTransfer struct {
+	InstructionID   string   `xml:"PmtId>InstrId"`
+	EndToEndID      string   `xml:"PmtId>EndToEndId"`
+	Amount          Amount   `xml:"Amt>InstdAmt"`
+	CreditorName    string   `xml:"Cdtr>Nm"`
+	CreditorAccount *Account `xml:"CdtrAcct"`
+	Remittance      []string `xml:"RmtInf>Ustrd"`
+}
+
+// Amount is an instructed amount in its currency
+type Amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// Parse reads a pain.001.001.03 document and checks its structure and its control totals
+func Parse(r io.Reader) (*Initiation, error) {
+	var initiation Initiation
+	if err := xml.NewDecoder(r).Decode(&initiation); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if err := initiation.validate(); err != nil {
+		return nil, err
+	}
+	return &initiation, nil
+}
+
+func (initiation *Initiation) validate() error {
+	header := initiation.GroupHeader
+	if header.MessageID == "" {
+		return fmt.Errorf("%w: MsgId is required", ErrInvalidMessage)
+	}
+	if len(initiation.Payments) == 0 {
+		return fmt.Errorf("%w: no PmtInf", ErrInvalidMessage)
+	}
+
+	var count int
+	total := new(big.Rat)
+	for _, payment := range initiation.Payments {
+		if payment.ID == "" {
+			return fmt.Errorf("%w: PmtInfId is required", ErrInvalidMessage)
+		}
+		// 只處理轉帳，支票（CHK）和 TRA 不收
+		if payment.Method != "TRF" {
+			return fmt.Errorf("%w: PmtInf %s has payment method %q", ErrInvalidMessage, payment.ID, payment.Method)
+		}
+		if len(payment.Transactions) == 0 {
+			return fmt.Errorf("%w: PmtInf %s has no CdtTrfTxInf", ErrInvalidMessage, payment.ID)
+		}
+
+		sum := new(big.Rat)
+		for _, transaction := range payment.Transactions {
+			if transaction.EndToEndID == "" {
+				return fmt.Errorf("%w: PmtInf %s has a transaction without EndToEndId", ErrInvalidMessage, payment.ID)
+			}
+			// 重送檔案時靠 EndToEndId 認出已經執行過的交易，NOTPROVIDED 認不出來
+			if transaction.EndToEndID == db.EndToEndIDNotProvided {
+				return fmt.Errorf("%w: PmtInf %s has a transaction with EndToEndId %s, every transaction needs its own", ErrInvalidMessage, payment.ID, db.EndToEndIDNotProvided)
+			}
+			amount, err := parseDecimal(transaction.Amount.Value)
+			if err != nil {
+				return fmt.Errorf("%w: EndToEndId %s: %v", ErrInvalidMessage, transaction.EndToEndID, err)
+			}
+			sum.Add(sum, amount)
+		}
+
+		if err := checkTotals("PmtInf "+payment.ID, payment.NumberOfTransactions, payment.ControlSum, len(payment.Transactions), sum); err != nil {
+			return err
+		}
+		count += len(payment.Transactions)
+		total.Add(total, sum)
+	}
+
+	return checkTotals("GrpHdr", header.NumberOfTransactions, header.ControlSum, count, total)
+}
+
+// checkTotals 比對 NbOfTxs 和 CtrlSum；PmtInf 的可以省略，GrpHdr 的 NbOfTxs 一定要有
+func checkTotals(block string, numberOfTransactions string, controlSum string, count int, sum *big.Rat) error {
+	if numberOfTransactions != "" || block == "GrpHdr" {
+		n, err := strconv.Atoi(numberOfTransactions)
+		if err != nil || n != count {
+			return fmt.Errorf("%w: %s NbOfTxs %q but %d transactions", ErrInvalidMessage, block, numberOfTransactions, count)
+		}
+	}
+	if controlSum != "" {
+		want, err := parseDecimal(controlSum)
+		if err != nil {
+			return fmt.Errorf("%w: %s CtrlSum: %v", ErrInvalidMessage, block, err)
+		}
+		if want.Cmp(sum) != 0 {
+			return fmt.Errorf("%w: %s CtrlSum %s but the amounts add up to %s", ErrInvalidMessage, block, controlSum, sum.FloatString(2))
+		}
+	}
+	return nil
+}
+
+var decimalPattern = regexp.MustCompile(`^[0-9]{1,18}(\.[0-9]{1,17})?$`)
+
+// parseDecimal 只接受 ISO 的 decimal 寫法，big.Rat.SetString 本身還會收 1/2 和 1e3
+func parseDecimal(value string) (*big.Rat, error) {
+	if !decimalPattern.MatchString(value) {
+		return nil, fmt.Errorf("%q is not a decimal amount", value)
+	}
+	amount, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a decimal amount", value)
+	}
+	return amount, nil
+}
+
+var hundred = big.NewRat(100, 1)
+
+// minorUnits converts a decimal amount to cents, all the supported currencies have two decimals
+func minorUnits(value string) (int64, error) {
+	amount, err := parseDecimal(value)
+	if err != nil {
+		return 0, err
+	}
+	amount.Mul(amount, hundred)
+	if !amount.IsInt() || !amount.Num().IsInt64() {
+		return 0, fmt.Errorf("%s has more than two decimals or is too large", value)
+	}
+	return amount.Num().Int64(), nil
+}
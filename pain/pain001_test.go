@@ -0,0 +1,101 @@
+package pain
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	file, err := os.Open("testdata/pain001.xml")
+	require.NoError(t, err)
+	defer file.Close()
+
+	initiation, err := Parse(file)
+	require.NoError(t, err)
+	require.Equal(t, "TREASURY-20260415-001", initiation.GroupHeader.MessageID)
+	require.Equal(t, "Acme Trading Ltd", initiation.GroupHeader.InitiatingParty)
+	require.Len(t, initiation.Payments, 2)
+
+	payment := initiation.Payments[0]
+	require.Equal(t, "PMT-EUR-1", payment.ID)
+	require.Equal(t, Account{Other: "10", Currency: "EUR"}, payment.DebtorAccount)
+	require.Len(t, payment.Transactions, 6)
+	require.Equal(t, CreditTransfer{
+		InstructionID:   "INSTR-1",
+		EndToEndID:      "E2E-0001",
+		Amount:          Amount{Currency: "EUR", Value: "150.00"},
+		CreditorName:    "Supplier One",
+		CreditorAccount: &Account{Other: "20"},
+		Remittance:      []string{"Invoice 2026-117"},
+	}, payment.Transactions[0])
+
+	require.Equal(t, "DE89370400440532013000", initiation.Payments[1].DebtorAccount.IBAN)
+}
+
+// painDocument 包出一份只有一個 PmtInf 的 pain.001
+func painDocument(header string, payment string) string {
+	return `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"><CstmrCdtTrfInitn>` +
+		`<GrpHdr>` + header + `</GrpHdr><PmtInf>` + payment + `</PmtInf></CstmrCdtTrfInitn></Document>`
+}
+
+func TestParseInvalid(t *testing.T) {
+	transaction := `<CdtTrfTxInf><PmtId><EndToEndId>E1</EndToEndId></PmtId><Amt><InstdAmt Ccy="EUR">1.50</InstdAmt></Amt></CdtTrfTxInf>`
+	validHeader := `<MsgId>M1</MsgId><NbOfTxs>1</NbOfTxs>`
+	validPayment := `<PmtInfId>P1</PmtInfId><PmtMtd>TRF</PmtMtd>` + transaction
+
+	// 先確認這份是合法的，下面每個案例只改一個地方
+	_, err := Parse(strings.NewReader(painDocument(validHeader, validPayment)))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		document string
+	}{
+		{"not xml", "<Document"},
+		{"namespace", strings.Replace(painDocument(validHeader, validPayment), "pain.001.001.03", "pain.001.001.09", 1)},
+		{"no MsgId", painDocument(`<NbOfTxs>1</NbOfTxs>`, validPayment)},
+		{"NbOfTxs", painDocument(`<MsgId>M1</MsgId><NbOfTxs>2</NbOfTxs>`, validPayment)},
+		{"CtrlSum", painDocument(`<MsgId>M1</MsgId><NbOfTxs>1</NbOfTxs><CtrlSum>1.49</CtrlSum>`, validPayment)},
+		{"PmtInf CtrlSum", painDocument(validHeader, `<PmtInfId>P1</PmtInfId><PmtMtd>TRF</PmtMtd><CtrlSum>2</CtrlSum>`+transaction)},
+		{"cheque", painDocument(validHeader, `<PmtInfId>P1</PmtInfId><PmtMtd>CHK</PmtMtd>`+transaction)},
+		{"no EndToEndId", painDocument(validHeader, strings.Replace(validPayment, "<EndToEndId>E1</EndToEndId>", "", 1))},
+		{"EndToEndId not provided", painDocument(validHeader, strings.Replace(validPayment, "E1", "NOTPROVIDED", 1))},
+		{"amount", painDocument(validHeader, strings.Replace(validPayment, "1.50", "1e3", 1))},
+		{"no transactions", painDocument(`<MsgId>M1</MsgId><NbOfTxs>0</NbOfTxs>`, `<PmtInfId>P1</PmtInfId><PmtMtd>TRF</PmtMtd>`)},
+	}
+
+	for _, tc := range testCases {
+		_, err := Parse(strings.NewReader(tc.document))
+		require.ErrorIs(t, err, ErrInvalidMessage, tc.name)
+	}
+}
+
+func TestMinorUnits(t *testing.T) {
+	testCases := []struct {
+		value string
+		units int64
+		ok    bool
+	}{
+		{"150.00", 15000, true},
+		{"12.5", 1250, true},
+		{"7", 700, true},
+		{"0", 0, true},
+		{"0.001", 0, false},
+		{"-1.00", 0, false},
+		{"1,00", 0, false},
+		{"99999999999999999", 0, false},
+	}
+
+	for _, tc := range testCases {
+		units, err := minorUnits(tc.value)
+		if !tc.ok {
+			require.Error(t, err, tc.value)
+			continue
+		}
+		require.NoError(t, err, tc.value)
+		require.Equal(t, tc.units, units, tc.value)
+	}
+}
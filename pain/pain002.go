@@ -0,0 +1,145 @@
+package pain
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Pain002Namespace is the namespace of the pain.002.001.03 customer payment status report
+const Pain002Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.002.001.03"
+
+const (
+	isoDateTime = "2006-01-02T15:04:05Z"
+	// max35Text 和 max105Text 是 Max35Text、Max105Text 欄位的長度上限
+	max35Text  = 35
+	max105Text = 105
+)
+
+// 下面的 struct 依 pain.002.001.03 schema 的元素順序排列，encoding/xml 照欄位順序輸出，不能隨意調換
+
+type statusDocument struct {
+	XMLName xml.Name     `xml:"Document"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Report  statusReport `xml:"CstmrPmtStsRpt"`
+}
+
+type statusReport struct {
+	GroupHeader statusGroupHeader   `xml:"GrpHdr"`
+	Group       statusOriginalGroup `xml:"OrgnlGrpInfAndSts"`
+	Payments    []statusPayment     `xml:"OrgnlPmtInfAndSts"`
+}
+
+type statusGroupHeader struct {
+	MessageID string `xml:"MsgId"`
+	CreatedAt string `xml:"CreDtTm"`
+}
+
+type statusOriginalGroup struct {
+	MessageID            string `xml:"OrgnlMsgId"`
+	MessageName          string `xml:"OrgnlMsgNmId"`
+	CreatedAt            string `xml:"OrgnlCreDtTm,omitempty"`
+	NumberOfTransactions string `xml:"OrgnlNbOfTxs,omitempty"`
+	ControlSum           string `xml:"OrgnlCtrlSum,omitempty"`
+	Status               string `xml:"GrpSts"`
+}
+
+type statusPayment struct {
+	ID                   string              `xml:"OrgnlPmtInfId"`
+	NumberOfTransactions string              `xml:"OrgnlNbOfTxs,omitempty"`
+	ControlSum           string              `xml:"OrgnlCtrlSum,omitempty"`
+	Status               string              `xml:"PmtInfSts"`
+	Transactions         []statusTransaction `xml:"TxInfAndSts"`
+}
+
+type statusTransaction struct {
+	InstructionID string        `xml:"OrgnlInstrId,omitempty"`
+	EndToEndID    string        `xml:"OrgnlEndToEndId"`
+	Status        string        `xml:"TxSts"`
+	Reason        *statusReason `xml:"StsRsnInf,omitempty"`
+	ServicerRef   string        `xml:"AcctSvcrRef,omitempty"`
+}
+
+type statusReason struct {
+	Reason         *statusReasonCode `xml:"Rsn,omitempty"`
+	AdditionalInfo string            `xml:"AddtlInf,omitempty"`
+}
+
+type statusReasonCode struct {
+	Code string `xml:"Cd"`
+}
+
+// WriteStatusReport writes report as a pain.002.001.03 document created at createdAt.
+// Accepted transactions carry the transfer id as AcctSvcrRef.
+func WriteStatusReport(w io.Writer, report *Report, createdAt time.Time) error {
+	document := statusDocument{
+		Xmlns: Pain002Namespace,
+		Report: statusReport{
+			GroupHeader: statusGroupHeader{
+				MessageID: truncate("PAIN002-"+report.OriginalMessageID, max35Text),
+				CreatedAt: createdAt.UTC().Format(isoDateTime),
+			},
+			Group: statusOriginalGroup{
+				MessageID:            report.OriginalMessageID,
+				MessageName:          "pain.001.001.03",
+				CreatedAt:            report.OriginalCreatedAt,
+				NumberOfTransactions: report.NumberOfTransactions,
+				ControlSum:           report.ControlSum,
+				Status:               report.Status,
+			},
+		},
+	}
+
+	for _, payment := range report.Payments {
+		statusPayment := statusPayment{
+			ID:                   payment.OriginalID,
+			NumberOfTransactions: payment.NumberOfTransactions,
+			ControlSum:           payment.ControlSum,
+			Status:               payment.Status,
+		}
+		for _, transaction := range payment.Transactions {
+			statusPayment.Transactions = append(statusPayment.Transactions, statusTransactionOf(transaction))
+		}
+		document.Report.Payments = append(document.Report.Payments, statusPayment)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func statusTransactionOf(transaction TransactionStatus) statusTransaction {
+	status := statusTransaction{
+		InstructionID: transaction.InstructionID,
+		EndToEndID:    transaction.EndToEndID,
+		Status:        transaction.Status,
+	}
+	if transaction.ReasonCode != "" || transaction.Reason != "" {
+		status.Reason = &statusReason{AdditionalInfo: truncate(transaction.Reason, max105Text)}
+		// 待審核的只有說明，沒有原因碼
+		if transaction.ReasonCode != "" {
+			status.Reason.Reason = &statusReasonCode{Code: transaction.ReasonCode}
+		}
+	}
+	if transaction.TransferID != 0 {
+		status.ServicerRef = strconv.FormatInt(transaction.TransferID, 10)
+	}
+	return status
+}
+
+// truncate cuts s to at most n runes, for the MaxNText fields
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
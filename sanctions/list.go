@@ -0,0 +1,143 @@
+package sanctions
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidList is returned when a list file cannot be read as a sanctions list
+var ErrInvalidList = errors.New("invalid sanctions list")
+
+// Entry is one listed person or organization
+type Entry struct {
+	ID      string   `xml:"id,attr"`
+	Program string   `xml:"program,attr"`
+	Name    string   `xml:"name"`
+	Aliases []string `xml:"alias"`
+}
+
+// names returns the name and the aliases of the entry
+func (entry Entry) names() []string {
+	return append([]string{entry.Name}, entry.Aliases...)
+}
+
+// List is a loaded sanctions list, the names are normalized once when loading
+type List struct {
+	entries []Entry
+	// names[i] 是 entries[i] 的名字和別名，連同正規化之後的結果
+	names [][]listedName
+}
+
+type listedName struct {
+	name       string
+	normalized string
+}
+
+// NewList creates a List from entries
+func NewList(entries []Entry) (*List, error) {
+	list := &List{entries: entries}
+	for _, entry := range entries {
+		if entry.ID == "" || strings.TrimSpace(entry.Name) == "" {
+			return nil, fmt.Errorf("%w: entry %q has no id or name", ErrInvalidList, entry.ID)
+		}
+
+		var names []listedName
+		for _, name := range entry.names() {
+			if normalized := Normalize(name); normalized != "" {
+				names = append(names, listedName{name: name, normalized: normalized})
+			}
+		}
+		list.names = append(list.names, names)
+	}
+	return list, nil
+}
+
+// Len returns the number of entries
+func (list *List) Len() int {
+	return len(list.entries)
+}
+
+// LoadCSV reads a list with the header id,name,aliases,program; aliases are separated by semicolons
+func LoadCSV(r io.Reader) (*List, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidList, err)
+	}
+	if strings.Join(header, ",") != "id,name,aliases,program" {
+		return nil, fmt.Errorf("%w: unexpected header %q", ErrInvalidList, strings.Join(header, ","))
+	}
+
+	var entries []Entry
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidList, err)
+		}
+
+		entry := Entry{ID: record[0], Name: record[1], Program: record[3]}
+		for _, alias := range strings.Split(record[2], ";") {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				entry.Aliases = append(entry.Aliases, alias)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return NewList(entries)
+}
+
+// xmlList is the root element of an XML list:
+// <sanctionsList><entry id="..." program="..."><name>...</name><alias>...</alias></entry></sanctionsList>
+type xmlList struct {
+	XMLName xml.Name `xml:"sanctionsList"`
+	Entries []Entry  `xml:"entry"`
+}
+
+// LoadXML reads a list in the XML format described by xmlList
+func LoadXML(r io.Reader) (*List, error) {
+	var list xmlList
+	if err := xml.NewDecoder(r).Decode(&list); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidList, err)
+	}
+
+	for i := range list.Entries {
+		list.Entries[i].Name = strings.TrimSpace(list.Entries[i].Name)
+		for j, alias := range list.Entries[i].Aliases {
+			list.Entries[i].Aliases[j] = strings.TrimSpace(alias)
+		}
+	}
+	return NewList(list.Entries)
+}
+
+// LoadFile reads a .csv or .xml list file
+func LoadFile(path string) (*List, error) {
+	var load func(r io.Reader) (*List, error)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		load = LoadCSV
+	case ".xml":
+		load = LoadXML
+	default:
+		return nil, fmt.Errorf("%w: unknown file type %q", ErrInvalidList, filepath.Ext(path))
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return load(file)
+}
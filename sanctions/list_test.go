@@ -0,0 +1,40 @@
+package sanctions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFile(t *testing.T) {
+	for _, path := range []string{"testdata/list.csv", "testdata/list.xml"} {
+		list, err := LoadFile(path)
+		require.NoError(t, err, path)
+		require.Equal(t, 3, list.Len(), path)
+
+		require.Equal(t, []Entry{
+			{ID: "SDN-1001", Program: "UKRAINE-EO13661", Name: "Ivan Petrov", Aliases: []string{"Ivan Petroff", "I. Petrov"}},
+			{ID: "SDN-1002", Program: "SDGT", Name: "Global Trade Holdings Ltd"},
+			{ID: "SDN-1003", Program: "CMIC", Name: "王小明", Aliases: []string{"王曉明"}},
+		}, list.entries, path)
+	}
+}
+
+func TestLoadInvalidList(t *testing.T) {
+	testCases := []struct {
+		name string
+		load func() (*List, error)
+	}{
+		{"csv header", func() (*List, error) { return LoadCSV(strings.NewReader("name,id\n")) }},
+		{"csv fields", func() (*List, error) { return LoadCSV(strings.NewReader("id,name,aliases,program\n1,x\n")) }},
+		{"csv no name", func() (*List, error) { return LoadCSV(strings.NewReader("id,name,aliases,program\n1,,,\n")) }},
+		{"xml", func() (*List, error) { return LoadXML(strings.NewReader("<entries/>")) }},
+		{"file type", func() (*List, error) { return LoadFile("testdata/list.json") }},
+	}
+
+	for _, tc := range testCases {
+		_, err := tc.load()
+		require.ErrorIs(t, err, ErrInvalidList, tc.name)
+	}
+}
@@ -0,0 +1,74 @@
+package sanctions
+
+import (
+	"sort"
+	"strings"
+)
+
+// JaroWinkler returns the Jaro-Winkler similarity of a and b from 0 to 1, comparing runes so that CJK names work too
+func JaroWinkler(a string, b string) float64 {
+	s1, s2 := []rune(a), []rune(b)
+	if len(s1) == 0 && len(s2) == 0 {
+		return 1
+	}
+	if len(s1) == 0 || len(s2) == 0 {
+		return 0
+	}
+
+	window := max(len(s1), len(s2))/2 - 1
+	window = max(window, 0)
+
+	matched1 := make([]bool, len(s1))
+	matched2 := make([]bool, len(s2))
+	matches := 0
+	for i := range s1 {
+		for j := max(0, i-window); j < min(len(s2), i+window+1); j++ {
+			if matched2[j] || s1[i] != s2[j] {
+				continue
+			}
+			matched1[i], matched2[j] = true, true
+			matches++
+			break
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// 兩邊配對到的字元依序比較，順序不同的一半算一次 transposition
+	transpositions := 0
+	j := 0
+	for i := range s1 {
+		if !matched1[i] {
+			continue
+		}
+		for !matched2[j] {
+			j++
+		}
+		if s1[i] != s2[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s1)) + m/float64(len(s2)) + (m-float64(transpositions/2))/m) / 3
+
+	// 開頭相同（最多 4 個字元）的再加分
+	prefix := 0
+	for prefix < min(4, len(s1), len(s2)) && s1[prefix] == s2[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// Similarity compares two normalized names. Word order does not matter: "doe john" matches "john doe".
+func Similarity(a string, b string) float64 {
+	return max(JaroWinkler(a, b), JaroWinkler(sortWords(a), sortWords(b)))
+}
+
+func sortWords(name string) string {
+	words := strings.Fields(name)
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
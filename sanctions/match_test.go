@@ -0,0 +1,44 @@
+package sanctions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		name       string
+		normalized string
+	}{
+		{"  José  Müller-Lüdenscheidt ", "jose muller ludenscheidt"},
+		{"ＩＶＡＮ　ＰＥＴＲＯＶ", "ivan petrov"},
+		{"O'Brien, Seán", "o brien sean"},
+		{"STRASSE", "strasse"},
+		{"王小明", "王小明"},
+		// 全形空白和標點也會被拿掉
+		{"王　小明。", "王 小明"},
+		{"---", ""},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.normalized, Normalize(tc.name), tc.name)
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	require.Equal(t, 1.0, JaroWinkler("martha", "martha"))
+	require.InDelta(t, 0.961, JaroWinkler("martha", "marhta"), 0.001)
+	require.InDelta(t, 0.840, JaroWinkler("dwayne", "duane"), 0.001)
+	require.InDelta(t, 0.813, JaroWinkler("dixon", "dicksonx"), 0.001)
+	require.Zero(t, JaroWinkler("abc", "xyz"))
+	require.Zero(t, JaroWinkler("", "abc"))
+
+	// 以 rune 比較，CJK 一個字就是一個字元
+	require.InDelta(t, 0.8, JaroWinkler("王小明", "王曉明"), 0.001)
+}
+
+func TestSimilarityWordOrder(t *testing.T) {
+	require.Equal(t, 1.0, Similarity("petrov ivan", "ivan petrov"))
+	require.Less(t, JaroWinkler("petrov ivan", "ivan petrov"), 1.0)
+}
@@ -0,0 +1,37 @@
+package sanctions
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// transform.Transformer 有狀態不能共用，每次 Normalize 都建一個新的
+func newNormalizer() transform.Transformer {
+	return transform.Chain(
+		// NFKD 把全形、相容字元（例如 ＡＢＣ、ﬁ）拆成一般字元，重音拆成獨立的組合符號
+		norm.NFKD,
+		runes.Remove(runes.In(unicode.Mn)),
+		cases.Fold(),
+		norm.NFC,
+	)
+}
+
+// Normalize prepares a name for matching: compatibility characters are decomposed, accents dropped, case folded,
+// punctuation turned into spaces and spaces collapsed. CJK characters are kept as they are,
+// Traditional and Simplified forms are not mapped onto each other.
+func Normalize(name string) string {
+	normalized, _, err := transform.String(newNormalizer(), name)
+	if err != nil {
+		normalized = name
+	}
+
+	fields := strings.FieldsFunc(normalized, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,55 @@
+package sanctions
+
+import (
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+// Screener screens names against a List, it implements db.NameScreener
+type Screener struct {
+	list *List
+	// 分數到 flagScore 就 hold 等審核，到 blockScore 就擋下
+	flagScore  float64
+	blockScore float64
+}
+
+var _ db.NameScreener = (*Screener)(nil)
+
+// NewScreener creates a new Screener, names scoring at least flagScore are held and at least blockScore blocked
+func NewScreener(list *List, flagScore float64, blockScore float64) *Screener {
+	return &Screener{
+		list:       list,
+		flagScore:  flagScore,
+		blockScore: blockScore,
+	}
+}
+
+// ScreenName implements db.NameScreener, comparing name with every name and alias of the list
+func (screener *Screener) ScreenName(name string) db.NameScreening {
+	screening := db.NameScreening{
+		Outcome:        db.RuleAllow,
+		NormalizedName: Normalize(name),
+	}
+	if screening.NormalizedName == "" {
+		return screening
+	}
+
+	for i, entry := range screener.list.entries {
+		for _, listed := range screener.list.names[i] {
+			score := Similarity(screening.NormalizedName, listed.normalized)
+			if score <= screening.Score {
+				continue
+			}
+			screening.Score = score
+			screening.EntryID = entry.ID
+			screening.MatchedName = listed.name
+		}
+	}
+
+	switch {
+	case screening.Score >= screener.blockScore:
+		screening.Outcome = db.RuleBlock
+	case screening.Score >= screener.flagScore:
+		screening.Outcome = db.RuleHold
+	}
+	return screening
+}
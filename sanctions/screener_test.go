@@ -0,0 +1,55 @@
+package sanctions
+
+import (
+	"testing"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/andyrestart9/bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScreenName(t *testing.T) {
+	list, err := LoadFile("testdata/list.csv")
+	require.NoError(t, err)
+	screener := NewScreener(list, 0.85, 0.95)
+
+	testCases := []struct {
+		name    string
+		outcome db.RuleOutcome
+		entryID string
+	}{
+		{"Ivan Petrov", db.RuleBlock, "SDN-1001"},
+		{"PETROV, Ivan", db.RuleBlock, "SDN-1001"},
+		{"Ívan Petróv", db.RuleBlock, "SDN-1001"},
+		{"Ivo Petrov", db.RuleHold, "SDN-1001"},
+		{"Global Trade Holding", db.RuleBlock, "SDN-1002"},
+		{"王曉明", db.RuleBlock, "SDN-1003"},
+		{"Jane Smith", db.RuleAllow, ""},
+	}
+
+	for _, tc := range testCases {
+		screening := screener.ScreenName(tc.name)
+		require.Equal(t, tc.outcome, screening.Outcome, tc.name)
+		if tc.outcome != db.RuleAllow {
+			require.Equal(t, tc.entryID, screening.EntryID, tc.name)
+			require.NotEmpty(t, screening.MatchedName, tc.name)
+		}
+	}
+
+	// alias 命中時回傳的是 alias 本來的寫法
+	screening := screener.ScreenName("Ivan Petroff")
+	require.Equal(t, "Ivan Petroff", screening.MatchedName)
+	require.Equal(t, 1.0, screening.Score)
+}
+
+func TestScreenRandomOwners(t *testing.T) {
+	list, err := LoadFile("testdata/list.xml")
+	require.NoError(t, err)
+	screener := NewScreener(list, 0.85, 0.95)
+
+	// RandomOwner 產生 6 個隨機漢字，幾乎不可能像名單上的名字
+	for i := 0; i < 100; i++ {
+		owner := util.RandomOwner()
+		require.Equal(t, db.RuleAllow, screener.ScreenName(owner).Outcome, owner)
+	}
+}
@@ -0,0 +1,282 @@
+package statement
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+)
+
+// CAMT053Namespace is the namespace of the camt.053.001.02 bank to customer statement
+const CAMT053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+const (
+	isoDateTime = "2006-01-02T15:04:05Z"
+	isoDate     = "2006-01-02"
+	// max35Text 是 Max35Text 欄位的長度上限
+	max35Text = 35
+)
+
+// 下面的 struct 依 camt.053.001.02 schema 的元素順序排列，encoding/xml 照欄位順序輸出，不能隨意調換
+
+type camtDocument struct {
+	XMLName xml.Name      `xml:"Document"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Report  camtStatement `xml:"BkToCstmrStmt"`
+}
+
+type camtStatement struct {
+	GroupHeader camtGroupHeader `xml:"GrpHdr"`
+	Statement   camtAccountStmt `xml:"Stmt"`
+}
+
+type camtGroupHeader struct {
+	MessageID string `xml:"MsgId"`
+	CreatedAt string `xml:"CreDtTm"`
+}
+
+type camtAccountStmt struct {
+	ID        string        `xml:"Id"`
+	CreatedAt string        `xml:"CreDtTm"`
+	Period    camtPeriod    `xml:"FrToDt"`
+	Account   camtAccount   `xml:"Acct"`
+	Balances  []camtBalance `xml:"Bal"`
+	Summary   camtSummary   `xml:"TxsSummry"`
+	Entries   []camtEntry   `xml:"Ntry"`
+}
+
+type camtPeriod struct {
+	From string `xml:"FrDtTm"`
+	To   string `xml:"ToDtTm"`
+}
+
+type camtAccount struct {
+	ID       camtAccountID `xml:"Id"`
+	Currency string        `xml:"Ccy,omitempty"`
+	Owner    *camtParty    `xml:"Ownr,omitempty"`
+}
+
+// camtAccountID 用 IBAN：帳號照 IBAN 的格式組成
+type camtAccountID struct {
+	IBAN string `xml:"IBAN"`
+}
+
+type camtParty struct {
+	Name string `xml:"Nm"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Type      camtBalanceType `xml:"Tp"`
+	Amount    camtAmount      `xml:"Amt"`
+	Indicator string          `xml:"CdtDbtInd"`
+	Date      camtDate        `xml:"Dt"`
+}
+
+type camtBalanceType struct {
+	Code string `xml:"CdOrPrtry>Cd"`
+}
+
+type camtDate struct {
+	Date string `xml:"Dt"`
+}
+
+type camtSummary struct {
+	Total   camtTotal    `xml:"TtlNtries"`
+	Credits camtSubtotal `xml:"TtlCdtNtries"`
+	Debits  camtSubtotal `xml:"TtlDbtNtries"`
+}
+
+type camtTotal struct {
+	Count     string `xml:"NbOfNtries"`
+	Sum       string `xml:"Sum"`
+	NetAmount string `xml:"TtlNetNtryAmt"`
+	Indicator string `xml:"CdtDbtInd"`
+}
+
+type camtSubtotal struct {
+	Count string `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+}
+
+type camtEntry struct {
+	Reference       string          `xml:"NtryRef"`
+	Amount          camtAmount      `xml:"Amt"`
+	Indicator       string          `xml:"CdtDbtInd"`
+	Status          string          `xml:"Sts"`
+	BookingDate     camtDateTime    `xml:"BookgDt"`
+	ValueDate       camtDate        `xml:"ValDt"`
+	ServicerRef     string          `xml:"AcctSvcrRef"`
+	TransactionCode camtTransaction `xml:"BkTxCd"`
+	Details         camtEntryDetail `xml:"NtryDtls"`
+}
+
+type camtDateTime struct {
+	DateTime string `xml:"DtTm"`
+}
+
+type camtTransaction struct {
+	Code string `xml:"Prtry>Cd"`
+}
+
+type camtEntryDetail struct {
+	Transaction camtTransactionDetail `xml:"TxDtls"`
+}
+
+type camtTransactionDetail struct {
+	Refs    camtRefs          `xml:"Refs"`
+	Parties *camtRelatedParty `xml:"RltdPties,omitempty"`
+}
+
+type camtRefs struct {
+	ServicerRef string `xml:"AcctSvcrRef"`
+	EndToEndID  string `xml:"EndToEndId"`
+}
+
+type camtRelatedParty struct {
+	DebtorAccount   *camtAccount `xml:"DbtrAcct,omitempty"`
+	CreditorAccount *camtAccount `xml:"CdtrAcct,omitempty"`
+}
+
+// WriteCAMT053 writes statement as a camt.053.001.02 document created at createdAt
+func WriteCAMT053(w io.Writer, statement Statement, createdAt time.Time) error {
+	currency := statement.Account.Currency
+	credits, creditSum := statement.Credits()
+	debits, debitSum := statement.Debits()
+	net := creditSum - debitSum
+
+	stmt := camtAccountStmt{
+		ID:        statement.ID(),
+		CreatedAt: createdAt.UTC().Format(isoDateTime),
+		Period: camtPeriod{
+			From: statement.From.UTC().Format(isoDateTime),
+			To:   statement.LastDay().Format(isoDateTime),
+		},
+		Account: camtAccount{
+			ID:       camtAccountID{IBAN: statement.Account.AccountNumber},
+			Currency: currency,
+		},
+		Balances: []camtBalance{
+			camtBalanceOf("OPBD", statement.OpeningBalance, currency, statement.From),
+			camtBalanceOf("CLBD", statement.ClosingBalance, currency, statement.LastDay()),
+		},
+		Summary: camtSummary{
+			Total: camtTotal{
+				Count:     strconv.Itoa(credits + debits),
+				Sum:       formatAmount(creditSum + debitSum),
+				NetAmount: formatAmount(abs(net)),
+				Indicator: indicator(net),
+			},
+			Credits: camtSubtotal{Count: strconv.Itoa(credits), Sum: formatAmount(creditSum)},
+			Debits:  camtSubtotal{Count: strconv.Itoa(debits), Sum: formatAmount(debitSum)},
+		},
+	}
+	if statement.Account.Owner != "" {
+		stmt.Account.Owner = &camtParty{Name: truncate(statement.Account.Owner, 140)}
+	}
+	for _, entry := range statement.Entries {
+		stmt.Entries = append(stmt.Entries, camtEntryOf(entry, currency))
+	}
+
+	document := camtDocument{
+		Xmlns: CAMT053Namespace,
+		Report: camtStatement{
+			GroupHeader: camtGroupHeader{
+				MessageID: truncate("CAMT053-"+statement.ID(), max35Text),
+				CreatedAt: createdAt.UTC().Format(isoDateTime),
+			},
+			Statement: stmt,
+		},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func camtBalanceOf(code string, balance int64, currency string, date time.Time) camtBalance {
+	return camtBalance{
+		Type:      camtBalanceType{Code: code},
+		Amount:    camtAmount{Currency: currency, Value: formatAmount(abs(balance))},
+		Indicator: indicator(balance),
+		Date:      camtDate{Date: date.UTC().Format(isoDate)},
+	}
+}
+
+func camtEntryOf(entry Entry, currency string) camtEntry {
+	reference := formatID(entry.EntryID)
+
+	// 沒有外部參考號的用 transfer id；都沒有時照 ISO 的慣例填 NOTPROVIDED
+	endToEndID := "NOTPROVIDED"
+	switch {
+	case entry.Reference != "":
+		endToEndID = truncate(entry.Reference, max35Text)
+	case entry.TransferID != 0:
+		endToEndID = "T" + formatID(entry.TransferID)
+	}
+
+	detail := camtTransactionDetail{
+		Refs: camtRefs{ServicerRef: reference, EndToEndID: endToEndID},
+	}
+	// 入帳的對方是付款人，出帳的對方是收款人
+	if entry.CounterpartyAccountNumber != "" {
+		counterparty := &camtAccount{ID: camtAccountID{IBAN: entry.CounterpartyAccountNumber}}
+		if entry.Amount > 0 {
+			detail.Parties = &camtRelatedParty{DebtorAccount: counterparty}
+		} else {
+			detail.Parties = &camtRelatedParty{CreditorAccount: counterparty}
+		}
+	}
+
+	code := entry.Kind
+	if code == "" {
+		code = "other"
+	}
+
+	return camtEntry{
+		Reference:       reference,
+		Amount:          camtAmount{Currency: currency, Value: formatAmount(abs(entry.Amount))},
+		Indicator:       indicator(entry.Amount),
+		Status:          "BOOK",
+		BookingDate:     camtDateTime{DateTime: entry.BookedAt.UTC().Format(isoDateTime)},
+		ValueDate:       camtDate{Date: entry.BookedAt.UTC().Format(isoDate)},
+		ServicerRef:     reference,
+		TransactionCode: camtTransaction{Code: code},
+		Details:         camtEntryDetail{Transaction: detail},
+	}
+}
+
+// indicator returns the credit/debit indicator of amount, zero balances count as credit
+func indicator(amount int64) string {
+	if amount < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+func abs(amount int64) int64 {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
+
+// truncate cuts s to at most n runes, for the MaxNText fields
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
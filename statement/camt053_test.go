@@ -0,0 +1,62 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// validateXML 用 xmllint 依 schema 驗證 document，沒有裝 xmllint 時跳過
+func validateXML(t *testing.T, schema string, document []byte) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint is not installed")
+	}
+
+	path := filepath.Join(t.TempDir(), "document.xml")
+	require.NoError(t, os.WriteFile(path, document, 0o644))
+
+	output, err := exec.Command(xmllint, "--noout", "--schema", schema, path).CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func TestWriteCAMT053(t *testing.T) {
+	var out bytes.Buffer
+	err := WriteCAMT053(&out, testStatement(), time.Date(2026, time.April, 1, 6, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	requireGolden(t, "camt053.golden.xml", out.Bytes())
+	validateXML(t, "testdata/camt.053.001.02.xsd", out.Bytes())
+}
+
+func TestWriteCAMT053Balances(t *testing.T) {
+	var out bytes.Buffer
+	err := WriteCAMT053(&out, testStatement(), time.Now())
+	require.NoError(t, err)
+
+	var document camtDocument
+	require.NoError(t, xml.Unmarshal(out.Bytes(), &document))
+
+	stmt := document.Report.Statement
+	require.Len(t, stmt.Balances, 2)
+	require.Equal(t, "OPBD", stmt.Balances[0].Type.Code)
+	require.Equal(t, "1250.00", stmt.Balances[0].Amount.Value)
+	require.Equal(t, "CRDT", stmt.Balances[0].Indicator)
+	require.Equal(t, "CLBD", stmt.Balances[1].Type.Code)
+	require.Equal(t, "45.50", stmt.Balances[1].Amount.Value)
+	require.Equal(t, "DBIT", stmt.Balances[1].Indicator)
+	require.Equal(t, "2026-03-31", stmt.Balances[1].Date.Date)
+
+	require.Len(t, stmt.Entries, 4)
+	require.Equal(t, "DBIT", stmt.Entries[2].Indicator)
+	require.Equal(t, "TW46ANDY295574031886", stmt.Entries[2].Details.Transaction.Parties.CreditorAccount.ID.IBAN)
+	require.Equal(t, "TW74ANDY730118264905", stmt.Entries[1].Details.Transaction.Parties.DebtorAccount.ID.IBAN)
+	require.Equal(t, "T77", stmt.Entries[1].Details.Transaction.Refs.EndToEndID)
+	require.Equal(t, "SEPA-2026-03-02-0001", stmt.Entries[0].Details.Transaction.Refs.EndToEndID)
+}
@@ -0,0 +1,386 @@
+package statement
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	mt940Date      = "060102"
+	mt940EntryDate = "0102"
+	// mt940LineLength 是 :86: 每一行的長度上限，最多 mt940InfoLines 行
+	mt940LineLength = 65
+	mt940InfoLines  = 6
+	// max16x 是 :20: 和 :61: 參考號的長度上限
+	max16x = 16
+)
+
+// Debit/credit marks of MT940 statement lines
+const (
+	MarkCredit = "C"
+	MarkDebit  = "D"
+	// MarkReversalCredit is a debit reversing an earlier credit
+	MarkReversalCredit = "RC"
+	// MarkReversalDebit is a credit reversing an earlier debit
+	MarkReversalDebit = "RD"
+)
+
+// ErrInvalidMT940 is returned by ParseMT940 when the message is malformed or its balances do not add up
+var ErrInvalidMT940 = errors.New("invalid MT940 message")
+
+// MT940Balance is a balance line (:60F:, :62F:) of an MT940 message
+type MT940Balance struct {
+	Date     time.Time `json:"date"`
+	Currency string    `json:"currency"`
+	// Amount is negative for debit balances
+	Amount int64 `json:"amount"`
+}
+
+// MT940Transaction is a statement line (:61:) of an MT940 message with its information to account owner (:86:)
+type MT940Transaction struct {
+	ValueDate time.Time `json:"value_date"`
+	EntryDate time.Time `json:"entry_date"`
+	Mark      string    `json:"mark"`
+	// Amount is positive for credits and negative for debits, including reversals
+	Amount int64 `json:"amount"`
+	// TypeCode is the transaction type identification code, e.g. NTRF
+	TypeCode      string `json:"type_code"`
+	Reference     string `json:"reference"`
+	BankReference string `json:"bank_reference"`
+	Information   string `json:"information"`
+}
+
+// MT940 is a parsed MT940 customer statement message
+type MT940 struct {
+	Reference       string             `json:"reference"`
+	AccountID       string             `json:"account_id"`
+	StatementNumber string             `json:"statement_number"`
+	Opening         MT940Balance       `json:"opening"`
+	Closing         MT940Balance       `json:"closing"`
+	Transactions    []MT940Transaction `json:"transactions"`
+}
+
+// WriteMT940 writes statement as a SWIFT MT940 customer statement message (block 4 only, CRLF line endings)
+func WriteMT940(w io.Writer, statement Statement) error {
+	currency := statement.Account.Currency
+
+	lines := []string{
+		":20:" + truncate(swiftText(statement.ID()), max16x),
+		":25:" + statement.Account.AccountNumber,
+		// 報表編號用期初日期的 YYDDD（年和一年中的第幾天），同一個帳戶不會重複而且一直遞增
+		":28C:" + fmt.Sprintf("%s%03d", statement.From.UTC().Format("06"), statement.From.UTC().YearDay()),
+		":60F:" + mt940Balance(statement.OpeningBalance, currency, statement.From),
+	}
+	for _, entry := range statement.Entries {
+		lines = append(lines, mt940StatementLine(entry))
+		for i, line := range wrapSwiftText(mt940Information(entry)) {
+			if i == 0 {
+				line = ":86:" + line
+			}
+			lines = append(lines, line)
+		}
+	}
+	lines = append(lines,
+		":62F:"+mt940Balance(statement.ClosingBalance, currency, statement.LastDay()),
+		"-",
+	)
+
+	_, err := io.WriteString(w, strings.Join(lines, "\r\n")+"\r\n")
+	return err
+}
+
+func mt940Balance(balance int64, currency string, date time.Time) string {
+	mark := MarkCredit
+	if balance < 0 {
+		mark = MarkDebit
+	}
+	return mark + date.UTC().Format(mt940Date) + currency + mt940Amount(abs(balance))
+}
+
+// mt940StatementLine 依 :61: 的子欄位順序輸出：起息日、記帳日、借貸記號、金額、交易類型、客戶參考號、//銀行參考號
+func mt940StatementLine(entry Entry) string {
+	bookedAt := entry.BookedAt.UTC()
+	return ":61:" + bookedAt.Format(mt940Date) + bookedAt.Format(mt940EntryDate) +
+		mt940Mark(entry) + mt940Amount(abs(entry.Amount)) + mt940TypeCode(entry.Kind) +
+		mt940Reference(entry) + "//" + formatID(entry.EntryID)
+}
+
+func mt940Mark(entry Entry) string {
+	// 沖正的入帳是把之前的扣款退回來（RD），反過來是 RC
+	if entry.Kind == KindReversal {
+		if entry.Amount < 0 {
+			return MarkReversalCredit
+		}
+		return MarkReversalDebit
+	}
+	if entry.Amount < 0 {
+		return MarkDebit
+	}
+	return MarkCredit
+}
+
+// mt940TypeCode returns the SWIFT transaction type identification code of kind
+func mt940TypeCode(kind string) string {
+	switch kind {
+	case KindTransfer, KindDeposit, KindWithdrawal:
+		return "NTRF"
+	case KindReversal:
+		return "NRTI"
+	default:
+		return "NMSC"
+	}
+}
+
+// mt940Reference 和 camt.053 的 EndToEndId 一樣：外部參考號、transfer id，都沒有時填 NONREF。
+// 參考號裡不能有 /，不然會和後面的 //銀行參考號 分不開
+func mt940Reference(entry Entry) string {
+	switch {
+	case entry.Reference != "":
+		return truncate(strings.ReplaceAll(swiftText(entry.Reference), "/", "."), max16x)
+	case entry.TransferID != 0:
+		return "T" + formatID(entry.TransferID)
+	}
+	return "NONREF"
+}
+
+func mt940Information(entry Entry) string {
+	kind := entry.Kind
+	if kind == "" {
+		kind = "other"
+	}
+	parts := []string{strings.ToUpper(kind)}
+	if entry.TransferID != 0 {
+		parts = append(parts, "T"+formatID(entry.TransferID))
+	}
+	if entry.CounterpartyAccountNumber != "" {
+		parts = append(parts, "ACCOUNT "+entry.CounterpartyAccountNumber)
+	}
+	if entry.Reference != "" {
+		parts = append(parts, "REF "+swiftText(entry.Reference))
+	}
+	return strings.Join(parts, " ")
+}
+
+// mt940Amount formats a positive amount of minor units with the decimal comma of SWIFT
+func mt940Amount(amount int64) string {
+	return fmt.Sprintf("%d,%02d", amount/100, amount%100)
+}
+
+// swiftText replaces the characters outside of the SWIFT x character set with a dot
+func swiftText(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune("/-?:().,'+ ", r):
+			return r
+		}
+		return '.'
+	}, s)
+}
+
+// wrapSwiftText 把 s 切成最多 mt940InfoLines 行；續行不能以 : 或 - 開頭，不然會被當成新欄位或訊息結尾
+func wrapSwiftText(s string) []string {
+	var lines []string
+	for s != "" && len(lines) < mt940InfoLines {
+		n := min(len(s), mt940LineLength)
+		for n > 1 && n < len(s) && (s[n] == ':' || s[n] == '-') {
+			n--
+		}
+		lines = append(lines, s[:n])
+		s = s[n:]
+	}
+	return lines
+}
+
+var (
+	mt940TagPattern     = regexp.MustCompile(`^:([0-9]{2}[A-Z]?):(.*)$`)
+	mt940BalancePattern = regexp.MustCompile(`^(C|D)([0-9]{6})([A-Z]{3})([0-9]{1,12},[0-9]{0,2})$`)
+	mt940LinePattern    = regexp.MustCompile(`^([0-9]{6})([0-9]{4})?(C|D|RC|RD)([A-Z])?([0-9]{1,12},[0-9]{0,2})([NFS][A-Z0-9]{3})(.{1,16}?)(?://(.{1,16}))?$`)
+)
+
+type mt940Field struct {
+	tag   string
+	lines []string
+}
+
+// ParseMT940 parses an MT940 message as written by WriteMT940 or by other banks.
+// SWIFT block headers are skipped, only the first statement of the message is read,
+// and the opening balance plus the statement lines must add up to the closing balance.
+func ParseMT940(r io.Reader) (MT940, error) {
+	fields, err := readMT940Fields(r)
+	if err != nil {
+		return MT940{}, err
+	}
+
+	var message MT940
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		value := field.lines[0]
+		seen[field.tag[:2]] = true
+
+		switch field.tag {
+		case "20":
+			message.Reference = value
+		case "25":
+			message.AccountID = value
+		case "28C":
+			message.StatementNumber = value
+		case "60F", "60M":
+			message.Opening, err = parseMT940Balance(value)
+		case "62F", "62M":
+			message.Closing, err = parseMT940Balance(value)
+		case "61":
+			var transaction MT940Transaction
+			transaction, err = parseMT940Transaction(value)
+			message.Transactions = append(message.Transactions, transaction)
+		case "86":
+			// 緊接在 :61: 後面的 :86: 屬於那一筆交易，其他位置的是整份報表的說明，這裡不保留
+			if len(message.Transactions) > 0 && !seen["62"] {
+				message.Transactions[len(message.Transactions)-1].Information = strings.Join(field.lines, "")
+			}
+		}
+		if err != nil {
+			return MT940{}, fmt.Errorf("%w: field :%s:: %v", ErrInvalidMT940, field.tag, err)
+		}
+	}
+
+	for _, tag := range []string{"20", "25", "28", "60", "62"} {
+		if !seen[tag] {
+			return MT940{}, fmt.Errorf("%w: missing field :%s:", ErrInvalidMT940, tag)
+		}
+	}
+	if message.Opening.Currency != message.Closing.Currency {
+		return MT940{}, fmt.Errorf("%w: opening balance in %s, closing balance in %s", ErrInvalidMT940, message.Opening.Currency, message.Closing.Currency)
+	}
+
+	balance := message.Opening.Amount
+	for _, transaction := range message.Transactions {
+		balance += transaction.Amount
+	}
+	if balance != message.Closing.Amount {
+		return MT940{}, fmt.Errorf("%w: statement lines add up to %s, closing balance is %s", ErrInvalidMT940, formatAmount(balance), formatAmount(message.Closing.Amount))
+	}
+	return message, nil
+}
+
+// readMT940Fields 把訊息切成欄位，續行接在前一個欄位後面；遇到 - 結尾就停
+func readMT940Fields(r io.Reader) ([]mt940Field, error) {
+	var fields []mt940Field
+	var supplementary bool
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "{") {
+			continue
+		}
+		if line == "-" || line == "-}" {
+			break
+		}
+
+		if match := mt940TagPattern.FindStringSubmatch(line); match != nil {
+			fields = append(fields, mt940Field{tag: match[1], lines: []string{match[2]}})
+			supplementary = match[1] == "61"
+			continue
+		}
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("%w: text before the first field", ErrInvalidMT940)
+		}
+
+		// :61: 的第二行是補充說明，不是參考號的一部分，直接略過
+		if supplementary {
+			supplementary = false
+			continue
+		}
+		last := &fields[len(fields)-1]
+		last.lines = append(last.lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: no fields", ErrInvalidMT940)
+	}
+	return fields, nil
+}
+
+func parseMT940Balance(value string) (MT940Balance, error) {
+	match := mt940BalancePattern.FindStringSubmatch(value)
+	if match == nil {
+		return MT940Balance{}, fmt.Errorf("malformed balance %q", value)
+	}
+
+	date, err := time.Parse(mt940Date, match[2])
+	if err != nil {
+		return MT940Balance{}, err
+	}
+	amount, err := parseAmount(match[4], ",")
+	if err != nil {
+		return MT940Balance{}, err
+	}
+	if match[1] == MarkDebit {
+		amount = -amount
+	}
+	return MT940Balance{Date: date, Currency: match[3], Amount: amount}, nil
+}
+
+func parseMT940Transaction(value string) (MT940Transaction, error) {
+	match := mt940LinePattern.FindStringSubmatch(value)
+	if match == nil {
+		return MT940Transaction{}, fmt.Errorf("malformed statement line %q", value)
+	}
+
+	valueDate, err := time.Parse(mt940Date, match[1])
+	if err != nil {
+		return MT940Transaction{}, err
+	}
+	entryDate := valueDate
+	if match[2] != "" {
+		entryDate, err = mt940EntryDateNear(match[2], valueDate)
+		if err != nil {
+			return MT940Transaction{}, err
+		}
+	}
+
+	amount, err := parseAmount(match[5], ",")
+	if err != nil {
+		return MT940Transaction{}, err
+	}
+	if match[3] == MarkDebit || match[3] == MarkReversalCredit {
+		amount = -amount
+	}
+
+	return MT940Transaction{
+		ValueDate:     valueDate,
+		EntryDate:     entryDate,
+		Mark:          match[3],
+		Amount:        amount,
+		TypeCode:      match[6],
+		Reference:     match[7],
+		BankReference: match[8],
+	}, nil
+}
+
+// mt940EntryDateNear 補上記帳日（MMDD）的年份：取離起息日最近的那一年，跨年的交易才不會差一年
+func mt940EntryDateNear(value string, valueDate time.Time) (time.Time, error) {
+	date, err := time.Parse(mt940EntryDate, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	entryDate := time.Date(valueDate.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	const halfYear = 183 * 24 * time.Hour
+	switch {
+	case entryDate.Sub(valueDate) > halfYear:
+		entryDate = entryDate.AddDate(-1, 0, 0)
+	case valueDate.Sub(entryDate) > halfYear:
+		entryDate = entryDate.AddDate(1, 0, 0)
+	}
+	return entryDate, nil
+}
@@ -0,0 +1,158 @@
+package statement
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMT940(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteMT940(&out, testStatement()))
+
+	requireGolden(t, "mt940.golden.sta", out.Bytes())
+}
+
+func TestMT940RoundTrip(t *testing.T) {
+	statement := testStatement()
+
+	var out bytes.Buffer
+	require.NoError(t, WriteMT940(&out, statement))
+
+	message, err := ParseMT940(&out)
+	require.NoError(t, err)
+	require.Equal(t, "42-20260301", message.Reference)
+	require.Equal(t, "TW40ANDY482910375561", message.AccountID)
+	require.Equal(t, "26060", message.StatementNumber)
+	require.Equal(t, MT940Balance{Date: statement.From, Currency: "EUR", Amount: 125000}, message.Opening)
+	require.Equal(t, MT940Balance{Date: time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC), Currency: "EUR", Amount: -4550}, message.Closing)
+
+	require.Len(t, message.Transactions, len(statement.Entries))
+	for i, entry := range statement.Entries {
+		transaction := message.Transactions[i]
+		day := time.Date(entry.BookedAt.Year(), entry.BookedAt.Month(), entry.BookedAt.Day(), 0, 0, 0, 0, time.UTC)
+		require.Equal(t, entry.Amount, transaction.Amount)
+		require.Equal(t, day, transaction.ValueDate)
+		require.Equal(t, day, transaction.EntryDate)
+		require.Equal(t, formatID(entry.EntryID), transaction.BankReference)
+	}
+
+	require.Equal(t, MarkCredit, message.Transactions[0].Mark)
+	require.Equal(t, "SEPA-2026-03-02-", message.Transactions[0].Reference)
+	require.Equal(t, "DEPOSIT ACCOUNT TW55ANDY100000000017 REF SEPA-2026-03-02-0001", message.Transactions[0].Information)
+	require.Equal(t, "NTRF", message.Transactions[1].TypeCode)
+	require.Equal(t, "T77", message.Transactions[1].Reference)
+	require.Equal(t, MarkDebit, message.Transactions[2].Mark)
+	require.Equal(t, "TRANSFER T80 ACCOUNT TW46ANDY295574031886", message.Transactions[2].Information)
+	require.Equal(t, "NMSC", message.Transactions[3].TypeCode)
+	require.Equal(t, "NONREF", message.Transactions[3].Reference)
+}
+
+func TestMT940Reversal(t *testing.T) {
+	statement := testStatement()
+	statement.Entries = []Entry{
+		{EntryID: 7, Amount: -1000, BookedAt: time.Date(2026, time.March, 5, 10, 0, 0, 0, time.UTC), Kind: KindWithdrawal, CounterpartyAccountID: 2, CounterpartyAccountNumber: "TW55ANDY100000000017", Reference: "wd_1"},
+		{EntryID: 9, Amount: 1000, BookedAt: time.Date(2026, time.March, 6, 10, 0, 0, 0, time.UTC), Kind: KindReversal, CounterpartyAccountID: 2, CounterpartyAccountNumber: "TW55ANDY100000000017", Reference: "wd_1"},
+	}
+	statement.ClosingBalance = statement.OpeningBalance
+
+	var out bytes.Buffer
+	require.NoError(t, WriteMT940(&out, statement))
+	require.Contains(t, out.String(), ":61:2603060306RD10,00NRTIwd.1//9\r\n")
+
+	message, err := ParseMT940(&out)
+	require.NoError(t, err)
+	require.Len(t, message.Transactions, 2)
+	require.Equal(t, MarkReversalDebit, message.Transactions[1].Mark)
+	require.Equal(t, int64(1000), message.Transactions[1].Amount)
+}
+
+func TestMT940LongInformation(t *testing.T) {
+	statement := testStatement()
+	statement.Entries = []Entry{
+		{EntryID: 1, Amount: 100, BookedAt: statement.From, Kind: KindDeposit, Reference: strings.Repeat("é", 30) + strings.Repeat("-", 40) + strings.Repeat("x", 400)},
+	}
+	statement.ClosingBalance = statement.OpeningBalance + 100
+
+	var out bytes.Buffer
+	require.NoError(t, WriteMT940(&out, statement))
+
+	// :86: 最多六行，每行不超過 65 個字元，續行不會被當成新欄位或訊息結尾
+	lines := strings.Split(out.String(), "\r\n")
+	start := slices.IndexFunc(lines, func(line string) bool { return strings.HasPrefix(line, ":86:") })
+	end := slices.IndexFunc(lines, func(line string) bool { return strings.HasPrefix(line, ":62F:") })
+	require.Equal(t, mt940InfoLines, end-start)
+	for _, line := range lines[start+1 : end] {
+		require.LessOrEqual(t, len(line), mt940LineLength)
+		require.NotContains(t, ":-", line[:1])
+	}
+
+	message, err := ParseMT940(strings.NewReader(out.String()))
+	require.NoError(t, err)
+	require.Len(t, message.Transactions, 1)
+	require.True(t, strings.HasPrefix(message.Transactions[0].Information, "DEPOSIT REF ..."))
+}
+
+func TestParseMT940OtherBank(t *testing.T) {
+	// 其他銀行常見的寫法：有 SWIFT block、:61: 沒有記帳日、有補充說明行、跨年的記帳日
+	message := strings.Join([]string{
+		"{1:F01ABNANL2AXXXX0000000000}{2:O9400000000000ABNANL2AXXXX00000000000000000000N}{4:",
+		":20:ABN AMRO BANK NV",
+		":25:417164300",
+		":28C:1/1",
+		":60F:D251231EUR0,5",
+		":61:2512311231D12,FCHGNONREF",
+		"SERVICE FEE",
+		":86:COSTS",
+		"DECEMBER",
+		":61:2512310102C112,5NTRFEREF-1//B-77",
+		":62M:C251231EUR100,",
+		"-}",
+	}, "\n")
+
+	parsed, err := ParseMT940(strings.NewReader(message))
+	require.NoError(t, err)
+	require.Equal(t, "417164300", parsed.AccountID)
+	require.Equal(t, int64(-50), parsed.Opening.Amount)
+	require.Equal(t, int64(10000), parsed.Closing.Amount)
+
+	require.Len(t, parsed.Transactions, 2)
+	require.Equal(t, int64(-1200), parsed.Transactions[0].Amount)
+	require.Equal(t, "FCHG", parsed.Transactions[0].TypeCode)
+	require.Equal(t, "NONREF", parsed.Transactions[0].Reference)
+	require.Equal(t, "COSTSDECEMBER", parsed.Transactions[0].Information)
+	require.Equal(t, int64(11250), parsed.Transactions[1].Amount)
+	require.Equal(t, "EREF-1", parsed.Transactions[1].Reference)
+	require.Equal(t, "B-77", parsed.Transactions[1].BankReference)
+	require.Equal(t, time.Date(2026, time.January, 2, 0, 0, 0, 0, time.UTC), parsed.Transactions[1].EntryDate)
+}
+
+func TestParseMT940Invalid(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteMT940(&out, testStatement()))
+	valid := out.String()
+
+	testCases := []struct {
+		name    string
+		message string
+	}{
+		{"empty", ""},
+		{"unbalanced", strings.Replace(valid, ":62F:D260331EUR45,50", ":62F:D260331EUR45,51", 1)},
+		{"missing closing balance", strings.Replace(valid, ":62F:", ":65:", 1)},
+		{"malformed amount", strings.Replace(valid, "EUR1250,00", "EUR1250.00", 1)},
+		{"malformed statement line", strings.Replace(valid, "C500,00NTRF", "X500,00NTRF", 1)},
+		{"currency mismatch", strings.Replace(valid, ":62F:D260331EUR", ":62F:D260331USD", 1)},
+		{"text before the first field", "hello\r\n" + valid},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseMT940(strings.NewReader(tc.message))
+			require.ErrorIs(t, err, ErrInvalidMT940)
+		})
+	}
+}
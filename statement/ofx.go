@@ -0,0 +1,316 @@
+package statement
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ofxHeader 是 OFX 2.2 放在 XML 宣告後面的處理指令
+	ofxHeader   = `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+	ofxDateTime = "20060102150405.000"
+	// ofxName 是 STMTTRN 的 NAME 長度上限
+	ofxName = 32
+	ofxMemo = 255
+)
+
+// Transaction types of OFX statement transactions
+const (
+	OFXCredit   = "CREDIT"
+	OFXDebit    = "DEBIT"
+	OFXDeposit  = "DEP"
+	OFXTransfer = "XFER"
+	OFXOther    = "OTHER"
+)
+
+// ErrInvalidOFX is returned by ParseOFX when the document is malformed or its amounts cannot be read
+var ErrInvalidOFX = errors.New("invalid OFX document")
+
+// 下面的 struct 依 OFX 2.2 schema 的元素順序排列，和 camt.053 一樣不能隨意調換
+
+type ofxDocument struct {
+	XMLName xml.Name             `xml:"OFX"`
+	SignOn  ofxSignOn            `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank    ofxStatementResponse `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+type ofxSignOn struct {
+	Status     ofxStatus `xml:"STATUS"`
+	ServerTime string    `xml:"DTSERVER"`
+	Language   string    `xml:"LANGUAGE"`
+}
+
+type ofxStatus struct {
+	Code     string `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxStatementResponse struct {
+	TransactionID string       `xml:"TRNUID"`
+	Status        ofxStatus    `xml:"STATUS"`
+	Statement     ofxStatement `xml:"STMTRS"`
+}
+
+type ofxStatement struct {
+	Currency         string             `xml:"CURDEF"`
+	Account          ofxAccount         `xml:"BANKACCTFROM"`
+	Transactions     ofxTransactionList `xml:"BANKTRANLIST"`
+	LedgerBalance    ofxBalance         `xml:"LEDGERBAL"`
+	AvailableBalance *ofxBalance        `xml:"AVAILBAL,omitempty"`
+}
+
+type ofxAccount struct {
+	BankID    string `xml:"BANKID"`
+	AccountID string `xml:"ACCTID"`
+	Type      string `xml:"ACCTTYPE"`
+}
+
+type ofxTransactionList struct {
+	Start        string           `xml:"DTSTART"`
+	End          string           `xml:"DTEND"`
+	Transactions []ofxTransaction `xml:"STMTTRN"`
+}
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	Amount string `xml:"TRNAMT"`
+	FITID  string `xml:"FITID"`
+	Name   string `xml:"NAME,omitempty"`
+	Memo   string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+// OFXTransaction is a statement transaction (STMTTRN) of an OFX document
+type OFXTransaction struct {
+	Type   string    `json:"type"`
+	Posted time.Time `json:"posted"`
+	// Amount is positive for credits and negative for debits
+	Amount int64  `json:"amount"`
+	FITID  string `json:"fitid"`
+	Name   string `json:"name"`
+	Memo   string `json:"memo"`
+}
+
+// OFX is a parsed OFX bank statement response
+type OFX struct {
+	BankID          string           `json:"bank_id"`
+	AccountID       string           `json:"account_id"`
+	Currency        string           `json:"currency"`
+	Start           time.Time        `json:"start"`
+	End             time.Time        `json:"end"`
+	Transactions    []OFXTransaction `json:"transactions"`
+	LedgerBalance   int64            `json:"ledger_balance"`
+	LedgerBalanceAt time.Time        `json:"ledger_balance_at"`
+	// AvailableBalance is only set when the document has one
+	AvailableBalance *int64 `json:"available_balance"`
+}
+
+// WriteOFX writes statement as an OFX 2.2 bank statement response of the bank bankID, created at createdAt.
+// OFX has no opening balance: the ledger balance is the closing balance, the available balance adds the overdraft limit.
+func WriteOFX(w io.Writer, statement Statement, bankID string, createdAt time.Time) error {
+	ok := ofxStatus{Code: "0", Severity: "INFO"}
+
+	stmt := ofxStatement{
+		Currency: statement.Account.Currency,
+		Account: ofxAccount{
+			BankID:    bankID,
+			AccountID: statement.Account.AccountNumber,
+			Type:      "CHECKING",
+		},
+		// DTEND 依 OFX 的定義是下一次下載的 DTSTART，所以用不含的 To
+		Transactions: ofxTransactionList{
+			Start: ofxTime(statement.From),
+			End:   ofxTime(statement.To),
+		},
+		LedgerBalance: ofxBalance{Amount: formatAmount(statement.ClosingBalance), AsOf: ofxTime(statement.To)},
+		AvailableBalance: &ofxBalance{
+			Amount: formatAmount(statement.ClosingBalance + statement.Account.OverdraftLimit),
+			AsOf:   ofxTime(statement.To),
+		},
+	}
+	for _, entry := range statement.Entries {
+		stmt.Transactions.Transactions = append(stmt.Transactions.Transactions, ofxTransactionOf(entry))
+	}
+
+	document := ofxDocument{
+		SignOn: ofxSignOn{Status: ok, ServerTime: ofxTime(createdAt), Language: "ENG"},
+		Bank: ofxStatementResponse{
+			TransactionID: statement.ID(),
+			Status:        ok,
+			Statement:     stmt,
+		},
+	}
+
+	if _, err := io.WriteString(w, xml.Header+ofxHeader); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func ofxTransactionOf(entry Entry) ofxTransaction {
+	kind := entry.Kind
+	if kind == "" {
+		kind = "other"
+	}
+	memo := kind
+	switch {
+	case entry.Reference != "":
+		memo += " " + entry.Reference
+	case entry.TransferID != 0:
+		memo += " T" + formatID(entry.TransferID)
+	}
+
+	transaction := ofxTransaction{
+		Type:   ofxTransactionType(entry),
+		Posted: ofxTime(entry.BookedAt),
+		Amount: formatAmount(entry.Amount),
+		// FITID 要在同一個帳戶裡唯一而且重新下載時不變，entry id 正好符合
+		FITID: formatID(entry.EntryID),
+		Memo:  truncate(memo, ofxMemo),
+	}
+	if entry.CounterpartyAccountNumber != "" {
+		transaction.Name = truncate("Account "+entry.CounterpartyAccountNumber, ofxName)
+	}
+	return transaction
+}
+
+func ofxTransactionType(entry Entry) string {
+	switch entry.Kind {
+	case KindTransfer:
+		return OFXTransfer
+	case KindDeposit:
+		return OFXDeposit
+	case KindWithdrawal:
+		return OFXDebit
+	case KindReversal:
+		if entry.Amount < 0 {
+			return OFXDebit
+		}
+		return OFXCredit
+	}
+	return OFXOther
+}
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format(ofxDateTime) + "[0:GMT]"
+}
+
+// ParseOFX parses an OFX 2.x bank statement response as written by WriteOFX.
+// OFX 1.x SGML documents, whose elements are not closed, are not supported.
+func ParseOFX(r io.Reader) (OFX, error) {
+	var document ofxDocument
+	if err := xml.NewDecoder(r).Decode(&document); err != nil {
+		return OFX{}, fmt.Errorf("%w: %v", ErrInvalidOFX, err)
+	}
+
+	response := document.Bank
+	if response.Status.Code != "0" {
+		return OFX{}, fmt.Errorf("%w: statement status %q", ErrInvalidOFX, response.Status.Code)
+	}
+	stmt := response.Statement
+
+	result := OFX{
+		BankID:    stmt.Account.BankID,
+		AccountID: stmt.Account.AccountID,
+		Currency:  stmt.Currency,
+	}
+
+	var err error
+	if result.Start, err = parseOFXTime(stmt.Transactions.Start); err != nil {
+		return OFX{}, err
+	}
+	if result.End, err = parseOFXTime(stmt.Transactions.End); err != nil {
+		return OFX{}, err
+	}
+	if result.LedgerBalance, err = parseOFXAmount(stmt.LedgerBalance.Amount); err != nil {
+		return OFX{}, err
+	}
+	if result.LedgerBalanceAt, err = parseOFXTime(stmt.LedgerBalance.AsOf); err != nil {
+		return OFX{}, err
+	}
+	if stmt.AvailableBalance != nil {
+		available, err := parseOFXAmount(stmt.AvailableBalance.Amount)
+		if err != nil {
+			return OFX{}, err
+		}
+		result.AvailableBalance = &available
+	}
+
+	for _, transaction := range stmt.Transactions.Transactions {
+		posted, err := parseOFXTime(transaction.Posted)
+		if err != nil {
+			return OFX{}, err
+		}
+		amount, err := parseOFXAmount(transaction.Amount)
+		if err != nil {
+			return OFX{}, err
+		}
+		if transaction.FITID == "" {
+			return OFX{}, fmt.Errorf("%w: transaction without FITID", ErrInvalidOFX)
+		}
+
+		result.Transactions = append(result.Transactions, OFXTransaction{
+			Type:   transaction.Type,
+			Posted: posted,
+			Amount: amount,
+			FITID:  transaction.FITID,
+			Name:   transaction.Name,
+			Memo:   transaction.Memo,
+		})
+	}
+	return result, nil
+}
+
+func parseOFXAmount(value string) (int64, error) {
+	amount, err := parseAmount(strings.TrimSpace(value), ".,")
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidOFX, err)
+	}
+	return amount, nil
+}
+
+// parseOFXTime 讀 OFX 的日期：YYYYMMDD[HHMMSS[.XXX]][gmt offset[:tz name]]，沒有時區時當成 GMT
+func parseOFXTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	location := time.UTC
+	if i := strings.IndexByte(value, '['); i >= 0 {
+		zone := strings.TrimSuffix(value[i+1:], "]")
+		value = value[:i]
+
+		offset, name, _ := strings.Cut(zone, ":")
+		hours, err := strconv.ParseFloat(offset, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: malformed time zone %q", ErrInvalidOFX, zone)
+		}
+		location = time.FixedZone(name, int(hours*3600))
+	}
+
+	for _, layout := range []string{ofxDateTime, "20060102150405", "20060102"} {
+		if len(value) != len(layout) {
+			continue
+		}
+		t, err := time.ParseInLocation(layout, value, location)
+		if err != nil {
+			break
+		}
+		return t.UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: malformed date %q", ErrInvalidOFX, value)
+}
@@ -0,0 +1,117 @@
+package statement
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testBankID = "004"
+
+func TestWriteOFX(t *testing.T) {
+	statement := testStatement()
+	statement.Account.OverdraftLimit = 10000
+
+	var out bytes.Buffer
+	err := WriteOFX(&out, statement, testBankID, time.Date(2026, time.April, 1, 6, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	requireGolden(t, "ofx.golden.ofx", out.Bytes())
+}
+
+func TestOFXRoundTrip(t *testing.T) {
+	statement := testStatement()
+	statement.Account.OverdraftLimit = 10000
+
+	var out bytes.Buffer
+	require.NoError(t, WriteOFX(&out, statement, testBankID, time.Now()))
+
+	document, err := ParseOFX(&out)
+	require.NoError(t, err)
+	require.Equal(t, testBankID, document.BankID)
+	require.Equal(t, "TW40ANDY482910375561", document.AccountID)
+	require.Equal(t, "EUR", document.Currency)
+	require.Equal(t, statement.From, document.Start)
+	require.Equal(t, statement.To, document.End)
+	require.Equal(t, statement.ClosingBalance, document.LedgerBalance)
+	require.Equal(t, statement.To, document.LedgerBalanceAt)
+	require.NotNil(t, document.AvailableBalance)
+	require.Equal(t, int64(5450), *document.AvailableBalance)
+
+	// OFX 沒有期初餘額，期末餘額減掉所有交易要回到對帳單的期初
+	require.Len(t, document.Transactions, len(statement.Entries))
+	opening := document.LedgerBalance
+	for i, entry := range statement.Entries {
+		transaction := document.Transactions[i]
+		require.Equal(t, entry.Amount, transaction.Amount)
+		require.Equal(t, entry.BookedAt, transaction.Posted)
+		require.Equal(t, formatID(entry.EntryID), transaction.FITID)
+		opening -= transaction.Amount
+	}
+	require.Equal(t, statement.OpeningBalance, opening)
+
+	require.Equal(t, OFXDeposit, document.Transactions[0].Type)
+	require.Equal(t, "deposit SEPA-2026-03-02-0001", document.Transactions[0].Memo)
+	require.Equal(t, OFXTransfer, document.Transactions[2].Type)
+	require.Equal(t, "Account TW46ANDY295574031886", document.Transactions[2].Name)
+	require.Equal(t, "transfer T80", document.Transactions[2].Memo)
+	require.Equal(t, OFXOther, document.Transactions[3].Type)
+}
+
+func TestOFXTransactionType(t *testing.T) {
+	require.Equal(t, OFXDebit, ofxTransactionType(Entry{Kind: KindWithdrawal, Amount: -1}))
+	require.Equal(t, OFXCredit, ofxTransactionType(Entry{Kind: KindReversal, Amount: 1}))
+	require.Equal(t, OFXDebit, ofxTransactionType(Entry{Kind: KindReversal, Amount: -1}))
+	require.Equal(t, OFXOther, ofxTransactionType(Entry{}))
+}
+
+func TestParseOFXTime(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  time.Time
+	}{
+		{"20260302093000.000[0:GMT]", time.Date(2026, time.March, 2, 9, 30, 0, 0, time.UTC)},
+		{"20260302093000[-5:EST]", time.Date(2026, time.March, 2, 14, 30, 0, 0, time.UTC)},
+		{"20260302093000.250[5.5:IST]", time.Date(2026, time.March, 2, 4, 0, 0, 250*int(time.Millisecond), time.UTC)},
+		{"20260302", time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseOFXTime(tc.value)
+		require.NoError(t, err, tc.value)
+		require.Equal(t, tc.want, got, tc.value)
+	}
+
+	for _, value := range []string{"", "2026-03-02", "20260302093000[x:GMT]", "20261302"} {
+		_, err := parseOFXTime(value)
+		require.ErrorIs(t, err, ErrInvalidOFX, value)
+	}
+}
+
+func TestParseOFXInvalid(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteOFX(&out, testStatement(), testBankID, time.Now()))
+	valid := out.String()
+
+	testCases := []struct {
+		name     string
+		document string
+	}{
+		{"not xml", "OFXHEADER:100\nDATA:OFXSGML\n<OFX><SIGNONMSGSRSV1>"},
+		{"error status", strings.Replace(valid, "<STMTTRNRS>\n      <TRNUID>42-20260301</TRNUID>\n      <STATUS>\n        <CODE>0</CODE>", "<STMTTRNRS>\n      <TRNUID>42-20260301</TRNUID>\n      <STATUS>\n        <CODE>2000</CODE>", 1)},
+		{"malformed amount", strings.Replace(valid, "<TRNAMT>500.00</TRNAMT>", "<TRNAMT>500.001</TRNAMT>", 1)},
+		{"malformed date", strings.Replace(valid, "<DTPOSTED>20260302093000.000[0:GMT]</DTPOSTED>", "<DTPOSTED>2026-03-02</DTPOSTED>", 1)},
+		{"missing FITID", strings.Replace(valid, "<FITID>1001</FITID>", "<FITID></FITID>", 1)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NotEqual(t, valid, tc.document)
+			_, err := ParseOFX(strings.NewReader(tc.document))
+			require.ErrorIs(t, err, ErrInvalidOFX)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package statement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+// Kinds of statement entries, see ListStatementEntries
+const (
+	KindTransfer   = "transfer"
+	KindDeposit    = "deposit"
+	KindWithdrawal = "withdrawal"
+	KindReversal   = "reversal"
+	KindAdjustment = "adjustment"
+)
+
+// ErrInvalidPeriod is returned by Build when the period is empty
+var ErrInvalidPeriod = errors.New("invalid statement period")
+
+// Entry is one booked entry of a statement
+type Entry struct {
+	EntryID int64 `json:"entry_id"`
+	// Amount is positive for credits and negative for debits
+	Amount   int64     `json:"amount"`
+	BookedAt time.Time `json:"booked_at"`
+	Kind     string    `json:"kind"`
+	// TransferID is 0 for entries of external transfers and adjustments
+	TransferID            int64 `json:"transfer_id"`
+	CounterpartyAccountID int64 `json:"counterparty_account_id"`
+	// CounterpartyAccountNumber is what the exported files show of the counterparty, empty when there is none
+	CounterpartyAccountNumber string `json:"counterparty_account_number"`
+	Reference                 string `json:"reference"`
+}
+
+// Statement is the booked entries of an account for the period [From, To)
+type Statement struct {
+	Account        db.Account `json:"account"`
+	From           time.Time  `json:"from"`
+	To             time.Time  `json:"to"`
+	OpeningBalance int64      `json:"opening_balance"`
+	ClosingBalance int64      `json:"closing_balance"`
+	Entries        []Entry    `json:"entries"`
+}
+
+// ID identifies the statement by account and period start
+func (statement Statement) ID() string {
+	return fmt.Sprintf("%d-%s", statement.Account.ID, statement.From.UTC().Format("20060102"))
+}
+
+// LastDay returns the last day the statement covers, To is exclusive
+func (statement Statement) LastDay() time.Time {
+	return statement.To.UTC().Add(-time.Second)
+}
+
+// Credits returns the number and the sum of the credit entries
+func (statement Statement) Credits() (int, int64) {
+	var count int
+	var sum int64
+	for _, entry := range statement.Entries {
+		if entry.Amount > 0 {
+			count++
+			sum += entry.Amount
+		}
+	}
+	return count, sum
+}
+
+// Debits returns the number and the sum of the debit entries, the sum is positive
+func (statement Statement) Debits() (int, int64) {
+	var count int
+	var sum int64
+	for _, entry := range statement.Entries {
+		if entry.Amount < 0 {
+			count++
+			sum -= entry.Amount
+		}
+	}
+	return count, sum
+}
+
+// Store is the part of db.Store used by Build
+type Store interface {
+	GetAccount(ctx context.Context, id int64) (db.Account, error)
+	GetBalanceAt(ctx context.Context, arg db.GetBalanceAtParams) (db.GetBalanceAtRow, error)
+	ListStatementEntries(ctx context.Context, arg db.ListStatementEntriesParams) ([]db.ListStatementEntriesRow, error)
+}
+
+var _ Store = (*db.Store)(nil)
+
+// Build reads the statement of account for the period [from, to).
+// It fails with db.ErrBalanceArchived when from falls in an archived month of the account, its opening balance is unknown.
+func Build(ctx context.Context, store Store, accountID int64, from time.Time, to time.Time) (Statement, error) {
+	if !from.Before(to) {
+		return Statement{}, fmt.Errorf("%w: %s is not before %s", ErrInvalidPeriod, from, to)
+	}
+
+	account, err := store.GetAccount(ctx, accountID)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	opening, err := store.GetBalanceAt(ctx, db.GetBalanceAtParams{AccountID: accountID, At: from})
+	if err != nil {
+		return Statement{}, err
+	}
+	if opening.Archived {
+		return Statement{}, fmt.Errorf("%w: account %d at %s", db.ErrBalanceArchived, accountID, from.UTC().Format(time.RFC3339))
+	}
+
+	rows, err := store.ListStatementEntries(ctx, db.ListStatementEntriesParams{
+		AccountID: accountID,
+		FromTime:  from,
+		ToTime:    to,
+	})
+	if err != nil {
+		return Statement{}, err
+	}
+
+	statement := Statement{
+		Account:        account,
+		From:           from.UTC(),
+		To:             to.UTC(),
+		OpeningBalance: opening.Balance,
+		ClosingBalance: opening.Balance,
+		Entries:        make([]Entry, 0, len(rows)),
+	}
+	// 期末餘額用期初加上列出來的 entries 算，不另外查，兩者一定對得起來
+	for _, row := range rows {
+		statement.Entries = append(statement.Entries, Entry{
+			EntryID:                   row.ID,
+			Amount:                    row.Amount,
+			BookedAt:                  row.CreatedAt.UTC(),
+			Kind:                      row.Kind,
+			TransferID:                row.TransferID,
+			CounterpartyAccountID:     row.CounterpartyAccountID,
+			CounterpartyAccountNumber: row.CounterpartyAccountNumber,
+			Reference:                 row.Reference,
+		})
+		statement.ClosingBalance += row.Amount
+	}
+	return statement, nil
+}
+
+// formatAmount formats an amount of minor units with two decimals, all the supported currencies have cents
+func formatAmount(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
+
+// formatID is how entries and transfers are identified in the exported files;
+// accounts are identified by their account number, the id is internal
+func formatID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// parseAmount parses a decimal amount with at most two decimals into minor units,
+// separators lists the accepted decimal separators
+func parseAmount(value string, separators string) (int64, error) {
+	digits, negative := strings.CutPrefix(value, "-")
+	if !negative {
+		digits = strings.TrimPrefix(digits, "+")
+	}
+
+	units, cents := digits, ""
+	if i := strings.IndexAny(digits, separators); i >= 0 {
+		units, cents = digits[:i], digits[i+1:]
+	}
+	if units == "" || len(cents) > 2 || strings.Trim(units+cents, "0123456789") != "" {
+		return 0, fmt.Errorf("malformed amount %q", value)
+	}
+	// 小數不足兩位時補零，"12,5" 是 12.50
+	cents += strings.Repeat("0", 2-len(cents))
+
+	amount, err := strconv.ParseInt(units+cents, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed amount %q: %w", value, err)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
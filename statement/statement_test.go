@@ -0,0 +1,133 @@
+package statement
+
+import (
+	"context"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// requireGolden compares got with testdata/name, with -update it rewrites the file instead
+func requireGolden(t *testing.T, name string, got []byte) {
+	path := "testdata/" + name
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(got))
+}
+
+// testStatement 是 golden 檔共用的對帳單：入金、轉入、轉出、調帳，期末餘額變成負的
+func testStatement() Statement {
+	return Statement{
+		Account: db.Account{
+			ID:            42,
+			AccountNumber: "TW40ANDY482910375561",
+			Owner:         "Acme Trading Ltd",
+			Currency:      "EUR",
+		},
+		From:           time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+		To:             time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC),
+		OpeningBalance: 125000,
+		ClosingBalance: -4550,
+		Entries: []Entry{
+			{EntryID: 1001, Amount: 50000, BookedAt: time.Date(2026, time.March, 2, 9, 30, 0, 0, time.UTC), Kind: KindDeposit, CounterpartyAccountID: 2, CounterpartyAccountNumber: "TW55ANDY100000000017", Reference: "SEPA-2026-03-02-0001"},
+			{EntryID: 1005, Amount: 1999, BookedAt: time.Date(2026, time.March, 10, 14, 0, 5, 0, time.UTC), Kind: KindTransfer, TransferID: 77, CounterpartyAccountID: 51, CounterpartyAccountNumber: "TW74ANDY730118264905"},
+			{EntryID: 1010, Amount: -179549, BookedAt: time.Date(2026, time.March, 20, 8, 15, 0, 0, time.UTC), Kind: KindTransfer, TransferID: 80, CounterpartyAccountID: 63, CounterpartyAccountNumber: "TW46ANDY295574031886"},
+			{EntryID: 1012, Amount: -2000, BookedAt: time.Date(2026, time.March, 31, 23, 59, 59, 0, time.UTC), Kind: KindAdjustment, CounterpartyAccountID: 3, CounterpartyAccountNumber: "TW94ANDY861203349170"},
+		},
+	}
+}
+
+type fakeStore struct {
+	account db.Account
+	opening db.GetBalanceAtRow
+	rows    []db.ListStatementEntriesRow
+}
+
+func (store fakeStore) GetAccount(ctx context.Context, id int64) (db.Account, error) {
+	return store.account, nil
+}
+
+func (store fakeStore) GetBalanceAt(ctx context.Context, arg db.GetBalanceAtParams) (db.GetBalanceAtRow, error) {
+	return store.opening, nil
+}
+
+func (store fakeStore) ListStatementEntries(ctx context.Context, arg db.ListStatementEntriesParams) ([]db.ListStatementEntriesRow, error) {
+	return store.rows, nil
+}
+
+func TestBuild(t *testing.T) {
+	store := fakeStore{
+		account: db.Account{ID: 7, Owner: "alice", Currency: "USD"},
+		opening: db.GetBalanceAtRow{Balance: 1000},
+		rows: []db.ListStatementEntriesRow{
+			{ID: 1, Amount: 250, Kind: KindTransfer, TransferID: 9, CounterpartyAccountID: 8},
+			{ID: 2, Amount: -1500, Kind: KindWithdrawal, CounterpartyAccountID: 3, CounterpartyAccountNumber: "TW94ANDY861203349170", Reference: "wd-1"},
+		},
+	}
+	from := time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	statement, err := Build(context.Background(), store, 7, from, to)
+	require.NoError(t, err)
+	require.Equal(t, "7-20260501", statement.ID())
+	require.Equal(t, int64(1000), statement.OpeningBalance)
+	require.Equal(t, int64(-250), statement.ClosingBalance)
+	require.Len(t, statement.Entries, 2)
+	require.Equal(t, "wd-1", statement.Entries[1].Reference)
+	require.Equal(t, "TW94ANDY861203349170", statement.Entries[1].CounterpartyAccountNumber)
+
+	count, sum := statement.Credits()
+	require.Equal(t, 1, count)
+	require.Equal(t, int64(250), sum)
+	count, sum = statement.Debits()
+	require.Equal(t, 1, count)
+	require.Equal(t, int64(1500), sum)
+
+	_, err = Build(context.Background(), store, 7, to, from)
+	require.ErrorIs(t, err, ErrInvalidPeriod)
+
+	// 期初落在卸下的月份裡，算不出期初餘額
+	store.opening.Archived = true
+	_, err = Build(context.Background(), store, 7, from, to)
+	require.ErrorIs(t, err, db.ErrBalanceArchived)
+}
+
+func TestFormatAmount(t *testing.T) {
+	require.Equal(t, "0.00", formatAmount(0))
+	require.Equal(t, "0.05", formatAmount(5))
+	require.Equal(t, "1795.49", formatAmount(179549))
+	require.Equal(t, "-12.30", formatAmount(-1230))
+}
+
+func TestParseAmount(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  int64
+	}{
+		{"0", 0},
+		{"12,5", 1250},
+		{"100,", 10000},
+		{"-1795.49", -179549},
+		{"+0.05", 5},
+	}
+	for _, tc := range testCases {
+		got, err := parseAmount(tc.value, ".,")
+		require.NoError(t, err, tc.value)
+		require.Equal(t, tc.want, got, tc.value)
+	}
+
+	for _, value := range []string{"", ",50", "1.234", "1 000", "--1", "1,000.00", "99999999999999999999"} {
+		_, err := parseAmount(value, ".,")
+		require.Error(t, err, value)
+	}
+}
@@ -0,0 +1,117 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Prefix of the account numbers of the bank, see the generate_account_number database function
+const (
+	AccountNumberCountry  = "TW"
+	AccountNumberBankCode = "ANDY"
+	// AccountNumberLength is the length of an account number without spaces
+	AccountNumberLength = 20
+)
+
+// ErrInvalidAccountNumber is returned when an account number is not a well-formed IBAN with a valid check digit
+var ErrInvalidAccountNumber = errors.New("invalid account number")
+
+// NormalizeAccountNumber removes the spaces and uppercases an account number as typed by a person
+func NormalizeAccountNumber(number string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(number), ""))
+}
+
+// FormatAccountNumber splits a normalized account number into groups of four characters for display
+func FormatAccountNumber(number string) string {
+	var groups []string
+	for len(number) > 4 {
+		groups = append(groups, number[:4])
+		number = number[4:]
+	}
+	return strings.Join(append(groups, number), " ")
+}
+
+// IBANCheckDigits returns the two ISO 13616 check digits of bban in country
+func IBANCheckDigits(country string, bban string) (string, error) {
+	remainder, err := ibanMod97(bban + country + "00")
+	if err != nil {
+		return "", err
+	}
+	return leftPad(strconv.Itoa(98-remainder), 2), nil
+}
+
+// ValidateIBAN checks the structure and the mod-97 check digits of a normalized IBAN
+func ValidateIBAN(iban string) error {
+	if len(iban) < 5 || len(iban) > 34 {
+		return fmt.Errorf("%w: length must be between 5 and 34", ErrInvalidAccountNumber)
+	}
+	if !isUpperLetters(iban[:2]) || !isDigits(iban[2:4]) {
+		return fmt.Errorf("%w: must start with a country code and two check digits", ErrInvalidAccountNumber)
+	}
+
+	// 前四碼移到最後再 mod 97，結果要是 1
+	remainder, err := ibanMod97(iban[4:] + iban[:4])
+	if err != nil {
+		return err
+	}
+	if remainder != 1 {
+		return fmt.Errorf("%w: check digits do not match", ErrInvalidAccountNumber)
+	}
+	return nil
+}
+
+// ValidateAccountNumber checks that a normalized account number is a valid IBAN with the prefix of the bank
+func ValidateAccountNumber(number string) error {
+	if err := ValidateIBAN(number); err != nil {
+		return err
+	}
+	if !IsBankAccountNumber(number) {
+		return fmt.Errorf("%w: not an account number of the bank", ErrInvalidAccountNumber)
+	}
+	return nil
+}
+
+// IsBankAccountNumber reports whether a normalized IBAN has the country, bank code and length of the account numbers of the bank.
+// It does not check the check digits.
+func IsBankAccountNumber(number string) bool {
+	return len(number) == AccountNumberLength &&
+		number[:2] == AccountNumberCountry &&
+		number[4:4+len(AccountNumberBankCode)] == AccountNumberBankCode &&
+		isDigits(number[4+len(AccountNumberBankCode):])
+}
+
+// ibanMod97 把字母換成 10 到 35 之後的整串數字 mod 97，只接受數字和大寫字母
+func ibanMod97(s string) (int, error) {
+	var digits strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		default:
+			return 0, fmt.Errorf("%w: only digits and uppercase letters are allowed", ErrInvalidAccountNumber)
+		}
+	}
+
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return 0, fmt.Errorf("%w: empty", ErrInvalidAccountNumber)
+	}
+	return int(new(big.Int).Mod(n, big.NewInt(97)).Int64()), nil
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+func isUpperLetters(s string) bool {
+	return s != "" && strings.Trim(s, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == ""
+}
+
+func leftPad(s string, n int) string {
+	return strings.Repeat("0", max(n-len(s), 0)) + s
+}
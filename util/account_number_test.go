@@ -0,0 +1,62 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIBANCheckDigits(t *testing.T) {
+	// 官方範例：DE89 3704 0044 0532 0130 00、GB82 WEST 1234 5698 7654 32
+	checkDigits, err := IBANCheckDigits("DE", "370400440532013000")
+	require.NoError(t, err)
+	require.Equal(t, "89", checkDigits)
+
+	checkDigits, err = IBANCheckDigits("GB", "WEST12345698765432")
+	require.NoError(t, err)
+	require.Equal(t, "82", checkDigits)
+
+	_, err = IBANCheckDigits("DE", "3704-0044")
+	require.ErrorIs(t, err, ErrInvalidAccountNumber)
+}
+
+func TestValidateIBAN(t *testing.T) {
+	require.NoError(t, ValidateIBAN("DE89370400440532013000"))
+	require.NoError(t, ValidateIBAN(NormalizeAccountNumber("gb82 west 1234 5698 7654 32")))
+
+	for _, iban := range []string{
+		"",
+		"DE8937",
+		"DE88370400440532013000",
+		"D189370400440532013000",
+		"DEX9370400440532013000",
+		"DE89 3704 0044 0532 0130 00",
+		"DE89370400440532013000000000000000000",
+	} {
+		require.ErrorIs(t, ValidateIBAN(iban), ErrInvalidAccountNumber, iban)
+	}
+}
+
+func TestValidateAccountNumber(t *testing.T) {
+	for range 100 {
+		number := RandomAccountNumber()
+		require.Len(t, number, AccountNumberLength)
+		require.NoError(t, ValidateAccountNumber(number))
+	}
+
+	// 合法的 IBAN，但不是本行的帳號
+	require.NoError(t, ValidateIBAN("DE89370400440532013000"))
+	require.ErrorIs(t, ValidateAccountNumber("DE89370400440532013000"), ErrInvalidAccountNumber)
+
+	// 改掉任何一位數字，檢查碼就對不上
+	number := RandomAccountNumber()
+	last := number[len(number)-1]
+	typo := number[:len(number)-1] + string('0'+(last-'0'+1)%10)
+	require.ErrorIs(t, ValidateAccountNumber(typo), ErrInvalidAccountNumber)
+}
+
+func TestFormatAccountNumber(t *testing.T) {
+	require.Equal(t, "TW12 ANDY 0000 0000 0042", FormatAccountNumber("TW12ANDY000000000042"))
+	require.Equal(t, "DE89 3704 0044 0532 0130 00", FormatAccountNumber("DE89370400440532013000"))
+	require.Equal(t, "TW12ANDY000000000042", NormalizeAccountNumber(" tw12 andy 0000\t0000 0042 "))
+}
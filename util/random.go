@@ -1,6 +1,7 @@
 package util
 
 import (
+	"fmt"       // 用於格式化帳號的數字部分
 	"math/rand" // Go 標準庫的隨機數產生套件，提供 Intn、Int63n 等方法
 	"strings"   // 用於操作字串，這裡主要使用 strings.Builder 來高效拼接字串
 	"time"      // 用於取得系統時間，作為隨機數種子的來源
@@ -67,7 +68,14 @@ func RandomMoney() int64 {
 // RandomCurrency generates a random currency
 // 隨機從 currencies 切片中挑一個貨幣代碼回傳
 func RandomCurrency() string {
-	currencies := []string{"USD", "EUR", "CAD"}
+	currencies := []string{USD, EUR, CAD}
 	// rng.Intn(len(currencies)) 會回傳 0,1,2 中的一個隨機索引
 	return currencies[rng.Intn(len(currencies))]
 }
+
+// RandomAccountNumber generates a random account number with valid check digits
+func RandomAccountNumber() string {
+	bban := AccountNumberBankCode + fmt.Sprintf("%012d", rng.Int63n(1e12))
+	checkDigits, _ := IBANCheckDigits(AccountNumberCountry, bban)
+	return AccountNumberCountry + checkDigits + bban
+}
@@ -0,0 +1,228 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+// Delivery statuses, a dead delivery has exhausted its retries and waits for a manual replay
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+)
+
+// DelivererConfig contains the retry and HTTP settings of a Deliverer
+type DelivererConfig struct {
+	// MaxAttempts is the number of attempts before a delivery is moved to the dead state
+	MaxAttempts int32
+	// BaseBackoff is the delay after the first failed attempt, it doubles after every failure
+	BaseBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts
+	MaxBackoff time.Duration
+	// BatchSize is the maximum number of deliveries claimed at once
+	BatchSize int32
+	// Lease is added to BatchSize×Timeout, the longest the requests of a batch can take,
+	// to hide claimed deliveries from other deliverers until the whole batch is sent
+	Lease time.Duration
+	// Timeout bounds a single HTTP request
+	Timeout time.Duration
+	// PollInterval is how long Run waits between two rounds
+	PollInterval time.Duration
+}
+
+// DefaultDelivererConfig retries for roughly a day: 30s, 1m, 2m, ... capped at 6h
+var DefaultDelivererConfig = DelivererConfig{
+	MaxAttempts:  12,
+	BaseBackoff:  30 * time.Second,
+	MaxBackoff:   6 * time.Hour,
+	BatchSize:    50,
+	Lease:        time.Minute,
+	Timeout:      10 * time.Second,
+	PollInterval: time.Second,
+}
+
+// Deliverer POSTs pending deliveries to their endpoints, retrying failures with exponential backoff
+type Deliverer struct {
+	store  Store
+	client *http.Client
+	config DelivererConfig
+}
+
+// NewDeliverer creates a new Deliverer, zero values in config are replaced by DefaultDelivererConfig
+func NewDeliverer(store Store, config DelivererConfig) *Deliverer {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultDelivererConfig.MaxAttempts
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = DefaultDelivererConfig.BaseBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultDelivererConfig.MaxBackoff
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultDelivererConfig.BatchSize
+	}
+	if config.Lease <= 0 {
+		config.Lease = DefaultDelivererConfig.Lease
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultDelivererConfig.Timeout
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultDelivererConfig.PollInterval
+	}
+
+	return &Deliverer{
+		store:  store,
+		client: &http.Client{Timeout: config.Timeout},
+		config: config,
+	}
+}
+
+// Backoff returns the delay before the next attempt after attempt failed
+func (deliverer *Deliverer) Backoff(attempt int32) time.Duration {
+	delay := deliverer.config.BaseBackoff
+	for i := int32(1); i < attempt; i++ {
+		delay *= 2
+		if delay >= deliverer.config.MaxBackoff {
+			return deliverer.config.MaxBackoff
+		}
+	}
+	return min(delay, deliverer.config.MaxBackoff)
+}
+
+// DeliverDue sends every delivery that is due and returns how many attempts were made
+func (deliverer *Deliverer) DeliverDue(ctx context.Context) (int, error) {
+	// 先用 lease 把這批 delivery 的 next_attempt_at 往後推，再在事務外送 HTTP：
+	// 不會為了等對方回應一直佔著資料庫事務，deliverer 當掉的話 lease 到期後別的 deliverer 會接手重送。
+	// 一批是一個接一個送的，lease 要撐過整批都逾時的情況
+	batch := time.Duration(deliverer.config.BatchSize) * deliverer.config.Timeout
+	deliveries, err := deliverer.store.ClaimDueWebhookDeliveries(ctx, db.ClaimDueWebhookDeliveriesParams{
+		LeaseUntil: time.Now().Add(batch + deliverer.config.Lease),
+		LimitCount: deliverer.config.BatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		_, err := deliverer.deliver(ctx, delivery)
+		if errors.Is(err, db.ErrWebhookClaimLost) {
+			// 別的 deliverer 已經記下這一次的結果，這邊的結果不算
+			log.Printf("webhook deliverer: %v", err)
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// Replay moves a delivery, usually a dead one, back to pending so that it is sent again with a fresh retry budget.
+// The previous attempts stay in the delivery log and the attempt numbers keep counting up, the attempts carry the replay they belong to.
+func (deliverer *Deliverer) Replay(ctx context.Context, deliveryID int64) (db.WebhookDelivery, error) {
+	return deliverer.store.ReplayWebhookDelivery(ctx, deliveryID)
+}
+
+// Run keeps delivering until ctx is cancelled
+func (deliverer *Deliverer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(deliverer.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := deliverer.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("webhook deliverer: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (deliverer *Deliverer) deliver(ctx context.Context, delivery db.WebhookDelivery) (db.RecordWebhookAttemptTxResult, error) {
+	attempt := delivery.Attempts + 1
+	arg := db.RecordWebhookAttemptTxParams{
+		DeliveryID: delivery.ID,
+		Attempt:    attempt,
+		Replay:     delivery.Replays,
+	}
+	// 重試次數和 backoff 從最近一次 replay 開始算
+	replayAttempt := attempt - delivery.ReplayedAttempts
+
+	start := time.Now()
+	endpoint, err := deliverer.store.GetWebhookEndpoint(ctx, delivery.EndpointID)
+	if err != nil {
+		return db.RecordWebhookAttemptTxResult{}, err
+	}
+
+	var sendErr error
+	if endpoint.IsActive {
+		var statusCode int
+		statusCode, sendErr = deliverer.send(ctx, endpoint, delivery, attempt)
+		if statusCode != 0 {
+			arg.StatusCode = sql.NullInt32{Int32: int32(statusCode), Valid: true}
+		}
+	} else {
+		sendErr = fmt.Errorf("endpoint %d is disabled", endpoint.ID)
+	}
+	arg.Duration = time.Since(start)
+
+	switch {
+	case sendErr == nil:
+		arg.Status = StatusSucceeded
+		arg.NextAttemptAt = time.Now()
+	case replayAttempt >= deliverer.config.MaxAttempts || !endpoint.IsActive:
+		arg.Status = StatusDead
+		arg.NextAttemptAt = time.Now()
+		arg.Error = sql.NullString{String: sendErr.Error(), Valid: true}
+	default:
+		arg.Status = StatusPending
+		arg.NextAttemptAt = time.Now().Add(deliverer.Backoff(replayAttempt))
+		arg.Error = sql.NullString{String: sendErr.Error(), Valid: true}
+	}
+
+	return deliverer.store.RecordWebhookAttemptTx(ctx, arg)
+}
+
+func (deliverer *Deliverer) send(ctx context.Context, endpoint db.WebhookEndpoint, delivery db.WebhookDelivery, attempt int32) (int, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderAttempt, strconv.Itoa(int(attempt)))
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, time.Now(), body))
+
+	resp, err := deliverer.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// 讀完 body 連線才能被重用
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
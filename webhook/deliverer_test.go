@@ -0,0 +1,391 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore keeps webhook endpoints and deliveries in memory
+type fakeStore struct {
+	mu         sync.Mutex
+	endpoints  []db.WebhookEndpoint
+	deliveries []db.WebhookDelivery
+	attempts   []db.WebhookDeliveryAttempt
+	leaseUntil time.Time
+}
+
+func (store *fakeStore) CreateWebhookEndpoint(ctx context.Context, arg db.CreateWebhookEndpointParams) (db.WebhookEndpoint, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	endpoint := db.WebhookEndpoint{
+		ID:         int64(len(store.endpoints) + 1),
+		Owner:      arg.Owner,
+		Url:        arg.Url,
+		Secret:     arg.Secret,
+		EventTypes: arg.EventTypes,
+		IsActive:   true,
+		CreatedAt:  time.Now(),
+	}
+	store.endpoints = append(store.endpoints, endpoint)
+	return endpoint, nil
+}
+
+func (store *fakeStore) GetWebhookEndpoint(ctx context.Context, id int64) (db.WebhookEndpoint, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, endpoint := range store.endpoints {
+		if endpoint.ID == id {
+			return endpoint, nil
+		}
+	}
+	return db.WebhookEndpoint{}, sql.ErrNoRows
+}
+
+func (store *fakeStore) ListActiveWebhookEndpointsByOwner(ctx context.Context, owner string) ([]db.WebhookEndpoint, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	endpoints := []db.WebhookEndpoint{}
+	for _, endpoint := range store.endpoints {
+		if endpoint.Owner == owner && endpoint.IsActive {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+func (store *fakeStore) CreateWebhookDelivery(ctx context.Context, arg db.CreateWebhookDeliveryParams) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, delivery := range store.deliveries {
+		if delivery.EndpointID == arg.EndpointID && delivery.EventID == arg.EventID {
+			return nil
+		}
+	}
+	store.deliveries = append(store.deliveries, db.WebhookDelivery{
+		ID:            int64(len(store.deliveries) + 1),
+		EndpointID:    arg.EndpointID,
+		EventID:       arg.EventID,
+		EventType:     arg.EventType,
+		Payload:       arg.Payload,
+		Status:        StatusPending,
+		NextAttemptAt: time.Now(),
+	})
+	return nil
+}
+
+func (store *fakeStore) ClaimDueWebhookDeliveries(ctx context.Context, arg db.ClaimDueWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.leaseUntil = arg.LeaseUntil
+	claimed := []db.WebhookDelivery{}
+	for i := range store.deliveries {
+		delivery := &store.deliveries[i]
+		if delivery.Status != StatusPending || delivery.NextAttemptAt.After(time.Now()) || len(claimed) == int(arg.LimitCount) {
+			continue
+		}
+		delivery.NextAttemptAt = arg.LeaseUntil
+		claimed = append(claimed, *delivery)
+	}
+	return claimed, nil
+}
+
+func (store *fakeStore) RecordWebhookAttemptTx(ctx context.Context, arg db.RecordWebhookAttemptTxParams) (db.RecordWebhookAttemptTxResult, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delivery := &store.deliveries[arg.DeliveryID-1]
+	if delivery.Status != StatusPending || delivery.Attempts != arg.Attempt-1 {
+		return db.RecordWebhookAttemptTxResult{}, db.ErrWebhookClaimLost
+	}
+
+	attempt := db.WebhookDeliveryAttempt{
+		ID:         int64(len(store.attempts) + 1),
+		DeliveryID: arg.DeliveryID,
+		Attempt:    arg.Attempt,
+		Replay:     arg.Replay,
+		StatusCode: arg.StatusCode,
+		Error:      arg.Error,
+		DurationMs: arg.Duration.Milliseconds(),
+	}
+	store.attempts = append(store.attempts, attempt)
+
+	delivery.Status = arg.Status
+	delivery.Attempts = arg.Attempt
+	delivery.NextAttemptAt = arg.NextAttemptAt
+	delivery.LastError = arg.Error
+
+	return db.RecordWebhookAttemptTxResult{Delivery: *delivery, Attempt: attempt}, nil
+}
+
+func (store *fakeStore) ReplayWebhookDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delivery := &store.deliveries[id-1]
+	delivery.Status = StatusPending
+	delivery.Replays++
+	delivery.ReplayedAttempts = delivery.Attempts
+	delivery.NextAttemptAt = time.Now()
+	delivery.LastError = sql.NullString{}
+	return *delivery, nil
+}
+
+func (store *fakeStore) delivery(id int64) db.WebhookDelivery {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.deliveries[id-1]
+}
+
+// receiver is an httptest server that verifies signatures and fails the first failures requests
+type receiver struct {
+	mu       sync.Mutex
+	secret   string
+	failures int
+	events   []Event
+	bad      int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	body, _ := io.ReadAll(req.Body)
+	if err := VerifySignature(r.secret, req.Header.Get(HeaderSignature), body, time.Minute, time.Now()); err != nil {
+		r.bad++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.events = append(r.events, event)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func transferOutboxEvent(t *testing.T, sender string, receiver string) db.Outbox {
+	result := db.TransferTxResult{
+		Transfer:    db.Transfer{ID: 7, FromAccountID: 1, ToAccountID: 2, Amount: 10, CreatedAt: time.Now()},
+		FromAccount: db.Account{ID: 1, Owner: sender, Balance: 90, Currency: "USD"},
+		ToAccount:   db.Account{ID: 2, Owner: receiver, Balance: 110, Currency: "USD"},
+	}
+	payload, err := json.Marshal(result)
+	require.NoError(t, err)
+
+	return db.Outbox{ID: 1, AggregateType: db.AggregateTransfer, AggregateID: 7, EventType: db.EventTransferCreated, Payload: payload}
+}
+
+func setupWebhook(t *testing.T, failures int, config DelivererConfig) (*fakeStore, *receiver, *Deliverer) {
+	store := &fakeStore{}
+	recv := &receiver{failures: failures}
+	server := httptest.NewServer(recv)
+	t.Cleanup(server.Close)
+
+	endpoint, err := RegisterEndpoint(context.Background(), store, "bob", server.URL, []string{EventTransferReceived})
+	require.NoError(t, err)
+	recv.secret = endpoint.Secret
+
+	dispatcher := NewDispatcher(store)
+	require.NoError(t, dispatcher.Publish(context.Background(), transferOutboxEvent(t, "alice", "bob")))
+	// relay 重送同一個 outbox 事件時不會產生重複的 delivery
+	require.NoError(t, dispatcher.Publish(context.Background(), transferOutboxEvent(t, "alice", "bob")))
+	require.Len(t, store.deliveries, 1)
+
+	return store, recv, NewDeliverer(store, config)
+}
+
+// deliverUntil keeps delivering until the delivery is no longer pending
+func deliverUntil(t *testing.T, store *fakeStore, deliverer *Deliverer, id int64) db.WebhookDelivery {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		_, err := deliverer.DeliverDue(context.Background())
+		require.NoError(t, err)
+
+		if delivery := store.delivery(id); delivery.Status != StatusPending {
+			return delivery
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("delivery is still pending")
+	return db.WebhookDelivery{}
+}
+
+func TestDeliverRetriesUntilSuccess(t *testing.T) {
+	store, recv, deliverer := setupWebhook(t, 2, DelivererConfig{
+		MaxAttempts: 5,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  4 * time.Millisecond,
+	})
+
+	delivery := deliverUntil(t, store, deliverer, 1)
+	require.Equal(t, StatusSucceeded, delivery.Status)
+	require.Equal(t, int32(3), delivery.Attempts)
+	require.Zero(t, recv.bad)
+
+	require.Len(t, recv.events, 1)
+	event := recv.events[0]
+	require.Equal(t, EventTransferReceived, event.Type)
+	require.Equal(t, DirectionCredit, event.Data.Direction)
+	require.Equal(t, int64(2), event.Data.AccountID)
+	require.Equal(t, int64(1), event.Data.CounterpartyAccountID)
+	require.Equal(t, int64(10), event.Data.Amount)
+	require.Equal(t, int64(110), event.Data.Balance)
+
+	require.Len(t, store.attempts, 3)
+	for i, attempt := range store.attempts {
+		require.Equal(t, int32(i+1), attempt.Attempt)
+	}
+	require.Equal(t, int32(http.StatusServiceUnavailable), store.attempts[0].StatusCode.Int32)
+	require.True(t, store.attempts[0].Error.Valid)
+	require.Equal(t, int32(http.StatusNoContent), store.attempts[2].StatusCode.Int32)
+	require.False(t, store.attempts[2].Error.Valid)
+}
+
+func TestDeliverDeadLetterAndReplay(t *testing.T) {
+	store, recv, deliverer := setupWebhook(t, 3, DelivererConfig{
+		MaxAttempts: 3,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+	})
+
+	delivery := deliverUntil(t, store, deliverer, 1)
+	require.Equal(t, StatusDead, delivery.Status)
+	require.Equal(t, int32(3), delivery.Attempts)
+	require.Empty(t, recv.events)
+
+	// dead 的 delivery 不會再被送出
+	n, err := deliverer.DeliverDue(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	_, err = deliverer.Replay(context.Background(), delivery.ID)
+	require.NoError(t, err)
+
+	delivery = deliverUntil(t, store, deliverer, 1)
+	require.Equal(t, StatusSucceeded, delivery.Status)
+	require.Len(t, recv.events, 1)
+	require.Len(t, store.attempts, 4)
+	// replay 之後 attempt 編號接著往下數，記著是第幾次 replay
+	require.Equal(t, int32(4), store.attempts[3].Attempt)
+	require.Equal(t, int32(1), store.attempts[3].Replay)
+	require.Equal(t, int32(4), delivery.Attempts)
+}
+
+func TestDeliverClaimLost(t *testing.T) {
+	store, recv, deliverer := setupWebhook(t, 0, DelivererConfig{})
+	delivery := store.delivery(1)
+
+	// lease 過期後別的 deliverer 重新 claim，先記下了這一次的結果
+	_, err := deliverer.deliver(context.Background(), delivery)
+	require.NoError(t, err)
+	_, err = deliverer.deliver(context.Background(), delivery)
+	require.ErrorIs(t, err, db.ErrWebhookClaimLost)
+
+	require.Len(t, store.attempts, 1)
+	require.Equal(t, StatusSucceeded, store.delivery(1).Status)
+	require.Len(t, recv.events, 2)
+}
+
+func TestBackoff(t *testing.T) {
+	deliverer := NewDeliverer(&fakeStore{}, DelivererConfig{
+		BaseBackoff: time.Second,
+		MaxBackoff:  10 * time.Second,
+	})
+
+	require.Equal(t, time.Second, deliverer.Backoff(1))
+	require.Equal(t, 2*time.Second, deliverer.Backoff(2))
+	require.Equal(t, 8*time.Second, deliverer.Backoff(4))
+	require.Equal(t, 10*time.Second, deliverer.Backoff(5))
+	require.Equal(t, 10*time.Second, deliverer.Backoff(100))
+}
+
+func TestRegisterEndpointValidation(t *testing.T) {
+	store := &fakeStore{}
+
+	_, err := RegisterEndpoint(context.Background(), store, "bob", "ftp://example.com", nil)
+	require.ErrorIs(t, err, ErrInvalidEndpoint)
+
+	_, err = RegisterEndpoint(context.Background(), store, "bob", "https://example.com/hook", []string{"account.created"})
+	require.ErrorIs(t, err, ErrInvalidEndpoint)
+
+	endpoint, err := RegisterEndpoint(context.Background(), store, "bob", "https://example.com/hook", nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, endpoint.Secret)
+	require.Empty(t, endpoint.EventTypes)
+}
+
+func externalTransferOutboxEvent(t *testing.T, eventType string, direction string, status string) db.Outbox {
+	result := db.ExternalTransferTxResult{
+		ExternalTransfer: db.ExternalTransfer{ID: 7, AccountID: 2, ClearingAccountID: 3, Direction: direction, Amount: 10, ExternalRef: "ref-7", Status: status, UpdatedAt: time.Now()},
+		Account:          db.Account{ID: 2, Owner: "bob", Balance: 110, Currency: "USD"},
+		ClearingAccount:  db.Account{ID: 3, Owner: "system", Balance: -110, Currency: "USD"},
+	}
+	payload, err := json.Marshal(result)
+	require.NoError(t, err)
+
+	return db.Outbox{ID: 2, AggregateType: db.AggregateExternalTransfer, AggregateID: 7, EventType: eventType, Payload: payload}
+}
+
+func TestPublishExternalTransfers(t *testing.T) {
+	store := &fakeStore{}
+	_, err := RegisterEndpoint(context.Background(), store, "bob", "https://example.com/hook", nil)
+	require.NoError(t, err)
+	dispatcher := NewDispatcher(store)
+
+	// 入金結清才有錢進來；出金失敗才會退款
+	require.NoError(t, dispatcher.Publish(context.Background(), externalTransferOutboxEvent(t, db.EventExternalTransferSettled, db.ExternalWithdrawal, db.ExternalSettled)))
+	require.NoError(t, dispatcher.Publish(context.Background(), externalTransferOutboxEvent(t, db.EventExternalTransferFailed, db.ExternalDeposit, db.ExternalFailed)))
+	require.Empty(t, store.deliveries)
+
+	require.NoError(t, dispatcher.Publish(context.Background(), externalTransferOutboxEvent(t, db.EventExternalTransferSettled, db.ExternalDeposit, db.ExternalSettled)))
+	require.NoError(t, dispatcher.Publish(context.Background(), externalTransferOutboxEvent(t, db.EventExternalTransferFailed, db.ExternalWithdrawal, db.ExternalFailed)))
+	// 和轉帳同一個 ID 的事件不會被當成重送
+	require.NoError(t, dispatcher.Publish(context.Background(), transferOutboxEvent(t, "alice", "bob")))
+	require.Len(t, store.deliveries, 3)
+	require.Equal(t, EventTransferReceived, store.deliveries[0].EventType)
+	require.Equal(t, EventTransferReversed, store.deliveries[1].EventType)
+	require.Equal(t, EventTransferReceived, store.deliveries[2].EventType)
+
+	var event Event
+	require.NoError(t, json.Unmarshal(store.deliveries[1].Payload, &event))
+	require.Equal(t, DirectionCredit, event.Data.Direction)
+	require.Zero(t, event.Data.TransferID)
+	require.Equal(t, int64(7), event.Data.ExternalTransferID)
+	require.Equal(t, "ref-7", event.Data.ExternalRef)
+	require.Equal(t, int64(2), event.Data.AccountID)
+	require.Equal(t, int64(3), event.Data.CounterpartyAccountID)
+	require.Equal(t, int64(110), event.Data.Balance)
+}
+
+func TestDeliverDueLease(t *testing.T) {
+	store := &fakeStore{}
+	deliverer := NewDeliverer(store, DelivererConfig{BatchSize: 20, Timeout: 5 * time.Second, Lease: time.Minute})
+
+	// 整批都逾時也要在 lease 到期前送完
+	start := time.Now()
+	_, err := deliverer.DeliverDue(context.Background())
+	require.NoError(t, err)
+	require.WithinDuration(t, start.Add(20*5*time.Second+time.Minute), store.leaseUntil, time.Second)
+}
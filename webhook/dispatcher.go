@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/andyrestart9/bank/outbox"
+)
+
+// Store contains the database operations used by webhooks, *db.Store implements it
+type Store interface {
+	CreateWebhookEndpoint(ctx context.Context, arg db.CreateWebhookEndpointParams) (db.WebhookEndpoint, error)
+	GetWebhookEndpoint(ctx context.Context, id int64) (db.WebhookEndpoint, error)
+	ListActiveWebhookEndpointsByOwner(ctx context.Context, owner string) ([]db.WebhookEndpoint, error)
+	CreateWebhookDelivery(ctx context.Context, arg db.CreateWebhookDeliveryParams) error
+	ClaimDueWebhookDeliveries(ctx context.Context, arg db.ClaimDueWebhookDeliveriesParams) ([]db.WebhookDelivery, error)
+	RecordWebhookAttemptTx(ctx context.Context, arg db.RecordWebhookAttemptTxParams) (db.RecordWebhookAttemptTxResult, error)
+	ReplayWebhookDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error)
+}
+
+var _ Store = (*db.Store)(nil)
+
+var ErrInvalidEndpoint = errors.New("invalid webhook endpoint")
+
+// RegisterEndpoint registers a webhook endpoint for owner with a newly generated signing secret.
+// An empty eventTypes subscribes the endpoint to every event type.
+func RegisterEndpoint(ctx context.Context, store Store, owner string, rawURL string, eventTypes []string) (db.WebhookEndpoint, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return db.WebhookEndpoint{}, fmt.Errorf("%w: url must be an absolute http or https url", ErrInvalidEndpoint)
+	}
+
+	for _, eventType := range eventTypes {
+		if !slices.Contains(EventTypes, eventType) {
+			return db.WebhookEndpoint{}, fmt.Errorf("%w: unknown event type %q", ErrInvalidEndpoint, eventType)
+		}
+	}
+
+	secret, err := GenerateSecret()
+	if err != nil {
+		return db.WebhookEndpoint{}, err
+	}
+
+	return store.CreateWebhookEndpoint(ctx, db.CreateWebhookEndpointParams{
+		Owner:      owner,
+		Url:        u.String(),
+		Secret:     secret,
+		EventTypes: append([]string{}, eventTypes...),
+	})
+}
+
+// Dispatcher turns outbox events into webhook deliveries for every subscribed endpoint.
+// It implements outbox.Publisher, so webhooks are fed by the outbox relay and
+// only exist for transfers that were committed.
+type Dispatcher struct {
+	store Store
+}
+
+var _ outbox.Publisher = (*Dispatcher)(nil)
+
+// NewDispatcher creates a new Dispatcher
+func NewDispatcher(store Store) *Dispatcher {
+	return &Dispatcher{store: store}
+}
+
+// Publish enqueues a delivery per subscribed endpoint for the transfer.created and external_transfer.* outbox events,
+// other outbox events are ignored.
+// Enqueuing is idempotent per endpoint and event ID, so the relay may publish the same event again.
+func (dispatcher *Dispatcher) Publish(ctx context.Context, event db.Outbox) error {
+	switch event.EventType {
+	case db.EventTransferCreated:
+		var result db.TransferTxResult
+		if err := json.Unmarshal(event.Payload, &result); err != nil {
+			return fmt.Errorf("cannot unmarshal outbox event %d: %w", event.ID, err)
+		}
+		return dispatcher.Dispatch(ctx, NewTransferEvents(result)...)
+	case db.EventExternalTransferSettled, db.EventExternalTransferFailed:
+		var result db.ExternalTransferTxResult
+		if err := json.Unmarshal(event.Payload, &result); err != nil {
+			return fmt.Errorf("cannot unmarshal outbox event %d: %w", event.ID, err)
+		}
+		return dispatcher.Dispatch(ctx, NewExternalTransferEvents(result)...)
+	default:
+		return nil
+	}
+}
+
+// Dispatch enqueues a delivery of every event to each active endpoint of its owner subscribed to the event type
+func (dispatcher *Dispatcher) Dispatch(ctx context.Context, events ...Event) error {
+	for _, event := range events {
+		endpoints, err := dispatcher.store.ListActiveWebhookEndpointsByOwner(ctx, event.owner)
+		if err != nil {
+			return err
+		}
+
+		var payload []byte
+		for _, endpoint := range endpoints {
+			if !subscribed(endpoint, event.Type) {
+				continue
+			}
+
+			if payload == nil {
+				payload, err = json.Marshal(event)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = dispatcher.store.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+				EndpointID: endpoint.ID,
+				EventID:    event.ID,
+				EventType:  event.Type,
+				Payload:    payload,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func subscribed(endpoint db.WebhookEndpoint, eventType string) bool {
+	return len(endpoint.EventTypes) == 0 || slices.Contains(endpoint.EventTypes, eventType)
+}
@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"fmt"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+// Webhook event types
+const (
+	// EventTransferCreated is sent to the owner of the account money was sent from
+	EventTransferCreated = "transfer.created"
+	// EventTransferReceived is sent to the owner of the account money arrived in, from another account or a settled deposit
+	EventTransferReceived = "transfer.received"
+	// EventTransferReversed is sent to the owner of the account a failed withdrawal gave the money back to
+	EventTransferReversed = "transfer.reversed"
+)
+
+// EventTypes lists every event type an endpoint can subscribe to
+var EventTypes = []string{
+	EventTransferCreated,
+	EventTransferReceived,
+	EventTransferReversed,
+}
+
+// Directions of a transfer seen from the account receiving the webhook
+const (
+	DirectionDebit  = "debit"
+	DirectionCredit = "credit"
+)
+
+// Event is the body POSTed to a webhook endpoint
+type Event struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	CreatedAt time.Time         `json:"created_at"`
+	Data      TransferEventData `json:"data"`
+
+	// owner 是收到這個事件的帳戶擁有者，用來找要送到哪些 endpoint，不會送給對方
+	owner string
+}
+
+// TransferEventData describes a transfer from the point of view of one of its accounts.
+// Deposits and withdrawals carry ExternalTransferID and ExternalRef instead of TransferID,
+// their counterparty is the clearing account of the payment rail.
+type TransferEventData struct {
+	TransferID            int64     `json:"transfer_id,omitempty"`
+	ExternalTransferID    int64     `json:"external_transfer_id,omitempty"`
+	ExternalRef           string    `json:"external_ref,omitempty"`
+	AccountID             int64     `json:"account_id"`
+	CounterpartyAccountID int64     `json:"counterparty_account_id"`
+	Direction             string    `json:"direction"`
+	Amount                int64     `json:"amount"`
+	Currency              string    `json:"currency"`
+	Balance               int64     `json:"balance"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+// NewTransferEvents builds the transfer.created event for the sender and the transfer.received event for the receiver
+func NewTransferEvents(result db.TransferTxResult) []Event {
+	return []Event{
+		newTransferEvent(EventTransferCreated, result, DirectionDebit),
+		newTransferEvent(EventTransferReceived, result, DirectionCredit),
+	}
+}
+
+// NewExternalTransferEvents builds the transfer.received event of a settled deposit and the transfer.reversed event of a failed withdrawal.
+// The other external transfers move no money into the account and have no events.
+func NewExternalTransferEvents(result db.ExternalTransferTxResult) []Event {
+	transfer := result.ExternalTransfer
+	var eventType string
+	switch {
+	case transfer.Direction == db.ExternalDeposit && transfer.Status == db.ExternalSettled:
+		eventType = EventTransferReceived
+	case transfer.Direction == db.ExternalWithdrawal && transfer.Status == db.ExternalFailed:
+		eventType = EventTransferReversed
+	default:
+		return nil
+	}
+
+	return []Event{{
+		// 入出金和轉帳的 ID 是兩個序列，加上 external 才不會和轉帳的事件撞在一起
+		ID:        fmt.Sprintf("%s.external.%d.%d", eventType, transfer.ID, result.Account.ID),
+		Type:      eventType,
+		CreatedAt: transfer.UpdatedAt,
+		Data: TransferEventData{
+			ExternalTransferID:    transfer.ID,
+			ExternalRef:           transfer.ExternalRef,
+			AccountID:             result.Account.ID,
+			CounterpartyAccountID: result.ClearingAccount.ID,
+			Direction:             DirectionCredit,
+			Amount:                transfer.Amount,
+			Currency:              result.Account.Currency,
+			Balance:               result.Account.Balance,
+			CreatedAt:             transfer.UpdatedAt,
+		},
+		owner: result.Account.Owner,
+	}}
+}
+
+func newTransferEvent(eventType string, result db.TransferTxResult, direction string) Event {
+	account, counterparty := result.FromAccount, result.ToAccount
+	if direction == DirectionCredit {
+		account, counterparty = result.ToAccount, result.FromAccount
+	}
+
+	return Event{
+		// 同一筆轉帳、同一個帳戶、同一種事件只會有一個 ID，outbox 重送時可以靠它去重
+		ID:        fmt.Sprintf("%s.%d.%d", eventType, result.Transfer.ID, account.ID),
+		Type:      eventType,
+		CreatedAt: result.Transfer.CreatedAt,
+		Data: TransferEventData{
+			TransferID:            result.Transfer.ID,
+			AccountID:             account.ID,
+			CounterpartyAccountID: counterparty.ID,
+			Direction:             direction,
+			Amount:                result.Transfer.Amount,
+			Currency:              account.Currency,
+			Balance:               account.Balance,
+			CreatedAt:             result.Transfer.CreatedAt,
+		},
+		owner: account.Owner,
+	}
+}
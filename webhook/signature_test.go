@@ -0,0 +1,25 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(secret, secretPrefix))
+
+	body := []byte(`{"id":"transfer.received.1.2"}`)
+	now := time.Now()
+	header := Sign(secret, now, body)
+
+	require.NoError(t, VerifySignature(secret, header, body, 5*time.Minute, now))
+	require.ErrorIs(t, VerifySignature("whsec_other", header, body, 5*time.Minute, now), ErrSignatureMismatch)
+	require.ErrorIs(t, VerifySignature(secret, header, []byte(`{}`), 5*time.Minute, now), ErrSignatureMismatch)
+	require.ErrorIs(t, VerifySignature(secret, header, body, 5*time.Minute, now.Add(time.Hour)), ErrSignatureExpired)
+	require.ErrorIs(t, VerifySignature(secret, "v1=abc", body, 5*time.Minute, now), ErrInvalidSignatureHeader)
+}
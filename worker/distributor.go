@@ -0,0 +1,126 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+const (
+	// DefaultQueue is used when a task is distributed without the Queue option
+	DefaultQueue = "default"
+	// DefaultMaxAttempts is used when a task is distributed without the MaxAttempts option
+	DefaultMaxAttempts = 10
+)
+
+// ErrTaskExists is returned by DistributeTask when a task with the same UniqueKey was already enqueued
+var ErrTaskExists = errors.New("task already exists")
+
+// TaskDistributor puts tasks on a queue for a TaskProcessor to run later
+type TaskDistributor interface {
+	DistributeTask(ctx context.Context, taskType string, payload any, opts ...Option) (db.Task, error)
+}
+
+// TaskCreator inserts tasks, both *db.Store and the *db.Queries bound to a transaction implement it
+type TaskCreator interface {
+	CreateTask(ctx context.Context, arg db.CreateTaskParams) (db.Task, error)
+}
+
+var (
+	_ TaskCreator = (*db.Store)(nil)
+	_ TaskCreator = (*db.Queries)(nil)
+)
+
+// Option configures how a task is distributed
+type Option func(arg *db.CreateTaskParams)
+
+// Queue puts the task on the named queue
+func Queue(name string) Option {
+	return func(arg *db.CreateTaskParams) {
+		arg.Queue = name
+	}
+}
+
+// Priority sets the task priority, tasks with a higher priority run first within a queue
+func Priority(priority int32) Option {
+	return func(arg *db.CreateTaskParams) {
+		arg.Priority = priority
+	}
+}
+
+// MaxAttempts sets how many times the task is attempted before it is marked as failed
+func MaxAttempts(n int32) Option {
+	return func(arg *db.CreateTaskParams) {
+		arg.MaxAttempts = n
+	}
+}
+
+// ProcessAt delays the task until t
+func ProcessAt(t time.Time) Option {
+	return func(arg *db.CreateTaskParams) {
+		arg.RunAt = t
+	}
+}
+
+// UniqueKey enqueues the task only when no task was enqueued with key before, e.g. one run per period of a periodic task;
+// otherwise DistributeTask fails with ErrTaskExists
+func UniqueKey(key string) Option {
+	return func(arg *db.CreateTaskParams) {
+		arg.UniqueKey = sql.NullString{String: key, Valid: true}
+	}
+}
+
+// ProcessIn delays the task by d
+func ProcessIn(d time.Duration) Option {
+	return func(arg *db.CreateTaskParams) {
+		arg.RunAt = time.Now().Add(d)
+	}
+}
+
+// PGTaskDistributor stores tasks in the PostgreSQL tasks table
+type PGTaskDistributor struct {
+	creator TaskCreator
+}
+
+var _ TaskDistributor = (*PGTaskDistributor)(nil)
+
+// NewPGTaskDistributor creates a new PGTaskDistributor.
+// Pass the q given to TransferTxParams.AfterTransfer to enqueue tasks in the transfer transaction,
+// so that they only exist if the transfer is committed.
+func NewPGTaskDistributor(creator TaskCreator) *PGTaskDistributor {
+	return &PGTaskDistributor{creator: creator}
+}
+
+// DistributeTask marshals payload to JSON and enqueues a task of taskType
+func (distributor *PGTaskDistributor) DistributeTask(ctx context.Context, taskType string, payload any, opts ...Option) (db.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return db.Task{}, fmt.Errorf("cannot marshal %s payload: %w", taskType, err)
+	}
+
+	arg := db.CreateTaskParams{
+		Queue:       DefaultQueue,
+		Type:        taskType,
+		Payload:     data,
+		MaxAttempts: DefaultMaxAttempts,
+		RunAt:       time.Now(),
+	}
+	for _, opt := range opts {
+		opt(&arg)
+	}
+
+	task, err := distributor.creator.CreateTask(ctx, arg)
+	// ON CONFLICT DO NOTHING 沒有回傳 row：同一個 unique_key 已經排過了
+	if errors.Is(err, sql.ErrNoRows) && arg.UniqueKey.Valid {
+		return db.Task{}, fmt.Errorf("%w: %s task %s", ErrTaskExists, taskType, arg.UniqueKey.String)
+	}
+	if err != nil {
+		return db.Task{}, fmt.Errorf("cannot enqueue %s task: %w", taskType, err)
+	}
+	return task, nil
+}
@@ -0,0 +1,256 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+// TaskHandler runs a single task, returning an error schedules a retry
+type TaskHandler func(ctx context.Context, task db.Task) error
+
+// NextTaskFunc enqueues the task that follows a successful run of task through distributor, e.g. the next period of a periodic task
+type NextTaskFunc func(ctx context.Context, distributor TaskDistributor, task db.Task) error
+
+// TaskProcessor runs the tasks put on the queues by a TaskDistributor
+type TaskProcessor interface {
+	Register(taskType string, handler TaskHandler)
+	RegisterNext(taskType string, next NextTaskFunc)
+	Start() error
+	Shutdown()
+}
+
+// TaskStore contains the queue operations used by PGTaskProcessor, *db.Store implements it
+type TaskStore interface {
+	ClaimNextTask(ctx context.Context, arg db.ClaimNextTaskParams) (db.Task, error)
+	CompleteTaskTx(ctx context.Context, arg db.CompleteTaskTxParams) (int64, error)
+	RetryTask(ctx context.Context, arg db.RetryTaskParams) (int64, error)
+	FailTask(ctx context.Context, arg db.FailTaskParams) (int64, error)
+	RequeueExpiredTasks(ctx context.Context) (int64, error)
+}
+
+var _ TaskStore = (*db.Store)(nil)
+
+// ErrSkipRetry marks a task as failed without retrying it, wrap it when retrying cannot help
+var ErrSkipRetry = errors.New("skip retry")
+
+// ErrLeaseLost is returned by ProcessNext when the lock of the task expired while it ran and the task was claimed again,
+// the result of the run is then dropped in favour of the new attempt
+var ErrLeaseLost = errors.New("task lease lost")
+
+// ProcessorConfig contains the settings of a PGTaskProcessor
+type ProcessorConfig struct {
+	// Queues maps every queue to its number of concurrent workers
+	Queues map[string]int
+	// PollInterval is how long an idle worker waits before looking for tasks again
+	PollInterval time.Duration
+	// LockTimeout is how long a task may run before another worker can pick it up again
+	LockTimeout time.Duration
+	// RetryDelay returns the delay before the next attempt after attempts failed
+	RetryDelay func(attempts int32) time.Duration
+}
+
+// DefaultRetryDelay waits 2^attempts seconds, capped at one hour
+func DefaultRetryDelay(attempts int32) time.Duration {
+	if attempts >= 12 {
+		return time.Hour
+	}
+	return min(time.Duration(1<<attempts)*time.Second, time.Hour)
+}
+
+// PGTaskProcessor polls the PostgreSQL tasks table with SELECT ... FOR UPDATE SKIP LOCKED,
+// so that many workers, also in different processes, can share the queues without extra infrastructure
+type PGTaskProcessor struct {
+	store    TaskStore
+	config   ProcessorConfig
+	mu       sync.RWMutex
+	handlers map[string]TaskHandler
+	nexts    map[string]NextTaskFunc
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+var _ TaskProcessor = (*PGTaskProcessor)(nil)
+
+// NewPGTaskProcessor creates a new PGTaskProcessor, zero values in config are replaced by defaults
+func NewPGTaskProcessor(store TaskStore, config ProcessorConfig) *PGTaskProcessor {
+	if len(config.Queues) == 0 {
+		config.Queues = map[string]int{DefaultQueue: 1}
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+	if config.LockTimeout <= 0 {
+		config.LockTimeout = 5 * time.Minute
+	}
+	if config.RetryDelay == nil {
+		config.RetryDelay = DefaultRetryDelay
+	}
+
+	return &PGTaskProcessor{
+		store:    store,
+		config:   config,
+		handlers: make(map[string]TaskHandler),
+		nexts:    make(map[string]NextTaskFunc),
+	}
+}
+
+// Register sets the handler of taskType, it must be called before Start
+func (processor *PGTaskProcessor) Register(taskType string, handler TaskHandler) {
+	processor.mu.Lock()
+	defer processor.mu.Unlock()
+
+	processor.handlers[taskType] = handler
+}
+
+// RegisterNext sets the function that enqueues the task following a successful run of a taskType task, it must be called before Start.
+// It runs in the transaction that marks the task as succeeded, so the next task exists exactly when the run is recorded;
+// when it fails the task is not completed and runs again once its lock expires.
+func (processor *PGTaskProcessor) RegisterNext(taskType string, next NextTaskFunc) {
+	processor.mu.Lock()
+	defer processor.mu.Unlock()
+
+	processor.nexts[taskType] = next
+}
+
+// Start launches the workers of every queue in the background
+func (processor *PGTaskProcessor) Start() error {
+	if processor.cancel != nil {
+		return errors.New("task processor already started")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	processor.cancel = cancel
+
+	for queue, concurrency := range processor.config.Queues {
+		for i := 0; i < concurrency; i++ {
+			processor.wg.Add(1)
+			go func() {
+				defer processor.wg.Done()
+				processor.work(ctx, queue)
+			}()
+		}
+	}
+
+	return nil
+}
+
+// Shutdown stops the workers and waits for the running tasks to return
+func (processor *PGTaskProcessor) Shutdown() {
+	if processor.cancel != nil {
+		processor.cancel()
+	}
+	processor.wg.Wait()
+}
+
+func (processor *PGTaskProcessor) work(ctx context.Context, queue string) {
+	for {
+		processed, err := processor.ProcessNext(ctx, queue)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("task processor: queue %s: %v", queue, err)
+		}
+
+		if processed {
+			continue
+		}
+
+		// 沒有可以跑的 task，順便把鎖過期（worker 當掉）的 task 放回佇列，再等一下
+		if _, err := processor.store.RequeueExpiredTasks(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("task processor: requeue expired tasks: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(processor.config.PollInterval):
+		}
+	}
+}
+
+// ProcessNext claims and runs the next task of queue, it reports whether a task was found
+func (processor *PGTaskProcessor) ProcessNext(ctx context.Context, queue string) (bool, error) {
+	task, err := processor.store.ClaimNextTask(ctx, db.ClaimNextTaskParams{
+		LockedUntil: time.Now().Add(processor.config.LockTimeout),
+		Queue:       queue,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// task 本身和寫回結果都用獨立的 context，Shutdown 時不會被中途打斷，只是不再拿新的 task；
+	// 結果沒寫回去的話 task 會停在 running，鎖過期後又被跑一次
+	ctx = context.WithoutCancel(ctx)
+	runErr := processor.run(ctx, task)
+
+	var rows int64
+	lastError := sql.NullString{String: fmt.Sprint(runErr), Valid: runErr != nil}
+	switch {
+	case runErr == nil:
+		rows, err = processor.store.CompleteTaskTx(ctx, db.CompleteTaskTxParams{
+			ID:            task.ID,
+			Attempts:      task.Attempts,
+			AfterComplete: processor.afterComplete(ctx, task),
+		})
+	case errors.Is(runErr, ErrSkipRetry) || task.Attempts >= task.MaxAttempts:
+		rows, err = processor.store.FailTask(ctx, db.FailTaskParams{
+			ID:        task.ID,
+			Attempts:  task.Attempts,
+			LastError: lastError,
+		})
+	default:
+		rows, err = processor.store.RetryTask(ctx, db.RetryTaskParams{
+			ID:        task.ID,
+			Attempts:  task.Attempts,
+			RunAt:     time.Now().Add(processor.config.RetryDelay(task.Attempts)),
+			LastError: lastError,
+		})
+	}
+	if err != nil {
+		return true, err
+	}
+	if rows == 0 {
+		return true, fmt.Errorf("%w: task %d attempt %d", ErrLeaseLost, task.ID, task.Attempts)
+	}
+	return true, nil
+}
+
+// afterComplete 回傳在完成 task 的事務裡排下一個 task 的函式，沒有 RegisterNext 的話是 nil
+func (processor *PGTaskProcessor) afterComplete(ctx context.Context, task db.Task) func(q *db.Queries) error {
+	processor.mu.RLock()
+	next, ok := processor.nexts[task.Type]
+	processor.mu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+	return func(q *db.Queries) error {
+		return next(ctx, NewPGTaskDistributor(q), task)
+	}
+}
+
+func (processor *PGTaskProcessor) run(ctx context.Context, task db.Task) (err error) {
+	processor.mu.RLock()
+	handler, ok := processor.handlers[task.Type]
+	processor.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: no handler registered for task type %s", ErrSkipRetry, task.Type)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task %d panicked: %v", task.ID, r)
+		}
+	}()
+
+	return handler(ctx, task)
+}
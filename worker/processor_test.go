@@ -0,0 +1,371 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTaskStore keeps the tasks table in memory and mimics the claim ordering of ClaimNextTask
+// and the unique index on unique_key
+type fakeTaskStore struct {
+	mu    sync.Mutex
+	tasks []db.Task
+	// afterComplete 記下 CompleteTaskTx 收到 AfterComplete 的 task；*db.Queries 沒辦法假造，不會真的執行
+	afterComplete []int64
+}
+
+func (store *fakeTaskStore) CreateTask(ctx context.Context, arg db.CreateTaskParams) (db.Task, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, task := range store.tasks {
+		if arg.UniqueKey.Valid && task.UniqueKey == arg.UniqueKey {
+			return db.Task{}, sql.ErrNoRows
+		}
+	}
+
+	task := db.Task{
+		ID:          int64(len(store.tasks) + 1),
+		Queue:       arg.Queue,
+		Type:        arg.Type,
+		Payload:     arg.Payload,
+		Priority:    arg.Priority,
+		Status:      "pending",
+		MaxAttempts: arg.MaxAttempts,
+		RunAt:       arg.RunAt,
+		UniqueKey:   arg.UniqueKey,
+	}
+	store.tasks = append(store.tasks, task)
+	return task, nil
+}
+
+func (store *fakeTaskStore) ClaimNextTask(ctx context.Context, arg db.ClaimNextTaskParams) (db.Task, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var due []*db.Task
+	for i := range store.tasks {
+		task := &store.tasks[i]
+		if task.Queue == arg.Queue && task.Status == "pending" && !task.RunAt.After(time.Now()) {
+			due = append(due, task)
+		}
+	}
+	if len(due) == 0 {
+		return db.Task{}, sql.ErrNoRows
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		if due[i].Priority != due[j].Priority {
+			return due[i].Priority > due[j].Priority
+		}
+		return due[i].RunAt.Before(due[j].RunAt)
+	})
+
+	task := due[0]
+	task.Status = "running"
+	task.Attempts++
+	task.LockedUntil = sql.NullTime{Time: arg.LockedUntil, Valid: true}
+	return *task, nil
+}
+
+func (store *fakeTaskStore) CompleteTaskTx(ctx context.Context, arg db.CompleteTaskTxParams) (int64, error) {
+	rows, err := store.update(ctx, arg.ID, arg.Attempts, func(task *db.Task) {
+		task.Status = "succeeded"
+	})
+	if rows == 1 && arg.AfterComplete != nil {
+		store.mu.Lock()
+		store.afterComplete = append(store.afterComplete, arg.ID)
+		store.mu.Unlock()
+	}
+	return rows, err
+}
+
+func (store *fakeTaskStore) RetryTask(ctx context.Context, arg db.RetryTaskParams) (int64, error) {
+	return store.update(ctx, arg.ID, arg.Attempts, func(task *db.Task) {
+		task.Status = "pending"
+		task.RunAt = arg.RunAt
+		task.LastError = arg.LastError
+	})
+}
+
+func (store *fakeTaskStore) FailTask(ctx context.Context, arg db.FailTaskParams) (int64, error) {
+	return store.update(ctx, arg.ID, arg.Attempts, func(task *db.Task) {
+		task.Status = "failed"
+		task.LastError = arg.LastError
+	})
+}
+
+func (store *fakeTaskStore) RequeueExpiredTasks(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// update 和真的查詢一樣，只改還在 running 而且 attempts 對得上的 task；ctx 取消了就和資料庫一樣失敗
+func (store *fakeTaskStore) update(ctx context.Context, id int64, attempts int32, fn func(task *db.Task)) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	task := &store.tasks[id-1]
+	if task.Status != "running" || task.Attempts != attempts {
+		return 0, nil
+	}
+	fn(task)
+	return 1, nil
+}
+
+func (store *fakeTaskStore) task(id int64) db.Task {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.tasks[id-1]
+}
+
+func noDelay(attempts int32) time.Duration {
+	return 0
+}
+
+func TestProcessNextPriority(t *testing.T) {
+	store := &fakeTaskStore{}
+	distributor := NewPGTaskDistributor(store)
+	processor := NewPGTaskProcessor(store, ProcessorConfig{RetryDelay: noDelay})
+
+	var order []string
+	processor.Register("record", func(ctx context.Context, task db.Task) error {
+		order = append(order, string(task.Payload))
+		return nil
+	})
+
+	ctx := context.Background()
+	_, err := distributor.DistributeTask(ctx, "record", "low")
+	require.NoError(t, err)
+	_, err = distributor.DistributeTask(ctx, "record", "high", Priority(10))
+	require.NoError(t, err)
+	_, err = distributor.DistributeTask(ctx, "record", "later", ProcessIn(time.Hour))
+	require.NoError(t, err)
+	_, err = distributor.DistributeTask(ctx, "record", "other", Queue("emails"))
+	require.NoError(t, err)
+
+	for {
+		processed, err := processor.ProcessNext(ctx, DefaultQueue)
+		require.NoError(t, err)
+		if !processed {
+			break
+		}
+	}
+
+	require.Equal(t, []string{`"high"`, `"low"`}, order)
+	require.Equal(t, "succeeded", store.task(1).Status)
+	require.Equal(t, "pending", store.task(3).Status)
+	require.Equal(t, "pending", store.task(4).Status)
+}
+
+func TestProcessNextRetries(t *testing.T) {
+	store := &fakeTaskStore{}
+	processor := NewPGTaskProcessor(store, ProcessorConfig{RetryDelay: noDelay})
+
+	calls := 0
+	processor.Register("flaky", func(ctx context.Context, task db.Task) error {
+		calls++
+		if calls < 3 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+
+	task, err := NewPGTaskDistributor(store).DistributeTask(context.Background(), "flaky", nil, MaxAttempts(5))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		processed, err := processor.ProcessNext(context.Background(), DefaultQueue)
+		require.NoError(t, err)
+		require.True(t, processed)
+	}
+
+	task = store.task(task.ID)
+	require.Equal(t, "succeeded", task.Status)
+	require.Equal(t, int32(3), task.Attempts)
+}
+
+func TestProcessNextGivesUp(t *testing.T) {
+	store := &fakeTaskStore{}
+	processor := NewPGTaskProcessor(store, ProcessorConfig{RetryDelay: noDelay})
+
+	processor.Register("broken", func(ctx context.Context, task db.Task) error {
+		return errors.New("always fails")
+	})
+	processor.Register("invalid", func(ctx context.Context, task db.Task) error {
+		return fmt.Errorf("bad payload: %w", ErrSkipRetry)
+	})
+
+	distributor := NewPGTaskDistributor(store)
+	broken, err := distributor.DistributeTask(context.Background(), "broken", nil, MaxAttempts(2))
+	require.NoError(t, err)
+	invalid, err := distributor.DistributeTask(context.Background(), "invalid", nil)
+	require.NoError(t, err)
+	unknown, err := distributor.DistributeTask(context.Background(), "unknown", nil)
+	require.NoError(t, err)
+
+	for {
+		processed, err := processor.ProcessNext(context.Background(), DefaultQueue)
+		require.NoError(t, err)
+		if !processed {
+			break
+		}
+	}
+
+	require.Equal(t, "failed", store.task(broken.ID).Status)
+	require.Equal(t, int32(2), store.task(broken.ID).Attempts)
+	require.Equal(t, "failed", store.task(invalid.ID).Status)
+	require.Equal(t, int32(1), store.task(invalid.ID).Attempts)
+	require.Equal(t, "failed", store.task(unknown.ID).Status)
+	require.Contains(t, store.task(unknown.ID).LastError.String, "no handler")
+}
+
+func TestProcessNextRegisterNext(t *testing.T) {
+	store := &fakeTaskStore{}
+	processor := NewPGTaskProcessor(store, ProcessorConfig{RetryDelay: noDelay})
+
+	record := func(ctx context.Context, task db.Task) error { return nil }
+	processor.Register("periodic", record)
+	processor.Register("once", record)
+	processor.RegisterNext("periodic", func(ctx context.Context, distributor TaskDistributor, task db.Task) error {
+		return nil
+	})
+	processor.Register("broken", func(ctx context.Context, task db.Task) error {
+		return fmt.Errorf("bad payload: %w", ErrSkipRetry)
+	})
+	processor.RegisterNext("broken", func(ctx context.Context, distributor TaskDistributor, task db.Task) error {
+		return nil
+	})
+
+	distributor := NewPGTaskDistributor(store)
+	periodic, err := distributor.DistributeTask(context.Background(), "periodic", nil)
+	require.NoError(t, err)
+	_, err = distributor.DistributeTask(context.Background(), "once", nil)
+	require.NoError(t, err)
+	_, err = distributor.DistributeTask(context.Background(), "broken", nil)
+	require.NoError(t, err)
+
+	for {
+		processed, err := processor.ProcessNext(context.Background(), DefaultQueue)
+		require.NoError(t, err)
+		if !processed {
+			break
+		}
+	}
+
+	// 只有成功而且有 RegisterNext 的 task 在完成的事務裡排下一個
+	require.Equal(t, []int64{periodic.ID}, store.afterComplete)
+}
+
+func TestDistributeTaskUniqueKey(t *testing.T) {
+	store := &fakeTaskStore{}
+	distributor := NewPGTaskDistributor(store)
+
+	task, err := distributor.DistributeTask(context.Background(), "periodic", nil, UniqueKey("periodic:2025-03"))
+	require.NoError(t, err)
+	require.Equal(t, "periodic:2025-03", task.UniqueKey.String)
+
+	_, err = distributor.DistributeTask(context.Background(), "periodic", nil, UniqueKey("periodic:2025-03"))
+	require.ErrorIs(t, err, ErrTaskExists)
+	_, err = distributor.DistributeTask(context.Background(), "periodic", nil, UniqueKey("periodic:2025-04"))
+	require.NoError(t, err)
+	_, err = distributor.DistributeTask(context.Background(), "periodic", nil)
+	require.NoError(t, err)
+	_, err = distributor.DistributeTask(context.Background(), "periodic", nil)
+	require.NoError(t, err)
+	require.Len(t, store.tasks, 4)
+}
+
+func TestProcessorStartShutdown(t *testing.T) {
+	store := &fakeTaskStore{}
+	processor := NewPGTaskProcessor(store, ProcessorConfig{
+		Queues:       map[string]int{DefaultQueue: 2, "emails": 1},
+		PollInterval: time.Millisecond,
+	})
+
+	done := make(chan int64, 3)
+	processor.Register("record", func(ctx context.Context, task db.Task) error {
+		done <- task.ID
+		return nil
+	})
+
+	distributor := NewPGTaskDistributor(store)
+	for _, queue := range []string{DefaultQueue, DefaultQueue, "emails"} {
+		_, err := distributor.DistributeTask(context.Background(), "record", nil, Queue(queue))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, processor.Start())
+	require.Error(t, processor.Start())
+
+	seen := make(map[int64]bool)
+	for i := 0; i < 3; i++ {
+		select {
+		case id := <-done:
+			seen[id] = true
+		case <-time.After(5 * time.Second):
+			t.Fatal("tasks were not processed")
+		}
+	}
+	processor.Shutdown()
+
+	require.Len(t, seen, 3)
+}
+
+func TestProcessNextAfterShutdown(t *testing.T) {
+	store := &fakeTaskStore{}
+	processor := NewPGTaskProcessor(store, ProcessorConfig{RetryDelay: noDelay})
+
+	// Shutdown 在 task 跑到一半時取消 ctx，跑完的結果還是要寫回去
+	ctx, cancel := context.WithCancel(context.Background())
+	processor.Register("record", func(ctx context.Context, task db.Task) error {
+		cancel()
+		return ctx.Err()
+	})
+
+	task, err := NewPGTaskDistributor(store).DistributeTask(context.Background(), "record", nil)
+	require.NoError(t, err)
+
+	processed, err := processor.ProcessNext(ctx, DefaultQueue)
+	require.NoError(t, err)
+	require.True(t, processed)
+	require.Equal(t, "succeeded", store.task(task.ID).Status)
+}
+
+func TestProcessNextLeaseLost(t *testing.T) {
+	store := &fakeTaskStore{}
+	processor := NewPGTaskProcessor(store, ProcessorConfig{RetryDelay: noDelay})
+
+	// 跑太久鎖過期，別的 worker 重新 claim 了同一個 task
+	processor.Register("slow", func(ctx context.Context, task db.Task) error {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		store.tasks[task.ID-1].Attempts++
+		return errors.New("too late")
+	})
+
+	task, err := NewPGTaskDistributor(store).DistributeTask(context.Background(), "slow", nil, MaxAttempts(5))
+	require.NoError(t, err)
+
+	processed, err := processor.ProcessNext(context.Background(), DefaultQueue)
+	require.True(t, processed)
+	require.ErrorIs(t, err, ErrLeaseLost)
+
+	// 舊的 worker 不會蓋掉新的那次 claim
+	task = store.task(task.ID)
+	require.Equal(t, "running", task.Status)
+	require.False(t, task.LastError.Valid)
+}
@@ -0,0 +1,165 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+// TaskAccrueInterest accrues one day of interest on every account with a product and posts the periods ending that day
+const TaskAccrueInterest = "task:accrue_interest"
+
+// PayloadAccrueInterest is the payload of TaskAccrueInterest
+type PayloadAccrueInterest struct {
+	// Date is the day to accrue as YYYY-MM-DD, the task should run after the day has ended
+	Date string `json:"date"`
+}
+
+// DistributeTaskAccrueInterest enqueues the interest job of date, to run right after date ends in location.
+// Each day is enqueued once, enqueuing a day again does nothing; once a run succeeds it enqueues the next day itself.
+func DistributeTaskAccrueInterest(ctx context.Context, distributor TaskDistributor, date time.Time, location *time.Location, opts ...Option) error {
+	y, m, d := date.Date()
+	endOfDay := time.Date(y, m, d+1, 0, 0, 0, 0, location)
+	day := date.Format(time.DateOnly)
+	opts = append([]Option{ProcessAt(endOfDay), UniqueKey(TaskAccrueInterest + ":" + day)}, opts...)
+	_, err := distributor.DistributeTask(ctx, TaskAccrueInterest, PayloadAccrueInterest{Date: day}, opts...)
+	if errors.Is(err, ErrTaskExists) {
+		return nil
+	}
+	return err
+}
+
+// InterestStore contains the database operations used by the interest job, *db.Store implements it
+type InterestStore interface {
+	ListAccruingAccounts(ctx context.Context, arg db.ListAccruingAccountsParams) ([]db.ListAccruingAccountsRow, error)
+	AccrueInterestTx(ctx context.Context, arg db.AccrueInterestTxParams) (db.AccrueInterestTxResult, error)
+	PostInterestTx(ctx context.Context, arg db.PostInterestTxParams) (db.PostInterestTxResult, error)
+}
+
+var _ InterestStore = (*db.Store)(nil)
+
+// InterestRunResult counts what an interest run did
+type InterestRunResult struct {
+	Accounts int `json:"accounts"`
+	// Accrued and Posted do not count the days and periods already done by an earlier run
+	Accrued int `json:"accrued"`
+	Posted  int `json:"posted"`
+}
+
+// InterestTasks handles the interest job
+type InterestTasks struct {
+	store    InterestStore
+	location *time.Location
+	pageSize int32
+}
+
+// NewInterestTasks creates a new InterestTasks, days start and end at midnight in location
+func NewInterestTasks(store InterestStore, location *time.Location) *InterestTasks {
+	return &InterestTasks{
+		store:    store,
+		location: location,
+		pageSize: 100,
+	}
+}
+
+// Register registers the handler of TaskAccrueInterest on processor, and NextAccrueInterest to enqueue the next day
+func (tasks *InterestTasks) Register(processor TaskProcessor) {
+	processor.Register(TaskAccrueInterest, tasks.AccrueInterest)
+	processor.RegisterNext(TaskAccrueInterest, tasks.NextAccrueInterest)
+}
+
+// AccrueInterest handles TaskAccrueInterest
+func (tasks *InterestTasks) AccrueInterest(ctx context.Context, task db.Task) error {
+	date, err := tasks.date(task)
+	if err != nil {
+		return err
+	}
+
+	_, err = tasks.RunDay(ctx, date)
+	return err
+}
+
+// NextAccrueInterest enqueues the interest job of the day after the one task accrued
+func (tasks *InterestTasks) NextAccrueInterest(ctx context.Context, distributor TaskDistributor, task db.Task) error {
+	date, err := tasks.date(task)
+	if err != nil {
+		return err
+	}
+	return DistributeTaskAccrueInterest(ctx, distributor, date.AddDate(0, 0, 1), tasks.location)
+}
+
+// date 解出 TaskAccrueInterest 要計息的那一天
+func (tasks *InterestTasks) date(task db.Task) (time.Time, error) {
+	var payload PayloadAccrueInterest
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return time.Time{}, fmt.Errorf("cannot unmarshal payload: %w", ErrSkipRetry)
+	}
+
+	date, err := time.ParseInLocation(time.DateOnly, payload.Date, tasks.location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: %w", payload.Date, ErrSkipRetry)
+	}
+	return date, nil
+}
+
+// RunDay accrues the interest of date on every account with a product, and posts the interest of the accounts
+// whose posting period ends on date. Every account is done in its own transactions;
+// running the same day again only does what the previous run did not finish.
+func (tasks *InterestTasks) RunDay(ctx context.Context, date time.Time) (InterestRunResult, error) {
+	var result InterestRunResult
+	date = date.In(tasks.location)
+
+	var afterID int64
+	for {
+		accounts, err := tasks.store.ListAccruingAccounts(ctx, db.ListAccruingAccountsParams{
+			AfterID:    afterID,
+			LimitCount: tasks.pageSize,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		for _, account := range accounts {
+			afterID = account.AccountID
+			result.Accounts++
+
+			accrual, err := tasks.store.AccrueInterestTx(ctx, db.AccrueInterestTxParams{
+				AccountID: account.AccountID,
+				Date:      date,
+			})
+			if err != nil {
+				return result, fmt.Errorf("cannot accrue interest of account %d: %w", account.AccountID, err)
+			}
+			if accrual.Created {
+				result.Accrued++
+			}
+
+			due, err := db.IsPostingDate(account.PostingFrequency, date)
+			if err != nil {
+				return result, err
+			}
+			if !due {
+				continue
+			}
+
+			posting, err := tasks.store.PostInterestTx(ctx, db.PostInterestTxParams{
+				AccountID: account.AccountID,
+				PeriodEnd: date,
+			})
+			if err != nil {
+				return result, fmt.Errorf("cannot post interest of account %d: %w", account.AccountID, err)
+			}
+			if posting.Created {
+				result.Posted++
+			}
+		}
+
+		if len(accounts) < int(tasks.pageSize) {
+			return result, nil
+		}
+	}
+}
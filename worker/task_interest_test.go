@@ -0,0 +1,138 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInterestStore remembers the accrued days and posted periods like the unique indexes do
+type fakeInterestStore struct {
+	accounts []db.ListAccruingAccountsRow
+	accrued  map[int64][]string
+	posted   map[int64][]string
+}
+
+func newFakeInterestStore(accounts ...db.ListAccruingAccountsRow) *fakeInterestStore {
+	return &fakeInterestStore{
+		accounts: accounts,
+		accrued:  map[int64][]string{},
+		posted:   map[int64][]string{},
+	}
+}
+
+func (store *fakeInterestStore) ListAccruingAccounts(ctx context.Context, arg db.ListAccruingAccountsParams) ([]db.ListAccruingAccountsRow, error) {
+	var accounts []db.ListAccruingAccountsRow
+	for _, account := range store.accounts {
+		if account.AccountID > arg.AfterID && len(accounts) < int(arg.LimitCount) {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts, nil
+}
+
+func (store *fakeInterestStore) AccrueInterestTx(ctx context.Context, arg db.AccrueInterestTxParams) (db.AccrueInterestTxResult, error) {
+	day := arg.Date.Format(time.DateOnly)
+	for _, accrued := range store.accrued[arg.AccountID] {
+		if accrued == day {
+			return db.AccrueInterestTxResult{}, nil
+		}
+	}
+	store.accrued[arg.AccountID] = append(store.accrued[arg.AccountID], day)
+	return db.AccrueInterestTxResult{Created: true}, nil
+}
+
+func (store *fakeInterestStore) PostInterestTx(ctx context.Context, arg db.PostInterestTxParams) (db.PostInterestTxResult, error) {
+	day := arg.PeriodEnd.Format(time.DateOnly)
+	for _, posted := range store.posted[arg.AccountID] {
+		if posted == day {
+			return db.PostInterestTxResult{}, nil
+		}
+	}
+	store.posted[arg.AccountID] = append(store.posted[arg.AccountID], day)
+	return db.PostInterestTxResult{Created: true}, nil
+}
+
+func TestInterestTasksRunDay(t *testing.T) {
+	var accounts []db.ListAccruingAccountsRow
+	for i := int64(1); i <= 5; i++ {
+		frequency := db.PostingMonthly
+		if i%2 == 0 {
+			frequency = db.PostingQuarterly
+		}
+		accounts = append(accounts, db.ListAccruingAccountsRow{AccountID: i, PostingFrequency: frequency})
+	}
+	store := newFakeInterestStore(accounts...)
+	tasks := NewInterestTasks(store, time.UTC)
+	tasks.pageSize = 2
+
+	result, err := tasks.RunDay(context.Background(), time.Date(2025, 1, 30, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, InterestRunResult{Accounts: 5, Accrued: 5}, result)
+
+	// 1 月底只有按月入息的帳戶入息
+	result, err = tasks.RunDay(context.Background(), time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, InterestRunResult{Accounts: 5, Accrued: 5, Posted: 3}, result)
+
+	// 重跑同一天什麼都不會多做
+	result, err = tasks.RunDay(context.Background(), time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, InterestRunResult{Accounts: 5}, result)
+
+	require.Equal(t, []string{"2025-01-30", "2025-01-31"}, store.accrued[2])
+	require.Empty(t, store.posted[2])
+	require.Equal(t, []string{"2025-01-31"}, store.posted[1])
+}
+
+func TestInterestTasksAccrueInterestPayload(t *testing.T) {
+	location := time.FixedZone("UTC+8", 8*60*60)
+	store := newFakeInterestStore(db.ListAccruingAccountsRow{AccountID: 1, PostingFrequency: db.PostingMonthly})
+	tasks := NewInterestTasks(store, location)
+
+	payload, err := json.Marshal(PayloadAccrueInterest{Date: "2025-03-31"})
+	require.NoError(t, err)
+	require.NoError(t, tasks.AccrueInterest(context.Background(), db.Task{Payload: payload}))
+	require.Equal(t, []string{"2025-03-31"}, store.posted[1])
+
+	err = tasks.AccrueInterest(context.Background(), db.Task{Payload: json.RawMessage(`{"date":"31/03/2025"}`)})
+	require.ErrorIs(t, err, ErrSkipRetry)
+}
+
+func TestDistributeTaskAccrueInterest(t *testing.T) {
+	location := time.FixedZone("UTC+8", 8*60*60)
+	store := &fakeTaskStore{}
+
+	err := DistributeTaskAccrueInterest(context.Background(), NewPGTaskDistributor(store), time.Date(2025, 3, 31, 15, 0, 0, 0, location), location)
+	require.NoError(t, err)
+	require.Len(t, store.tasks, 1)
+	require.Equal(t, TaskAccrueInterest, store.tasks[0].Type)
+	require.JSONEq(t, `{"date":"2025-03-31"}`, string(store.tasks[0].Payload))
+	require.True(t, store.tasks[0].RunAt.Equal(time.Date(2025, 4, 1, 0, 0, 0, 0, location)))
+
+	// 同一天只排一次
+	err = DistributeTaskAccrueInterest(context.Background(), NewPGTaskDistributor(store), time.Date(2025, 3, 31, 0, 0, 0, 0, location), location)
+	require.NoError(t, err)
+	require.Len(t, store.tasks, 1)
+}
+
+func TestInterestTasksNextAccrueInterest(t *testing.T) {
+	location := time.FixedZone("UTC+8", 8*60*60)
+	store := &fakeTaskStore{}
+	tasks := NewInterestTasks(newFakeInterestStore(), location)
+
+	payload, err := json.Marshal(PayloadAccrueInterest{Date: "2025-03-31"})
+	require.NoError(t, err)
+	// 鎖過期重跑同一天，下一天也只排一次
+	for i := 0; i < 2; i++ {
+		require.NoError(t, tasks.NextAccrueInterest(context.Background(), NewPGTaskDistributor(store), db.Task{Payload: payload}))
+	}
+	require.Len(t, store.tasks, 1)
+	require.JSONEq(t, `{"date":"2025-04-01"}`, string(store.tasks[0].Payload))
+	require.Equal(t, TaskAccrueInterest+":2025-04-01", store.tasks[0].UniqueKey.String)
+	require.True(t, store.tasks[0].RunAt.Equal(time.Date(2025, 4, 2, 0, 0, 0, 0, location)))
+}
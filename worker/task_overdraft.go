@@ -0,0 +1,150 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+// TaskChargeOverdraft charges the overdraft interest and fees of the period ending on a day to every account with an overdraft rate or fee
+const TaskChargeOverdraft = "task:charge_overdraft"
+
+// PayloadChargeOverdraft is the payload of TaskChargeOverdraft
+type PayloadChargeOverdraft struct {
+	// PeriodEnd is the last day of the period as YYYY-MM-DD, the task should run after the day has ended
+	PeriodEnd string `json:"period_end"`
+}
+
+// DistributeTaskChargeOverdraft enqueues the overdraft job of the period ending on periodEnd, to run right after periodEnd ends in location.
+// Each period is enqueued once, enqueuing a period again does nothing; once a run succeeds it enqueues the next period itself.
+func DistributeTaskChargeOverdraft(ctx context.Context, distributor TaskDistributor, periodEnd time.Time, location *time.Location, opts ...Option) error {
+	y, m, d := periodEnd.Date()
+	endOfDay := time.Date(y, m, d+1, 0, 0, 0, 0, location)
+	day := periodEnd.Format(time.DateOnly)
+	opts = append([]Option{ProcessAt(endOfDay), UniqueKey(TaskChargeOverdraft + ":" + day)}, opts...)
+	_, err := distributor.DistributeTask(ctx, TaskChargeOverdraft, PayloadChargeOverdraft{PeriodEnd: day}, opts...)
+	if errors.Is(err, ErrTaskExists) {
+		return nil
+	}
+	return err
+}
+
+// OverdraftStore contains the database operations used by the overdraft job, *db.Store implements it
+type OverdraftStore interface {
+	ListChargeableOverdraftAccounts(ctx context.Context, arg db.ListChargeableOverdraftAccountsParams) ([]db.ListChargeableOverdraftAccountsRow, error)
+	ChargeOverdraftTx(ctx context.Context, arg db.ChargeOverdraftTxParams) (db.ChargeOverdraftTxResult, error)
+}
+
+var _ OverdraftStore = (*db.Store)(nil)
+
+// OverdraftRunResult counts what an overdraft run did
+type OverdraftRunResult struct {
+	Accounts int `json:"accounts"`
+	// Charged does not count the periods already charged by an earlier run
+	Charged int `json:"charged"`
+	// Amount is the total charged by this run, in minor units of every currency
+	Amount int64 `json:"amount"`
+}
+
+// OverdraftTasks handles the overdraft job
+type OverdraftTasks struct {
+	store    OverdraftStore
+	location *time.Location
+	pageSize int32
+}
+
+// NewOverdraftTasks creates a new OverdraftTasks, days start and end at midnight in location
+func NewOverdraftTasks(store OverdraftStore, location *time.Location) *OverdraftTasks {
+	return &OverdraftTasks{
+		store:    store,
+		location: location,
+		pageSize: 100,
+	}
+}
+
+// Register registers the handler of TaskChargeOverdraft on processor, and NextChargeOverdraft to enqueue the next period
+func (tasks *OverdraftTasks) Register(processor TaskProcessor) {
+	processor.Register(TaskChargeOverdraft, tasks.ChargeOverdraft)
+	processor.RegisterNext(TaskChargeOverdraft, tasks.NextChargeOverdraft)
+}
+
+// ChargeOverdraft handles TaskChargeOverdraft
+func (tasks *OverdraftTasks) ChargeOverdraft(ctx context.Context, task db.Task) error {
+	periodEnd, err := tasks.periodEnd(task)
+	if err != nil {
+		return err
+	}
+
+	_, err = tasks.RunPeriod(ctx, periodEnd)
+	return err
+}
+
+// NextChargeOverdraft enqueues the overdraft job of the next period: overdrafts are charged monthly,
+// the next period ends on the last day of the month after the one task charged
+func (tasks *OverdraftTasks) NextChargeOverdraft(ctx context.Context, distributor TaskDistributor, task db.Task) error {
+	periodEnd, err := tasks.periodEnd(task)
+	if err != nil {
+		return err
+	}
+	// 第 0 天是前一個月的最後一天
+	y, m, _ := periodEnd.Date()
+	next := time.Date(y, m+2, 0, 0, 0, 0, 0, tasks.location)
+	return DistributeTaskChargeOverdraft(ctx, distributor, next, tasks.location)
+}
+
+// periodEnd 解出 TaskChargeOverdraft 要收費的那一期的最後一天
+func (tasks *OverdraftTasks) periodEnd(task db.Task) (time.Time, error) {
+	var payload PayloadChargeOverdraft
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return time.Time{}, fmt.Errorf("cannot unmarshal payload: %w", ErrSkipRetry)
+	}
+
+	periodEnd, err := time.ParseInLocation(time.DateOnly, payload.PeriodEnd, tasks.location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid period end %q: %w", payload.PeriodEnd, ErrSkipRetry)
+	}
+	return periodEnd, nil
+}
+
+// RunPeriod charges every account with an overdraft rate or fee for the period ending on periodEnd.
+// Every account is charged in its own transaction; running the same period again only does what the previous run did not finish.
+func (tasks *OverdraftTasks) RunPeriod(ctx context.Context, periodEnd time.Time) (OverdraftRunResult, error) {
+	var result OverdraftRunResult
+	periodEnd = periodEnd.In(tasks.location)
+
+	var afterID int64
+	for {
+		accounts, err := tasks.store.ListChargeableOverdraftAccounts(ctx, db.ListChargeableOverdraftAccountsParams{
+			AfterID:    afterID,
+			LimitCount: tasks.pageSize,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		for _, account := range accounts {
+			afterID = account.AccountID
+			result.Accounts++
+
+			charge, err := tasks.store.ChargeOverdraftTx(ctx, db.ChargeOverdraftTxParams{
+				AccountID: account.AccountID,
+				PeriodEnd: periodEnd,
+			})
+			if err != nil {
+				return result, fmt.Errorf("cannot charge overdraft of account %d: %w", account.AccountID, err)
+			}
+			if charge.Created {
+				result.Charged++
+				result.Amount += charge.Charge.Amount
+			}
+		}
+
+		if len(accounts) < int(tasks.pageSize) {
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOverdraftStore remembers the charged periods like the unique index does
+type fakeOverdraftStore struct {
+	accounts []db.ListChargeableOverdraftAccountsRow
+	charged  map[int64][]string
+}
+
+func (store *fakeOverdraftStore) ListChargeableOverdraftAccounts(ctx context.Context, arg db.ListChargeableOverdraftAccountsParams) ([]db.ListChargeableOverdraftAccountsRow, error) {
+	var accounts []db.ListChargeableOverdraftAccountsRow
+	for _, account := range store.accounts {
+		if account.AccountID > arg.AfterID && len(accounts) < int(arg.LimitCount) {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts, nil
+}
+
+func (store *fakeOverdraftStore) ChargeOverdraftTx(ctx context.Context, arg db.ChargeOverdraftTxParams) (db.ChargeOverdraftTxResult, error) {
+	day := arg.PeriodEnd.Format(time.DateOnly)
+	for _, charged := range store.charged[arg.AccountID] {
+		if charged == day {
+			return db.ChargeOverdraftTxResult{}, nil
+		}
+	}
+	store.charged[arg.AccountID] = append(store.charged[arg.AccountID], day)
+	return db.ChargeOverdraftTxResult{Charge: db.OverdraftCharge{Amount: 10}, Created: true}, nil
+}
+
+func TestOverdraftTasksRunPeriod(t *testing.T) {
+	store := &fakeOverdraftStore{charged: map[int64][]string{}}
+	for i := int64(1); i <= 3; i++ {
+		store.accounts = append(store.accounts, db.ListChargeableOverdraftAccountsRow{AccountID: i, OverdraftRateBps: 1000})
+	}
+	tasks := NewOverdraftTasks(store, time.UTC)
+	tasks.pageSize = 2
+
+	result, err := tasks.RunPeriod(context.Background(), time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, OverdraftRunResult{Accounts: 3, Charged: 3, Amount: 30}, result)
+
+	// 重跑同一期什麼都不會多做
+	result, err = tasks.RunPeriod(context.Background(), time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, OverdraftRunResult{Accounts: 3}, result)
+	require.Equal(t, []string{"2025-01-31"}, store.charged[3])
+}
+
+func TestOverdraftTasksChargeOverdraftPayload(t *testing.T) {
+	location := time.FixedZone("UTC+8", 8*60*60)
+	store := &fakeOverdraftStore{
+		accounts: []db.ListChargeableOverdraftAccountsRow{{AccountID: 1, OverdraftFee: 5}},
+		charged:  map[int64][]string{},
+	}
+	tasks := NewOverdraftTasks(store, location)
+
+	payload, err := json.Marshal(PayloadChargeOverdraft{PeriodEnd: "2025-03-31"})
+	require.NoError(t, err)
+	require.NoError(t, tasks.ChargeOverdraft(context.Background(), db.Task{Payload: payload}))
+	require.Equal(t, []string{"2025-03-31"}, store.charged[1])
+
+	err = tasks.ChargeOverdraft(context.Background(), db.Task{Payload: json.RawMessage(`{"period_end":"31/03/2025"}`)})
+	require.ErrorIs(t, err, ErrSkipRetry)
+}
+
+func TestDistributeTaskChargeOverdraft(t *testing.T) {
+	location := time.FixedZone("UTC+8", 8*60*60)
+	store := &fakeTaskStore{}
+
+	err := DistributeTaskChargeOverdraft(context.Background(), NewPGTaskDistributor(store), time.Date(2025, 3, 31, 15, 0, 0, 0, location), location)
+	require.NoError(t, err)
+	require.Len(t, store.tasks, 1)
+	require.Equal(t, TaskChargeOverdraft, store.tasks[0].Type)
+	require.JSONEq(t, `{"period_end":"2025-03-31"}`, string(store.tasks[0].Payload))
+	require.True(t, store.tasks[0].RunAt.Equal(time.Date(2025, 4, 1, 0, 0, 0, 0, location)))
+
+	// 同一期只排一次
+	err = DistributeTaskChargeOverdraft(context.Background(), NewPGTaskDistributor(store), time.Date(2025, 3, 31, 0, 0, 0, 0, location), location)
+	require.NoError(t, err)
+	require.Len(t, store.tasks, 1)
+}
+
+func TestOverdraftTasksNextChargeOverdraft(t *testing.T) {
+	location := time.FixedZone("UTC+8", 8*60*60)
+	store := &fakeTaskStore{}
+	tasks := NewOverdraftTasks(&fakeOverdraftStore{}, location)
+
+	payload, err := json.Marshal(PayloadChargeOverdraft{PeriodEnd: "2025-01-31"})
+	require.NoError(t, err)
+	// 鎖過期重跑同一期，下一期也只排一次
+	for i := 0; i < 2; i++ {
+		require.NoError(t, tasks.NextChargeOverdraft(context.Background(), NewPGTaskDistributor(store), db.Task{Payload: payload}))
+	}
+	require.Len(t, store.tasks, 1)
+	require.JSONEq(t, `{"period_end":"2025-02-28"}`, string(store.tasks[0].Payload))
+	require.Equal(t, TaskChargeOverdraft+":2025-02-28", store.tasks[0].UniqueKey.String)
+	require.True(t, store.tasks[0].RunAt.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, location)))
+}
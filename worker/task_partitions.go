@@ -0,0 +1,146 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+)
+
+// TaskMaintainPartitions creates the future monthly partitions of entries and transfers and detaches the expired ones
+const TaskMaintainPartitions = "task:maintain_partitions"
+
+// PayloadMaintainPartitions is the payload of TaskMaintainPartitions
+type PayloadMaintainPartitions struct {
+	// Date is the day the maintenance is for as YYYY-MM-DD, months are counted from it
+	Date string `json:"date"`
+}
+
+// DistributeTaskMaintainPartitions enqueues the partition maintenance of date, to run at the start of date in UTC.
+// Each month is enqueued once, enqueuing the same month again does nothing; once a run succeeds it enqueues the next month itself.
+func DistributeTaskMaintainPartitions(ctx context.Context, distributor TaskDistributor, date time.Time, opts ...Option) error {
+	y, m, d := date.Date()
+	month := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+	opts = append([]Option{ProcessAt(time.Date(y, m, d, 0, 0, 0, 0, time.UTC)), UniqueKey(TaskMaintainPartitions + ":" + month)}, opts...)
+	_, err := distributor.DistributeTask(ctx, TaskMaintainPartitions, PayloadMaintainPartitions{Date: date.Format(time.DateOnly)}, opts...)
+	if errors.Is(err, ErrTaskExists) {
+		return nil
+	}
+	return err
+}
+
+// PartitionStore contains the database operations used by the partition maintenance, *db.Store implements it
+type PartitionStore interface {
+	CreateLedgerPartitions(ctx context.Context, arg db.CreateLedgerPartitionsParams) ([]string, error)
+	DetachLedgerPartitions(ctx context.Context, arg db.DetachLedgerPartitionsParams) ([]string, error)
+}
+
+var _ PartitionStore = (*db.Store)(nil)
+
+// PartitionConfig decides which monthly partitions are kept
+type PartitionConfig struct {
+	// MonthsAhead is how many months after the current one get a partition before they start
+	MonthsAhead int
+	// RetainMonths is how many months before the current one stay attached, 0 keeps every partition
+	RetainMonths int
+	// ArchiveSchema is the schema the detached partitions are moved to, empty leaves them in the public schema
+	ArchiveSchema string
+}
+
+// PartitionRunResult lists the partitions a maintenance run created and detached
+type PartitionRunResult struct {
+	Created  []string `json:"created"`
+	Detached []string `json:"detached"`
+}
+
+// PartitionTasks handles the partition maintenance
+type PartitionTasks struct {
+	store  PartitionStore
+	config PartitionConfig
+}
+
+// NewPartitionTasks creates a new PartitionTasks, a zero MonthsAhead means three months
+func NewPartitionTasks(store PartitionStore, config PartitionConfig) *PartitionTasks {
+	if config.MonthsAhead <= 0 {
+		config.MonthsAhead = 3
+	}
+	return &PartitionTasks{
+		store:  store,
+		config: config,
+	}
+}
+
+// Register registers the handler of TaskMaintainPartitions on processor, and NextMaintainPartitions to enqueue the next month
+func (tasks *PartitionTasks) Register(processor TaskProcessor) {
+	processor.Register(TaskMaintainPartitions, tasks.MaintainPartitions)
+	processor.RegisterNext(TaskMaintainPartitions, tasks.NextMaintainPartitions)
+}
+
+// MaintainPartitions handles TaskMaintainPartitions
+func (tasks *PartitionTasks) MaintainPartitions(ctx context.Context, task db.Task) error {
+	date, err := tasks.date(task)
+	if err != nil {
+		return err
+	}
+
+	_, err = tasks.Run(ctx, date)
+	return err
+}
+
+// NextMaintainPartitions enqueues the partition maintenance of the first day of the month after the one task maintained
+func (tasks *PartitionTasks) NextMaintainPartitions(ctx context.Context, distributor TaskDistributor, task db.Task) error {
+	date, err := tasks.date(task)
+	if err != nil {
+		return err
+	}
+	y, m, _ := date.Date()
+	return DistributeTaskMaintainPartitions(ctx, distributor, time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// date 解出 TaskMaintainPartitions 是哪一天的維護
+func (tasks *PartitionTasks) date(task db.Task) (time.Time, error) {
+	var payload PayloadMaintainPartitions
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return time.Time{}, fmt.Errorf("cannot unmarshal payload: %w", ErrSkipRetry)
+	}
+
+	date, err := time.Parse(time.DateOnly, payload.Date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: %w", payload.Date, ErrSkipRetry)
+	}
+	return date, nil
+}
+
+// Run creates the partitions from the month of date up to MonthsAhead months after it and detaches the ones older than RetainMonths.
+// Partitions are monthly in UTC; both steps skip what already exists or is already detached, so running again is safe.
+func (tasks *PartitionTasks) Run(ctx context.Context, date time.Time) (PartitionRunResult, error) {
+	var result PartitionRunResult
+	y, m, _ := date.UTC().Date()
+	month := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+
+	created, err := tasks.store.CreateLedgerPartitions(ctx, db.CreateLedgerPartitionsParams{
+		FromMonth:    month,
+		ThroughMonth: month.AddDate(0, tasks.config.MonthsAhead, 0),
+	})
+	if err != nil {
+		return result, fmt.Errorf("cannot create partitions: %w", err)
+	}
+	result.Created = created
+
+	if tasks.config.RetainMonths <= 0 {
+		return result, nil
+	}
+	// 保留這個月之前的 RetainMonths 個月，更早結束的分區都卸下
+	detached, err := tasks.store.DetachLedgerPartitions(ctx, db.DetachLedgerPartitionsParams{
+		DetachBefore:  month.AddDate(0, -tasks.config.RetainMonths, 0),
+		ArchiveSchema: tasks.config.ArchiveSchema,
+	})
+	if err != nil {
+		return result, fmt.Errorf("cannot detach partitions: %w", err)
+	}
+	result.Detached = detached
+	return result, nil
+}
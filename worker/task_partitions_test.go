@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePartitionStore remembers the existing and detached months like the database functions do
+type fakePartitionStore struct {
+	months   map[string]bool
+	created  []db.CreateLedgerPartitionsParams
+	detached []db.DetachLedgerPartitionsParams
+}
+
+func (store *fakePartitionStore) CreateLedgerPartitions(ctx context.Context, arg db.CreateLedgerPartitionsParams) ([]string, error) {
+	store.created = append(store.created, arg)
+	var created []string
+	for month := arg.FromMonth; !month.After(arg.ThroughMonth); month = month.AddDate(0, 1, 0) {
+		name := month.Format("200601")
+		if !store.months[name] {
+			store.months[name] = true
+			created = append(created, "entries_p"+name, "transfers_p"+name)
+		}
+	}
+	return created, nil
+}
+
+func (store *fakePartitionStore) DetachLedgerPartitions(ctx context.Context, arg db.DetachLedgerPartitionsParams) ([]string, error) {
+	store.detached = append(store.detached, arg)
+	var detached []string
+	for name := range store.months {
+		month, _ := time.Parse("200601", name)
+		if !month.AddDate(0, 1, 0).After(arg.DetachBefore) {
+			delete(store.months, name)
+			detached = append(detached, "entries_p"+name)
+		}
+	}
+	return detached, nil
+}
+
+func TestPartitionTasksRun(t *testing.T) {
+	store := &fakePartitionStore{
+		months: map[string]bool{"202412": true, "202501": true, "202503": true},
+	}
+	tasks := NewPartitionTasks(store, PartitionConfig{MonthsAhead: 2, RetainMonths: 2, ArchiveSchema: "ledger_archive"})
+
+	result, err := tasks.Run(context.Background(), time.Date(2025, time.March, 17, 23, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, []string{"entries_p202504", "transfers_p202504", "entries_p202505", "transfers_p202505"}, result.Created)
+	// 月份從 date 那個月算起，不是跑的時候的月份
+	require.Equal(t, []db.CreateLedgerPartitionsParams{{
+		FromMonth:    time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC),
+		ThroughMonth: time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC),
+	}}, store.created)
+	require.Equal(t, []string{"entries_p202412"}, result.Detached)
+	require.Equal(t, []db.DetachLedgerPartitionsParams{{
+		DetachBefore:  time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		ArchiveSchema: "ledger_archive",
+	}}, store.detached)
+
+	// 重跑什麼都不會多做
+	result, err = tasks.Run(context.Background(), time.Date(2025, time.March, 17, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Empty(t, result.Created)
+	require.Empty(t, result.Detached)
+}
+
+func TestPartitionTasksKeepAll(t *testing.T) {
+	store := &fakePartitionStore{
+		months: map[string]bool{"200101": true},
+	}
+	tasks := NewPartitionTasks(store, PartitionConfig{})
+
+	result, err := tasks.Run(context.Background(), time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	// 預設往後建三個月，RetainMonths 是 0 時不卸下任何分區
+	require.Len(t, result.Created, 8)
+	require.Empty(t, result.Detached)
+	require.Empty(t, store.detached)
+}
+
+func TestPartitionTasksMaintainPartitionsPayload(t *testing.T) {
+	store := &fakePartitionStore{
+		months: map[string]bool{},
+	}
+	tasks := NewPartitionTasks(store, PartitionConfig{MonthsAhead: 1})
+
+	payload, err := json.Marshal(PayloadMaintainPartitions{Date: "2025-03-01"})
+	require.NoError(t, err)
+	require.NoError(t, tasks.MaintainPartitions(context.Background(), db.Task{Payload: payload}))
+	require.True(t, store.months["202504"])
+
+	err = tasks.MaintainPartitions(context.Background(), db.Task{Payload: json.RawMessage(`{"date":"01/03/2025"}`)})
+	require.ErrorIs(t, err, ErrSkipRetry)
+}
+
+func TestDistributeTaskMaintainPartitions(t *testing.T) {
+	store := &fakeTaskStore{}
+
+	err := DistributeTaskMaintainPartitions(context.Background(), NewPGTaskDistributor(store), time.Date(2025, time.March, 1, 15, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, store.tasks, 1)
+	require.Equal(t, TaskMaintainPartitions, store.tasks[0].Type)
+	require.JSONEq(t, `{"date":"2025-03-01"}`, string(store.tasks[0].Payload))
+	require.True(t, store.tasks[0].RunAt.Equal(time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestPartitionTasksNextMaintainPartitions(t *testing.T) {
+	store := &fakeTaskStore{}
+	tasks := NewPartitionTasks(&fakePartitionStore{}, PartitionConfig{})
+
+	payload, err := json.Marshal(PayloadMaintainPartitions{Date: "2025-03-15"})
+	require.NoError(t, err)
+	// 鎖過期重跑同一個月，下個月也只排一次
+	for i := 0; i < 2; i++ {
+		require.NoError(t, tasks.NextMaintainPartitions(context.Background(), NewPGTaskDistributor(store), db.Task{Payload: payload}))
+	}
+	require.Len(t, store.tasks, 1)
+	require.JSONEq(t, `{"date":"2025-04-01"}`, string(store.tasks[0].Payload))
+	require.Equal(t, TaskMaintainPartitions+":2025-04", store.tasks[0].UniqueKey.String)
+	require.True(t, store.tasks[0].RunAt.Equal(time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)))
+
+	// 同一個月的其他日子也不會多排一次
+	err = DistributeTaskMaintainPartitions(context.Background(), NewPGTaskDistributor(store), time.Date(2025, time.April, 20, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, store.tasks, 1)
+}
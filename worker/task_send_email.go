@@ -0,0 +1,236 @@
+package worker
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/andyrestart9/bank/mail"
+)
+
+// Email task types, they run on QueueMail
+const (
+	TaskSendVerifyEmail           = "task:send_verify_email"
+	TaskSendTransferNotifications = "task:send_transfer_notifications"
+
+	QueueMail = "mail"
+)
+
+// PayloadSendVerifyEmail is the payload of TaskSendVerifyEmail
+type PayloadSendVerifyEmail struct {
+	Username string `json:"username"`
+}
+
+// PayloadSendTransferNotifications is the payload of TaskSendTransferNotifications
+type PayloadSendTransferNotifications struct {
+	Result db.TransferTxResult `json:"result"`
+}
+
+// DistributeTaskSendVerifyEmail enqueues a verification email for a user, usually from CreateUserTxParams.AfterCreate
+func DistributeTaskSendVerifyEmail(ctx context.Context, distributor TaskDistributor, payload PayloadSendVerifyEmail, opts ...Option) error {
+	opts = append([]Option{Queue(QueueMail), Priority(10)}, opts...)
+	_, err := distributor.DistributeTask(ctx, TaskSendVerifyEmail, payload, opts...)
+	return err
+}
+
+// EnqueueTransferNotifications returns a TransferTxParams.AfterTransfer callback
+// that enqueues the transfer notifications in the transfer transaction
+func EnqueueTransferNotifications(ctx context.Context, opts ...Option) func(q *db.Queries, result db.TransferTxResult) error {
+	opts = append([]Option{Queue(QueueMail)}, opts...)
+	return func(q *db.Queries, result db.TransferTxResult) error {
+		_, err := NewPGTaskDistributor(q).DistributeTask(ctx, TaskSendTransferNotifications, PayloadSendTransferNotifications{Result: result}, opts...)
+		return err
+	}
+}
+
+// MailStore contains the database operations used by the email tasks, *db.Store implements it
+type MailStore interface {
+	GetUser(ctx context.Context, username string) (db.User, error)
+	CreateVerifyEmail(ctx context.Context, arg db.CreateVerifyEmailParams) (db.VerifyEmail, error)
+}
+
+var _ MailStore = (*db.Store)(nil)
+
+// MailConfig contains the settings of the email tasks
+type MailConfig struct {
+	// VerifyEmailURL is the address of the verify email endpoint, email_id and secret_code are added to its query
+	VerifyEmailURL string
+	// LargeTransferThreshold is the smallest incoming amount the receiver is notified about
+	LargeTransferThreshold int64
+	// LowBalanceThreshold triggers a low balance alert when a transfer takes the sender's balance below it
+	LowBalanceThreshold int64
+}
+
+// MailTasks handles the email tasks
+type MailTasks struct {
+	store  MailStore
+	sender mail.EmailSender
+	config MailConfig
+}
+
+// NewMailTasks creates a new MailTasks
+func NewMailTasks(store MailStore, sender mail.EmailSender, config MailConfig) *MailTasks {
+	return &MailTasks{
+		store:  store,
+		sender: sender,
+		config: config,
+	}
+}
+
+// Register registers the handlers of the email task types on processor
+func (tasks *MailTasks) Register(processor TaskProcessor) {
+	processor.Register(TaskSendVerifyEmail, tasks.SendVerifyEmail)
+	processor.Register(TaskSendTransferNotifications, tasks.SendTransferNotifications)
+}
+
+// SendVerifyEmail creates a verification code for the user and emails the link to consume it
+func (tasks *MailTasks) SendVerifyEmail(ctx context.Context, task db.Task) error {
+	var payload PayloadSendVerifyEmail
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return fmt.Errorf("cannot unmarshal payload: %w", ErrSkipRetry)
+	}
+
+	user, err := tasks.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("user %s does not exist: %w", payload.Username, ErrSkipRetry)
+		}
+		return err
+	}
+
+	secretCode, err := newSecretCode()
+	if err != nil {
+		return err
+	}
+
+	verifyEmail, err := tasks.store.CreateVerifyEmail(ctx, db.CreateVerifyEmailParams{
+		Username:   user.Username,
+		Email:      user.Email,
+		SecretCode: secretCode,
+	})
+	if err != nil {
+		return err
+	}
+
+	verifyURL, err := url.Parse(tasks.config.VerifyEmailURL)
+	if err != nil {
+		return fmt.Errorf("invalid verify email url: %w", ErrSkipRetry)
+	}
+	query := verifyURL.Query()
+	query.Set("email_id", strconv.FormatInt(verifyEmail.ID, 10))
+	query.Set("secret_code", verifyEmail.SecretCode)
+	verifyURL.RawQuery = query.Encode()
+
+	msg, err := mail.Render(mail.TemplateVerifyEmail, []string{user.Email}, mail.VerifyEmailData{
+		FullName:  user.FullName,
+		VerifyURL: verifyURL.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, ErrSkipRetry)
+	}
+
+	return tasks.sender.SendEmail(ctx, msg)
+}
+
+// SendTransferNotifications emails the owners involved in a transfer:
+// the sender gets a transfer sent notification and, when the transfer takes the balance below the threshold, a low balance alert;
+// the receiver is notified of large incoming transfers.
+// Only owners with a verified email address are notified.
+func (tasks *MailTasks) SendTransferNotifications(ctx context.Context, task db.Task) error {
+	var payload PayloadSendTransferNotifications
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return fmt.Errorf("cannot unmarshal payload: %w", ErrSkipRetry)
+	}
+	result := payload.Result
+	transfer := result.Transfer
+
+	// 失敗重試時整個 task 會重跑，前面已經寄出的信可能會重複寄一次（at-least-once）
+	sent := mail.TransferData{
+		TransferID:            transfer.ID,
+		AccountID:             result.FromAccount.ID,
+		CounterpartyAccountID: result.ToAccount.ID,
+		Amount:                transfer.Amount,
+		Balance:               result.FromAccount.Balance,
+		Currency:              result.FromAccount.Currency,
+		CreatedAt:             transfer.CreatedAt,
+	}
+	if err := tasks.notify(ctx, result.FromAccount.Owner, mail.TemplateTransferSent, func(user db.User) any {
+		sent.FullName = user.FullName
+		return sent
+	}); err != nil {
+		return err
+	}
+
+	threshold := tasks.config.LowBalanceThreshold
+	if result.FromAccount.Balance < threshold && result.FromAccount.Balance+transfer.Amount >= threshold {
+		if err := tasks.notify(ctx, result.FromAccount.Owner, mail.TemplateLowBalance, func(user db.User) any {
+			return mail.LowBalanceData{
+				FullName:  user.FullName,
+				AccountID: result.FromAccount.ID,
+				Balance:   result.FromAccount.Balance,
+				Threshold: threshold,
+				Currency:  result.FromAccount.Currency,
+			}
+		}); err != nil {
+			return err
+		}
+	}
+
+	if transfer.Amount >= tasks.config.LargeTransferThreshold {
+		received := mail.TransferData{
+			TransferID:            transfer.ID,
+			AccountID:             result.ToAccount.ID,
+			CounterpartyAccountID: result.FromAccount.ID,
+			Amount:                transfer.Amount,
+			Balance:               result.ToAccount.Balance,
+			Currency:              result.ToAccount.Currency,
+			CreatedAt:             transfer.CreatedAt,
+		}
+		if err := tasks.notify(ctx, result.ToAccount.Owner, mail.TemplateTransferReceived, func(user db.User) any {
+			received.FullName = user.FullName
+			return received
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notify renders the template with the data built for the owner's user and sends it,
+// owners without a user or without a verified email are skipped
+func (tasks *MailTasks) notify(ctx context.Context, owner string, template string, data func(user db.User) any) error {
+	user, err := tasks.store.GetUser(ctx, owner)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if !user.IsEmailVerified {
+		return nil
+	}
+
+	msg, err := mail.Render(template, []string{user.Email}, data(user))
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, ErrSkipRetry)
+	}
+
+	return tasks.sender.SendEmail(ctx, msg)
+}
+
+// newSecretCode 用 crypto/rand 產生驗證碼；util.RandomString 是用 math/rand 產生測試資料的，可以被猜到，不能拿來當秘密
+func newSecretCode() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
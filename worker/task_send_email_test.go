@@ -0,0 +1,160 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+
+	db "github.com/andyrestart9/bank/db/sqlc"
+	"github.com/andyrestart9/bank/mail"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMailStore keeps users and verify emails in memory
+type fakeMailStore struct {
+	users        map[string]db.User
+	verifyEmails []db.VerifyEmail
+}
+
+func (store *fakeMailStore) GetUser(ctx context.Context, username string) (db.User, error) {
+	user, ok := store.users[username]
+	if !ok {
+		return db.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (store *fakeMailStore) CreateVerifyEmail(ctx context.Context, arg db.CreateVerifyEmailParams) (db.VerifyEmail, error) {
+	verifyEmail := db.VerifyEmail{
+		ID:         int64(len(store.verifyEmails) + 1),
+		Username:   arg.Username,
+		Email:      arg.Email,
+		SecretCode: arg.SecretCode,
+	}
+	store.verifyEmails = append(store.verifyEmails, verifyEmail)
+	return verifyEmail, nil
+}
+
+func newMailTask(t *testing.T, taskType string, payload any) db.Task {
+	raw, err := json.Marshal(payload)
+	require.NoError(t, err)
+	return db.Task{ID: 1, Type: taskType, Payload: raw}
+}
+
+func TestSendVerifyEmail(t *testing.T) {
+	store := &fakeMailStore{users: map[string]db.User{
+		"alice": {Username: "alice", FullName: "Alice", Email: "alice@example.com"},
+	}}
+	sender := &mail.RecordingSender{}
+	tasks := NewMailTasks(store, sender, MailConfig{VerifyEmailURL: "https://bank.example/verify_email"})
+
+	err := tasks.SendVerifyEmail(context.Background(), newMailTask(t, TaskSendVerifyEmail, PayloadSendVerifyEmail{Username: "alice"}))
+	require.NoError(t, err)
+
+	require.Len(t, store.verifyEmails, 1)
+	verifyEmail := store.verifyEmails[0]
+	require.Len(t, verifyEmail.SecretCode, 32)
+
+	messages := sender.Messages()
+	require.Len(t, messages, 1)
+	require.Equal(t, []string{"alice@example.com"}, messages[0].To)
+
+	link := url.Values{"email_id": {"1"}, "secret_code": {verifyEmail.SecretCode}}.Encode()
+	require.Contains(t, messages[0].Text, "https://bank.example/verify_email?"+link)
+}
+
+func TestSendVerifyEmailUnknownUser(t *testing.T) {
+	store := &fakeMailStore{users: map[string]db.User{}}
+	sender := &mail.RecordingSender{}
+	tasks := NewMailTasks(store, sender, MailConfig{VerifyEmailURL: "https://bank.example/verify_email"})
+
+	err := tasks.SendVerifyEmail(context.Background(), newMailTask(t, TaskSendVerifyEmail, PayloadSendVerifyEmail{Username: "nobody"}))
+	require.ErrorIs(t, err, ErrSkipRetry)
+	require.Empty(t, sender.Messages())
+}
+
+func TestSendTransferNotifications(t *testing.T) {
+	users := map[string]db.User{
+		"alice": {Username: "alice", FullName: "Alice", Email: "alice@example.com", IsEmailVerified: true},
+		"bob":   {Username: "bob", FullName: "Bob", Email: "bob@example.com", IsEmailVerified: true},
+		"carol": {Username: "carol", FullName: "Carol", Email: "carol@example.com"},
+	}
+	config := MailConfig{LargeTransferThreshold: 1000, LowBalanceThreshold: 100}
+
+	newResult := func(from string, to string, amount int64, fromBalance int64) db.TransferTxResult {
+		return db.TransferTxResult{
+			Transfer:    db.Transfer{ID: 1, FromAccountID: 1, ToAccountID: 2, Amount: amount},
+			FromAccount: db.Account{ID: 1, Owner: from, Balance: fromBalance, Currency: "USD"},
+			ToAccount:   db.Account{ID: 2, Owner: to, Balance: 5000, Currency: "USD"},
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		result db.TransferTxResult
+		to     [][]string
+	}{
+		{
+			name:   "SentOnly",
+			result: newResult("alice", "bob", 10, 500),
+			to:     [][]string{{"alice@example.com"}},
+		},
+		{
+			name:   "LargeTransfer",
+			result: newResult("alice", "bob", 1000, 500),
+			to:     [][]string{{"alice@example.com"}, {"bob@example.com"}},
+		},
+		{
+			name:   "CrossesLowBalance",
+			result: newResult("alice", "bob", 10, 95),
+			to:     [][]string{{"alice@example.com"}, {"alice@example.com"}},
+		},
+		{
+			name:   "AlreadyBelowLowBalance",
+			result: newResult("alice", "bob", 10, 50),
+			to:     [][]string{{"alice@example.com"}},
+		},
+		{
+			name:   "UnverifiedReceiver",
+			result: newResult("alice", "carol", 1000, 500),
+			to:     [][]string{{"alice@example.com"}},
+		},
+		{
+			name:   "NoUser",
+			result: newResult("dave", "bob", 10, 500),
+			to:     nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sender := &mail.RecordingSender{}
+			tasks := NewMailTasks(&fakeMailStore{users: users}, sender, config)
+
+			err := tasks.SendTransferNotifications(context.Background(), newMailTask(t, TaskSendTransferNotifications, PayloadSendTransferNotifications{Result: tc.result}))
+			require.NoError(t, err)
+
+			var to [][]string
+			for _, msg := range sender.Messages() {
+				to = append(to, msg.To)
+			}
+			require.Equal(t, tc.to, to)
+		})
+	}
+}
+
+func TestSendTransferNotificationsSendError(t *testing.T) {
+	errSend := errors.New("smtp unavailable")
+	sender := &mail.RecordingSender{Err: errSend}
+	store := &fakeMailStore{users: map[string]db.User{
+		"alice": {Username: "alice", Email: "alice@example.com", IsEmailVerified: true},
+	}}
+	tasks := NewMailTasks(store, sender, MailConfig{})
+
+	result := db.TransferTxResult{FromAccount: db.Account{Owner: "alice"}, ToAccount: db.Account{Owner: "bob"}}
+	err := tasks.SendTransferNotifications(context.Background(), newMailTask(t, TaskSendTransferNotifications, PayloadSendTransferNotifications{Result: result}))
+	require.ErrorIs(t, err, errSend)
+}